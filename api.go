@@ -7,6 +7,8 @@ package webrtc
 
 import (
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/report"
+	"github.com/pion/interceptor/pkg/twcc"
 	"github.com/pion/logging"
 )
 
@@ -20,6 +22,9 @@ type API struct {
 	mediaEngine         *MediaEngine
 	interceptorRegistry *interceptor.Registry
 
+	// Shared by all PeerConnections created from this API
+	handshakeLimiter *handshakeLimiter
+
 	interceptor interceptor.Interceptor // Generated per PeerConnection
 }
 
@@ -43,6 +48,8 @@ func NewAPI(options ...func(*API)) *API {
 
 	logger := api.settingEngine.LoggerFactory.NewLogger("api")
 
+	api.handshakeLimiter = newHandshakeLimiter(api.settingEngine.handshakeConcurrencyLimit)
+
 	if api.mediaEngine == nil {
 		api.mediaEngine = &MediaEngine{}
 		err := api.mediaEngine.RegisterDefaultCodecs()
@@ -53,8 +60,18 @@ func NewAPI(options ...func(*API)) *API {
 
 	if api.interceptorRegistry == nil {
 		api.interceptorRegistry = &interceptor.Registry{}
-		err := RegisterDefaultInterceptorsWithOptions(api.mediaEngine, api.interceptorRegistry,
-			WithInterceptorLoggerFactory(api.settingEngine.LoggerFactory))
+		opts := []InterceptorOption{WithInterceptorLoggerFactory(api.settingEngine.LoggerFactory)}
+		if interval := api.settingEngine.rtcpReportInterval; interval != 0 {
+			opts = append(opts,
+				WithReportReceiverOptions(report.ReceiverInterval(interval)),
+				WithReportSenderOptions(report.SenderInterval(interval)))
+		}
+		if api.settingEngine.disableTWCCFeedback {
+			opts = append(opts, WithTWCCSenderDisabled())
+		} else if interval := api.settingEngine.twccFeedbackInterval; interval != 0 {
+			opts = append(opts, WithTWCCOptions(twcc.SendInterval(interval)))
+		}
+		err := RegisterDefaultInterceptorsWithOptions(api.mediaEngine, api.interceptorRegistry, opts...)
 		if err != nil {
 			logger.Errorf("Failed to register default interceptors %s", err)
 		}
@@ -63,6 +80,29 @@ func NewAPI(options ...func(*API)) *API {
 	return api
 }
 
+// HandshakeQueueDepth returns how many DTLS handshakes are waiting for a slot.
+// It is always zero unless SettingEngine.SetHandshakeConcurrencyLimit is used.
+func (api *API) HandshakeQueueDepth() int {
+	return api.handshakeLimiter.queueDepth()
+}
+
+// ActiveHandshakes returns how many DTLS handshakes are running. It is only tracked
+// when SettingEngine.SetHandshakeConcurrencyLimit is used, and is zero otherwise.
+func (api *API) ActiveHandshakes() int {
+	return api.handshakeLimiter.activeCount()
+}
+
+// getRTPParametersByKind returns the RTP parameters of the MediaEngine, without the header
+// extension IDs that require a=extmap-allow-mixed if the CompatibilityProfile omits it.
+func (api *API) getRTPParametersByKind(typ RTPCodecType, directions []RTPTransceiverDirection) RTPParameters {
+	parameters := api.mediaEngine.getRTPParametersByKind(typ, directions)
+	if api.settingEngine.compatibilityProfile.OmitExtmapAllowMixed {
+		parameters = withoutTwoByteHeaderExtensionIDs(parameters)
+	}
+
+	return parameters
+}
+
 // WithMediaEngine allows providing a MediaEngine to the API.
 // Settings can be changed after passing the engine to an API.
 // When a PeerConnection is created the MediaEngine is copied
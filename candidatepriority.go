@@ -0,0 +1,185 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/transport/v4/stdnet"
+)
+
+// newLocalICECandidate converts a local ice.Candidate, the priority is
+// computed by SettingEngine.SetCandidatePriorityFunction if one is set. Only the
+// signaled candidate gets that priority, the agent keeps the one it computed.
+// The candidate carries the current local username fragment.
+func (g *ICEGatherer) newLocalICECandidate(
+	candidate ice.Candidate,
+	sdpMid string,
+	sdpMLineIndex uint16,
+) (ICECandidate, error) {
+	c, err := newICECandidateFromICE(candidate, sdpMid, sdpMLineIndex)
+	if err != nil {
+		return c, err
+	}
+	c.NetworkInterface = g.localCandidateInterface(candidate)
+	c.NetworkType = interfaceNetworkType(c.NetworkInterface)
+	c.Priority = g.localCandidatePriority(candidate, c.Typ, c.NetworkInterface, c.Priority)
+	if usernameFragment, ok := g.usernameFragment.Load().(string); ok {
+		c.usernameFragment = usernameFragment
+	}
+
+	return c, nil
+}
+
+func (g *ICEGatherer) newLocalICECandidates(
+	candidates []ice.Candidate,
+	sdpMid string,
+	sdpMLineIndex uint16,
+) ([]ICECandidate, error) {
+	converted := []ICECandidate{}
+	for _, candidate := range candidates {
+		c, err := g.newLocalICECandidate(candidate, sdpMid, sdpMLineIndex)
+		if err != nil {
+			return nil, err
+		}
+		converted = append(converted, c)
+	}
+
+	return converted, nil
+}
+
+func (g *ICEGatherer) localCandidatePriority(
+	candidate ice.Candidate,
+	typ ICECandidateType,
+	networkInterface string,
+	defaultPriority uint32,
+) uint32 {
+	priorityFunction := g.api.settingEngine.candidates.priorityFunction
+	if priorityFunction == nil {
+		return defaultPriority
+	}
+
+	return priorityFunction(typ, candidate.NetworkType().String(), networkInterface, defaultPriority)
+}
+
+// localCandidateInterface is like candidateInterface, the interfaces are only looked up
+// once per candidate.
+func (g *ICEGatherer) localCandidateInterface(candidate ice.Candidate) string {
+	if name, ok := g.localCandidateInterfaces.Load(candidate.ID()); ok {
+		return name.(string) //nolint:forcetypeassert
+	}
+
+	name, _ := g.localCandidateInterfaces.LoadOrStore(candidate.ID(), g.candidateInterface(candidate))
+
+	return name.(string) //nolint:forcetypeassert
+}
+
+// candidateInterface returns the name of the interface a local candidate was
+// gathered on, or an empty string if it can't be determined (e.g. for mDNS candidates).
+func (g *ICEGatherer) candidateInterface(candidate ice.Candidate) string {
+	ip := candidateBaseIP(candidate)
+	if ip == nil {
+		return ""
+	}
+
+	return g.getInterfaceNames()[ip.String()]
+}
+
+// candidateBaseIP returns the address of the interface a local candidate was gathered on,
+// or nil if it isn't known (e.g. for mDNS candidates). Candidates other than host are
+// looked up by their base, unless it is unspecified.
+func candidateBaseIP(candidate ice.Candidate) net.IP {
+	ip := net.ParseIP(candidate.Address())
+	if related := candidate.RelatedAddress(); candidate.Type() != ice.CandidateTypeHost && related != nil {
+		if base := net.ParseIP(related.Address); base != nil && !base.IsUnspecified() {
+			ip = base
+		}
+	}
+
+	return ip
+}
+
+// resetInterfaceNames makes the next lookup enumerate the interfaces again, the interfaces
+// may have changed since the last gather. The interfaces of the candidates gathered before
+// are forgotten, an ICE restart replaces them.
+func (g *ICEGatherer) resetInterfaceNames() {
+	g.interfaceNamesMu.Lock()
+	g.interfaceNames = nil
+	g.interfaceNamesMu.Unlock()
+
+	g.localCandidateInterfaces.Clear()
+}
+
+// interfaceNetworkType guesses the W3C network type of an interface from its name, following
+// the naming schemes of Linux, Android and iOS. It returns unknown for other names, like en0
+// which is either wifi or ethernet on macOS, and an empty string if the interface isn't known.
+func interfaceNetworkType(name string) string {
+	if name == "" {
+		return ""
+	}
+
+	for _, prefixes := range []struct {
+		networkType string
+		prefixes    []string
+	}{
+		{"wifi", []string{"wl"}},
+		{"cellular", []string{"ww", "rmnet", "ccmni", "pdp_ip", "seth_lte"}},
+		{"vpn", []string{"tun", "tap", "utun", "wg", "ppp", "ipsec"}},
+		{"bluetooth", []string{"bnep", "bt-pan"}},
+		{"ethernet", []string{"eth", "enp", "eno", "ens", "enx"}},
+	} {
+		for _, prefix := range prefixes.prefixes {
+			if strings.HasPrefix(name, prefix) {
+				return prefixes.networkType
+			}
+		}
+	}
+
+	return "unknown"
+}
+
+// getInterfaceNames returns the names of the local interfaces by address, the interfaces
+// are only enumerated once per gather.
+func (g *ICEGatherer) getInterfaceNames() map[string]string {
+	g.interfaceNamesMu.Lock()
+	defer g.interfaceNamesMu.Unlock()
+
+	if g.interfaceNames != nil {
+		return g.interfaceNames
+	}
+
+	g.interfaceNames = map[string]string{}
+	candidateNet := g.api.settingEngine.net
+	if candidateNet == nil {
+		var err error
+		if candidateNet, err = stdnet.NewNet(); err != nil {
+			return g.interfaceNames
+		}
+	}
+
+	interfaces, err := candidateNet.Interfaces()
+	if err != nil {
+		return g.interfaceNames
+	}
+
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				if _, ok := g.interfaceNames[ipNet.IP.String()]; !ok {
+					g.interfaceNames[ipNet.IP.String()] = iface.Name
+				}
+			}
+		}
+	}
+
+	return g.interfaceNames
+}
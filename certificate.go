@@ -106,6 +106,7 @@ func (c Certificate) Expires() time.Time {
 
 // GetFingerprints returns the list of certificate fingerprints, one of which
 // is computed with the digest algorithm used in the certificate signature.
+// The values are lower case hex, the descriptions carry them in upper case.
 func (c Certificate) GetFingerprints() ([]DTLSFingerprint, error) {
 	fingerprintAlgorithms := []crypto.Hash{crypto.SHA256}
 	res := make([]DTLSFingerprint, len(fingerprintAlgorithms))
@@ -134,6 +135,17 @@ func (c Certificate) GetFingerprints() ([]DTLSFingerprint, error) {
 // GenerateCertificate causes the creation of an X.509 certificate and
 // corresponding private key.
 func GenerateCertificate(secretKey crypto.PrivateKey) (*Certificate, error) {
+	return GenerateCertificateWithExpiry(secretKey, time.Now().AddDate(0, 1, -1))
+}
+
+// GenerateCertificateWithExpiry is like GenerateCertificate, with a certificate that expires
+// at notAfter instead of a month from now. A certificate meant to be persisted with PEM, so
+// the remote can pin its fingerprint across restarts, usually needs to live longer.
+func GenerateCertificateWithExpiry(secretKey crypto.PrivateKey, notAfter time.Time) (*Certificate, error) {
+	if !notAfter.After(time.Now()) {
+		return nil, &rtcerr.InvalidAccessError{Err: ErrCertificateExpired}
+	}
+
 	// Max random value, a 130-bits integer, i.e 2^130 - 1
 	maxBigInt := new(big.Int)
 	/* #nosec */
@@ -147,7 +159,7 @@ func GenerateCertificate(secretKey crypto.PrivateKey) (*Certificate, error) {
 	return NewCertificate(secretKey, x509.Certificate{
 		Issuer:       pkix.Name{CommonName: generatedCertificateOrigin},
 		NotBefore:    time.Now().AddDate(0, 0, -1),
-		NotAfter:     time.Now().AddDate(0, 1, -1),
+		NotAfter:     notAfter,
 		SerialNumber: serialNumber,
 		Version:      2,
 		Subject:      pkix.Name{CommonName: generatedCertificateOrigin},
@@ -178,7 +190,6 @@ func (c Certificate) collectStats(report *statsReportCollector) error {
 		Fingerprint:          fingerPrintAlgo[0].Value,
 		FingerprintAlgorithm: fingerPrintAlgo[0].Algorithm,
 		Base64Certificate:    base64Certificate,
-		IssuerCertificateID:  c.x509Cert.Issuer.String(),
 	}
 
 	report.Collect(stats.ID, stats)
@@ -187,7 +198,9 @@ func (c Certificate) collectStats(report *statsReportCollector) error {
 }
 
 // CertificateFromPEM creates a fresh certificate based on a string containing
-// pem blocks fort the private key and x509 certificate.
+// pem blocks fort the private key and x509 certificate. The private key is read from a
+// PKCS #8 PRIVATE KEY block, as written by PEM, or from the RSA PRIVATE KEY (PKCS #1) and
+// EC PRIVATE KEY (SEC 1) blocks of openssl. It must be the key of the certificate.
 func CertificateFromPEM(pems string) (*Certificate, error) { //nolint: cyclop
 	var cert *x509.Certificate
 	var privateKey crypto.PrivateKey
@@ -217,11 +230,11 @@ func CertificateFromPEM(pems string) (*Certificate, error) { //nolint: cyclop
 					cert, err = x509.ParseCertificate(certBytes[:n])
 				}
 			}
-		case "PRIVATE KEY":
+		case "PRIVATE KEY", "RSA PRIVATE KEY", "EC PRIVATE KEY":
 			if privateKey != nil {
 				return nil, errCertificatePEMMultiplePriv
 			}
-			privateKey, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+			privateKey, err = parsePEMPrivateKey(block)
 		}
 
 		// Report errors from parsing either the private key or the certificate
@@ -234,13 +247,33 @@ func CertificateFromPEM(pems string) (*Certificate, error) { //nolint: cyclop
 		return nil, errCertificatePEMMissing
 	}
 
+	signer, ok := privateKey.(crypto.Signer)
+	if !ok {
+		return nil, &rtcerr.NotSupportedError{Err: ErrPrivateKeyType}
+	}
+	if public, ok := signer.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !public.Equal(cert.PublicKey) {
+		return nil, errCertificatePEMKeyMismatch
+	}
+
 	ret := CertificateFromX509(privateKey, cert)
 
 	return &ret, nil
 }
 
+func parsePEMPrivateKey(block *pem.Block) (crypto.PrivateKey, error) {
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		return x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		return x509.ParseECPrivateKey(block.Bytes)
+	default:
+		return x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+}
+
 // PEM returns the certificate encoded as two pem block: once for the X509
-// certificate and the other for the private key.
+// certificate and the other for the private key, in PKCS #8. The certificate is kept
+// as it is, so the one read back by CertificateFromPEM has the same fingerprints.
 func (c Certificate) PEM() (string, error) {
 	// First write the X509 certificate
 	var builder strings.Builder
@@ -6,6 +6,7 @@
 package webrtc
 
 import (
+	"crypto"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
@@ -13,6 +14,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"encoding/pem"
+	"strings"
 	"testing"
 	"time"
 
@@ -118,6 +120,114 @@ func TestPEM(t *testing.T) {
 	assert.Equal(t, pem, pem2)
 }
 
+func TestCertificatePEMRoundTrip(t *testing.T) {
+	offeredFingerprint := func(t *testing.T, cert *Certificate) string {
+		t.Helper()
+
+		pc, err := NewPeerConnection(Configuration{Certificates: []Certificate{*cert}})
+		assert.NoError(t, err)
+		defer func() { assert.NoError(t, pc.Close()) }()
+
+		_, err = pc.CreateDataChannel("data", nil)
+		assert.NoError(t, err)
+		offer, err := pc.CreateOffer(nil)
+		assert.NoError(t, err)
+
+		for _, line := range strings.Split(offer.SDP, "\r\n") {
+			if fingerprint, ok := strings.CutPrefix(line, "a=fingerprint:"); ok {
+				return fingerprint
+			}
+		}
+		assert.Fail(t, "no fingerprint in the offer")
+
+		return ""
+	}
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	notAfter := time.Now().AddDate(1, 0, 0)
+	for _, test := range []struct {
+		name string
+		key  crypto.PrivateKey
+	}{
+		{"ECDSA", ecdsaKey},
+		{"RSA", rsaKey},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			cert, err := GenerateCertificateWithExpiry(test.key, notAfter)
+			assert.NoError(t, err)
+			assert.True(t, notAfter.Truncate(time.Second).Equal(cert.Expires()))
+
+			pems, err := cert.PEM()
+			assert.NoError(t, err)
+			reloaded, err := CertificateFromPEM(pems)
+			assert.NoError(t, err)
+			assert.True(t, cert.Equals(*reloaded))
+			assert.Equal(t, cert.Expires(), reloaded.Expires())
+
+			fingerprints, err := cert.GetFingerprints()
+			assert.NoError(t, err)
+			reloadedFingerprints, err := reloaded.GetFingerprints()
+			assert.NoError(t, err)
+			assert.Equal(t, fingerprints, reloadedFingerprints)
+
+			// The SDP carries the hex in upper case
+			expected := fingerprints[0].Algorithm + " " + strings.ToUpper(fingerprints[0].Value)
+			assert.Equal(t, expected, offeredFingerprint(t, cert))
+			assert.Equal(t, expected, offeredFingerprint(t, reloaded))
+		})
+	}
+
+	t.Run("PKCS1 and SEC1 keys", func(t *testing.T) {
+		ecdsaDER, err := x509.MarshalECPrivateKey(ecdsaKey)
+		assert.NoError(t, err)
+
+		for _, keyBlock := range []*pem.Block{
+			{Type: "EC PRIVATE KEY", Bytes: ecdsaDER},
+			{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey)},
+		} {
+			key := crypto.PrivateKey(ecdsaKey)
+			if keyBlock.Type == "RSA PRIVATE KEY" {
+				key = rsaKey
+			}
+			cert, err := GenerateCertificate(key)
+			assert.NoError(t, err)
+
+			pems := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.x509Cert.Raw})) +
+				string(pem.EncodeToMemory(keyBlock))
+			reloaded, err := CertificateFromPEM(pems)
+			assert.NoError(t, err, keyBlock.Type)
+			if reloaded != nil {
+				assert.True(t, cert.Equals(*reloaded), keyBlock.Type)
+			}
+		}
+	})
+
+	t.Run("Key of another certificate", func(t *testing.T) {
+		cert, err := GenerateCertificate(ecdsaKey)
+		assert.NoError(t, err)
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		assert.NoError(t, err)
+		otherDER, err := x509.MarshalPKCS8PrivateKey(otherKey)
+		assert.NoError(t, err)
+
+		pems := string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.x509Cert.Raw})) +
+			string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: otherDER}))
+		reloaded, err := CertificateFromPEM(pems)
+		assert.Nil(t, reloaded)
+		assert.ErrorIs(t, err, errCertificatePEMKeyMismatch)
+	})
+
+	t.Run("Expiry in the past", func(t *testing.T) {
+		cert, err := GenerateCertificateWithExpiry(ecdsaKey, time.Now().Add(-time.Minute))
+		assert.Nil(t, cert)
+		assert.ErrorIs(t, err, ErrCertificateExpired)
+	})
+}
+
 const (
 	certHeader = `!! This is a test certificate: Don't use it in production !!
 You can create your own using openssl
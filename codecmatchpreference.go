@@ -0,0 +1,36 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// CodecMatchPreference controls how the codecs of a remote description
+// are matched with the codecs registered in the MediaEngine.
+type CodecMatchPreference int
+
+const (
+	// CodecMatchStrict requires the fmtp parameters identifying a media format
+	// configuration to be compatible, like the profile of a H264 profile-level-id.
+	CodecMatchStrict CodecMatchPreference = iota
+
+	// CodecMatchLoose ignores the parameters that only identify the profile of a codec.
+	// H264 codecs match when their packetization-mode is equal, whatever their
+	// profile-level-id. Use it with decoders that accept more profiles than they announce.
+	CodecMatchLoose
+)
+
+// This is done this way because of a linter.
+const (
+	codecMatchStrictStr = "strict"
+	codecMatchLooseStr  = "loose"
+)
+
+func (t CodecMatchPreference) String() string {
+	switch t {
+	case CodecMatchStrict:
+		return codecMatchStrictStr
+	case CodecMatchLoose:
+		return codecMatchLooseStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
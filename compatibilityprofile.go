@@ -0,0 +1,126 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"strings"
+
+	"github.com/pion/rtcp"
+)
+
+// CompatibilityProfile is a set of SDP shaping toggles applied to every offer and
+// answer a PeerConnection generates. It allows interoperating with remote endpoints
+// that have known quirks without munging the SDP by hand.
+// The zero value generates the same SDP as when no profile is set.
+type CompatibilityProfile struct {
+	// RTCPMuxOnly adds a=rtcp-mux-only to every media section, see RFC 8858.
+	RTCPMuxOnly bool
+
+	// RecvonlySSRC adds a=ssrc lines to recvonly media sections, announcing the
+	// SSRC used for RTCP receiver reports. The receiver reports, NACKs and PLIs
+	// about the tracks received by these media sections are sent from it.
+	RecvonlySSRC bool
+
+	// OmitExtmapAllowMixed never adds a=extmap-allow-mixed, even if the remote offered it.
+	OmitExtmapAllowMixed bool
+
+	// EchoH264ProfileLevelID copies the profile-level-id of the remote H264 codec with
+	// the same payload type into the local fmtp line, instead of the locally configured one.
+	EchoH264ProfileLevelID bool
+}
+
+var (
+	// CompatibilityProfileStrict generates SDP that follows the latest specifications
+	// as closely as possible.
+	CompatibilityProfileStrict = CompatibilityProfile{ //nolint:gochecknoglobals
+		RTCPMuxOnly: true,
+	}
+
+	// CompatibilityProfileLegacyTelepresence generates SDP accepted by older SIP
+	// and telepresence endpoints.
+	CompatibilityProfileLegacyTelepresence = CompatibilityProfile{ //nolint:gochecknoglobals
+		RecvonlySSRC:           true,
+		OmitExtmapAllowMixed:   true,
+		EchoH264ProfileLevelID: true,
+	}
+)
+
+const sdpAttributeRTCPMuxOnly = "rtcp-mux-only"
+
+// echoH264ProfileLevelID replaces the profile-level-id in fmtpLine with the one of
+// the remote H264 codec using the same payload type.
+func echoH264ProfileLevelID(fmtpLine string, payloadType PayloadType, remoteCodecs []RTPCodecParameters) string {
+	for _, remoteCodec := range remoteCodecs {
+		if remoteCodec.PayloadType != payloadType || !strings.EqualFold(remoteCodec.MimeType, MimeTypeH264) {
+			continue
+		}
+
+		remoteProfileLevelID := h264ProfileLevelID(remoteCodec.SDPFmtpLine)
+		if remoteProfileLevelID == "" {
+			return fmtpLine
+		}
+
+		params := strings.Split(fmtpLine, ";")
+		for i, param := range params {
+			key, _, found := strings.Cut(strings.TrimSpace(param), "=")
+			if found && strings.EqualFold(key, "profile-level-id") {
+				params[i] = "profile-level-id=" + remoteProfileLevelID
+
+				return strings.Join(params, ";")
+			}
+		}
+
+		return fmtpLine
+	}
+
+	return fmtpLine
+}
+
+func h264ProfileLevelID(fmtpLine string) string {
+	for param := range strings.SplitSeq(fmtpLine, ";") {
+		key, value, found := strings.Cut(strings.TrimSpace(param), "=")
+		if found && strings.EqualFold(key, "profile-level-id") {
+			return value
+		}
+	}
+
+	return ""
+}
+
+// withRecvonlySenderSSRC returns pkts with the receiver reports and feedback about a media
+// SSRC sent from the SSRC senderSSRC returns for it, if any. The packets are copied before
+// they are changed, they might be shared with the application.
+func withRecvonlySenderSSRC(pkts []rtcp.Packet, senderSSRC func(mediaSSRC uint32) (uint32, bool)) []rtcp.Packet {
+	out := make([]rtcp.Packet, len(pkts))
+	for i, pkt := range pkts {
+		switch packet := pkt.(type) {
+		case *rtcp.ReceiverReport:
+			if len(packet.Reports) == 0 {
+				break
+			}
+			if ssrc, ok := senderSSRC(packet.Reports[0].SSRC); ok {
+				report := *packet
+				report.SSRC = ssrc
+				pkt = &report
+			}
+		case *rtcp.TransportLayerNack:
+			if ssrc, ok := senderSSRC(packet.MediaSSRC); ok {
+				nack := *packet
+				nack.SenderSSRC = ssrc
+				pkt = &nack
+			}
+		case *rtcp.PictureLossIndication:
+			if ssrc, ok := senderSSRC(packet.MediaSSRC); ok {
+				pli := *packet
+				pli.SenderSSRC = ssrc
+				pkt = &pli
+			}
+		}
+		out[i] = pkt
+	}
+
+	return out
+}
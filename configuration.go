@@ -5,6 +5,13 @@
 
 package webrtc
 
+import (
+	"fmt"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+)
+
 // A Configuration defines how peer-to-peer communication via PeerConnection
 // is established or re-established.
 // Configurations may be set up once and reused across multiple connections.
@@ -16,9 +23,18 @@ type Configuration struct {
 	ICEServers []ICEServer `json:"iceServers,omitempty"`
 
 	// ICETransportPolicy indicates which candidates the ICEAgent is allowed
-	// to use.
+	// to use. When it is changed by SetConfiguration, the remote candidates
+	// follow the new policy from the next ICE restart on, while the types of
+	// the local candidates gathered stay the ones gathering started with.
 	ICETransportPolicy ICETransportPolicy `json:"iceTransportPolicy,omitempty"`
 
+	// ICECandidateTypes restricts the types of the local candidates, all of
+	// them if empty. At least one of the types has to be allowed by the
+	// ICETransportPolicy. SetConfiguration can only remove types, an empty
+	// slice keeps the current ones. The candidates of the removed types are
+	// dropped from then on, they are not gathered again on ICE restarts.
+	ICECandidateTypes []ICECandidateType `json:"iceCandidateTypes,omitempty"`
+
 	// BundlePolicy indicates which media-bundling policy to use when gathering
 	// ICE candidates.
 	BundlePolicy BundlePolicy `json:"bundlePolicy,omitempty"`
@@ -56,3 +72,28 @@ type Configuration struct {
 	// to always negotiate data channels in the initial SDP offer.
 	AlwaysNegotiateDataChannels bool `json:"alwaysNegotiateDataChannels,omitempty"`
 }
+
+// Validate checks the ICE servers and certificates like NewPeerConnection and SetConfiguration,
+// but returns all the problems instead of the first one. The errors of the ICE servers tell
+// the index of the server, and which of its URLs is invalid with an ICEServerURLError.
+func (c Configuration) Validate() []error {
+	var errs []error
+	for i, server := range c.ICEServers {
+		for _, err := range server.validateURLs() {
+			errs = append(errs, fmt.Errorf("iceServers[%d]: %w", i, err))
+		}
+	}
+
+	if err := validateICECandidateTypes(c.ICECandidateTypes, c.ICETransportPolicy); err != nil {
+		errs = append(errs, fmt.Errorf("iceCandidateTypes: %w", err))
+	}
+
+	now := time.Now()
+	for i, certificate := range c.Certificates {
+		if !certificate.Expires().IsZero() && now.After(certificate.Expires()) {
+			errs = append(errs, fmt.Errorf("certificates[%d]: %w", i, &rtcerr.InvalidAccessError{Err: ErrCertificateExpired}))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor/pkg/cc"
+	"github.com/pion/rtcp"
+)
+
+const (
+	// connectionQualitySmoothing is the weight of a new sample in the smoothed loss values.
+	connectionQualitySmoothing = 0.2
+
+	// inboundLossInterval is how often the inbound loss is sampled from the sequence numbers.
+	inboundLossInterval = time.Second
+
+	// maxSequenceNumberJump is the largest forward jump of sequence numbers counted as loss,
+	// larger jumps are treated as a restart of the stream.
+	maxSequenceNumberJump = 3000
+)
+
+// ConnectionQuality is a snapshot of the quality of a PeerConnection, see PeerConnection.ConnectionQuality.
+//
+// The values are updated while the connection is used and are not refreshed when
+// they are read. A value that was never measured is zero and so is its timestamp.
+// When no media flows the loss values keep the last measurement, compare their
+// timestamps with the current time to detect stale values.
+type ConnectionQuality struct {
+	// RoundTripTime is the round trip time of the selected ICE candidate pair,
+	// measured by the last answered connectivity check. It keeps being updated
+	// by the ICE keepalives when no media flows.
+	RoundTripTime time.Duration
+	// RoundTripTimeTimestamp is when RoundTripTime was last updated.
+	RoundTripTimeTimestamp time.Time
+
+	// OutboundLoss is the smoothed fraction of sent RTP packets lost, between 0 and 1,
+	// as reported by the remote in its receiver reports.
+	OutboundLoss float64
+	// OutboundLossTimestamp is when OutboundLoss was last updated.
+	OutboundLossTimestamp time.Time
+
+	// InboundLoss is the smoothed fraction of received RTP packets lost, between 0 and 1,
+	// computed from the gaps in the sequence numbers of the received packets.
+	InboundLoss float64
+	// InboundLossTimestamp is when InboundLoss was last updated.
+	InboundLossTimestamp time.Time
+
+	// EstimatedBitrate is the target bitrate in bits per second of the bandwidth
+	// estimator set with PeerConnection.SetBandwidthEstimator, or 0 if there is none.
+	EstimatedBitrate int
+}
+
+// qualityValue is the last value of a connection quality metric and when it was updated.
+type qualityValue struct {
+	bits    atomic.Uint64 // math.Float64bits of the value
+	updated atomic.Int64  // UnixNano, 0 if never updated
+}
+
+func (v *qualityValue) load() (float64, time.Time) {
+	updated := v.updated.Load()
+	if updated == 0 {
+		return 0, time.Time{}
+	}
+
+	return math.Float64frombits(v.bits.Load()), time.Unix(0, updated)
+}
+
+func (v *qualityValue) set(value float64, now time.Time) {
+	v.bits.Store(math.Float64bits(value))
+	v.updated.Store(now.UnixNano())
+}
+
+// smooth folds a sample into the value with an exponentially weighted moving average.
+func (v *qualityValue) smooth(sample float64, now time.Time) {
+	if v.updated.Load() == 0 {
+		v.set(sample, now)
+
+		return
+	}
+
+	for {
+		oldBits := v.bits.Load()
+		old := math.Float64frombits(oldBits)
+		value := old + connectionQualitySmoothing*(sample-old)
+		if v.bits.CompareAndSwap(oldBits, math.Float64bits(value)) {
+			break
+		}
+	}
+	v.updated.Store(now.UnixNano())
+}
+
+// transportQuality holds the loss metrics of the streams of a DTLSTransport.
+type transportQuality struct {
+	outboundLoss qualityValue
+	inboundLoss  qualityValue
+
+	// Packets expected and received over all streams, sampled every inboundLossInterval
+	expected, received         atomic.Uint64
+	lastExpected, lastReceived atomic.Uint64
+	lastSample                 atomic.Int64 // UnixNano
+}
+
+// countPacket accounts a received RTP packet of a stream. highest holds the highest
+// sequence number seen on the stream, with bit 16 set once it is valid.
+func (q *transportQuality) countPacket(highest *atomic.Uint32, sequenceNumber uint16, now time.Time) {
+	const sequenceNumberValid = 1 << 16
+
+	prev := highest.Load()
+	delta := sequenceNumber - uint16(prev) //nolint:gosec // G115
+
+	switch {
+	case prev&sequenceNumberValid == 0, delta >= maxSequenceNumberJump && delta < 1<<15:
+		q.expected.Add(1)
+		highest.Store(uint32(sequenceNumber) | sequenceNumberValid)
+	case delta != 0 && delta < 1<<15:
+		q.expected.Add(uint64(delta))
+		highest.Store(uint32(sequenceNumber) | sequenceNumberValid)
+	}
+	q.received.Add(1)
+
+	q.sampleInboundLoss(now)
+}
+
+func (q *transportQuality) sampleInboundLoss(now time.Time) {
+	last := q.lastSample.Load()
+	if last == 0 {
+		q.lastSample.CompareAndSwap(0, now.UnixNano())
+
+		return
+	}
+	if now.UnixNano()-last < int64(inboundLossInterval) || !q.lastSample.CompareAndSwap(last, now.UnixNano()) {
+		return
+	}
+
+	expected := q.expected.Load()
+	received := q.received.Load()
+	expectedInterval := expected - q.lastExpected.Swap(expected)
+	receivedInterval := received - q.lastReceived.Swap(received)
+	if expectedInterval == 0 {
+		return
+	}
+
+	// Retransmitted and reordered packets can make up for losses of the previous interval
+	lost := 0.0
+	if expectedInterval > receivedInterval {
+		lost = float64(expectedInterval-receivedInterval) / float64(expectedInterval)
+	}
+	q.inboundLoss.smooth(lost, now)
+}
+
+// handleRTCP updates the outbound loss from the report blocks about ssrc in a compound RTCP packet.
+func (q *transportQuality) handleRTCP(ssrc SSRC, buf []byte, now time.Time) {
+	for len(buf) >= 4 {
+		var header rtcp.Header
+		if err := header.Unmarshal(buf); err != nil {
+			return
+		}
+
+		size := (int(header.Length) + 1) * 4
+		if size > len(buf) {
+			return
+		}
+
+		var reports []rtcp.ReceptionReport
+		switch header.Type {
+		case rtcp.TypeReceiverReport:
+			receiverReport := rtcp.ReceiverReport{}
+			if receiverReport.Unmarshal(buf[:size]) == nil {
+				reports = receiverReport.Reports
+			}
+		case rtcp.TypeSenderReport:
+			senderReport := rtcp.SenderReport{}
+			if senderReport.Unmarshal(buf[:size]) == nil {
+				reports = senderReport.Reports
+			}
+		default:
+		}
+
+		for _, report := range reports {
+			if report.SSRC == uint32(ssrc) {
+				q.outboundLoss.smooth(float64(report.FractionLost)/256, now)
+			}
+		}
+
+		buf = buf[size:]
+	}
+}
+
+type bandwidthEstimatorHolder struct {
+	estimator cc.BandwidthEstimator
+}
+
+// SetBandwidthEstimator sets the estimator whose target bitrate is reported by ConnectionQuality.
+// It is usually the estimator passed to the OnNewPeerConnection callback of the congestion
+// control interceptor for the ID of this PeerConnection. Passing nil removes the estimator.
+func (pc *PeerConnection) SetBandwidthEstimator(estimator cc.BandwidthEstimator) {
+	pc.bandwidthEstimator.Store(bandwidthEstimatorHolder{estimator})
+}
+
+// ConnectionQuality returns the round trip time, the packet loss in both directions and the
+// estimated bitrate of the PeerConnection. The values are maintained as packets are sent and
+// received, reading them is cheap enough to poll frequently.
+//
+// The outbound loss is taken from the RTCP read from the RTPSenders, so it is only updated
+// if the application reads it, as it must for the interceptors to work.
+func (pc *PeerConnection) ConnectionQuality() ConnectionQuality {
+	var quality ConnectionQuality
+
+	roundTripTime, updated := pc.iceGatherer.roundTripTime.load()
+	quality.RoundTripTime = time.Duration(roundTripTime * float64(time.Second))
+	quality.RoundTripTimeTimestamp = updated
+
+	quality.OutboundLoss, quality.OutboundLossTimestamp = pc.dtlsTransport.quality.outboundLoss.load()
+	quality.InboundLoss, quality.InboundLossTimestamp = pc.dtlsTransport.quality.inboundLoss.load()
+
+	if holder, ok := pc.bandwidthEstimator.Load().(bandwidthEstimatorHolder); ok && holder.estimator != nil {
+		quality.EstimatedBitrate = holder.estimator.GetTargetBitrate()
+	}
+
+	return quality
+}
@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportQuality_InboundLoss(t *testing.T) {
+	var quality transportQuality
+	var highest atomic.Uint32
+	now := time.Now()
+
+	// The first packet starts the first interval
+	quality.countPacket(&highest, 65530, now)
+
+	// Lose one packet in four, across the wrap-around of the sequence numbers
+	sequenceNumber := uint16(65530)
+	for i := 0; i < 40; i++ {
+		sequenceNumber++
+		if i%4 != 0 {
+			quality.countPacket(&highest, sequenceNumber, now)
+		}
+	}
+
+	// A duplicate and a reordered packet are received without being expected
+	quality.countPacket(&highest, sequenceNumber, now)
+	quality.countPacket(&highest, sequenceNumber-10, now)
+
+	loss, updated := quality.inboundLoss.load()
+	assert.True(t, updated.IsZero())
+	assert.Zero(t, loss)
+
+	sequenceNumber++
+	quality.countPacket(&highest, sequenceNumber, now.Add(inboundLossInterval))
+	loss, updated = quality.inboundLoss.load()
+	assert.Equal(t, now.Add(inboundLossInterval).UnixNano(), updated.UnixNano())
+	assert.InDelta(t, 8.0/42, loss, 0.001)
+
+	// A jump of the sequence numbers restarts the stream instead of counting as loss
+	quality.countPacket(&highest, sequenceNumber+maxSequenceNumberJump, now.Add(inboundLossInterval))
+	quality.countPacket(&highest, sequenceNumber+maxSequenceNumberJump+1, now.Add(2*inboundLossInterval))
+	loss, _ = quality.inboundLoss.load()
+	assert.InDelta(t, 0.8*8.0/42, loss, 0.001)
+}
+
+func TestTransportQuality_OutboundLoss(t *testing.T) {
+	var quality transportQuality
+	now := time.Now()
+
+	buf, err := rtcp.Marshal([]rtcp.Packet{
+		&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{SSRC: 2, FractionLost: 128}, {SSRC: 1, FractionLost: 64}}},
+		&rtcp.PictureLossIndication{MediaSSRC: 1},
+		&rtcp.SenderReport{Reports: []rtcp.ReceptionReport{{SSRC: 1, FractionLost: 0}}},
+	})
+	require.NoError(t, err)
+
+	quality.handleRTCP(1, buf, now)
+	loss, updated := quality.outboundLoss.load()
+	assert.Equal(t, now.UnixNano(), updated.UnixNano())
+	assert.InDelta(t, 0.8*0.25, loss, 0.001)
+
+	// The reports after a truncated packet are ignored
+	quality.handleRTCP(1, buf[:len(buf)-4], now)
+	loss, _ = quality.outboundLoss.load()
+	assert.InDelta(t, 0.21, loss, 0.001)
+}
+
+func TestPeerConnection_ConnectionQuality_Allocations(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	pc.dtlsTransport.quality.inboundLoss.set(0.5, time.Now())
+	var quality ConnectionQuality
+	assert.Zero(t, testing.AllocsPerRun(100, func() {
+		quality = pc.ConnectionQuality()
+	}))
+	assert.Equal(t, 0.5, quality.InboundLoss)
+
+	require.NoError(t, pc.Close())
+}
@@ -0,0 +1,133 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// TimelineEventName is the name of a milestone of the connection setup.
+type TimelineEventName string
+
+const (
+	// TimelineEventICEGatheringStarted is when the ICEGatherer started gathering.
+	TimelineEventICEGatheringStarted TimelineEventName = "iceGatheringStarted"
+	// TimelineEventICEGatheringComplete is when all local candidates were gathered.
+	TimelineEventICEGatheringComplete TimelineEventName = "iceGatheringComplete"
+	// TimelineEventICEFirstCheck is when the ICE agent started connectivity checks.
+	TimelineEventICEFirstCheck TimelineEventName = "iceFirstCheck"
+	// TimelineEventICEPairNominated is when a candidate pair was selected, the detail is the pair.
+	TimelineEventICEPairNominated TimelineEventName = "icePairNominated"
+	// TimelineEventICEConnected is when the ICETransport became connected.
+	TimelineEventICEConnected TimelineEventName = "iceConnected"
+	// TimelineEventDTLSHandshakeStarted is when the DTLS handshake started, the detail is the DTLS role.
+	TimelineEventDTLSHandshakeStarted TimelineEventName = "dtlsHandshakeStarted"
+	// TimelineEventDTLSHelloFlight is when the ClientHello or ServerHello was sent, the detail is the message.
+	TimelineEventDTLSHelloFlight TimelineEventName = "dtlsHelloFlight"
+	// TimelineEventDTLSPeerCertificate is when the certificate of the remote was received.
+	TimelineEventDTLSPeerCertificate TimelineEventName = "dtlsPeerCertificate"
+	// TimelineEventDTLSConnected is when the DTLS handshake completed.
+	TimelineEventDTLSConnected TimelineEventName = "dtlsConnected"
+	// TimelineEventFirstSRTPPacket is when the first SRTP packet was received and decrypted.
+	TimelineEventFirstSRTPPacket TimelineEventName = "firstSrtpPacket"
+)
+
+// TimelineEvent is a milestone of the connection setup, see PeerConnection.ConnectionTimeline.
+type TimelineEvent struct {
+	Name TimelineEventName
+	Time time.Time
+	// Detail describes the milestone, like the nominated candidate pair. It may be empty.
+	Detail string
+}
+
+// timelineEvents are the milestones in the order they are expected in.
+var timelineEvents = [...]TimelineEventName{ //nolint:gochecknoglobals
+	TimelineEventICEGatheringStarted,
+	TimelineEventICEGatheringComplete,
+	TimelineEventICEFirstCheck,
+	TimelineEventICEPairNominated,
+	TimelineEventICEConnected,
+	TimelineEventDTLSHandshakeStarted,
+	TimelineEventDTLSHelloFlight,
+	TimelineEventDTLSPeerCertificate,
+	TimelineEventDTLSConnected,
+	TimelineEventFirstSRTPPacket,
+}
+
+// connectionTimeline records when each milestone was first reached. It is lock free so it
+// can be recorded from the state transitions and the packet paths. A nil connectionTimeline
+// records nothing, like the one of transports created with the ORTC API.
+type connectionTimeline struct {
+	times   [len(timelineEvents)]atomic.Int64
+	details [len(timelineEvents)]atomic.Pointer[string]
+}
+
+func timelineEventIndex(name TimelineEventName) int {
+	return slices.Index(timelineEvents[:], name)
+}
+
+// record stores the time of the milestone if it wasn't reached before.
+func (c *connectionTimeline) record(name TimelineEventName, detail string) {
+	if c == nil {
+		return
+	}
+
+	i := timelineEventIndex(name)
+	if c.times[i].Load() != 0 || !c.times[i].CompareAndSwap(0, time.Now().UnixNano()) {
+		return
+	}
+	if detail != "" {
+		c.details[i].Store(&detail)
+	}
+}
+
+// recorded returns true if the milestone was reached. It is cheap enough for the packet paths.
+func (c *connectionTimeline) recorded(name TimelineEventName) bool {
+	return c == nil || c.times[timelineEventIndex(name)].Load() != 0
+}
+
+// between returns the time from one milestone to another, if both were reached.
+func (c *connectionTimeline) between(from, to TimelineEventName) (time.Duration, bool) {
+	if c == nil {
+		return 0, false
+	}
+
+	start, end := c.times[timelineEventIndex(from)].Load(), c.times[timelineEventIndex(to)].Load()
+	if start == 0 || end == 0 {
+		return 0, false
+	}
+
+	return time.Duration(end - start), true
+}
+
+// events returns the reached milestones ordered by time.
+func (c *connectionTimeline) events() []TimelineEvent {
+	events := []TimelineEvent{}
+	if c == nil {
+		return events
+	}
+
+	for i, name := range timelineEvents {
+		nanos := c.times[i].Load()
+		if nanos == 0 {
+			continue
+		}
+
+		event := TimelineEvent{Name: name, Time: time.Unix(0, nanos)}
+		if detail := c.details[i].Load(); detail != nil {
+			event.Detail = *detail
+		}
+		events = append(events, event)
+	}
+
+	slices.SortStableFunc(events, func(a, b TimelineEvent) int {
+		return a.Time.Compare(b.Time)
+	})
+
+	return events
+}
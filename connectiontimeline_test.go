@@ -0,0 +1,106 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerConnection_ConnectionTimeline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, wan := createVNetPair(t, nil)
+	assert.Empty(t, pcOffer.ConnectionTimeline())
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	onTrack := make(chan struct{})
+	pcAnswer.OnTrack(func(*TrackRemote, *RTPReceiver) {
+		close(onTrack)
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, onTrack, []*TrackLocalStaticSample{track})
+
+	assertTimeline := func(pc *PeerConnection, expected []TimelineEventName) {
+		t.Helper()
+
+		events := pc.ConnectionTimeline()
+		times := map[TimelineEventName]time.Time{}
+		for i, event := range events {
+			if i > 0 {
+				assert.False(t, event.Time.Before(events[i-1].Time), "timeline must be ordered")
+			}
+			times[event.Name] = event.Time
+		}
+		require.Len(t, times, len(expected))
+		for _, name := range expected {
+			assert.Contains(t, times, name)
+		}
+
+		assertOrder := func(names ...TimelineEventName) {
+			t.Helper()
+
+			for i := 1; i < len(names); i++ {
+				assert.False(t, times[names[i]].Before(times[names[i-1]]), "%s before %s", names[i], names[i-1])
+			}
+		}
+		assertOrder(TimelineEventICEGatheringStarted, TimelineEventICEGatheringComplete)
+		assertOrder(TimelineEventICEFirstCheck, TimelineEventICEPairNominated)
+		assertOrder(TimelineEventICEFirstCheck, TimelineEventICEConnected)
+		assertOrder(
+			TimelineEventICEFirstCheck,
+			TimelineEventDTLSHandshakeStarted,
+			TimelineEventDTLSHelloFlight,
+			TimelineEventDTLSPeerCertificate,
+			TimelineEventDTLSConnected,
+		)
+
+		for _, event := range events {
+			if event.Name == TimelineEventICEPairNominated {
+				assert.Contains(t, event.Detail, "1.2.3.")
+			}
+		}
+
+		stats, ok := pc.GetStats()["iceTransport"].(TransportStats)
+		require.True(t, ok)
+		assert.Positive(t, stats.DTLSHandshakeDuration)
+		assert.Positive(t, stats.ICEFirstCheckToNominated)
+	}
+
+	milestones := []TimelineEventName{
+		TimelineEventICEGatheringStarted,
+		TimelineEventICEGatheringComplete,
+		TimelineEventICEFirstCheck,
+		TimelineEventICEPairNominated,
+		TimelineEventICEConnected,
+		TimelineEventDTLSHandshakeStarted,
+		TimelineEventDTLSHelloFlight,
+		TimelineEventDTLSPeerCertificate,
+		TimelineEventDTLSConnected,
+	}
+	// Only the answerer receives media
+	assertTimeline(pcOffer, milestones)
+	assertTimeline(pcAnswer, append(milestones, TimelineEventFirstSRTPPacket))
+
+	answerTimeline := pcAnswer.ConnectionTimeline()
+	assert.Equal(t, TimelineEventFirstSRTPPacket, answerTimeline[len(answerTimeline)-1].Name)
+
+	closePairNow(t, pcOffer, pcAnswer)
+	assert.NoError(t, wan.Stop())
+}
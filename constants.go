@@ -5,6 +5,7 @@ package webrtc
 
 import (
 	"math"
+	"time"
 
 	"github.com/pion/dtls/v3"
 )
@@ -23,6 +24,40 @@ const (
 	// If the total amount of incoming SSRCes exceeds this new requests will be ignored.
 	simulcastMaxProbeRoutines = 25
 
+	// defaultSimulcastProbeStreamLimit is how many streams of undeclared SSRCs are kept open
+	// while they aren't resolved. Past it the oldest ones are closed.
+	// can be overwritten with SettingEngine.SetSimulcastProbeStreamLimit().
+	defaultSimulcastProbeStreamLimit = 64
+
+	// defaultICEMulticastDNSTimeout is how long resolving a remote mDNS candidate may take,
+	// it matches the default STUN gather timeout of pion/ice.
+	defaultICEMulticastDNSTimeout = 5 * time.Second
+
+	// iceMulticastDNSMaxQueries is how many remote mDNS candidates are resolved at once.
+	// Candidates past it are reported with OnICECandidateError.
+	iceMulticastDNSMaxQueries = 16
+
+	// defaultUnknownSSRCBufferedPacketLimit is how many RTP Packets of an unknown SSRC
+	// are buffered while waiting for the transceiver it is attached to.
+	// can be overwritten with SettingEngine.SetUnknownSSRCBufferedPacketLimit().
+	defaultUnknownSSRCBufferedPacketLimit = 256
+
+	// defaultTrackIdentifierMaxLength is the longest track or stream identifier accepted
+	// by the track identifier policy, RFC 8830 limits them to 64 characters.
+	// can be overwritten with SettingEngine.SetTrackIdentifierPolicy().
+	defaultTrackIdentifierMaxLength = 64
+
+	// maxOneByteHeaderExtensionID is the largest ID of the RFC 8285 one-byte header
+	// extension form, larger IDs require the two-byte form and a=extmap-allow-mixed.
+	maxOneByteHeaderExtensionID = 14
+
+	// maxOneByteHeaderExtensionSize is the largest payload of the RFC 8285 one-byte header
+	// extension form.
+	maxOneByteHeaderExtensionSize = 16
+
+	// maxTwoByteHeaderExtensionID is the largest ID of the RFC 8285 two-byte header extension form.
+	maxTwoByteHeaderExtensionID = 255
+
 	// Default Max SCTP Message Size is the largest single DataChannel
 	// message we can send or accept. This default was chosen to match FireFox.
 	defaultMaxSCTPMessageSize = 1073741823
@@ -38,9 +73,57 @@ const (
 
 	sdpAttributeSimulcast = "simulcast"
 
+	sdpAttributeBundleOnly = "bundle-only"
+
 	outboundMTU = 1200
 
 	rtpPayloadTypeBitmask = 0x7F
+	rtpPaddingBitmask     = 0x20
+	rtpExtensionBitmask   = 0x10
+
+	// defaultKeyframeGatingTimeout is how long a gated track waits for a keyframe
+	// before releasing packets anyway.
+	defaultKeyframeGatingTimeout = 3 * time.Second
+
+	// keyframeGatingPLIInterval is how often a PLI is repeated while a gated track
+	// waits for a keyframe.
+	keyframeGatingPLIInterval = 500 * time.Millisecond
+
+	// defaultJitterBufferLatency is how long the jitter buffer of a track read by
+	// ReadSample waits for the missing packets of a frame.
+	defaultJitterBufferLatency = 200 * time.Millisecond
+
+	// jitterBufferMaxLatePackets is how many packets the jitter buffer waits for at most,
+	// the latency of the buffer usually releases them before.
+	jitterBufferMaxLatePackets = 1000
+
+	// keyframeEnforcementGracePeriod is how long keyframe interval enforcement is paused
+	// after the encoder was asked for a keyframe for another reason, like a PLI.
+	keyframeEnforcementGracePeriod = time.Second
+
+	// defaultKeyframeRequestInterval is the minimum interval between keyframe requests
+	// a RTCPFeedbackRouter forwards upstream.
+	// can be overwritten with FeedbackPolicy.KeyframeRequestInterval.
+	defaultKeyframeRequestInterval = 500 * time.Millisecond
+
+	// rtpSenderFlushInterval is how often RTPSender.StopWithFlush checks if the packets
+	// queued by the interceptors were sent.
+	rtpSenderFlushInterval = 5 * time.Millisecond
+
+	// silenceFrameDuration is the cadence of the keep-alive media a RTPSender
+	// generates while its track is replaced with nil.
+	silenceFrameDuration = 20 * time.Millisecond
+
+	// silencePaddingSize is the padding of the padding-only keep-alive packets
+	// generated for codecs without a known silence frame.
+	silencePaddingSize = 224
+
+	// rtpPaddingMaxSize is the largest padding of the packets sent by RTPSender.SendPadding,
+	// the padding size is a single byte, which keeps them far below outboundMTU.
+	rtpPaddingMaxSize = 255
+
+	// rtpPaddingMaxBitrate is the highest bitrate RTPSender.SendPadding sends padding at.
+	rtpPaddingMaxBitrate = 10_000_000
 
 	incomingUnhandledRTPSsrc = "Incoming unhandled RTP ssrc(%d), OnTrack will not be fired. %v"
 
@@ -57,6 +140,29 @@ const (
 	// AttributeRtxSequenceNumber is the interceptor attribute added when
 	// Read() returns an RTX packet containing the RTX stream sequence number.
 	AttributeRtxSequenceNumber = "rtx_sequence_number"
+	// AttributePaddingSize is the interceptor attribute added when Read()
+	// returns an RTP packet carrying padding alongside its payload, containing
+	// the number of padding bytes.
+	AttributePaddingSize = "padding_size"
+	// AttributeVideoLayersAllocation is the interceptor attribute added when ReadRTP()
+	// returns an RTP packet carrying the video-layers-allocation header extension,
+	// containing the decoded VideoLayersAllocation.
+	AttributeVideoLayersAllocation = "video_layers_allocation"
+	// AttributeVideoOrientation is the interceptor attribute added when Read() returns an
+	// RTP packet carrying the video orientation header extension, containing the decoded
+	// VideoOrientation. It is also set on the packets of a sample written with a
+	// VideoOrientation as Metadata.
+	AttributeVideoOrientation = "video_orientation"
+	// AttributeSourceStallFiller is the interceptor attribute set to true on the RTP packets
+	// of the filler samples written by TrackLocalStaticSample.SetSourceStallFiller.
+	AttributeSourceStallFiller = "source_stall_filler"
+	// AttributePacketizedAt is the interceptor attribute set by TrackLocalStaticSample on the
+	// RTP packets of a sample, containing the time.Time the sample was packetized at.
+	AttributePacketizedAt = "packetized_at"
+	// AttributeSentAt is the interceptor attribute added to the attributes of a RTP packet
+	// once the RTPSender handed it to the socket, containing the time.Time it was sent at.
+	// Interceptors read it after the Write of the next RTPWriter returned.
+	AttributeSentAt = "sent_at"
 )
 
 func defaultSrtpProtectionProfiles() []dtls.SRTPProtectionProfile {
@@ -39,6 +39,7 @@ type DataChannel struct {
 	detachCalled               bool
 	readLoopActive             chan struct{}
 	isGracefulClosed           bool
+	userData                   userData
 
 	// The binaryType represents attribute MUST, on getting, return the value to
 	// which it was last set. On setting, if the new value is either the string
@@ -168,7 +169,7 @@ func (d *DataChannel) open(sctpTransport *SCTPTransport) error { //nolint:cyclop
 		// avoid holding lock when generating ID, since id generation locks
 		d.mu.Unlock()
 		var dcID *uint16
-		err := d.sctpTransport.generateAndSetDataChannelID(d.sctpTransport.dtlsTransport.role(), &dcID)
+		err := d.sctpTransport.generateAndSetDataChannelID(d.sctpTransport.dtlsTransport.currentRole(), &dcID)
 		if err != nil {
 			return err
 		}
@@ -293,9 +294,33 @@ func (d *DataChannel) onClose() {
 	handler := d.onCloseHandler
 	d.mu.RUnlock()
 
-	if handler != nil {
-		go handler()
+	if handler == nil {
+		d.userData.clear()
+
+		return
 	}
+
+	// The user data is released once the handler used it
+	go func() {
+		handler()
+		d.userData.clear()
+	}()
+}
+
+// SetUserData stores an application value under key on the DataChannel, a nil value
+// removes it. Like for context.WithValue, the key must be comparable,
+// ErrUserDataKeyNotComparable is returned otherwise, and should be of an unexported type to
+// avoid collisions. The value is stored as given: a struct is copied, pointers, maps and
+// slices are shared. The values are released once the DataChannel is closed and its OnClose
+// handler returned, or when a detached DataChannel is closed. Values set afterwards are
+// dropped.
+func (d *DataChannel) SetUserData(key, value any) error {
+	return d.userData.set(key, value)
+}
+
+// UserData returns the value stored under key with SetUserData, nil if there is none.
+func (d *DataChannel) UserData(key any) any {
+	return d.userData.get(key)
 }
 
 // OnMessage sets an event handler which is invoked on a binary
@@ -557,8 +582,14 @@ func (d *DataChannel) close(shouldGracefullyClose bool) error {
 		}()
 	}
 	haveSctpTransport := d.dataChannel != nil
+	detached := d.detachCalled
 	d.mu.Unlock()
 
+	// A detached DataChannel has no read loop to fire OnClose
+	if detached {
+		defer d.userData.clear()
+	}
+
 	if d.ReadyState() == DataChannelStateClosed {
 		return nil
 	}
@@ -11,8 +11,8 @@ import (
 	"encoding/binary"
 	"io"
 	"math/big"
-	"regexp"
-	"strings"
+	"slices"
+	"strconv"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -176,6 +176,86 @@ func TestDataChannelParamters_Go(t *testing.T) {
 	})
 }
 
+func TestPeerConnection_OnDataChannelOpenRequest(t *testing.T) { //nolint:cyclop
+	defer test.TimeOut(time.Second * 10).Stop()
+	defer test.CheckRoutines(t)()
+
+	pair, err := NewAPI().NewLoopbackPeerConnectionPair(Configuration{})
+	assert.NoError(t, err)
+
+	unordered := false
+	zero, retransmits, lifeTime := uint16(0), uint16(5), uint16(250)
+	inits := map[string]*DataChannelInit{
+		"reliable":                    {Protocol: refString("chat")},
+		"unordered":                   {Ordered: &unordered},
+		"no retransmits":              {MaxRetransmits: &zero},
+		"unordered with retransmits":  {Ordered: &unordered, MaxRetransmits: &retransmits},
+		"with packet life time":       {MaxPacketLifeTime: &lifeTime, Protocol: refString("game")},
+		"refused by its label":        {},
+		"refused by its protocol too": {Protocol: refString("refused")},
+	}
+
+	openers := map[string]*DataChannel{}
+	refused := make(chan string, len(inits))
+	for label, init := range inits {
+		dataChannel, createErr := pair.Offerer.CreateDataChannel(label, init)
+		assert.NoError(t, createErr)
+		openers[label] = dataChannel
+		dataChannel.OnClose(func() {
+			refused <- label
+		})
+	}
+
+	requests := make(chan DataChannelParameters, len(inits))
+	pair.Answerer.OnDataChannelOpenRequest(func(params DataChannelParameters) bool {
+		requests <- params
+
+		return params.Label != "refused by its label" && params.Protocol != "refused"
+	})
+	accepted := make(chan DataChannelParameters, len(inits))
+	pair.Answerer.OnDataChannel(func(d *DataChannel) {
+		accepted <- DataChannelParameters{
+			Label:             d.Label(),
+			Protocol:          d.Protocol(),
+			ID:                d.ID(),
+			Ordered:           d.Ordered(),
+			MaxPacketLifeTime: d.MaxPacketLifeTime(),
+			MaxRetransmits:    d.MaxRetransmits(),
+			Negotiated:        d.Negotiated(),
+		}
+	})
+
+	assert.NoError(t, pair.Negotiate())
+
+	// The refused channels are closed for the opener
+	refusedLabels := []string{<-refused, <-refused}
+	assert.ElementsMatch(t, []string{"refused by its label", "refused by its protocol too"}, refusedLabels)
+
+	expected := func(label string) DataChannelParameters {
+		opener := openers[label]
+
+		return DataChannelParameters{
+			Label:             label,
+			Protocol:          opener.Protocol(),
+			ID:                opener.ID(),
+			Ordered:           opener.Ordered(),
+			MaxPacketLifeTime: opener.MaxPacketLifeTime(),
+			MaxRetransmits:    opener.MaxRetransmits(),
+		}
+	}
+	for range inits {
+		request := <-requests
+		assert.Equal(t, expected(request.Label), request)
+	}
+	for range len(inits) - len(refusedLabels) {
+		params := <-accepted
+		assert.NotContains(t, refusedLabels, params.Label)
+		assert.Equal(t, expected(params.Label), params)
+	}
+
+	assert.NoError(t, pair.Close())
+}
+
 func TestDataChannelBufferedAmount(t *testing.T) { //nolint:cyclop
 	t.Run("set before datachannel becomes open", func(t *testing.T) {
 		report := test.CheckRoutines(t)
@@ -508,62 +588,6 @@ func TestEOF(t *testing.T) { //nolint:cyclop
 	})
 }
 
-// Assert that a Session Description that doesn't follow
-// draft-ietf-mmusic-sctp-sdp is still accepted.
-func TestDataChannel_NonStandardSessionDescription(t *testing.T) {
-	to := test.TimeOut(time.Second * 20)
-	defer to.Stop()
-
-	report := test.CheckRoutines(t)
-	defer report()
-
-	offerPC, answerPC, err := newPair()
-	assert.NoError(t, err)
-
-	_, err = offerPC.CreateDataChannel("foo", nil)
-	assert.NoError(t, err)
-
-	onDataChannelCalled := make(chan struct{})
-	answerPC.OnDataChannel(func(_ *DataChannel) {
-		close(onDataChannelCalled)
-	})
-
-	offer, err := offerPC.CreateOffer(nil)
-	assert.NoError(t, err)
-
-	offerGatheringComplete := GatheringCompletePromise(offerPC)
-	assert.NoError(t, offerPC.SetLocalDescription(offer))
-	<-offerGatheringComplete
-
-	offer = *offerPC.LocalDescription()
-
-	// Replace with old values
-	const (
-		oldApplication = "m=application 63743 DTLS/SCTP 5000\r"
-		oldAttribute   = "a=sctpmap:5000 webrtc-datachannel 256\r"
-	)
-
-	offer.SDP = regexp.MustCompile(`m=application (.*?)\r`).ReplaceAllString(offer.SDP, oldApplication)
-	offer.SDP = regexp.MustCompile(`a=sctp-port(.*?)\r`).ReplaceAllString(offer.SDP, oldAttribute)
-
-	// Assert that replace worked
-	assert.True(t, strings.Contains(offer.SDP, oldApplication))
-	assert.True(t, strings.Contains(offer.SDP, oldAttribute))
-
-	assert.NoError(t, answerPC.SetRemoteDescription(offer))
-
-	answer, err := answerPC.CreateAnswer(nil)
-	assert.NoError(t, err)
-
-	answerGatheringComplete := GatheringCompletePromise(answerPC)
-	assert.NoError(t, answerPC.SetLocalDescription(answer))
-	<-answerGatheringComplete
-	assert.NoError(t, offerPC.SetRemoteDescription(*answerPC.LocalDescription()))
-
-	<-onDataChannelCalled
-	closePairNow(t, offerPC, answerPC)
-}
-
 func TestDataChannel_Dial(t *testing.T) {
 	t.Run("handler should be called once, by dialing peer only", func(t *testing.T) {
 		report := test.CheckRoutines(t)
@@ -900,3 +924,167 @@ func TestOnBufferedAmountLowRespectsReadyState(t *testing.T) {
 		}
 	})
 }
+
+// The DataChannel ID parity follows the role of the DTLS connection, even if the
+// role derived from the descriptions changes with a renegotiation.
+func TestDataChannel_IDAfterDTLSRoleFlip(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	initial, err := offerPC.CreateDataChannel("initial", nil)
+	assert.NoError(t, err)
+	initialOpened := make(chan struct{})
+	initial.OnOpen(func() { close(initialOpened) })
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+	<-initialOpened
+
+	// Simulate a remote that flipped its setup attribute
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		pc.dtlsTransport.lock.Lock()
+		if pc.dtlsTransport.remoteParameters.Role == DTLSRoleClient {
+			pc.dtlsTransport.remoteParameters.Role = DTLSRoleServer
+		} else {
+			pc.dtlsTransport.remoteParameters.Role = DTLSRoleClient
+		}
+		pc.dtlsTransport.lock.Unlock()
+	}
+
+	var wg sync.WaitGroup
+	for _, pcs := range [][2]*PeerConnection{{offerPC, answerPC}, {answerPC, offerPC}} {
+		local, remote := pcs[0], pcs[1]
+
+		remoteOpened := make(chan struct{})
+		remote.OnDataChannel(func(d *DataChannel) {
+			if d.Label() == "after-flip" {
+				d.OnOpen(func() { close(remoteOpened) })
+			}
+		})
+
+		localOpened := make(chan struct{})
+		dc, err := local.CreateDataChannel("after-flip", nil)
+		assert.NoError(t, err)
+		dc.OnOpen(func() { close(localOpened) })
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			<-localOpened
+			<-remoteOpened
+
+			expectedParity := uint16(1)
+			if local.dtlsTransport.currentRole() == DTLSRoleClient {
+				expectedParity = 0
+			}
+			assert.Equal(t, expectedParity, *dc.ID()%2)
+		}()
+	}
+	wg.Wait()
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestPeerConnection_EnsureDataChannel(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	assert.NoError(t, err)
+
+	// Every peer ensures every label from multiple goroutines
+	labels := []string{"chat", "control", "files"}
+
+	var dcepChannels atomic.Int32
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		pc.OnDataChannel(func(d *DataChannel) {
+			if slices.Contains(labels, d.Label()) {
+				dcepChannels.Add(1)
+			}
+		})
+	}
+	const callers = 4
+	ensured := map[*PeerConnection][][]*DataChannel{}
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		ensured[pc] = make([][]*DataChannel, len(labels))
+		for i := range labels {
+			ensured[pc][i] = make([]*DataChannel, callers)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		for i, label := range labels {
+			for caller := range callers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+
+					dc, ensureErr := pc.EnsureDataChannel(label, nil)
+					assert.NoError(t, ensureErr)
+					ensured[pc][i][caller] = dc
+				}()
+			}
+		}
+	}
+	wg.Wait()
+
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		for _, channels := range ensured[pc] {
+			for _, dc := range channels {
+				assert.Same(t, channels[0], dc)
+			}
+		}
+	}
+
+	assert.NoError(t, signalPair(offerPC, answerPC))
+
+	for i, label := range labels {
+		offerDC, answerDC := ensured[offerPC][i][0], ensured[answerPC][i][0]
+		assert.Equal(t, *offerDC.ID(), *answerDC.ID())
+
+		received := make(chan string)
+		answerDC.OnMessage(func(msg DataChannelMessage) { received <- string(msg.Data) })
+
+		opened := make(chan struct{})
+		offerDC.OnOpen(func() { close(opened) })
+		<-opened
+
+		assert.NoError(t, offerDC.SendText(label))
+		assert.Equal(t, label, <-received)
+	}
+	assert.Zero(t, dcepChannels.Load())
+
+	closePairNow(t, offerPC, answerPC)
+}
+
+func TestPeerConnection_EnsureDataChannel_Collision(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	dc, err := pc.EnsureDataChannel("label", nil)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, *dc.ID(), ensuredDataChannelIDBase)
+	assert.Less(t, *dc.ID(), ensuredDataChannelIDBase+ensuredDataChannelIDCount)
+
+	collision := ""
+	for i := 0; collision == ""; i++ {
+		if candidate := "label-" + strconv.Itoa(i); ensuredDataChannelID(candidate) == *dc.ID() {
+			collision = candidate
+		}
+	}
+
+	_, err = pc.EnsureDataChannel(collision, nil)
+	assert.ErrorIs(t, err, ErrDataChannelIDInUse)
+
+	assert.NoError(t, pc.Close())
+}
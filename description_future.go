@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+)
+
+// DescriptionFuture is the pending result of SetLocalDescriptionAsync and SetRemoteDescriptionAsync.
+type DescriptionFuture struct {
+	done    chan struct{}
+	resolve func(error)
+	err     error
+}
+
+func newDescriptionFuture() *DescriptionFuture {
+	future := &DescriptionFuture{done: make(chan struct{})}
+	var once sync.Once
+	future.resolve = func(err error) {
+		once.Do(func() {
+			future.err = err
+			close(future.done)
+		})
+	}
+
+	return future
+}
+
+// Done returns a channel that is closed when the description has been applied or failed to apply.
+func (f *DescriptionFuture) Done() <-chan struct{} {
+	return f.done
+}
+
+// Err returns the error of applying the description, it is nil until Done is closed.
+func (f *DescriptionFuture) Err() error {
+	select {
+	case <-f.done:
+		return f.err
+	default:
+		return nil
+	}
+}
+
+// Wait blocks until the description has been applied and returns its error, or the error of
+// ctx if it is done first. Abandoning the wait doesn't cancel applying the description.
+func (f *DescriptionFuture) Wait(ctx context.Context) error {
+	select {
+	case <-f.done:
+		return f.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// SetLocalDescriptionAsync is SetLocalDescription without blocking the caller. Descriptions
+// passed to SetLocalDescriptionAsync and SetRemoteDescriptionAsync are applied in the order
+// of the calls on the operations queue, after the operations enqueued before them. Close
+// fails the descriptions that weren't applied yet with an InvalidStateError.
+func (pc *PeerConnection) SetLocalDescriptionAsync(desc SessionDescription) *DescriptionFuture {
+	return pc.setDescriptionAsync(func() error {
+		return pc.setLocalDescription(desc)
+	})
+}
+
+// SetRemoteDescriptionAsync is SetRemoteDescription without blocking the caller. Descriptions
+// passed to SetLocalDescriptionAsync and SetRemoteDescriptionAsync are applied in the order
+// of the calls on the operations queue, after the operations enqueued before them. Close
+// fails the descriptions that weren't applied yet with an InvalidStateError.
+func (pc *PeerConnection) SetRemoteDescriptionAsync(desc SessionDescription) *DescriptionFuture {
+	return pc.setDescriptionAsync(func() error {
+		return pc.setRemoteDescription(desc)
+	})
+}
+
+func (pc *PeerConnection) setDescriptionAsync(apply func() error) *DescriptionFuture {
+	future := newDescriptionFuture()
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	if pc.isClosed.Load() {
+		future.resolve(&rtcerr.InvalidStateError{Err: ErrConnectionClosed})
+
+		return future
+	}
+
+	pc.ops.mu.Lock()
+	enqueued := pc.ops.tryEnqueue(func() {
+		future.resolve(apply())
+		pc.removePendingDescription(future)
+	})
+	pc.ops.mu.Unlock()
+	if !enqueued {
+		future.resolve(&rtcerr.InvalidStateError{Err: ErrConnectionClosed})
+
+		return future
+	}
+	pc.pendingDescriptions = append(pc.pendingDescriptions, future)
+
+	return future
+}
+
+func (pc *PeerConnection) removePendingDescription(future *DescriptionFuture) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	for i, pending := range pc.pendingDescriptions {
+		if pending == future {
+			pc.pendingDescriptions = append(pc.pendingDescriptions[:i], pc.pendingDescriptions[i+1:]...)
+
+			return
+		}
+	}
+}
+
+// waitPendingDescriptions blocks until the descriptions of SetLocalDescriptionAsync and
+// SetRemoteDescriptionAsync are applied. Callbacks run by operations don't wait, the
+// pending descriptions are queued after them.
+func (pc *PeerConnection) waitPendingDescriptions() {
+	if pc.ops.inCallback() {
+		return
+	}
+
+	pc.mu.RLock()
+	var last *DescriptionFuture
+	if n := len(pc.pendingDescriptions); n > 0 {
+		last = pc.pendingDescriptions[n-1]
+	}
+	pc.mu.RUnlock()
+
+	if last != nil {
+		<-last.Done()
+	}
+}
+
+// resolvePendingDescriptions fails the descriptions of SetLocalDescriptionAsync and
+// SetRemoteDescriptionAsync that weren't applied yet, it is called by Close.
+func (pc *PeerConnection) resolvePendingDescriptions() {
+	pc.mu.Lock()
+	pending := pc.pendingDescriptions
+	pc.pendingDescriptions = nil
+	pc.mu.Unlock()
+
+	for _, future := range pending {
+		future.resolve(&rtcerr.InvalidStateError{Err: ErrConnectionClosed})
+	}
+}
@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerConnection_SetDescriptionAsync(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const pairs = 10
+
+	var wg sync.WaitGroup
+	for range pairs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			pcOffer, pcAnswer, err := newPair()
+			if !assert.NoError(t, err) {
+				return
+			}
+			defer closePairNow(t, pcOffer, pcAnswer)
+
+			_, err = pcOffer.CreateDataChannel("data", nil)
+			assert.NoError(t, err)
+
+			offer, err := pcOffer.CreateOffer(nil)
+			assert.NoError(t, err)
+			offerApplied := pcOffer.SetLocalDescriptionAsync(offer)
+
+			// The answer is enqueued right after the offer it answers
+			assert.NoError(t, pcAnswer.SetRemoteDescriptionAsync(offer).Wait(context.Background()))
+			answer, err := pcAnswer.CreateAnswer(nil)
+			assert.NoError(t, err)
+			answerApplied := pcAnswer.SetLocalDescriptionAsync(answer)
+			remoteAnswerApplied := pcOffer.SetRemoteDescriptionAsync(answer)
+
+			for _, future := range []*DescriptionFuture{offerApplied, answerApplied, remoteAnswerApplied} {
+				<-future.Done()
+				assert.NoError(t, future.Err())
+			}
+
+			assert.Equal(t, SignalingStateStable, pcOffer.SignalingState())
+			assert.Equal(t, SignalingStateStable, pcAnswer.SignalingState())
+			assert.Equal(t, offer.SDP, pcAnswer.CurrentRemoteDescription().SDP)
+			assert.Equal(t, answer.SDP, pcOffer.CurrentRemoteDescription().SDP)
+		}()
+	}
+	wg.Wait()
+}
+
+func TestPeerConnection_SetDescriptionAsync_Errors(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	// Invalid descriptions fail on the operations queue
+	future := pc.SetRemoteDescriptionAsync(SessionDescription{Type: SDPTypeAnswer, SDP: "invalid"})
+	assert.Error(t, future.Wait(context.Background()))
+
+	// Abandoning the wait still applies the description
+	_, err = pc.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+
+	blocked := make(chan struct{})
+	pc.ops.Enqueue(func() { <-blocked })
+
+	future = pc.SetLocalDescriptionAsync(offer)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, future.Wait(ctx), context.Canceled)
+	assert.NoError(t, future.Err())
+
+	close(blocked)
+	<-future.Done()
+	assert.NoError(t, future.Err())
+	assert.Equal(t, SignalingStateHaveLocalOffer, pc.SignalingState())
+
+	// SetLocalDescription applies the pending descriptions first
+	blocked = make(chan struct{})
+	pc.ops.Enqueue(func() { <-blocked })
+	future = pc.SetLocalDescriptionAsync(SessionDescription{Type: SDPTypeRollback})
+	applied := make(chan error)
+	go func() { applied <- pc.SetLocalDescription(offer) }()
+	select {
+	case <-applied:
+		assert.Fail(t, "SetLocalDescription didn't wait for the pending description")
+	case <-time.After(50 * time.Millisecond):
+	}
+	close(blocked)
+	assert.NoError(t, future.Wait(context.Background()))
+	assert.NoError(t, <-applied)
+	assert.Equal(t, SignalingStateHaveLocalOffer, pc.SignalingState())
+
+	// Close fails the pending descriptions
+	blocked = make(chan struct{})
+	pc.ops.Enqueue(func() { <-blocked })
+	future = pc.SetLocalDescriptionAsync(SessionDescription{Type: SDPTypeRollback})
+	assert.NoError(t, pc.Close())
+
+	var stateErr *rtcerr.InvalidStateError
+	assert.ErrorAs(t, future.Wait(context.Background()), &stateErr)
+	close(blocked)
+
+	assert.ErrorAs(t, pc.SetLocalDescriptionAsync(offer).Wait(context.Background()), &stateErr)
+}
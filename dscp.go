@@ -0,0 +1,132 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/v4"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// maxDSCP is the highest value of the 6 bit Differentiated Services Code Point.
+const maxDSCP = 63
+
+// dscpMarker sets the DSCP of the packets sent by the sockets of the ICE agent. All the
+// traffic of a bundled PeerConnection shares the sockets, so a single value is used for them.
+type dscpMarker struct {
+	mu    sync.Mutex
+	dscp  uint8
+	conns map[net.PacketConn]struct{}
+	log   logging.LeveledLogger
+}
+
+func newDSCPMarker(dscp uint8, log logging.LeveledLogger) *dscpMarker {
+	return &dscpMarker{
+		dscp:  dscp,
+		conns: map[net.PacketConn]struct{}{},
+		log:   log,
+	}
+}
+
+// set marks the open sockets and the ones opened from now on with dscp.
+func (m *dscpMarker) set(dscp uint8) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.dscp == dscp {
+		return
+	}
+
+	m.dscp = dscp
+	for conn := range m.conns {
+		m.mark(conn)
+	}
+}
+
+func (m *dscpMarker) add(conn net.PacketConn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.conns[conn] = struct{}{}
+	m.mark(conn)
+}
+
+func (m *dscpMarker) remove(conn net.PacketConn) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.conns, conn)
+}
+
+// mark sets IP_TOS or IPV6_TCLASS of the socket, it only works for the conns of the
+// operating system. The lower two bits of the field are left to ECN.
+func (m *dscpMarker) mark(conn net.PacketConn) {
+	tos := int(m.dscp) << 2
+
+	var err error
+	if addr, ok := conn.LocalAddr().(*net.UDPAddr); ok && addr.IP.To4() == nil {
+		err = ipv6.NewPacketConn(conn).SetTrafficClass(tos)
+	} else {
+		err = ipv4.NewPacketConn(conn).SetTOS(tos)
+	}
+	if err != nil {
+		m.log.Debugf("Failed to set DSCP %d on %s: %v", m.dscp, conn.LocalAddr(), err)
+	}
+}
+
+// dscpNet registers the UDP sockets of the ICE agent with the dscpMarker, this includes the
+// sockets used to reach TURN servers.
+type dscpNet struct {
+	transport.Net
+	marker *dscpMarker
+}
+
+func (n *dscpNet) ListenPacket(network, address string) (net.PacketConn, error) {
+	conn, err := n.Net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+	n.marker.add(conn)
+
+	return &dscpPacketConn{PacketConn: conn, marker: n.marker}, nil
+}
+
+func (n *dscpNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	conn, err := n.Net.ListenUDP(network, locAddr)
+	if err != nil || (locAddr != nil && locAddr.IP.IsMulticast()) {
+		// The multicast DNS conns need the socket of the conn to join their group
+		return conn, err
+	}
+	n.marker.add(conn)
+
+	return &dscpUDPConn{UDPConn: conn, marker: n.marker}, nil
+}
+
+type dscpPacketConn struct {
+	net.PacketConn
+	marker *dscpMarker
+}
+
+func (c *dscpPacketConn) Close() error {
+	c.marker.remove(c.PacketConn)
+
+	return c.PacketConn.Close()
+}
+
+type dscpUDPConn struct {
+	transport.UDPConn
+	marker *dscpMarker
+}
+
+func (c *dscpUDPConn) Close() error {
+	c.marker.remove(c.UDPConn)
+
+	return c.UDPConn.Close()
+}
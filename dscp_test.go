@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4"
+	"github.com/pion/transport/v4/stdnet"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// dscpRecordingNet keeps the UDP sockets opened by the ICE agent.
+type dscpRecordingNet struct {
+	transport.Net
+
+	mu    sync.Mutex
+	conns []transport.UDPConn
+}
+
+func (n *dscpRecordingNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	conn, err := n.Net.ListenUDP(network, locAddr)
+	if err == nil && (locAddr == nil || !locAddr.IP.IsMulticast()) {
+		n.mu.Lock()
+		n.conns = append(n.conns, conn)
+		n.mu.Unlock()
+	}
+
+	return conn, err
+}
+
+// dscpValues returns the DSCP of the sockets, read back from IP_TOS or IPV6_TCLASS.
+func (n *dscpRecordingNet) dscpValues(t *testing.T) []int {
+	t.Helper()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	values := make([]int, 0, len(n.conns))
+	for _, conn := range n.conns {
+		var (
+			tos int
+			err error
+		)
+		if conn.LocalAddr().(*net.UDPAddr).IP.To4() == nil { //nolint:forcetypeassert
+			tos, err = ipv6.NewPacketConn(conn).TrafficClass()
+		} else {
+			tos, err = ipv4.NewPacketConn(conn).TOS()
+		}
+		require.NoError(t, err)
+		values = append(values, tos>>2)
+	}
+
+	return values
+}
+
+func TestSettingEngine_SetDSCP(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newPeerConnection := func() (*PeerConnection, *dscpRecordingNet) {
+		baseNet, err := stdnet.NewNet()
+		require.NoError(t, err)
+		recordingNet := &dscpRecordingNet{Net: baseNet}
+
+		s := SettingEngine{}
+		s.SetNet(recordingNet)
+		s.SetNetworkTypes([]NetworkType{NetworkTypeUDP4, NetworkTypeUDP6})
+		require.NoError(t, s.SetDSCP(46, 34, 10))
+
+		pc, err := NewAPI(WithSettingEngine(s)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc, recordingNet
+	}
+
+	// The offerer sends audio and data, the answerer only data
+	pcOffer, offerNet := newPeerConnection()
+	pcAnswer, answerNet := newPeerConnection()
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	for _, peer := range []struct {
+		name string
+		net  *dscpRecordingNet
+		dscp int
+	}{
+		{"offerer", offerNet, 46},
+		{"answerer", answerNet, 10},
+	} {
+		values := peer.net.dscpValues(t)
+		assert.NotEmpty(t, values, peer.name)
+		for _, value := range values {
+			assert.Equal(t, peer.dscp, value, peer.name)
+		}
+	}
+
+	// The open sockets follow a change of the value
+	pcOffer.iceGatherer.setDSCP(12)
+	for _, value := range offerNet.dscpValues(t) {
+		assert.Equal(t, 12, value)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+
+	// The closed sockets are no longer marked
+	for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+		pc.iceGatherer.dscp.mu.Lock()
+		assert.Empty(t, pc.iceGatherer.dscp.conns)
+		pc.iceGatherer.dscp.mu.Unlock()
+	}
+}
+
+func TestSettingEngine_SetDSCP_Invalid(t *testing.T) {
+	s := SettingEngine{}
+	assert.ErrorIs(t, s.SetDSCP(46, 34, 64), errSettingEngineSetDSCP)
+	assert.False(t, s.dscp.enabled)
+}
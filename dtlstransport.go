@@ -6,13 +6,17 @@
 package webrtc
 
 import (
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/binary"
 	"errors"
 	"fmt"
+	"io"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -20,10 +24,13 @@ import (
 
 	"github.com/pion/dtls/v3"
 	"github.com/pion/dtls/v3/pkg/crypto/fingerprint"
+	"github.com/pion/dtls/v3/pkg/protocol/handshake"
 	"github.com/pion/interceptor"
 	"github.com/pion/logging"
+	"github.com/pion/randutil"
 	"github.com/pion/rtcp"
 	"github.com/pion/srtp/v3"
+	"github.com/pion/transport/v4/packetio"
 	"github.com/pion/webrtc/v4/internal/mux"
 	"github.com/pion/webrtc/v4/internal/util"
 	"github.com/pion/webrtc/v4/pkg/rtcerr"
@@ -39,6 +46,7 @@ type DTLSTransport struct {
 	iceTransport          *ICETransport
 	certificates          []Certificate
 	remoteParameters      DTLSParameters
+	startedRole           DTLSRole // role of the DTLS connection, once started
 	remoteCertificate     []byte
 	state                 DTLSTransportState
 	srtpProtectionProfile srtp.ProtectionProfile
@@ -50,18 +58,53 @@ type DTLSTransport struct {
 
 	srtpSession, srtcpSession   atomic.Value
 	srtpEndpoint, srtcpEndpoint *mux.Endpoint
-	simulcastStreams            []simulcastStreamPair
+	simulcastStreams            []*simulcastStreamPair
+	probeStreamsEvicted         uint64
 	srtpReady                   chan struct{}
+	receiveBuffers              *srtpReceiveBuffers
+	quality                     transportQuality
 
 	dtlsMatcher mux.MatchFunc
 
+	// The RTCP writer of the interceptors of the PeerConnection, nil with ORTC
+	interceptorRTCPWriter interceptor.RTCPWriter
+
+	// rtcpCompound is set if reduced-size RTCP wasn't negotiated, RFC 5506
+	rtcpCompound atomic.Bool
+	rtcpSplits   atomic.Uint64
+
+	// The bytes SRTCP adds to RTCP with the negotiated protection profile
+	srtcpOverhead atomic.Int32
+
+	// The source of compound RTCP written without a sender SSRC
+	rtcpSSRC  uint32
+	rtcpCNAME string
+
+	// localCNAME returns the CNAME announced for a local SSRC, nil with ORTC
+	localCNAME func(ssrc uint32) string
+
+	srtpReplayDiscards, srtcpReplayDiscards atomic.Uint64
+
+	// The handlers of a RTCP BYE received for a SSRC, by SSRC
+	goodbyeHandlers sync.Map // func()
+
+	cancelQueuedHandshake context.CancelFunc
+
+	// The setup milestones of the PeerConnection, nil with ORTC
+	timeline *connectionTimeline
+
 	api *API
 	log logging.LeveledLogger
 }
 
 type simulcastStreamPair struct {
+	ssrc  SSRC
 	srtp  *srtp.ReadStreamSRTP
 	srtcp *srtp.ReadStreamSRTCP
+
+	// resolved is set once the SSRC is bound to a track, or was handled by the
+	// UnknownSSRCHandler. Unresolved pairs are still probed and can be evicted.
+	resolved bool
 }
 
 type streamsForSSRCResult struct {
@@ -81,9 +124,19 @@ func (api *API) NewDTLSTransport(transport *ICETransport, certificates []Certifi
 		state:        DTLSTransportStateNew,
 		dtlsMatcher:  mux.MatchDTLS,
 		srtpReady:    make(chan struct{}),
-		log:          api.settingEngine.LoggerFactory.NewLogger("DTLSTransport"),
+		receiveBuffers: newSRTPReceiveBuffers(
+			api.settingEngine.getSRTPReceiveBufferSize(),
+			api.settingEngine.getSRTCPReceiveBufferSize(),
+		),
+		log: api.settingEngine.LoggerFactory.NewLogger("DTLSTransport"),
 	}
 
+	cname, err := randutil.GenerateCryptoRandomString(16, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+	if err != nil {
+		return nil, &rtcerr.UnknownError{Err: err}
+	}
+	trans.rtcpSSRC, trans.rtcpCNAME = util.RandUint32(), cname
+
 	if len(certificates) > 0 {
 		now := time.Now()
 		for _, x509Cert := range certificates {
@@ -142,11 +195,24 @@ func (t *DTLSTransport) State() DTLSTransportState {
 }
 
 // WriteRTCP sends a user provided RTCP packet to the connected peer. If no peer is connected the
-// packet is discarded.
+// packet is discarded. RTCP larger than SettingEngine.SetRTCPMaxPacketSize is split across
+// several datagrams, ErrRTCPPacketTooLarge is returned if one of pkts can't be split to fit.
 func (t *DTLSTransport) WriteRTCP(pkts []rtcp.Packet) (int, error) {
+	var source *rtcpSource
+	if t.rtcpCompound.Load() {
+		source = t.rtcpSource(pkts)
+		pkts = compoundRTCP(pkts, source)
+	}
+
+	maxSize := t.api.settingEngine.getRTCPMaxPacketSize() - int(t.srtcpOverhead.Load())
 	raw, err := rtcp.Marshal(pkts)
-	if err != nil {
-		return 0, err
+	datagrams := [][]byte{raw}
+	if err != nil || len(raw) > maxSize {
+		// Packets with too many report blocks can't be marshaled before they are split
+		if datagrams, err = marshalSplitRTCP(pkts, maxSize, source); err != nil {
+			return 0, err
+		}
+		t.rtcpSplits.Add(1)
 	}
 
 	srtcpSession, err := t.getSRTCPSession()
@@ -160,7 +226,128 @@ func (t *DTLSTransport) WriteRTCP(pkts []rtcp.Packet) (int, error) {
 		return 0, fmt.Errorf("%w: %v", errPeerConnWriteRTCPOpenWriteStream, err)
 	}
 
-	return writeStream.Write(raw)
+	written := 0
+	for _, datagram := range datagrams {
+		n, err := writeStream.Write(datagram)
+		written += n
+		if err != nil {
+			return written, err
+		}
+	}
+
+	return written, nil
+}
+
+func marshalSplitRTCP(pkts []rtcp.Packet, maxSize int, source *rtcpSource) ([][]byte, error) {
+	split, err := splitRTCP(pkts, maxSize, source)
+	if err != nil {
+		return nil, err
+	}
+
+	datagrams := make([][]byte, 0, len(split))
+	for _, datagram := range split {
+		raw, err := rtcp.Marshal(datagram)
+		if err != nil {
+			return nil, err
+		}
+		datagrams = append(datagrams, raw)
+	}
+
+	return datagrams, nil
+}
+
+// rtcpSplitCount returns how many RTCP writes were split because they were too large.
+func (t *DTLSTransport) rtcpSplitCount() uint64 {
+	return t.rtcpSplits.Load()
+}
+
+// setRTCPCompound sets if RTCP must be sent as compound packets, because reduced-size
+// RTCP wasn't negotiated.
+func (t *DTLSTransport) setRTCPCompound(compound bool) {
+	t.rtcpCompound.Store(compound)
+}
+
+// rtcpSource returns the source of pkts as compound RTCP: the sender of their first packet,
+// or the source of the transport if it isn't known.
+func (t *DTLSTransport) rtcpSource(pkts []rtcp.Packet) *rtcpSource {
+	source := &rtcpSource{ssrc: t.rtcpSSRC, cname: t.rtcpCNAME}
+	if len(pkts) == 0 {
+		return source
+	}
+
+	if ssrc := rtcpSenderSSRC(pkts[0]); ssrc != 0 {
+		source.ssrc = ssrc
+		if t.localCNAME != nil {
+			if cname := t.localCNAME(ssrc); cname != "" {
+				source.cname = cname
+			}
+		}
+	}
+
+	return source
+}
+
+func (t *DTLSTransport) setInterceptorRTCPWriter(writer interceptor.RTCPWriter) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.interceptorRTCPWriter = writer
+}
+
+// writeRTCPThroughInterceptors sends pkts through the interceptors of the PeerConnection,
+// or directly when the transport isn't owned by one.
+func (t *DTLSTransport) writeRTCPThroughInterceptors(pkts []rtcp.Packet) (int, error) {
+	t.lock.RLock()
+	writer := t.interceptorRTCPWriter
+	t.lock.RUnlock()
+
+	if writer == nil {
+		return t.WriteRTCP(pkts)
+	}
+
+	return writer.Write(pkts, interceptor.Attributes{immediateRTCPKey{}: true})
+}
+
+// setRTCPSSRCs sets the sender SSRC of pkts, and the media SSRC of feedback, where they are
+// zero. A zero senderSSRC or mediaSSRC leaves them unchanged.
+func setRTCPSSRCs(pkts []rtcp.Packet, senderSSRC, mediaSSRC uint32) { //nolint:cyclop
+	setIfZero := func(field *uint32, ssrc uint32) {
+		if *field == 0 {
+			*field = ssrc
+		}
+	}
+
+	for _, pkt := range pkts {
+		switch pkt := pkt.(type) {
+		case *rtcp.PictureLossIndication:
+			setIfZero(&pkt.SenderSSRC, senderSSRC)
+			setIfZero(&pkt.MediaSSRC, mediaSSRC)
+		case *rtcp.FullIntraRequest:
+			setIfZero(&pkt.SenderSSRC, senderSSRC)
+			setIfZero(&pkt.MediaSSRC, mediaSSRC)
+		case *rtcp.TransportLayerNack:
+			setIfZero(&pkt.SenderSSRC, senderSSRC)
+			setIfZero(&pkt.MediaSSRC, mediaSSRC)
+		case *rtcp.SliceLossIndication:
+			setIfZero(&pkt.SenderSSRC, senderSSRC)
+			setIfZero(&pkt.MediaSSRC, mediaSSRC)
+		case *rtcp.RapidResynchronizationRequest:
+			setIfZero(&pkt.SenderSSRC, senderSSRC)
+			setIfZero(&pkt.MediaSSRC, mediaSSRC)
+		case *rtcp.TransportLayerCC:
+			setIfZero(&pkt.SenderSSRC, senderSSRC)
+			setIfZero(&pkt.MediaSSRC, mediaSSRC)
+		case *rtcp.ReceiverEstimatedMaximumBitrate:
+			setIfZero(&pkt.SenderSSRC, senderSSRC)
+			if len(pkt.SSRCs) == 0 && mediaSSRC != 0 {
+				pkt.SSRCs = []uint32{mediaSSRC}
+			}
+		case *rtcp.ReceiverReport:
+			setIfZero(&pkt.SSRC, senderSSRC)
+		case *rtcp.SenderReport:
+			setIfZero(&pkt.SSRC, senderSSRC)
+		}
+	}
 }
 
 // GetLocalParameters returns the DTLS parameters of the local DTLSTransport upon construction.
@@ -197,33 +384,11 @@ func (t *DTLSTransport) startSRTP() error {
 		BufferFactory: t.api.settingEngine.BufferFactory,
 		LoggerFactory: t.api.settingEngine.LoggerFactory,
 	}
-	if t.api.settingEngine.replayProtection.SRTP != nil {
-		srtpConfig.RemoteOptions = append(
-			srtpConfig.RemoteOptions,
-			srtp.SRTPReplayProtection(*t.api.settingEngine.replayProtection.SRTP),
-		)
-	}
-
-	if t.api.settingEngine.disableSRTPReplayProtection {
-		srtpConfig.RemoteOptions = append(
-			srtpConfig.RemoteOptions,
-			srtp.SRTPNoReplayProtection(),
-		)
-	}
-
-	if t.api.settingEngine.replayProtection.SRTCP != nil {
-		srtpConfig.RemoteOptions = append(
-			srtpConfig.RemoteOptions,
-			srtp.SRTCPReplayProtection(*t.api.settingEngine.replayProtection.SRTCP),
-		)
-	}
-
-	if t.api.settingEngine.disableSRTCPReplayProtection {
-		srtpConfig.RemoteOptions = append(
-			srtpConfig.RemoteOptions,
-			srtp.SRTCPNoReplayProtection(),
-		)
+	if srtpConfig.BufferFactory == nil {
+		srtpConfig.BufferFactory = t.receiveBuffers.newBuffer
 	}
+	srtpConfig.BufferFactory = t.goodbyeBufferFactory(srtpConfig.BufferFactory)
+	srtpConfig.RemoteOptions = append(srtpConfig.RemoteOptions, t.replayProtectionOptions()...)
 
 	connState, ok := t.conn.ConnectionState()
 	if !ok {
@@ -231,7 +396,7 @@ func (t *DTLSTransport) startSRTP() error {
 		return fmt.Errorf("%w: Failed to get DTLS ConnectionState", errDtlsKeyExtractionFailed)
 	}
 
-	err := srtpConfig.ExtractSessionKeysFromDTLS(&connState, t.role() == DTLSRoleClient)
+	err := srtpConfig.ExtractSessionKeysFromDTLS(&connState, t.startedRole == DTLSRoleClient)
 	if err != nil {
 		// nolint
 		return fmt.Errorf("%w: %v", errDtlsKeyExtractionFailed, err)
@@ -256,6 +421,37 @@ func (t *DTLSTransport) startSRTP() error {
 	return nil
 }
 
+// goodbyeBufferFactory wraps the RTCP buffers made by bufferFactory, a RTCP BYE written
+// to them is handled before the RTCP is read.
+func (t *DTLSTransport) goodbyeBufferFactory(
+	bufferFactory func(packetio.BufferPacketType, uint32) io.ReadWriteCloser,
+) func(packetio.BufferPacketType, uint32) io.ReadWriteCloser {
+	return func(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+		buffer := bufferFactory(packetType, ssrc)
+		if packetType != packetio.RTCPBufferPacket {
+			return buffer
+		}
+
+		return &goodbyeBuffer{ReadWriteCloser: buffer, ssrc: ssrc, onGoodbye: t.handleGoodbye}
+	}
+}
+
+// onGoodbye sets the handler of a RTCP BYE for ssrc, a nil handler removes it.
+func (t *DTLSTransport) onGoodbye(ssrc SSRC, handler func()) {
+	if handler == nil {
+		t.goodbyeHandlers.Delete(ssrc)
+
+		return
+	}
+	t.goodbyeHandlers.Store(ssrc, handler)
+}
+
+func (t *DTLSTransport) handleGoodbye(ssrc SSRC) {
+	if handler, ok := t.goodbyeHandlers.Load(ssrc); ok {
+		handler.(func())() //nolint:forcetypeassert
+	}
+}
+
 func (t *DTLSTransport) getSRTPSession() (*srtp.SessionSRTP, error) {
 	if value, ok := t.srtpSession.Load().(*srtp.SessionSRTP); ok {
 		return value, nil
@@ -292,13 +488,26 @@ func (t *DTLSTransport) role() DTLSRole {
 	}
 
 	// Remote was auto and no explicit role was configured via SettingEngine
-	if t.iceTransport.Role() == ICERoleControlling {
+	if t.iceTransport.signaledRole() == ICERoleControlling {
 		return DTLSRoleServer
 	}
 
 	return defaultDtlsRoleAnswer
 }
 
+// currentRole returns the role of the DTLS connection. The role derived from the
+// descriptions can change with a renegotiation, the one of the connection can't.
+func (t *DTLSTransport) currentRole() DTLSRole {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if t.startedRole != DTLSRoleUnknown {
+		return t.startedRole
+	}
+
+	return t.role()
+}
+
 // Start DTLS transport negotiation with the parameters of the remote DTLS transport.
 func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	role, certificate, err := t.prepareStart(remoteParameters)
@@ -309,17 +518,27 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	dtlsEndpoint := t.iceTransport.newEndpoint(mux.MatchDTLS)
 	dtlsEndpoint.SetOnClose(t.internalOnCloseHandler)
 
+	if err = t.acquireHandshakeSlot(); err != nil {
+		dtlsEndpoint.SetOnClose(nil)
+		_ = dtlsEndpoint.Close()
+
+		return t.failStart(err)
+	}
+
 	sharedOpts := t.dtlsSharedOptions(certificate)
 
 	dtlsConn, err := t.connectDTLS(dtlsEndpoint, role, sharedOpts)
 	if err != nil {
+		t.api.handshakeLimiter.release()
 		dtlsEndpoint.SetOnClose(nil)
 		_ = dtlsEndpoint.Close()
 
 		return t.failStart(err)
 	}
 
-	if err = t.handshakeDTLS(dtlsConn); err != nil {
+	err = t.handshakeDTLS(dtlsConn)
+	t.api.handshakeLimiter.release()
+	if err != nil {
 		dtlsEndpoint.SetOnClose(nil)
 		_ = dtlsConn.Close()
 
@@ -336,6 +555,34 @@ func (t *DTLSTransport) Start(remoteParameters DTLSParameters) error {
 	return nil
 }
 
+// acquireHandshakeSlot waits until the API handshake limit allows this transport to
+// handshake. Waiting is aborted if the DTLSTransport is stopped.
+func (t *DTLSTransport) acquireHandshakeSlot() error {
+	if t.api.handshakeLimiter == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	t.lock.Lock()
+	if t.state == DTLSTransportStateClosed {
+		t.lock.Unlock()
+
+		return errHandshakeQueueCanceled
+	}
+	t.cancelQueuedHandshake = cancel
+	t.lock.Unlock()
+
+	err := t.api.handshakeLimiter.acquire(ctx)
+
+	t.lock.Lock()
+	t.cancelQueuedHandshake = nil
+	t.lock.Unlock()
+
+	return err
+}
+
 func (t *DTLSTransport) prepareStart(remoteParameters DTLSParameters) (DTLSRole, tls.Certificate, error) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
@@ -353,11 +600,13 @@ func (t *DTLSTransport) prepareStart(remoteParameters DTLSParameters) (DTLSRole,
 	t.srtpEndpoint = t.iceTransport.newEndpoint(mux.MatchSRTP)
 	t.srtcpEndpoint = t.iceTransport.newEndpoint(mux.MatchSRTCP)
 	t.remoteParameters = remoteParameters
+	t.startedRole = t.role()
 
 	cert := t.certificates[0]
+	t.timeline.record(TimelineEventDTLSHandshakeStarted, t.startedRole.String())
 	t.onStateChange(DTLSTransportStateConnecting)
 
-	return t.role(), tls.Certificate{
+	return t.startedRole, tls.Certificate{
 		Certificate: [][]byte{cert.x509Cert.Raw},
 		PrivateKey:  cert.privateKey,
 	}, nil
@@ -434,11 +683,42 @@ func (t *DTLSTransport) srtpProtectionProfiles() []dtls.SRTPProtectionProfile {
 	return defaultSrtpProtectionProfiles()
 }
 
+// collectCertificateStats collects the stats of the remote certificate once it is received,
+// it returns the IDs of the CertificateStats of the local and remote certificates.
+func (t *DTLSTransport) collectCertificateStats(collector *statsReportCollector) (localID, remoteID string) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	if len(t.certificates) != 0 {
+		localID = t.certificates[0].statsID
+	}
+	if len(t.remoteCertificate) == 0 {
+		return localID, ""
+	}
+
+	parsedRemoteCert, err := x509.ParseCertificate(t.remoteCertificate)
+	if err != nil {
+		return localID, ""
+	}
+	remoteCertificate := Certificate{x509Cert: parsedRemoteCert}
+	fingerprints, err := remoteCertificate.GetFingerprints()
+	if err != nil {
+		return localID, ""
+	}
+	remoteCertificate.statsID = "certificate-remote-" + fingerprints[0].Value
+	if err = remoteCertificate.collectStats(collector); err != nil {
+		return localID, ""
+	}
+
+	return localID, remoteCertificate.statsID
+}
+
 func (t *DTLSTransport) verifyPeerCertificateFunc() func([][]byte, [][]*x509.Certificate) error {
 	return func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
 		if len(rawCerts) == 0 {
 			return errNoRemoteCertificate
 		}
+		t.timeline.record(TimelineEventDTLSPeerCertificate, "")
 
 		t.lock.Lock()
 		defer t.lock.Unlock()
@@ -498,11 +778,18 @@ func (t *DTLSTransport) toDTLSServerOptions(sharedOpts []dtls.Option) []dtls.Ser
 		dtls.WithInsecureSkipVerifyHello(t.api.settingEngine.dtls.insecureSkipHelloVerify),
 	)
 
-	if t.api.settingEngine.dtls.serverHelloMessageHook != nil {
-		serverOpts = append(
-			serverOpts,
-			dtls.WithServerHelloMessageHook(t.api.settingEngine.dtls.serverHelloMessageHook),
-		)
+	// The hook also records the hello flight in the connection timeline
+	if hook := t.api.settingEngine.dtls.serverHelloMessageHook; hook != nil || t.timeline != nil {
+		serverOpts = append(serverOpts, dtls.WithServerHelloMessageHook(
+			func(hello handshake.MessageServerHello) handshake.Message {
+				t.timeline.record(TimelineEventDTLSHelloFlight, "ServerHello")
+				if hook != nil {
+					return hook(hello)
+				}
+
+				return &hello
+			},
+		))
 	}
 
 	if t.api.settingEngine.dtls.certificateRequestMessageHook != nil {
@@ -521,11 +808,17 @@ func (t *DTLSTransport) toDTLSClientOptions(sharedOpts []dtls.Option) []dtls.Cli
 		clientOpts = append(clientOpts, opt)
 	}
 
-	if t.api.settingEngine.dtls.clientHelloMessageHook != nil {
-		clientOpts = append(
-			clientOpts,
-			dtls.WithClientHelloMessageHook(t.api.settingEngine.dtls.clientHelloMessageHook),
-		)
+	if hook := t.api.settingEngine.dtls.clientHelloMessageHook; hook != nil || t.timeline != nil {
+		clientOpts = append(clientOpts, dtls.WithClientHelloMessageHook(
+			func(hello handshake.MessageClientHello) handshake.Message {
+				t.timeline.record(TimelineEventDTLSHelloFlight, "ClientHello")
+				if hook != nil {
+					return hook(hello)
+				}
+
+				return &hello
+			},
+		))
 	}
 
 	return clientOpts
@@ -557,7 +850,9 @@ func (t *DTLSTransport) completeStart(dtlsConn *dtls.Conn) error {
 	}
 
 	t.srtpProtectionProfile = srtpProtectionProfile
+	t.srtcpOverhead.Store(int32(srtcpOverhead(srtpProtectionProfile))) //nolint:gosec // G115
 	t.conn = dtlsConn
+	t.timeline.record(TimelineEventDTLSConnected, "")
 	t.onStateChange(DTLSTransportStateConnected)
 
 	return t.startSRTP()
@@ -600,6 +895,10 @@ func (t *DTLSTransport) Stop() error {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
+	if t.cancelQueuedHandshake != nil {
+		t.cancelQueuedHandshake()
+	}
+
 	// Try closing everything and collect the errors
 	var closeErrs []error
 
@@ -656,13 +955,71 @@ func (t *DTLSTransport) ensureICEConn() error {
 }
 
 func (t *DTLSTransport) storeSimulcastStream(
+	ssrc SSRC,
 	srtpReadStream *srtp.ReadStreamSRTP,
 	srtcpReadStream *srtp.ReadStreamSRTCP,
-) {
+) *simulcastStreamPair {
+	pair := &simulcastStreamPair{ssrc: ssrc, srtp: srtpReadStream, srtcp: srtcpReadStream}
+	limit := t.api.settingEngine.getSimulcastProbeStreamLimit()
+
+	t.lock.Lock()
+	t.simulcastStreams = append(t.simulcastStreams, pair)
+
+	// Evict the oldest unresolved streams, a remote sending many random SSRCs
+	// would otherwise make us hold on to a stream for each of them.
+	var evicted []*simulcastStreamPair
+	unresolved := 0
+	for _, p := range t.simulcastStreams {
+		if !p.resolved {
+			unresolved++
+		}
+	}
+	for unresolved > limit {
+		i := slices.IndexFunc(t.simulcastStreams, func(p *simulcastStreamPair) bool { return !p.resolved })
+		evicted = append(evicted, t.simulcastStreams[i])
+		t.simulcastStreams = slices.Delete(t.simulcastStreams, i, i+1)
+		unresolved--
+	}
+	t.probeStreamsEvicted += uint64(len(evicted))
+	t.lock.Unlock()
+
+	for _, p := range evicted {
+		t.log.Warnf("Simulcast probe stream limit of %d reached, evicting SSRC %d", limit, p.ssrc)
+		if err := p.srtp.Close(); err != nil {
+			t.log.Warnf("Failed to close RTP stream %v", err)
+		}
+		if err := p.srtcp.Close(); err != nil {
+			t.log.Warnf("Failed to close RTCP stream %v", err)
+		}
+		if handler := t.api.settingEngine.simulcastProbe.limitHandler; handler != nil {
+			handler(p.ssrc)
+		}
+	}
+
+	return pair
+}
+
+// resolveSimulcastStream marks a stored stream as resolved, it isn't evicted anymore.
+func (t *DTLSTransport) resolveSimulcastStream(pair *simulcastStreamPair) {
 	t.lock.Lock()
 	defer t.lock.Unlock()
 
-	t.simulcastStreams = append(t.simulcastStreams, simulcastStreamPair{srtpReadStream, srtcpReadStream})
+	pair.resolved = true
+}
+
+// probeStreamCounts returns how many stored streams are still unresolved, and how many
+// were evicted because of the simulcast probe stream limit.
+func (t *DTLSTransport) probeStreamCounts() (unresolved uint32, evicted uint64) {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
+	for _, pair := range t.simulcastStreams {
+		if !pair.resolved {
+			unresolved++
+		}
+	}
+
+	return unresolved, t.probeStreamsEvicted
 }
 
 func (t *DTLSTransport) streamsForSSRC(
@@ -679,11 +1036,21 @@ func (t *DTLSTransport) streamsForSSRC(
 		return nil, err
 	}
 
+	// The highest sequence number received on the stream, see transportQuality.countPacket.
+	// Retransmissions are accounted by the loss of the original stream.
+	var highestSequenceNumber atomic.Uint32
+	countLoss := !strings.EqualFold(streamInfo.MimeType, MimeTypeRTX)
 	rtpInterceptor := t.api.interceptor.BindRemoteStream(
 		&streamInfo,
 		interceptor.RTPReaderFunc(
 			func(in []byte, a interceptor.Attributes) (n int, attributes interceptor.Attributes, err error) {
 				n, err = rtpReadStream.Read(in)
+				if err == nil && !t.timeline.recorded(TimelineEventFirstSRTPPacket) {
+					t.timeline.record(TimelineEventFirstSRTPPacket, "")
+				}
+				if err == nil && countLoss && n >= 4 {
+					t.quality.countPacket(&highestSequenceNumber, binary.BigEndian.Uint16(in[2:4]), time.Now())
+				}
 
 				return n, a, err
 			},
@@ -5,6 +5,8 @@ package webrtc
 
 import (
 	"errors"
+	"fmt"
+	"io"
 )
 
 var (
@@ -30,6 +32,10 @@ var (
 	// or malformed.
 	ErrTurnCredentials = errors.New("invalid turn server credentials")
 
+	// ErrOAuthTURNUnsupported indicates that no relay candidates can be gathered
+	// from a TURN server with OAuth credentials.
+	ErrOAuthTURNUnsupported = errors.New("OAuth credentials are not supported for TURN")
+
 	// ErrExistingTrack indicates that a track already exists.
 	ErrExistingTrack = errors.New("track already exists")
 
@@ -57,6 +63,10 @@ var (
 	// ICECandidatePoolSize was made after PeerConnection has been initialized.
 	ErrModifyingICECandidatePoolSize = errors.New("ice candidate pool size cannot be modified")
 
+	// ErrModifyingICECandidateTypes indicates that SetConfiguration was called with
+	// ICECandidateTypes allowing a type the current configuration doesn't allow.
+	ErrModifyingICECandidateTypes = errors.New("ice candidate types can only be restricted further")
+
 	// ErrStringSizeLimit indicates that the character size limit of string is
 	// exceeded. The limit is hardcoded to 65535 according to specifications.
 	ErrStringSizeLimit = errors.New("data channel label exceeds size limit")
@@ -65,6 +75,10 @@ var (
 	// specified for a data channel has been exceeded.
 	ErrMaxDataChannelID = errors.New("maximum number ID for datachannel specified")
 
+	// ErrDataChannelIDInUse indicates that an attempt to create a data channel was
+	// made with the ID of a data channel that isn't closed yet.
+	ErrDataChannelIDInUse = errors.New("data channel ID already in use")
+
 	// ErrNegotiatedWithoutID indicates that an attempt to create a data channel
 	// was made while setting the negotiated option to true without providing
 	// the negotiated channel ID.
@@ -79,6 +93,10 @@ var (
 	// ErrCodecNotFound is returned when a codec search to the Media Engine fails.
 	ErrCodecNotFound = errors.New("codec not found")
 
+	// ErrCodecInUse indicates that a codec can't be unregistered from a MediaEngine
+	// because a sender or receiver of a PeerConnection using it is bound to the codec.
+	ErrCodecInUse = errors.New("codec is in use by a transceiver")
+
 	// ErrNoRemoteDescription indicates that an operation was rejected because
 	// the remote description is not set.
 	ErrNoRemoteDescription = errors.New("remote description is not set")
@@ -145,6 +163,14 @@ var (
 	// ErrUnsupportedCodec indicates the remote peer doesn't support the requested codec.
 	ErrUnsupportedCodec = errors.New("unable to start track, codec is not supported by remote")
 
+	// ErrClockRateMismatch indicates that the clock rate of a track differs from the one
+	// of the codecs with the same MimeType registered in the MediaEngine.
+	ErrClockRateMismatch = errors.New("track clock rate doesn't match the registered codec")
+
+	// ErrChannelsMismatch indicates that the channel count of a track differs from the one
+	// of the codecs with the same MimeType registered in the MediaEngine.
+	ErrChannelsMismatch = errors.New("track channel count doesn't match the registered codec")
+
 	// ErrSenderWithNoCodecs indicates that a RTPSender was created without any codecs. To send media the MediaEngine
 	//  needs at least one configured codec.
 	ErrSenderWithNoCodecs = errors.New("unable to populate media section, RTPSender created with no codecs")
@@ -152,6 +178,10 @@ var (
 	// ErrCodecAlreadyRegistered indicates that a codec has already been registered for the same payload type.
 	ErrCodecAlreadyRegistered = errors.New("codec already registered for same payload type")
 
+	// ErrUnknownAssociatedPayloadType indicates that a RTX, RED or FEC codec references a payload
+	// type, with apt or its redundant encodings, that isn't registered for the same kind.
+	ErrUnknownAssociatedPayloadType = errors.New("codec references an unknown associated payload type")
+
 	// ErrRTPSenderNewTrackHasIncorrectKind indicates that the new track is of a different kind than the previous/original.
 	ErrRTPSenderNewTrackHasIncorrectKind = errors.New("new track must be of the same kind as previous")
 
@@ -175,9 +205,83 @@ var (
 	// and the requested SSRC was ignored.
 	ErrSimulcastProbeOverflow = errors.New("simulcast probe limit has been reached, new SSRC has been discarded")
 
+	// ErrSSRCConflict indicates that an SSRC set for a sender with WithSSRC, WithRTXSSRC or
+	// RTPTransceiverInit is used by another sender or by the remote.
+	ErrSSRCConflict = errors.New("SSRC is already in use")
+
 	// ErrSDPUnmarshalling indicates that the SDP could not be unmarshalled.
 	ErrSDPUnmarshalling = errors.New("failed to unmarshal SDP")
 
+	// ErrTrackIdentifierTooLong indicates that a remote description contains a track or stream
+	// identifier longer than allowed by SettingEngine.SetTrackIdentifierPolicy.
+	ErrTrackIdentifierTooLong = errors.New("track identifier is too long")
+
+	// ErrTrackIdentifierInvalidCharacter indicates that a remote description contains a track or
+	// stream identifier with control characters or invalid UTF-8.
+	ErrTrackIdentifierInvalidCharacter = errors.New("track identifier contains invalid characters")
+
+	// ErrTrackIdentifierDuplicate indicates that a remote description uses the same track
+	// identifier for tracks of the same stream in different media sections.
+	ErrTrackIdentifierDuplicate = errors.New("duplicate track identifier in stream")
+
+	// ErrICECandidateTypesConflict indicates that none of the candidate types of
+	// Configuration.ICECandidateTypes can be gathered with the ICETransportPolicy.
+	ErrICECandidateTypesConflict = errors.New("ice candidate types exclude the types allowed by the transport policy")
+
+	// ErrInvalidSampleDuration indicates that a Sample without a positive Duration was written,
+	// and its AllowZeroDuration wasn't set.
+	ErrInvalidSampleDuration = errors.New("sample duration must be positive")
+
+	// ErrEmptySample indicates that a Sample without Data was written, and it didn't account
+	// for dropped packets either.
+	ErrEmptySample = errors.New("sample has no data")
+
+	// ErrInvalidVideoOrientation indicates that a Sample was written with a VideoOrientation
+	// that the video orientation header extension can't carry.
+	ErrInvalidVideoOrientation = errors.New("video orientation rotation must be 0, 90, 180 or 270")
+
+	// ErrTrackLocalDraining indicates that a sample was written to a track while its RTPSender
+	// is stopped with StopWithFlush. The samples written before it are still sent.
+	ErrTrackLocalDraining = errors.New("track is draining, the RTPSender is stopping")
+
+	// ErrTrackStarvation indicates that a TrackRemote received no packet within the timeout
+	// of SettingEngine.SetTrackFirstPacketTimeout. It isn't fatal, the track can be read again.
+	ErrTrackStarvation = errors.New("track received no packet")
+
+	// ErrTrackEnded indicates that the remote ended a TrackRemote: its media section no longer
+	// sends, was rejected, or a RTCP BYE was received for its SSRC. It wraps io.EOF.
+	ErrTrackEnded = fmt.Errorf("track ended by the remote: %w", io.EOF)
+
+	// ErrReceiverRestarted indicates that the RTPReceiver of a TrackRemote was replaced in a
+	// renegotiation while the remote media section still sends, e.g. because its SSRC changed.
+	// The TrackRemote isn't read anymore, the media of the remote is delivered by OnTrack with
+	// a new TrackRemote once it arrives on the new RTPReceiver.
+	ErrReceiverRestarted = errors.New("receiver was restarted by a renegotiation")
+
+	// ErrJitterBufferDisabled indicates that TrackRemote.ReadSample was called, but
+	// SettingEngine.EnableJitterBuffer wasn't.
+	ErrJitterBufferDisabled = errors.New("jitter buffer is not enabled")
+
+	// ErrJitterBufferCodecNotSupported indicates that TrackRemote.ReadSample can't
+	// depacketize the codec of the track.
+	ErrJitterBufferCodecNotSupported = errors.New("jitter buffer doesn't support the codec of the track")
+
+	// ErrTrackReadBySample indicates that Read or ReadRTP was called on a TrackRemote
+	// that is read by ReadSample. A track is read by either, not both.
+	ErrTrackReadBySample = errors.New("track is read by ReadSample")
+
+	// ErrWaitInOperation indicates that WaitForPendingOperations was called from a callback
+	// running on the operations queue of the PeerConnection, where it would never return.
+	ErrWaitInOperation = errors.New("can't wait for pending operations from an operation")
+
+	// ErrRTCPPacketTooLarge indicates that a RTCP packet doesn't fit in the maximum size of a
+	// RTCP datagram, see SettingEngine.SetRTCPMaxPacketSize, and it can't be split.
+	ErrRTCPPacketTooLarge = errors.New("RTCP packet is too large")
+
+	// ErrUserDataKeyNotComparable indicates that SetUserData was given a key that can't be
+	// compared, like a slice or a map, or a nil key.
+	ErrUserDataKeyNotComparable = errors.New("user data key must be comparable and not nil")
+
 	errDetachNotEnabled                 = errors.New("enable detaching by calling webrtc.DetachDataChannels()")
 	errDetachBeforeOpened               = errors.New("datachannel not opened yet, try calling Detach from OnOpen")
 	errDtlsTransportNotStarted          = errors.New("the DTLS transport has not started yet")
@@ -191,6 +295,7 @@ var (
 
 	errICEConnectionNotStarted        = errors.New("ICE connection not started")
 	errICECandidateTypeUnknown        = errors.New("unknown candidate type")
+	errICECandidateTypeNotGathered    = errors.New("candidate type can't be gathered")
 	errICEInvalidConvertCandidateType = errors.New(
 		"cannot convert ice.CandidateType into webrtc.ICECandidateType, invalid type",
 	)
@@ -199,8 +304,13 @@ var (
 	errICERoleUnknown              = errors.New("unknown ICE Role")
 	errICEProtocolUnknown          = errors.New("unknown protocol")
 	errICEGathererNotStarted       = errors.New("gatherer not started")
+	errICEBindingRequestAddress    = errors.New("binding request address is neither UDP nor TCP")
 	errAddressRewriteWithNAT1To1   = errors.New("address rewrite rules cannot be combined with NAT1To1IPs")
 
+	errAddressRewriteWithStaticCandidates = errors.New(
+		"address rewrite rules and NAT1To1IPs cannot be combined with static local candidates",
+	)
+
 	errNetworkTypeUnknown = errors.New("unknown network type")
 
 	errSDPDoesNotMatchOffer        = errors.New("new sdp does not match previous offer")
@@ -211,6 +321,7 @@ var (
 	errPeerConnStateChangeInvalid                     = errors.New("invalid state change op")
 	errPeerConnStateChangeUnhandled                   = errors.New("unhandled state change op")
 	errPeerConnSDPTypeInvalidValueSetLocalDescription = errors.New("invalid SDP type supplied to SetLocalDescription()")
+	errPeerConnRemoteRollbackUnsupported              = errors.New("rolling back a remote offer is not supported")
 	errPeerConnRemoteDescriptionWithoutMidValue       = errors.New(
 		"remoteDescription contained media section without mid value",
 	)
@@ -248,7 +359,6 @@ var (
 	errRTPSenderTrackNil             = errors.New("Track must not be nil")
 	errRTPSenderDTLSTransportNil     = errors.New("DTLSTransport must not be nil")
 	errRTPSenderSendAlreadyCalled    = errors.New("Send has already been called")
-	errRTPSenderSendNotCalled        = errors.New("Send has not been called")
 	errRTPSenderStopped              = errors.New("Sender has already been stopped")
 	errRTPSenderTrackRemoved         = errors.New("Sender Track has been removed or replaced to nil")
 	errRTPSenderRidNil               = errors.New("Sender cannot add encoding as rid is empty")
@@ -256,20 +366,52 @@ var (
 	errRTPSenderBaseEncodingMismatch = errors.New("Sender cannot add encoding as provided track does not match base track")
 	errRTPSenderRIDCollision         = errors.New("Sender cannot encoding due to RID collision")
 	errRTPSenderNoTrackForRID        = errors.New("Sender does not have track for RID")
+	errRTPSenderNotSending           = errors.New("Sender has not started sending")
+	errRTPSenderPaddingBeforeMedia   = errors.New("Sender cannot send padding without RTX before media")
 
 	errRTPTransceiverCannotChangeMid        = errors.New("cannot change transceiver mid")
 	errRTPTransceiverSetSendingInvalidState = errors.New("invalid state change in RTPTransceiver.setSending")
 	errRTPTransceiverCodecUnsupported       = errors.New("unsupported codec type by this transceiver")
+	errRTPTransceiverNoSenderForDirection   = errors.New("cannot set a sending direction on a transceiver without sender")
+	errRTPTransceiverNotCreatedByConnection = errors.New("RTPTransceiver not created by this PeerConnection")
 
 	errSCTPTransportDTLS = errors.New("DTLS not established")
 
+	errRTCPFeedbackRouterTrackNil      = errors.New("RTCPFeedbackRouter requires an upstream track")
+	errRTCPFeedbackRouterInvalidAction = errors.New("invalid FeedbackAction")
+	errRTCPFeedbackRouterCannotForward = errors.New("only NACK, PLI and FIR can be forwarded upstream")
+
+	errHandshakeQueueCanceled = errors.New("DTLSTransport stopped while waiting for a handshake slot")
+
 	errSDPZeroTransceivers                 = errors.New("addTransceiverSDP() called with 0 transceivers")
+	errSDPPayloadTypeNotFound              = errors.New("payload type not found")
 	errSDPMediaSectionMediaDataChanInvalid = errors.New("invalid Media Section. Media + DataChannel both enabled")
 	errSDPMediaSectionMultipleTrackInvalid = errors.New(
 		"invalid Media Section. Can not have multiple tracks in one MediaSection in UnifiedPlan",
 	)
 
-	errSettingEngineSetAnsweringDTLSRole = errors.New("SetAnsweringDTLSRole must DTLSRoleClient or DTLSRoleServer")
+	errSettingEngineSetAnsweringDTLSRole      = errors.New("SetAnsweringDTLSRole must DTLSRoleClient or DTLSRoleServer")
+	errSettingEngineSetICERole                = errors.New("SetICERole must be ICERoleControlling or ICERoleControlled")
+	errSettingEngineSetDSCP                   = errors.New("SetDSCP values must be lower than 64")
+	errSettingEngineICEMaxBindingRequests     = errors.New("ICE max binding requests must be at least 1")
+	errSettingEngineICECheckInterval          = errors.New("ICE check interval must be greater than zero")
+	errSettingEngineICENominationMode         = errors.New("unknown ICE nomination mode")
+	errSettingEngineICEGatheringPolicy        = errors.New("unknown ICE gathering policy")
+	errSettingEngineICENetworkMonitorInterval = errors.New("ICE network monitor interval must be greater than zero")
+	errSettingEngineICEUsernameFragment       = errors.New(
+		"ICE username fragment must be 4 to 256 characters of ALPHA, DIGIT, '+' or '/'",
+	)
+	errSettingEngineICEPassword = errors.New(
+		"ICE password must be 22 to 256 characters of ALPHA, DIGIT, '+' or '/'",
+	)
+
+	errSettingEngineStaticLocalCandidate        = errors.New("invalid static local candidate")
+	errSettingEngineStaticLocalCandidatePort    = errors.New("static local candidate doesn't match the port of the UDPMux")
+	errSettingEngineStaticLocalCandidatesUDPMux = errors.New("static local candidates require a UDPMux")
+	errSettingEngineStaticLocalCandidateFamily  = errors.New("no static local candidate for the IP family of the UDPMux")
+	errSettingEngineDynamicGatheringDisabled    = errors.New(
+		"dynamic gathering can only be disabled with static local candidates",
+	)
 
 	errSignalingStateCannotRollback            = errors.New("can't rollback from stable state")
 	errSignalingStateProposedTransitionInvalid = errors.New("invalid proposed signaling state transition")
@@ -286,11 +428,23 @@ var (
 	errICETransportNotInNew = errors.New("ICETransport can only be called in ICETransportStateNew")
 	errICETransportClosed   = errors.New("ICETransport closed")
 
+	errICEMulticastDNSDisabled       = errors.New("remote mDNS candidate added, but mDNS is disabled")
+	errICEMulticastDNSTooManyQueries = errors.New("too many remote mDNS candidates are resolved at once")
+
 	errCertificatePEMMultipleCert = errors.New("failed parsing certificate, more than 1 CERTIFICATE block in pems")
 	errCertificatePEMMultiplePriv = errors.New("failed parsing certificate, more than 1 PRIVATE KEY block in pems")
 	errCertificatePEMMissing      = errors.New("failed parsing certificate, pems must contain both a CERTIFICATE block and a PRIVATE KEY block") // nolint: lll
+	errCertificatePEMKeyMismatch  = errors.New("failed parsing certificate, the PRIVATE KEY doesn't match the CERTIFICATE")
 
 	errRTPTooShort = errors.New("not long enough to be a RTP Packet")
 
+	errVideoLayersAllocationTooShort  = errors.New("video layers allocation is truncated")
+	errVideoLayersAllocationInvalid   = errors.New("video layers allocation is out of range")
+	errVideoLayersAllocationUnordered = errors.New("video layers allocation must be ordered by stream and spatial id")
+
+	errPlayoutDelayInvalid = errors.New("playout delay is out of range")
+
+	errOpusMaxAverageBitrateInvalid = errors.New("opus maxaveragebitrate must be between 6000 and 510000")
+
 	errExcessiveRetries = errors.New("excessive retries in CreateOffer")
 )
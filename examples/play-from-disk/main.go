@@ -219,7 +219,10 @@ func main() { //nolint:gocognit,cyclop,gocyclo,maintidx
 				lastGranule = pageHeader.GranulePosition
 				sampleDuration := time.Duration((sampleCount/48000)*1000) * time.Millisecond
 
-				if oggErr = audioTrack.WriteSample(media.Sample{Data: pageData, Duration: sampleDuration}); oggErr != nil {
+				// Pages without audio, like the comment header, don't advance the granule position
+				if oggErr = audioTrack.WriteSample(media.Sample{
+					Data: pageData, Duration: sampleDuration, AllowZeroDuration: true,
+				}); oggErr != nil {
 					panic(oggErr)
 				}
 			}
@@ -192,10 +192,12 @@ func createRTCConn() *webrtc.PeerConnection { //nolint:cyclop
 		for rtp, _, readErr := tr.ReadRTP(); readErr == nil; rtp, _, readErr = tr.ReadRTP() {
 			sb.Push(rtp)
 			for sample := sb.Pop(); sample != nil; sample = sb.Pop() {
-				// WriteSample takes sample.Data and packetizes it according to the track's codec
+				// WriteSample takes sample.Data and packetizes it according to the track's codec.
+				// The first sample has no Duration, there is no previous one to measure it from.
 				err := newTrack.WriteSample(media.Sample{
-					Data:     sample.Data,
-					Duration: sample.Duration,
+					Data:              sample.Data,
+					Duration:          sample.Duration,
+					AllowZeroDuration: true,
 				})
 				if err != nil {
 					panic(err)
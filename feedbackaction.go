@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// FeedbackAction is what a RTCPFeedbackRouter does with a RTCP packet.
+type FeedbackAction int
+
+const (
+	// FeedbackActionUnknown is the enum's zero-value.
+	FeedbackActionUnknown FeedbackAction = iota
+
+	// FeedbackActionAnswerLocally leaves the packet to the interceptors of the
+	// RTPSender it was read from, e.g. NACKs are answered by the NACK responder
+	// from its local packet cache.
+	FeedbackActionAnswerLocally
+
+	// FeedbackActionForwardUpstream writes the packet to the PeerConnection
+	// receiving the forwarded track, addressed to its media source. The
+	// interceptors of the RTPSender don't see it, so it isn't answered locally.
+	FeedbackActionForwardUpstream
+
+	// FeedbackActionDrop discards the packet before the interceptors of the
+	// RTPSender see it.
+	FeedbackActionDrop
+)
+
+// This is done this way because of a linter.
+const (
+	feedbackActionAnswerLocallyStr   = "answer-locally"
+	feedbackActionForwardUpstreamStr = "forward-upstream"
+	feedbackActionDropStr            = "drop"
+)
+
+func (a FeedbackAction) String() string {
+	switch a {
+	case FeedbackActionAnswerLocally:
+		return feedbackActionAnswerLocallyStr
+	case FeedbackActionForwardUpstream:
+		return feedbackActionForwardUpstreamStr
+	case FeedbackActionDrop:
+		return feedbackActionDropStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFeedbackAction_String(t *testing.T) {
+	testCases := []struct {
+		action         FeedbackAction
+		expectedString string
+	}{
+		{FeedbackActionUnknown, ErrUnknownType.Error()},
+		{FeedbackActionAnswerLocally, "answer-locally"},
+		{FeedbackActionForwardUpstream, "forward-upstream"},
+		{FeedbackActionDrop, "drop"},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.action.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
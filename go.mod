@@ -8,6 +8,7 @@ require (
 	github.com/pion/ice/v4 v4.2.2
 	github.com/pion/interceptor v0.1.44
 	github.com/pion/logging v0.2.4
+	github.com/pion/mdns/v2 v2.1.0
 	github.com/pion/randutil v0.1.0
 	github.com/pion/rtcp v1.2.16
 	github.com/pion/rtp v1.10.1
@@ -27,7 +28,6 @@ require (
 	github.com/google/uuid v1.6.0 // indirect
 	github.com/onsi/ginkgo v1.16.5 // indirect
 	github.com/onsi/gomega v1.17.0 // indirect
-	github.com/pion/mdns/v2 v2.1.0 // indirect
 	github.com/pmezard/go-difflib v1.0.0 // indirect
 	github.com/wlynxg/anet v0.0.5 // indirect
 	golang.org/x/crypto v0.48.0 // indirect
@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+)
+
+// handshakeLimiter is a FIFO semaphore that bounds how many DTLS handshakes
+// run at the same time across all PeerConnections of an API.
+type handshakeLimiter struct {
+	mu      sync.Mutex
+	limit   int
+	active  int
+	waiters []chan struct{}
+}
+
+func newHandshakeLimiter(limit int) *handshakeLimiter {
+	if limit <= 0 {
+		return nil
+	}
+
+	return &handshakeLimiter{limit: limit}
+}
+
+// acquire blocks until a handshake slot is available or ctx is done.
+// Slots are handed out in the order acquire was called.
+func (l *handshakeLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+
+	l.mu.Lock()
+	if l.active < l.limit && len(l.waiters) == 0 {
+		l.active++
+		l.mu.Unlock()
+
+		return nil
+	}
+
+	ready := make(chan struct{})
+	l.waiters = append(l.waiters, ready)
+	l.mu.Unlock()
+
+	select {
+	case <-ready:
+		return nil
+	case <-ctx.Done():
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i, waiter := range l.waiters {
+		if waiter == ready {
+			l.waiters = append(l.waiters[:i], l.waiters[i+1:]...)
+
+			return errHandshakeQueueCanceled
+		}
+	}
+
+	// The slot was handed to us while we were canceled, pass it on.
+	l.releaseLocked()
+
+	return errHandshakeQueueCanceled
+}
+
+// release returns a slot obtained with acquire.
+func (l *handshakeLimiter) release() {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.releaseLocked()
+}
+
+func (l *handshakeLimiter) releaseLocked() {
+	if len(l.waiters) == 0 {
+		l.active--
+
+		return
+	}
+
+	// Hand the slot directly to the oldest waiter, active stays the same.
+	next := l.waiters[0]
+	l.waiters = l.waiters[1:]
+	close(next)
+}
+
+func (l *handshakeLimiter) queueDepth() int {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return len(l.waiters)
+}
+
+func (l *handshakeLimiter) activeCount() int {
+	if l == nil {
+		return 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.active
+}
@@ -0,0 +1,145 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHandshakeLimiterUnlimited(t *testing.T) {
+	var limiter *handshakeLimiter
+	assert.Nil(t, newHandshakeLimiter(0))
+
+	assert.NoError(t, limiter.acquire(context.Background()))
+	limiter.release()
+	assert.Equal(t, 0, limiter.queueDepth())
+	assert.Equal(t, 0, limiter.activeCount())
+}
+
+func TestHandshakeLimiterFIFO(t *testing.T) {
+	limiter := newHandshakeLimiter(1)
+	require.NoError(t, limiter.acquire(context.Background()))
+
+	const waiters = 5
+	order := make(chan int, waiters)
+	for i := range waiters {
+		go func() {
+			assert.NoError(t, limiter.acquire(context.Background()))
+			order <- i
+		}()
+
+		// Wait for the waiter to be queued, so the queue order is deterministic.
+		require.Eventually(t, func() bool { return limiter.queueDepth() == i+1 }, time.Second, time.Millisecond)
+	}
+
+	for i := range waiters {
+		limiter.release()
+		assert.Equal(t, i, <-order)
+		assert.Equal(t, 1, limiter.activeCount())
+	}
+
+	limiter.release()
+	assert.Equal(t, 0, limiter.activeCount())
+	assert.Equal(t, 0, limiter.queueDepth())
+}
+
+func TestHandshakeLimiterCancel(t *testing.T) {
+	limiter := newHandshakeLimiter(1)
+	require.NoError(t, limiter.acquire(context.Background()))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	errChan := make(chan error)
+	go func() {
+		errChan <- limiter.acquire(ctx)
+	}()
+
+	require.Eventually(t, func() bool { return limiter.queueDepth() == 1 }, time.Second, time.Millisecond)
+	cancel()
+	assert.ErrorIs(t, <-errChan, errHandshakeQueueCanceled)
+	assert.Equal(t, 0, limiter.queueDepth())
+
+	limiter.release()
+	assert.Equal(t, 0, limiter.activeCount())
+}
+
+func TestSetHandshakeConcurrencyLimit(t *testing.T) {
+	lim := test.TimeOut(time.Second * 60)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		limit = 2
+		pairs = 8
+	)
+
+	// Only the offering side is limited, it plays a server that many remote clients reconnect to.
+	settingEngine := SettingEngine{}
+	settingEngine.SetHandshakeConcurrencyLimit(limit)
+	api := NewAPI(WithSettingEngine(settingEngine))
+	remoteAPI := NewAPI()
+
+	peerConnections := make([]*PeerConnection, 0, pairs*2)
+	connected := &sync.WaitGroup{}
+	for range pairs {
+		pcOffer, err := api.NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		pcAnswer, err := remoteAPI.NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		peerConnections = append(peerConnections, pcOffer, pcAnswer)
+
+		connected.Add(2)
+		for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+			var once sync.Once
+			pc.OnConnectionStateChange(func(state PeerConnectionState) {
+				if state == PeerConnectionStateConnected {
+					once.Do(connected.Done)
+				}
+			})
+		}
+	}
+
+	done := make(chan struct{})
+	peakActive := 0
+	pollerDone := make(chan struct{})
+	go func() {
+		defer close(pollerDone)
+		for {
+			select {
+			case <-done:
+				return
+			case <-time.After(time.Millisecond):
+				peakActive = max(peakActive, api.ActiveHandshakes())
+			}
+		}
+	}()
+
+	for i := 0; i < len(peerConnections); i += 2 {
+		go func(pcOffer, pcAnswer *PeerConnection) {
+			assert.NoError(t, signalPair(pcOffer, pcAnswer))
+		}(peerConnections[i], peerConnections[i+1])
+	}
+
+	connected.Wait()
+	close(done)
+	<-pollerDone
+
+	assert.LessOrEqual(t, peakActive, limit)
+	assert.Equal(t, 0, api.HandshakeQueueDepth())
+	assert.Equal(t, 0, api.ActiveHandshakes())
+
+	for i := 0; i < len(peerConnections); i += 2 {
+		closePairNow(t, peerConnections[i], peerConnections[i+1])
+	}
+}
@@ -12,20 +12,34 @@ import (
 
 // ICECandidate represents a ice candidate.
 type ICECandidate struct {
-	statsID        string
-	Foundation     string           `json:"foundation"`
-	Priority       uint32           `json:"priority"`
-	Address        string           `json:"address"`
-	Protocol       ICEProtocol      `json:"protocol"`
-	Port           uint16           `json:"port"`
-	Typ            ICECandidateType `json:"type"`
-	Component      uint16           `json:"component"`
-	RelatedAddress string           `json:"relatedAddress"`
-	RelatedPort    uint16           `json:"relatedPort"`
-	TCPType        string           `json:"tcpType"`
-	SDPMid         string           `json:"sdpMid"`
-	SDPMLineIndex  uint16           `json:"sdpMLineIndex"`
-	extensions     string
+	statsID          string
+	usernameFragment string
+	Foundation       string           `json:"foundation"`
+	Priority         uint32           `json:"priority"`
+	Address          string           `json:"address"`
+	Protocol         ICEProtocol      `json:"protocol"`
+	Port             uint16           `json:"port"`
+	Typ              ICECandidateType `json:"type"`
+	Component        uint16           `json:"component"`
+	RelatedAddress   string           `json:"relatedAddress"`
+	RelatedPort      uint16           `json:"relatedPort"`
+	TCPType          string           `json:"tcpType"`
+	SDPMid           string           `json:"sdpMid"`
+	SDPMLineIndex    uint16           `json:"sdpMLineIndex"`
+
+	// RelayProtocol is the protocol used to reach the TURN server of a local relay
+	// candidate, one of udp, tcp, tls or dtls.
+	RelayProtocol string `json:"relayProtocol,omitempty"`
+
+	// NetworkInterface is the name of the interface a local candidate was gathered on,
+	// it is empty if that can't be determined, like for mDNS candidates.
+	NetworkInterface string `json:"networkInterface,omitempty"`
+
+	// NetworkType is the type of the interface a local candidate was gathered on, like
+	// wifi or cellular, see ICECandidateStats.NetworkType.
+	NetworkType string `json:"networkType,omitempty"`
+
+	extensions string
 }
 
 // Conversion for package ice.
@@ -73,6 +87,10 @@ func newICECandidateFromICE(candidate ice.Candidate, sdpMid string, sdpMLineInde
 
 	newCandidate.setExtensions(candidate.Extensions())
 
+	if relay, ok := candidate.(*ice.CandidateRelay); ok {
+		newCandidate.RelayProtocol = relay.RelayProtocol()
+	}
+
 	if candidate.RelatedAddress() != nil {
 		newCandidate.RelatedAddress = candidate.RelatedAddress().Address
 		newCandidate.RelatedPort = uint16(candidate.RelatedAddress().Port) //nolint:gosec // G115
@@ -128,15 +146,16 @@ func (c ICECandidate) ToICE() (cand ice.Candidate, err error) {
 		cand, err = ice.NewCandidatePeerReflexive(&config)
 	case ICECandidateTypeRelay:
 		config := ice.CandidateRelayConfig{
-			CandidateID: candidateID,
-			Network:     c.Protocol.String(),
-			Address:     c.Address,
-			Port:        int(c.Port),
-			Component:   c.Component,
-			Foundation:  c.Foundation,
-			Priority:    c.Priority,
-			RelAddr:     c.RelatedAddress,
-			RelPort:     int(c.RelatedPort),
+			CandidateID:   candidateID,
+			Network:       c.Protocol.String(),
+			Address:       c.Address,
+			Port:          int(c.Port),
+			Component:     c.Component,
+			Foundation:    c.Foundation,
+			Priority:      c.Priority,
+			RelAddr:       c.RelatedAddress,
+			RelPort:       int(c.RelatedPort),
+			RelayProtocol: c.RelayProtocol,
 		}
 
 		cand, err = ice.NewCandidateRelay(&config)
@@ -236,9 +255,15 @@ func (c ICECandidate) ToJSON() ICECandidateInit {
 		candidateStr = candidate.Marshal()
 	}
 
+	var usernameFragment *string
+	if c.usernameFragment != "" {
+		usernameFragment = &c.usernameFragment
+	}
+
 	return ICECandidateInit{
-		Candidate:     fmt.Sprintf("candidate:%s", candidateStr),
-		SDPMid:        &c.SDPMid,
-		SDPMLineIndex: &c.SDPMLineIndex,
+		Candidate:        fmt.Sprintf("candidate:%s", candidateStr),
+		SDPMid:           &c.SDPMid,
+		SDPMLineIndex:    &c.SDPMLineIndex,
+		UsernameFragment: usernameFragment,
 	}
 }
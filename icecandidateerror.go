@@ -0,0 +1,17 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// ICECandidateError describes a remote ICE candidate that couldn't be used, like a
+// mDNS candidate whose host name couldn't be resolved, or an ICE server no local
+// candidates could be gathered from.
+type ICECandidateError struct {
+	// Candidate is the remote candidate, with the address it was signaled with.
+	// It is empty for the errors of an ICE server.
+	Candidate ICECandidate
+	// URL is the URL of the ICE server, it is empty for the errors of a remote candidate.
+	URL string
+	// Err is why the candidate couldn't be used or gathered.
+	Err error
+}
@@ -0,0 +1,179 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"errors"
+	"net"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/transport/v4/stdnet"
+)
+
+// defaultNetworkMonitorInterval is how often the interfaces are polled when gathering
+// continually, it matches the default of the ICE agent.
+const defaultNetworkMonitorInterval = 2 * time.Second
+
+// watchRemovedAddresses starts to prune the local candidates of agent whose addresses
+// disappear from the interfaces, if candidates are gathered continually. The ICE agent
+// only gathers the candidates of new addresses, it keeps the ones of removed addresses.
+func (g *ICEGatherer) watchRemovedAddresses(agent *ice.Agent) {
+	if g.api.settingEngine.iceGatheringPolicy != ICEGatheringPolicyGatherContinually {
+		return
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	// Gather runs again for an ICE restart, the agent is kept
+	if g.agent != agent || g.removedAddressesDone != nil {
+		return
+	}
+	done := make(chan struct{})
+	g.removedAddressesDone = done
+
+	interval := defaultNetworkMonitorInterval
+	if g.api.settingEngine.iceNetworkMonitorInterval != nil {
+		interval = *g.api.settingEngine.iceNetworkMonitorInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				// Closing a PeerConnection with a mux closes the agent, not the ICEGatherer
+				if err := g.pruneRemovedAddresses(agent); errors.Is(err, ice.ErrClosed) {
+					return
+				}
+			}
+		}
+	}()
+}
+
+// stopWatchingRemovedAddresses stops watchRemovedAddresses, g.lock must be held.
+func (g *ICEGatherer) stopWatchingRemovedAddresses() {
+	if g.removedAddressesDone != nil {
+		close(g.removedAddressesDone)
+		g.removedAddressesDone = nil
+	}
+}
+
+// pruneRemovedAddresses prunes the local candidates of the addresses that are no longer on
+// an interface, and fires OnLocalCandidateRemoved for them. Candidates of an address that
+// comes back aren't pruned anymore.
+func (g *ICEGatherer) pruneRemovedAddresses(agent *ice.Agent) error {
+	addresses, ok := g.interfaceAddresses()
+	if !ok {
+		return nil
+	}
+	candidates, err := agent.GetLocalCandidates()
+	if err != nil {
+		return err
+	}
+
+	var removed []ice.Candidate
+	for _, candidate := range candidates {
+		ip := candidateBaseIP(candidate)
+		if ip == nil || ip.IsUnspecified() {
+			continue
+		}
+
+		if _, ok := addresses[ip.String()]; ok {
+			g.removedAddresses.Delete(ip.String())
+			g.prunedCandidates.Delete(candidate.ID())
+
+			continue
+		}
+		g.removedAddresses.Store(ip.String(), struct{}{})
+		if _, pruned := g.prunedCandidates.LoadOrStore(candidate.ID(), struct{}{}); !pruned {
+			removed = append(removed, candidate)
+		}
+	}
+
+	handler, ok := g.onLocalCandidateRemovedHandler.Load().(func(candidate *ICECandidate))
+	if !ok || handler == nil {
+		return nil
+	}
+
+	sdpMid := ""
+	if mid, ok := g.sdpMid.Load().(string); ok {
+		sdpMid = mid
+	}
+	sdpMLineIndex := uint16(g.sdpMLineIndex.Load()) //nolint:gosec // G115
+
+	for _, candidate := range removed {
+		if !g.allowsLocalCandidateType(candidate) {
+			continue
+		}
+
+		c, err := g.newLocalICECandidate(candidate, sdpMid, sdpMLineIndex)
+		if err != nil {
+			g.log.Warnf("Failed to convert ice.Candidate: %s", err)
+
+			continue
+		}
+		handler(&c)
+	}
+
+	return nil
+}
+
+// isPruned reports if candidate was gathered on an address that was removed since.
+func (g *ICEGatherer) isPruned(candidate ice.Candidate) bool {
+	ip := candidateBaseIP(candidate)
+	if ip == nil {
+		return false
+	}
+	_, removed := g.removedAddresses.Load(ip.String())
+
+	return removed
+}
+
+// interfaceAddresses returns the addresses of the interfaces of the configured Net.
+func (g *ICEGatherer) interfaceAddresses() (map[string]struct{}, bool) {
+	interfacesNet := g.api.settingEngine.net
+	if interfacesNet == nil {
+		var err error
+		if interfacesNet, err = stdnet.NewNet(); err != nil {
+			return nil, false
+		}
+	}
+
+	interfaces, err := interfacesNet.Interfaces()
+	if err != nil {
+		g.log.Warnf("Failed to get the interfaces to prune candidates: %v", err)
+
+		return nil, false
+	}
+
+	addresses := map[string]struct{}{}
+	for _, iface := range interfaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				addresses[ipNet.IP.String()] = struct{}{}
+			}
+		}
+	}
+
+	return addresses, true
+}
+
+// OnLocalCandidateRemoved sets an event handler which fires when a local ICE candidate is
+// pruned because the address it was gathered on disappeared from the interfaces. It only
+// fires when candidates are gathered continually, see SettingEngine.EnableContinualGathering.
+func (g *ICEGatherer) OnLocalCandidateRemoved(f func(*ICECandidate)) {
+	g.onLocalCandidateRemovedHandler.Store(f)
+}
@@ -7,6 +7,9 @@ package webrtc
 
 import (
 	"fmt"
+	"net"
+	"net/netip"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -15,6 +18,9 @@ import (
 	"github.com/pion/ice/v4"
 	"github.com/pion/logging"
 	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v4"
+	"github.com/pion/transport/v4/stdnet"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
 )
 
 // ICEGatherer gathers local host, server reflexive and relay
@@ -28,11 +34,20 @@ type ICEGatherer struct {
 
 	validatedServers []*stun.URI
 	gatherPolicy     ICETransportPolicy
+	// Set if GatherWith added servers while the agent was gathering
+	pendingServers bool
+	// The URLs of validatedServers no candidates can be gathered from, see OAuthCredential
+	unsupportedURLs []string
+
+	// The types of the local candidates, all of them if empty
+	candidateTypes atomic.Value // []ICECandidateType
 
 	agent *ice.Agent
 
-	onLocalCandidateHandler atomic.Value // func(candidate *ICECandidate)
-	onStateChangeHandler    atomic.Value // func(state ICEGathererState)
+	onLocalCandidateHandler        atomic.Value // func(candidate *ICECandidate)
+	onLocalCandidateRemovedHandler atomic.Value // func(candidate *ICECandidate)
+	onStateChangeHandler           atomic.Value // func(state ICEGathererState)
+	onCandidateErrorHandler        atomic.Value // func(ICECandidateError)
 
 	// Used for GatheringCompletePromise
 	onGatheringCompleteHandler atomic.Value // func()
@@ -44,10 +59,54 @@ type ICEGatherer struct {
 	sdpMid        atomic.Value  // string
 	sdpMLineIndex atomic.Uint32 // uint16
 
+	// The local username fragment of the agent, it is added to local candidates
+	// so the remote can tell which ICE generation they belong to.
+	usernameFragment atomic.Value // string
+
+	// The names of the interfaces local candidates were gathered on, by candidate ID
+	localCandidateInterfaces sync.Map // string
+
+	// The names of the local interfaces by address, enumerated once per gather
+	interfaceNamesMu sync.Mutex
+	interfaceNames   map[string]string
+
 	// Used for ICE candidate pooling
 	candidatePoolLock    sync.Mutex
 	candidatePool        []ice.Candidate
 	iceCandidatePoolSize uint8
+
+	// The ICE role of the remote agent, as seen in the last accepted binding request
+	remoteICERole atomic.Int32 // ICERole
+
+	// Set if only remote relay candidates are accepted, it follows gatherPolicy when the
+	// agent is created or restarted. Peer reflexive candidates are then only accepted from
+	// the addresses of the remote relay candidates.
+	remoteRelayOnly      atomic.Bool
+	remoteRelayAddresses sync.Map // netip.Addr
+
+	// The candidates of the selected pair, and its round trip time in seconds as seen
+	// when the remote last checked it
+	selectedCandidates atomic.Value // selectedICECandidates
+	roundTripTime      qualityValue
+
+	// The setup milestones of the PeerConnection, nil with ORTC
+	timeline *connectionTimeline
+
+	// Marks the sockets of the agent, nil unless SettingEngine.SetDSCP was used
+	dscp *dscpMarker
+
+	// The progress of gathering, see GatheringProgress
+	progress iceGatheringProgress
+
+	// The addresses that disappeared from the interfaces while gathering continually, their
+	// local candidates are pruned. prunedCandidates holds the IDs of the pruned candidates.
+	removedAddresses     sync.Map // struct{}
+	prunedCandidates     sync.Map // struct{}
+	removedAddressesDone chan struct{}
+}
+
+type selectedICECandidates struct {
+	local, remote ice.Candidate
 }
 
 // ICEAddressRewriteMode controls whether a rule replaces or appends candidates.
@@ -107,7 +166,7 @@ func (api *API) NewICEGatherer(opts ICEGatherOptions) (*ICEGatherer, error) {
 		}
 	}
 
-	return &ICEGatherer{
+	gatherer := &ICEGatherer{
 		state:                ICEGathererStateNew,
 		gatherPolicy:         opts.ICEGatherPolicy,
 		validatedServers:     validatedServers,
@@ -117,7 +176,46 @@ func (api *API) NewICEGatherer(opts ICEGatherOptions) (*ICEGatherer, error) {
 		sdpMLineIndex:        atomic.Uint32{},
 		candidatePool:        make([]ice.Candidate, 0, opts.ICECandidatePoolSize),
 		iceCandidatePoolSize: opts.ICECandidatePoolSize,
-	}, nil
+		unsupportedURLs:      unsupportedURLs(opts.ICEServers),
+	}
+
+	if err := gatherer.checkCandidateTypes(opts.ICECandidateTypes, opts.ICEGatherPolicy); err != nil {
+		return nil, err
+	}
+	gatherer.setCandidateTypes(opts.ICECandidateTypes)
+
+	if dscp := api.settingEngine.dscp; dscp.enabled {
+		gatherer.dscp = newDSCPMarker(max(dscp.audio, dscp.video, dscp.data), gatherer.log)
+	}
+
+	return gatherer, nil
+}
+
+// unsupportedURLs returns the URLs of the ICE servers that pass validation, but can't be
+// used for gathering.
+func unsupportedURLs(servers []ICEServer) []string {
+	var urls []string
+	for _, server := range servers {
+		urls = append(urls, server.oauthTURNURLs()...)
+	}
+
+	return urls
+}
+
+// reportUnsupportedServers reports the URLs no candidates can be gathered from to the
+// OnCandidateError handler.
+func (g *ICEGatherer) reportUnsupportedServers() {
+	g.lock.RLock()
+	urls := g.unsupportedURLs
+	g.lock.RUnlock()
+
+	handler, ok := g.onCandidateErrorHandler.Load().(func(ICECandidateError))
+	if !ok || handler == nil {
+		return
+	}
+	for _, url := range urls {
+		handler(ICECandidateError{URL: url, Err: ErrOAuthTURNUnsupported})
+	}
 }
 
 // updateServers updates the ICE servers and gather policy.
@@ -138,6 +236,7 @@ func (g *ICEGatherer) updateServers(servers []ICEServer, policy ICETransportPoli
 
 	g.validatedServers = validatedServers
 	g.gatherPolicy = policy
+	g.unsupportedURLs = unsupportedURLs(servers)
 
 	if g.agent != nil && (g.State() != ICEGathererStateGathering ||
 		g.iceCandidatePoolSize == 0) {
@@ -147,6 +246,67 @@ func (g *ICEGatherer) updateServers(servers []ICEServer, policy ICETransportPoli
 	return nil
 }
 
+// GatherWith adds servers to the ICE servers used for gathering. If gathering hasn't
+// started yet, candidates of the new servers are gathered with the others.
+//
+// The ICE Agent gathers once per ICE session, once it started the candidates of the new
+// servers are gathered by the next ICE restart. PeerConnection.AddICEServers requests
+// one for them.
+func (g *ICEGatherer) GatherWith(servers []ICEServer) error {
+	var urls []*stun.URI
+	for _, server := range servers {
+		serverURLs, err := server.urls()
+		if err != nil {
+			return err
+		}
+		urls = append(urls, serverURLs...)
+	}
+
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	validatedServers := append(append([]*stun.URI{}, g.validatedServers...), urls...)
+	switch {
+	case g.agent == nil:
+	case g.State() == ICEGathererStateGathering:
+		// The running gathering reads the servers of the agent, they are set once it completed
+		g.pendingServers = true
+	default:
+		if err := g.agent.UpdateOptions(ice.WithUrls(validatedServers)); err != nil {
+			return err
+		}
+	}
+	g.validatedServers = validatedServers
+	g.unsupportedURLs = append(g.unsupportedURLs, unsupportedURLs(servers)...)
+
+	return nil
+}
+
+// applyPendingServers sets the servers added by GatherWith while gathering on the agent.
+func (g *ICEGatherer) applyPendingServers(agent *ice.Agent) {
+	g.lock.Lock()
+	defer g.lock.Unlock()
+
+	if !g.pendingServers {
+		return
+	}
+	if err := agent.UpdateOptions(ice.WithUrls(g.validatedServers)); err != nil {
+		g.log.Warnf("Failed to set the ICE servers added while gathering: %v", err)
+
+		return
+	}
+	g.pendingServers = false
+}
+
+// hasPendingServers reports if servers were added by GatherWith that the agent doesn't
+// gather from yet, because it was gathering.
+func (g *ICEGatherer) hasPendingServers() bool {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	return g.pendingServers
+}
+
 // validatedServersCount returns the number of validated ICE server URLs.
 func (g *ICEGatherer) validatedServersCount() int {
 	g.lock.RLock()
@@ -174,6 +334,19 @@ func (g *ICEGatherer) createAgent() error {
 	}
 
 	g.agent = agent
+	g.applyRemotePolicy(g.gatherPolicy)
+
+	return g.updateUsernameFragment(agent)
+}
+
+// updateUsernameFragment caches the local username fragment of agent, it has to
+// be called whenever the agent changes its credentials.
+func (g *ICEGatherer) updateUsernameFragment(agent *ice.Agent) error {
+	usernameFragment, _, err := agent.GetLocalUserCredentials()
+	if err != nil {
+		return err
+	}
+	g.usernameFragment.Store(usernameFragment)
 
 	return nil
 }
@@ -183,7 +356,12 @@ func (g *ICEGatherer) buildAgentOptions() ([]ice.AgentOption, error) {
 	nat1To1CandiTyp := g.resolveNAT1To1CandidateType()
 	mDNSMode := g.sanitizedMDNSMode()
 
-	options := g.baseAgentOptions(mDNSMode)
+	agentNet, err := g.agentNet()
+	if err != nil {
+		return nil, err
+	}
+
+	options := g.baseAgentOptions(mDNSMode, agentNet)
 	if len(candidateTypes) > 0 {
 		options = append(options, ice.WithCandidateTypes(candidateTypes))
 	}
@@ -195,34 +373,126 @@ func (g *ICEGatherer) buildAgentOptions() ([]ice.AgentOption, error) {
 		return nil, err
 	}
 	options = append(options, rewriteOptions...)
+
+	options = append(options, g.stunBindingRequestOptions(agentNet)...)
+	options = append(options, g.connectivityCheckOptions()...)
 	options = append(options, g.timeoutOptions()...)
 	options = append(options, g.miscOptions()...)
 	options = append(options, g.renominationOptions()...)
+	options = append(options, g.gatheringPolicyOptions()...)
 
 	requestedNetworkTypes := g.api.settingEngine.candidates.ICENetworkTypes
 	if len(requestedNetworkTypes) == 0 {
 		requestedNetworkTypes = supportedNetworkTypes()
 	}
+	if g.api.settingEngine.candidates.disableDynamicGathering {
+		// The static local candidates are UDP, this skips the TCP candidates
+		requestedNetworkTypes = slices.DeleteFunc(slices.Clone(requestedNetworkTypes), func(t NetworkType) bool {
+			return t.Protocol() != "udp"
+		})
+	}
 
 	return append(options, ice.WithNetworkTypes(toICENetworkTypes(requestedNetworkTypes))), nil
 }
 
 func (g *ICEGatherer) resolveCandidateTypes() []ice.CandidateType {
-	if g.api.settingEngine.candidates.ICELite {
-		return []ice.CandidateType{ice.CandidateTypeHost}
-	}
+	return g.gatheredCandidateTypes(g.gatherPolicy, g.getCandidateTypes())
+}
 
-	switch g.gatherPolicy {
-	case ICETransportPolicyRelay:
-		return []ice.CandidateType{ice.CandidateTypeRelay}
-	case ICETransportPolicyNoHost:
-		return []ice.CandidateType{ice.CandidateTypeServerReflexive, ice.CandidateTypeRelay}
+// gatheredCandidateTypes returns the candidate types the agent gathers with policy and the
+// allowed types, nil if it gathers all of them.
+func (g *ICEGatherer) gatheredCandidateTypes(
+	policy ICETransportPolicy,
+	allowed []ICECandidateType,
+) []ice.CandidateType {
+	var candidateTypes []ice.CandidateType
+	switch {
+	// The static local candidates replace the host candidates of the UDPMux
+	case g.api.settingEngine.candidates.ICELite || g.api.settingEngine.candidates.disableDynamicGathering:
+		candidateTypes = []ice.CandidateType{ice.CandidateTypeHost}
+	case policy == ICETransportPolicyRelay:
+		candidateTypes = []ice.CandidateType{ice.CandidateTypeRelay}
+	case policy == ICETransportPolicyNoHost:
+		candidateTypes = []ice.CandidateType{ice.CandidateTypeServerReflexive, ice.CandidateTypeRelay}
+	case len(allowed) == 0:
+		return nil
 	default:
+		candidateTypes = []ice.CandidateType{
+			ice.CandidateTypeHost, ice.CandidateTypeServerReflexive, ice.CandidateTypeRelay,
+		}
+	}
+
+	if len(allowed) == 0 {
+		return candidateTypes
+	}
+
+	return slices.DeleteFunc(candidateTypes, func(typ ice.CandidateType) bool {
+		return !slices.ContainsFunc(allowed, func(t ICECandidateType) bool { return t.toICE() == typ })
+	})
+}
+
+// validateICECandidateTypes checks that the types can be gathered, and that policy allows
+// at least one of them. No types mean all of them.
+func validateICECandidateTypes(types []ICECandidateType, policy ICETransportPolicy) error {
+	for _, typ := range types {
+		switch typ {
+		case ICECandidateTypeHost, ICECandidateTypeSrflx, ICECandidateTypeRelay:
+		default:
+			return &rtcerr.TypeError{Err: fmt.Errorf("%w: %s", errICECandidateTypeNotGathered, typ)}
+		}
+	}
+
+	if len(types) == 0 || policy == ICETransportPolicyAll ||
+		slices.Contains(types, ICECandidateTypeRelay) ||
+		(policy == ICETransportPolicyNoHost && slices.Contains(types, ICECandidateTypeSrflx)) {
+		return nil
+	}
+
+	return &rtcerr.InvalidAccessError{Err: ErrICECandidateTypesConflict}
+}
+
+// checkCandidateTypes validates the types against policy, and against the candidates the
+// SettingEngine restricts gathering to.
+func (g *ICEGatherer) checkCandidateTypes(types []ICECandidateType, policy ICETransportPolicy) error {
+	if err := validateICECandidateTypes(types, policy); err != nil {
+		return err
+	}
+
+	if candidateTypes := g.gatheredCandidateTypes(policy, types); candidateTypes != nil && len(candidateTypes) == 0 {
+		return &rtcerr.InvalidAccessError{Err: ErrICECandidateTypesConflict}
 	}
 
 	return nil
 }
 
+// setCandidateTypes restricts the types of the local candidates, all of them if types is empty.
+// The types are given to the ICE Agent when it is created. As it can't change them later on, the
+// local candidates of the types removed afterwards are dropped, on ICE restarts as well.
+func (g *ICEGatherer) setCandidateTypes(types []ICECandidateType) {
+	g.candidateTypes.Store(slices.Clone(types))
+}
+
+func (g *ICEGatherer) getCandidateTypes() []ICECandidateType {
+	types, _ := g.candidateTypes.Load().([]ICECandidateType)
+
+	return types
+}
+
+// allowsLocalCandidate returns false if the candidate is of a type removed by setCandidateTypes,
+// or if it was pruned because its address was removed.
+func (g *ICEGatherer) allowsLocalCandidate(candidate ice.Candidate) bool {
+	return g.allowsLocalCandidateType(candidate) && !g.isPruned(candidate)
+}
+
+// allowsLocalCandidateType returns false if the candidate is of a type removed by setCandidateTypes.
+func (g *ICEGatherer) allowsLocalCandidateType(candidate ice.Candidate) bool {
+	types := g.getCandidateTypes()
+
+	return len(types) == 0 || slices.ContainsFunc(types, func(typ ICECandidateType) bool {
+		return typ.toICE() == candidate.Type()
+	})
+}
+
 func (g *ICEGatherer) resolveNAT1To1CandidateType() ice.CandidateType {
 	switch g.api.settingEngine.candidates.NAT1To1IPCandidateType {
 	case ICECandidateTypeHost:
@@ -236,6 +506,10 @@ func (g *ICEGatherer) resolveNAT1To1CandidateType() ice.CandidateType {
 
 func (g *ICEGatherer) sanitizedMDNSMode() ice.MulticastDNSMode {
 	mode := g.api.settingEngine.candidates.MulticastDNSMode
+	if mode == ice.MulticastDNSModeQueryAndGather && len(g.api.settingEngine.candidates.staticLocalCandidates) != 0 {
+		// mDNS names would hide the addresses of the static local candidates
+		return ice.MulticastDNSModeQueryOnly
+	}
 	if mode == ice.MulticastDNSModeDisabled || mode == ice.MulticastDNSModeQueryAndGather {
 		return mode
 	}
@@ -243,21 +517,153 @@ func (g *ICEGatherer) sanitizedMDNSMode() ice.MulticastDNSMode {
 	return ice.MulticastDNSModeQueryOnly
 }
 
-func (g *ICEGatherer) baseAgentOptions(mDNSMode ice.MulticastDNSMode) []ice.AgentOption {
+// agentNet returns the Net of the agent, it reports the gathering progress.
+func (g *ICEGatherer) agentNet() (transport.Net, error) {
+	agentNet := g.api.settingEngine.net
+	if agentNet == nil {
+		var err error
+		if agentNet, err = stdnet.NewNet(); err != nil {
+			return nil, err
+		}
+	}
+
+	if g.dscp != nil {
+		agentNet = &dscpNet{Net: agentNet, marker: g.dscp}
+	}
+
+	return &gatheringNet{Net: agentNet, progress: &g.progress}, nil
+}
+
+// setDSCP sets the DSCP of the sockets of the agent, if SettingEngine.SetDSCP was used.
+func (g *ICEGatherer) setDSCP(dscp uint8) {
+	if g.dscp != nil {
+		g.dscp.set(dscp)
+	}
+}
+
+func (g *ICEGatherer) baseAgentOptions(mDNSMode ice.MulticastDNSMode, agentNet transport.Net) []ice.AgentOption {
+	servers := g.validatedServers
+	if g.api.settingEngine.candidates.disableDynamicGathering {
+		servers = nil
+	}
+	// The ICE Agent refuses servers it has no use for, when only host candidates are gathered
+	if candidateTypes := g.resolveCandidateTypes(); candidateTypes != nil &&
+		!slices.Contains(candidateTypes, ice.CandidateTypeServerReflexive) &&
+		!slices.Contains(candidateTypes, ice.CandidateTypeRelay) {
+		servers = nil
+	}
+
 	return []ice.AgentOption{
 		ice.WithICELite(g.api.settingEngine.candidates.ICELite),
-		ice.WithUrls(g.validatedServers),
+		ice.WithUrls(servers),
 		ice.WithPortRange(g.api.settingEngine.ephemeralUDP.PortMin, g.api.settingEngine.ephemeralUDP.PortMax),
 		ice.WithLoggerFactory(g.api.settingEngine.LoggerFactory),
 		ice.WithInterfaceFilter(g.api.settingEngine.candidates.InterfaceFilter),
 		ice.WithIPFilter(g.api.settingEngine.candidates.IPFilter),
-		ice.WithRemoteIPFilter(g.api.settingEngine.candidates.RemoteIPFilter),
-		ice.WithNet(g.api.settingEngine.net),
+		ice.WithRemoteIPFilter(g.remoteIPFilter),
+		ice.WithNet(agentNet),
 		ice.WithMulticastDNSMode(mDNSMode),
 		ice.WithTCPMux(g.api.settingEngine.iceTCPMux),
 		ice.WithUDPMux(g.api.settingEngine.iceUDPMux),
 		ice.WithProxyDialer(g.api.settingEngine.iceProxyDialer),
-		ice.WithBindingRequestHandler(g.api.settingEngine.iceBindingRequestHandler),
+		ice.WithBindingRequestHandler(g.bindingRequestHandler),
+	}
+}
+
+// applyRemotePolicy restricts the remote candidates according to policy, it is called
+// whenever the agent starts over without remote candidates.
+func (g *ICEGatherer) applyRemotePolicy(policy ICETransportPolicy) {
+	g.remoteRelayAddresses.Clear()
+	g.remoteRelayOnly.Store(policy == ICETransportPolicyRelay)
+}
+
+// restartRemotePolicy applies the current gather policy to the remote candidates, once
+// the agent was restarted.
+func (g *ICEGatherer) restartRemotePolicy() {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	g.applyRemotePolicy(g.gatherPolicy)
+}
+
+// acceptRemoteCandidate returns false if the remote candidate c must be ignored because of
+// the policy, the addresses of accepted relay candidates are kept for remoteIPFilter.
+func (g *ICEGatherer) acceptRemoteCandidate(c *ICECandidate) bool {
+	if c == nil || !g.remoteRelayOnly.Load() {
+		return true
+	}
+	if c.Typ != ICECandidateTypeRelay {
+		return false
+	}
+
+	if addr, err := netip.ParseAddr(c.Address); err == nil {
+		g.remoteRelayAddresses.Store(addr.Unmap(), struct{}{})
+	}
+
+	return true
+}
+
+// remoteIPFilter is the filter of the remote candidate addresses of the agent, peer reflexive
+// candidates included. It applies the policy before the filter of the SettingEngine.
+func (g *ICEGatherer) remoteIPFilter(ip net.IP) bool {
+	if g.remoteRelayOnly.Load() {
+		addr, ok := netip.AddrFromSlice(ip)
+		if !ok {
+			return false
+		}
+		if _, ok = g.remoteRelayAddresses.Load(addr.Unmap()); !ok {
+			return false
+		}
+	}
+
+	if filter := g.api.settingEngine.candidates.RemoteIPFilter; filter != nil {
+		return filter(ip)
+	}
+
+	return true
+}
+
+// bindingRequestHandler records the ICE role of the remote agent before calling the handler
+// of the SettingEngine. The agent only accepts binding requests without a role conflict,
+// so the remote role is always the opposite of the one of the agent. A panic of the handler
+// is logged and doesn't switch the selected pair.
+func (g *ICEGatherer) bindingRequestHandler(
+	m *stun.Message,
+	local, remote ice.Candidate,
+	pair *ice.CandidatePair,
+) (switchPair bool) {
+	switch {
+	case m.Contains(stun.AttrICEControlling):
+		g.remoteICERole.Store(int32(ICERoleControlling))
+	case m.Contains(stun.AttrICEControlled):
+		g.remoteICERole.Store(int32(ICERoleControlled))
+	}
+	g.updateRoundTripTime(pair)
+
+	if handler := g.api.settingEngine.iceBindingRequestHandler; handler != nil {
+		defer func() {
+			if r := recover(); r != nil {
+				g.log.Errorf("ICEBindingRequestHandler panicked on Binding Request from %s: %v", remote, r)
+				switchPair = false
+			}
+		}()
+
+		return handler(m, local, remote, pair)
+	}
+
+	return false
+}
+
+// updateRoundTripTime records the round trip time of the selected pair, the agent updates
+// it from the responses to its own checks, which the remote answers as often as it checks.
+func (g *ICEGatherer) updateRoundTripTime(pair *ice.CandidatePair) {
+	selected, ok := g.selectedCandidates.Load().(selectedICECandidates)
+	if !ok || pair == nil || pair.Local != selected.local || pair.Remote != selected.remote {
+		return
+	}
+
+	if roundTripTime := pair.CurrentRoundTripTime(); roundTripTime > 0 {
+		g.roundTripTime.set(roundTripTime, time.Now())
 	}
 }
 
@@ -280,6 +686,18 @@ func (g *ICEGatherer) addressRewriteOptions(candidateType ice.CandidateType) ([]
 		return nil, errAddressRewriteWithNAT1To1
 	}
 
+	if candidates := g.api.settingEngine.candidates.staticLocalCandidates; len(candidates) > 0 {
+		if len(rules) > 0 || len(nat1To1IPs) > 0 {
+			return nil, errAddressRewriteWithStaticCandidates
+		}
+
+		return []ice.AgentOption{
+			ice.WithAddressRewriteRules(
+				staticLocalCandidateRewriteRules(candidates)...,
+			),
+		}, nil
+	}
+
 	if len(rules) > 0 {
 		return []ice.AgentOption{ice.WithAddressRewriteRules(rules...)}, nil
 	}
@@ -344,10 +762,46 @@ func (g *ICEGatherer) miscOptions() []ice.AgentOption {
 		opts = append(opts, ice.WithDisableActiveTCP())
 	}
 
+	return opts
+}
+
+// connectivityCheckOptions must be applied before timeoutOptions, so explicitly
+// configured acceptance waits override the ones implied by aggressive nomination.
+func (g *ICEGatherer) connectivityCheckOptions() []ice.AgentOption {
+	opts := make([]ice.AgentOption, 0, 6)
+
 	if g.api.settingEngine.iceMaxBindingRequests != nil {
 		opts = append(opts, ice.WithMaxBindingRequests(*g.api.settingEngine.iceMaxBindingRequests))
 	}
 
+	if g.api.settingEngine.iceCheckInterval != nil {
+		opts = append(opts, ice.WithCheckInterval(*g.api.settingEngine.iceCheckInterval))
+	}
+
+	if g.api.settingEngine.iceNominationMode == ICENominationModeAggressive {
+		opts = append(opts,
+			ice.WithHostAcceptanceMinWait(0),
+			ice.WithSrflxAcceptanceMinWait(0),
+			ice.WithPrflxAcceptanceMinWait(0),
+			ice.WithRelayAcceptanceMinWait(0),
+		)
+	}
+
+	return opts
+}
+
+func (g *ICEGatherer) gatheringPolicyOptions() []ice.AgentOption {
+	if g.api.settingEngine.iceGatheringPolicy != ICEGatheringPolicyGatherContinually {
+		return nil
+	}
+
+	opts := []ice.AgentOption{
+		ice.WithContinualGatheringPolicy(g.api.settingEngine.iceGatheringPolicy.toICE()),
+	}
+	if g.api.settingEngine.iceNetworkMonitorInterval != nil {
+		opts = append(opts, ice.WithNetworkMonitorInterval(*g.api.settingEngine.iceNetworkMonitorInterval))
+	}
+
 	return opts
 }
 
@@ -379,6 +833,34 @@ func (g *ICEGatherer) renominationOptions() []ice.AgentOption {
 	return opts
 }
 
+// staticLocalCandidateRewriteRules replaces the host candidates of the UDPMux with the
+// addresses of the static local candidates of the same IP family.
+func staticLocalCandidateRewriteRules(candidates []ICECandidate) []ice.AddressRewriteRule {
+	var ipv4, ipv6 []string
+	for _, candidate := range candidates {
+		// The addresses were validated by SetStaticLocalCandidates
+		addr := netip.MustParseAddr(candidate.Address).Unmap()
+		if addr.Is4() && !slices.Contains(ipv4, addr.String()) {
+			ipv4 = append(ipv4, addr.String())
+		} else if addr.Is6() && !slices.Contains(ipv6, addr.String()) {
+			ipv6 = append(ipv6, addr.String())
+		}
+	}
+
+	rules := make([]ice.AddressRewriteRule, 0, 2)
+	for _, external := range [][]string{ipv4, ipv6} {
+		if len(external) != 0 {
+			rules = append(rules, ice.AddressRewriteRule{
+				External:        external,
+				AsCandidateType: ice.CandidateTypeHost,
+				Mode:            ice.AddressRewriteReplace,
+			})
+		}
+	}
+
+	return rules
+}
+
 func legacyNAT1To1AddressRewriteRules(ips []string, candidateType ice.CandidateType) []ice.AddressRewriteRule {
 	catchAll := make([]string, 0, len(ips))
 	rules := make([]ice.AddressRewriteRule, 0, len(ips)+1)
@@ -441,6 +923,11 @@ func (g *ICEGatherer) Gather() error { //nolint:cyclop
 		sdpMLineIndex := uint16(g.sdpMLineIndex.Load()) //nolint:gosec // G115
 
 		if candidate != nil {
+			if !g.allowsLocalCandidate(candidate) {
+				return
+			}
+			g.progress.candidate(candidate)
+
 			g.candidatePoolLock.Lock()
 			if g.iceCandidatePoolSize > 0 && g.candidatePool != nil {
 				g.candidatePool = append(g.candidatePool, candidate)
@@ -450,7 +937,7 @@ func (g *ICEGatherer) Gather() error { //nolint:cyclop
 			}
 			g.candidatePoolLock.Unlock()
 
-			c, err := newICECandidateFromICE(candidate, sdpMid, sdpMLineIndex)
+			c, err := g.newLocalICECandidate(candidate, sdpMid, sdpMLineIndex)
 			if err != nil {
 				g.log.Warnf("Failed to convert ice.Candidate: %s", err)
 
@@ -458,6 +945,8 @@ func (g *ICEGatherer) Gather() error { //nolint:cyclop
 			}
 			onLocalCandidateHandler(&c)
 		} else {
+			g.applyPendingServers(agent)
+			g.progress.complete()
 			g.setState(ICEGathererStateComplete)
 			onGatheringCompleteHandler()
 
@@ -477,9 +966,36 @@ func (g *ICEGatherer) Gather() error { //nolint:cyclop
 		return err
 	}
 
+	g.progress.start(g.gatheringServers())
+	g.reportUnsupportedServers()
+	g.resetInterfaceNames()
+	g.watchRemovedAddresses(agent)
+
 	return agent.GatherCandidates()
 }
 
+// gatheringServers returns the ICE server URLs gathering uses, the ones which can't produce
+// any of the gathered candidate types are left out.
+func (g *ICEGatherer) gatheringServers() []*stun.URI {
+	g.lock.RLock()
+	defer g.lock.RUnlock()
+
+	if g.api.settingEngine.candidates.disableDynamicGathering {
+		return nil
+	}
+
+	candidateTypes := g.resolveCandidateTypes()
+	if candidateTypes == nil {
+		return g.validatedServers
+	}
+
+	return slices.DeleteFunc(slices.Clone(g.validatedServers), func(url *stun.URI) bool {
+		return !slices.ContainsFunc(candidateTypes, func(typ ice.CandidateType) bool {
+			return iceServerGathers(url, typ)
+		})
+	})
+}
+
 // set media stream identification tag and media description index for this gatherer.
 func (g *ICEGatherer) setMediaStreamIdentification(mid string, mLineIndex uint16) {
 	g.sdpMid.Store(mid)
@@ -510,7 +1026,10 @@ func (g *ICEGatherer) flushCandidates() {
 	currentState := g.State()
 
 	for _, candidate := range candidates {
-		c, err := newICECandidateFromICE(candidate, sdpMid, sdpMLineIndex)
+		if !g.allowsLocalCandidate(candidate) {
+			continue
+		}
+		c, err := g.newLocalICECandidate(candidate, sdpMid, sdpMLineIndex)
 		if err != nil {
 			g.log.Warnf("Failed to convert pooled ice.Candidate: %s", err)
 
@@ -542,6 +1061,8 @@ func (g *ICEGatherer) close(shouldGracefullyClose bool) error {
 	g.lock.Lock()
 	defer g.lock.Unlock()
 
+	g.stopWatchingRemovedAddresses()
+
 	if g.agent == nil {
 		return nil
 	}
@@ -608,6 +1129,9 @@ func (g *ICEGatherer) GetLocalCandidates() ([]ICECandidate, error) {
 	if err != nil {
 		return nil, err
 	}
+	iceCandidates = slices.DeleteFunc(iceCandidates, func(candidate ice.Candidate) bool {
+		return !g.allowsLocalCandidate(candidate)
+	})
 
 	sdpMid := ""
 	if mid, ok := g.sdpMid.Load().(string); ok {
@@ -616,7 +1140,24 @@ func (g *ICEGatherer) GetLocalCandidates() ([]ICECandidate, error) {
 
 	sdpMLineIndex := uint16(g.sdpMLineIndex.Load()) //nolint:gosec // G115
 
-	return newICECandidatesFromICE(iceCandidates, sdpMid, sdpMLineIndex)
+	return g.newLocalICECandidates(iceCandidates, sdpMid, sdpMLineIndex)
+}
+
+// GetLocalCandidatesByType returns the local ICE candidates of type typ gathered so far.
+func (g *ICEGatherer) GetLocalCandidatesByType(typ ICECandidateType) ([]ICECandidate, error) {
+	candidates, err := g.GetLocalCandidates()
+	if err != nil {
+		return nil, err
+	}
+
+	byType := []ICECandidate{}
+	for _, candidate := range candidates {
+		if candidate.Typ == typ {
+			byType = append(byType, candidate)
+		}
+	}
+
+	return byType, nil
 }
 
 // OnLocalCandidate sets an event handler which fires when a new local ICE candidate is available
@@ -625,6 +1166,12 @@ func (g *ICEGatherer) OnLocalCandidate(f func(*ICECandidate)) {
 	g.onLocalCandidateHandler.Store(f)
 }
 
+// OnCandidateError sets an event handler which is invoked when candidates can't be
+// gathered from an ICE server, like a TURN server with OAuth credentials.
+func (g *ICEGatherer) OnCandidateError(f func(ICECandidateError)) {
+	g.onCandidateErrorHandler.Store(f)
+}
+
 // OnStateChange fires any time the ICEGatherer changes.
 func (g *ICEGatherer) OnStateChange(f func(ICEGathererState)) {
 	g.onStateChangeHandler.Store(f)
@@ -638,6 +1185,14 @@ func (g *ICEGatherer) State() ICEGathererState {
 func (g *ICEGatherer) setState(s ICEGathererState) {
 	atomicStoreICEGathererState(&g.state, s)
 
+	switch s {
+	case ICEGathererStateGathering:
+		g.timeline.record(TimelineEventICEGatheringStarted, "")
+	case ICEGathererStateComplete:
+		g.timeline.record(TimelineEventICEGatheringComplete, "")
+	default:
+	}
+
 	if handler, ok := g.onStateChangeHandler.Load().(func(state ICEGathererState)); ok && handler != nil {
 		handler(s)
 	}
@@ -658,6 +1213,13 @@ func (g *ICEGatherer) collectStats(collector *statsReportCollector) {
 
 	collector.Collecting()
 	go func(collector *statsReportCollector, agent *ice.Agent) {
+		localCandidateInterfaces := map[string]string{}
+		if localCandidates, err := agent.GetLocalCandidates(); err == nil {
+			for _, candidate := range localCandidates {
+				localCandidateInterfaces[candidate.ID()] = g.localCandidateInterface(candidate)
+			}
+		}
+
 		for _, candidatePairStats := range agent.GetCandidatePairsStats() {
 			collector.Collecting()
 
@@ -686,17 +1248,19 @@ func (g *ICEGatherer) collectStats(collector *statsReportCollector) {
 			}
 
 			stats := ICECandidateStats{
-				Timestamp:     statsTimestampFrom(candidateStats.Timestamp),
-				ID:            candidateStats.ID,
-				Type:          StatsTypeLocalCandidate,
-				IP:            candidateStats.IP,
-				Port:          int32(candidateStats.Port), //nolint:gosec // G115, no overflow, port
-				Protocol:      networkType.Protocol(),
-				CandidateType: candidateType,
-				Priority:      int32(candidateStats.Priority), //nolint:gosec
-				URL:           candidateStats.URL,
-				RelayProtocol: candidateStats.RelayProtocol,
-				Deleted:       candidateStats.Deleted,
+				Timestamp:        statsTimestampFrom(candidateStats.Timestamp),
+				ID:               candidateStats.ID,
+				Type:             StatsTypeLocalCandidate,
+				IP:               candidateStats.IP,
+				Port:             int32(candidateStats.Port), //nolint:gosec // G115, no overflow, port
+				Protocol:         networkType.Protocol(),
+				CandidateType:    candidateType,
+				Priority:         int32(candidateStats.Priority), //nolint:gosec
+				URL:              candidateStats.URL,
+				RelayProtocol:    candidateStats.RelayProtocol,
+				NetworkInterface: localCandidateInterfaces[candidateStats.ID],
+				NetworkType:      interfaceNetworkType(localCandidateInterfaces[candidateStats.ID]),
+				Deleted:          candidateStats.Deleted,
 			}
 			collector.Collect(stats.ID, stats)
 		}
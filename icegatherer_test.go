@@ -9,6 +9,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -18,9 +19,11 @@ import (
 	"github.com/pion/ice/v4"
 	"github.com/pion/logging"
 	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v4"
 	"github.com/pion/transport/v4/test"
 	"github.com/pion/transport/v4/vnet"
 	"github.com/pion/turn/v4"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -130,6 +133,169 @@ func TestICEGatherer_updateServers(t *testing.T) {
 	assert.NoError(t, gatherer.Close())
 }
 
+func TestICEGatherer_OAuthTURN(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	gatherer, err := NewAPI().NewICEGatherer(ICEGatherOptions{
+		ICEServers: []ICEServer{{
+			URLs:     []string{"turn:127.0.0.1:1?transport=udp"},
+			Username: "unittest",
+			Credential: OAuthCredential{ //nolint:gosec // not hardcoded credentials.
+				MACKey:      "WmtzanB3ZW9peFhtdm42NzUzNG0=",
+				AccessToken: "AAwg3kPHWPfvk9bDFL936wYvkoctMADzQ==",
+			},
+			CredentialType: ICECredentialTypeOauth,
+		}},
+	})
+	require.NoError(t, err)
+
+	candidateErrors := make(chan ICECandidateError, 1)
+	gatherer.OnCandidateError(func(candidateError ICECandidateError) {
+		candidateErrors <- candidateError
+	})
+	require.NoError(t, gatherer.Gather())
+
+	candidateError := <-candidateErrors
+	assert.Equal(t, "turn:127.0.0.1:1?transport=udp", candidateError.URL)
+	assert.ErrorIs(t, candidateError.Err, ErrOAuthTURNUnsupported)
+
+	assert.NoError(t, gatherer.Close())
+}
+
+func TestICEGatherer_RemoteRelayPolicy(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	se := SettingEngine{}
+	se.SetRemoteIPFilter(func(ip net.IP) bool {
+		return !ip.Equal(net.ParseIP("10.0.0.4"))
+	})
+	gatherer, err := NewAPI(WithSettingEngine(se)).NewICEGatherer(ICEGatherOptions{
+		ICEGatherPolicy: ICETransportPolicyRelay,
+	})
+	require.NoError(t, err)
+	require.NoError(t, gatherer.createAgent())
+
+	relay := &ICECandidate{Typ: ICECandidateTypeRelay, Address: "10.0.0.1"}
+	assert.True(t, gatherer.acceptRemoteCandidate(nil))
+	assert.False(t, gatherer.acceptRemoteCandidate(&ICECandidate{Typ: ICECandidateTypeHost, Address: "10.0.0.2"}))
+	assert.False(t, gatherer.acceptRemoteCandidate(&ICECandidate{Typ: ICECandidateTypeSrflx, Address: "10.0.0.3"}))
+	assert.True(t, gatherer.acceptRemoteCandidate(relay))
+	assert.True(t, gatherer.acceptRemoteCandidate(&ICECandidate{Typ: ICECandidateTypeRelay, Address: "10.0.0.4"}))
+
+	// Peer reflexive candidates are only accepted from the relay addresses
+	assert.True(t, gatherer.remoteIPFilter(net.ParseIP("10.0.0.1")))
+	assert.False(t, gatherer.remoteIPFilter(net.ParseIP("10.0.0.2")))
+	assert.False(t, gatherer.remoteIPFilter(net.ParseIP("10.0.0.4")), "the filter of the SettingEngine applies too")
+
+	// A new policy applies once the agent starts over
+	require.NoError(t, gatherer.updateServers(nil, ICETransportPolicyAll))
+	assert.False(t, gatherer.acceptRemoteCandidate(&ICECandidate{Typ: ICECandidateTypeHost, Address: "10.0.0.2"}))
+	gatherer.restartRemotePolicy()
+	assert.True(t, gatherer.acceptRemoteCandidate(&ICECandidate{Typ: ICECandidateTypeHost, Address: "10.0.0.2"}))
+	assert.True(t, gatherer.remoteIPFilter(net.ParseIP("10.0.0.2")))
+	assert.False(t, gatherer.remoteIPFilter(net.ParseIP("10.0.0.4")))
+
+	require.NoError(t, gatherer.Close())
+}
+
+func TestICEGatherer_GatherWith(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		stunIP     = "1.2.3.4"
+		externalIP = "1.2.3.10"
+		localIP    = "10.0.0.1"
+	)
+
+	loggerFactory := logging.NewDefaultLoggerFactory()
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{CIDR: "1.2.3.0/24", LoggerFactory: loggerFactory})
+	require.NoError(t, err)
+
+	stunNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{stunIP}})
+	require.NoError(t, err)
+	require.NoError(t, wan.AddNet(stunNet))
+	stunListener, err := stunNet.ListenPacket("udp4", net.JoinHostPort(stunIP, "3478"))
+	require.NoError(t, err)
+	stunServer, err := turn.NewServer(turn.ServerConfig{
+		LoggerFactory: loggerFactory,
+		PacketConnConfigs: []turn.PacketConnConfig{{
+			PacketConn: stunListener,
+			RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+				RelayAddress: net.ParseIP(stunIP), Address: "0.0.0.0", Net: stunNet,
+			},
+		}},
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, stunServer.Close())
+	}()
+
+	clientLAN, err := vnet.NewRouter(&vnet.RouterConfig{
+		StaticIPs:     []string{fmt.Sprintf("%s/%s", externalIP, localIP)},
+		CIDR:          "10.0.0.0/24",
+		NATType:       &vnet.NATType{Mode: vnet.NATModeNAT1To1},
+		LoggerFactory: loggerFactory,
+	})
+	require.NoError(t, err)
+	clientNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{localIP}})
+	require.NoError(t, err)
+	require.NoError(t, clientLAN.AddNet(clientNet))
+	require.NoError(t, wan.AddRouter(clientLAN))
+	require.NoError(t, wan.Start())
+	defer func() {
+		assert.NoError(t, wan.Stop())
+	}()
+
+	se := SettingEngine{}
+	se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	se.SetNet(clientNet)
+
+	gatherer, err := NewAPI(WithSettingEngine(se)).NewICEGatherer(ICEGatherOptions{})
+	require.NoError(t, err)
+
+	newServers := []ICEServer{{URLs: []string{"stun:" + stunIP + ":3478"}}}
+	assert.NoError(t, gatherer.GatherWith(newServers))
+	assert.Equal(t, 1, gatherer.validatedServersCount())
+
+	assert.Error(t, gatherer.GatherWith([]ICEServer{{URLs: []string{"turn:127.0.0.1"}}}))
+	assert.Equal(t, 1, gatherer.validatedServersCount())
+
+	var srflx []*ICECandidate
+	gatherFinished := make(chan struct{})
+	gatherer.OnLocalCandidate(func(candidate *ICECandidate) {
+		if candidate == nil {
+			close(gatherFinished)
+		} else if candidate.Typ == ICECandidateTypeSrflx {
+			srflx = append(srflx, candidate)
+		}
+	})
+	require.NoError(t, gatherer.Gather())
+	<-gatherFinished
+
+	// The candidate of the added server is gathered
+	require.Len(t, srflx, 1)
+	assert.Equal(t, externalIP, srflx[0].Address)
+
+	// Servers added once gathering started are kept for the next ICE restart
+	assert.NoError(t, gatherer.GatherWith([]ICEServer{{URLs: []string{"stun:1.2.3.5:3478"}}}))
+	assert.Equal(t, 2, gatherer.validatedServersCount())
+
+	assert.NoError(t, gatherer.Close())
+}
+
 func TestLegacyNAT1To1AddressRewriteRules(t *testing.T) {
 	t.Run("empty", func(t *testing.T) {
 		assert.Empty(t, legacyNAT1To1AddressRewriteRules(nil, ice.CandidateTypeHost))
@@ -504,6 +670,426 @@ func TestICEGatherer_NoHostPolicyVNet(t *testing.T) { //nolint:cyclop
 	})
 }
 
+func TestICEGatherer_CandidateTypesVNet(t *testing.T) { //nolint:cyclop,maintidx
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		serverIP   = "1.2.3.4"
+		serverPort = "3478"
+		externalIP = "1.2.3.10"
+		localIP    = "10.0.0.1"
+		realm      = "pion.ly"
+		timeout    = 5 * time.Second
+	)
+
+	loggerFactory := logging.NewDefaultLoggerFactory()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: loggerFactory,
+	})
+	require.NoError(t, err)
+
+	serverNet, err := vnet.NewNet(&vnet.NetConfig{
+		StaticIPs: []string{serverIP},
+	})
+	require.NoError(t, err)
+	require.NoError(t, wan.AddNet(serverNet))
+
+	clientLAN, err := vnet.NewRouter(&vnet.RouterConfig{
+		StaticIPs:     []string{fmt.Sprintf("%s/%s", externalIP, localIP)},
+		CIDR:          "10.0.0.0/24",
+		NATType:       &vnet.NATType{Mode: vnet.NATModeNAT1To1},
+		LoggerFactory: loggerFactory,
+	})
+	require.NoError(t, err)
+
+	clientNet, err := vnet.NewNet(&vnet.NetConfig{
+		StaticIPs: []string{localIP},
+	})
+	require.NoError(t, err)
+	require.NoError(t, clientLAN.AddNet(clientNet))
+	require.NoError(t, wan.AddRouter(clientLAN))
+	require.NoError(t, wan.Start())
+	defer func() {
+		assert.NoError(t, wan.Stop())
+	}()
+
+	listener, err := serverNet.ListenPacket("udp4", net.JoinHostPort(serverIP, serverPort))
+	require.NoError(t, err)
+
+	authKey := turn.GenerateAuthKey("user", realm, "pass")
+	turnServer, err := turn.NewServer(turn.ServerConfig{
+		Realm: realm,
+		AuthHandler: func(u, r string, _ net.Addr) ([]byte, bool) {
+			return authKey, u == "user" && r == realm
+		},
+		PacketConnConfigs: []turn.PacketConnConfig{
+			{
+				PacketConn: listener,
+				RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+					RelayAddress: net.ParseIP(serverIP),
+					Address:      "0.0.0.0",
+					Net:          serverNet,
+				},
+			},
+		},
+		LoggerFactory: loggerFactory,
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, turnServer.Close())
+	}()
+
+	iceServers := []ICEServer{
+		{URLs: []string{"stun:" + net.JoinHostPort(serverIP, serverPort)}},
+		{
+			URLs:       []string{"turn:" + net.JoinHostPort(serverIP, serverPort) + "?transport=udp"},
+			Username:   "user",
+			Credential: "pass",
+		},
+	}
+
+	se := SettingEngine{}
+	se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	se.SetNet(clientNet)
+	api := NewAPI(WithSettingEngine(se))
+
+	// typesOf returns the sorted types of the candidates, without duplicates
+	typesOf := func(candidates []ICECandidate) []ICECandidateType {
+		types := []ICECandidateType{}
+		for _, c := range candidates {
+			types = append(types, c.Typ)
+		}
+		slices.Sort(types)
+
+		return slices.Compact(types)
+	}
+
+	// gather sets a new local offer, and returns the types of the candidates passed to
+	// OnICECandidate and of the ones in the local description, once gathering is complete.
+	gather := func(t *testing.T, pc *PeerConnection, options *OfferOptions) ([]ICECandidateType, []ICECandidateType) {
+		t.Helper()
+
+		var (
+			mu         sync.Mutex
+			candidates []ICECandidate
+		)
+		done := make(chan struct{})
+		pc.OnICECandidate(func(c *ICECandidate) {
+			if c == nil {
+				close(done)
+
+				return
+			}
+			mu.Lock()
+			candidates = append(candidates, *c)
+			mu.Unlock()
+		})
+
+		offer, err := pc.CreateOffer(options)
+		require.NoError(t, err)
+		require.NoError(t, pc.SetLocalDescription(offer))
+
+		select {
+		case <-done:
+		case <-time.After(timeout):
+			require.Fail(t, "gathering did not complete")
+		}
+		pc.OnICECandidate(nil)
+
+		parsed := pc.LocalDescription().parsed
+		require.Len(t, parsed.MediaDescriptions, 1)
+		var described []ICECandidate
+		for _, attr := range parsed.MediaDescriptions[0].Attributes {
+			if !attr.IsICECandidate() {
+				continue
+			}
+			candidate, err := ice.UnmarshalCandidate(attr.Value)
+			require.NoError(t, err)
+			c, err := newICECandidateFromICE(candidate, "", 0)
+			require.NoError(t, err)
+			described = append(described, c)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return typesOf(candidates), typesOf(described)
+	}
+
+	newPeerConnection := func(t *testing.T, types []ICECandidateType) *PeerConnection {
+		t.Helper()
+
+		pc, err := api.NewPeerConnection(Configuration{
+			ICEServers:        iceServers,
+			ICECandidateTypes: types,
+		})
+		require.NoError(t, err)
+		_, err = pc.CreateDataChannel("data", nil)
+		require.NoError(t, err)
+
+		return pc
+	}
+
+	host, srflx, relay := ICECandidateTypeHost, ICECandidateTypeSrflx, ICECandidateTypeRelay
+	for _, test := range []struct {
+		name     string
+		types    []ICECandidateType
+		expected []ICECandidateType
+	}{
+		{"All", nil, []ICECandidateType{host, srflx, relay}},
+		{"Host", []ICECandidateType{host}, []ICECandidateType{host}},
+		{"Srflx", []ICECandidateType{srflx}, []ICECandidateType{srflx}},
+		{"Relay", []ICECandidateType{relay}, []ICECandidateType{relay}},
+		{"HostAndRelay", []ICECandidateType{relay, host}, []ICECandidateType{host, relay}},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			pc := newPeerConnection(t, test.types)
+			defer func() {
+				assert.NoError(t, pc.Close())
+			}()
+
+			emitted, described := gather(t, pc, nil)
+			assert.Equal(t, test.expected, emitted)
+			assert.Equal(t, test.expected, described)
+		})
+	}
+
+	t.Run("Restricted on ICE restart", func(t *testing.T) {
+		pc := newPeerConnection(t, []ICECandidateType{host, relay})
+		defer func() {
+			assert.NoError(t, pc.Close())
+		}()
+
+		emitted, described := gather(t, pc, nil)
+		assert.Equal(t, []ICECandidateType{host, relay}, emitted)
+		assert.Equal(t, []ICECandidateType{host, relay}, described)
+
+		err := pc.SetConfiguration(Configuration{
+			ICEServers:        iceServers,
+			ICECandidateTypes: []ICECandidateType{host, srflx},
+		})
+		var modErr *rtcerr.InvalidModificationError
+		require.ErrorAs(t, err, &modErr)
+		assert.ErrorIs(t, err, ErrModifyingICECandidateTypes)
+
+		require.NoError(t, pc.SetConfiguration(Configuration{
+			ICEServers:        iceServers,
+			ICECandidateTypes: []ICECandidateType{relay},
+		}))
+		assert.Equal(t, []ICECandidateType{relay}, pc.GetConfiguration().ICECandidateTypes)
+
+		// The candidates gathered before are left out of the description from now on
+		offer, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		assert.NotContains(t, offer.SDP, "typ host")
+		require.NoError(t, pc.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+
+		emitted, described = gather(t, pc, &OfferOptions{ICERestart: true})
+		assert.Equal(t, []ICECandidateType{relay}, emitted)
+		assert.Equal(t, []ICECandidateType{relay}, described)
+
+		// No types keep the current ones
+		require.NoError(t, pc.SetConfiguration(Configuration{ICEServers: iceServers}))
+		assert.Equal(t, []ICECandidateType{relay}, pc.GetConfiguration().ICECandidateTypes)
+	})
+}
+
+func TestICEGatherer_CandidateTypesValidation(t *testing.T) {
+	host, srflx, relay := ICECandidateTypeHost, ICECandidateTypeSrflx, ICECandidateTypeRelay
+
+	t.Run("Policy", func(t *testing.T) {
+		for _, test := range []struct {
+			policy   ICETransportPolicy
+			types    []ICECandidateType
+			conflict bool
+		}{
+			{ICETransportPolicyAll, []ICECandidateType{host}, false},
+			{ICETransportPolicyRelay, nil, false},
+			{ICETransportPolicyRelay, []ICECandidateType{host, relay}, false},
+			{ICETransportPolicyRelay, []ICECandidateType{host, srflx}, true},
+			{ICETransportPolicyNoHost, []ICECandidateType{host, srflx}, false},
+			{ICETransportPolicyNoHost, []ICECandidateType{host}, true},
+		} {
+			pc, err := NewPeerConnection(Configuration{
+				ICETransportPolicy: test.policy,
+				ICECandidateTypes:  test.types,
+			})
+			if test.conflict {
+				var accessErr *rtcerr.InvalidAccessError
+				assert.ErrorAs(t, err, &accessErr)
+				assert.ErrorIs(t, err, ErrICECandidateTypesConflict)
+
+				continue
+			}
+			require.NoError(t, err)
+
+			// The policy is validated against the current types
+			err = pc.SetConfiguration(Configuration{ICETransportPolicy: ICETransportPolicyRelay})
+			if slices.Contains(test.types, relay) || len(test.types) == 0 {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, ErrICECandidateTypesConflict)
+			}
+			assert.NoError(t, pc.Close())
+		}
+	})
+
+	t.Run("Not gathered", func(t *testing.T) {
+		for _, typ := range []ICECandidateType{ICECandidateTypePrflx, ICECandidateTypeUnknown} {
+			_, err := NewPeerConnection(Configuration{ICECandidateTypes: []ICECandidateType{typ}})
+			var typeErr *rtcerr.TypeError
+			assert.ErrorAs(t, err, &typeErr)
+			assert.ErrorIs(t, err, errICECandidateTypeNotGathered)
+
+			errs := Configuration{ICECandidateTypes: []ICECandidateType{host, typ}}.Validate()
+			if assert.Len(t, errs, 1) {
+				assert.ErrorIs(t, errs[0], errICECandidateTypeNotGathered)
+			}
+		}
+	})
+
+	t.Run("ICE Lite", func(t *testing.T) {
+		se := SettingEngine{}
+		se.SetLite(true)
+
+		_, err := NewAPI(WithSettingEngine(se)).NewICEGatherer(ICEGatherOptions{
+			ICECandidateTypes: []ICECandidateType{srflx, relay},
+		})
+		assert.ErrorIs(t, err, ErrICECandidateTypesConflict)
+
+		gatherer, err := NewAPI(WithSettingEngine(se)).NewICEGatherer(ICEGatherOptions{
+			ICECandidateTypes: []ICECandidateType{host, relay},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []ice.CandidateType{ice.CandidateTypeHost}, gatherer.resolveCandidateTypes())
+	})
+}
+
+func TestICEGatherer_GatheringProgress(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		externalIP = "1.2.3.10"
+		localIP    = "10.0.0.1"
+		// Nothing answers on the address of the TURN server
+		blackholedTURNIP = "1.2.3.9"
+	)
+	stunIPs := []string{"1.2.3.4", "1.2.3.5"}
+
+	loggerFactory := logging.NewDefaultLoggerFactory()
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{CIDR: "1.2.3.0/24", LoggerFactory: loggerFactory})
+	require.NoError(t, err)
+
+	for _, stunIP := range stunIPs {
+		stunNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{stunIP}})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(stunNet))
+
+		stunListener, netErr := stunNet.ListenPacket("udp4", net.JoinHostPort(stunIP, "3478"))
+		require.NoError(t, netErr)
+		stunServer, netErr := turn.NewServer(turn.ServerConfig{
+			LoggerFactory: loggerFactory,
+			PacketConnConfigs: []turn.PacketConnConfig{{
+				PacketConn: stunListener,
+				RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+					RelayAddress: net.ParseIP(stunIP), Address: "0.0.0.0", Net: stunNet,
+				},
+			}},
+		})
+		require.NoError(t, netErr)
+		defer func() {
+			assert.NoError(t, stunServer.Close())
+		}()
+	}
+
+	clientLAN, err := vnet.NewRouter(&vnet.RouterConfig{
+		StaticIPs:     []string{fmt.Sprintf("%s/%s", externalIP, localIP)},
+		CIDR:          "10.0.0.0/24",
+		NATType:       &vnet.NATType{Mode: vnet.NATModeNAT1To1},
+		LoggerFactory: loggerFactory,
+	})
+	require.NoError(t, err)
+	clientNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{localIP}})
+	require.NoError(t, err)
+	require.NoError(t, clientLAN.AddNet(clientNet))
+	require.NoError(t, wan.AddRouter(clientLAN))
+	require.NoError(t, wan.Start())
+	defer func() {
+		assert.NoError(t, wan.Stop())
+	}()
+
+	se := SettingEngine{}
+	se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	se.SetNet(clientNet)
+	se.SetSTUNGatherTimeout(time.Second)
+
+	gatherer, err := NewAPI(WithSettingEngine(se)).NewICEGatherer(ICEGatherOptions{
+		ICEServers: []ICEServer{
+			{URLs: []string{"stun:" + stunIPs[0] + ":3478", "stun:" + stunIPs[1] + ":3478"}},
+			{URLs: []string{"turn:" + blackholedTURNIP + ":3478"}, Username: "user", Credential: "pass"},
+		},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, ICEGatheringProgress{State: ICEGathererStateNew}, gatherer.GatheringProgress())
+
+	done := make(chan struct{})
+	var completeProgress ICEGatheringProgress
+	gatherer.OnStateChange(func(state ICEGathererState) {
+		if state == ICEGathererStateComplete {
+			completeProgress = gatherer.GatheringProgress()
+			close(done)
+		}
+	})
+	require.NoError(t, gatherer.Gather())
+
+	// Both STUN servers answer while the TURN server holds up gathering
+	var progress ICEGatheringProgress
+	for {
+		progress = gatherer.GatheringProgress()
+		if progress.Servers[0].Candidates != 0 && progress.Servers[1].Candidates != 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, ICEGathererStateGathering, progress.State)
+	assert.Equal(t, 1, progress.HostCandidates)
+	require.Len(t, progress.Servers, 3)
+	for i, stunIP := range stunIPs {
+		assert.Equal(t, "stun:"+stunIP+":3478", progress.Servers[i].URL)
+		assert.Equal(t, ICEServerGatheringStatusSucceeded, progress.Servers[i].Status)
+		assert.Equal(t, 1, progress.Servers[i].Candidates)
+		assert.Greater(t, progress.Servers[i].Duration, time.Duration(0))
+	}
+	assert.Equal(t, "turn:"+blackholedTURNIP+":3478?transport=udp", progress.Servers[2].URL)
+	assert.Equal(t, ICEServerGatheringStatusPending, progress.Servers[2].Status)
+	assert.Zero(t, progress.Servers[2].Candidates)
+
+	<-done
+	assert.Equal(t, ICEGathererStateComplete, completeProgress.State)
+	assert.Equal(t, ICEServerGatheringStatusSucceeded, completeProgress.Servers[0].Status)
+	assert.Equal(t, progress.Servers[0].Duration, completeProgress.Servers[0].Duration)
+	assert.Equal(t, ICEServerGatheringStatusFailed, completeProgress.Servers[2].Status)
+	assert.Zero(t, completeProgress.Servers[2].Candidates)
+	assert.Equal(t, completeProgress.Duration, completeProgress.Servers[2].Duration)
+	assert.Greater(t, completeProgress.Duration, time.Second)
+	assert.Equal(t, completeProgress, gatherer.GatheringProgress(), "the progress doesn't change after gathering")
+
+	assert.NoError(t, gatherer.Close())
+}
+
 func TestICEGatherer_AddressRewriteRulesVNet(t *testing.T) { //nolint:cyclop
 	lim := test.TimeOut(time.Second * 10)
 	defer lim.Stop()
@@ -1324,63 +1910,407 @@ func TestICEGatherer_StaticLocalCredentialsVNet(t *testing.T) { //nolint:cyclop
 			}
 		}
 
-		return ufrag, pwd
-	}
+		return ufrag, pwd
+	}
+
+	router, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "10.0.0.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	assert.NoError(t, err)
+
+	offerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"10.0.0.2"}})
+	assert.NoError(t, err)
+	answerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"10.0.0.3"}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, router.AddNet(offerNet))
+	assert.NoError(t, router.AddNet(answerNet))
+	assert.NoError(t, router.Start())
+	defer func() {
+		assert.NoError(t, router.Stop())
+	}()
+
+	buildSE := func(n *vnet.Net, ufrag, pwd string) SettingEngine {
+		se := SettingEngine{}
+		se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+		se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+		se.SetNet(n)
+		se.SetICECredentials(ufrag, pwd)
+
+		return se
+	}
+
+	const (
+		offerUfrag  = "offerufrag123"
+		offerPwd    = "offerpassword1234567890"
+		answerUfrag = "answerufrag123"
+		answerPwd   = "answerpassword1234567890"
+	)
+
+	pcOffer, err := NewAPI(WithSettingEngine(buildSE(offerNet, offerUfrag, offerPwd))).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(
+		WithSettingEngine(buildSE(answerNet, answerUfrag, answerPwd)),
+	).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	defer closePairNow(t, pcOffer, pcAnswer)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	gotUfrag, gotPwd := parseCreds(pcOffer.LocalDescription().SDP)
+	assert.Equal(t, offerUfrag, gotUfrag)
+	assert.Equal(t, offerPwd, gotPwd)
+
+	gotUfrag, gotPwd = parseCreds(pcAnswer.LocalDescription().SDP)
+	assert.Equal(t, answerUfrag, gotUfrag)
+	assert.Equal(t, answerPwd, gotPwd)
+}
+
+func TestICEGatherer_StaticLocalCredentialsValidation(t *testing.T) {
+	for _, test := range []struct {
+		name             string
+		usernameFragment string
+		password         string
+		expected         error
+	}{
+		{"Random", "", "", nil},
+		{"Valid", "ufrg", "abcdefghijklmnopqrstuv", nil},
+		{"Valid charset", "a+b/", "ABCDEFGHIJKLMNOPQRSTU+/09", nil},
+		{"Short ufrag", "ufr", "", errSettingEngineICEUsernameFragment},
+		{"Ufrag invalid char", "ufrag-1", "", errSettingEngineICEUsernameFragment},
+		{"Long ufrag", strings.Repeat("u", 257), "", errSettingEngineICEUsernameFragment},
+		{"Short pwd", "", "abcdefghijklmnopqrstu", errSettingEngineICEPassword},
+		{"Pwd invalid char", "", "abcdefghijklmnopqrstuv:", errSettingEngineICEPassword},
+	} {
+		se := SettingEngine{}
+		se.SetICECredentials(test.usernameFragment, test.password)
+
+		pc, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		if test.expected != nil {
+			assert.ErrorIs(t, err, test.expected, test.name)
+
+			continue
+		}
+
+		assert.NoError(t, err, test.name)
+		assert.NoError(t, pc.Close())
+	}
+}
+
+func TestICEGatherer_StaticLocalCredentialsRestart(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		ufrag        = "staticufrag"
+		pwd          = "staticpassword1234567890"
+		restartUfrag = "restartufrag"
+		restartPwd   = "restartpassword1234567890"
+	)
+
+	offerUfrag := func(t *testing.T, pc *PeerConnection, options *OfferOptions) string {
+		t.Helper()
+
+		offer, err := pc.CreateOffer(options)
+		require.NoError(t, err)
+
+		parsed, err := offer.Unmarshal()
+		require.NoError(t, err)
+		value, ok := parsed.MediaDescriptions[0].Attribute("ice-ufrag")
+		require.True(t, ok)
+
+		return value
+	}
+
+	t.Run("Rotate", func(t *testing.T) {
+		se := SettingEngine{}
+		se.SetICECredentials(ufrag, pwd)
+
+		pc, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		_, err = pc.CreateDataChannel("data", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, ufrag, offerUfrag(t, pc, nil))
+
+		restarted := offerUfrag(t, pc, &OfferOptions{ICERestart: true})
+		assert.NotEqual(t, ufrag, restarted)
+		assert.NotEmpty(t, restarted)
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("Hook", func(t *testing.T) {
+		se := SettingEngine{}
+		se.SetICECredentials(ufrag, pwd)
+		se.SetICERestartCredentials(func() (string, string) {
+			return restartUfrag, restartPwd
+		})
+
+		pc, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		_, err = pc.CreateDataChannel("data", nil)
+		require.NoError(t, err)
+
+		assert.Equal(t, ufrag, offerUfrag(t, pc, nil))
+		assert.Equal(t, restartUfrag, offerUfrag(t, pc, &OfferOptions{ICERestart: true}))
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("Invalid hook", func(t *testing.T) {
+		se := SettingEngine{}
+		se.SetICERestartCredentials(func() (string, string) {
+			return "bad", ""
+		})
+
+		pc, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		_, err = pc.CreateDataChannel("data", nil)
+		require.NoError(t, err)
+
+		offerUfrag(t, pc, nil)
+		_, err = pc.CreateOffer(&OfferOptions{ICERestart: true})
+		assert.ErrorIs(t, err, errSettingEngineICEUsernameFragment)
+		assert.NoError(t, pc.Close())
+	})
+}
+
+func TestICEGatherer_StaticLocalCredentialsSharedUDPMux(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	udpMux := ice.NewUDPMuxDefault(ice.UDPMuxParams{UDPConn: udpConn})
+	defer func() {
+		assert.NoError(t, udpMux.Close())
+	}()
+
+	newSettingEngine := func() SettingEngine {
+		se := SettingEngine{}
+		se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+		se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+		se.SetIncludeLoopbackCandidate(true)
+
+		return se
+	}
+
+	var peerConnections []*PeerConnection
+	for _, ufrag := range []string{"nodeufragaaaa", "nodeufragbbbb"} {
+		muxSettingEngine := newSettingEngine()
+		muxSettingEngine.SetICEUDPMux(udpMux)
+		muxSettingEngine.SetICECredentials(ufrag, "sharedmuxpassword1234567890")
+
+		pcMux, err := NewAPI(WithSettingEngine(muxSettingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		pcRemote, err := NewAPI(WithSettingEngine(newSettingEngine())).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcMux, pcRemote)
+		require.NoError(t, signalPair(pcMux, pcRemote))
+		connected.Wait()
+
+		assert.Contains(t, pcMux.LocalDescription().SDP, "a=ice-ufrag:"+ufrag)
+		peerConnections = append(peerConnections, pcMux, pcRemote)
+	}
+
+	// Both agents have to be selected over the single shared port.
+	for i := 0; i < len(peerConnections); i += 2 {
+		pair, err := peerConnections[i].SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+		require.NoError(t, err)
+		require.NotNil(t, pair)
+		assert.Equal(t, udpConn.LocalAddr().(*net.UDPAddr).Port, int(pair.Local.Port)) //nolint:forcetypeassert
+	}
+
+	for i := 0; i < len(peerConnections); i += 2 {
+		closePairNow(t, peerConnections[i], peerConnections[i+1])
+	}
+}
+
+func newStaticCandidateUDPMux(t *testing.T) (*ice.UDPMuxDefault, int) {
+	t.Helper()
+
+	udpConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	require.NoError(t, err)
+
+	port := udpConn.LocalAddr().(*net.UDPAddr).Port //nolint:forcetypeassert
+
+	return ice.NewUDPMuxDefault(ice.UDPMuxParams{UDPConn: udpConn}), port
+}
+
+func newStaticCandidateSettingEngine(
+	t *testing.T, udpMux ice.UDPMux, candidates ...ICECandidate,
+) SettingEngine {
+	t.Helper()
+
+	se := SettingEngine{}
+	se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	se.SetIncludeLoopbackCandidate(true)
+	se.SetICEUDPMux(udpMux)
+	require.NoError(t, se.SetStaticLocalCandidates(candidates))
+	se.DisableDynamicGathering(true)
+
+	return se
+}
+
+func TestICEGatherer_StaticLocalCandidates(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	t.Run("Gather", func(t *testing.T) {
+		udpMux, port := newStaticCandidateUDPMux(t)
+		defer func() {
+			assert.NoError(t, udpMux.Close())
+		}()
+
+		// The advertised address of the port mapping replaces the one of the UDPMux
+		public := ICECandidate{
+			Typ:      ICECandidateTypeSrflx,
+			Protocol: ICEProtocolUDP,
+			Address:  "203.0.113.7",
+			Port:     uint16(port), //nolint:gosec // G115
+		}
+		se := newStaticCandidateSettingEngine(t, udpMux, public)
+
+		// STUN servers aren't used without dynamic gathering
+		pc, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{
+			ICEServers: []ICEServer{{URLs: []string{"stun:127.0.0.1:1"}}},
+		})
+		require.NoError(t, err)
+
+		var candidatesMu sync.Mutex
+		var candidates []ICECandidate
+		pc.OnICECandidate(func(c *ICECandidate) {
+			if c != nil {
+				candidatesMu.Lock()
+				candidates = append(candidates, *c)
+				candidatesMu.Unlock()
+			}
+		})
+
+		_, err = pc.CreateDataChannel("data", nil)
+		require.NoError(t, err)
+		offer, err := pc.CreateOffer(nil)
+		require.NoError(t, err)
+		gatheringComplete := GatheringCompletePromise(pc)
+		require.NoError(t, pc.SetLocalDescription(offer))
+
+		select {
+		case <-gatheringComplete:
+		case <-time.After(time.Second):
+			assert.Fail(t, "gathering didn't complete right away")
+		}
+
+		candidatesMu.Lock()
+		require.Len(t, candidates, 1)
+		assert.Equal(t, ICECandidateTypeHost, candidates[0].Typ)
+		assert.Equal(t, ICEProtocolUDP, candidates[0].Protocol)
+		assert.Equal(t, public.Address, candidates[0].Address)
+		assert.Equal(t, public.Port, candidates[0].Port)
+		candidatesMu.Unlock()
 
-	router, err := vnet.NewRouter(&vnet.RouterConfig{
-		CIDR:          "10.0.0.0/24",
-		LoggerFactory: logging.NewDefaultLoggerFactory(),
-	})
-	assert.NoError(t, err)
+		assert.Contains(t, pc.LocalDescription().SDP, fmt.Sprintf("203.0.113.7 %d typ host", port))
+		assert.NotContains(t, pc.LocalDescription().SDP, "127.0.0.1")
 
-	offerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"10.0.0.2"}})
-	assert.NoError(t, err)
-	answerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"10.0.0.3"}})
-	assert.NoError(t, err)
+		assert.NoError(t, pc.Close())
+	})
 
-	assert.NoError(t, router.AddNet(offerNet))
-	assert.NoError(t, router.AddNet(answerNet))
-	assert.NoError(t, router.Start())
-	defer func() {
-		assert.NoError(t, router.Stop())
-	}()
+	t.Run("Connect", func(t *testing.T) {
+		var peerConnections []*PeerConnection
+		for _, typ := range []ICECandidateType{ICECandidateTypeHost, ICECandidateTypeSrflx} {
+			udpMux, port := newStaticCandidateUDPMux(t)
+			defer func() {
+				assert.NoError(t, udpMux.Close())
+			}()
 
-	buildSE := func(n *vnet.Net, ufrag, pwd string) SettingEngine {
-		se := SettingEngine{}
-		se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
-		se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
-		se.SetNet(n)
-		se.SetICECredentials(ufrag, pwd)
+			se := newStaticCandidateSettingEngine(t, udpMux, ICECandidate{
+				Typ: typ, Protocol: ICEProtocolUDP, Address: "127.0.0.1", Port: uint16(port), //nolint:gosec // G115
+			})
+			pc, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+			require.NoError(t, err)
+			peerConnections = append(peerConnections, pc)
+		}
+		pcOffer, pcAnswer := peerConnections[0], peerConnections[1]
 
-		return se
-	}
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
 
-	const (
-		offerUfrag  = "offerufrag123"
-		offerPwd    = "offerpassword123456"
-		answerUfrag = "answerufrag123"
-		answerPwd   = "answerpassword123456"
-	)
+		for _, pc := range peerConnections {
+			assert.Equal(t, 1, strings.Count(pc.LocalDescription().SDP, " 1 udp "))
 
-	pcOffer, err := NewAPI(WithSettingEngine(buildSE(offerNet, offerUfrag, offerPwd))).NewPeerConnection(Configuration{})
-	assert.NoError(t, err)
-	pcAnswer, err := NewAPI(
-		WithSettingEngine(buildSE(answerNet, answerUfrag, answerPwd)),
-	).NewPeerConnection(Configuration{})
-	assert.NoError(t, err)
-	defer closePairNow(t, pcOffer, pcAnswer)
+			pair, err := pc.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+			require.NoError(t, err)
+			require.NotNil(t, pair)
+			assert.Equal(t, ICECandidateTypeHost, pair.Local.Typ)
+			assert.Equal(t, "127.0.0.1", pair.Remote.Address)
+		}
+		closePairNow(t, pcOffer, pcAnswer)
+	})
 
-	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
-	assert.NoError(t, signalPair(pcOffer, pcAnswer))
-	connected.Wait()
+	t.Run("Invalid", func(t *testing.T) {
+		udpMux, port := newStaticCandidateUDPMux(t)
+		defer func() {
+			assert.NoError(t, udpMux.Close())
+		}()
 
-	gotUfrag, gotPwd := parseCreds(pcOffer.LocalDescription().SDP)
-	assert.Equal(t, offerUfrag, gotUfrag)
-	assert.Equal(t, offerPwd, gotPwd)
+		valid := ICECandidate{
+			Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: "127.0.0.1", Port: uint16(port), //nolint:gosec // G115
+		}
 
-	gotUfrag, gotPwd = parseCreds(pcAnswer.LocalDescription().SDP)
-	assert.Equal(t, answerUfrag, gotUfrag)
-	assert.Equal(t, answerPwd, gotPwd)
+		se := SettingEngine{}
+		for _, invalid := range []ICECandidate{
+			{Typ: ICECandidateTypeRelay, Protocol: ICEProtocolUDP, Address: "127.0.0.1", Port: valid.Port},
+			{Typ: ICECandidateTypeHost, Protocol: ICEProtocolTCP, Address: "127.0.0.1", Port: valid.Port},
+			{Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: "pion.local", Port: valid.Port},
+			{Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: "0.0.0.0", Port: valid.Port},
+			{Typ: ICECandidateTypeHost, Protocol: ICEProtocolUDP, Address: "127.0.0.1"},
+		} {
+			assert.ErrorIs(t, se.SetStaticLocalCandidates([]ICECandidate{valid, invalid}), errSettingEngineStaticLocalCandidate)
+		}
+		assert.Empty(t, se.candidates.staticLocalCandidates)
+
+		// Nothing to publish without the static local candidates
+		se.DisableDynamicGathering(true)
+		_, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		assert.ErrorIs(t, err, errSettingEngineDynamicGatheringDisabled)
+
+		// The sockets come from the UDPMux
+		require.NoError(t, se.SetStaticLocalCandidates([]ICECandidate{valid}))
+		_, err = NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		assert.ErrorIs(t, err, errSettingEngineStaticLocalCandidatesUDPMux)
+
+		se.SetICEUDPMux(udpMux)
+		mismatch := valid
+		mismatch.Port++
+		require.NoError(t, se.SetStaticLocalCandidates([]ICECandidate{valid, mismatch}))
+		_, err = NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		assert.ErrorIs(t, err, errSettingEngineStaticLocalCandidatePort)
+
+		// The IPv4 host candidate of the UDPMux would be published as is
+		ipv6 := valid
+		ipv6.Address = "2001:db8::7"
+		require.NoError(t, se.SetStaticLocalCandidates([]ICECandidate{ipv6}))
+		_, err = NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		assert.ErrorIs(t, err, errSettingEngineStaticLocalCandidateFamily)
+
+		require.NoError(t, se.SetStaticLocalCandidates([]ICECandidate{valid}))
+		se.SetNAT1To1IPs([]string{"203.0.113.7"}, ICECandidateTypeHost)
+		_, err = NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+		assert.ErrorIs(t, err, errAddressRewriteWithStaticCandidates)
+	})
 }
 
 func TestICEGatherer_AlreadyClosed(t *testing.T) {
@@ -2911,3 +3841,393 @@ func startTrickleRenomination(
 	assert.NoError(t, offerSender.flushSrflx())
 	assert.NoError(t, answerSender.flushSrflx())
 }
+
+func TestICEGatherer_CandidatePriorityFunctionVNet(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		offerIP  = "1.2.3.4"
+		answerIP = "1.2.3.5"
+	)
+
+	// invertPriority swaps the type preferences of host and srflx candidates.
+	typePreferenceDelta := uint32(ice.CandidateTypeHost.Preference()-ice.CandidateTypeServerReflexive.Preference()) << 24
+	var calls atomic.Int32
+	invertPriority := func(candidateType ICECandidateType, network, intf string, defaultPriority uint32) uint32 {
+		calls.Add(1)
+		assert.Equal(t, "udp4", network)
+		assert.Equal(t, "eth0", intf)
+
+		switch candidateType {
+		case ICECandidateTypeHost:
+			return defaultPriority - typePreferenceDelta
+		case ICECandidateTypeSrflx:
+			return defaultPriority + typePreferenceDelta
+		default:
+			return defaultPriority
+		}
+	}
+
+	connect := func(t *testing.T, priorityFunction func(ICECandidateType, string, string, uint32) uint32) (
+		answerCandidates []ICECandidate, answerSDP string, selectedRemote ICECandidate,
+	) {
+		t.Helper()
+
+		wan, err := vnet.NewRouter(&vnet.RouterConfig{
+			CIDR:          "1.2.3.0/24",
+			LoggerFactory: logging.NewDefaultLoggerFactory(),
+		})
+		require.NoError(t, err)
+
+		offerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{offerIP}})
+		require.NoError(t, err)
+		answerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{answerIP}})
+		require.NoError(t, err)
+		require.NoError(t, wan.AddNet(offerNet))
+		require.NoError(t, wan.AddNet(answerNet))
+		require.NoError(t, wan.Start())
+		defer func() {
+			assert.NoError(t, wan.Stop())
+		}()
+
+		offerSE := SettingEngine{}
+		offerSE.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+		offerSE.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+		offerSE.SetNet(offerNet)
+		// Wait until every pair was checked before nominating.
+		offerSE.SetHostAcceptanceMinWait(time.Second)
+
+		answerSE := SettingEngine{}
+		answerSE.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+		answerSE.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+		answerSE.SetNet(answerNet)
+		// A srflx candidate with the host address, so both candidates are reachable.
+		require.NoError(t, answerSE.SetICEAddressRewriteRules(ICEAddressRewriteRule{
+			External:        []string{answerIP},
+			AsCandidateType: ICECandidateTypeSrflx,
+			Mode:            ICEAddressRewriteAppend,
+		}))
+		answerSE.SetCandidatePriorityFunction(priorityFunction)
+
+		offerPC, err := NewAPI(WithSettingEngine(offerSE)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		answerPC, err := NewAPI(WithSettingEngine(answerSE)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		defer closePairNow(t, offerPC, answerPC)
+
+		var candidatesMu sync.Mutex
+		answerPC.OnICECandidate(func(c *ICECandidate) {
+			if c != nil {
+				candidatesMu.Lock()
+				answerCandidates = append(answerCandidates, *c)
+				candidatesMu.Unlock()
+			}
+		})
+
+		connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+		require.NoError(t, signalPair(offerPC, answerPC))
+		connected.Wait()
+
+		pair, err := offerPC.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+		require.NoError(t, err)
+		require.NotNil(t, pair)
+
+		candidatesMu.Lock()
+		defer candidatesMu.Unlock()
+
+		return answerCandidates, answerPC.LocalDescription().SDP, *pair.Remote
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		_, _, selectedRemote := connect(t, nil)
+		assert.Equal(t, ICECandidateTypeHost, selectedRemote.Typ)
+	})
+
+	t.Run("Inverted", func(t *testing.T) {
+		candidates, answerSDP, selectedRemote := connect(t, invertPriority)
+		assert.NotZero(t, calls.Load())
+
+		priorities := map[ICECandidateType]uint32{}
+		for _, c := range candidates {
+			priorities[c.Typ] = c.Priority
+
+			iceCandidate, err := c.ToICE()
+			require.NoError(t, err)
+			assert.Contains(t, answerSDP, iceCandidate.Marshal())
+		}
+		require.Contains(t, priorities, ICECandidateTypeHost)
+		require.Contains(t, priorities, ICECandidateTypeSrflx)
+		assert.Greater(t, priorities[ICECandidateTypeSrflx], priorities[ICECandidateTypeHost])
+
+		// The remote agent uses the signaled priorities.
+		assert.Equal(t, ICECandidateTypeSrflx, selectedRemote.Typ)
+		assert.Equal(t, answerIP, selectedRemote.Address)
+		assert.Equal(t, priorities[ICECandidateTypeSrflx], selectedRemote.Priority)
+	})
+}
+
+// interfacesCountingNet counts how often the interfaces are enumerated.
+type interfacesCountingNet struct {
+	transport.Net
+
+	calls atomic.Int32
+}
+
+func (n *interfacesCountingNet) Interfaces() ([]*transport.Interface, error) {
+	n.calls.Add(1)
+
+	return n.Net.Interfaces()
+}
+
+func TestICEGatherer_CandidateInterfaceVNet(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+	vnetNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"1.2.3.4", "1.2.3.5"}})
+	require.NoError(t, err)
+	require.NoError(t, wan.AddNet(vnetNet))
+	require.NoError(t, wan.Start())
+	defer func() {
+		assert.NoError(t, wan.Stop())
+	}()
+
+	countingNet := &interfacesCountingNet{Net: vnetNet}
+	se := SettingEngine{}
+	se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+	se.SetNet(countingNet)
+	var intfs []string
+	se.SetCandidatePriorityFunction(func(_ ICECandidateType, _, intf string, defaultPriority uint32) uint32 {
+		intfs = append(intfs, intf)
+
+		return defaultPriority
+	})
+
+	gatherer, err := NewAPI(WithSettingEngine(se)).NewICEGatherer(ICEGatherOptions{})
+	require.NoError(t, err)
+
+	gatherFinished := make(chan struct{})
+	gatherer.OnLocalCandidate(func(candidate *ICECandidate) {
+		if candidate == nil {
+			close(gatherFinished)
+		}
+	})
+	require.NoError(t, gatherer.Gather())
+	<-gatherFinished
+	assert.Equal(t, []string{"eth0", "eth0"}, intfs)
+
+	// The interfaces are only enumerated again by the next gather
+	calls := countingNet.calls.Load()
+	candidates, err := gatherer.getAgent().GetLocalCandidates()
+	require.NoError(t, err)
+	require.Len(t, candidates, 2)
+	for _, candidate := range candidates {
+		assert.Equal(t, "eth0", gatherer.candidateInterface(candidate))
+	}
+	assert.Equal(t, calls, countingNet.calls.Load())
+
+	assert.NoError(t, gatherer.Close())
+}
+
+func TestICEGatherer_RelayProtocolTCP(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// vnet doesn't support TCP, the TURN server listens on the loopback interface
+	turnListener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	authKey := turn.GenerateAuthKey("user", "pion.ly", "pass")
+	turnServer, err := turn.NewServer(turn.ServerConfig{
+		Realm: "pion.ly",
+		AuthHandler: func(u, r string, _ net.Addr) ([]byte, bool) {
+			if u == "user" && r == "pion.ly" {
+				return authKey, true
+			}
+
+			return nil, false
+		},
+		ListenerConfigs: []turn.ListenerConfig{
+			{
+				Listener: turnListener,
+				RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+					RelayAddress: net.ParseIP("127.0.0.1"),
+					Address:      "127.0.0.1",
+				},
+			},
+		},
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, turnServer.Close())
+	}()
+
+	se := SettingEngine{}
+	se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4, NetworkTypeTCP4})
+	pc, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{
+		ICEServers: []ICEServer{{
+			URLs:       []string{fmt.Sprintf("turn:%s?transport=tcp", turnListener.Addr())},
+			Username:   "user",
+			Credential: "pass",
+		}},
+		ICETransportPolicy: ICETransportPolicyRelay,
+	})
+	require.NoError(t, err)
+
+	var candidatesMu sync.Mutex
+	var candidates []ICECandidate
+	pc.OnICECandidate(func(c *ICECandidate) {
+		if c != nil {
+			candidatesMu.Lock()
+			candidates = append(candidates, *c)
+			candidatesMu.Unlock()
+		}
+	})
+
+	_, err = pc.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	gatheringComplete := GatheringCompletePromise(pc)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	<-gatheringComplete
+
+	candidatesMu.Lock()
+	require.NotEmpty(t, candidates)
+	for _, c := range candidates {
+		assert.Equal(t, ICECandidateTypeRelay, c.Typ)
+		assert.Equal(t, "tcp", c.RelayProtocol)
+
+		// The relay protocol isn't part of the candidate attribute
+		parsed, parseErr := ice.UnmarshalCandidate(c.ToJSON().Candidate)
+		require.NoError(t, parseErr)
+		assert.Equal(t, c.Address, parsed.Address())
+	}
+	candidatesMu.Unlock()
+
+	var relayStats []ICECandidateStats
+	for _, s := range pc.GetStats() {
+		if stats, ok := s.(ICECandidateStats); ok && stats.Type == StatsTypeLocalCandidate {
+			relayStats = append(relayStats, stats)
+		}
+	}
+	require.NotEmpty(t, relayStats)
+	for _, stats := range relayStats {
+		assert.Equal(t, ICECandidateTypeRelay, stats.CandidateType)
+		assert.Equal(t, "tcp", stats.RelayProtocol)
+	}
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestInterfaceNetworkType(t *testing.T) {
+	for name, networkType := range map[string]string{
+		"":        "",
+		"eth0":    "ethernet",
+		"enp3s0":  "ethernet",
+		"wlan0":   "wifi",
+		"wlp2s0":  "wifi",
+		"rmnet0":  "cellular",
+		"pdp_ip0": "cellular",
+		"wg0":     "vpn",
+		"utun3":   "vpn",
+		"bnep0":   "bluetooth",
+		"en0":     "unknown",
+		"lo":      "unknown",
+		"docker0": "unknown",
+	} {
+		assert.Equal(t, networkType, interfaceNetworkType(name), name)
+	}
+}
+
+func TestICEGatherer_NetworkInterfaceVNet(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, wan := createVNetPair(t, nil)
+
+	var candidatesMu sync.Mutex
+	var candidates []ICECandidate
+	pcOffer.OnICECandidate(func(c *ICECandidate) {
+		if c != nil {
+			candidatesMu.Lock()
+			candidates = append(candidates, *c)
+			candidatesMu.Unlock()
+		}
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	_, err := pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	candidatesMu.Lock()
+	require.NotEmpty(t, candidates)
+	for _, c := range candidates {
+		assert.Equal(t, "eth0", c.NetworkInterface)
+		assert.Equal(t, "ethernet", c.NetworkType)
+		assert.Empty(t, c.RelayProtocol)
+	}
+	candidatesMu.Unlock()
+
+	localCandidates, remoteCandidates := 0, 0
+	for _, s := range pcOffer.GetStats() {
+		stats, ok := s.(ICECandidateStats)
+		switch {
+		case !ok:
+		case stats.Type == StatsTypeLocalCandidate:
+			localCandidates++
+			assert.Equal(t, "eth0", stats.NetworkInterface)
+			assert.Equal(t, "ethernet", stats.NetworkType)
+		case stats.Type == StatsTypeRemoteCandidate:
+			remoteCandidates++
+			assert.Empty(t, stats.NetworkInterface)
+			assert.Empty(t, stats.NetworkType)
+		}
+	}
+	assert.Positive(t, localCandidates)
+	assert.Positive(t, remoteCandidates)
+
+	// An ICE restart replaces the candidates, the interfaces of the previous ones are forgotten
+	previous := map[string]bool{}
+	pcOffer.iceGatherer.localCandidateInterfaces.Range(func(id, _ any) bool {
+		previous[id.(string)] = true //nolint:forcetypeassert
+
+		return true
+	})
+	require.NotEmpty(t, previous)
+	offer, err := pcOffer.CreateOffer(&OfferOptions{ICERestart: true})
+	require.NoError(t, err)
+	gatheringComplete := GatheringCompletePromise(pcOffer)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	<-gatheringComplete
+	pcOffer.iceGatherer.localCandidateInterfaces.Range(func(id, _ any) bool {
+		assert.False(t, previous[id.(string)]) //nolint:forcetypeassert
+
+		return true
+	})
+
+	closePairNow(t, pcOffer, pcAnswer)
+	assert.NoError(t, wan.Stop())
+}
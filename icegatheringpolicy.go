@@ -0,0 +1,47 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import "github.com/pion/ice/v4"
+
+// ICEGatheringPolicy controls whether the ICE agent keeps gathering
+// candidates after the initial gathering finished.
+type ICEGatheringPolicy int
+
+const (
+	// ICEGatheringPolicyGatherOnce gathers candidates once and then
+	// completes gathering.
+	ICEGatheringPolicyGatherOnce ICEGatheringPolicy = iota
+
+	// ICEGatheringPolicyGatherContinually keeps watching the network
+	// interfaces, gathers candidates for addresses that appear and prunes
+	// the candidates of addresses that disappear.
+	// Gathering never completes in this mode.
+	ICEGatheringPolicyGatherContinually
+)
+
+// This is done this way because of a linter.
+const (
+	iceGatheringPolicyGatherOnceStr        = "gather_once"
+	iceGatheringPolicyGatherContinuallyStr = "gather_continually"
+)
+
+func (t ICEGatheringPolicy) String() string {
+	switch t {
+	case ICEGatheringPolicyGatherOnce:
+		return iceGatheringPolicyGatherOnceStr
+	case ICEGatheringPolicyGatherContinually:
+		return iceGatheringPolicyGatherContinuallyStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+func (t ICEGatheringPolicy) toICE() ice.ContinualGatheringPolicy {
+	if t == ICEGatheringPolicyGatherContinually {
+		return ice.GatherContinually
+	}
+
+	return ice.GatherOnce
+}
@@ -0,0 +1,35 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/ice/v4"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestICEGatheringPolicy_String(t *testing.T) {
+	testCases := []struct {
+		policy         ICEGatheringPolicy
+		expectedString string
+	}{
+		{ICEGatheringPolicyGatherOnce, "gather_once"},
+		{ICEGatheringPolicyGatherContinually, "gather_continually"},
+		{ICEGatheringPolicy(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.policy.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
+
+func TestICEGatheringPolicy_toICE(t *testing.T) {
+	assert.Equal(t, ice.GatherOnce, ICEGatheringPolicyGatherOnce.toICE())
+	assert.Equal(t, ice.GatherContinually, ICEGatheringPolicyGatherContinually.toICE())
+}
@@ -0,0 +1,373 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v4"
+)
+
+// ICEServerGatheringStatus is the status of the gathering of candidates from an ICE server URL.
+type ICEServerGatheringStatus int
+
+const (
+	// ICEServerGatheringStatusUnknown is the enum's zero-value.
+	ICEServerGatheringStatusUnknown ICEServerGatheringStatus = iota
+
+	// ICEServerGatheringStatusPending indicates no candidate was gathered from the
+	// server yet, while gathering is still in progress.
+	ICEServerGatheringStatusPending
+
+	// ICEServerGatheringStatusSucceeded indicates at least one candidate was gathered
+	// from the server.
+	ICEServerGatheringStatusSucceeded
+
+	// ICEServerGatheringStatusFailed indicates gathering completed without any
+	// candidate from the server, it was unreachable or refused the requests.
+	ICEServerGatheringStatusFailed
+)
+
+func (s ICEServerGatheringStatus) String() string {
+	switch s {
+	case ICEServerGatheringStatusPending:
+		return "pending"
+	case ICEServerGatheringStatusSucceeded:
+		return "succeeded"
+	case ICEServerGatheringStatusFailed:
+		return "failed"
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// ICEServerGatheringProgress is the progress of the gathering of candidates from one
+// ICE server URL.
+type ICEServerGatheringProgress struct {
+	// URL is the URL of the server, without its credentials.
+	URL string
+	// Status tells if candidates were gathered from the server.
+	Status ICEServerGatheringStatus
+	// Duration is the time from the start of gathering to the first candidate of the
+	// server if it succeeded, to the end of gathering if it failed, and to now while
+	// it is pending.
+	Duration time.Duration
+	// Candidates is the number of candidates gathered from the server, server reflexive
+	// candidates for STUN servers, and relay and server reflexive ones for TURN servers.
+	Candidates int
+}
+
+// ICEGatheringProgress is a snapshot of the gathering of local candidates, see
+// ICEGatherer.GatheringProgress.
+type ICEGatheringProgress struct {
+	// State is the state of the ICEGatherer.
+	State ICEGathererState
+	// Duration is the time from the start of gathering to its end, or to now while it
+	// is in progress.
+	Duration time.Duration
+	// HostCandidates is the number of host candidates gathered.
+	HostCandidates int
+	// Servers are the ICE server URLs gathering uses, in the order of the configuration.
+	Servers []ICEServerGatheringProgress
+}
+
+// iceServerGathering follows the gathering from one ICE server URL.
+type iceServerGathering struct {
+	url      *stun.URI
+	hostPort string
+
+	firstCandidate time.Duration
+	candidates     int
+}
+
+// iceGatheringProgress follows the gathering of an ICEGatherer. The agent doesn't tell which
+// server a candidate was gathered from, so the Net of the agent reports which local address
+// reached which server, and the server reflexive and relay candidates are attributed to a
+// server by their related address, which is the local address they were gathered on.
+type iceGatheringProgress struct {
+	// gathering is set from the start to the end of gathering, the Net only reports then
+	gathering atomic.Bool
+
+	mu             sync.Mutex
+	startedAt      time.Time
+	duration       time.Duration
+	hostCandidates int
+	servers        []*iceServerGathering
+	// The host:port of the servers by the addresses they were resolved to, and by the local
+	// addresses that sent to them
+	serverAddresses map[string]string
+	localAddresses  map[string]string
+}
+
+// start resets the progress for gathering from servers.
+func (p *iceGatheringProgress) start(servers []*stun.URI) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.startedAt = time.Now()
+	p.duration = 0
+	p.hostCandidates = 0
+	p.servers = make([]*iceServerGathering, 0, len(servers))
+	for _, server := range servers {
+		p.servers = append(p.servers, &iceServerGathering{
+			url:      server,
+			hostPort: net.JoinHostPort(server.Host, strconv.Itoa(server.Port)),
+		})
+	}
+	p.serverAddresses = map[string]string{}
+	p.localAddresses = map[string]string{}
+	p.gathering.Store(true)
+}
+
+// complete marks the end of gathering, the servers without candidates have failed.
+func (p *iceGatheringProgress) complete() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.gathering.Swap(false) {
+		return
+	}
+	p.duration = time.Since(p.startedAt)
+	p.serverAddresses, p.localAddresses = nil, nil
+}
+
+// resolved is reported by the Net when hostPort is resolved to addr.
+func (p *iceGatheringProgress) resolved(hostPort string, addr net.Addr) {
+	if !p.gathering.Load() || addr == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, server := range p.servers {
+		if server.hostPort == hostPort && p.serverAddresses != nil {
+			p.serverAddresses[addr.String()] = hostPort
+
+			return
+		}
+	}
+}
+
+// sent is reported by the Net when local sends to remote.
+func (p *iceGatheringProgress) sent(local, remote net.Addr) {
+	if !p.gathering.Load() || local == nil || remote == nil {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if hostPort, ok := p.serverAddresses[remote.String()]; ok {
+		p.localAddresses[local.String()] = hostPort
+	}
+}
+
+// candidate counts a local candidate, and attributes it to its server.
+func (p *iceGatheringProgress) candidate(candidate ice.Candidate) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if candidate.Type() == ice.CandidateTypeHost {
+		p.hostCandidates++
+
+		return
+	}
+
+	if server := p.serverOf(candidate); server != nil {
+		if server.candidates == 0 {
+			server.firstCandidate = time.Since(p.startedAt)
+		}
+		server.candidates++
+	}
+}
+
+// serverOf returns the server candidate was gathered from, or nil if it is unknown. A candidate
+// whose local address didn't reach any server is attributed to the only server that can have
+// produced it, if there is one. p.mu must be held.
+func (p *iceGatheringProgress) serverOf(candidate ice.Candidate) *iceServerGathering {
+	var hostPort string
+	if related := candidate.RelatedAddress(); related != nil {
+		hostPort = p.localAddresses[net.JoinHostPort(related.Address, strconv.Itoa(related.Port))]
+	}
+
+	var eligible []*iceServerGathering
+	for _, server := range p.servers {
+		if iceServerGathers(server.url, candidate.Type()) && (hostPort == "" || server.hostPort == hostPort) {
+			eligible = append(eligible, server)
+		}
+	}
+	switch {
+	case len(eligible) == 1:
+		return eligible[0]
+	case len(eligible) == 0 || hostPort == "":
+		return nil
+	}
+
+	// The STUN and TURN URLs of a server both gather server reflexive candidates, they are
+	// attributed to the STUN URL
+	for _, server := range eligible {
+		if server.url.Scheme == stun.SchemeTypeSTUN {
+			return server
+		}
+	}
+
+	return eligible[0]
+}
+
+// iceServerGathers returns true if candidates of typ are gathered from url.
+func iceServerGathers(url *stun.URI, typ ice.CandidateType) bool {
+	switch typ {
+	case ice.CandidateTypeServerReflexive:
+		return (url.Scheme == stun.SchemeTypeSTUN || url.Scheme == stun.SchemeTypeTURN) && url.Proto != stun.ProtoTypeTCP
+	case ice.CandidateTypeRelay:
+		return url.Scheme == stun.SchemeTypeTURN || url.Scheme == stun.SchemeTypeTURNS
+	default:
+		return false
+	}
+}
+
+// snapshot returns the progress, state is the state of the ICEGatherer.
+func (p *iceGatheringProgress) snapshot(state ICEGathererState) ICEGatheringProgress {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	progress := ICEGatheringProgress{
+		State:          state,
+		Duration:       p.duration,
+		HostCandidates: p.hostCandidates,
+	}
+	gathering := p.gathering.Load()
+	if gathering {
+		progress.Duration = time.Since(p.startedAt)
+	}
+
+	for _, server := range p.servers {
+		serverProgress := ICEServerGatheringProgress{
+			URL:        server.url.String(),
+			Candidates: server.candidates,
+		}
+		switch {
+		case server.candidates != 0:
+			serverProgress.Status = ICEServerGatheringStatusSucceeded
+			serverProgress.Duration = server.firstCandidate
+		case gathering:
+			serverProgress.Status = ICEServerGatheringStatusPending
+			serverProgress.Duration = progress.Duration
+		default:
+			serverProgress.Status = ICEServerGatheringStatusFailed
+			serverProgress.Duration = progress.Duration
+		}
+		progress.Servers = append(progress.Servers, serverProgress)
+	}
+
+	return progress
+}
+
+// GatheringProgress returns a snapshot of the gathering of local candidates, with the
+// progress of every ICE server: whether candidates were gathered from it, how long it
+// took and how many. It tells which server holds up gathering, while
+// GatheringCompletePromise only tells when it completed.
+//
+// The candidates gathered through the UDPMux of SettingEngine.SetICEUDPMux or through
+// a proxy can't be told apart by server, they are only attributed to a server if it is
+// the only one that can have produced them.
+func (g *ICEGatherer) GatheringProgress() ICEGatheringProgress {
+	return g.progress.snapshot(g.State())
+}
+
+// gatheringNet is the Net of the agent, it reports to progress which local addresses
+// reach the ICE servers.
+type gatheringNet struct {
+	transport.Net
+	progress *iceGatheringProgress
+}
+
+func (n *gatheringNet) ResolveUDPAddr(network, address string) (*net.UDPAddr, error) {
+	addr, err := n.Net.ResolveUDPAddr(network, address)
+	if err == nil {
+		n.progress.resolved(address, addr)
+	}
+
+	return addr, err
+}
+
+func (n *gatheringNet) ResolveTCPAddr(network, address string) (*net.TCPAddr, error) {
+	addr, err := n.Net.ResolveTCPAddr(network, address)
+	if err == nil {
+		n.progress.resolved(address, addr)
+	}
+
+	return addr, err
+}
+
+func (n *gatheringNet) ListenPacket(network, address string) (net.PacketConn, error) {
+	conn, err := n.Net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &gatheringPacketConn{PacketConn: conn, progress: n.progress}, nil
+}
+
+func (n *gatheringNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	conn, err := n.Net.ListenUDP(network, locAddr)
+	if err != nil || (locAddr != nil && locAddr.IP.IsMulticast()) {
+		// The multicast DNS conns need the socket of the conn to join their group
+		return conn, err
+	}
+
+	return &gatheringUDPConn{UDPConn: conn, progress: n.progress}, nil
+}
+
+func (n *gatheringNet) DialTCP(network string, laddr, raddr *net.TCPAddr) (transport.TCPConn, error) {
+	conn, err := n.Net.DialTCP(network, laddr, raddr)
+	if err == nil {
+		n.progress.sent(conn.LocalAddr(), raddr)
+	}
+
+	return conn, err
+}
+
+type gatheringPacketConn struct {
+	net.PacketConn
+	progress *iceGatheringProgress
+}
+
+func (c *gatheringPacketConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.progress.gathering.Load() {
+		c.progress.sent(c.LocalAddr(), addr)
+	}
+
+	return c.PacketConn.WriteTo(p, addr)
+}
+
+type gatheringUDPConn struct {
+	transport.UDPConn
+	progress *iceGatheringProgress
+}
+
+func (c *gatheringUDPConn) WriteTo(p []byte, addr net.Addr) (int, error) {
+	if c.progress.gathering.Load() {
+		c.progress.sent(c.LocalAddr(), addr)
+	}
+
+	return c.UDPConn.WriteTo(p, addr)
+}
+
+func (c *gatheringUDPConn) WriteToUDP(p []byte, addr *net.UDPAddr) (int, error) {
+	if c.progress.gathering.Load() {
+		c.progress.sent(c.LocalAddr(), addr)
+	}
+
+	return c.UDPConn.WriteToUDP(p, addr)
+}
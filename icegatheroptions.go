@@ -8,4 +8,7 @@ type ICEGatherOptions struct {
 	ICEServers           []ICEServer
 	ICEGatherPolicy      ICETransportPolicy
 	ICECandidatePoolSize uint8
+
+	// ICECandidateTypes restricts the types of the gathered candidates, all of them if empty.
+	ICECandidateTypes []ICECandidateType
 }
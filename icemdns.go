@@ -0,0 +1,214 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"context"
+	"net/netip"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/mdns/v2"
+	"github.com/pion/transport/v4"
+	"github.com/pion/transport/v4/stdnet"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+)
+
+// ICEMulticastDNSResolver resolves the mDNS host name of a remote ICE candidate,
+// see SettingEngine.SetICEMulticastDNSResolver. It must return once ctx is done.
+type ICEMulticastDNSResolver func(ctx context.Context, name string) (netip.Addr, error)
+
+// iceMulticastDNSResolver resolves the host names of remote mDNS candidates before they
+// are added to the ICE agent, so failures can be reported with OnICECandidateError.
+type iceMulticastDNSResolver struct {
+	resolver      ICEMulticastDNSResolver
+	disabled      bool
+	timeout       time.Duration
+	settingEngine *SettingEngine
+	loggerFactory logging.LoggerFactory
+	log           logging.LeveledLogger
+
+	// queries bounds the outstanding resolutions
+	queries chan struct{}
+
+	ctx    context.Context //nolint:containedctx
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu   sync.Mutex
+	conn *mdns.Conn
+}
+
+func newICEMulticastDNSResolver(
+	settingEngine *SettingEngine,
+	loggerFactory logging.LoggerFactory,
+) *iceMulticastDNSResolver {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &iceMulticastDNSResolver{
+		resolver:      settingEngine.candidates.MulticastDNSResolver,
+		disabled:      settingEngine.candidates.MulticastDNSMode == ice.MulticastDNSModeDisabled,
+		timeout:       settingEngine.getICEMulticastDNSTimeout(),
+		settingEngine: settingEngine,
+		loggerFactory: loggerFactory,
+		log:           loggerFactory.NewLogger("ice_mdns"),
+		queries:       make(chan struct{}, iceMulticastDNSMaxQueries),
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// isMulticastDNSCandidate returns true if the address of the candidate is a mDNS host name.
+func isMulticastDNSCandidate(candidate *ICECandidate) bool {
+	return candidate != nil && candidate.Typ == ICECandidateTypeHost && strings.HasSuffix(candidate.Address, ".local")
+}
+
+// resolve resolves the host name of candidate in the background. add is called with the
+// resolved candidate, onError if it can't be resolved.
+func (r *iceMulticastDNSResolver) resolve(
+	candidate ICECandidate,
+	add func(ICECandidate) error,
+	onError func(ICECandidateError),
+) {
+	// The caller may hold locks the error handler needs
+	reportError := func(err error) {
+		r.wg.Add(1)
+		go func() {
+			defer r.wg.Done()
+			onError(ICECandidateError{Candidate: candidate, Err: err})
+		}()
+	}
+
+	if r.resolver == nil && r.disabled {
+		r.log.Warnf("Remote mDNS candidate added, but mDNS is disabled: (%s)", candidate.Address)
+		reportError(errICEMulticastDNSDisabled)
+
+		return
+	}
+
+	select {
+	case r.queries <- struct{}{}:
+	default:
+		reportError(errICEMulticastDNSTooManyQueries)
+
+		return
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		defer func() { <-r.queries }()
+
+		ctx, cancel := context.WithTimeout(r.ctx, r.timeout)
+		defer cancel()
+
+		addr, err := r.lookup(ctx, candidate.Address)
+		if r.ctx.Err() != nil {
+			return
+		}
+		if err != nil {
+			r.log.Warnf("Failed to resolve mDNS candidate %s: %v", candidate.Address, err)
+			onError(ICECandidateError{Candidate: candidate, Err: err})
+
+			return
+		}
+
+		candidate.Address = addr.Unmap().String()
+		if err = add(candidate); err != nil {
+			r.log.Warnf("Failed to add mDNS candidate %s: %v", candidate.Address, err)
+		}
+	}()
+}
+
+func (r *iceMulticastDNSResolver) lookup(ctx context.Context, name string) (netip.Addr, error) {
+	if r.resolver != nil {
+		return r.resolver(ctx, name)
+	}
+
+	conn, err := r.getConn()
+	if err != nil {
+		return netip.Addr{}, err
+	}
+
+	_, addr, err := conn.QueryAddr(ctx, name)
+
+	return addr, err
+}
+
+// getConn returns the query only mDNS connection, it is opened by the first query.
+func (r *iceMulticastDNSResolver) getConn() (*mdns.Conn, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn != nil {
+		return r.conn, nil
+	} else if err := r.ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	var err error
+	netTransport := r.settingEngine.net
+	if netTransport == nil {
+		if netTransport, err = stdnet.NewNet(); err != nil {
+			return nil, err
+		}
+	}
+
+	var pktConnV4 *ipv4.PacketConn
+	if conn, err4 := listenMulticastDNS(netTransport, "udp4", mdns.DefaultAddressIPv4); err4 == nil {
+		pktConnV4 = ipv4.NewPacketConn(conn)
+	} else {
+		err = err4
+	}
+
+	var pktConnV6 *ipv6.PacketConn
+	if conn, err6 := listenMulticastDNS(netTransport, "udp6", mdns.DefaultAddressIPv6); err6 == nil {
+		pktConnV6 = ipv6.NewPacketConn(conn)
+	} else if pktConnV4 == nil {
+		return nil, err
+	}
+
+	conn, err := mdns.Server(pktConnV4, pktConnV6, &mdns.Config{
+		IncludeLoopback: r.settingEngine.candidates.IncludeLoopbackCandidate,
+		LoggerFactory:   r.loggerFactory,
+	})
+	if err != nil {
+		return nil, err
+	}
+	r.conn = conn
+
+	return conn, nil
+}
+
+func listenMulticastDNS(netTransport transport.Net, network, address string) (transport.UDPConn, error) {
+	addr, err := netTransport.ResolveUDPAddr(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return netTransport.ListenUDP(network, addr)
+}
+
+// close cancels the outstanding resolutions, wait also waits for them to return.
+func (r *iceMulticastDNSResolver) close(wait bool) error {
+	r.cancel()
+	if wait {
+		r.wg.Wait()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.conn == nil {
+		return nil
+	}
+
+	return r.conn.Close()
+}
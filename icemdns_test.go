@@ -0,0 +1,169 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestICEMulticastDNSResolver_Connection(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	errUnknownName := errors.New("unknown name")
+	var namesLock sync.Mutex
+	names := map[string]netip.Addr{}
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	settingEngine.SetICEMulticastDNSResolver(func(_ context.Context, name string) (netip.Addr, error) {
+		namesLock.Lock()
+		defer namesLock.Unlock()
+
+		if addr, ok := names[name]; ok {
+			return addr, nil
+		}
+
+		return netip.Addr{}, errUnknownName
+	})
+
+	pcOffer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	candidateErrors := make(chan ICECandidateError, 1)
+	pcAnswer.OnICECandidateError(func(candidateError ICECandidateError) {
+		candidateErrors <- candidateError
+	})
+
+	// The answerer only learns the candidates of the offerer under a mDNS name, and one
+	// name that can't be resolved
+	addedUnknown := false
+	pcOffer.OnICECandidate(func(candidate *ICECandidate) {
+		if candidate == nil || candidate.Typ != ICECandidateTypeHost || candidate.Protocol != ICEProtocolUDP {
+			return
+		}
+
+		addr, parseErr := netip.ParseAddr(candidate.Address)
+		if parseErr != nil {
+			return
+		}
+
+		if !addedUnknown {
+			addedUnknown = true
+			unknown := *candidate
+			unknown.Address = "unknown.local"
+			assert.NoError(t, pcAnswer.AddICECandidate(unknown.ToJSON()))
+		}
+
+		namesLock.Lock()
+		name := fmt.Sprintf("pion-%d.local", len(names))
+		names[name] = addr
+		namesLock.Unlock()
+
+		renamed := *candidate
+		renamed.Address = name
+		assert.NoError(t, pcAnswer.AddICECandidate(renamed.ToJSON()))
+	})
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcAnswer.SetLocalDescription(answer))
+	require.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	candidateError := <-candidateErrors
+	assert.Equal(t, "unknown.local", candidateError.Candidate.Address)
+	assert.ErrorIs(t, candidateError.Err, errUnknownName)
+
+	connected.Wait()
+
+	pair, err := pcAnswer.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+	require.NoError(t, err)
+	require.NotNil(t, pair)
+	assert.Equal(t, ICECandidateTypeHost, pair.Remote.Typ)
+
+	namesLock.Lock()
+	var resolved []string
+	for _, addr := range names {
+		resolved = append(resolved, addr.String())
+	}
+	namesLock.Unlock()
+	assert.Contains(t, resolved, pair.Remote.Address)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestICEMulticastDNSResolver_Bounded(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := &SettingEngine{}
+	settingEngine.SetICEMulticastDNSResolver(func(ctx context.Context, _ string) (netip.Addr, error) {
+		<-ctx.Done()
+
+		return netip.Addr{}, ctx.Err()
+	})
+	resolver := newICEMulticastDNSResolver(settingEngine, logging.NewDefaultLoggerFactory())
+
+	candidateErrors := make(chan ICECandidateError, iceMulticastDNSMaxQueries+1)
+	add := func(ICECandidate) error {
+		assert.Fail(t, "candidate must not be resolved")
+
+		return nil
+	}
+	onError := func(candidateError ICECandidateError) {
+		candidateErrors <- candidateError
+	}
+
+	for i := range iceMulticastDNSMaxQueries + 1 {
+		candidate := ICECandidate{Typ: ICECandidateTypeHost, Address: fmt.Sprintf("pion-%d.local", i)}
+		resolver.resolve(candidate, add, onError)
+	}
+
+	// The query past the limit fails right away, the others are canceled by close
+	candidateError := <-candidateErrors
+	assert.Equal(t, fmt.Sprintf("pion-%d.local", iceMulticastDNSMaxQueries), candidateError.Candidate.Address)
+	assert.ErrorIs(t, candidateError.Err, errICEMulticastDNSTooManyQueries)
+
+	assert.NoError(t, resolver.close(true))
+	assert.Empty(t, candidateErrors)
+
+	// Without a resolver and with mDNS disabled candidates fail right away
+	settingEngine = &SettingEngine{}
+	settingEngine.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	resolver = newICEMulticastDNSResolver(settingEngine, logging.NewDefaultLoggerFactory())
+	resolver.resolve(ICECandidate{Typ: ICECandidateTypeHost, Address: "pion.local"}, add, onError)
+
+	candidateError = <-candidateErrors
+	assert.ErrorIs(t, candidateError.Err, errICEMulticastDNSDisabled)
+	assert.NoError(t, resolver.close(true))
+}
@@ -0,0 +1,37 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// ICENominationMode controls how eagerly the controlling ICE agent
+// nominates a candidate pair.
+type ICENominationMode int
+
+const (
+	// ICENominationModeRegular waits for the candidate type acceptance
+	// minimum wait before nominating, which gives higher priority
+	// candidate pairs a chance to succeed first.
+	ICENominationModeRegular ICENominationMode = iota
+
+	// ICENominationModeAggressive nominates the first candidate pair that
+	// succeeds. This reduces connection setup latency at the cost of
+	// possibly selecting a less preferred candidate pair.
+	ICENominationModeAggressive
+)
+
+// This is done this way because of a linter.
+const (
+	iceNominationModeRegularStr    = "regular"
+	iceNominationModeAggressiveStr = "aggressive"
+)
+
+func (t ICENominationMode) String() string {
+	switch t {
+	case ICENominationModeRegular:
+		return iceNominationModeRegularStr
+	case ICENominationModeAggressive:
+		return iceNominationModeAggressiveStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
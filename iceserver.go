@@ -1,15 +1,11 @@
 // SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
 // SPDX-License-Identifier: MIT
 
-//go:build !js
-
 package webrtc
 
 import (
 	"encoding/json"
-
-	"github.com/pion/stun/v3"
-	"github.com/pion/webrtc/v4/pkg/rtcerr"
+	"fmt"
 )
 
 // ICEServer describes a single STUN and TURN server that can be used by
@@ -21,56 +17,21 @@ type ICEServer struct {
 	CredentialType ICECredentialType `json:"credentialType,omitempty"`
 }
 
-func (s ICEServer) parseURL(i int) (*stun.URI, error) {
-	return stun.ParseURI(s.URLs[i])
+// ICEServerURLError tells which of the URLs of an ICEServer is invalid.
+type ICEServerURLError struct {
+	// Index is the index of the URL in ICEServer.URLs.
+	Index int
+	URL   string
+	Err   error
 }
 
-func (s ICEServer) validate() error {
-	_, err := s.urls()
-
-	return err
+func (e *ICEServerURLError) Error() string {
+	return fmt.Sprintf("urls[%d] %q: %v", e.Index, e.URL, e.Err)
 }
 
-func (s ICEServer) urls() ([]*stun.URI, error) { //nolint:cyclop
-	urls := []*stun.URI{}
-
-	for i := range s.URLs {
-		url, err := s.parseURL(i)
-		if err != nil {
-			return nil, &rtcerr.InvalidAccessError{Err: err}
-		}
-
-		if url.Scheme == stun.SchemeTypeTURN || url.Scheme == stun.SchemeTypeTURNS {
-			// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3.2)
-			if s.Username == "" || s.Credential == nil {
-				return nil, &rtcerr.InvalidAccessError{Err: ErrNoTurnCredentials}
-			}
-			url.Username = s.Username
-
-			switch s.CredentialType {
-			case ICECredentialTypePassword:
-				// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3.3)
-				password, ok := s.Credential.(string)
-				if !ok {
-					return nil, &rtcerr.InvalidAccessError{Err: ErrTurnCredentials}
-				}
-				url.Password = password
-
-			case ICECredentialTypeOauth:
-				// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3.4)
-				if _, ok := s.Credential.(OAuthCredential); !ok {
-					return nil, &rtcerr.InvalidAccessError{Err: ErrTurnCredentials}
-				}
-
-			default:
-				return nil, &rtcerr.InvalidAccessError{Err: ErrTurnCredentials}
-			}
-		}
-
-		urls = append(urls, url)
-	}
-
-	return urls, nil
+// Unwrap returns the reason the URL is invalid.
+func (e *ICEServerURLError) Unwrap() error {
+	return e.Err
 }
 
 func iceserverUnmarshalUrls(val any) (*[]string, error) {
@@ -82,7 +43,7 @@ func iceserverUnmarshalUrls(val any) (*[]string, error) {
 	for idx, url := range s {
 		out[idx], ok = url.(string)
 		if !ok {
-			return nil, errInvalidICEServer
+			return nil, fmt.Errorf("%w: urls[%d] is not a string: %v", errInvalidICEServer, idx, url)
 		}
 	}
 
@@ -0,0 +1,158 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/pion/stun/v3"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+)
+
+func (s ICEServer) parseURL(i int) (*stun.URI, error) {
+	return parseICEServerURL(s.URLs[i])
+}
+
+// parseICEServerURL parses raw with stun.ParseURI, after rewriting the variants browsers
+// accept as well: a "//" after the scheme, an empty query or a trailing '&', an upper case
+// transport and a transport on STUN URLs, which is ignored.
+func parseICEServerURL(raw string) (*stun.URI, error) {
+	scheme, rest, found := strings.Cut(raw, ":")
+	if !found {
+		return stun.ParseURI(raw)
+	}
+
+	address, rawQuery, _ := strings.Cut(strings.TrimPrefix(rest, "//"), "?")
+	query, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return nil, stun.ErrInvalidQuery
+	}
+
+	if transports, ok := query["transport"]; ok {
+		if len(transports) != 1 {
+			return nil, stun.ErrInvalidQuery
+		}
+		transport := strings.ToLower(transports[0])
+		query.Set("transport", transport)
+
+		switch stun.NewSchemeType(strings.ToLower(scheme)) {
+		case stun.SchemeTypeSTUN, stun.SchemeTypeSTUNS:
+			if stun.NewProtoType(transport) == stun.ProtoTypeUnknown {
+				return nil, stun.ErrProtoType
+			}
+			query.Del("transport")
+		default:
+		}
+	}
+
+	normalized := scheme + ":" + address
+	if len(query) != 0 {
+		normalized += "?" + query.Encode()
+	}
+
+	return stun.ParseURI(normalized)
+}
+
+func (s ICEServer) validate() error {
+	_, err := s.urls()
+
+	return err
+}
+
+// urls returns the parsed URLs, or the error of the first one that is invalid.
+func (s ICEServer) urls() ([]*stun.URI, error) {
+	urls := []*stun.URI{}
+
+	for i := range s.URLs {
+		url, err := s.url(i)
+		if err != nil {
+			return nil, err
+		}
+
+		urls = append(urls, url)
+	}
+
+	return urls, nil
+}
+
+// validateURLs returns the errors of all the URLs.
+func (s ICEServer) validateURLs() []error {
+	var errs []error
+	for i := range s.URLs {
+		if _, err := s.url(i); err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errs
+}
+
+// url parses the URL at index i and adds the credentials to TURN URLs. The errors are an
+// ICEServerURLError wrapped in a rtcerr.InvalidAccessError.
+func (s ICEServer) url(i int) (*stun.URI, error) {
+	url, err := s.parseURL(i)
+	if err == nil {
+		err = s.addCredentials(url)
+	}
+	if err != nil {
+		return nil, &rtcerr.InvalidAccessError{Err: &ICEServerURLError{Index: i, URL: s.URLs[i], Err: err}}
+	}
+
+	return url, nil
+}
+
+func (s ICEServer) addCredentials(url *stun.URI) error {
+	if url.Scheme != stun.SchemeTypeTURN && url.Scheme != stun.SchemeTypeTURNS {
+		return nil
+	}
+
+	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3.2)
+	if s.Username == "" || s.Credential == nil {
+		return ErrNoTurnCredentials
+	}
+	url.Username = s.Username
+
+	switch s.CredentialType {
+	case ICECredentialTypePassword:
+		// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3.3)
+		password, ok := s.Credential.(string)
+		if !ok {
+			return ErrTurnCredentials
+		}
+		url.Password = password
+
+	case ICECredentialTypeOauth:
+		// https://www.w3.org/TR/webrtc/#set-the-configuration (step #11.3.4)
+		if _, ok := s.Credential.(OAuthCredential); !ok {
+			return ErrTurnCredentials
+		}
+
+	default:
+		return ErrTurnCredentials
+	}
+
+	return nil
+}
+
+// oauthTURNURLs returns the TURN URLs of the server if it has OAuth credentials. The TURN
+// client of the ICE Agent only supports the long-term credential mechanism, so no relay
+// candidates can be gathered from them.
+func (s ICEServer) oauthTURNURLs() []string {
+	if s.CredentialType != ICECredentialTypeOauth {
+		return nil
+	}
+
+	var urls []string
+	for i := range s.URLs {
+		if url, err := s.parseURL(i); err == nil &&
+			(url.Scheme == stun.SchemeTypeTURN || url.Scheme == stun.SchemeTypeTURNS) {
+			urls = append(urls, s.URLs[i])
+		}
+	}
+
+	return urls
+}
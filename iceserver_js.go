@@ -12,16 +12,6 @@ import (
 	"github.com/pion/ice/v4"
 )
 
-// ICEServer describes a single STUN and TURN server that can be used by
-// the ICEAgent to establish a connection with a peer.
-type ICEServer struct {
-	URLs     []string
-	Username string
-	// Note: TURN is not supported in the WASM bindings yet
-	Credential     any
-	CredentialType ICECredentialType
-}
-
 func (s ICEServer) parseURL(i int) (*ice.URL, error) {
 	return ice.ParseURL(s.URLs[i])
 }
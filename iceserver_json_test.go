@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// The JSON handling of ICEServer is shared by all builds, these tests also run with js/wasm.
+func TestICEServer_JSON(t *testing.T) {
+	t.Run("Marshal", func(t *testing.T) {
+		testCases := []struct {
+			iceServer ICEServer
+			expected  string
+		}{
+			{
+				ICEServer{URLs: []string{"stun:stun.l.google.com:19302"}},
+				`{"credentialType":"password","urls":["stun:stun.l.google.com:19302"]}`,
+			},
+			{
+				ICEServer{
+					URLs:           []string{"turn:192.158.29.39?transport=udp"},
+					Username:       "unittest",
+					Credential:     "placeholder",
+					CredentialType: ICECredentialTypePassword,
+				},
+				`{"credential":"placeholder","credentialType":"password",` +
+					`"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest"}`,
+			},
+			{
+				ICEServer{
+					URLs:     []string{"turn:192.158.29.39?transport=udp"},
+					Username: "unittest",
+					Credential: OAuthCredential{ //nolint:gosec // not hardcoded credentials.
+						MACKey:      "WmtzanB3ZW9peFhtdm42NzUzNG0=",
+						AccessToken: "AAwg3kPHWPfvk9bDFL936wYvkoctMADzQ5VhNDgeMR3+ZlZ35byg972fW8QjpEl7bx91YLBPFsIhsxloWcXPhA==",
+					},
+					CredentialType: ICECredentialTypeOauth,
+				},
+				`{"credential":{"MACKey":"WmtzanB3ZW9peFhtdm42NzUzNG0=",` +
+					`"AccessToken":"AAwg3kPHWPfvk9bDFL936wYvkoctMADzQ5VhNDgeMR3+ZlZ35byg972fW8QjpEl7bx91YLBPFsIhsxloWcXPhA=="},` +
+					`"credentialType":"oauth","urls":["turn:192.158.29.39?transport=udp"],"username":"unittest"}`,
+			},
+		}
+
+		for i, testCase := range testCases {
+			jsonobj, err := json.Marshal(testCase.iceServer)
+			assert.NoError(t, err)
+			assert.Equal(t, testCase.expected, string(jsonobj), "testCase: %d", i)
+
+			var iceServer ICEServer
+			assert.NoError(t, json.Unmarshal(jsonobj, &iceServer))
+			assert.Equal(t, testCase.iceServer.URLs, iceServer.URLs, "testCase: %d", i)
+			assert.Equal(t, testCase.iceServer.Credential, iceServer.Credential, "testCase: %d", i)
+		}
+	})
+	t.Run("Failure", func(t *testing.T) {
+		//nolint:lll
+		testCases := [][]byte{
+			[]byte(`{"urls":"NOTAURL","username":"unittest","credential":"placeholder","credentialType":"password"}`),
+			[]byte(`{"urls":["turn:[2001:db8:1234:5678::1]?transport=udp"],"username":"unittest","credential":"placeholder","credentialType":"invalid"}`),
+			[]byte(`{"urls":["turn:[2001:db8:1234:5678::1]?transport=udp"],"username":6,"credential":"placeholder","credentialType":"password"}`),
+			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"Bad Object": true},"credentialType":"oauth"}`),
+			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"MACKey":"WmtzanB3ZW9peFhtdm42NzUzNG0=","AccessToken":null,"credentialType":"oauth"}`),
+			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"MACKey":"WmtzanB3ZW9peFhtdm42NzUzNG0=","AccessToken":null,"credentialType":"password"}`),
+			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"MACKey":1337,"AccessToken":"AAwg3kPHWPfvk9bDFL936wYvkoctMADzQ5VhNDgeMR3+ZlZ35byg972fW8QjpEl7bx91YLBPFsIhsxloWcXPhA=="},"credentialType":"oauth"}`),
+		}
+		for i, testCase := range testCases {
+			var tc ICEServer
+			err := json.Unmarshal(testCase, &tc)
+			assert.Error(t, err, "testCase: %d %v", i, string(testCase))
+		}
+	})
+}
@@ -6,14 +6,27 @@
 package webrtc
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
 	"testing"
+	"time"
 
 	"github.com/pion/stun/v3"
 	"github.com/pion/webrtc/v4/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
+func iceServerURLError(index int, url string, err error) error {
+	return &rtcerr.InvalidAccessError{Err: &ICEServerURLError{Index: index, URL: url, Err: err}}
+}
+
 func TestICEServer_validate(t *testing.T) {
 	t.Run("Success", func(t *testing.T) {
 		testCases := []struct {
@@ -61,31 +74,31 @@ func TestICEServer_validate(t *testing.T) {
 		}{
 			{ICEServer{
 				URLs: []string{"turn:192.158.29.39?transport=udp"},
-			}, &rtcerr.InvalidAccessError{Err: ErrNoTurnCredentials}},
+			}, iceServerURLError(0, "turn:192.158.29.39?transport=udp", ErrNoTurnCredentials)},
 			{ICEServer{
 				URLs:           []string{"turn:192.158.29.39?transport=udp"},
 				Username:       "unittest",
 				Credential:     false,
 				CredentialType: ICECredentialTypePassword,
-			}, &rtcerr.InvalidAccessError{Err: ErrTurnCredentials}},
+			}, iceServerURLError(0, "turn:192.158.29.39?transport=udp", ErrTurnCredentials)},
 			{ICEServer{
 				URLs:           []string{"turn:192.158.29.39?transport=udp"},
 				Username:       "unittest",
 				Credential:     false,
 				CredentialType: ICECredentialTypeOauth,
-			}, &rtcerr.InvalidAccessError{Err: ErrTurnCredentials}},
+			}, iceServerURLError(0, "turn:192.158.29.39?transport=udp", ErrTurnCredentials)},
 			{ICEServer{
 				URLs:           []string{"turn:192.158.29.39?transport=udp"},
 				Username:       "unittest",
 				Credential:     false,
 				CredentialType: ICECredentialTypePassword,
-			}, &rtcerr.InvalidAccessError{Err: ErrTurnCredentials}},
+			}, iceServerURLError(0, "turn:192.158.29.39?transport=udp", ErrTurnCredentials)},
 			{ICEServer{
-				URLs:           []string{"stun:google.de?transport=udp"},
+				URLs:           []string{"stun:google.de?foo=bar"},
 				Username:       "unittest",
 				Credential:     false,
 				CredentialType: ICECredentialTypeOauth,
-			}, &rtcerr.InvalidAccessError{Err: stun.ErrSTUNQuery}},
+			}, iceServerURLError(0, "stun:google.de?foo=bar", stun.ErrSTUNQuery)},
 		}
 
 		for i, testCase := range testCases {
@@ -97,23 +110,6 @@ func TestICEServer_validate(t *testing.T) {
 			)
 		}
 	})
-	t.Run("JsonFailure", func(t *testing.T) {
-		//nolint:lll
-		testCases := [][]byte{
-			[]byte(`{"urls":"NOTAURL","username":"unittest","credential":"placeholder","credentialType":"password"}`),
-			[]byte(`{"urls":["turn:[2001:db8:1234:5678::1]?transport=udp"],"username":"unittest","credential":"placeholder","credentialType":"invalid"}`),
-			[]byte(`{"urls":["turn:[2001:db8:1234:5678::1]?transport=udp"],"username":6,"credential":"placeholder","credentialType":"password"}`),
-			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"Bad Object": true},"credentialType":"oauth"}`),
-			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"MACKey":"WmtzanB3ZW9peFhtdm42NzUzNG0=","AccessToken":null,"credentialType":"oauth"}`),
-			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"MACKey":"WmtzanB3ZW9peFhtdm42NzUzNG0=","AccessToken":null,"credentialType":"password"}`),
-			[]byte(`{"urls":["turn:192.158.29.39?transport=udp"],"username":"unittest","credential":{"MACKey":1337,"AccessToken":"AAwg3kPHWPfvk9bDFL936wYvkoctMADzQ5VhNDgeMR3+ZlZ35byg972fW8QjpEl7bx91YLBPFsIhsxloWcXPhA=="},"credentialType":"oauth"}`),
-		}
-		for i, testCase := range testCases {
-			var tc ICEServer
-			err := json.Unmarshal(testCase, &tc)
-			assert.Error(t, err, "testCase: %d %v", i, string(testCase))
-		}
-	})
 }
 
 func TestICEServerZeroValue(t *testing.T) {
@@ -124,3 +120,148 @@ func TestICEServerZeroValue(t *testing.T) {
 	}
 	assert.Equal(t, server.CredentialType, ICECredentialTypePassword)
 }
+
+func TestICEServer_oauthTURNURLs(t *testing.T) {
+	oauthCredential := OAuthCredential{ //nolint:gosec // not hardcoded credentials.
+		MACKey:      "WmtzanB3ZW9peFhtdm42NzUzNG0=",
+		AccessToken: "AAwg3kPHWPfvk9bDFL936wYvkoctMADzQ5VhNDgeMR3+ZlZ35byg972fW8QjpEl7bx91YLBPFsIhsxloWcXPhA==",
+	}
+
+	assert.Equal(t, []string{"turn:192.158.29.39?transport=udp"}, ICEServer{
+		URLs:           []string{"stun:192.158.29.39", "turn:192.158.29.39?transport=udp"},
+		Username:       "unittest",
+		Credential:     oauthCredential,
+		CredentialType: ICECredentialTypeOauth,
+	}.oauthTURNURLs())
+	assert.Empty(t, ICEServer{
+		URLs:           []string{"stun:192.158.29.39"},
+		CredentialType: ICECredentialTypeOauth,
+	}.oauthTURNURLs())
+	assert.Empty(t, ICEServer{
+		URLs:           []string{"turn:192.158.29.39?transport=udp"},
+		Username:       "unittest",
+		Credential:     "placeholder",
+		CredentialType: ICECredentialTypePassword,
+	}.oauthTURNURLs())
+}
+
+func TestICEServer_urls(t *testing.T) {
+	t.Run("Valid", func(t *testing.T) {
+		for _, testCase := range []struct {
+			url    string
+			scheme stun.SchemeType
+			host   string
+			port   int
+			proto  stun.ProtoType
+		}{
+			{"stun:stun.l.google.com:19302", stun.SchemeTypeSTUN, "stun.l.google.com", 19302, stun.ProtoTypeUDP},
+			{"stun:example.org:3478?transport=udp", stun.SchemeTypeSTUN, "example.org", 3478, stun.ProtoTypeUDP},
+			{"stun:example.org?transport=tcp", stun.SchemeTypeSTUN, "example.org", 3478, stun.ProtoTypeUDP},
+			{"stuns:example.org", stun.SchemeTypeSTUNS, "example.org", 5349, stun.ProtoTypeTCP},
+			{"stun://example.org:3479", stun.SchemeTypeSTUN, "example.org", 3479, stun.ProtoTypeUDP},
+			{"turn:example.org", stun.SchemeTypeTURN, "example.org", 3478, stun.ProtoTypeUDP},
+			{"turn:example.org?", stun.SchemeTypeTURN, "example.org", 3478, stun.ProtoTypeUDP},
+			{"turn:example.org:3479?transport=tcp&", stun.SchemeTypeTURN, "example.org", 3479, stun.ProtoTypeTCP},
+			{"turn://example.org:80?transport=udp", stun.SchemeTypeTURN, "example.org", 80, stun.ProtoTypeUDP},
+			{"turn:[2001:db8::1]:3478", stun.SchemeTypeTURN, "2001:db8::1", 3478, stun.ProtoTypeUDP},
+			{"turns:example.org:5349?transport=tcp", stun.SchemeTypeTURNS, "example.org", 5349, stun.ProtoTypeTCP},
+			{"turns:example.org:443?transport=TCP", stun.SchemeTypeTURNS, "example.org", 443, stun.ProtoTypeTCP},
+			{"turns:example.org", stun.SchemeTypeTURNS, "example.org", 5349, stun.ProtoTypeTCP},
+		} {
+			urls, err := ICEServer{URLs: []string{testCase.url}, Username: "user", Credential: "pass"}.urls()
+			require.NoError(t, err, testCase.url)
+			require.Len(t, urls, 1)
+			assert.Equal(t, testCase.scheme, urls[0].Scheme, testCase.url)
+			assert.Equal(t, testCase.host, urls[0].Host, testCase.url)
+			assert.Equal(t, testCase.port, urls[0].Port, testCase.url)
+			assert.Equal(t, testCase.proto, urls[0].Proto, testCase.url)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, testCase := range []struct {
+			url string
+			err error
+		}{
+			{"stun:example.org?foo=bar", stun.ErrSTUNQuery},
+			{"stun:example.org?transport=sctp", stun.ErrProtoType},
+			{"stuns:example.org?transport=udp&foo=bar", stun.ErrSTUNQuery},
+			{"turn:example.org?transport=sctp", stun.ErrProtoType},
+			{"turn:example.org?transport=udp&foo=bar", stun.ErrInvalidQuery},
+			{"turn:example.org?transport=udp&transport=tcp", stun.ErrInvalidQuery},
+			{"turn:example.org?%zz", stun.ErrInvalidQuery},
+			{"turn:example.org:port", stun.ErrPort},
+			{"stun::3478", stun.ErrHost},
+			{"http:example.org", stun.ErrSchemeType},
+			{"example.org", stun.ErrSchemeType},
+		} {
+			server := ICEServer{
+				URLs:       []string{"stun:stun.l.google.com:19302", testCase.url},
+				Username:   "user",
+				Credential: "pass",
+			}
+			_, err := server.urls()
+			assert.ErrorIs(t, err, testCase.err, testCase.url)
+
+			var urlErr *ICEServerURLError
+			require.ErrorAs(t, err, &urlErr, testCase.url)
+			assert.Equal(t, 1, urlErr.Index)
+			assert.Equal(t, testCase.url, urlErr.URL)
+			assert.EqualError(t, err, fmt.Sprintf("InvalidAccessError: urls[1] %q: %v", testCase.url, testCase.err))
+		}
+	})
+}
+
+func TestICEServer_UnmarshalJSON_URLs(t *testing.T) {
+	var server ICEServer
+	err := json.Unmarshal([]byte(`{"urls": ["stun:a.example.org", "stun:b.example.org", 3478]}`), &server)
+	assert.ErrorIs(t, err, errInvalidICEServer)
+	assert.ErrorContains(t, err, "urls[2]")
+}
+
+func TestConfiguration_Validate(t *testing.T) {
+	secretKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	expired, err := NewCertificate(secretKey, x509.Certificate{
+		Version:      2,
+		SerialNumber: big.NewInt(1653),
+		NotBefore:    time.Now().AddDate(0, -2, 0),
+		NotAfter:     time.Now().AddDate(0, -1, 0),
+	})
+	require.NoError(t, err)
+	valid, err := GenerateCertificate(secretKey)
+	require.NoError(t, err)
+
+	assert.Empty(t, Configuration{
+		ICEServers: []ICEServer{
+			{URLs: []string{"stun:stun.l.google.com:19302?transport=udp"}},
+			{URLs: []string{"turns:example.org:443?transport=tcp"}, Username: "user", Credential: "pass"},
+		},
+		Certificates: []Certificate{*valid},
+	}.Validate())
+
+	errs := Configuration{
+		ICEServers: []ICEServer{
+			{URLs: []string{"stun:stun.l.google.com:19302", "stun:example.org?foo=bar"}},
+			{URLs: []string{"turn:example.org", "turns:example.org:5349?transport=tcp", "turn:example.org:port"}},
+		},
+		Certificates: []Certificate{*valid, *expired},
+	}.Validate()
+
+	// Every problem is reported, not only the first one
+	require.Len(t, errs, 5)
+	assert.EqualError(t, errs[0],
+		`iceServers[0]: InvalidAccessError: urls[1] "stun:example.org?foo=bar": queries not supported in stun address`)
+	assert.EqualError(t, errs[1],
+		`iceServers[1]: InvalidAccessError: urls[0] "turn:example.org": turn server credentials required`)
+	assert.EqualError(t, errs[2],
+		`iceServers[1]: InvalidAccessError: urls[1] "turns:example.org:5349?transport=tcp": turn server credentials required`)
+	assert.EqualError(t, errs[3], `iceServers[1]: InvalidAccessError: urls[2] "turn:example.org:port": invalid port`)
+	assert.ErrorIs(t, errs[4], ErrCertificateExpired)
+	assert.ErrorContains(t, errs[4], "certificates[1]")
+
+	var accessErr *rtcerr.InvalidAccessError
+	for _, err := range errs {
+		assert.True(t, errors.As(err, &accessErr))
+	}
+}
@@ -23,11 +23,18 @@ import (
 type ICETransport struct {
 	lock sync.RWMutex
 
-	role ICERole
+	// role is the role passed to Start, agentRole the one the agent started with
+	role, agentRole ICERole
 
 	onConnectionStateChangeHandler         atomic.Value // func(ICETransportState)
 	internalOnConnectionStateChangeHandler atomic.Value // func(ICETransportState)
 	onSelectedCandidatePairChangeHandler   atomic.Value // func(*ICECandidatePair)
+	onCandidateErrorHandler                atomic.Value // func(ICECandidateError)
+
+	mDNSResolver atomic.Pointer[iceMulticastDNSResolver]
+
+	// The setup milestones of the PeerConnection, nil with ORTC
+	timeline *connectionTimeline
 
 	state atomic.Value // ICETransportState
 
@@ -55,7 +62,7 @@ func (t *ICETransport) GetSelectedCandidatePair() (*ICECandidatePair, error) {
 		return nil, err
 	}
 
-	local, err := newICECandidateFromICE(icePair.Local, "", 0)
+	local, err := t.gatherer.newLocalICECandidate(icePair.Local, "", 0)
 	if err != nil {
 		return nil, err
 	}
@@ -110,6 +117,13 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 
 	if err := agent.OnConnectionStateChange(func(iceState ice.ConnectionState) {
 		state := newICETransportStateFromICE(iceState)
+		switch state {
+		case ICETransportStateChecking:
+			t.timeline.record(TimelineEventICEFirstCheck, "")
+		case ICETransportStateConnected:
+			t.timeline.record(TimelineEventICEConnected, "")
+		default:
+		}
 
 		t.setState(state)
 		t.onConnectionStateChange(state)
@@ -117,13 +131,23 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		return err
 	}
 	if err := agent.OnSelectedCandidatePairChange(func(local, remote ice.Candidate) {
-		candidates, err := newICECandidatesFromICE([]ice.Candidate{local, remote}, "", 0)
+		t.gatherer.selectedCandidates.Store(selectedICECandidates{local, remote})
+
+		localCandidate, err := t.gatherer.newLocalICECandidate(local, "", 0)
+		if err != nil {
+			t.log.Warnf("%w: %s", errICECandiatesCoversionFailed, err)
+
+			return
+		}
+		remoteCandidate, err := newICECandidateFromICE(remote, "", 0)
 		if err != nil {
 			t.log.Warnf("%w: %s", errICECandiatesCoversionFailed, err)
 
 			return
 		}
-		t.onSelectedCandidatePairChange(NewICECandidatePair(&candidates[0], &candidates[1]))
+		pair := NewICECandidatePair(&localCandidate, &remoteCandidate)
+		t.timeline.record(TimelineEventICEPairNominated, pair.String())
+		t.onSelectedCandidatePairChange(pair)
 	}); err != nil {
 		return err
 	}
@@ -133,6 +157,11 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 		role = &controlled
 	}
 	t.role = *role
+	t.agentRole = *role
+	if forcedRole := t.gatherer.api.settingEngine.iceRole; forcedRole != ICERoleUnknown {
+		t.agentRole = forcedRole
+	}
+	agentRole := t.agentRole
 
 	ctx, ctxCancel := context.WithCancel(context.Background())
 	t.ctxCancel = ctxCancel
@@ -143,7 +172,7 @@ func (t *ICETransport) Start(gatherer *ICEGatherer, params ICEParameters, role *
 
 	var iceConn *ice.Conn
 	var err error
-	switch *role {
+	switch agentRole {
 	case ICERoleControlling:
 		iceConn, err = agent.Dial(ctx,
 			params.UsernameFragment,
@@ -191,10 +220,16 @@ func (t *ICETransport) restart() error {
 		return fmt.Errorf("%w: unable to restart ICETransport", errICEAgentNotExist)
 	}
 
-	if err := agent.Restart(
-		t.gatherer.api.settingEngine.candidates.UsernameFragment,
-		t.gatherer.api.settingEngine.candidates.Password,
-	); err != nil {
+	usernameFragment, password, err := t.gatherer.api.settingEngine.iceCredentialsForRestart()
+	if err != nil {
+		return err
+	}
+
+	if err := agent.Restart(usernameFragment, password); err != nil {
+		return err
+	}
+	t.gatherer.restartRemotePolicy()
+	if err := t.gatherer.updateUsernameFragment(agent); err != nil {
 		return err
 	}
 
@@ -226,6 +261,12 @@ func (t *ICETransport) stop(shouldGracefullyClose bool) error {
 	gatherer := t.gatherer
 	t.lock.Unlock()
 
+	if resolver := t.mDNSResolver.Swap(nil); resolver != nil {
+		if err := resolver.close(shouldGracefullyClose); err != nil {
+			t.log.Warnf("Failed to close mDNS resolver: %v", err)
+		}
+	}
+
 	if mux != nil {
 		var closeErrs []error
 		if shouldGracefullyClose && gatherer != nil {
@@ -253,6 +294,18 @@ func (t *ICETransport) OnSelectedCandidatePairChange(f func(*ICECandidatePair))
 	t.onSelectedCandidatePairChangeHandler.Store(f)
 }
 
+// OnCandidateError sets a handler that is invoked when a remote candidate can't be used,
+// like a mDNS candidate whose host name can't be resolved.
+func (t *ICETransport) OnCandidateError(f func(ICECandidateError)) {
+	t.onCandidateErrorHandler.Store(f)
+}
+
+func (t *ICETransport) onCandidateError(candidateError ICECandidateError) {
+	if handler, ok := t.onCandidateErrorHandler.Load().(func(ICECandidateError)); ok {
+		handler(candidateError)
+	}
+}
+
 func (t *ICETransport) onSelectedCandidatePairChange(pair *ICECandidatePair) {
 	if handler, ok := t.onSelectedCandidatePairChangeHandler.Load().(func(*ICECandidatePair)); ok {
 		handler(pair)
@@ -274,11 +327,32 @@ func (t *ICETransport) onConnectionStateChange(state ICETransportState) {
 	}
 }
 
-// Role indicates the current role of the ICE transport.
+// Role indicates the current role of the ICE transport. It is the role forced with
+// SettingEngine.SetICERole or passed to Start, or once connectivity checks of the remote
+// agent are received, the role after resolving role conflicts.
 func (t *ICETransport) Role() ICERole {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
+	if t.gatherer != nil {
+		switch ICERole(t.gatherer.remoteICERole.Load()) {
+		case ICERoleControlling:
+			return ICERoleControlled
+		case ICERoleControlled:
+			return ICERoleControlling
+		default:
+		}
+	}
+
+	return t.agentRole
+}
+
+// signaledRole returns the role passed to Start, which is derived from the
+// offer/answer exchange by the PeerConnection.
+func (t *ICETransport) signaledRole() ICERole {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+
 	return t.role
 }
 
@@ -296,13 +370,8 @@ func (t *ICETransport) SetRemoteCandidates(remoteCandidates []ICECandidate) erro
 		return fmt.Errorf("%w: unable to set remote candidates", errICEAgentNotExist)
 	}
 
-	for _, c := range remoteCandidates {
-		i, err := c.ToICE()
-		if err != nil {
-			return err
-		}
-
-		if err = agent.AddRemoteCandidate(i); err != nil {
+	for i := range remoteCandidates {
+		if err := t.addRemoteCandidate(agent, &remoteCandidates[i]); err != nil {
 			return err
 		}
 	}
@@ -315,27 +384,66 @@ func (t *ICETransport) AddRemoteCandidate(remoteCandidate *ICECandidate) error {
 	t.lock.RLock()
 	defer t.lock.RUnlock()
 
-	var (
-		candidate ice.Candidate
-		err       error
-	)
-
-	if err = t.ensureGatherer(); err != nil {
+	if err := t.ensureGatherer(); err != nil {
 		return err
 	}
 
+	agent := t.gatherer.getAgent()
+	if agent == nil {
+		return fmt.Errorf("%w: unable to add remote candidates", errICEAgentNotExist)
+	}
+
+	return t.addRemoteCandidate(agent, remoteCandidate)
+}
+
+// addRemoteCandidate adds a remote candidate to agent. The host names of mDNS candidates are
+// resolved first, in the background.
+func (t *ICETransport) addRemoteCandidate(agent *ice.Agent, remoteCandidate *ICECandidate) error {
+	if !t.gatherer.acceptRemoteCandidate(remoteCandidate) {
+		t.log.Debugf("Ignoring remote %s candidate, the ICE transport policy only allows relay", remoteCandidate.Typ)
+
+		return nil
+	}
+
+	if isMulticastDNSCandidate(remoteCandidate) {
+		if t.State() == ICETransportStateClosed {
+			return errICETransportClosed
+		}
+
+		t.getMulticastDNSResolver().resolve(*remoteCandidate, func(resolved ICECandidate) error {
+			candidate, err := resolved.ToICE()
+			if err != nil {
+				return err
+			}
+
+			return agent.AddRemoteCandidate(candidate)
+		}, t.onCandidateError)
+
+		return nil
+	}
+
+	var candidate ice.Candidate
 	if remoteCandidate != nil {
+		var err error
 		if candidate, err = remoteCandidate.ToICE(); err != nil {
 			return err
 		}
 	}
 
-	agent := t.gatherer.getAgent()
-	if agent == nil {
-		return fmt.Errorf("%w: unable to add remote candidates", errICEAgentNotExist)
+	return agent.AddRemoteCandidate(candidate)
+}
+
+func (t *ICETransport) getMulticastDNSResolver() *iceMulticastDNSResolver {
+	if resolver := t.mDNSResolver.Load(); resolver != nil {
+		return resolver
 	}
 
-	return agent.AddRemoteCandidate(candidate)
+	resolver := newICEMulticastDNSResolver(t.gatherer.api.settingEngine, t.loggerFactory)
+	if !t.mDNSResolver.CompareAndSwap(nil, resolver) {
+		_ = resolver.close(false)
+	}
+
+	return t.mDNSResolver.Load()
 }
 
 // State returns the current ice transport state.
@@ -421,9 +529,21 @@ func (t *ICETransport) Stats() TransportStats {
 	return stats
 }
 
-func (t *ICETransport) collectStats(collector *statsReportCollector) {
+func (t *ICETransport) collectStats(collector *statsReportCollector, dtlsTransport *DTLSTransport) {
 	collector.Collecting()
 	stats := t.Stats()
+	if dtlsTransport != nil {
+		stats.ProbeStreams, stats.ProbeStreamsEvicted = dtlsTransport.probeStreamCounts()
+		stats.RTCPPacketsSplit = dtlsTransport.rtcpSplitCount()
+		stats.SRTPPacketsReplayDiscarded, stats.SRTCPPacketsReplayDiscarded = dtlsTransport.replayDiscardCounts()
+		stats.LocalCertificateID, stats.RemoteCertificateID = dtlsTransport.collectCertificateStats(collector)
+	}
+	if d, ok := t.timeline.between(TimelineEventDTLSHandshakeStarted, TimelineEventDTLSConnected); ok {
+		stats.DTLSHandshakeDuration = d.Seconds()
+	}
+	if d, ok := t.timeline.between(TimelineEventICEFirstCheck, TimelineEventICEPairNominated); ok {
+		stats.ICEFirstCheckToNominated = d.Seconds()
+	}
 	collector.Collect(stats.ID, stats)
 }
 
@@ -6,13 +6,20 @@
 package webrtc
 
 import (
+	"fmt"
+	"net"
 	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
 	"github.com/pion/transport/v4/test"
+	"github.com/pion/transport/v4/vnet"
+	"github.com/pion/turn/v4"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestICETransport_OnConnectionStateChange(t *testing.T) {
@@ -156,3 +163,136 @@ func TestICETransport_GetLocalAndRemoteParameters(t *testing.T) {
 
 	closePairNow(t, offerer, answerer)
 }
+
+func TestICETransport_RelayPolicyRemoteCandidates(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 40)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		turnIP   = "10.0.0.1"
+		turnPort = 3478
+		username = "user"
+		password = "pass"
+		realm    = "pion.ly"
+	)
+
+	router, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "10.0.0.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	turnNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{turnIP}})
+	require.NoError(t, err)
+	offerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"10.0.0.2"}})
+	require.NoError(t, err)
+	answerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"10.0.0.3"}})
+	require.NoError(t, err)
+
+	require.NoError(t, router.AddNet(turnNet))
+	require.NoError(t, router.AddNet(offerNet))
+	require.NoError(t, router.AddNet(answerNet))
+	require.NoError(t, router.Start())
+	defer func() {
+		assert.NoError(t, router.Stop())
+	}()
+
+	turnListener, err := turnNet.ListenPacket("udp4", net.JoinHostPort(turnIP, fmt.Sprintf("%d", turnPort)))
+	require.NoError(t, err)
+
+	authKey := turn.GenerateAuthKey(username, realm, password)
+	turnServer, err := turn.NewServer(turn.ServerConfig{
+		Realm: realm,
+		AuthHandler: func(u, r string, _ net.Addr) ([]byte, bool) {
+			if u == username && r == realm {
+				return authKey, true
+			}
+
+			return nil, false
+		},
+		PacketConnConfigs: []turn.PacketConnConfig{
+			{
+				PacketConn: turnListener,
+				RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+					RelayAddress: net.ParseIP(turnIP),
+					Address:      turnIP,
+					Net:          turnNet,
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, turnServer.Close())
+	}()
+
+	newPeerConnection := func(n *vnet.Net, policy ICETransportPolicy) *PeerConnection {
+		se := SettingEngine{}
+		se.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+		se.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+		se.SetNet(n)
+		pc, pcErr := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{
+			ICEServers: []ICEServer{{
+				URLs:     []string{fmt.Sprintf("turn:%s:%d?transport=udp", turnIP, turnPort)},
+				Username: username, Credential: password,
+			}},
+			ICETransportPolicy: policy,
+		})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+
+	// The answerer offers its host candidates, and checks the relay candidates from its host address
+	offerPC := newPeerConnection(offerNet, ICETransportPolicyRelay)
+	answerPC := newPeerConnection(answerNet, ICETransportPolicyAll)
+
+	selectedPairs := make(chan *ICECandidatePair, 10)
+	offerPC.SCTP().Transport().ICETransport().OnSelectedCandidatePairChange(func(pair *ICECandidatePair) {
+		selectedPairs <- pair
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	require.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+	assert.Contains(t, answerPC.LocalDescription().SDP, "typ host")
+
+	for _, pc := range []*PeerConnection{offerPC, answerPC} {
+		pair, pairErr := pc.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+		require.NoError(t, pairErr)
+		require.NotNil(t, pair)
+		assert.Equal(t, ICECandidateTypeRelay, pair.Local.Typ)
+		assert.Equal(t, ICECandidateTypeRelay, pair.Remote.Typ)
+	}
+
+	// The new policy takes effect at the next ICE restart
+	require.NoError(t, offerPC.SetConfiguration(Configuration{
+		ICEServers:         offerPC.GetConfiguration().ICEServers,
+		ICETransportPolicy: ICETransportPolicyAll,
+	}))
+	offer, err := offerPC.CreateOffer(&OfferOptions{ICERestart: true})
+	require.NoError(t, err)
+	offerGatheringComplete := GatheringCompletePromise(offerPC)
+	require.NoError(t, offerPC.SetLocalDescription(offer))
+	<-offerGatheringComplete
+	require.NoError(t, answerPC.SetRemoteDescription(*offerPC.LocalDescription()))
+	answer, err := answerPC.CreateAnswer(nil)
+	require.NoError(t, err)
+	answerGatheringComplete := GatheringCompletePromise(answerPC)
+	require.NoError(t, answerPC.SetLocalDescription(answer))
+	<-answerGatheringComplete
+	require.NoError(t, offerPC.SetRemoteDescription(*answerPC.LocalDescription()))
+
+	for pair := range selectedPairs {
+		if pair.Remote.Typ == ICECandidateTypeHost {
+			assert.Equal(t, ICECandidateTypeRelay, pair.Local.Typ)
+
+			break
+		}
+	}
+
+	closePairNow(t, offerPC, answerPC)
+}
@@ -19,7 +19,10 @@ const (
 	ICETransportPolicyAll ICETransportPolicy = iota
 
 	// ICETransportPolicyRelay indicates only media relay candidates such
-	// as candidates passing through a TURN server are used.
+	// as candidates passing through a TURN server are used. The remote
+	// candidates that aren't relay candidates are ignored as well, and
+	// peer reflexive candidates are only accepted from the addresses of
+	// the remote relay candidates, so the pairs are relay on both sides.
 	ICETransportPolicyRelay
 
 	// ICETransportPolicyNoHost indicates only non-host candidates are used.
@@ -6,6 +6,7 @@
 package webrtc
 
 import (
+	"maps"
 	"sync"
 	"sync/atomic"
 
@@ -52,6 +53,11 @@ func RegisterDefaultInterceptorsWithOptions(mediaEngine *MediaEngine, intercepto
 		options.twccOptions = append(options.twccOptions, twcc.WithLoggerFactory(options.loggerFactory))
 	}
 
+	if options.nackResponderSize != 0 {
+		options.nackResponderOptions = append(options.nackResponderOptions,
+			nack.ResponderSize(options.nackResponderSize))
+	}
+
 	if err := ConfigureNackWithOptions(mediaEngine, interceptorRegistry, options.nackGeneratorOptions,
 		options.nackResponderOptions...); err != nil {
 		return err
@@ -70,6 +76,10 @@ func RegisterDefaultInterceptorsWithOptions(mediaEngine *MediaEngine, intercepto
 		return err
 	}
 
+	if options.disableTWCCSender {
+		return nil
+	}
+
 	return ConfigureTWCCSenderWithOptions(mediaEngine, interceptorRegistry, options.twccOptions...)
 }
 
@@ -267,6 +277,31 @@ func ConfigureSimulcastExtensionHeaders(mediaEngine *MediaEngine) error {
 	)
 }
 
+// ConfigureVideoLayersAllocationHeaderExtension enables the video-layers-allocation RTP Extension Header,
+// the allocation sent with it is set by RTPSender.SetVideoLayersAllocation.
+func ConfigureVideoLayersAllocationHeaderExtension(mediaEngine *MediaEngine) error {
+	return mediaEngine.RegisterHeaderExtension(
+		RTPHeaderExtensionCapability{URI: VideoLayersAllocationURI}, RTPCodecTypeVideo,
+	)
+}
+
+// ConfigureVideoOrientationHeaderExtension enables the video orientation (CVO) RTP Extension Header,
+// see TrackRemote.VideoOrientation. Once it is negotiated, the remote peer may send the frames
+// of its camera unrotated, leaving it to the application to render them upright.
+func ConfigureVideoOrientationHeaderExtension(mediaEngine *MediaEngine) error {
+	return mediaEngine.RegisterHeaderExtension(
+		RTPHeaderExtensionCapability{URI: VideoOrientationURI}, RTPCodecTypeVideo,
+	)
+}
+
+// ConfigurePlayoutDelayHeaderExtension enables the playout-delay RTP Extension Header,
+// the delay sent with it is set by RTPSender.SetPlayoutDelay.
+func ConfigurePlayoutDelayHeaderExtension(mediaEngine *MediaEngine) error {
+	return mediaEngine.RegisterHeaderExtension(
+		RTPHeaderExtensionCapability{URI: PlayoutDelayURI}, RTPCodecTypeVideo,
+	)
+}
+
 // ConfigureFlexFEC03 registers flexfec-03 codec with provided payloadType in mediaEngine
 // and adds corresponding interceptor to the registry.
 // Note that this function should be called before any other interceptor that modifies RTP packets
@@ -303,15 +338,155 @@ func ConfigureFlexFEC03(
 }
 
 // interceptorToTrackLocalWriter is an RTPWriter that holds a reference to interceptor.RTPWriter.
-type interceptorToTrackLocalWriter struct{ interceptor atomic.Value } // interceptor.RTPWriter }
+type interceptorToTrackLocalWriter struct {
+	interceptor atomic.Value // interceptor.RTPWriter
+
+	// twoByteHeaderExtensions is set if a negotiated header extension ID
+	// doesn't fit into the one-byte header extension form.
+	twoByteHeaderExtensions bool
+
+	// continuity keeps the stream continuous when the track of the RTPSender changes,
+	// it is enabled by RTPSender.SetSilenceGeneration.
+	continuity rtpContinuity
+
+	// keyframeFlush is set if the retransmission history is flushed before keyframes.
+	keyframeFlush atomic.Pointer[keyframeFlush]
+
+	// paused is set by the RTPSender while it must not send, packets are dropped then.
+	paused *atomic.Bool
+
+	// drain follows the packets through the interceptors, see RTPSender.StopWithFlush.
+	drain rtpDrain
+
+	// pacer is set while a TrackLocalStaticSample paces the packets written to it.
+	pacer atomic.Pointer[samplePacer]
+
+	// layersAllocation is set if the video-layers-allocation header extension is negotiated.
+	layersAllocation atomic.Pointer[videoLayersAllocationWriter]
+
+	// playoutDelay is set if the playout-delay header extension is negotiated.
+	playoutDelay atomic.Pointer[playoutDelayWriter]
+
+	// videoOrientation is set if the video orientation header extension is negotiated.
+	videoOrientation atomic.Pointer[videoOrientationWriter]
+
+	// sdesExtensions is set if the track asks for the mid and rid header extensions.
+	sdesExtensions atomic.Pointer[sdesExtensionsWriter]
+}
 
 // WriteRTP writes an RTP packet using the underlying interceptor.RTPWriter.
 func (i *interceptorToTrackLocalWriter) WriteRTP(header *rtp.Header, payload []byte) (int, error) {
-	if writer, ok := i.interceptor.Load().(interceptor.RTPWriter); ok && writer != nil {
-		return writer.Write(header, payload, interceptor.Attributes{})
+	return i.writeRTPWithAttributes(header, payload, interceptor.Attributes{})
+}
+
+// writeRTPWithAttributes is like WriteRTP, and hands attributes to the interceptors.
+func (i *interceptorToTrackLocalWriter) writeRTPWithAttributes(
+	header *rtp.Header, payload []byte, attributes interceptor.Attributes,
+) (int, error) {
+	if err := i.drain.accept(header); err != nil {
+		return 0, err
+	}
+
+	if flush := i.keyframeFlush.Load(); flush != nil {
+		flush.observe(header, payload)
+	}
+
+	if writer := i.layersAllocation.Load(); writer != nil {
+		header = writer.apply(header)
+	}
+
+	if writer := i.playoutDelay.Load(); writer != nil {
+		header = writer.apply(header)
+	}
+
+	if writer := i.videoOrientation.Load(); writer != nil {
+		header = writer.apply(header, attributes)
+	}
+
+	if writer := i.sdesExtensions.Load(); writer != nil {
+		header = writer.apply(header)
+	}
+
+	header = i.continuity.apply(header)
+
+	// The attributes might be shared with other packets and bindings of the track, every
+	// packet gets its own so AttributeSentAt can be added.
+	return i.write(header, payload, maps.Clone(attributes))
+}
+
+// write writes an RTP packet that is already part of the stream.
+func (i *interceptorToTrackLocalWriter) write(
+	header *rtp.Header, payload []byte, attributes interceptor.Attributes,
+) (int, error) {
+	n, queued, err := i.writeInterceptors(header, payload, attributes)
+	if queued {
+		i.drain.queue(header.SequenceNumber)
+	}
+
+	return n, err
+}
+
+// writeRepair writes a packet of the RTX stream, which the drain doesn't wait for.
+func (i *interceptorToTrackLocalWriter) writeRepair(
+	header *rtp.Header, payload []byte, attributes interceptor.Attributes,
+) (int, error) {
+	n, _, err := i.writeInterceptors(header, payload, attributes)
+
+	return n, err
+}
+
+// isPaused returns true while the RTPSender of the writer must not send.
+func (i *interceptorToTrackLocalWriter) isPaused() bool {
+	return i.paused != nil && i.paused.Load()
+}
+
+// writeInterceptors hands a packet to the interceptors, queued is set if they took it.
+func (i *interceptorToTrackLocalWriter) writeInterceptors(
+	header *rtp.Header, payload []byte, attributes interceptor.Attributes,
+) (n int, queued bool, err error) {
+	if i.isPaused() {
+		return 0, false, nil
+	}
+
+	writer, ok := i.interceptor.Load().(interceptor.RTPWriter)
+	if !ok || writer == nil {
+		return 0, false, nil
+	}
+
+	if needsTwoByteHeaderExtensions(header, i.twoByteHeaderExtensions) {
+		// The header might be shared with other bindings of the track, so don't modify it.
+		converted := *header
+		converted.Extension = true
+		converted.ExtensionProfile = rtp.ExtensionProfileTwoByte
+		header = &converted
+	}
+
+	n, err = writer.Write(header, payload, attributes)
+
+	return n, err == nil, err
+}
+
+// needsTwoByteHeaderExtensions returns true if header has to be switched to the RFC 8285
+// two-byte header extension form, because twoByte is set or because its extensions don't
+// fit into the one-byte form. Headers without extensions are switched if twoByte is set,
+// so extensions added by interceptors are encoded with the negotiated IDs.
+func needsTwoByteHeaderExtensions(header *rtp.Header, twoByte bool) bool {
+	switch {
+	case !header.Extension:
+		return twoByte
+	case header.ExtensionProfile != rtp.ExtensionProfileOneByte:
+		return false
+	case twoByte:
+		return true
+	}
+
+	for _, id := range header.GetExtensionIDs() {
+		if id > maxOneByteHeaderExtensionID || len(header.GetExtension(id)) > 16 {
+			return true
+		}
 	}
 
-	return 0, nil
+	return false
 }
 
 // Write writes a raw RTP packet using the underlying interceptor.RTPWriter.
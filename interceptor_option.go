@@ -19,10 +19,12 @@ type interceptorOptions struct {
 
 	nackGeneratorOptions  []nack.GeneratorOption
 	nackResponderOptions  []nack.ResponderOption
+	nackResponderSize     uint16
 	reportReceiverOptions []report.ReceiverOption
 	reportSenderOptions   []report.SenderOption
 	statsOptions          []stats.Option
 	twccOptions           []twcc.Option
+	disableTWCCSender     bool
 }
 
 // InterceptorOption is a function that configures InterceptorOptions.
@@ -49,6 +51,15 @@ func WithNackResponderOptions(opts ...nack.ResponderOption) InterceptorOption {
 	}
 }
 
+// WithNackResponderSize sets how many sent RTP packets are kept per stream to answer NACKs.
+// It must be a power of two. Increase it for high bitrate streams, where the default only
+// covers a short history.
+func WithNackResponderSize(size uint16) InterceptorOption {
+	return func(o *interceptorOptions) {
+		o.nackResponderSize = size
+	}
+}
+
 // WithReportReceiverOptions sets options for the report receiver interceptor.
 func WithReportReceiverOptions(opts ...report.ReceiverOption) InterceptorOption {
 	return func(o *interceptorOptions) {
@@ -76,3 +87,12 @@ func WithTWCCOptions(opts ...twcc.Option) InterceptorOption {
 		o.twccOptions = opts
 	}
 }
+
+// WithTWCCSenderDisabled leaves the TWCC interceptor, which sends transport-wide congestion
+// control feedback for the received packets, and the transport-cc negotiation out of the
+// default interceptors.
+func WithTWCCSenderDisabled() InterceptorOption {
+	return func(o *interceptorOptions) {
+		o.disableTWCCSender = true
+	}
+}
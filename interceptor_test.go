@@ -12,6 +12,7 @@ import (
 	"fmt"
 	"io"
 	"reflect"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
@@ -22,6 +23,7 @@ import (
 	"github.com/pion/logging"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v4/test"
 	"github.com/pion/transport/v4/vnet"
 	"github.com/pion/webrtc/v4/pkg/media"
@@ -883,3 +885,592 @@ func TestNackNotSentForRTX(t *testing.T) { //nolint:cyclop
 	closePairNow(t, pcOffer, pcAnswer)
 	<-done
 }
+
+// TestNackResponderSize asserts that WithNackResponderSize allows answering NACKs for
+// packets older than the default history.
+func TestNackResponderSize(t *testing.T) {
+	defer test.TimeOut(time.Second * 30).Stop()
+	defer test.CheckRoutines(t)()
+
+	for _, testCase := range []struct {
+		name          string
+		options       []InterceptorOption
+		retransmitted bool
+	}{
+		{name: "Default", retransmitted: false},
+		{name: "Larger history", options: []InterceptorOption{WithNackResponderSize(4096)}, retransmitted: true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			testNackResponderSize(t, testCase.options, testCase.retransmitted)
+		})
+	}
+}
+
+func testNackResponderSize(t *testing.T, options []InterceptorOption, retransmitted bool) {
+	t.Helper()
+
+	const packetCount = 2048
+
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	interceptorRegistry := &interceptor.Registry{}
+	assert.NoError(t, RegisterDefaultInterceptorsWithOptions(mediaEngine, interceptorRegistry, options...))
+
+	api := NewAPI(WithMediaEngine(mediaEngine), WithInterceptorRegistry(interceptorRegistry))
+	pcOffer, pcAnswer, err := api.newPair(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	rtpSender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	// NACKs are handled by the interceptors while RTCP is read
+	go func() {
+		for {
+			if _, _, rtcpErr := rtpSender.ReadRTCP(); rtcpErr != nil {
+				return
+			}
+		}
+	}()
+
+	var (
+		lastSequenceNumber atomic.Int32
+		nackSent           atomic.Bool
+		remoteSSRC         atomic.Uint32
+	)
+	lastSequenceNumber.Store(-1)
+	gotRetransmission := make(chan struct{})
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		remoteSSRC.Store(uint32(trackRemote.SSRC()))
+
+		for {
+			pkt, attributes, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			if attributes.Get(AttributeRtxSsrc) == nil {
+				lastSequenceNumber.Store(int32(pkt.SequenceNumber))
+			} else if nackSent.Load() && pkt.SequenceNumber == 0 {
+				close(gotRetransmission)
+			}
+		}
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	for sequenceNumber := range packetCount {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: uint16(sequenceNumber)}, //nolint:gosec
+			Payload: []byte{0x10, 0x00},
+		}))
+		if sequenceNumber%64 == 0 {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	assert.Eventually(t, func() bool {
+		return lastSequenceNumber.Load() == packetCount-1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	nackSent.Store(true)
+	assert.NoError(t, pcAnswer.WriteRTCP([]rtcp.Packet{&rtcp.TransportLayerNack{
+		MediaSSRC: remoteSSRC.Load(),
+		Nacks:     rtcp.NackPairsFromSequenceNumbers([]uint16{0}),
+	}}))
+
+	// RTX packets are only returned by ReadRTP when media packets keep arriving
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	timeout := time.After(time.Second)
+	sequenceNumber := uint16(packetCount)
+waitRetransmission:
+	for {
+		select {
+		case <-gotRetransmission:
+			assert.True(t, retransmitted, "packet older than the NACK history was retransmitted")
+
+			break waitRetransmission
+		case <-timeout:
+			assert.False(t, retransmitted, "packet in the NACK history wasn't retransmitted")
+
+			break waitRetransmission
+		case <-ticker.C:
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+				Payload: []byte{0x10, 0x00},
+			}))
+			sequenceNumber++
+		}
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestTWCCFeedback(t *testing.T) { //nolint:cyclop
+	defer test.TimeOut(time.Second * 30).Stop()
+	defer test.CheckRoutines(t)()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetTWCCFeedbackInterval(20 * time.Millisecond)
+	api := NewAPI(WithSettingEngine(settingEngine))
+	// The offerer stamps the transport-wide sequence numbers the answerer reports on
+	assert.NoError(t, ConfigureTWCCHeaderExtensionSender(api.mediaEngine, api.interceptorRegistry))
+
+	pair, err := api.NewLoopbackPeerConnectionPair(Configuration{})
+	assert.NoError(t, err)
+
+	feedback := make(chan *rtcp.TransportLayerCC, 100)
+	var tracks []*TrackLocalStaticRTP
+	ssrcs := map[uint32]bool{}
+	for _, id := range []string{"video1", "video2"} {
+		track, trackErr := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, id, "pion")
+		assert.NoError(t, trackErr)
+		sender, senderErr := pair.Offerer.AddTrack(track)
+		assert.NoError(t, senderErr)
+		tracks = append(tracks, track)
+		ssrcs[uint32(sender.GetParameters().Encodings[0].SSRC)] = true
+
+		go func() {
+			for {
+				packets, _, readErr := sender.ReadRTCP()
+				if readErr != nil {
+					return
+				}
+				for _, packet := range packets {
+					if cc, ok := packet.(*rtcp.TransportLayerCC); ok {
+						select {
+						case feedback <- cc:
+						default:
+						}
+					}
+				}
+			}
+		}()
+	}
+
+	pair.Answerer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		for {
+			if _, _, readErr := trackRemote.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, pair.Negotiate())
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		ticker := time.NewTicker(5 * time.Millisecond)
+		defer ticker.Stop()
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				for _, track := range tracks {
+					assert.NoError(t, track.WriteRTP(&rtp.Packet{
+						Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+						Payload: []byte{0x10, 0x00},
+					}))
+				}
+			}
+		}
+	}()
+
+	// A single feedback stream covers both tracks, a stream per track would repeat the counts
+	senderSSRC := uint32(0)
+	feedbackCounts := map[uint8]bool{}
+	for len(feedbackCounts) < 10 {
+		cc := <-feedback
+		assert.True(t, ssrcs[cc.MediaSSRC], "feedback for unknown SSRC %d", cc.MediaSSRC)
+		if senderSSRC == 0 {
+			senderSSRC = cc.SenderSSRC
+		}
+		assert.Equal(t, senderSSRC, cc.SenderSSRC)
+		assert.False(t, feedbackCounts[cc.FbPktCount], "feedback count %d sent twice", cc.FbPktCount)
+		feedbackCounts[cc.FbPktCount] = true
+	}
+	close(done)
+	<-sent
+
+	assert.NoError(t, pair.Close())
+}
+
+func TestTWCCFeedback_Disabled(t *testing.T) {
+	settingEngine := SettingEngine{}
+	settingEngine.DisableTWCCFeedback(true)
+	pc, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, offer.SDP, "transport-cc")
+	assert.NotContains(t, offer.SDP, sdp.TransportCCURI)
+
+	assert.NoError(t, pc.Close())
+}
+
+func Test_InterceptorToTrackLocalWriter_TwoByteHeaderExtensions(t *testing.T) {
+	withExtensions := func(profile uint16, extensions map[uint8][]byte) *rtp.Header {
+		header := &rtp.Header{Extension: true, ExtensionProfile: profile}
+		for id, payload := range extensions {
+			assert.NoError(t, header.SetExtension(id, payload))
+		}
+
+		return header
+	}
+
+	// rtp picks the one-byte form for the first extension, regardless of its ID.
+	outOfRangeOneByte := &rtp.Header{}
+	assert.NoError(t, outOfRangeOneByte.SetExtension(15, []byte{0x01}))
+
+	for _, testCase := range []struct {
+		name     string
+		header   *rtp.Header
+		twoByte  bool
+		expected bool
+	}{
+		{"No Extensions", &rtp.Header{}, false, false},
+		{"No Extensions Two-Byte IDs", &rtp.Header{}, true, true},
+		{"One-Byte", withExtensions(rtp.ExtensionProfileOneByte, map[uint8][]byte{1: {0x01}}), false, false},
+		{"One-Byte Two-Byte IDs", withExtensions(rtp.ExtensionProfileOneByte, map[uint8][]byte{1: {0x01}}), true, true},
+		{"One-Byte Large ID", outOfRangeOneByte, false, true},
+		{"Two-Byte", withExtensions(rtp.ExtensionProfileTwoByte, map[uint8][]byte{15: {0x01}}), true, false},
+		{"RFC 3550", &rtp.Header{Extension: true, ExtensionProfile: 0x1234}, true, false},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			assert.Equal(t, testCase.expected, needsTwoByteHeaderExtensions(testCase.header, testCase.twoByte))
+		})
+	}
+
+	header := withExtensions(rtp.ExtensionProfileOneByte, map[uint8][]byte{1: {0x01}})
+	var written *rtp.Header
+	writer := &interceptorToTrackLocalWriter{twoByteHeaderExtensions: true}
+	writer.interceptor.Store(interceptor.RTPWriter(interceptor.RTPWriterFunc(
+		func(header *rtp.Header, _ []byte, _ interceptor.Attributes) (int, error) {
+			written = header
+
+			return 0, nil
+		},
+	)))
+	_, err := writer.WriteRTP(header, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, uint16(rtp.ExtensionProfileTwoByte), written.ExtensionProfile)
+	assert.Equal(t, []byte{0x01}, written.GetExtension(1))
+	assert.Equal(t, uint16(rtp.ExtensionProfileOneByte), header.ExtensionProfile, "the written header is not modified")
+}
+
+func TestPeerConnection_WithoutInterceptors(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	var built atomic.Int32
+	ir := &interceptor.Registry{}
+	ir.Add(&mock_interceptor.Factory{
+		NewInterceptorFn: func(_ string) (interceptor.Interceptor, error) {
+			built.Add(1)
+
+			return &interceptor.NoOp{}, nil
+		},
+	})
+	api := NewAPI(WithInterceptorRegistry(ir))
+
+	pcOffer, err := api.NewPeerConnectionWithOptions(Configuration{}, WithoutInterceptors())
+	assert.NoError(t, err)
+	pcAnswer, err := NewPeerConnectionWithOptions(Configuration{}, WithoutInterceptors())
+	assert.NoError(t, err)
+
+	assert.Zero(t, built.Load())
+	assert.Nil(t, pcAnswer.statsGetter)
+	_, ok := lookupStats(pcAnswer.id)
+	assert.False(t, ok)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	seenRTP, seenRTPCancel := context.WithCancel(context.Background())
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		_, _, readErr := track.ReadRTP()
+		assert.NoError(t, readErr)
+
+		assert.NoError(t, pcAnswer.WriteRTCP([]rtcp.Packet{
+			&rtcp.PictureLossIndication{MediaSSRC: uint32(track.SSRC())},
+		}))
+		seenRTPCancel()
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	func() {
+		ticker := time.NewTicker(time.Millisecond * 20)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-seenRTP.Done():
+				return
+			case <-ticker.C:
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+			}
+		}
+	}()
+
+	// RTCP is still handed to the sender
+	for seenPLI := false; !seenPLI; {
+		pkts, _, readErr := sender.ReadRTCP()
+		assert.NoError(t, readErr)
+		for _, pkt := range pkts {
+			_, seenPLI = pkt.(*rtcp.PictureLossIndication)
+		}
+	}
+
+	_, ok = pcAnswer.GetStats().GetConnectionStats(pcAnswer)
+	assert.True(t, ok)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_WithInterceptors(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Every interceptor logs the RTP packets it sees as "<PeerConnection id> <name> <direction>"
+	var (
+		eventsMu sync.Mutex
+		events   []string
+	)
+	logEvent := func(id, name, direction string) {
+		eventsMu.Lock()
+		defer eventsMu.Unlock()
+		events = append(events, id+" "+name+" "+direction)
+	}
+	eventsOf := func(id string) (ofPeerConnection []string) {
+		eventsMu.Lock()
+		defer eventsMu.Unlock()
+		for _, event := range events {
+			if strings.HasPrefix(event, id+" ") {
+				ofPeerConnection = append(ofPeerConnection, strings.TrimPrefix(event, id+" "))
+			}
+		}
+
+		return ofPeerConnection
+	}
+	loggingFactory := func(name string) interceptor.Factory {
+		return &mock_interceptor.Factory{
+			NewInterceptorFn: func(id string) (interceptor.Interceptor, error) {
+				return &mock_interceptor.Interceptor{
+					BindLocalStreamFn: func(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+						return interceptor.RTPWriterFunc(
+							func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+								logEvent(id, name, "write")
+
+								return writer.Write(header, payload, attributes)
+							},
+						)
+					},
+					BindRemoteStreamFn: func(_ *interceptor.StreamInfo, reader interceptor.RTPReader) interceptor.RTPReader {
+						return interceptor.RTPReaderFunc(func(b []byte, a interceptor.Attributes) (int, interceptor.Attributes, error) {
+							n, a, err := reader.Read(b, a)
+							if err == nil {
+								logEvent(id, name, "read")
+							}
+
+							return n, a, err
+						})
+					},
+				}, nil
+			},
+		}
+	}
+
+	// Both pairs share an API with the default interceptors
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	ir := &interceptor.Registry{}
+	assert.NoError(t, RegisterDefaultInterceptors(mediaEngine, ir))
+	ir.Add(loggingFactory("registry"))
+	api := NewAPI(WithMediaEngine(mediaEngine), WithInterceptorRegistry(ir))
+
+	newConnectedPair := func(options ...PeerConnectionOption) (*PeerConnection, *PeerConnection, func()) {
+		pcOffer, err := api.NewPeerConnectionWithOptions(Configuration{}, options...)
+		assert.NoError(t, err)
+		pcAnswer, err := api.NewPeerConnectionWithOptions(Configuration{}, options...)
+		assert.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		assert.NoError(t, err)
+		_, err = pcOffer.AddTrack(track)
+		assert.NoError(t, err)
+
+		seenRTP, seenRTPCancel := context.WithCancel(context.Background())
+		pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+			_, _, readErr := track.ReadRTP()
+			assert.NoError(t, readErr)
+			seenRTPCancel()
+		})
+
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		return pcOffer, pcAnswer, func() {
+			ticker := time.NewTicker(time.Millisecond * 20)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-seenRTP.Done():
+					return
+				case <-ticker.C:
+					assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+				}
+			}
+		}
+	}
+
+	extraOffer, extraAnswer, sendExtra := newConnectedPair(WithInterceptors(loggingFactory("extra")))
+	plainOffer, plainAnswer, sendPlain := newConnectedPair()
+	sendExtra()
+	sendPlain()
+
+	// Outgoing packets pass the added interceptors first, incoming ones last
+	assert.Equal(t, []string{"extra write", "registry write"}, eventsOf(extraOffer.id)[:2])
+	assert.Equal(t, []string{"registry read", "extra read"}, eventsOf(extraAnswer.id)[:2])
+
+	// The connections without them only see the interceptors of the registry
+	for _, pc := range []*PeerConnection{plainOffer, plainAnswer} {
+		pcEvents := eventsOf(pc.id)
+		assert.NotEmpty(t, pcEvents)
+		for _, event := range pcEvents {
+			assert.True(t, strings.HasPrefix(event, "registry "), event)
+		}
+	}
+
+	closePairNow(t, extraOffer, extraAnswer)
+	closePairNow(t, plainOffer, plainAnswer)
+}
+
+func TestPeerConnection_WithInterceptors_WithoutInterceptors(t *testing.T) {
+	var registryBuilt, extraBuilt atomic.Int32
+	countingFactory := func(built *atomic.Int32) interceptor.Factory {
+		return &mock_interceptor.Factory{
+			NewInterceptorFn: func(_ string) (interceptor.Interceptor, error) {
+				built.Add(1)
+
+				return &interceptor.NoOp{}, nil
+			},
+		}
+	}
+
+	ir := &interceptor.Registry{}
+	ir.Add(countingFactory(&registryBuilt))
+	api := NewAPI(WithInterceptorRegistry(ir))
+
+	pc, err := api.NewPeerConnectionWithOptions(
+		Configuration{}, WithoutInterceptors(), WithInterceptors(countingFactory(&extraBuilt)),
+	)
+	assert.NoError(t, err)
+	assert.Zero(t, registryBuilt.Load())
+	assert.Equal(t, int32(1), extraBuilt.Load())
+	assert.NoError(t, pc.Close())
+
+	errBuild := errors.New("build failed")
+	_, err = api.NewPeerConnectionWithOptions(Configuration{}, WithInterceptors(&mock_interceptor.Factory{
+		NewInterceptorFn: func(string) (interceptor.Interceptor, error) {
+			return nil, errBuild
+		},
+	}))
+	assert.ErrorIs(t, err, errBuild)
+}
+
+// BenchmarkPeerConnection_WithoutInterceptors measures the cost of relaying a packet from a
+// local track to the remote track of a connected pair, with and without interceptors.
+func BenchmarkPeerConnection_WithoutInterceptors(b *testing.B) {
+	for _, benchCase := range []struct {
+		name    string
+		options []PeerConnectionOption
+	}{
+		{"Default", nil},
+		{"WithoutInterceptors", []PeerConnectionOption{WithoutInterceptors()}},
+	} {
+		b.Run(benchCase.name, func(b *testing.B) {
+			benchmarkRTPRelay(b, benchCase.options...)
+		})
+	}
+}
+
+func benchmarkRTPRelay(b *testing.B, options ...PeerConnectionOption) {
+	b.Helper()
+
+	pcOffer, err := NewPeerConnectionWithOptions(Configuration{}, options...)
+	assert.NoError(b, err)
+	pcAnswer, err := NewPeerConnectionWithOptions(Configuration{}, options...)
+	assert.NoError(b, err)
+	defer closePairNow(b, pcOffer, pcAnswer)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(b, err)
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(b, err)
+
+	// The reader reports the sequence number of every packet it reads
+	received := make(chan uint16, 1024)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		for {
+			pkt, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			received <- pkt.SequenceNumber
+		}
+	})
+	assert.NoError(b, signalPair(pcOffer, pcAnswer))
+
+	var sequenceNumber uint16
+	payload := make([]byte, 1000)
+	relay := func(timeout time.Duration) bool {
+		sequenceNumber++
+		assert.NoError(b, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 96},
+			Payload: payload,
+		}))
+
+		deadline := time.After(timeout)
+		for {
+			select {
+			case read := <-received:
+				if read == sequenceNumber {
+					return true
+				}
+			case <-deadline:
+				return false
+			}
+		}
+	}
+
+	// Wait for the track to be bound on the remote
+	for bound := false; !bound; {
+		bound = relay(20 * time.Millisecond)
+	}
+
+	b.SetBytes(int64(len(payload)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if !relay(time.Second) {
+			b.Fatalf("Packet %d was not relayed", sequenceNumber)
+		}
+	}
+}
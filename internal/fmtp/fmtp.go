@@ -141,6 +141,13 @@ func Parse(mimeType string, clockRate uint32, channels uint16, line string) FMTP
 			parameters: parameters,
 		}
 
+	case strings.EqualFold(mimeType, "audio/opus"):
+		fmtp = &opusFMTP{
+			clockRate:  clockRate,
+			channels:   channels,
+			parameters: parameters,
+		}
+
 	default:
 		fmtp = &genericFMTP{
 			mimeType:   mimeType,
@@ -153,6 +160,17 @@ func Parse(mimeType string, clockRate uint32, channels uint16, line string) FMTP
 	return fmtp
 }
 
+// MatchLoose returns true if a and b are compatible when the parameters that only
+// identify the profile of a codec are ignored. For H264 only the packetization-mode
+// has to be equal. Other MimeTypes are compared with Match.
+func MatchLoose(a, b FMTP) bool {
+	if h, ok := a.(*h264FMTP); ok {
+		return h.matchLoose(b)
+	}
+
+	return a.Match(b)
+}
+
 type genericFMTP struct {
 	mimeType   string
 	clockRate  uint32
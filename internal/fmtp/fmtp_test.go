@@ -128,6 +128,20 @@ func TestParse(t *testing.T) {
 				},
 			},
 		},
+		{
+			"opus",
+			"audio/opus",
+			48000,
+			2,
+			"stereo=1",
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+				parameters: map[string]string{
+					"stereo": "1",
+				},
+			},
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			f := Parse(ca.mimeType, ca.clockRate, ca.channels, ca.line)
@@ -696,6 +710,41 @@ func TestMatch(t *testing.T) { //nolint:maintidx
 			},
 			true,
 		},
+		{
+			"opus preferences",
+			&opusFMTP{
+				clockRate: 48000,
+				channels:  2,
+				parameters: map[string]string{
+					"minptime":     "10",
+					"useinbandfec": "1",
+				},
+			},
+			&opusFMTP{
+				clockRate: 0,
+				channels:  0,
+				parameters: map[string]string{
+					"stereo":            "1",
+					"useinbandfec":      "0",
+					"maxaveragebitrate": "128000",
+				},
+			},
+			true,
+		},
+		{
+			"opus channels",
+			&opusFMTP{
+				clockRate:  48000,
+				channels:   2,
+				parameters: map[string]string{},
+			},
+			&opusFMTP{
+				clockRate:  48000,
+				channels:   1,
+				parameters: map[string]string{},
+			},
+			false,
+		},
 	} {
 		t.Run(ca.name, func(t *testing.T) {
 			c := ca.a.Match(ca.b)
@@ -715,3 +764,37 @@ func TestMatch(t *testing.T) { //nolint:maintidx
 		})
 	}
 }
+
+func TestMatchLoose(t *testing.T) {
+	for _, ca := range []struct {
+		name    string
+		a, b    string
+		mime    string
+		consist bool
+	}{
+		{
+			"h264 different profile",
+			"level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640c1f",
+			"packetization-mode=1;profile-level-id=42e01f",
+			"video/h264",
+			true,
+		},
+		{"h264 default packetization-mode", "profile-level-id=640c1f", "packetization-mode=0", "video/h264", true},
+		{
+			"h264 different packetization-mode",
+			"packetization-mode=1;profile-level-id=42e01f",
+			"packetization-mode=0;profile-level-id=42e01f",
+			"video/h264",
+			false,
+		},
+		{"vp9 different profile", "profile-id=0", "profile-id=2", "video/vp9", false},
+		{"generic", "apt=96", "apt=96", "video/rtx", true},
+	} {
+		t.Run(ca.name, func(t *testing.T) {
+			a := Parse(ca.mime, 90000, 0, ca.a)
+			b := Parse(ca.mime, 90000, 0, ca.b)
+			assert.Equal(t, ca.consist, MatchLoose(a, b))
+			assert.Equal(t, ca.consist, MatchLoose(b, a))
+		})
+	}
+}
@@ -79,6 +79,25 @@ func (h *h264FMTP) Match(b FMTP) bool {
 	return true
 }
 
+// matchLoose is Match without the profile-level-id. A missing packetization-mode
+// is 0, see RFC6184 Section 8.1.
+func (h *h264FMTP) matchLoose(b FMTP) bool {
+	fmtp, ok := b.(*h264FMTP)
+	if !ok {
+		return false
+	}
+
+	packetizationMode := func(parameters map[string]string) string {
+		if mode, ok := parameters["packetization-mode"]; ok {
+			return mode
+		}
+
+		return "0"
+	}
+
+	return packetizationMode(h.parameters) == packetizationMode(fmtp.parameters)
+}
+
 func (h *h264FMTP) Parameter(key string) (string, bool) {
 	v, ok := h.parameters[key]
 
@@ -0,0 +1,33 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package fmtp
+
+// opusFMTP is the fmtp of Opus. Its parameters only declare the preferences of the side
+// that sends them, like stereo or maxaveragebitrate, so they don't affect compatibility.
+// https://datatracker.ietf.org/doc/html/rfc7587#section-7
+type opusFMTP struct {
+	clockRate  uint32
+	channels   uint16
+	parameters map[string]string
+}
+
+func (o *opusFMTP) MimeType() string {
+	return "audio/opus"
+}
+
+func (o *opusFMTP) Match(b FMTP) bool {
+	c, ok := b.(*opusFMTP)
+	if !ok {
+		return false
+	}
+
+	return ClockRateEqual(o.MimeType(), o.clockRate, c.clockRate) &&
+		ChannelsEqual(o.MimeType(), o.channels, c.channels)
+}
+
+func (o *opusFMTP) Parameter(key string) (string, bool) {
+	v, ok := o.parameters[key]
+
+	return v, ok
+}
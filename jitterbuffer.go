@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/rtp/codecs"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/media/samplebuilder"
+)
+
+// jitterBuffer reorders the packets of a TrackRemote read by ReadSample, and builds its frames.
+type jitterBuffer struct {
+	mu       sync.Mutex
+	latency  time.Duration
+	mimeType string
+	builder  *samplebuilder.SampleBuilder
+
+	// The packets sent before the first one received may still arrive, so the frames
+	// are only built once the latency of media was received after it.
+	latencyTicks   uint32
+	firstTimestamp uint32
+	received       bool
+	started        bool
+}
+
+func newJitterBuffer(codec RTPCodecParameters, latency time.Duration) (*jitterBuffer, error) {
+	buffer := &jitterBuffer{latency: latency}
+	if err := buffer.setCodec(codec); err != nil {
+		return nil, err
+	}
+
+	return buffer, nil
+}
+
+// depacketizerForMimeType returns the depacketizer of a codec, or nil if ReadSample
+// doesn't support it.
+func depacketizerForMimeType(mimeType string) rtp.Depacketizer {
+	switch {
+	case strings.EqualFold(mimeType, MimeTypeVP8):
+		return &codecs.VP8Packet{}
+	case strings.EqualFold(mimeType, MimeTypeVP9):
+		return &codecs.VP9Packet{}
+	case strings.EqualFold(mimeType, MimeTypeH264):
+		return &codecs.H264Packet{}
+	case strings.EqualFold(mimeType, MimeTypeAV1):
+		return &codecs.AV1Depacketizer{}
+	case strings.EqualFold(mimeType, MimeTypeOpus):
+		return &codecs.OpusPacket{}
+	default:
+		return nil
+	}
+}
+
+// setCodec starts over with the depacketizer of codec, the buffered packets are discarded.
+// b.mu must be held, unless b isn't shared yet.
+func (b *jitterBuffer) setCodec(codec RTPCodecParameters) error {
+	depacketizer := depacketizerForMimeType(codec.MimeType)
+	if depacketizer == nil || codec.ClockRate == 0 {
+		return ErrJitterBufferCodecNotSupported
+	}
+
+	b.mimeType = codec.MimeType
+	b.latencyTicks = uint32(b.latency.Seconds() * float64(codec.ClockRate))
+	b.received, b.started = false, false
+	b.builder = samplebuilder.New(
+		jitterBufferMaxLatePackets, depacketizer, codec.ClockRate,
+		samplebuilder.WithMaxTimeDelay(b.latency),
+	)
+
+	return nil
+}
+
+// push adds a packet of codec to the buffer, b.mu must be held.
+func (b *jitterBuffer) push(packet *rtp.Packet, codec RTPCodecParameters) error {
+	if !strings.EqualFold(codec.MimeType, b.mimeType) {
+		if err := b.setCodec(codec); err != nil {
+			return err
+		}
+	}
+	b.builder.Push(packet)
+
+	switch {
+	case b.started:
+	case !b.received:
+		b.firstTimestamp, b.received = packet.Timestamp, true
+	default:
+		b.started = int32(packet.Timestamp-b.firstTimestamp) >= int32(b.latencyTicks) //nolint:gosec // G115
+	}
+
+	return nil
+}
+
+// pop returns the next frame, nil until one is complete. b.mu must be held.
+func (b *jitterBuffer) pop() *media.Sample {
+	if !b.started {
+		return nil
+	}
+
+	return b.builder.Pop()
+}
@@ -29,7 +29,7 @@ func awaitPromise(promise js.Value) (js.Value, error) {
 
 	catchFunc := js.FuncOf(func(this js.Value, args []js.Value) any {
 		go func() {
-			errChan <- js.Error{args[0]}
+			errChan <- js.Error{Value: args[0]}
 		}()
 		return js.Undefined()
 	})
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"strings"
+)
+
+// keyframeDetector reports if an RTP payload carries the first packet of a keyframe.
+type keyframeDetector func(payload []byte) bool
+
+// keyframeDetectorForMimeType returns the keyframe detector for a codec, or nil
+// if keyframes can't be detected for it.
+func keyframeDetectorForMimeType(mimeType string) keyframeDetector {
+	switch {
+	case strings.EqualFold(mimeType, MimeTypeVP8):
+		return isVP8KeyframeStart
+	case strings.EqualFold(mimeType, MimeTypeVP9):
+		return isVP9KeyframeStart
+	case strings.EqualFold(mimeType, MimeTypeH264):
+		return isH264KeyframeStart
+	case strings.EqualFold(mimeType, MimeTypeH265):
+		return isH265KeyframeStart
+	case strings.EqualFold(mimeType, MimeTypeAV1):
+		return isAV1KeyframeStart
+	default:
+		return nil
+	}
+}
+
+// isVP8KeyframeStart parses the VP8 payload descriptor, see RFC 7741 section 4.2.
+func isVP8KeyframeStart(payload []byte) bool { //nolint:cyclop
+	if len(payload) < 1 {
+		return false
+	}
+
+	// The start of a keyframe is the start of partition 0.
+	if payload[0]&0x10 == 0 || payload[0]&0x07 != 0 {
+		return false
+	}
+
+	offset := 1
+	if payload[0]&0x80 != 0 {
+		if len(payload) <= offset {
+			return false
+		}
+		extension := payload[offset]
+		offset++
+
+		if extension&0x80 != 0 {
+			if len(payload) <= offset {
+				return false
+			}
+			if payload[offset]&0x80 != 0 {
+				offset++
+			}
+			offset++
+		}
+		if extension&0x40 != 0 {
+			offset++
+		}
+		if extension&0x30 != 0 {
+			offset++
+		}
+	}
+
+	if len(payload) <= offset {
+		return false
+	}
+
+	// The inverse keyframe bit of the VP8 payload header, see RFC 6386 section 9.1.
+	return payload[offset]&0x01 == 0
+}
+
+// isVP9KeyframeStart checks the VP9 payload descriptor, see RFC 9628 section 4.2.
+// A keyframe starts with the B bit set and the P bit cleared.
+func isVP9KeyframeStart(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	return payload[0]&0x08 != 0 && payload[0]&0x40 == 0
+}
+
+const (
+	h264NALUTypeIDR   = 5
+	h264NALUTypeSPS   = 7
+	h264NALUTypeSTAPA = 24
+	h264NALUTypeFUA   = 28
+)
+
+func isH264KeyframeNALU(naluType byte) bool {
+	return naluType == h264NALUTypeIDR || naluType == h264NALUTypeSPS
+}
+
+// isH264KeyframeStart checks for an IDR or SPS NAL unit, see RFC 6184 section 5.
+func isH264KeyframeStart(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	switch naluType := payload[0] & 0x1F; naluType {
+	case h264NALUTypeSTAPA:
+		for offset := 1; offset+2 < len(payload); {
+			size := int(binary.BigEndian.Uint16(payload[offset:]))
+			offset += 2
+			if size == 0 || offset >= len(payload) {
+				return false
+			}
+			if isH264KeyframeNALU(payload[offset] & 0x1F) {
+				return true
+			}
+			offset += size
+		}
+
+		return false
+	case h264NALUTypeFUA:
+		return len(payload) > 1 && payload[1]&0x80 != 0 && isH264KeyframeNALU(payload[1]&0x1F)
+	default:
+		return isH264KeyframeNALU(naluType)
+	}
+}
+
+const (
+	h265NALUTypeBLAWLP  = 16
+	h265NALUTypeRSVIRAP = 23
+	h265NALUTypeVPS     = 32
+	h265NALUTypePPS     = 34
+	h265NALUTypeAP      = 48
+	h265NALUTypeFU      = 49
+)
+
+func isH265KeyframeNALU(naluType byte) bool {
+	return (naluType >= h265NALUTypeBLAWLP && naluType <= h265NALUTypeRSVIRAP) ||
+		(naluType >= h265NALUTypeVPS && naluType <= h265NALUTypePPS)
+}
+
+// isH265KeyframeStart checks for an IRAP or parameter set NAL unit, see RFC 7798 section 4.4.
+func isH265KeyframeStart(payload []byte) bool {
+	if len(payload) < 2 {
+		return false
+	}
+
+	switch naluType := (payload[0] >> 1) & 0x3F; naluType {
+	case h265NALUTypeAP:
+		for offset := 2; offset+2 < len(payload); {
+			size := int(binary.BigEndian.Uint16(payload[offset:]))
+			offset += 2
+			if size == 0 || offset >= len(payload) {
+				return false
+			}
+			if isH265KeyframeNALU((payload[offset] >> 1) & 0x3F) {
+				return true
+			}
+			offset += size
+		}
+
+		return false
+	case h265NALUTypeFU:
+		return len(payload) > 2 && payload[2]&0x80 != 0 && isH265KeyframeNALU(payload[2]&0x3F)
+	default:
+		return isH265KeyframeNALU(naluType)
+	}
+}
+
+// isAV1KeyframeStart checks the N bit of the AV1 aggregation header which marks
+// the first packet of a coded video sequence.
+func isAV1KeyframeStart(payload []byte) bool {
+	if len(payload) < 1 {
+		return false
+	}
+
+	return payload[0]&0x08 != 0
+}
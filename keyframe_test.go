@@ -0,0 +1,59 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKeyframeDetectorForMimeType(t *testing.T) {
+	assert.NotNil(t, keyframeDetectorForMimeType(MimeTypeVP8))
+	assert.NotNil(t, keyframeDetectorForMimeType("video/vp8"))
+	assert.NotNil(t, keyframeDetectorForMimeType(MimeTypeVP9))
+	assert.NotNil(t, keyframeDetectorForMimeType(MimeTypeH264))
+	assert.NotNil(t, keyframeDetectorForMimeType(MimeTypeH265))
+	assert.NotNil(t, keyframeDetectorForMimeType(MimeTypeAV1))
+	assert.Nil(t, keyframeDetectorForMimeType(MimeTypeOpus))
+	assert.Nil(t, keyframeDetectorForMimeType(""))
+}
+
+func TestIsKeyframeStart(t *testing.T) {
+	for _, test := range []struct {
+		name     string
+		detector keyframeDetector
+		payload  []byte
+		expected bool
+	}{
+		{"VP8 empty", isVP8KeyframeStart, nil, false},
+		{"VP8 keyframe", isVP8KeyframeStart, []byte{0x10, 0x00}, true},
+		{"VP8 interframe", isVP8KeyframeStart, []byte{0x10, 0x01}, false},
+		{"VP8 continuation", isVP8KeyframeStart, []byte{0x00, 0x00}, false},
+		{"VP8 second partition", isVP8KeyframeStart, []byte{0x11, 0x00}, false},
+		{"VP8 keyframe with 15 bit picture ID", isVP8KeyframeStart, []byte{0x90, 0x80, 0x81, 0x02, 0x00}, true},
+		{"VP8 keyframe with all extensions", isVP8KeyframeStart, []byte{0x90, 0xF0, 0x01, 0x02, 0x03, 0x00}, true},
+		{"VP8 truncated extensions", isVP8KeyframeStart, []byte{0x90, 0xF0, 0x01}, false},
+		{"VP9 keyframe", isVP9KeyframeStart, []byte{0x08}, true},
+		{"VP9 interframe", isVP9KeyframeStart, []byte{0x48}, false},
+		{"VP9 continuation", isVP9KeyframeStart, []byte{0x00}, false},
+		{"H264 IDR", isH264KeyframeStart, []byte{0x65}, true},
+		{"H264 SPS", isH264KeyframeStart, []byte{0x67}, true},
+		{"H264 non-IDR", isH264KeyframeStart, []byte{0x41}, false},
+		{"H264 STAP-A with SPS", isH264KeyframeStart, []byte{0x78, 0x00, 0x02, 0x67, 0x42, 0x00, 0x01, 0x68}, true},
+		{"H264 STAP-A without SPS", isH264KeyframeStart, []byte{0x78, 0x00, 0x01, 0x41, 0x00, 0x01, 0x41}, false},
+		{"H264 FU-A IDR start", isH264KeyframeStart, []byte{0x7C, 0x85}, true},
+		{"H264 FU-A IDR middle", isH264KeyframeStart, []byte{0x7C, 0x05}, false},
+		{"H265 IDR", isH265KeyframeStart, []byte{0x26, 0x01}, true},
+		{"H265 VPS", isH265KeyframeStart, []byte{0x40, 0x01}, true},
+		{"H265 trailing picture", isH265KeyframeStart, []byte{0x02, 0x01}, false},
+		{"H265 AP with VPS", isH265KeyframeStart, []byte{0x60, 0x01, 0x00, 0x02, 0x40, 0x01}, true},
+		{"H265 FU IDR start", isH265KeyframeStart, []byte{0x62, 0x01, 0x93}, true},
+		{"H265 FU IDR middle", isH265KeyframeStart, []byte{0x62, 0x01, 0x13}, false},
+		{"AV1 new coded video sequence", isAV1KeyframeStart, []byte{0x18}, true},
+		{"AV1 interframe", isAV1KeyframeStart, []byte{0x10}, false},
+	} {
+		assert.Equal(t, test.expected, test.detector(test.payload), test.name)
+	}
+}
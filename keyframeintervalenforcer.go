@@ -0,0 +1,130 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+)
+
+// keyframeIntervalEnforcer asks for a keyframe when none was written for maxInterval.
+// Time is the media time of the written samples, the sum of their durations, or the
+// time between the timestamps of the written RTP packets.
+type keyframeIntervalEnforcer struct {
+	mu sync.Mutex
+
+	maxInterval time.Duration
+	request     func()
+
+	now          time.Duration
+	lastKeyframe time.Duration
+	seenKeyframe bool
+	nextRequest  time.Duration
+	pausedUntil  time.Duration
+
+	// the frame of the RTP packets written last, see observePacket
+	frameStarted   bool
+	frameTimestamp uint32
+	frameKeyframe  bool
+
+	enforcements  uint64
+	intervalCount uint64
+	intervalSum   time.Duration
+}
+
+func newKeyframeIntervalEnforcer(maxInterval time.Duration, request func()) *keyframeIntervalEnforcer {
+	return &keyframeIntervalEnforcer{
+		maxInterval: maxInterval,
+		request:     request,
+		nextRequest: maxInterval,
+	}
+}
+
+// observe records a written sample and reports if a keyframe has to be requested.
+func (k *keyframeIntervalEnforcer) observe(keyframe bool, duration time.Duration) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.advance(keyframe, duration)
+}
+
+// observePacket records a written RTP packet and reports if a keyframe has to be requested.
+// The packets of a frame share their timestamp, a frame is observed when the first packet
+// of the next one is written. Packets with an older timestamp are ignored.
+func (k *keyframeIntervalEnforcer) observePacket(timestamp, clockRate uint32, keyframe bool) bool {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if !k.frameStarted || timestamp == k.frameTimestamp {
+		k.frameStarted, k.frameTimestamp = true, timestamp
+		k.frameKeyframe = k.frameKeyframe || keyframe
+
+		return false
+	}
+
+	ticks := timestamp - k.frameTimestamp
+	if int32(ticks) < 0 { //nolint:gosec // G115, the difference wraps around
+		return false
+	}
+	frameKeyframe := k.frameKeyframe
+	k.frameTimestamp, k.frameKeyframe = timestamp, keyframe
+
+	duration := time.Duration(int64(ticks) * int64(time.Second) / int64(clockRate))
+
+	return k.advance(frameKeyframe, duration)
+}
+
+// advance moves the time by a written sample or frame, k.mu must be held.
+func (k *keyframeIntervalEnforcer) advance(keyframe bool, duration time.Duration) bool {
+	now := k.now
+	k.now += duration
+
+	if keyframe {
+		if k.seenKeyframe {
+			k.intervalSum += now - k.lastKeyframe
+			k.intervalCount++
+		}
+		k.lastKeyframe, k.seenKeyframe = now, true
+		k.nextRequest = now + k.maxInterval
+		k.pausedUntil = 0
+
+		return false
+	}
+
+	if now < k.nextRequest || now < k.pausedUntil {
+		return false
+	}
+	k.nextRequest = now + k.maxInterval
+	k.enforcements++
+
+	return true
+}
+
+// pause suppresses requests until the next keyframe or the end of the grace period.
+func (k *keyframeIntervalEnforcer) pause() {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	k.pausedUntil = k.now + keyframeEnforcementGracePeriod
+}
+
+func (k *keyframeIntervalEnforcer) enforcementCount() uint64 {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	return k.enforcements
+}
+
+func (k *keyframeIntervalEnforcer) averageInterval() time.Duration {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if k.intervalCount == 0 {
+		return 0
+	}
+
+	return k.intervalSum / time.Duration(k.intervalCount) //nolint:gosec // G115
+}
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"errors"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/interceptor"
+	"github.com/pion/transport/v4/vnet"
+)
+
+const (
+	loopbackCIDR       = "192.0.2.0/24"
+	loopbackOffererIP  = "192.0.2.1"
+	loopbackAnswererIP = "192.0.2.2"
+)
+
+// LoopbackPeerConnectionPair is a pair of PeerConnections connected to each other over an
+// in-memory network, see API.NewLoopbackPeerConnectionPair. The PeerConnections are regular
+// ones, ICE, DTLS, SCTP and the interceptors run as they would over a real network, without
+// opening any socket.
+type LoopbackPeerConnectionPair struct {
+	// Offerer creates the offers of Negotiate.
+	Offerer *PeerConnection
+	// Answerer answers the offers of Negotiate.
+	Answerer *PeerConnection
+
+	router *vnet.Router
+}
+
+// NewLoopbackPeerConnectionPair creates two PeerConnections with configuration, connected to
+// each other over an in-memory network. It is meant for testing the code that consumes the
+// PeerConnections, their tracks and DataChannels, without the time and flakiness of sockets.
+//
+// Both PeerConnections use the MediaEngine, the interceptors and the SettingEngine of the API,
+// except for the network settings of the SettingEngine which are replaced by the in-memory
+// network. Only host candidates are gathered, the ICEServers of configuration are not used.
+//
+// Tracks and DataChannels are added like on any PeerConnection, then Negotiate does the offer
+// and answer. Close closes both PeerConnections and the network.
+func (api *API) NewLoopbackPeerConnectionPair(configuration Configuration) (*LoopbackPeerConnectionPair, error) {
+	router, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          loopbackCIDR,
+		LoggerFactory: api.settingEngine.LoggerFactory,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pair := &LoopbackPeerConnectionPair{router: router}
+	configuration.ICEServers = nil
+
+	newPeerConnection := func(ip string) (*PeerConnection, error) {
+		network, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		if netErr != nil {
+			return nil, netErr
+		}
+		if netErr = router.AddNet(network); netErr != nil {
+			return nil, netErr
+		}
+
+		settingEngine := *api.settingEngine
+		settingEngine.SetNet(network)
+		settingEngine.SetNetworkTypes([]NetworkType{NetworkTypeUDP4})
+		settingEngine.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+		settingEngine.iceUDPMux = nil
+		settingEngine.iceTCPMux = nil
+		settingEngine.candidates.InterfaceFilter = nil
+		settingEngine.candidates.IPFilter = nil
+		settingEngine.candidates.NAT1To1IPs = nil
+		// The PeerConnections can't share the codecs they negotiate
+		settingEngine.disableMediaEngineCopy = false
+
+		return (&API{
+			settingEngine:       &settingEngine,
+			mediaEngine:         api.mediaEngine,
+			interceptorRegistry: api.interceptorRegistry,
+			handshakeLimiter:    api.handshakeLimiter,
+			interceptor:         &interceptor.NoOp{},
+		}).NewPeerConnection(configuration)
+	}
+
+	if pair.Offerer, err = newPeerConnection(loopbackOffererIP); err == nil {
+		if pair.Answerer, err = newPeerConnection(loopbackAnswererIP); err == nil {
+			err = router.Start()
+		}
+	}
+	if err != nil {
+		return nil, errors.Join(err, pair.closePeerConnections())
+	}
+
+	return pair, nil
+}
+
+// Negotiate makes Offerer create an offer, Answerer answer it, and sets both descriptions
+// on both PeerConnections. The candidates are gathered before the descriptions are exchanged,
+// the PeerConnections connect once Negotiate returns.
+func (p *LoopbackPeerConnectionPair) Negotiate() error {
+	offer, err := p.Offerer.CreateOffer(nil)
+	if err != nil {
+		return err
+	}
+	offerGatheringComplete := GatheringCompletePromise(p.Offerer)
+	if err = p.Offerer.SetLocalDescription(offer); err != nil {
+		return err
+	}
+	<-offerGatheringComplete
+
+	if err = p.Answerer.SetRemoteDescription(*p.Offerer.LocalDescription()); err != nil {
+		return err
+	}
+
+	answer, err := p.Answerer.CreateAnswer(nil)
+	if err != nil {
+		return err
+	}
+	answerGatheringComplete := GatheringCompletePromise(p.Answerer)
+	if err = p.Answerer.SetLocalDescription(answer); err != nil {
+		return err
+	}
+	<-answerGatheringComplete
+
+	return p.Offerer.SetRemoteDescription(*p.Answerer.LocalDescription())
+}
+
+// Close closes both PeerConnections, then the in-memory network.
+func (p *LoopbackPeerConnectionPair) Close() error {
+	return errors.Join(p.closePeerConnections(), p.router.Stop())
+}
+
+func (p *LoopbackPeerConnectionPair) closePeerConnections() error {
+	var err error
+	for _, pc := range []*PeerConnection{p.Offerer, p.Answerer} {
+		if pc != nil {
+			err = errors.Join(err, pc.Close())
+		}
+	}
+
+	return err
+}
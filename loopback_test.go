@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoopbackPeerConnectionPair(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pair, err := NewAPI().NewLoopbackPeerConnectionPair(Configuration{
+		ICEServers: []ICEServer{{URLs: []string{"stun:stun.invalid:3478"}}},
+	})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pair.Offerer.AddTrack(track)
+	require.NoError(t, err)
+
+	message := make(chan string, 1)
+	pair.Answerer.OnDataChannel(func(d *DataChannel) {
+		d.OnMessage(func(msg DataChannelMessage) {
+			message <- string(msg.Data)
+		})
+	})
+	dataChannel, err := pair.Offerer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	dataChannel.OnOpen(func() {
+		assert.NoError(t, dataChannel.SendText("pion"))
+	})
+
+	received := make(chan uint16, 10)
+	pair.Answerer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		for {
+			pkt, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			received <- pkt.SequenceNumber
+		}
+	})
+
+	require.NoError(t, pair.Negotiate())
+	for _, description := range []*SessionDescription{pair.Offerer.LocalDescription(), pair.Answerer.LocalDescription()} {
+		assert.Contains(t, description.SDP, "typ host")
+		assert.NotContains(t, description.SDP, "typ srflx")
+	}
+	assert.Equal(t, "pion", <-message)
+
+	// Sequence number 3 is lost, the answerer asks for it with a NACK
+	nack := make(chan []rtcp.NackPair, 1)
+	go func() {
+		for {
+			packets, _, readErr := sender.ReadRTCP()
+			if readErr != nil {
+				return
+			}
+			for _, packet := range packets {
+				if n, ok := packet.(*rtcp.TransportLayerNack); ok {
+					select {
+					case nack <- n.Nacks:
+					default:
+					}
+				}
+			}
+		}
+	}()
+
+	for _, sequenceNumber := range []uint16{1, 2, 4, 5} {
+		require.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+			Payload: []byte{0x10, 0x00},
+		}))
+	}
+	for _, sequenceNumber := range []uint16{1, 2, 4, 5} {
+		assert.Equal(t, sequenceNumber, <-received)
+	}
+	assert.Equal(t, []rtcp.NackPair{{PacketID: 3}}, <-nack)
+
+	assert.NoError(t, pair.Close())
+}
@@ -8,6 +8,7 @@ package webrtc
 import (
 	"errors"
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -33,6 +34,9 @@ type MediaEngine struct {
 	// If we have attempted to negotiate a codec type yet.
 	negotiatedVideo, negotiatedAudio bool
 	negotiateMultiCodecs             bool
+	rtcpReducedSizeDisabled          bool
+
+	videoCodecMatch, audioCodecMatch CodecMatchPreference
 
 	videoCodecs, audioCodecs                     []RTPCodecParameters
 	negotiatedVideoCodecs, negotiatedAudioCodecs []RTPCodecParameters
@@ -40,17 +44,80 @@ type MediaEngine struct {
 	headerExtensions           []mediaEngineHeaderExtension
 	negotiatedHeaderExtensions map[int]mediaEngineHeaderExtension
 
+	// generation is incremented by every change of the MediaEngine, parameters
+	// resolved from it are cached until it changes.
+	generation          uint64
+	rtpParametersByKind map[rtpParametersKey]RTPParameters
+
+	// The PeerConnections using the MediaEngine or a copy of it, codecs they use can't be unregistered
+	users map[codecUser]struct{}
+	// origin is the MediaEngine this one was copied from for a PeerConnection
+	origin *MediaEngine
+
+	// unregisterMu is held by UnregisterCodec and ResetCodecs from checking that
+	// codecs are unused until they are removed. It is separate from mu, which the
+	// users take to check their codecs.
+	unregisterMu sync.Mutex
+
 	mu sync.RWMutex
 }
 
+// codecUser is implemented by the users of a MediaEngine.
+type codecUser interface {
+	usesCodec(mimeType string, typ RTPCodecType) bool
+	// codecMediaEngine returns the MediaEngine the user negotiates with, which is a copy
+	// of the one it was added to unless SettingEngine.DisableMediaEngineCopy is used.
+	codecMediaEngine() *MediaEngine
+}
+
+type rtpParametersKey struct {
+	typ        RTPCodecType
+	directions uint32 // bit set of RTPTransceiverDirection
+}
+
+// changed invalidates everything resolved from the MediaEngine, m.mu must be held.
+func (m *MediaEngine) changed() {
+	m.generation++
+	m.rtpParametersByKind = nil
+}
+
+// getGeneration returns the current generation of the MediaEngine.
+func (m *MediaEngine) getGeneration() uint64 {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.generation
+}
+
 // setMultiCodecNegotiation enables or disables the negotiation of multiple codecs.
 func (m *MediaEngine) setMultiCodecNegotiation(negotiateMultiCodecs bool) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.changed()
 
 	m.negotiateMultiCodecs = negotiateMultiCodecs
 }
 
+// SetRTCPReducedSize sets if reduced-size RTCP (RFC 5506) is offered and accepted with
+// a=rtcp-rsize, it is enabled by default like in browsers. When it isn't negotiated, the
+// RTCP feedback written without a report is sent as a compound packet led by an empty
+// receiver report.
+func (m *MediaEngine) SetRTCPReducedSize(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changed()
+
+	m.rtcpReducedSizeDisabled = !enabled
+}
+
+// rtcpReducedSize returns true if reduced-size RTCP is offered and accepted.
+func (m *MediaEngine) rtcpReducedSize() bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return !m.rtcpReducedSizeDisabled
+}
+
 // multiCodecNegotiation returns the current state of the negotiation of multiple codecs.
 func (m *MediaEngine) multiCodecNegotiation() bool {
 	m.mu.RLock()
@@ -59,13 +126,64 @@ func (m *MediaEngine) multiCodecNegotiation() bool {
 	return m.negotiateMultiCodecs
 }
 
+// SetCodecMatchPreference sets how the codecs of a kind in remote descriptions are matched
+// with the registered codecs, both when answering and when mapping the payload types of
+// incoming RTP packets. The default is CodecMatchStrict.
+func (m *MediaEngine) SetCodecMatchPreference(typ RTPCodecType, preference CodecMatchPreference) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.changed()
+
+	switch typ {
+	case RTPCodecTypeVideo:
+		m.videoCodecMatch = preference
+	case RTPCodecTypeAudio:
+		m.audioCodecMatch = preference
+	default:
+	}
+}
+
+// codecMatchPreference returns the CodecMatchPreference of a kind, m.mu must be held.
+func (m *MediaEngine) codecMatchPreference(typ RTPCodecType) CodecMatchPreference {
+	if typ == RTPCodecTypeAudio {
+		return m.audioCodecMatch
+	}
+
+	return m.videoCodecMatch
+}
+
+func (m *MediaEngine) getCodecMatchPreference(typ RTPCodecType) CodecMatchPreference {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.codecMatchPreference(typ)
+}
+
 // RegisterDefaultCodecs registers the default codecs supported by Pion WebRTC.
 // RegisterDefaultCodecs is not safe for concurrent use.
 func (m *MediaEngine) RegisterDefaultCodecs() error {
+	return m.RegisterDefaultCodecsWithOptions()
+}
+
+// RegisterDefaultCodecsWithOptions is RegisterDefaultCodecs, with options adjusting the
+// parameters of the default codecs. The Opus parameters tell the remote what is preferred
+// for receiving. In an answer, stereo and useinbandfec are only kept if the remote offered
+// them too, and the lowest maxaveragebitrate of both sides is used.
+// RegisterDefaultCodecsWithOptions is not safe for concurrent use.
+func (m *MediaEngine) RegisterDefaultCodecsWithOptions(opts ...DefaultCodecsOption) error {
+	options := defaultCodecsOptions{opusInbandFEC: true}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	opusFmtpLine, err := options.opusFmtpLine()
+	if err != nil {
+		return err
+	}
+
 	// Default Pion Audio Codecs
 	for _, codec := range []RTPCodecParameters{
 		{
-			RTPCodecCapability: RTPCodecCapability{MimeTypeOpus, 48000, 2, "minptime=10;useinbandfec=1", nil},
+			RTPCodecCapability: RTPCodecCapability{MimeTypeOpus, 48000, 2, opusFmtpLine, nil},
 			PayloadType:        111,
 		},
 		{
@@ -254,9 +372,12 @@ func (m *MediaEngine) addCodec(codecs []RTPCodecParameters, codec RTPCodecParame
 
 // RegisterCodec adds codec to the MediaEngine
 // These are the list of codecs supported by this PeerConnection.
+// RTX, RED and FEC codecs must reference codecs of the same kind, NewPeerConnection
+// returns ErrUnknownAssociatedPayloadType otherwise.
 func (m *MediaEngine) RegisterCodec(codec RTPCodecParameters, typ RTPCodecType) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.changed()
 
 	var err error
 	codec.statsID = fmt.Sprintf("RTPCodec-%d", time.Now().UnixNano())
@@ -272,8 +393,205 @@ func (m *MediaEngine) RegisterCodec(codec RTPCodecParameters, typ RTPCodecType)
 	return err
 }
 
+// UnregisterCodec removes the codecs of mimeType from the MediaEngine, together with the RTX,
+// RED and FEC codecs referencing them. Subsequent offers and answers of the PeerConnections
+// using the MediaEngine don't contain them anymore, even if they were negotiated before.
+// The codecs are removed from the copies the PeerConnections made of the MediaEngine too.
+//
+// ErrCodecInUse is returned and nothing is removed if a sender or receiver of one of these
+// PeerConnections uses such a codec. ErrCodecNotFound is returned if there is none.
+func (m *MediaEngine) UnregisterCodec(mimeType string, typ RTPCodecType) error {
+	if typ != RTPCodecTypeAudio && typ != RTPCodecTypeVideo {
+		return ErrUnknownType
+	}
+
+	m.unregisterMu.Lock()
+	defer m.unregisterMu.Unlock()
+
+	if m.codecInUse(mimeType, typ) {
+		return fmt.Errorf("%w: %s", ErrCodecInUse, mimeType)
+	}
+
+	isMimeType := func(codec RTPCodecParameters) bool {
+		return strings.EqualFold(codec.MimeType, mimeType)
+	}
+	m.mu.RLock()
+	codecs, negotiatedCodecs := m.codecListsByKind(typ)
+	found := slices.ContainsFunc(*codecs, isMimeType) || slices.ContainsFunc(*negotiatedCodecs, isMimeType)
+	m.mu.RUnlock()
+	if !found {
+		return fmt.Errorf("%w: %s", ErrCodecNotFound, mimeType)
+	}
+
+	for _, engine := range m.engines() {
+		engine.unregisterCodecs(typ, isMimeType)
+	}
+
+	return nil
+}
+
+// ResetCodecs removes all the codecs of typ from the MediaEngine, so others can be registered
+// for the subsequent negotiations. Codecs used by a sender or receiver of a PeerConnection
+// using the MediaEngine are kept, see UnregisterCodec.
+func (m *MediaEngine) ResetCodecs(typ RTPCodecType) {
+	if typ != RTPCodecTypeAudio && typ != RTPCodecTypeVideo {
+		return
+	}
+
+	m.unregisterMu.Lock()
+	defer m.unregisterMu.Unlock()
+
+	engines := m.engines()
+	mimeTypes := map[string]struct{}{}
+	for _, engine := range engines {
+		engine.mu.RLock()
+		codecs, negotiatedCodecs := engine.codecListsByKind(typ)
+		for _, codec := range slices.Concat(*codecs, *negotiatedCodecs) {
+			mimeTypes[strings.ToLower(codec.MimeType)] = struct{}{}
+		}
+		engine.mu.RUnlock()
+	}
+
+	inUse := map[string]bool{}
+	for mimeType := range mimeTypes {
+		inUse[mimeType] = m.codecInUse(mimeType, typ)
+	}
+
+	// RTX, RED and FEC codecs go away with their primary codecs
+	unused := func(codec RTPCodecParameters) bool {
+		payloadTypes, err := associatedPayloadTypes(codec)
+
+		return err == nil && len(payloadTypes) == 0 && !inUse[strings.ToLower(codec.MimeType)]
+	}
+	for _, engine := range engines {
+		engine.unregisterCodecs(typ, unused)
+	}
+}
+
+// engines returns the MediaEngine and the copies of it its users negotiate with.
+func (m *MediaEngine) engines() []*MediaEngine {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	engines := []*MediaEngine{m}
+	for user := range m.users {
+		if engine := user.codecMediaEngine(); !slices.Contains(engines, engine) {
+			engines = append(engines, engine)
+		}
+	}
+
+	return engines
+}
+
+// unregisterCodecs removes the registered and negotiated codecs of typ matching remove.
+func (m *MediaEngine) unregisterCodecs(typ RTPCodecType, remove func(RTPCodecParameters) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.changed()
+	codecs, negotiatedCodecs := m.codecListsByKind(typ)
+	*codecs = removeCodecs(*codecs, remove)
+	*negotiatedCodecs = removeCodecs(*negotiatedCodecs, remove)
+}
+
+// removeNegotiatedCodecs removes the negotiated codecs of typ matching remove.
+func (m *MediaEngine) removeNegotiatedCodecs(typ RTPCodecType, remove func(RTPCodecParameters) bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, negotiatedCodecs := m.codecListsByKind(typ)
+	if !slices.ContainsFunc(*negotiatedCodecs, remove) {
+		return
+	}
+
+	m.changed()
+	*negotiatedCodecs = removeCodecs(*negotiatedCodecs, remove)
+}
+
+// codecListsByKind returns the registered and the negotiated codecs of typ, m.mu must be held.
+func (m *MediaEngine) codecListsByKind(typ RTPCodecType) (*[]RTPCodecParameters, *[]RTPCodecParameters) {
+	if typ == RTPCodecTypeAudio {
+		return &m.audioCodecs, &m.negotiatedAudioCodecs
+	}
+
+	return &m.videoCodecs, &m.negotiatedVideoCodecs
+}
+
+// removeCodecs returns a copy of codecs without the ones matching remove and the RTX, RED
+// and FEC codecs left without primary. The slices of the MediaEngine are handed out, so
+// they are never modified in place.
+func removeCodecs(codecs []RTPCodecParameters, remove func(RTPCodecParameters) bool) []RTPCodecParameters {
+	kept := make([]RTPCodecParameters, 0, len(codecs))
+	for _, codec := range codecs {
+		if !remove(codec) {
+			kept = append(kept, codec)
+		}
+	}
+
+	return filterUnattachedCodecs(kept)
+}
+
+// codecInUse reports if a user of the MediaEngine uses a codec of mimeType.
+func (m *MediaEngine) codecInUse(mimeType string, typ RTPCodecType) bool {
+	m.mu.RLock()
+	users := make([]codecUser, 0, len(m.users))
+	for user := range m.users {
+		users = append(users, user)
+	}
+	m.mu.RUnlock()
+
+	return slices.ContainsFunc(users, func(user codecUser) bool {
+		return user.usesCodec(mimeType, typ)
+	})
+}
+
+func (m *MediaEngine) addUser(user codecUser) {
+	m.mu.Lock()
+	if m.users == nil {
+		m.users = map[codecUser]struct{}{}
+	}
+	m.users[user] = struct{}{}
+	m.mu.Unlock()
+
+	// The MediaEngine the copy was made from applies its changes to it
+	if m.origin != nil {
+		m.origin.addUser(user)
+	}
+}
+
+func (m *MediaEngine) removeUser(user codecUser) {
+	m.mu.Lock()
+	delete(m.users, user)
+	m.mu.Unlock()
+
+	if m.origin != nil {
+		m.origin.removeUser(user)
+	}
+}
+
+// validateCodecAssociations checks that the RTX, RED and FEC codecs only reference
+// registered codecs of the same kind.
+func (m *MediaEngine) validateCodecAssociations() error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, codecs := range [][]RTPCodecParameters{m.audioCodecs, m.videoCodecs} {
+		for _, codec := range codecs {
+			if !codecAssociationsExist(codec, codecs) {
+				return fmt.Errorf("%w: %s %d", ErrUnknownAssociatedPayloadType, codec.MimeType, codec.PayloadType)
+			}
+		}
+	}
+
+	return nil
+}
+
 // RegisterHeaderExtension adds a header extension to the MediaEngine
 // To determine the negotiated value use `GetHeaderExtensionID` after signaling is complete.
+// An extension allowed in only one direction has it in its extmap, and the directions the
+// remote allows with its extmap are respected by the RTPSenders and RTPReceivers. Like the
+// IDs, the directions are negotiated per URI and kind for all media sections, an extension
+// the remote allows in a direction in any media section of typ is used in it by all of them.
 //
 //nolint:cyclop
 func (m *MediaEngine) RegisterHeaderExtension(
@@ -283,6 +601,7 @@ func (m *MediaEngine) RegisterHeaderExtension(
 ) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.changed()
 
 	if m.negotiatedHeaderExtensions == nil {
 		m.negotiatedHeaderExtensions = map[int]mediaEngineHeaderExtension{}
@@ -326,6 +645,7 @@ func (m *MediaEngine) RegisterHeaderExtension(
 func (m *MediaEngine) RegisterFeedback(feedback RTCPFeedback, typ RTPCodecType) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.changed()
 
 	addUniqueFeedback := func(existing []RTCPFeedback) []RTCPFeedback {
 		for _, f := range existing {
@@ -383,6 +703,12 @@ func (m *MediaEngine) copy() *MediaEngine {
 		videoCodecs:      append([]RTPCodecParameters{}, m.videoCodecs...),
 		audioCodecs:      append([]RTPCodecParameters{}, m.audioCodecs...),
 		headerExtensions: append([]mediaEngineHeaderExtension{}, m.headerExtensions...),
+		videoCodecMatch:  m.videoCodecMatch,
+		audioCodecMatch:  m.audioCodecMatch,
+
+		rtcpReducedSizeDisabled: m.rtcpReducedSizeDisabled,
+
+		origin: m,
 	}
 	if len(m.headerExtensions) > 0 {
 		cloned.negotiatedHeaderExtensions = map[int]mediaEngineHeaderExtension{}
@@ -431,11 +757,13 @@ func (m *MediaEngine) getCodecByPayload(payloadType PayloadType) (RTPCodecParame
 	return RTPCodecParameters{}, 0, ErrCodecNotFound
 }
 
+// collectStats collects the negotiated codecs, which the RTP stream stats reference, and
+// the registered codecs of the kinds that are not negotiated yet.
 func (m *MediaEngine) collectStats(collector *statsReportCollector) {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
 
-	statsLoop := func(codecs []RTPCodecParameters) {
+	statsLoop := func(codecs []RTPCodecParameters, transportID string) {
 		for _, codec := range codecs {
 			collector.Collecting()
 			stats := CodecStats{
@@ -443,6 +771,7 @@ func (m *MediaEngine) collectStats(collector *statsReportCollector) {
 				Type:        StatsTypeCodec,
 				ID:          codec.statsID,
 				PayloadType: codec.PayloadType,
+				TransportID: transportID,
 				MimeType:    codec.MimeType,
 				ClockRate:   codec.ClockRate,
 				Channels:    uint8(codec.Channels), //nolint:gosec // G115
@@ -453,8 +782,16 @@ func (m *MediaEngine) collectStats(collector *statsReportCollector) {
 		}
 	}
 
-	statsLoop(m.videoCodecs)
-	statsLoop(m.audioCodecs)
+	if m.negotiatedVideo {
+		statsLoop(m.negotiatedVideoCodecs, "iceTransport")
+	} else {
+		statsLoop(m.videoCodecs, "")
+	}
+	if m.negotiatedAudio {
+		statsLoop(m.negotiatedAudioCodecs, "iceTransport")
+	} else {
+		statsLoop(m.audioCodecs, "")
+	}
 }
 
 // Look up a codec and enable if it exists.
@@ -469,6 +806,7 @@ func (m *MediaEngine) matchRemoteCodec(
 	if typ == RTPCodecTypeAudio {
 		codecs = m.audioCodecs
 	}
+	preference := m.codecMatchPreference(typ)
 
 	remoteFmtp := fmtp.Parse(
 		remoteCodec.RTPCodecCapability.MimeType,
@@ -510,7 +848,7 @@ func (m *MediaEngine) matchRemoteCodec(
 
 		// replace the apt value with the original codec's payload type
 		toMatchCodec := remoteCodec
-		if aptMatched, mt := codecParametersFuzzySearch(aptCodec, codecs); mt == aptMatch {
+		if aptMatched, mt := codecParametersFuzzySearchWithPreference(aptCodec, codecs, preference); mt == aptMatch {
 			toMatchCodec.SDPFmtpLine = strings.Replace(
 				toMatchCodec.SDPFmtpLine,
 				fmt.Sprintf("apt=%d", payloadType),
@@ -520,7 +858,7 @@ func (m *MediaEngine) matchRemoteCodec(
 		}
 
 		// if apt's media codec is partial match, then apt codec must be partial match too.
-		localCodec, matchType := codecParametersFuzzySearch(toMatchCodec, codecs)
+		localCodec, matchType := codecParametersFuzzySearchWithPreference(toMatchCodec, codecs, preference)
 		if matchType == codecMatchExact && aptMatch == codecMatchPartial {
 			matchType = codecMatchPartial
 		}
@@ -528,13 +866,14 @@ func (m *MediaEngine) matchRemoteCodec(
 		return localCodec, matchType, nil
 	}
 
-	localCodec, matchType := codecParametersFuzzySearch(remoteCodec, codecs)
+	localCodec, matchType := codecParametersFuzzySearchWithPreference(remoteCodec, codecs, preference)
 
 	return localCodec, matchType, nil
 }
 
-// Update header extensions from a remote media section.
-func (m *MediaEngine) updateHeaderExtensionFromMediaSection(media *sdp.MediaDescription) error {
+// Update header extensions from a remote media section. The IDs that require the two-byte
+// header extension form are ignored unless the remote signaled a=extmap-allow-mixed.
+func (m *MediaEngine) updateHeaderExtensionFromMediaSection(media *sdp.MediaDescription, extmapAllowMixed bool) error {
 	var typ RTPCodecType
 	switch {
 	case strings.EqualFold(media.MediaName.Media, "audio"):
@@ -548,9 +887,16 @@ func (m *MediaEngine) updateHeaderExtensionFromMediaSection(media *sdp.MediaDesc
 	if err != nil {
 		return err
 	}
+	directions, err := rtpExtensionDirectionsFromMediaDescription(media)
+	if err != nil {
+		return err
+	}
 
 	for extension, id := range extensions {
-		if err = m.updateHeaderExtension(id, extension, typ); err != nil {
+		if id > maxOneByteHeaderExtensionID && !extmapAllowMixed {
+			continue
+		}
+		if err = m.updateHeaderExtension(id, extension, typ, directions[extension]); err != nil {
 			return err
 		}
 	}
@@ -558,17 +904,33 @@ func (m *MediaEngine) updateHeaderExtensionFromMediaSection(media *sdp.MediaDesc
 	return nil
 }
 
-// Look up a header extension and enable if it exists.
-func (m *MediaEngine) updateHeaderExtension(id int, extension string, typ RTPCodecType) error {
+// Look up a header extension and enable if it exists. remoteDirection is the direction of
+// its extmap in the remote description, RTPTransceiverDirectionUnknown if it has none.
+func (m *MediaEngine) updateHeaderExtension(
+	id int,
+	extension string,
+	typ RTPCodecType,
+	remoteDirection RTPTransceiverDirection,
+) error {
 	if m.negotiatedHeaderExtensions == nil {
 		return nil
 	}
 
 	for _, localExtension := range m.headerExtensions {
 		if localExtension.uri == extension {
-			h := mediaEngineHeaderExtension{uri: extension, allowedDirections: localExtension.allowedDirections}
+			allowedDirections := negotiateHeaderExtensionDirections(localExtension.allowedDirections, remoteDirection)
+			if len(allowedDirections) == 0 {
+				continue
+			}
+
+			h := mediaEngineHeaderExtension{uri: extension, allowedDirections: allowedDirections}
 			if existingValue, ok := m.negotiatedHeaderExtensions[id]; ok {
 				h = existingValue
+				for _, direction := range allowedDirections {
+					if !slices.Contains(h.allowedDirections, direction) {
+						h.allowedDirections = append(slices.Clone(h.allowedDirections), direction)
+					}
+				}
 			}
 
 			switch {
@@ -585,9 +947,98 @@ func (m *MediaEngine) updateHeaderExtension(id int, extension string, typ RTPCod
 	return nil
 }
 
+// negotiateHeaderExtensionDirections returns the local directions of a header extension the
+// remote allows with the direction of its extmap, which is from the remote's point of view.
+func negotiateHeaderExtensionDirections(
+	local []RTPTransceiverDirection,
+	remoteDirection RTPTransceiverDirection,
+) []RTPTransceiverDirection {
+	if remoteDirection == RTPTransceiverDirectionUnknown {
+		return local
+	}
+
+	allowed := make([]RTPTransceiverDirection, 0, len(local))
+	for _, direction := range local {
+		if direction == RTPTransceiverDirectionSendonly && remoteDirection.hasRecv() ||
+			direction == RTPTransceiverDirectionRecvonly && remoteDirection.hasSend() {
+			allowed = append(allowed, direction)
+		}
+	}
+
+	return allowed
+}
+
+// headerExtensionDirection returns the direction a header extension of typ is used in, the
+// negotiated one once typ was negotiated. It is RTPTransceiverDirectionSendrecv if the
+// extension isn't restricted to a direction, or isn't known. The direction isn't scoped to a
+// media section: the negotiated directions of all media sections of typ are merged, the same
+// way all of them share the ID of the extension.
+func (m *MediaEngine) headerExtensionDirection(uri string, typ RTPCodecType) RTPTransceiverDirection {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var allowedDirections []RTPTransceiverDirection
+	found := false
+	matches := func(e mediaEngineHeaderExtension) bool {
+		return e.uri == uri && (e.isAudio && typ == RTPCodecTypeAudio || e.isVideo && typ == RTPCodecTypeVideo)
+	}
+	if (m.negotiatedVideo && typ == RTPCodecTypeVideo) || (m.negotiatedAudio && typ == RTPCodecTypeAudio) {
+		for _, e := range m.negotiatedHeaderExtensions {
+			if matches(e) {
+				allowedDirections, found = e.allowedDirections, true
+
+				break
+			}
+		}
+	} else {
+		for _, e := range m.headerExtensions {
+			if matches(e) {
+				allowedDirections, found = e.allowedDirections, true
+
+				break
+			}
+		}
+	}
+
+	send := slices.Contains(allowedDirections, RTPTransceiverDirectionSendonly)
+	recv := slices.Contains(allowedDirections, RTPTransceiverDirectionRecvonly)
+	switch {
+	case !found || send && recv:
+		return RTPTransceiverDirectionSendrecv
+	case send:
+		return RTPTransceiverDirectionSendonly
+	case recv:
+		return RTPTransceiverDirectionRecvonly
+	default:
+		return RTPTransceiverDirectionInactive
+	}
+}
+
+// headerExtensionsForDirection returns the header extensions of typ that are used in direction,
+// which is RTPTransceiverDirectionSendonly or RTPTransceiverDirectionRecvonly.
+func (m *MediaEngine) headerExtensionsForDirection(
+	extensions []RTPHeaderExtensionParameter,
+	typ RTPCodecType,
+	direction RTPTransceiverDirection,
+) []RTPHeaderExtensionParameter {
+	filtered := make([]RTPHeaderExtensionParameter, 0, len(extensions))
+	for _, extension := range extensions {
+		allowed := m.headerExtensionDirection(extension.URI, typ)
+		if direction == RTPTransceiverDirectionSendonly && allowed.hasSend() ||
+			direction == RTPTransceiverDirectionRecvonly && allowed.hasRecv() {
+			filtered = append(filtered, extension)
+		}
+	}
+
+	return filtered
+}
+
 func (m *MediaEngine) pushCodecs(codecs []RTPCodecParameters, typ RTPCodecType) error {
 	var joinedErr error
 	for _, codec := range codecs {
+		// The payload types of a transport are unique, and keep their codec on renegotiation
+		codec.statsID = fmt.Sprintf("RTPCodec-%s-%d", typ, codec.PayloadType)
+
 		var err error
 		if typ == RTPCodecTypeAudio {
 			m.negotiatedAudioCodecs, err = m.addCodec(m.negotiatedAudioCodecs, codec)
@@ -606,7 +1057,9 @@ func (m *MediaEngine) pushCodecs(codecs []RTPCodecParameters, typ RTPCodecType)
 func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) error { //nolint:cyclop,gocognit
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.changed()
 
+	extmapAllowMixed := isExtMapAllowMixedSet(&desc)
 	for _, media := range desc.MediaDescriptions {
 		var typ RTPCodecType
 
@@ -627,7 +1080,7 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 			// would send updated header extension in renegotiation.
 			// e.g. publish first track without simucalst ->negotiated-> publish second track with simucalst
 			// then the two media secontions have different rtp header extensions in offer
-			if err := m.updateHeaderExtensionFromMediaSection(media); err != nil {
+			if err := m.updateHeaderExtensionFromMediaSection(media, extmapAllowMixed); err != nil {
 				return err
 			}
 
@@ -668,6 +1121,9 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 			}
 
 			remoteCodec.RTCPFeedback = rtcpFeedbackIntersection(localCodec.RTCPFeedback, remoteCodec.RTCPFeedback)
+			if matchType != codecMatchNone && strings.EqualFold(remoteCodec.MimeType, MimeTypeOpus) {
+				remoteCodec.SDPFmtpLine = opusFmtpIntersection(localCodec.SDPFmtpLine, remoteCodec.SDPFmtpLine)
+			}
 
 			if matchType == codecMatchExact {
 				exactMatches = addIfNew(exactMatches, remoteCodec)
@@ -683,6 +1139,9 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 			}
 
 			remoteCodec.RTCPFeedback = rtcpFeedbackIntersection(localCodec.RTCPFeedback, remoteCodec.RTCPFeedback)
+			if matchType != codecMatchNone && strings.EqualFold(remoteCodec.MimeType, MimeTypeOpus) {
+				remoteCodec.SDPFmtpLine = opusFmtpIntersection(localCodec.SDPFmtpLine, remoteCodec.SDPFmtpLine)
+			}
 
 			if matchType == codecMatchExact {
 				exactMatches = addIfNew(exactMatches, remoteCodec)
@@ -691,12 +1150,13 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 			}
 		}
 
-		// use exact matches when they exist, otherwise fall back to partial
+		// use exact matches when they exist, otherwise fall back to partial.
+		// RTX, RED and FEC codecs whose primary codec didn't match are dropped.
 		switch {
 		case len(exactMatches) > 0:
-			err = m.pushCodecs(exactMatches, typ)
+			err = m.pushCodecs(filterUnattachedCodecs(exactMatches), typ)
 		case len(partialMatches) > 0:
-			err = m.pushCodecs(partialMatches, typ)
+			err = m.pushCodecs(filterUnattachedCodecs(partialMatches), typ)
 		default:
 			// no match, not negotiated
 			continue
@@ -705,7 +1165,7 @@ func (m *MediaEngine) updateFromRemoteDescription(desc sdp.SessionDescription) e
 			return err
 		}
 
-		if err := m.updateHeaderExtensionFromMediaSection(media); err != nil {
+		if err := m.updateHeaderExtensionFromMediaSection(media, extmapAllowMixed); err != nil {
 			return err
 		}
 	}
@@ -734,8 +1194,43 @@ func (m *MediaEngine) getCodecsByKind(typ RTPCodecType) []RTPCodecParameters {
 	return nil
 }
 
-//nolint:gocognit,cyclop
 func (m *MediaEngine) getRTPParametersByKind(typ RTPCodecType, directions []RTPTransceiverDirection) RTPParameters {
+	key := rtpParametersKey{typ: typ}
+	for _, direction := range directions {
+		key.directions |= 1 << uint(direction)
+	}
+
+	m.mu.RLock()
+	parameters, ok := m.rtpParametersByKind[key]
+	generation := m.generation
+	m.mu.RUnlock()
+
+	if !ok {
+		parameters = m.resolveRTPParametersByKind(typ, directions)
+
+		m.mu.Lock()
+		if m.generation == generation {
+			if m.rtpParametersByKind == nil {
+				m.rtpParametersByKind = map[rtpParametersKey]RTPParameters{}
+			}
+			m.rtpParametersByKind[key] = parameters
+		}
+		m.mu.Unlock()
+	}
+
+	// Callers are allowed to modify the header extensions, the codecs are
+	// shared with the MediaEngine as they have always been.
+	return RTPParameters{
+		HeaderExtensions: slices.Clone(parameters.HeaderExtensions),
+		Codecs:           parameters.Codecs,
+	}
+}
+
+//nolint:gocognit,cyclop
+func (m *MediaEngine) resolveRTPParametersByKind(
+	typ RTPCodecType,
+	directions []RTPTransceiverDirection,
+) RTPParameters {
 	headerExtensions := make([]RTPHeaderExtensionParameter, 0)
 
 	// perform before locking to prevent recursive RLocks
@@ -765,7 +1260,9 @@ func (m *MediaEngine) getRTPParametersByKind(typ RTPCodecType, directions []RTPT
 				}
 			}
 			if !usingNegotiatedID {
-				for id := 1; id < 15; id++ {
+				// The IDs above maxOneByteHeaderExtensionID are left out unless
+				// a=extmap-allow-mixed is signaled, see withoutTwoByteHeaderExtensionIDs
+				for id := 1; id <= maxTwoByteHeaderExtensionID; id++ {
 					idAvailable := true
 					if _, ok := mediaHeaderExtensions[id]; ok {
 						idAvailable = false
@@ -839,6 +1336,22 @@ func payloaderForCodec(codec RTPCodecCapability) (rtp.Payloader, error) {
 	}
 }
 
+// disableRTX removes the RTX codecs of the given kinds.
+// withoutTwoByteHeaderExtensionIDs returns parameters without the header extensions whose IDs
+// require the two-byte form, for PeerConnections that don't signal a=extmap-allow-mixed.
+func withoutTwoByteHeaderExtensionIDs(parameters RTPParameters) RTPParameters {
+	isTwoByte := func(ext RTPHeaderExtensionParameter) bool {
+		return ext.ID > maxOneByteHeaderExtensionID
+	}
+	parameters.HeaderExtensions = slices.DeleteFunc(parameters.HeaderExtensions, isTwoByte)
+
+	return parameters
+}
+
+func isRTXCodec(codec RTPCodecParameters) bool {
+	return strings.EqualFold(codec.MimeType, MimeTypeRTX)
+}
+
 func (m *MediaEngine) isRTXEnabled(typ RTPCodecType, directions []RTPTransceiverDirection) bool {
 	for _, p := range m.getRTPParametersByKind(typ, directions).Codecs {
 		if strings.EqualFold(p.MimeType, MimeTypeRTX) {
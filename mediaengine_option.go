@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	opusMinAverageBitrate = 6000
+	opusMaxAverageBitrate = 510000
+)
+
+// defaultCodecsOptions contains the options of RegisterDefaultCodecsWithOptions.
+type defaultCodecsOptions struct {
+	opusStereo            bool
+	opusInbandFEC         bool
+	opusMaxAverageBitrate uint32
+}
+
+// DefaultCodecsOption is a function that configures the codecs registered by
+// RegisterDefaultCodecsWithOptions.
+type DefaultCodecsOption func(*defaultCodecsOptions)
+
+// WithOpusStereo sets whether stereo Opus is preferred for receiving, with stereo=1.
+// It is off by default.
+func WithOpusStereo(stereo bool) DefaultCodecsOption {
+	return func(o *defaultCodecsOptions) {
+		o.opusStereo = stereo
+	}
+}
+
+// WithOpusInbandFEC sets whether Opus in-band forward error correction is requested for
+// receiving, with useinbandfec=1. It is on by default.
+func WithOpusInbandFEC(fec bool) DefaultCodecsOption {
+	return func(o *defaultCodecsOptions) {
+		o.opusInbandFEC = fec
+	}
+}
+
+// WithOpusMaxAverageBitrate sets the maximum average bitrate in bits per second of the Opus
+// audio received, with maxaveragebitrate. It must be between 6000 and 510000, zero leaves
+// it unset.
+func WithOpusMaxAverageBitrate(bps uint32) DefaultCodecsOption {
+	return func(o *defaultCodecsOptions) {
+		o.opusMaxAverageBitrate = bps
+	}
+}
+
+// opusFmtpLine returns the fmtp line of the default Opus codec.
+func (o defaultCodecsOptions) opusFmtpLine() (string, error) {
+	parameters := []string{"minptime=10"}
+	if o.opusInbandFEC {
+		parameters = append(parameters, "useinbandfec=1")
+	}
+	if o.opusStereo {
+		parameters = append(parameters, "stereo=1")
+	}
+	if bps := o.opusMaxAverageBitrate; bps != 0 {
+		if bps < opusMinAverageBitrate || bps > opusMaxAverageBitrate {
+			return "", fmt.Errorf("%w: %d", errOpusMaxAverageBitrateInvalid, bps)
+		}
+		parameters = append(parameters, fmt.Sprintf("maxaveragebitrate=%d", bps))
+	}
+
+	return strings.Join(parameters, ";"), nil
+}
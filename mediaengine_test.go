@@ -10,7 +10,9 @@ import (
 	"regexp"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/pion/rtp"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v4/test"
 	"github.com/stretchr/testify/assert"
@@ -292,6 +294,45 @@ a=fmtp:98 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f
 		assert.Error(t, err)
 	})
 
+	t.Run("Matches different H264 profile with loose matching", func(t *testing.T) {
+		const profileLevels = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=video 60323 UDP/TLS/RTP/SAVPF 96 98
+a=rtpmap:96 H264/90000
+a=fmtp:96 packetization-mode=1;profile-level-id=640c1f
+a=rtpmap:98 VP8/90000
+`
+		for _, preference := range []CodecMatchPreference{CodecMatchStrict, CodecMatchLoose} {
+			mediaEngine := MediaEngine{}
+			assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+				RTPCodecCapability: RTPCodecCapability{
+					MimeTypeH264, 90000, 0, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", nil,
+				},
+				PayloadType: 102,
+			}, RTPCodecTypeVideo))
+			assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+				RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil},
+				PayloadType:        98,
+			}, RTPCodecTypeVideo))
+			mediaEngine.SetCodecMatchPreference(RTPCodecTypeVideo, preference)
+			assert.NoError(t, mediaEngine.updateFromRemoteDescription(mustParse(profileLevels)))
+
+			_, _, err := mediaEngine.getCodecByPayload(98)
+			assert.NoError(t, err)
+
+			h264Codec, _, err := mediaEngine.getCodecByPayload(96)
+			if preference == CodecMatchStrict {
+				assert.ErrorIs(t, err, ErrCodecNotFound)
+
+				continue
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "packetization-mode=1;profile-level-id=640c1f", h264Codec.SDPFmtpLine)
+		}
+	})
+
 	t.Run("Does not match when fmtpline is set and does not match", func(t *testing.T) {
 		const profileLevels = `v=0
 o=- 4596489990601351948 2 IN IP4 127.0.0.1
@@ -534,6 +575,38 @@ func TestMediaEngineHeaderExtensionDirection(t *testing.T) {
 		assert.Equal(t, 0, len(params.HeaderExtensions))
 	})
 
+	t.Run("Remote Direction", func(t *testing.T) {
+		mediaEngine := &MediaEngine{}
+		registerCodec(mediaEngine)
+		assert.NoError(t, mediaEngine.RegisterHeaderExtension(
+			RTPHeaderExtensionCapability{"pion-header-test"}, RTPCodecTypeAudio,
+		))
+		assert.NoError(t, mediaEngine.RegisterHeaderExtension(
+			RTPHeaderExtensionCapability{"pion-header-test2"}, RTPCodecTypeAudio, RTPTransceiverDirectionSendonly,
+		))
+
+		// The remote only sends both, so they can only be received
+		assert.NoError(t, mediaEngine.updateHeaderExtension(
+			1, "pion-header-test", RTPCodecTypeAudio, RTPTransceiverDirectionSendonly,
+		))
+		assert.NoError(t, mediaEngine.updateHeaderExtension(
+			2, "pion-header-test2", RTPCodecTypeAudio, RTPTransceiverDirectionSendonly,
+		))
+		mediaEngine.negotiatedAudio = true
+
+		recv := mediaEngine.getRTPParametersByKind(
+			RTPCodecTypeAudio, []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly},
+		)
+		send := mediaEngine.getRTPParametersByKind(
+			RTPCodecTypeAudio, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
+		)
+		assert.Equal(t, []RTPHeaderExtensionParameter{{URI: "pion-header-test", ID: 1}}, recv.HeaderExtensions)
+		assert.Empty(t, send.HeaderExtensions)
+		assert.Equal(
+			t, RTPTransceiverDirectionRecvonly, mediaEngine.headerExtensionDirection("pion-header-test", RTPCodecTypeAudio),
+		)
+	})
+
 	t.Run("Invalid Direction", func(t *testing.T) {
 		mediaEngine := &MediaEngine{}
 		registerCodec(mediaEngine)
@@ -572,6 +645,80 @@ func TestMediaEngineHeaderExtensionDirection(t *testing.T) {
 	})
 }
 
+func TestMediaEngineTwoByteHeaderExtensionIDs(t *testing.T) {
+	newMediaEngine := func() *MediaEngine {
+		mediaEngine := &MediaEngine{}
+		assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		for i := 1; i <= 16; i++ {
+			assert.NoError(t, mediaEngine.RegisterHeaderExtension(
+				RTPHeaderExtensionCapability{fmt.Sprintf("pion-header-test-%d", i)}, RTPCodecTypeVideo,
+			))
+		}
+
+		return mediaEngine
+	}
+	maxID := func(params RTPParameters) (maxID int) {
+		for _, ext := range params.HeaderExtensions {
+			maxID = max(maxID, ext.ID)
+		}
+
+		return maxID
+	}
+	recvonly := []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly}
+
+	t.Run("One-Byte IDs", func(t *testing.T) {
+		settingEngine := SettingEngine{}
+		settingEngine.SetCompatibilityProfile(CompatibilityProfile{OmitExtmapAllowMixed: true})
+		api := &API{mediaEngine: newMediaEngine(), settingEngine: &settingEngine}
+
+		params := api.getRTPParametersByKind(RTPCodecTypeVideo, recvonly)
+		assert.Len(t, params.HeaderExtensions, 14)
+		assert.Equal(t, 14, maxID(params))
+	})
+
+	t.Run("Two-Byte IDs", func(t *testing.T) {
+		api := &API{mediaEngine: newMediaEngine(), settingEngine: &SettingEngine{}}
+
+		params := api.getRTPParametersByKind(RTPCodecTypeVideo, recvonly)
+		assert.Len(t, params.HeaderExtensions, 16)
+		assert.Equal(t, 16, maxID(params))
+	})
+
+	remoteDescription := func(extmapAllowMixed string) sdp.SessionDescription {
+		desc := sdp.SessionDescription{}
+		assert.NoError(t, desc.UnmarshalString(`v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+`+extmapAllowMixed+`m=video 9 UDP/TLS/RTP/SAVPF 96
+a=extmap:1 pion-header-test-1
+a=extmap:15 pion-header-test-15
+a=rtpmap:96 VP8/90000
+`))
+
+		return desc
+	}
+
+	t.Run("Remote Without extmap-allow-mixed", func(t *testing.T) {
+		mediaEngine := newMediaEngine()
+		assert.NoError(t, mediaEngine.updateFromRemoteDescription(remoteDescription("")))
+
+		params := mediaEngine.getRTPParametersByKind(RTPCodecTypeVideo, recvonly)
+		assert.Equal(t, []RTPHeaderExtensionParameter{{URI: "pion-header-test-1", ID: 1}}, params.HeaderExtensions)
+	})
+
+	t.Run("Remote With extmap-allow-mixed", func(t *testing.T) {
+		mediaEngine := newMediaEngine()
+		assert.NoError(t, mediaEngine.updateFromRemoteDescription(remoteDescription("a=extmap-allow-mixed\n")))
+
+		params := mediaEngine.getRTPParametersByKind(RTPCodecTypeVideo, recvonly)
+		assert.ElementsMatch(t, []RTPHeaderExtensionParameter{
+			{URI: "pion-header-test-1", ID: 1},
+			{URI: "pion-header-test-15", ID: 15},
+		}, params.HeaderExtensions)
+	})
+}
+
 // If a user attempts to register a codec twice we should just discard duplicate calls.
 func TestMediaEngineDoubleRegister(t *testing.T) {
 	t.Run("Same Codec", func(t *testing.T) {
@@ -674,7 +821,7 @@ func TestUpdateHeaderExtenstionToClonedMediaEngine(t *testing.T) {
 	assert.NoError(t, src.RegisterHeaderExtension(RTPHeaderExtensionCapability{"test-extension"}, RTPCodecTypeAudio))
 
 	validate := func(m *MediaEngine) {
-		assert.NoError(t, m.updateHeaderExtension(2, "test-extension", RTPCodecTypeAudio))
+		assert.NoError(t, m.updateHeaderExtension(2, "test-extension", RTPCodecTypeAudio, RTPTransceiverDirectionUnknown))
 
 		id, audioNegotiated, videoNegotiated := m.getHeaderExtensionID(RTPHeaderExtensionCapability{URI: "test-extension"})
 		assert.Equal(t, 2, id)
@@ -1010,3 +1157,393 @@ a=ssrc:4281768245 msid:6ff05509-be96-4ef1-a74f-425e14720983 16d5d7fe-d076-4718-9
 		assert.Len(t, mediaEngine.negotiatedVideoCodecs, 2)
 	})
 }
+
+func TestCodecMatchPreferenceAnswer(t *testing.T) {
+	registerCodecs := func(mediaEngine *MediaEngine, h264Fmtp string) {
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, h264Fmtp, nil},
+			PayloadType:        102,
+		}, RTPCodecTypeVideo))
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil},
+			PayloadType:        96,
+		}, RTPCodecTypeVideo))
+	}
+
+	for _, preference := range []CodecMatchPreference{CodecMatchStrict, CodecMatchLoose} {
+		t.Run(preference.String(), func(t *testing.T) {
+			offerMediaEngine := &MediaEngine{}
+			registerCodecs(offerMediaEngine, "packetization-mode=1;profile-level-id=640c1f")
+			answerMediaEngine := &MediaEngine{}
+			registerCodecs(answerMediaEngine, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f")
+			answerMediaEngine.SetCodecMatchPreference(RTPCodecTypeVideo, preference)
+
+			pcOffer, err := NewAPI(WithMediaEngine(offerMediaEngine)).NewPeerConnection(Configuration{})
+			assert.NoError(t, err)
+			pcAnswer, err := NewAPI(WithMediaEngine(answerMediaEngine)).NewPeerConnection(Configuration{})
+			assert.NoError(t, err)
+
+			_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+			assert.NoError(t, err)
+			offer, err := pcOffer.CreateOffer(nil)
+			assert.NoError(t, err)
+			assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+			answer, err := pcAnswer.CreateAnswer(nil)
+			assert.NoError(t, err)
+
+			assert.Contains(t, answer.SDP, "VP8/90000")
+			if preference == CodecMatchLoose {
+				assert.Contains(t, answer.SDP, "H264/90000")
+			} else {
+				assert.NotContains(t, answer.SDP, "H264/90000")
+			}
+
+			closePairNow(t, pcOffer, pcAnswer)
+		})
+	}
+}
+
+func TestMediaEngineCodecAssociations(t *testing.T) {
+	t.Run("Dangling apt", func(t *testing.T) {
+		mediaEngine := &MediaEngine{}
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil},
+			PayloadType:        96,
+		}, RTPCodecTypeVideo))
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=102", nil},
+			PayloadType:        103,
+		}, RTPCodecTypeVideo))
+
+		_, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		assert.ErrorIs(t, err, ErrUnknownAssociatedPayloadType)
+
+		// The primary codec may be registered after the RTX codec
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, "packetization-mode=1;profile-level-id=42e01f", nil},
+			PayloadType:        102,
+		}, RTPCodecTypeVideo))
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("RED without primary codec is not negotiated", func(t *testing.T) {
+		const offer = `v=0
+o=- 4596489990601351948 2 IN IP4 127.0.0.1
+s=-
+t=0 0
+m=audio 9 UDP/TLS/RTP/SAVPF 111 63 0
+a=rtpmap:111 opus/48000/2
+a=rtpmap:63 red/48000/2
+a=fmtp:63 111/111
+a=rtpmap:0 PCMU/8000
+`
+		mediaEngine := MediaEngine{}
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeTypePCMU, 8000, 0, "", nil},
+			PayloadType:        0,
+		}, RTPCodecTypeAudio))
+		assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{"audio/red", 48000, 2, "0/0", nil},
+			PayloadType:        63,
+		}, RTPCodecTypeAudio))
+
+		parsed := sdp.SessionDescription{}
+		assert.NoError(t, parsed.Unmarshal([]byte(offer)))
+		assert.NoError(t, mediaEngine.updateFromRemoteDescription(parsed))
+
+		_, _, err := mediaEngine.getCodecByPayload(0)
+		assert.NoError(t, err)
+		_, _, err = mediaEngine.getCodecByPayload(63)
+		assert.ErrorIs(t, err, ErrCodecNotFound)
+	})
+
+	t.Run("RTX of excluded codec is dropped from answer", func(t *testing.T) {
+		offerMediaEngine := &MediaEngine{}
+		assert.NoError(t, offerMediaEngine.RegisterDefaultCodecs())
+		answerMediaEngine := &MediaEngine{}
+		for _, codec := range []RTPCodecParameters{
+			{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 96},
+			{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=96", nil}, PayloadType: 97},
+		} {
+			assert.NoError(t, answerMediaEngine.RegisterCodec(codec, RTPCodecTypeVideo))
+		}
+
+		pcOffer, err := NewAPI(WithMediaEngine(offerMediaEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		pcAnswer, err := NewAPI(WithMediaEngine(answerMediaEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+		assert.NoError(t, err)
+		offer, err := pcOffer.CreateOffer(nil)
+		assert.NoError(t, err)
+		assert.Contains(t, offer.SDP, "apt=102")
+		assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+		answer, err := pcAnswer.CreateAnswer(nil)
+		assert.NoError(t, err)
+
+		assert.Contains(t, answer.SDP, "a=fmtp:97 apt=96")
+		assert.NotContains(t, answer.SDP, "apt=102")
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+}
+
+func TestMediaEngineRTPParametersInvalidation(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+
+	directions := []RTPTransceiverDirection{RTPTransceiverDirectionRecvonly}
+	params := mediaEngine.getRTPParametersByKind(RTPCodecTypeVideo, directions)
+	assert.Empty(t, params.HeaderExtensions)
+
+	assert.NoError(t, mediaEngine.RegisterHeaderExtension(
+		RTPHeaderExtensionCapability{URI: sdp.SDESMidURI}, RTPCodecTypeVideo,
+	))
+	params = mediaEngine.getRTPParametersByKind(RTPCodecTypeVideo, directions)
+	assert.Equal(t, []RTPHeaderExtensionParameter{{ID: 1, URI: sdp.SDESMidURI}}, params.HeaderExtensions)
+
+	// Modifying the returned header extensions must not change the cached ones
+	params.HeaderExtensions[0].ID = 5
+	params = mediaEngine.getRTPParametersByKind(RTPCodecTypeVideo, directions)
+	assert.Equal(t, 1, params.HeaderExtensions[0].ID)
+
+	// Audio and other directions are resolved separately
+	assert.Empty(t, mediaEngine.getRTPParametersByKind(RTPCodecTypeAudio, directions).HeaderExtensions)
+	assert.Empty(t, mediaEngine.getRTPParametersByKind(
+		RTPCodecTypeVideo, []RTPTransceiverDirection{RTPTransceiverDirectionInactive},
+	).HeaderExtensions)
+}
+
+func TestMediaEngineUnregisterCodec(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+
+	assert.NoError(t, mediaEngine.UnregisterCodec(MimeTypeVP9, RTPCodecTypeVideo))
+	for _, codec := range mediaEngine.getCodecsByKind(RTPCodecTypeVideo) {
+		assert.NotEqual(t, MimeTypeVP9, codec.MimeType)
+		// The RTX codecs of VP9 go away with it
+		assert.NotContains(t, []string{"apt=98", "apt=100"}, codec.SDPFmtpLine)
+	}
+	_, _, err := mediaEngine.getCodecByPayload(97)
+	assert.NoError(t, err)
+
+	assert.ErrorIs(t, mediaEngine.UnregisterCodec("video/vp9", RTPCodecTypeVideo), ErrCodecNotFound)
+	assert.ErrorIs(t, mediaEngine.UnregisterCodec(MimeTypeOpus, RTPCodecTypeVideo), ErrCodecNotFound)
+	assert.ErrorIs(t, mediaEngine.UnregisterCodec(MimeTypeOpus, 0), ErrUnknownType)
+
+	mediaEngine.ResetCodecs(RTPCodecTypeVideo)
+	assert.Empty(t, mediaEngine.getCodecsByKind(RTPCodecTypeVideo))
+	assert.NotEmpty(t, mediaEngine.getCodecsByKind(RTPCodecTypeAudio))
+
+	// Codecs can be registered again after a reset
+	assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeVP9, 90000, 0, "profile-id=0", nil},
+		PayloadType:        98,
+	}, RTPCodecTypeVideo))
+	assert.Len(t, mediaEngine.getCodecsByKind(RTPCodecTypeVideo), 1)
+}
+
+func TestMediaEngineUnregisterCodecBetweenNegotiations(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The offerer negotiates with its copy of the MediaEngine, the changes are applied to it
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	pcOffer, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	packets := make(chan *rtp.Packet, 100)
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			packet, _, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			select {
+			case packets <- packet:
+			default:
+			}
+		}
+	})
+
+	done := make(chan struct{})
+	sendingDone := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+		close(sendingDone)
+	}()
+	defer func() {
+		close(done)
+		<-sendingDone
+	}()
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-packets
+
+	assert.ErrorIs(t, mediaEngine.UnregisterCodec(MimeTypeVP8, RTPCodecTypeVideo), ErrCodecInUse)
+	assert.NoError(t, mediaEngine.UnregisterCodec(MimeTypeVP9, RTPCodecTypeVideo))
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, offer.SDP, "VP9")
+	assert.Contains(t, offer.SDP, "VP8")
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// The existing track keeps flowing after the renegotiation
+	for len(packets) > 0 {
+		<-packets
+	}
+	packet := <-packets
+	assert.Equal(t, uint8(96), packet.PayloadType)
+
+	// Resetting keeps the codec in use
+	mediaEngine.ResetCodecs(RTPCodecTypeVideo)
+	offer, err = pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, offer.SDP, "H264")
+	assert.Contains(t, offer.SDP, "VP8")
+
+	closePairNow(t, pcOffer, pcAnswer)
+
+	// Closed PeerConnections don't use the MediaEngine anymore
+	assert.NoError(t, mediaEngine.UnregisterCodec(MimeTypeVP8, RTPCodecTypeVideo))
+	assert.Empty(t, mediaEngine.users)
+
+	// Neither do PeerConnections that failed to construct
+	_, err = NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{ICECandidatePoolSize: 2})
+	assert.Error(t, err)
+	assert.Empty(t, mediaEngine.users)
+}
+
+func TestRegisterDefaultCodecsWithOptions(t *testing.T) {
+	const offerSdp = `v=0
+o=- 8448668841136641781 4 IN IP4 127.0.0.1
+s=-
+t=0 0
+a=group:BUNDLE 0
+m=audio 9 UDP/TLS/RTP/SAVPF 111
+c=IN IP4 0.0.0.0
+a=ice-ufrag:1/MvHwjAyVf27aLu
+a=ice-pwd:3dBU7cFOBl120v33cynDvN1E
+a=fingerprint:sha-256 75:74:5A:A6:A4:E5:52:F4:A7:67:4C:01:C7:EE:91:3F:21:3D:A2:E3:53:7B:6F:30:86:F2:30:AA:65:FB:04:24
+a=setup:actpass
+a=mid:0
+a=sendrecv
+a=rtpmap:111 opus/48000/2
+a=fmtp:111 %s
+`
+
+	newPeerConnection := func(t *testing.T, opts ...DefaultCodecsOption) *PeerConnection {
+		t.Helper()
+
+		mediaEngine := &MediaEngine{}
+		assert.NoError(t, mediaEngine.RegisterDefaultCodecsWithOptions(opts...))
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		return pc
+	}
+
+	fmtpLine := func(t *testing.T, sdp string) string {
+		t.Helper()
+
+		match := regexp.MustCompile(`(?m)^a=fmtp:111 (.*)\r$`).FindStringSubmatch(sdp)
+		if !assert.Len(t, match, 2) {
+			return ""
+		}
+
+		return match[1]
+	}
+
+	t.Run("Offer", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			opts     []DefaultCodecsOption
+			expected string
+		}{
+			{"Default", nil, "minptime=10;useinbandfec=1"},
+			{"Stereo", []DefaultCodecsOption{WithOpusStereo(true)}, "minptime=10;useinbandfec=1;stereo=1"},
+			{"No FEC", []DefaultCodecsOption{WithOpusInbandFEC(false)}, "minptime=10"},
+			{
+				"Max Average Bitrate", []DefaultCodecsOption{WithOpusMaxAverageBitrate(32000)},
+				"minptime=10;useinbandfec=1;maxaveragebitrate=32000",
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				pc := newPeerConnection(t, test.opts...)
+				_, err := pc.AddTransceiverFromKind(RTPCodecTypeAudio)
+				assert.NoError(t, err)
+
+				offer, err := pc.CreateOffer(nil)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, fmtpLine(t, offer.SDP))
+				assert.NoError(t, pc.Close())
+			})
+		}
+	})
+
+	t.Run("Answer", func(t *testing.T) {
+		for _, test := range []struct {
+			name       string
+			opts       []DefaultCodecsOption
+			remoteFmtp string
+			expected   string
+		}{
+			{"Stereo not offered", []DefaultCodecsOption{WithOpusStereo(true)}, "minptime=10", "minptime=10"},
+			{"Stereo not enabled", nil, "minptime=10;useinbandfec=1;stereo=1", "minptime=10;useinbandfec=1"},
+			{
+				"Stereo on both sides", []DefaultCodecsOption{WithOpusStereo(true)},
+				"minptime=10;stereo=1", "minptime=10;stereo=1",
+			},
+			{"No FEC", []DefaultCodecsOption{WithOpusInbandFEC(false)}, "minptime=10;useinbandfec=1", "minptime=10"},
+			{
+				"Lower local bitrate", []DefaultCodecsOption{WithOpusMaxAverageBitrate(32000)},
+				"minptime=10;maxaveragebitrate=64000", "minptime=10;maxaveragebitrate=32000",
+			},
+			{
+				"Lower remote bitrate", []DefaultCodecsOption{WithOpusMaxAverageBitrate(64000)},
+				"minptime=10;maxaveragebitrate=32000", "minptime=10;maxaveragebitrate=32000",
+			},
+		} {
+			t.Run(test.name, func(t *testing.T) {
+				pc := newPeerConnection(t, test.opts...)
+				assert.NoError(t, pc.SetRemoteDescription(SessionDescription{
+					Type: SDPTypeOffer,
+					SDP:  fmt.Sprintf(offerSdp, test.remoteFmtp),
+				}))
+
+				answer, err := pc.CreateAnswer(nil)
+				assert.NoError(t, err)
+				assert.Equal(t, test.expected, fmtpLine(t, answer.SDP))
+				assert.NoError(t, pc.Close())
+			})
+		}
+	})
+
+	t.Run("Invalid Max Average Bitrate", func(t *testing.T) {
+		for _, bps := range []uint32{5999, 510001} {
+			mediaEngine := &MediaEngine{}
+			assert.ErrorIs(
+				t,
+				mediaEngine.RegisterDefaultCodecsWithOptions(WithOpusMaxAverageBitrate(bps)),
+				errOpusMaxAverageBitrateInvalid,
+			)
+		}
+	})
+}
@@ -7,6 +7,11 @@ package webrtc
 // the STUN/TURN client to connect to an ICE server as defined in
 // https://tools.ietf.org/html/rfc7635. Note that the kid parameter is not
 // located in OAuthCredential, but in ICEServer's username member.
+//
+// The TURN client used for gathering doesn't support RFC 7635 yet, so OAuth
+// credentials are validated but no relay candidates are gathered with them.
+// Each gathering reports their TURN URLs with ErrOAuthTURNUnsupported to
+// OnICECandidateError.
 type OAuthCredential struct {
 	// MACKey is a base64-url encoded format. It is used in STUN message
 	// integrity hash calculation.
@@ -9,6 +9,10 @@ type OfferAnswerOptions struct {
 	// VoiceActivityDetection allows the application to provide information
 	// about whether it wishes voice detection feature to be enabled or disabled.
 	VoiceActivityDetection bool
+	// DisableVoiceActivityDetection removes the comfort noise (CN) codecs from the
+	// audio media sections, and turns off the VAD of the codecs that signal it in
+	// their fmtp (G.729 annexb=no, Opus without usedtx).
+	DisableVoiceActivityDetection bool
 	// ICETricklingSupported indicates whether the ICE agent should use trickle ICE
 	// If set, the "a=ice-options:trickle" attribute is added to the generated SDP payload.
 	// (See https://datatracker.ietf.org/doc/html/rfc9725#section-4.3.3)
@@ -30,4 +34,12 @@ type OfferOptions struct {
 	// When this value is true, the generated description will have ICE
 	// credentials that are different from the current credentials
 	ICERestart bool
+
+	// OfferToReceiveAudio and OfferToReceiveVideo are a convenience for code ported
+	// from the legacy offerToReceiveAudio/offerToReceiveVideo options. When greater
+	// than zero, recvonly transceivers of that kind are added before the offer is
+	// created until at least that many transceivers of the kind receive. Zero leaves
+	// the transceivers as they are.
+	OfferToReceiveAudio int
+	OfferToReceiveVideo int
 }
@@ -5,6 +5,9 @@ package webrtc
 
 import (
 	"container/list"
+	"context"
+	"reflect"
+	"runtime"
 	"sync"
 	"sync/atomic"
 )
@@ -21,6 +24,9 @@ type operations struct {
 	updateNegotiationNeededFlagOnEmptyChain *atomic.Bool
 	onNegotiationNeeded                     func()
 	isClosed                                bool
+
+	// set while an operation runs a callback of the user, see inCallback
+	runningCallback atomic.Bool
 }
 
 func newOperations(
@@ -89,6 +95,79 @@ func (o *operations) Done() {
 	wg.Wait()
 }
 
+// Wait blocks until all currently enqueued operations are finished executing,
+// or until ctx is done. Unlike Done, it returns ErrWaitInOperation instead of
+// blocking forever when called by a callback run by an operation.
+func (o *operations) Wait(ctx context.Context) error {
+	if o.inCallback() {
+		return ErrWaitInOperation
+	}
+
+	done := make(chan struct{})
+	o.mu.Lock()
+	enqueued := o.tryEnqueue(func() {
+		close(done)
+	})
+	o.mu.Unlock()
+	if !enqueued {
+		return nil
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// runCallback runs a callback of the user from an operation, see inCallback.
+func (o *operations) runCallback(callback func()) {
+	o.runningCallback.Store(true)
+	defer o.runningCallback.Store(false)
+
+	callOperationCallback(callback)
+}
+
+// callOperationCallback calls callback, its frame marks the goroutines running a callback
+// of an operation.
+//
+//go:noinline
+func callOperationCallback(callback func()) {
+	callback()
+}
+
+// inCallback reports if it is called by the callback that an operation runs, which would
+// never return when waiting for the operations queued after it. Other goroutines wait while
+// the callback runs, only the stack of the calling goroutine tells them apart.
+func (o *operations) inCallback() bool {
+	if !o.runningCallback.Load() {
+		return false
+	}
+
+	callbackEntry := reflect.ValueOf(callOperationCallback).Pointer()
+	pcs := make([]uintptr, 32)
+	for {
+		n := runtime.Callers(2, pcs)
+		if n == len(pcs) {
+			pcs = make([]uintptr, 2*len(pcs))
+
+			continue
+		}
+
+		frames := runtime.CallersFrames(pcs[:n])
+		for {
+			frame, more := frames.Next()
+			if frame.Entry == callbackEntry {
+				return true
+			}
+			if !more {
+				return false
+			}
+		}
+	}
+}
+
 // GracefulClose waits for the operations queue to be cleared and forbids
 // new operations from being enqueued.
 func (o *operations) GracefulClose() {
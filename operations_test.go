@@ -4,9 +4,11 @@
 package webrtc
 
 import (
+	"context"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -53,6 +55,67 @@ func TestOperations_Done(*testing.T) {
 	ops.Done()
 }
 
+func TestOperations_Wait(t *testing.T) {
+	ops := newOperations(&atomic.Bool{}, func() {
+	})
+	defer ops.GracefulClose()
+
+	executed := atomic.Bool{}
+	ops.Enqueue(func() {
+		time.Sleep(10 * time.Millisecond)
+		executed.Store(true)
+	})
+	assert.NoError(t, ops.Wait(context.Background()))
+	assert.True(t, executed.Load())
+
+	t.Run("Context done", func(t *testing.T) {
+		unblock := make(chan struct{})
+		ops.Enqueue(func() {
+			<-unblock
+		})
+		defer close(unblock)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		assert.ErrorIs(t, ops.Wait(ctx), context.DeadlineExceeded)
+	})
+
+	t.Run("From operation callback", func(t *testing.T) {
+		waitErr := make(chan error, 1)
+		ops.Enqueue(func() {
+			ops.runCallback(func() {
+				waitErr <- ops.Wait(context.Background())
+			})
+		})
+		assert.ErrorIs(t, <-waitErr, ErrWaitInOperation)
+	})
+
+	t.Run("From another goroutine while the callback runs", func(t *testing.T) {
+		started, release := make(chan struct{}), make(chan struct{})
+		ops.Enqueue(func() {
+			ops.runCallback(func() {
+				close(started)
+				<-release
+			})
+		})
+		<-started
+
+		waitErr := make(chan error, 1)
+		go func() {
+			waitErr <- ops.Wait(context.Background())
+		}()
+
+		// Only the callback itself can't wait, others wait for it to return
+		select {
+		case err := <-waitErr:
+			assert.Fail(t, "Wait returned while the callback runs", "%v", err)
+		case <-time.After(20 * time.Millisecond):
+		}
+		close(release)
+		assert.NoError(t, <-waitErr)
+	})
+}
+
 func TestOperations_GracefulClose(t *testing.T) {
 	ops := newOperations(&atomic.Bool{}, func() {
 	})
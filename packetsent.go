@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+)
+
+type packetSentHandler struct {
+	every   uint64
+	count   atomic.Uint64
+	handler func(sequenceNumber uint16, size int, sentAt time.Time)
+}
+
+// OnPacketSent sets a handler that is called with the sequence number, the size on the wire
+// and the send time of the RTP packets of the sender, as soon as they were handed to the
+// socket. The time a packet is sent at can differ a lot from when it was written by the
+// track, because of pacing and buffering on the way.
+//
+// Only every n-th packet is reported, starting with the next one, 1 or less reports all of
+// them. The packets of the RTX and FEC streams aren't reported. The handler runs while the packet is sent, so it
+// must not block. A nil handler stops the reports.
+func (r *RTPSender) OnPacketSent(every int, handler func(sequenceNumber uint16, size int, sentAt time.Time)) {
+	if handler == nil {
+		r.packetSent.Store(nil)
+
+		return
+	}
+
+	r.packetSent.Store(&packetSentHandler{
+		every:   uint64(max(every, 1)), //nolint:gosec // every is positive
+		handler: handler,
+	})
+}
+
+// packetWritten is called once a packet of e was handed to the socket, size is the number
+// of bytes written.
+func (r *RTPSender) packetWritten(
+	e *trackEncoding, header *rtp.Header, size int, attributes interceptor.Attributes,
+) {
+	sentAt := time.Now()
+	if attributes != nil {
+		attributes[AttributeSentAt] = sentAt
+	}
+	if header.SSRC != uint32(e.ssrc) {
+		return
+	}
+
+	if packetizedAt, ok := attributes[AttributePacketizedAt].(time.Time); ok {
+		e.packetSendDelay.Add(int64(sentAt.Sub(packetizedAt)))
+	}
+	if filler, ok := attributes[AttributeSourceStallFiller].(bool); ok && filler {
+		e.stallFillerPacketsSent.Add(1)
+	}
+
+	if handler := r.packetSent.Load(); handler != nil && (handler.count.Add(1)-1)%handler.every == 0 {
+		handler.handler(header.SequenceNumber, size, sentAt)
+	}
+}
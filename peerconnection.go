@@ -6,11 +6,14 @@
 package webrtc
 
 import (
+	"cmp"
+	"context"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"errors"
 	"fmt"
+	"hash/fnv"
 	"slices"
 	"strconv"
 	"strings"
@@ -43,6 +46,13 @@ type PeerConnection struct {
 	// remote and local descriptions
 	ops *operations
 
+	// pendingDescriptions are the futures of SetLocalDescriptionAsync and
+	// SetRemoteDescriptionAsync waiting on ops, they are resolved by Close
+	pendingDescriptions []*DescriptionFuture
+
+	// transportsStarted is closed once startTransports returned
+	transportsStarted chan struct{}
+
 	configuration Configuration
 
 	currentLocalDescription  *SessionDescription
@@ -62,6 +72,15 @@ type PeerConnection struct {
 	isNegotiationNeeded                     *atomic.Bool
 	updateNegotiationNeededFlagOnEmptyChain *atomic.Bool
 
+	// set while UpdateTracks applies a batch, negotiationneeded is fired once afterwards
+	batchingTrackUpdates     atomic.Bool
+	batchedNegotiationNeeded atomic.Bool
+
+	// set by AddICEServers once gathering started, the next offer restarts ICE. It is cleared
+	// once the answer to iceRestartOffer, the last offer that restarted ICE for them, is applied.
+	iceRestartNeeded atomic.Bool
+	iceRestartOffer  string
+
 	lastOffer  string
 	lastAnswer string
 	// Whether the remote endpoint can accept trickled ICE candidates.
@@ -75,12 +94,18 @@ type PeerConnection struct {
 
 	rtpTransceivers        []*RTPTransceiver
 	nonMediaBandwidthProbe atomic.Value // RTPReceiver
+	bandwidthEstimator     atomic.Value // bandwidthEstimatorHolder
+
+	// SSRCs attached by the UnknownSSRCHandler that are waiting for their transceiver
+	pendingUnknownSSRCs   map[SSRC]struct{}
+	pendingUnknownSSRCsMu sync.Mutex
 
 	onSignalingStateChangeHandler     func(SignalingState)
 	onICEConnectionStateChangeHandler atomic.Value // func(ICEConnectionState)
 	onConnectionStateChangeHandler    atomic.Value // func(PeerConnectionState)
 	onTrackHandler                    func(*TrackRemote, *RTPReceiver)
 	onDataChannelHandler              func(*DataChannel)
+	onDataChannelOpenRequest          func(DataChannelParameters) bool
 	onNegotiationNeededHandler        atomic.Value // func()
 
 	iceGatherer   *ICEGatherer
@@ -88,6 +113,8 @@ type PeerConnection struct {
 	dtlsTransport *DTLSTransport
 	sctpTransport *SCTPTransport
 
+	timeline *connectionTimeline
+
 	// A reference to the associated API state used by this connection
 	api *API
 	log logging.LeveledLogger
@@ -107,16 +134,59 @@ func NewPeerConnection(configuration Configuration) (*PeerConnection, error) {
 	return api.NewPeerConnection(configuration)
 }
 
+// NewPeerConnectionWithOptions creates a PeerConnection with the default codecs and interceptors,
+// configured by options. See [(*API).NewPeerConnectionWithOptions].
+func NewPeerConnectionWithOptions(
+	configuration Configuration,
+	options ...PeerConnectionOption,
+) (*PeerConnection, error) {
+	api := NewAPI()
+
+	return api.NewPeerConnectionWithOptions(configuration, options...)
+}
+
 // NewPeerConnection creates a new PeerConnection with the provided configuration against the received API object.
 // This method will attach a default set of codecs and interceptors to
 // the resulting PeerConnection.  If this behavior is not desired,
 // set the set of codecs and interceptors explicitly by using
 // [WithMediaEngine] and [WithInterceptorRegistry] when calling [NewAPI].
 func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection, error) {
+	return api.NewPeerConnectionWithOptions(configuration)
+}
+
+// NewPeerConnectionWithOptions is like NewPeerConnection, with options that only apply to the
+// created PeerConnection, such as WithoutInterceptors.
+func (api *API) NewPeerConnectionWithOptions(
+	configuration Configuration,
+	options ...PeerConnectionOption,
+) (*PeerConnection, error) {
 	// https://w3c.github.io/webrtc-pc/#constructor (Step #2)
 	// Some variables defined explicitly despite their implicit zero values to
 	// allow better readability to understand what is happening.
 
+	if err := api.settingEngine.validateICEConnectivityChecks(); err != nil {
+		return nil, err
+	}
+
+	if err := api.settingEngine.validateICEGathering(); err != nil {
+		return nil, err
+	}
+
+	if err := api.settingEngine.validateStaticLocalCandidates(); err != nil {
+		return nil, err
+	}
+
+	if err := validateICECredentials(
+		api.settingEngine.candidates.UsernameFragment,
+		api.settingEngine.candidates.Password,
+	); err != nil {
+		return nil, err
+	}
+
+	if err := api.mediaEngine.validateCodecAssociations(); err != nil {
+		return nil, err
+	}
+
 	pc := &PeerConnection{
 		id: fmt.Sprintf("PeerConnection-%d", time.Now().UnixNano()),
 		configuration: Configuration{
@@ -136,16 +206,18 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		lastAnswer:                              "",
 		greaterMid:                              -1,
 		signalingState:                          SignalingStateStable,
+		timeline:                                &connectionTimeline{},
 
 		api: api,
 		log: api.settingEngine.LoggerFactory.NewLogger("pc"),
 	}
 	pc.ops = newOperations(pc.updateNegotiationNeededFlagOnEmptyChain, pc.onNegotiationNeeded)
+	pc.transportsStarted = make(chan struct{})
 
 	pc.iceConnectionState.Store(ICEConnectionStateNew)
 	pc.connectionState.Store(PeerConnectionStateNew)
 
-	i, err := api.interceptorRegistry.Build(pc.id)
+	i, err := api.buildInterceptor(pc.id, options)
 	if err != nil {
 		return nil, err
 	}
@@ -155,8 +227,9 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 	}
 
 	pc.api = &API{
-		settingEngine: api.settingEngine,
-		interceptor:   i,
+		settingEngine:    api.settingEngine,
+		interceptor:      i,
+		handshakeLimiter: api.handshakeLimiter,
 	}
 
 	if api.settingEngine.disableMediaEngineCopy {
@@ -165,7 +238,6 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 		pc.api.mediaEngine = api.mediaEngine.copy()
 		pc.api.mediaEngine.setMultiCodecNegotiation(!api.settingEngine.disableMediaEngineMultipleCodecs)
 	}
-
 	if err = pc.initConfiguration(configuration); err != nil {
 		return nil, err
 	}
@@ -186,9 +258,22 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 	}
 	pc.dtlsTransport = dtlsTransport
 
+	pc.iceGatherer.timeline = pc.timeline
+	pc.iceTransport.timeline = pc.timeline
+	pc.dtlsTransport.timeline = pc.timeline
+	pc.dtlsTransport.localCNAME = pc.localCNAME
+
 	// Create the SCTP transport
 	pc.sctpTransport = pc.api.NewSCTPTransport(pc.dtlsTransport)
 
+	// A lost SCTP association fails the PeerConnection if it carried DataChannels and
+	// isn't closed with the DTLS transport
+	pc.sctpTransport.internalOnStateChangeHandler = func(state SCTPTransportState) {
+		if state == SCTPTransportStateClosed && pc.sctpTransport.failed() {
+			pc.updateConnectionState(pc.ICEConnectionState(), pc.dtlsTransport.State())
+		}
+	}
+
 	// Wire up the on datachannel handler
 	pc.sctpTransport.OnDataChannel(func(d *DataChannel) {
 		pc.mu.RLock()
@@ -198,6 +283,13 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 			handler(d)
 		}
 	})
+	pc.sctpTransport.OnDataChannelOpenRequest(func(params DataChannelParameters) bool {
+		pc.mu.RLock()
+		handler := pc.onDataChannelOpenRequest
+		pc.mu.RUnlock()
+
+		return handler == nil || handler(params)
+	})
 
 	if pc.configuration.ICECandidatePoolSize > 0 {
 		if err := pc.iceGatherer.Gather(); err != nil {
@@ -206,6 +298,10 @@ func (api *API) NewPeerConnection(configuration Configuration) (*PeerConnection,
 	}
 
 	pc.interceptorRTCPWriter = pc.api.interceptor.BindRTCPWriter(interceptor.RTCPWriterFunc(pc.writeRTCP))
+	pc.dtlsTransport.setInterceptorRTCPWriter(pc.interceptorRTCPWriter)
+
+	// Registered last, a PeerConnection that failed to construct is never closed
+	pc.api.mediaEngine.addUser(pc)
 
 	return pc, nil
 }
@@ -259,6 +355,10 @@ func (pc *PeerConnection) initConfiguration(configuration Configuration) error {
 	}
 
 	pc.configuration.ICETransportPolicy = configuration.ICETransportPolicy
+	if err := validateICECandidateTypes(configuration.ICECandidateTypes, configuration.ICETransportPolicy); err != nil {
+		return err
+	}
+	pc.configuration.ICECandidateTypes = slices.Clone(configuration.ICECandidateTypes)
 	pc.configuration.SDPSemantics = configuration.SDPSemantics
 	pc.configuration.AlwaysNegotiateDataChannels = configuration.AlwaysNegotiateDataChannels
 
@@ -302,6 +402,16 @@ func (pc *PeerConnection) OnDataChannel(f func(*DataChannel)) {
 	pc.onDataChannelHandler = f
 }
 
+// OnDataChannelOpenRequest sets a handler which is invoked when the remote peer opens a data
+// channel, with the label, protocol, ID and reliability of the channel, before OnDataChannel
+// fires. Return false to refuse the channel, it is closed and never handed to OnDataChannel.
+// The remote peer sees its channel open, then close.
+func (pc *PeerConnection) OnDataChannelOpenRequest(f func(params DataChannelParameters) bool) {
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+	pc.onDataChannelOpenRequest = f
+}
+
 // OnNegotiationNeeded sets an event handler which is invoked when
 // a change has occurred which requires session negotiation.
 func (pc *PeerConnection) OnNegotiationNeeded(f func()) {
@@ -312,6 +422,12 @@ func (pc *PeerConnection) OnNegotiationNeeded(f func()) {
 // caller of this method should hold `pc.mu` lock
 // https://www.w3.org/TR/webrtc/#dfn-update-the-negotiation-needed-flag
 func (pc *PeerConnection) onNegotiationNeeded() {
+	if pc.batchingTrackUpdates.Load() {
+		pc.batchedNegotiationNeeded.Store(true)
+
+		return
+	}
+
 	// 4.7.3.1 If the length of connection.[[Operations]] is not 0, then set
 	// connection.[[UpdateNegotiationNeededFlagOnEmptyChain]] to true, and abort these steps.
 	if !pc.ops.IsEmpty() {
@@ -362,7 +478,7 @@ func (pc *PeerConnection) negotiationNeededOp() {
 
 	// 4.7.3.2.7 Fire an event named negotiationneeded at connection.
 	if handler, ok := pc.onNegotiationNeededHandler.Load().(func()); ok && handler != nil {
-		handler()
+		pc.ops.runCallback(handler)
 	}
 }
 
@@ -380,6 +496,11 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit,cycl
 		return true
 	}
 
+	// The ICE servers added after gathering started need an ICE restart
+	if pc.iceRestartNeeded.Load() {
+		return true
+	}
+
 	pc.sctpTransport.lock.Lock()
 	lenDataChannel := len(pc.sctpTransport.dataChannels)
 	pc.sctpTransport.lock.Unlock()
@@ -394,6 +515,9 @@ func (pc *PeerConnection) checkNegotiationNeeded() bool { //nolint:gocognit,cycl
 		// if t.stopping && !t.stopped {
 		// 	return true
 		// }
+		if transceiver.stopped.Load() {
+			continue
+		}
 		mid := getByMid(transceiver.Mid(), localDesc)
 
 		// Step 5.2
@@ -462,6 +586,30 @@ func (pc *PeerConnection) OnICECandidate(f func(*ICECandidate)) {
 	pc.iceGatherer.OnLocalCandidate(f)
 }
 
+// OnICECandidateRemoved sets an event handler which is invoked when a local ICE candidate
+// is pruned, because the address it was gathered on was removed from the interfaces while
+// gathering continually, see SettingEngine.EnableContinualGathering. The candidate is no
+// longer in the LocalDescription, signal its removal to the remote so it stops using it.
+func (pc *PeerConnection) OnICECandidateRemoved(f func(*ICECandidate)) {
+	pc.iceGatherer.OnLocalCandidateRemoved(f)
+}
+
+// ConnectionTimeline returns when each milestone of the connection setup was first
+// reached, ordered by time. Milestones that weren't reached yet are omitted.
+func (pc *PeerConnection) ConnectionTimeline() []TimelineEvent {
+	return pc.timeline.events()
+}
+
+// OnICECandidateError sets an event handler which is invoked when a remote
+// ICE candidate can't be used, like a mDNS candidate whose host name can't
+// be resolved, see SettingEngine.SetICEMulticastDNSResolver. It is also
+// invoked when candidates can't be gathered from an ICE server, like a TURN
+// server with OAuth credentials.
+func (pc *PeerConnection) OnICECandidateError(f func(ICECandidateError)) {
+	pc.iceTransport.OnCandidateError(f)
+	pc.iceGatherer.OnCandidateError(f)
+}
+
 // OnICEGatheringStateChange sets an event handler which is invoked when the
 // ICE candidate gathering state has changed.
 func (pc *PeerConnection) OnICEGatheringStateChange(f func(ICEGatheringState)) {
@@ -493,6 +641,10 @@ func (pc *PeerConnection) onTrack(t *TrackRemote, r *RTPReceiver) {
 
 	pc.log.Debugf("got new track: %+v", t)
 	if t != nil {
+		if gating := pc.api.settingEngine.keyframeGating; gating.enabled && t.Kind() == RTPCodecTypeVideo {
+			t.enableKeyframeGating(gating.timeout, pc.WriteRTCP)
+		}
+
 		if handler != nil {
 			go handler(t, r)
 		} else {
@@ -578,7 +730,7 @@ func (pc *PeerConnection) SetConfiguration(configuration Configuration) error {
 	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #3.6)
 	if configuration.ICECandidatePoolSize != 0 {
 		if pc.configuration.ICECandidatePoolSize != configuration.ICECandidatePoolSize &&
-			pc.LocalDescription() != nil {
+			pc.localDescription() != nil {
 			return &rtcerr.InvalidModificationError{Err: ErrModifyingICECandidatePoolSize}
 		}
 
@@ -597,8 +749,27 @@ func (pc *PeerConnection) SetConfiguration(configuration Configuration) error {
 		}
 	}
 
+	// Not in W3C spec, the types of the local candidates can only be restricted further.
+	candidateTypes := pc.configuration.ICECandidateTypes
+	if len(configuration.ICECandidateTypes) > 0 {
+		if len(candidateTypes) > 0 && slices.ContainsFunc(configuration.ICECandidateTypes, func(typ ICECandidateType) bool {
+			return !slices.Contains(candidateTypes, typ)
+		}) {
+			return &rtcerr.InvalidModificationError{Err: ErrModifyingICECandidateTypes}
+		}
+		candidateTypes = slices.Clone(configuration.ICECandidateTypes)
+	}
+	if pc.iceGatherer != nil {
+		if err := pc.iceGatherer.checkCandidateTypes(candidateTypes, configuration.ICETransportPolicy); err != nil {
+			return err
+		}
+	} else if err := validateICECandidateTypes(candidateTypes, configuration.ICETransportPolicy); err != nil {
+		return err
+	}
+
 	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #7)
 	pc.configuration.ICETransportPolicy = configuration.ICETransportPolicy
+	pc.configuration.ICECandidateTypes = candidateTypes
 
 	// AlwaysNegotiateDataChannels is treated like other zero-value configuration
 	// fields: only a non-zero value (true) updates the existing setting.
@@ -618,12 +789,53 @@ func (pc *PeerConnection) SetConfiguration(configuration Configuration) error {
 	// https://www.w3.org/TR/webrtc/#set-the-configuration (step #9)
 	// Update the ICE gatherer so new servers take effect at the next gathering phase.
 	if pc.iceGatherer != nil {
+		pc.iceGatherer.setCandidateTypes(candidateTypes)
 		if err := pc.iceGatherer.updateServers(configuration.ICEServers, pc.configuration.ICETransportPolicy); err != nil {
 			pc.log.Debugf("Could not update ICE gatherer servers: %v", err)
 		}
 	}
 
+	pc.mu.Lock()
 	pc.configuration.ICEServers = configuration.ICEServers
+	pc.mu.Unlock()
+
+	return nil
+}
+
+// AddICEServers adds servers to the ICE servers of the Configuration, keeping the existing ones.
+// Candidates are gathered from them as described in ICEGatherer.GatherWith. Once gathering
+// started, negotiation is needed and the next offer restarts ICE to gather from them, like
+// OfferOptions.ICERestart. The new candidates, relay ones of a TURN server included, are
+// then fired by OnICECandidate while the ICE gathering state goes back to gathering. If
+// gathering was still running, negotiation stays needed until a restart after it completed.
+func (pc *PeerConnection) AddICEServers(servers []ICEServer) error {
+	if pc.isClosed.Load() {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	for _, server := range servers {
+		if err := server.validate(); err != nil {
+			return err
+		}
+	}
+
+	if err := pc.iceGatherer.GatherWith(servers); err != nil {
+		return err
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	iceServers := make([]ICEServer, 0, len(pc.configuration.ICEServers)+len(servers))
+	iceServers = append(iceServers, pc.configuration.ICEServers...)
+	pc.configuration.ICEServers = append(iceServers, servers...)
+
+	if pc.iceGatherer.State() != ICEGathererStateNew {
+		// An offer created before doesn't restart ICE for these servers
+		pc.iceRestartNeeded.Store(true)
+		pc.iceRestartOffer = ""
+		pc.onNegotiationNeeded()
+	}
 
 	return nil
 }
@@ -634,6 +846,9 @@ func (pc *PeerConnection) SetConfiguration(configuration Configuration) error {
 // has been called with Configuration passed as its only argument.
 // https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-getconfiguration
 func (pc *PeerConnection) GetConfiguration() Configuration {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
 	return pc.configuration
 }
 
@@ -648,6 +863,11 @@ func (pc *PeerConnection) ID() string {
 // caller of this method should hold `pc.mu` lock.
 func (pc *PeerConnection) hasLocalDescriptionChanged(desc *SessionDescription) bool {
 	for _, t := range pc.rtpTransceivers {
+		// Stopped transceivers are placeholders without a mid
+		if t.stopped.Load() {
+			continue
+		}
+
 		m := getByMid(t.Mid(), desc)
 		if m == nil {
 			return true
@@ -674,10 +894,22 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
-	if options != nil && options.ICERestart {
+	restartsForServers := false
+	if (options != nil && options.ICERestart) || pc.iceRestartNeeded.Load() {
 		if err := pc.iceTransport.restart(); err != nil {
 			return SessionDescription{}, err
 		}
+		// Servers added while gathering are only gathered from by a restart once it completed
+		restartsForServers = pc.iceRestartNeeded.Load() && !pc.iceGatherer.hasPendingServers()
+	}
+
+	if options != nil {
+		if err := pc.offerToReceive(RTPCodecTypeAudio, options.OfferToReceiveAudio); err != nil {
+			return SessionDescription{}, err
+		}
+		if err := pc.offerToReceive(RTPCodecTypeVideo, options.OfferToReceiveVideo); err != nil {
+			return SessionDescription{}, err
+		}
 	}
 
 	var (
@@ -685,6 +917,7 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		offer SessionDescription
 		err   error
 	)
+	disableVAD := options != nil && options.DisableVoiceActivityDetection
 
 	// This may be necessary to recompute if, for example, createOffer was called when only an
 	// audio RTCRtpTransceiver was added to connection, but while performing the in-parallel
@@ -693,6 +926,9 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 	count := 0
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
+	if err = pc.validateSSRCs(pc.currentRemoteDescription); err != nil {
+		return SessionDescription{}, err
+	}
 	for {
 		// We cache current transceivers to ensure they aren't
 		// mutated during offer generation. We later check if they have
@@ -745,7 +981,7 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 		}
 
 		if pc.currentRemoteDescription == nil {
-			descr, err = pc.generateUnmatchedSDP(currentTransceivers, useIdentity)
+			descr, err = pc.generateUnmatchedSDP(currentTransceivers, useIdentity, disableVAD)
 		} else {
 			descr, err = pc.generateMatchedSDP(
 				currentTransceivers,
@@ -753,6 +989,7 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 				true, /*includeUnmatched */
 				connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
 				false,
+				disableVAD,
 			)
 		}
 
@@ -791,15 +1028,43 @@ func (pc *PeerConnection) CreateOffer(options *OfferOptions) (SessionDescription
 	}
 
 	pc.lastOffer = offer.SDP
+	pc.iceRestartOffer = ""
+	if restartsForServers {
+		pc.iceRestartOffer = offer.SDP
+	}
 
 	return offer, nil
 }
 
+// offerToReceive adds recvonly transceivers of kind until at least count of them receive.
+func (pc *PeerConnection) offerToReceive(kind RTPCodecType, count int) error {
+	if count <= 0 {
+		return nil
+	}
+
+	for _, t := range pc.GetTransceivers() {
+		if t.Kind() == kind && !t.stopped.Load() && t.Direction().hasRecv() {
+			count--
+		}
+	}
+
+	for ; count > 0; count-- {
+		if _, err := pc.AddTransceiverFromKind(kind, RTPTransceiverInit{
+			Direction: RTPTransceiverDirectionRecvonly,
+		}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (pc *PeerConnection) createICEGatherer() (*ICEGatherer, error) {
 	g, err := pc.api.NewICEGatherer(ICEGatherOptions{
 		ICEServers:           pc.configuration.getICEServers(),
 		ICEGatherPolicy:      pc.configuration.ICETransportPolicy,
 		ICECandidatePoolSize: pc.configuration.ICECandidatePoolSize,
+		ICECandidateTypes:    pc.configuration.ICECandidateTypes,
 	})
 	if err != nil {
 		return nil, err
@@ -826,6 +1091,11 @@ func (pc *PeerConnection) updateConnectionState(
 	case iceConnectionState == ICEConnectionStateFailed || dtlsTransportState == DTLSTransportStateFailed:
 		connectionState = PeerConnectionStateFailed
 
+	// The SCTP association carrying the DataChannels was lost, it isn't re-established.
+	// The PeerConnection is closed with its DTLS transport instead unless DisableCloseByDTLS.
+	case pc.api.settingEngine.disableCloseByDTLS && pc.sctpTransport.failed():
+		connectionState = PeerConnectionStateFailed
+
 	// Any of the RTCIceTransports or RTCDtlsTransports are in the "disconnected"
 	// state and none of them are in the "failed" or "connecting" or "checking" state.  */
 	case iceConnectionState == ICEConnectionStateDisconnected:
@@ -894,7 +1164,7 @@ func (pc *PeerConnection) createICETransport() *ICETransport {
 //nolint:cyclop
 func (pc *PeerConnection) CreateAnswer(options *AnswerOptions) (SessionDescription, error) {
 	useIdentity := pc.idpLoginURL != nil
-	remoteDesc := pc.RemoteDescription()
+	remoteDesc := pc.remoteDescription()
 	switch {
 	case remoteDesc == nil:
 		return SessionDescription{}, &rtcerr.InvalidStateError{Err: ErrNoRemoteDescription}
@@ -929,12 +1199,17 @@ func (pc *PeerConnection) CreateAnswer(options *AnswerOptions) (SessionDescripti
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
 
+	if err := pc.validateSSRCs(remoteDesc); err != nil {
+		return SessionDescription{}, err
+	}
+
 	descr, err := pc.generateMatchedSDP(
 		pc.rtpTransceivers,
 		useIdentity,
 		false, /*includeUnmatched */
 		connectionRole,
 		pc.api.settingEngine.ignoreRidPauseForRecv,
+		options != nil && options.DisableVoiceActivityDetection,
 	)
 	if err != nil {
 		return SessionDescription{}, err
@@ -1012,11 +1287,14 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 					pc.currentRemoteDescription = pc.pendingRemoteDescription
 					pc.pendingRemoteDescription = nil
 					pc.pendingLocalDescription = nil
+					pc.sortRTPTransceivers()
 				}
+			// have-local-offer->SetLocal(rollback)->stable
 			case SDPTypeRollback:
 				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setLocal, sd.Type)
 				if err == nil {
 					pc.pendingLocalDescription = nil
+					pc.clearUnnegotiatedMids()
 				}
 			// have-remote-offer->SetLocal(pranswer)->have-local-pranswer
 			case SDPTypePranswer:
@@ -1047,6 +1325,11 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 					pc.currentLocalDescription = pc.pendingLocalDescription
 					pc.pendingRemoteDescription = nil
 					pc.pendingLocalDescription = nil
+					pc.sortRTPTransceivers()
+					if pc.iceRestartOffer != "" && pc.currentLocalDescription.SDP == pc.iceRestartOffer {
+						pc.iceRestartNeeded.Store(false)
+						pc.iceRestartOffer = ""
+					}
 				}
 			case SDPTypeRollback:
 				nextState, err = checkNextSignalingState(cur, SignalingStateStable, setRemote, sd.Type)
@@ -1083,14 +1366,30 @@ func (pc *PeerConnection) setDescription(sd *SessionDescription, op stateChangeO
 	return err
 }
 
-// SetLocalDescription sets the SessionDescription of the local peer
+// SetLocalDescription sets the SessionDescription of the local peer. A description of type
+// rollback discards the pending local offer and returns to the stable state, the transceivers
+// that were first offered by it can then be matched by a remote offer. An ICE restart of the
+// discarded offer is not undone.
 //
-//nolint:cyclop
+// Descriptions passed to SetLocalDescriptionAsync and SetRemoteDescriptionAsync before are
+// applied first.
 func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
+	pc.waitPendingDescriptions()
+
+	return pc.setLocalDescription(desc)
+}
+
+//nolint:cyclop
+func (pc *PeerConnection) setLocalDescription(desc SessionDescription) error {
 	if pc.isClosed.Load() {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	// JSEP 5.7
+	if desc.Type == SDPTypeRollback {
+		return pc.setDescription(&desc, stateChangeOpSetLocal)
+	}
+
 	haveLocalDescription := pc.currentLocalDescription != nil
 
 	// JSEP 5.4
@@ -1115,12 +1414,18 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 		return err
 	}
 
+	// The comfort noise codecs left out of the answer, see DisableVoiceActivityDetection, aren't negotiated
+	if desc.Type == SDPTypeAnswer && !haveComfortNoise(desc.parsed) {
+		pc.api.mediaEngine.removeNegotiatedCodecs(RTPCodecTypeAudio, isComfortNoiseCodec)
+	}
+
 	currentTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
 
 	weAnswer := desc.Type == SDPTypeAnswer
-	remoteDesc := pc.RemoteDescription()
+	remoteDesc := pc.remoteDescription()
 	if weAnswer && remoteDesc != nil {
 		_ = setRTPTransceiverCurrentDirection(&desc, currentTransceivers, false)
+		pc.setRTPTransceiverNegotiatedParameters(&desc, currentTransceivers)
 		if err := pc.startRTPSenders(currentTransceivers); err != nil {
 			return err
 		}
@@ -1136,6 +1441,7 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 	}
 
 	pc.iceGatherer.flushCandidates()
+	pc.updateDSCP()
 
 	if pc.iceGatherer.State() == ICEGathererStateNew {
 		return pc.iceGatherer.Gather()
@@ -1144,6 +1450,55 @@ func (pc *PeerConnection) SetLocalDescription(desc SessionDescription) error {
 	return nil
 }
 
+// updateDSCP marks the sockets of the ICE agent with the highest DSCP of the kinds of traffic
+// the PeerConnection sends.
+func (pc *PeerConnection) updateDSCP() {
+	values := pc.api.settingEngine.dscp
+	if !values.enabled {
+		return
+	}
+
+	var dscp uint8
+	for _, transceiver := range pc.GetTransceivers() {
+		if transceiver.stopped.Load() || !transceiver.Direction().hasSend() {
+			continue
+		}
+		switch transceiver.Kind() {
+		case RTPCodecTypeAudio:
+			dscp = max(dscp, values.audio)
+		case RTPCodecTypeVideo:
+			dscp = max(dscp, values.video)
+		default:
+		}
+	}
+
+	for _, desc := range []*SessionDescription{pc.localDescription(), pc.remoteDescription()} {
+		if desc == nil || desc.parsed == nil {
+			continue
+		}
+		for _, media := range desc.parsed.MediaDescriptions {
+			if media.MediaName.Media == mediaSectionApplication && !isRejectedMediaSection(media) {
+				dscp = max(dscp, values.data)
+			}
+		}
+	}
+
+	pc.iceGatherer.setDSCP(dscp)
+}
+
+// clearUnnegotiatedMids clears the mids that were assigned by a local offer which was rolled
+// back, so the transceivers can be associated with the media sections of a remote offer.
+// caller of this method should hold `pc.mu` lock.
+func (pc *PeerConnection) clearUnnegotiatedMids() {
+	for _, t := range pc.rtpTransceivers {
+		mid := t.Mid()
+		if mid == "" || (pc.currentLocalDescription != nil && getByMid(mid, pc.currentLocalDescription) != nil) {
+			continue
+		}
+		t.mid.Store("")
+	}
+}
+
 // LocalDescription returns PendingLocalDescription if it is not null and
 // otherwise it returns CurrentLocalDescription. This property is used to
 // determine if SetLocalDescription has already been called.
@@ -1156,20 +1511,113 @@ func (pc *PeerConnection) LocalDescription() *SessionDescription {
 	return pc.CurrentLocalDescription()
 }
 
-// SetRemoteDescription sets the SessionDescription of the remote peer
+// localDescription is LocalDescription without the copy and the local candidates, the
+// description must not be modified.
+func (pc *PeerConnection) localDescription() *SessionDescription {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	if pc.pendingLocalDescription != nil {
+		return pc.pendingLocalDescription
+	}
+
+	return pc.currentLocalDescription
+}
+
+// negotiatedLocalDescription is CurrentLocalDescription without the copy and the local
+// candidates, the description must not be modified.
+func (pc *PeerConnection) negotiatedLocalDescription() *SessionDescription {
+	pc.mu.RLock()
+	defer pc.mu.RUnlock()
+
+	return pc.currentLocalDescription
+}
+
+// stopRejectedTransceivers stops the transceivers whose media sections were rejected by the remote.
+func (pc *PeerConnection) stopRejectedTransceivers(remoteDesc *sdp.SessionDescription) error {
+	transceivers := pc.GetTransceivers()
+	for _, media := range remoteDesc.MediaDescriptions {
+		midValue := getMidValue(media)
+		if midValue == "" || media.MediaName.Media == mediaSectionApplication || !isRejectedMediaSection(media) {
+			continue
+		}
+
+		for _, transceiver := range transceivers {
+			if transceiver.Mid() != midValue {
+				continue
+			}
+			if err := transceiver.stopRejected(); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// updateCandidateMLineIndex keeps the SDPMLineIndex of local candidates pointing at the media
+// section of their SDPMid, when the remote reorders its media sections.
+func (pc *PeerConnection) updateCandidateMLineIndex(remoteDesc *sdp.SessionDescription) {
+	mid, ok := pc.iceGatherer.sdpMid.Load().(string)
+	if !ok || mid == "" {
+		return
+	}
+
+	for i, media := range remoteDesc.MediaDescriptions {
+		if getMidValue(media) == mid {
+			pc.iceGatherer.setMediaStreamIdentification(mid, uint16(i)) //nolint:gosec // G115
+
+			return
+		}
+	}
+}
+
+// SetRemoteDescription sets the SessionDescription of the remote peer. Rolling back a remote
+// offer is not supported.
 //
-//nolint:gocognit,gocyclo,cyclop,maintidx
+// A media section of an offer that no transceiver matches gets a new one without a sender:
+// sendonly if the remote only receives, recvonly if it sends, and inactive otherwise. AddTrack
+// attaches tracks to these transceivers before it creates new ones.
+//
+// Descriptions passed to SetLocalDescriptionAsync and SetRemoteDescriptionAsync before are
+// applied first.
 func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
+	pc.waitPendingDescriptions()
+
+	return pc.setRemoteDescription(desc)
+}
+
+//nolint:gocognit,gocyclo,cyclop,maintidx
+func (pc *PeerConnection) setRemoteDescription(desc SessionDescription) error {
 	if pc.isClosed.Load() {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	// Applying a remote offer has effects that can't be undone, like starting the transports
+	if desc.Type == SDPTypeRollback {
+		return &rtcerr.InvalidModificationError{Err: errPeerConnRemoteRollbackUnsupported}
+	}
+
 	isRenegotiation := pc.currentRemoteDescription != nil
 
 	if _, err := desc.Unmarshal(); err != nil {
 		return err
 	}
 
+	if err := pc.api.settingEngine.trackIdentifierPolicy.validate(desc.parsed); err != nil {
+		if pc.api.settingEngine.trackIdentifierPolicy.rejectInvalid {
+			return &rtcerr.InvalidAccessError{Err: err}
+		}
+		pc.log.Warnf("Sanitizing track identifiers of remote description: %v", err)
+	}
+
+	pc.mu.Lock()
+	err := pc.validateSSRCs(&desc)
+	pc.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
 	if err := pc.setDescription(&desc, stateChangeOpSetRemote); err != nil {
 		return err
 	}
@@ -1178,6 +1626,8 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		return err
 	}
 
+	pc.updateCandidateMLineIndex(desc.parsed)
+
 	canTrickle := hasICETrickleOption(desc.parsed)
 	pc.mu.Lock()
 	switch desc.Type {
@@ -1199,17 +1649,30 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 
 	var transceiver *RTPTransceiver
 	localTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
-	detectedPlanB := descriptionIsPlanB(pc.RemoteDescription(), pc.log)
+	detectedPlanB := descriptionIsPlanB(pc.remoteDescription(), pc.log)
 	if pc.configuration.SDPSemantics != SDPSemanticsUnifiedPlan {
-		detectedPlanB = descriptionPossiblyPlanB(pc.RemoteDescription())
+		detectedPlanB = descriptionPossiblyPlanB(pc.remoteDescription())
 	}
 
 	weOffer := desc.Type == SDPTypeAnswer
 
+	if !detectedPlanB {
+		if err := pc.stopRejectedTransceivers(desc.parsed); err != nil {
+			return err
+		}
+	}
+
 	if !weOffer && !detectedPlanB { //nolint:nestif
-		for _, media := range pc.RemoteDescription().parsed.MediaDescriptions {
+		for _, media := range pc.remoteDescription().parsed.MediaDescriptions {
 			midValue := getMidValue(media)
-			if midValue == "" {
+			if isRejectedMediaSection(media) {
+				// Keep a stopped transceiver from matching a new media section
+				if midValue != "" {
+					_, localTransceivers = findByMid(midValue, localTransceivers)
+				}
+
+				continue
+			} else if midValue == "" {
 				return errPeerConnRemoteDescriptionWithoutMidValue
 			}
 
@@ -1227,6 +1690,9 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 			if transceiver == nil {
 				transceiver, localTransceivers = satisfyTypeAndDirection(kind, direction, localTransceivers)
 			} else if direction == RTPTransceiverDirectionInactive {
+				if err := transceiver.endRemoteTrack(); err != nil {
+					return err
+				}
 				if err := transceiver.Stop(); err != nil {
 					return err
 				}
@@ -1307,10 +1773,12 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 	}
 
 	currentTransceivers := append([]*RTPTransceiver{}, pc.GetTransceivers()...)
+	pc.updateDSCP()
 
 	if isRenegotiation {
 		if weOffer {
 			_ = setRTPTransceiverCurrentDirection(&desc, currentTransceivers, true)
+			pc.setRTPTransceiverNegotiatedParameters(&desc, currentTransceivers)
 			if err = pc.startRTPSenders(currentTransceivers); err != nil {
 				return err
 			}
@@ -1339,10 +1807,9 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		iceRole = ICERoleControlling
 	}
 
-	// Start the networking in a new routine since it will block until
-	// the connection is actually established.
 	if weOffer {
 		_ = setRTPTransceiverCurrentDirection(&desc, currentTransceivers, true)
+		pc.setRTPTransceiverNegotiatedParameters(&desc, currentTransceivers)
 		if err := pc.startRTPSenders(currentTransceivers); err != nil {
 			return err
 		}
@@ -1350,15 +1817,21 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 		pc.configureRTPReceivers(false, &desc, currentTransceivers)
 	}
 
+	// Start the networking in a new routine since it will block until
+	// the connection is actually established. It doesn't hold up the
+	// operations queue, startRTP waits for it instead.
 	pc.ops.Enqueue(func() {
-		pc.startTransports(
-			iceRole,
-			dtlsRoleFromSDP(desc.parsed),
-			iceDetails.Ufrag,
-			iceDetails.Password,
-			fingerprint,
-			fingerprintHash,
-		)
+		go func() {
+			defer close(pc.transportsStarted)
+			pc.startTransports(
+				iceRole,
+				dtlsRoleFromSDP(desc.parsed),
+				iceDetails.Ufrag,
+				iceDetails.Password,
+				fingerprint,
+				fingerprintHash,
+			)
+		}()
 		if weOffer {
 			pc.startRTP(false, &desc, currentTransceivers)
 		}
@@ -1367,6 +1840,20 @@ func (pc *PeerConnection) SetRemoteDescription(desc SessionDescription) error {
 	return nil
 }
 
+// WaitForPendingOperations blocks until the operations enqueued by the PeerConnection
+// so far are finished, like starting the RTPSenders and RTPReceivers after
+// SetLocalDescription and SetRemoteDescription. It returns the error of ctx if it is
+// done first. Callbacks running on the operations queue, like OnNegotiationNeeded,
+// get an InvalidStateError wrapping ErrWaitInOperation instead of blocking forever.
+func (pc *PeerConnection) WaitForPendingOperations(ctx context.Context) error {
+	err := pc.ops.Wait(ctx)
+	if errors.Is(err, ErrWaitInOperation) {
+		return &rtcerr.InvalidStateError{Err: err}
+	}
+
+	return err
+}
+
 func (pc *PeerConnection) configureReceiver(incoming trackDetails, receiver *RTPReceiver) {
 	receiver.configureReceive(trackDetailsToRTPReceiveParameters(&incoming))
 
@@ -1374,8 +1861,7 @@ func (pc *PeerConnection) configureReceiver(incoming trackDetails, receiver *RTP
 	// is received from the SDP.
 	for i := range receiver.tracks {
 		receiver.tracks[i].track.mu.Lock()
-		receiver.tracks[i].track.id = incoming.id
-		receiver.tracks[i].track.streamID = incoming.streamID
+		receiver.tracks[i].track.setIdentifiers(&incoming)
 		receiver.tracks[i].track.mu.Unlock()
 	}
 }
@@ -1393,6 +1879,7 @@ func (pc *PeerConnection) startReceiver(incoming trackDetails, receiver *RTPRece
 		}
 
 		if pc.api.settingEngine.fireOnTrackBeforeFirstRTP {
+			track.watchStarvation(pc.api.settingEngine.trackFirstPacketTimeout)
 			pc.onTrack(track, receiver)
 
 			return
@@ -1470,6 +1957,39 @@ func setRTPTransceiverCurrentDirection(
 	return nil
 }
 
+// setRTPTransceiverNegotiatedParameters gives the transceivers the parameters of their media
+// section in answer, which are the parameters both endpoints negotiated.
+func (pc *PeerConnection) setRTPTransceiverNegotiatedParameters(
+	answer *SessionDescription,
+	currentTransceivers []*RTPTransceiver,
+) {
+	// The media sections are bundled on one transport, RTCP is only sent reduced-size
+	// if all of them negotiated it
+	rtcpCompound := false
+	for _, media := range answer.parsed.MediaDescriptions {
+		midValue := getMidValue(media)
+		if midValue == "" || media.MediaName.Media == mediaSectionApplication || isRejectedMediaSection(media) {
+			continue
+		}
+
+		parameters, err := negotiatedParametersFromMediaDescription(media)
+		if err != nil {
+			pc.log.Warnf("Failed to parse the negotiated parameters of media section %s: %v", midValue, err)
+
+			continue
+		}
+		rtcpCompound = rtcpCompound || !parameters.RTCP.ReducedSize
+
+		// Plan-B shares a media section between transceivers
+		for _, transceiver := range currentTransceivers {
+			if transceiver.Mid() == midValue {
+				transceiver.setNegotiatedParameters(parameters)
+			}
+		}
+	}
+	pc.dtlsTransport.setRTCPCompound(rtcpCompound)
+}
+
 func runIfNewReceiver(
 	incomingTrack trackDetails,
 	transceivers []*RTPTransceiver,
@@ -1504,7 +2024,7 @@ func (pc *PeerConnection) configureRTPReceivers(
 	remoteDesc *SessionDescription,
 	currentTransceivers []*RTPTransceiver,
 ) {
-	incomingTracks := trackDetailsFromSDP(pc.log, remoteDesc.parsed)
+	incomingTracks := pc.filterPendingUnknownSSRCs(pc.remoteTrackDetails(remoteDesc.parsed))
 
 	if isRenegotiation { //nolint:nestif
 		for _, transceiver := range currentTransceivers {
@@ -1519,7 +2039,10 @@ func (pc *PeerConnection) configureRTPReceivers(
 			}
 
 			mid := transceiver.Mid()
-			receiverNeedsStopped := false
+			// A transceiver that doesn't receive anymore ends its tracks, even if the remote
+			// still sends. A new receiver is started once receiving is reinstated.
+			receives := transceiver.Direction().hasRecv()
+			receiverNeedsStopped := !receives
 			for _, trackRemote := range tracks {
 				func(track *TrackRemote) {
 					track.mu.Lock()
@@ -1527,15 +2050,13 @@ func (pc *PeerConnection) configureRTPReceivers(
 
 					if track.rid != "" {
 						if details := trackDetailsForRID(incomingTracks, mid, track.rid); details != nil {
-							track.id = details.id
-							track.streamID = details.streamID
+							track.setIdentifiers(details)
 
 							return
 						}
 					} else if track.ssrc != 0 {
 						if details := trackDetailsForSSRC(incomingTracks, track.ssrc); details != nil {
-							track.id = details.id
-							track.streamID = details.streamID
+							track.setIdentifiers(details)
 
 							return
 						}
@@ -1549,7 +2070,11 @@ func (pc *PeerConnection) configureRTPReceivers(
 				continue
 			}
 
-			if err := receiver.Stop(); err != nil {
+			reason := ErrTrackEnded
+			if receives && mediaSectionSends(remoteDesc.parsed, mid) {
+				reason = ErrReceiverRestarted
+			}
+			if err := receiver.stop(reason); err != nil {
 				pc.log.Warnf("Failed to stop RtpReceiver: %s", err)
 
 				continue
@@ -1589,9 +2114,17 @@ func (pc *PeerConnection) configureRTPReceivers(
 	}
 }
 
+// remoteTrackDetails returns the tracks of a remote description, with identifiers
+// conforming to the track identifier policy.
+func (pc *PeerConnection) remoteTrackDetails(desc *sdp.SessionDescription) []trackDetails {
+	return pc.api.settingEngine.trackIdentifierPolicy.apply(trackDetailsFromSDP(pc.log, desc))
+}
+
 // startRTPReceivers opens knows inbound SRTP streams from the RemoteDescription.
 func (pc *PeerConnection) startRTPReceivers(remoteDesc *SessionDescription, currentTransceivers []*RTPTransceiver) {
-	incomingTracks := trackDetailsFromSDP(pc.log, remoteDesc.parsed)
+	setReceiversRemoteCodecs(remoteDesc, currentTransceivers)
+
+	incomingTracks := pc.filterPendingUnknownSSRCs(pc.remoteTrackDetails(remoteDesc.parsed))
 	if len(incomingTracks) == 0 {
 		return
 	}
@@ -1611,7 +2144,7 @@ func (pc *PeerConnection) startRTPReceivers(remoteDesc *SessionDescription, curr
 	case SDPSemanticsPlanB:
 		remoteIsPlanB = true
 	case SDPSemanticsUnifiedPlanWithFallback:
-		remoteIsPlanB = descriptionPossiblyPlanB(pc.RemoteDescription())
+		remoteIsPlanB = descriptionPossiblyPlanB(pc.remoteDescription())
 	default:
 		// none
 	}
@@ -1632,12 +2165,46 @@ func (pc *PeerConnection) startRTPReceivers(remoteDesc *SessionDescription, curr
 	}
 }
 
-// startRTPSenders starts all outbound RTP streams.
+// setReceiversRemoteCodecs gives the receivers the codecs of their media section in the remote description,
+// payload types are only meaningful within a media section.
+func setReceiversRemoteCodecs(remoteDesc *SessionDescription, transceivers []*RTPTransceiver) {
+	for _, media := range remoteDesc.parsed.MediaDescriptions {
+		midValue := getMidValue(media)
+		if midValue == "" {
+			continue
+		}
+
+		codecs, err := codecsFromMediaDescription(media)
+		if err != nil {
+			continue
+		}
+
+		for _, transceiver := range transceivers {
+			if receiver := transceiver.Receiver(); receiver != nil && transceiver.Mid() == midValue {
+				receiver.setRemoteCodecs(codecs)
+			}
+		}
+	}
+}
+
+// startRTPSenders starts all outbound RTP streams. Senders are paused instead of stopped while
+// the negotiated direction doesn't allow sending, so they resume with the same SSRCs.
 func (pc *PeerConnection) startRTPSenders(currentTransceivers []*RTPTransceiver) error {
 	for _, transceiver := range currentTransceivers {
-		if sender := transceiver.Sender(); sender != nil && sender.isNegotiated() && !sender.hasSent() {
-			err := sender.Send(sender.GetParameters())
-			if err != nil {
+		sender := transceiver.Sender()
+		if sender == nil {
+			continue
+		}
+
+		switch transceiver.getCurrentDirection() {
+		case RTPTransceiverDirectionRecvonly, RTPTransceiverDirectionInactive:
+			sender.setSendPaused(true)
+		default:
+			sender.setSendPaused(false)
+		}
+
+		if sender.isNegotiated() && !sender.hasSent() {
+			if err := sender.Send(sender.GetParameters()); err != nil {
 				return err
 			}
 		}
@@ -1700,6 +2267,7 @@ func (pc *PeerConnection) handleUndeclaredSSRC(
 	if mediaSection.MediaName.Media == RTPCodecTypeAudio.String() {
 		incoming.kind = RTPCodecTypeAudio
 	}
+	incoming = pc.api.settingEngine.trackIdentifierPolicy.apply([]trackDetails{incoming})[0]
 
 	t, err := pc.AddTransceiverFromKind(incoming.kind, RTPTransceiverInit{
 		Direction: RTPTransceiverDirectionSendrecv,
@@ -1717,17 +2285,25 @@ func (pc *PeerConnection) handleUndeclaredSSRC(
 
 // For legacy clients that didn't support urn:ietf:params:rtp-hdrext:sdes:rtp-stream-id
 // or urn:ietf:params:rtp-hdrext:sdes:mid extension, and didn't declare a=ssrc lines.
-// Assumes that the payload type is unique across the media section.
+// Only media sections of the kind of the negotiated codec are considered, so an audio and
+// a video section using the same payload type don't depend on their order.
+// Assumes that the payload type is unique across the media sections of a kind.
 func (pc *PeerConnection) findMediaSectionByPayloadType(
 	payloadType PayloadType,
 	remoteDescription *SessionDescription,
 ) (selectedMediaSection *sdp.MediaDescription, ok bool) {
+	// codecKind is 0 if no codec uses the payload type
+	_, codecKind, _ := pc.api.mediaEngine.getCodecByPayload(payloadType)
+
 	for i := range remoteDescription.parsed.MediaDescriptions {
 		descr := remoteDescription.parsed.MediaDescriptions[i]
 		media := descr.MediaName.Media
 		if !strings.EqualFold(media, "video") && !strings.EqualFold(media, "audio") {
 			continue
 		}
+		if codecKind != 0 && NewRTPCodecType(media) != codecKind {
+			continue
+		}
 
 		formats := descr.MediaName.Formats
 		for _, payloadStr := range formats {
@@ -1777,7 +2353,7 @@ func (pc *PeerConnection) handleNonMediaBandwidthProbe() {
 }
 
 func (pc *PeerConnection) handleIncomingSSRC(rtpStream *srtp.ReadStreamSRTP, ssrc SSRC) error { //nolint:gocyclo,gocognit,cyclop,lll
-	remoteDescription := pc.RemoteDescription()
+	remoteDescription := pc.remoteDescription()
 	if remoteDescription == nil {
 		return errPeerConnRemoteDescriptionNil
 	}
@@ -1795,6 +2371,12 @@ func (pc *PeerConnection) handleIncomingSSRC(rtpStream *srtp.ReadStreamSRTP, ssr
 		}
 	}
 
+	if handler := pc.api.settingEngine.unknownSSRC.handler; handler != nil {
+		if handled, err := pc.handleUnknownSSRCWithHandler(rtpStream, ssrc, handler); handled || err != nil {
+			return err
+		}
+	}
+
 	// if the SSRC is not declared in the SDP and there is only one media section,
 	// we attempt to resolve it using this single section
 	// This applies even if the client supports RTP extensions:
@@ -1920,38 +2502,253 @@ func (pc *PeerConnection) handleIncomingSSRC(rtpStream *srtp.ReadStreamSRTP, ssr
 			continue
 		}
 
-		for _, t := range pc.GetTransceivers() {
-			receiver := t.Receiver()
-			if t.Mid() != mid || receiver == nil {
-				continue
-			}
+		transceiver := pc.transceiverForMidExtension(mid)
+		if transceiver == nil || transceiver.Receiver() == nil {
+			continue
+		}
+		receiver := transceiver.Receiver()
 
-			if rsid != "" {
-				return receiver.receiveForRtx(SSRC(0), rsid, streamInfo, readStream, interceptor, rtcpReadStream, rtcpInterceptor)
-			}
+		if rsid != "" {
+			return receiver.receiveForRtx(SSRC(0), rsid, streamInfo, readStream, interceptor, rtcpReadStream, rtcpInterceptor)
+		}
+
+		track, err := receiver.receiveForRid(
+			rid,
+			params,
+			streamInfo,
+			readStream,
+			interceptor,
+			rtcpReadStream,
+			rtcpInterceptor,
+			peekedPackets,
+		)
+		if err != nil {
+			return err
+		}
+		pc.onTrack(track, receiver)
+
+		return nil
+	}
+
+	pc.api.interceptor.UnbindRemoteStream(streamInfo)
+
+	return errPeerConnSimulcastIncomingSSRCFailed
+}
+
+// transceiverForMidExtension returns the transceiver of mid, the value of the mid RTP header
+// extension, or nil if there is none. Mids are opaque strings that are compared as a whole,
+// except that some senders truncate the mids longer than a one-byte header extension can
+// carry. A truncated mid matches the transceiver whose mid it is the prefix of, if there is
+// only one.
+func (pc *PeerConnection) transceiverForMidExtension(mid string) *RTPTransceiver {
+	var truncatedMatches []*RTPTransceiver
+	for _, t := range pc.GetTransceivers() {
+		transceiverMid := t.Mid()
+		switch {
+		case transceiverMid == mid:
+			return t
+		case len(mid) == maxOneByteHeaderExtensionSize && strings.HasPrefix(transceiverMid, mid):
+			truncatedMatches = append(truncatedMatches, t)
+		}
+	}
+
+	if len(truncatedMatches) != 1 {
+		return nil
+	}
+
+	return truncatedMatches[0]
+}
+
+// handleUnknownSSRCWithHandler asks the UnknownSSRCHandler what to do with an undeclared SSRC.
+// It returns false if the SSRC should be resolved with the default heuristics.
+func (pc *PeerConnection) handleUnknownSSRCWithHandler(
+	rtpStream *srtp.ReadStreamSRTP,
+	ssrc SSRC,
+	handler UnknownSSRCHandler,
+) (handled bool, err error) {
+	b := make([]byte, pc.api.settingEngine.getReceiveMTU())
+	i, err := rtpStream.Peek(b)
+	if err != nil {
+		return false, err
+	}
+
+	if i < 4 {
+		return false, errRTPTooShort
+	}
+
+	midExtensionID, _, _ := pc.api.mediaEngine.getHeaderExtensionID(RTPHeaderExtensionCapability{sdp.SDESMidURI})
+	streamIDExtensionID, _, _ := pc.api.mediaEngine.getHeaderExtensionID(
+		RTPHeaderExtensionCapability{sdp.SDESRTPStreamIDURI},
+	)
+	mid, rid, _, _, err := handleUnknownRTPPacket(
+		b[:i], uint8(midExtensionID), //nolint:gosec // G115
+		uint8(streamIDExtensionID), //nolint:gosec // G115
+		0,
+	)
+	if err != nil {
+		return false, err
+	}
+
+	payloadType := PayloadType(b[1] & rtpPayloadTypeBitmask)
+	action := handler(uint32(ssrc), uint8(payloadType), mid, rid)
+	switch action.Type {
+	case UnknownSSRCActionTypeDrop:
+		pc.log.Debugf("Dropping undeclared SSRC %d as requested by UnknownSSRCHandler", ssrc)
+
+		return true, nil
+	case UnknownSSRCActionTypeAttachToTransceiver:
+		return true, pc.attachUnknownSSRC(ssrc, payloadType, rid, action.MID)
+	default:
+		return false, nil
+	}
+}
+
+// attachUnknownSSRC binds an undeclared SSRC to the transceiver with the given mid. Until
+// that transceiver exists, packets are buffered so they can be read from the TrackRemote later.
+// The packets are buffered by a goroutine of its own, so the SSRC is resolved right away.
+func (pc *PeerConnection) attachUnknownSSRC(ssrc SSRC, payloadType PayloadType, rid, mid string) error {
+	params, err := pc.api.mediaEngine.getRTPParametersByPayloadType(payloadType)
+	if err != nil {
+		return err
+	}
+
+	pc.pendingUnknownSSRCsMu.Lock()
+	if pc.pendingUnknownSSRCs == nil {
+		pc.pendingUnknownSSRCs = map[SSRC]struct{}{}
+	}
+	pc.pendingUnknownSSRCs[ssrc] = struct{}{}
+	pc.pendingUnknownSSRCsMu.Unlock()
+
+	streamInfo := createStreamInfo(
+		"",
+		ssrc,
+		0, 0,
+		params.Codecs[0].PayloadType,
+		0, 0,
+		params.Codecs[0].RTPCodecCapability,
+		params.HeaderExtensions,
+	)
+	result, err := pc.dtlsTransport.streamsForSSRC(ssrc, *streamInfo)
+	if err != nil {
+		pc.removePendingUnknownSSRC(ssrc)
+
+		return err
+	}
+
+	go func() {
+		defer pc.removePendingUnknownSSRC(ssrc)
 
-			track, err := receiver.receiveForRid(
+		if err := pc.bufferUnknownSSRC(ssrc, rid, mid, params, streamInfo, result); err != nil {
+			pc.log.Errorf(incomingUnhandledRTPSsrc, ssrc, err)
+		}
+	}()
+
+	return nil
+}
+
+// bufferUnknownSSRC buffers the packets of an undeclared SSRC attached by the UnknownSSRCHandler
+// until the transceiver with mid exists, and binds them to its receiver then.
+func (pc *PeerConnection) bufferUnknownSSRC(
+	ssrc SSRC,
+	rid, mid string,
+	params RTPParameters,
+	streamInfo *interceptor.StreamInfo,
+	result *streamsForSSRCResult,
+) error {
+	limit := pc.api.settingEngine.getUnknownSSRCBufferedPacketLimit()
+	limitReached := false
+	peekedPackets := []*peekedPacket(nil)
+	b := make([]byte, pc.api.settingEngine.getReceiveMTU())
+	for {
+		if receiver := pc.receiverForUnknownSSRC(mid); receiver != nil {
+			track, err := receiver.receiveForSSRC(
+				ssrc,
 				rid,
 				params,
 				streamInfo,
-				readStream,
-				interceptor,
-				rtcpReadStream,
-				rtcpInterceptor,
+				result.rtpReadStream,
+				result.rtpInterceptor,
+				result.rtcpReadStream,
+				result.rtcpInterceptor,
 				peekedPackets,
 			)
 			if err != nil {
+				pc.api.interceptor.UnbindRemoteStream(streamInfo)
+
 				return err
 			}
+
+			if remoteDescription := pc.remoteDescription(); remoteDescription != nil {
+				if details := trackDetailsForSSRC(pc.remoteTrackDetails(remoteDescription.parsed), ssrc); details != nil {
+					track.mu.Lock()
+					track.setIdentifiers(details)
+					track.mu.Unlock()
+				}
+			}
 			pc.onTrack(track, receiver)
 
 			return nil
 		}
+
+		i, attributes, err := result.rtpInterceptor.Read(b, nil)
+		if err != nil {
+			pc.api.interceptor.UnbindRemoteStream(streamInfo)
+
+			return err
+		}
+
+		if len(peekedPackets) >= limit {
+			if !limitReached {
+				pc.log.Warnf("Buffered packet limit reached for undeclared SSRC %d, dropping packets", ssrc)
+				limitReached = true
+			}
+
+			continue
+		}
+
+		peekedPackets = append(peekedPackets, &peekedPacket{
+			payload:    slices.Clone(b[:i]),
+			attributes: attributes,
+		})
 	}
+}
 
-	pc.api.interceptor.UnbindRemoteStream(streamInfo)
+// receiverForUnknownSSRC returns the receiver an undeclared SSRC should be attached to,
+// or nil if its transceiver doesn't exist yet.
+func (pc *PeerConnection) receiverForUnknownSSRC(mid string) *RTPReceiver {
+	for _, t := range pc.GetTransceivers() {
+		direction := t.Direction()
+		if t.Mid() != mid ||
+			(direction != RTPTransceiverDirectionRecvonly && direction != RTPTransceiverDirectionSendrecv) {
+			continue
+		}
 
-	return errPeerConnSimulcastIncomingSSRCFailed
+		if receiver := t.Receiver(); receiver != nil && !receiver.haveReceived() {
+			return receiver
+		}
+	}
+
+	return nil
+}
+
+func (pc *PeerConnection) removePendingUnknownSSRC(ssrc SSRC) {
+	pc.pendingUnknownSSRCsMu.Lock()
+	defer pc.pendingUnknownSSRCsMu.Unlock()
+
+	delete(pc.pendingUnknownSSRCs, ssrc)
+}
+
+// filterPendingUnknownSSRCs removes tracks the UnknownSSRCHandler is already attaching,
+// so they aren't started a second time once they are signaled.
+func (pc *PeerConnection) filterPendingUnknownSSRCs(incomingTracks []trackDetails) []trackDetails {
+	pc.pendingUnknownSSRCsMu.Lock()
+	defer pc.pendingUnknownSSRCsMu.Unlock()
+
+	for ssrc := range pc.pendingUnknownSSRCs {
+		incomingTracks = filterTrackWithSSRC(incomingTracks, ssrc)
+	}
+
+	return incomingTracks
 }
 
 // undeclaredMediaProcessor handles RTP/RTCP packets that don't match any a:ssrc lines.
@@ -1983,6 +2780,7 @@ func (pc *PeerConnection) undeclaredRTPMediaProcessor() { //nolint:cyclop
 
 			return
 		}
+		pc.timeline.record(TimelineEventFirstSRTPPacket, "")
 
 		// open accompanying srtcp stream
 		srtcpReadStream, err := srtcpSession.OpenReadStream(ssrc)
@@ -2003,9 +2801,10 @@ func (pc *PeerConnection) undeclaredRTPMediaProcessor() { //nolint:cyclop
 			continue
 		}
 
-		pc.dtlsTransport.storeSimulcastStream(srtpReadStream, srtcpReadStream)
+		pair := pc.dtlsTransport.storeSimulcastStream(SSRC(ssrc), srtpReadStream, srtcpReadStream)
 
 		if ssrc == 0 {
+			pc.dtlsTransport.resolveSimulcastStream(pair)
 			go pc.handleNonMediaBandwidthProbe()
 
 			continue
@@ -2021,6 +2820,8 @@ func (pc *PeerConnection) undeclaredRTPMediaProcessor() { //nolint:cyclop
 		go func(rtpStream *srtp.ReadStreamSRTP, ssrc SSRC) {
 			if err := pc.handleIncomingSSRC(rtpStream, ssrc); err != nil {
 				pc.log.Errorf(incomingUnhandledRTPSsrc, ssrc, err)
+			} else {
+				pc.dtlsTransport.resolveSimulcastStream(pair)
 			}
 			atomic.AddUint64(&simulcastRoutineCount, ^uint64(0))
 		}(srtpReadStream, SSRC(ssrc))
@@ -2058,6 +2859,11 @@ func (pc *PeerConnection) undeclaredRTCPMediaProcessor() {
 // determine if setRemoteDescription has already been called.
 // https://www.w3.org/TR/webrtc/#dom-rtcpeerconnection-remotedescription
 func (pc *PeerConnection) RemoteDescription() *SessionDescription {
+	return pc.remoteDescription().clone()
+}
+
+// remoteDescription is RemoteDescription without the copy, the description must not be modified.
+func (pc *PeerConnection) remoteDescription() *SessionDescription {
 	pc.mu.RLock()
 	defer pc.mu.RUnlock()
 
@@ -2071,7 +2877,7 @@ func (pc *PeerConnection) RemoteDescription() *SessionDescription {
 // AddICECandidate accepts an ICE candidate string and adds it
 // to the existing set of candidates.
 func (pc *PeerConnection) AddICECandidate(candidate ICECandidateInit) error {
-	remoteDesc := pc.RemoteDescription()
+	remoteDesc := pc.remoteDescription()
 	if remoteDesc == nil {
 		return &rtcerr.InvalidStateError{Err: ErrNoRemoteDescription}
 	}
@@ -2093,36 +2899,83 @@ func (pc *PeerConnection) AddICECandidate(candidate ICECandidateInit) error {
 		return err
 	}
 
+	media, sdpMid, sdpMLineIndex := remoteMediaForCandidate(remoteDesc.parsed, candidate)
+
 	// Reject candidates from old generations.
 	// If candidate.usernameFragment is not null,
 	// and is not equal to any username fragment present in the corresponding media
 	//  description of an applied remote description,
 	// return a promise rejected with a newly created OperationError.
 	// https://w3c.github.io/webrtc-pc/#dom-peerconnection-addicecandidate
-	if ufrag, ok := cand.GetExtension("ufrag"); ok {
-		if !pc.descriptionContainsUfrag(remoteDesc.parsed, ufrag.Value) {
-			pc.log.Errorf("dropping candidate with ufrag %s because it doesn't match the current ufrags", ufrag.Value)
+	// They are ignored instead, as trickled candidates of the previous generation
+	// are expected to arrive after an ICE restart.
+	usernameFragment := ""
+	if candidate.UsernameFragment != nil {
+		usernameFragment = *candidate.UsernameFragment
+	} else if ufrag, ok := cand.GetExtension("ufrag"); ok {
+		usernameFragment = ufrag.Value
+	}
+	if usernameFragment != "" && !descriptionContainsUfrag(remoteDesc.parsed, media, usernameFragment) {
+		pc.log.Errorf("dropping candidate with ufrag %s because it doesn't match the current ufrags", usernameFragment)
 
-			return nil
-		}
+		return nil
 	}
 
-	c, err := newICECandidateFromICE(cand, "", 0)
+	c, err := newICECandidateFromICE(cand, sdpMid, sdpMLineIndex)
 	if err != nil {
 		return err
 	}
+	c.usernameFragment = usernameFragment
 
 	return pc.iceTransport.AddRemoteCandidate(&c)
 }
 
-// Return true if the sdp contains a specific ufrag.
-func (pc *PeerConnection) descriptionContainsUfrag(sdp *sdp.SessionDescription, matchUfrag string) bool {
-	ufrag, ok := sdp.Attribute("ice-ufrag")
+// remoteMediaForCandidate returns the media description of the remote description
+// a candidate belongs to, matched by SDPMid. SDPMLineIndex is only used when the candidate
+// has no SDPMid, as the remote may reorder its media sections between offers. The returned
+// sdpMLineIndex is the position of the media description in desc. media is nil if the
+// candidate doesn't identify one.
+func remoteMediaForCandidate(
+	desc *sdp.SessionDescription,
+	candidate ICECandidateInit,
+) (media *sdp.MediaDescription, sdpMid string, sdpMLineIndex uint16) {
+	if candidate.SDPMid != nil && *candidate.SDPMid != "" {
+		for i, m := range desc.MediaDescriptions {
+			if getMidValue(m) == *candidate.SDPMid {
+				return m, *candidate.SDPMid, uint16(i) //nolint:gosec // G115
+			}
+		}
+
+		return nil, "", 0
+	}
+
+	if candidate.SDPMLineIndex != nil && int(*candidate.SDPMLineIndex) < len(desc.MediaDescriptions) {
+		m := desc.MediaDescriptions[*candidate.SDPMLineIndex]
+
+		return m, getMidValue(m), *candidate.SDPMLineIndex
+	}
+
+	return nil, "", 0
+}
+
+// Return true if the sdp contains a specific ufrag. If media is set only its
+// ufrag is considered, or the session ufrag if it has none.
+func descriptionContainsUfrag(desc *sdp.SessionDescription, media *sdp.MediaDescription, matchUfrag string) bool {
+	if media != nil {
+		if ufrag, ok := media.Attribute("ice-ufrag"); ok {
+			return ufrag == matchUfrag
+		}
+	}
+
+	ufrag, ok := desc.Attribute("ice-ufrag")
 	if ok && ufrag == matchUfrag {
 		return true
 	}
+	if media != nil {
+		return false
+	}
 
-	for _, media := range sdp.MediaDescriptions {
+	for _, media := range desc.MediaDescriptions {
 		ufrag, ok := media.Attribute("ice-ufrag")
 		if ok && ufrag == matchUfrag {
 			return true
@@ -2171,6 +3024,8 @@ func (pc *PeerConnection) GetReceivers() (receivers []*RTPReceiver) {
 }
 
 // GetTransceivers returns the RtpTransceiver that are currently attached to this PeerConnection.
+// They are in the order of the media sections of the current local description, followed by
+// the ones that weren't negotiated yet in the order they were added.
 func (pc *PeerConnection) GetTransceivers() []*RTPTransceiver {
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
@@ -2178,8 +3033,70 @@ func (pc *PeerConnection) GetTransceivers() []*RTPTransceiver {
 	return pc.rtpTransceivers
 }
 
+// GetTransceiverByMid returns the RTPTransceiver of the media section with the given mid,
+// nil if there is none. The mid of a RTPTransceiver is set by CreateOffer, or when it is
+// created by a remote offer.
+func (pc *PeerConnection) GetTransceiverByMid(mid string) *RTPTransceiver {
+	if mid == "" {
+		return nil
+	}
+
+	for _, transceiver := range pc.GetTransceivers() {
+		if transceiver.Mid() == mid {
+			return transceiver
+		}
+	}
+
+	return nil
+}
+
+// GetRemoteCapabilities returns what was negotiated with the remote for every media section, keyed
+// by mid: the codecs with their payload types, the header extensions with their IDs and the RTCP
+// settings of the last applied answer. Media sections that weren't answered yet are left out.
+func (pc *PeerConnection) GetRemoteCapabilities() map[string]RTPParameters {
+	capabilities := map[string]RTPParameters{}
+	for _, transceiver := range pc.GetTransceivers() {
+		if parameters := transceiver.getNegotiatedParameters(); parameters != nil && transceiver.Mid() != "" {
+			capabilities[transceiver.Mid()] = *parameters
+		}
+	}
+
+	return capabilities
+}
+
+// usesCodec reports if a sender or receiver of kind typ uses a codec of mimeType,
+// the MediaEngine of the PeerConnection can't unregister it then.
+func (pc *PeerConnection) usesCodec(mimeType string, typ RTPCodecType) bool {
+	for _, transceiver := range pc.GetTransceivers() {
+		if transceiver.Kind() != typ {
+			continue
+		}
+
+		if sender := transceiver.Sender(); sender != nil && sender.usesCodec(mimeType) {
+			return true
+		}
+		if receiver := transceiver.Receiver(); receiver != nil && receiver.usesCodec(mimeType) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// codecMediaEngine returns the MediaEngine the PeerConnection negotiates with.
+func (pc *PeerConnection) codecMediaEngine() *MediaEngine {
+	return pc.api.mediaEngine
+}
+
 // AddTrack adds a Track to the PeerConnection.
 //
+// The track is attached to an existing transceiver of the same kind when one can send
+// and has no sender yet, otherwise a new sendrecv transceiver is created. While answering,
+// after SetRemoteDescription(offer), the transceivers associated to a media section of the
+// offer are preferred: the track is then sent from the answer on. A new transceiver can't
+// be part of the answer, it is negotiated by the next offer and OnNegotiationNeeded fires
+// once the signaling state returned to stable.
+//
 //nolint:cyclop
 func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
 	if pc.isClosed.Load() {
@@ -2188,11 +3105,20 @@ func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
 
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
-	for _, transceiver := range pc.rtpTransceivers {
-		if !transceiver.isSendAllowed(track.Kind()) {
-			continue
-		}
+	sender, _, err := pc.addTrack(track)
+
+	return sender, err
+}
 
+// addTrack adds a Track and returns a function that reverts it;
+// caller of this method should hold `pc.mu` lock.
+func (pc *PeerConnection) addTrack(track TrackLocal) (*RTPSender, func(), error) {
+	if err := pc.validateTrackCodec(track); err != nil {
+		return nil, nil, err
+	}
+
+	if transceiver := pc.reusableTransceiver(track.Kind()); transceiver != nil {
+		direction := transceiver.Direction()
 		sender, err := pc.api.NewRTPSender(track, pc.dtlsTransport)
 		if err == nil {
 			err = transceiver.SetSender(sender, track)
@@ -2202,20 +3128,53 @@ func (pc *PeerConnection) AddTrack(track TrackLocal) (*RTPSender, error) {
 			}
 		}
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		pc.onNegotiationNeeded()
 
-		return sender, nil
+		return sender, func() {
+			_ = sender.Stop()
+			transceiver.setSender(nil)
+			transceiver.setDirection(direction)
+		}, nil
 	}
 
 	transceiver, err := pc.newTransceiverFromTrack(RTPTransceiverDirectionSendrecv, track)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	pc.addRTPTransceiver(transceiver)
 
-	return transceiver.Sender(), nil
+	return transceiver.Sender(), func() { pc.removeRTPTransceiver(transceiver) }, nil
+}
+
+// reusableTransceiver returns the transceiver addTrack attaches a track of kind to, or nil if
+// none can be reused. In have-remote-offer, the transceivers associated to a media section of
+// the offer come first; caller of this method should hold `pc.mu` lock.
+func (pc *PeerConnection) reusableTransceiver(kind RTPCodecType) *RTPTransceiver {
+	var offer *SessionDescription
+	if pc.SignalingState() == SignalingStateHaveRemoteOffer && pc.pendingRemoteDescription != nil &&
+		pc.pendingRemoteDescription.parsed != nil {
+		offer = pc.pendingRemoteDescription
+	}
+
+	var reusable *RTPTransceiver
+	for _, transceiver := range pc.rtpTransceivers {
+		if !transceiver.isSendAllowed(kind) {
+			continue
+		}
+		if offer == nil {
+			return transceiver
+		}
+		if mid := transceiver.Mid(); mid != "" && getByMid(mid, offer) != nil {
+			return transceiver
+		}
+		if reusable == nil {
+			reusable = transceiver
+		}
+	}
+
+	return reusable
 }
 
 // RemoveTrack removes a Track from the PeerConnection.
@@ -2224,16 +3183,9 @@ func (pc *PeerConnection) RemoveTrack(sender *RTPSender) (err error) {
 		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
-	var transceiver *RTPTransceiver
 	pc.mu.Lock()
 	defer pc.mu.Unlock()
-	for _, t := range pc.rtpTransceivers {
-		if t.Sender() == sender {
-			transceiver = t
-
-			break
-		}
-	}
+	transceiver := pc.transceiverForSender(sender)
 	if transceiver == nil {
 		return &rtcerr.InvalidAccessError{Err: ErrSenderNotCreatedByConnection}
 	} else if err = sender.Stop(); err == nil {
@@ -2246,6 +3198,18 @@ func (pc *PeerConnection) RemoveTrack(sender *RTPSender) (err error) {
 	return
 }
 
+// transceiverForSender returns the RTPTransceiver of sender, or nil if it
+// wasn't created by this PeerConnection; caller of this method should hold `pc.mu` lock.
+func (pc *PeerConnection) transceiverForSender(sender *RTPSender) *RTPTransceiver {
+	for _, t := range pc.rtpTransceivers {
+		if t.Sender() == sender {
+			return t
+		}
+	}
+
+	return nil
+}
+
 //nolint:cyclop
 func (pc *PeerConnection) newTransceiverFromTrack(
 	direction RTPTransceiverDirection,
@@ -2272,18 +3236,96 @@ func (pc *PeerConnection) newTransceiverFromTrack(
 		return t, err
 	}
 
-	// Allow RTPTransceiverInit to override SSRC
-	if sender != nil && len(sender.trackEncodings) == 1 &&
-		len(init) == 1 && len(init[0].SendEncodings) == 1 && init[0].SendEncodings[0].SSRC != 0 {
-		sender.trackEncodings[0].ssrc = init[0].SendEncodings[0].SSRC
+	if sender != nil && len(init) == 1 {
+		if err = pc.provisionSendEncodings(sender, track, init[0].SendEncodings); err != nil {
+			return t, err
+		}
 	}
 
 	return newRTPTransceiver(receiver, sender, direction, track.Kind(), pc.api), nil
 }
 
+// provisionSendEncodings applies the SendEncodings of a RTPTransceiverInit to the encoding of
+// track, the only one of sender. A single encoding without RID only overrides the SSRCs.
+// Otherwise every encoding has its unique RID, and gets its SSRCs right away so they are
+// offered: track is sent with the encoding of its RID, or the first one if it has no RID,
+// the others are sent with placeholder tracks until RTPSender.ReplaceTrackForRID replaces them.
+func (pc *PeerConnection) provisionSendEncodings(
+	sender *RTPSender, track TrackLocal, encodings []RTPEncodingParameters,
+) error {
+	if len(encodings) == 0 {
+		return nil
+	}
+
+	trackIndex := 0
+	if len(encodings) > 1 || encodings[0].RID != "" {
+		trackIndex = -1
+		rids := map[string]bool{}
+		for i, encoding := range encodings {
+			switch {
+			case encoding.RID == "":
+				return errRTPSenderRidNil
+			case rids[encoding.RID]:
+				return errRTPSenderRIDCollision
+			case encoding.RID == track.RID() || (track.RID() == "" && i == 0):
+				trackIndex = i
+			}
+			rids[encoding.RID] = true
+		}
+		if trackIndex == -1 {
+			return fmt.Errorf("%w: %s", errRTPSenderNoTrackForRID, track.RID())
+		}
+	}
+
+	capability, err := pc.placeholderCodec(track)
+	if err != nil {
+		return err
+	}
+
+	trackEncoding := sender.trackEncodings[0]
+	sender.trackEncodings = nil
+	for i, encoding := range encodings {
+		if i == trackIndex {
+			trackEncoding.rid = encoding.RID
+			sender.trackEncodings = append(sender.trackEncodings, trackEncoding)
+		} else {
+			placeholder, err := NewTrackLocalStaticSample(
+				capability, track.ID(), track.StreamID(), WithRTPStreamID(encoding.RID),
+			)
+			if err != nil {
+				return err
+			}
+			sender.addEncoding(placeholder, encoding.RID)
+		}
+
+		// Allow RTPTransceiverInit to override SSRCs
+		if encoding.SSRC != 0 {
+			sender.trackEncodings[i].ssrc = encoding.SSRC
+		}
+		if encoding.RTX.SSRC != 0 && sender.trackEncodings[i].ssrcRTX != 0 {
+			sender.trackEncodings[i].ssrcRTX = encoding.RTX.SSRC
+		}
+	}
+
+	return nil
+}
+
+// placeholderCodec returns the codec of the placeholder tracks sent for the encodings of the
+// transceiver of track, see provisionSendEncodings.
+func (pc *PeerConnection) placeholderCodec(track TrackLocal) (RTPCodecCapability, error) {
+	if codecTrack, ok := track.(interface{ Codec() RTPCodecCapability }); ok {
+		return codecTrack.Codec(), nil
+	}
+
+	codecs := pc.api.mediaEngine.getCodecsByKind(track.Kind())
+	if len(codecs) == 0 {
+		return RTPCodecCapability{}, ErrNoCodecsAvailable
+	}
+
+	return codecs[0].RTPCodecCapability, nil
+}
+
 // AddTransceiverFromKind Create a new RtpTransceiver and adds it to the set of transceivers.
-//
-//nolint:cyclop
 func (pc *PeerConnection) AddTransceiverFromKind(
 	kind RTPCodecType,
 	init ...RTPTransceiverInit,
@@ -2292,6 +3334,22 @@ func (pc *PeerConnection) AddTransceiverFromKind(
 		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
 	}
 
+	t, err = pc.newTransceiverFromKind(kind, init...)
+	if err != nil {
+		return nil, err
+	}
+	pc.mu.Lock()
+	pc.addRTPTransceiver(t)
+	pc.mu.Unlock()
+
+	return t, nil
+}
+
+//nolint:cyclop
+func (pc *PeerConnection) newTransceiverFromKind(
+	kind RTPCodecType,
+	init ...RTPTransceiverInit,
+) (t *RTPTransceiver, err error) {
 	direction := RTPTransceiverDirectionSendrecv
 	if len(init) > 1 {
 		return nil, errPeerConnAddTransceiverFromKindOnlyAcceptsOne
@@ -2321,9 +3379,6 @@ func (pc *PeerConnection) AddTransceiverFromKind(
 	default:
 		return nil, errPeerConnAddTransceiverFromKindSupport
 	}
-	pc.mu.Lock()
-	pc.addRTPTransceiver(t)
-	pc.mu.Unlock()
 
 	return t, nil
 }
@@ -2344,6 +3399,10 @@ func (pc *PeerConnection) AddTransceiverFromTrack(
 		direction = init[0].Direction
 	}
 
+	if err = pc.validateTrackCodec(track); err != nil {
+		return nil, err
+	}
+
 	t, err = pc.newTransceiverFromTrack(direction, track, init...)
 	if err == nil {
 		pc.mu.Lock()
@@ -2354,6 +3413,105 @@ func (pc *PeerConnection) AddTransceiverFromTrack(
 	return
 }
 
+// EnsureDataChannel returns the DataChannel with the given label that both peers share, creating
+// it if needed. It is a negotiated DataChannel whose ID is derived from the label, so peers that
+// both call EnsureDataChannel with the same label end up with a single channel, without racing
+// to open it with DCEP. The Negotiated and ID fields of options are ignored.
+//
+// The IDs are in the range [512, 1024), two labels can have the same ID. EnsureDataChannel then
+// returns an OperationError wrapping ErrDataChannelIDInUse for the second label, use another label
+// or CreateDataChannel with an explicit ID instead.
+func (pc *PeerConnection) EnsureDataChannel(label string, options *DataChannelInit) (*DataChannel, error) {
+	id := ensuredDataChannelID(label)
+	ensured := func() (*DataChannel, error) {
+		existing := pc.sctpTransport.dataChannelByID(id)
+		if existing == nil {
+			return nil, nil //nolint:nilnil
+		} else if existing.Label() != label {
+			return nil, &rtcerr.OperationError{
+				Err: fmt.Errorf("%w: %d by label %q", ErrDataChannelIDInUse, id, existing.Label()),
+			}
+		}
+
+		return existing, nil
+	}
+
+	if existing, err := ensured(); existing != nil || err != nil {
+		return existing, err
+	}
+
+	init := DataChannelInit{}
+	if options != nil {
+		init = *options
+	}
+	negotiated := true
+	init.Negotiated = &negotiated
+	init.ID = &id
+
+	dataChannel, err := pc.CreateDataChannel(label, &init)
+	if errors.Is(err, ErrDataChannelIDInUse) {
+		// Created concurrently
+		if existing, ensuredErr := ensured(); existing != nil || ensuredErr != nil {
+			return existing, ensuredErr
+		}
+	}
+
+	return dataChannel, err
+}
+
+// ensuredDataChannelID derives the ID of the DataChannel of EnsureDataChannel from its label.
+func ensuredDataChannelID(label string) uint16 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(label))
+
+	return ensuredDataChannelIDBase + uint16(hash.Sum32()%uint32(ensuredDataChannelIDCount)) //nolint:gosec // G115
+}
+
+// validateTrackCodec checks that the codec of a track with a pre-set codec has the clock
+// rate and channel count of the registered codecs with its MimeType.
+func (pc *PeerConnection) validateTrackCodec(track TrackLocal) error {
+	codecTrack, ok := track.(interface {
+		Codec() RTPCodecCapability
+		codecMismatchAllowed() bool
+	})
+	if !ok || codecTrack.codecMismatchAllowed() {
+		return nil
+	}
+
+	return codecCapabilityMismatch(codecTrack.Codec(), pc.api.mediaEngine.getCodecsByKind(track.Kind()))
+}
+
+// validateSSRCs checks that the SSRCs of the senders are unique, and that the remote doesn't
+// send streams with them in remote, if it isn't nil; caller of this method should hold `pc.mu` lock.
+func (pc *PeerConnection) validateSSRCs(remote *SessionDescription) error {
+	remoteSSRCs := map[SSRC]struct{}{}
+	if remote != nil && remote.parsed != nil {
+		for _, ssrc := range ssrcsFromSDP(remote.parsed) {
+			remoteSSRCs[ssrc] = struct{}{}
+		}
+	}
+
+	localSSRCs := map[SSRC]struct{}{}
+	for _, transceiver := range pc.rtpTransceivers {
+		sender := transceiver.Sender()
+		if sender == nil || sender.hasStopped() {
+			continue
+		}
+
+		for _, ssrc := range sender.ssrcs() {
+			if _, ok := localSSRCs[ssrc]; ok {
+				return fmt.Errorf("%w: %d is used by several senders", ErrSSRCConflict, ssrc)
+			}
+			if _, ok := remoteSSRCs[ssrc]; ok {
+				return fmt.Errorf("%w: %d is used by a sender and by the remote", ErrSSRCConflict, ssrc)
+			}
+			localSSRCs[ssrc] = struct{}{}
+		}
+	}
+
+	return nil
+}
+
 // CreateDataChannel creates a new DataChannel object with the given label
 // and optional DataChannelInit used to configure properties of the
 // underlying channel such as data reliability.
@@ -2419,13 +3577,9 @@ func (pc *PeerConnection) CreateDataChannel(label string, options *DataChannelIn
 		return nil, &rtcerr.TypeError{Err: ErrRetransmitsOrPacketLifeTime}
 	}
 
-	pc.sctpTransport.lock.Lock()
-	pc.sctpTransport.dataChannels = append(pc.sctpTransport.dataChannels, dataChannel)
-	if dataChannel.ID() != nil {
-		pc.sctpTransport.dataChannelIDsUsed[*dataChannel.ID()] = struct{}{}
+	if err = pc.sctpTransport.addDataChannel(dataChannel); err != nil {
+		return nil, err
 	}
-	pc.sctpTransport.dataChannelsRequested++
-	pc.sctpTransport.lock.Unlock()
 
 	// If SCTP already connected open all the channels
 	if pc.sctpTransport.State() == SCTPTransportStateConnected {
@@ -2448,16 +3602,47 @@ func (pc *PeerConnection) SetIdentityProvider(string) error {
 
 // WriteRTCP sends a user provided RTCP packet to the connected peer. If no peer is connected the
 // packet is discarded. It also runs any configured interceptors.
+//
+// The packets are written as they are to the DTLSTransport of the PeerConnection, which carries
+// all media as Pion always bundles. Use RTPReceiver.WriteRTCP or RTPSender.WriteRTCP to have the
+// SSRCs of the receiver or sender filled in.
 func (pc *PeerConnection) WriteRTCP(pkts []rtcp.Packet) error {
-	_, err := pc.interceptorRTCPWriter.Write(pkts, make(interceptor.Attributes))
+	_, err := pc.interceptorRTCPWriter.Write(pkts, interceptor.Attributes{immediateRTCPKey{}: true})
 
 	return err
 }
 
 func (pc *PeerConnection) writeRTCP(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+	if pc.api.settingEngine.compatibilityProfile.RecvonlySSRC {
+		pkts = withRecvonlySenderSSRC(pkts, pc.recvonlySenderSSRC)
+	}
+
 	return pc.dtlsTransport.WriteRTCP(pkts)
 }
 
+// recvonlySenderSSRC returns the SSRC announced by the recvonly media section receiving
+// mediaSSRC, see CompatibilityProfile.RecvonlySSRC.
+func (pc *PeerConnection) recvonlySenderSSRC(mediaSSRC uint32) (uint32, bool) {
+	for _, transceiver := range pc.GetTransceivers() {
+		if ssrc, ok := transceiver.recvonlySenderSSRC(SSRC(mediaSSRC)); ok {
+			return uint32(ssrc), true
+		}
+	}
+
+	return 0, false
+}
+
+// localCNAME returns the CNAME announced for the local ssrc, empty if it isn't announced.
+func (pc *PeerConnection) localCNAME(ssrc uint32) string {
+	for _, transceiver := range pc.GetTransceivers() {
+		if cname := transceiver.localCNAME(SSRC(ssrc)); cname != "" {
+			return cname
+		}
+	}
+
+	return ""
+}
+
 // Close ends the PeerConnection.
 func (pc *PeerConnection) Close() error {
 	return pc.close(false /* shouldGracefullyClose */)
@@ -2507,6 +3692,7 @@ func (pc *PeerConnection) close(shouldGracefullyClose bool) error { //nolint:cyc
 		<-pc.isCloseDone
 	} else {
 		defer close(pc.isCloseDone)
+		pc.resolvePendingDescriptions()
 	}
 
 	if shouldGracefullyClose {
@@ -2588,6 +3774,7 @@ func (pc *PeerConnection) close(shouldGracefullyClose bool) error { //nolint:cyc
 
 	pc.statsGetter = nil
 	cleanupStats(pc.id)
+	pc.api.mediaEngine.removeUser(pc)
 
 	// Interceptor closes at the end to prevent Bind from being called after interceptor is closed
 	closeErrs = append(closeErrs, pc.api.interceptor.Close())
@@ -2603,6 +3790,46 @@ func (pc *PeerConnection) addRTPTransceiver(t *RTPTransceiver) {
 	pc.onNegotiationNeeded()
 }
 
+// sortRTPTransceivers orders rtpTransceivers by the media sections of the current local
+// description, the ones that aren't in it keep the order they were added in after them;
+// caller of this method should hold `pc.mu` lock.
+func (pc *PeerConnection) sortRTPTransceivers() {
+	if pc.currentLocalDescription == nil || pc.currentLocalDescription.parsed == nil {
+		return
+	}
+
+	mediaSections := pc.currentLocalDescription.parsed.MediaDescriptions
+	mediaSectionIndexes := make(map[string]int, len(mediaSections))
+	for i, media := range mediaSections {
+		if mid := getMidValue(media); mid != "" {
+			mediaSectionIndexes[mid] = i
+		}
+	}
+	mediaSectionIndex := func(t *RTPTransceiver) int {
+		if i, ok := mediaSectionIndexes[t.Mid()]; ok {
+			return i
+		}
+
+		return len(mediaSections)
+	}
+
+	// Sort a copy, the slice returned by GetTransceivers may still be in use
+	transceivers := slices.Clone(pc.rtpTransceivers)
+	slices.SortStableFunc(transceivers, func(a, b *RTPTransceiver) int {
+		return cmp.Compare(mediaSectionIndex(a), mediaSectionIndex(b))
+	})
+	pc.rtpTransceivers = transceivers
+}
+
+// removeRTPTransceiver stops t and removes it from rtpTransceivers, it reverts
+// addRTPTransceiver before t was negotiated; caller of this method should hold `pc.mu` lock.
+func (pc *PeerConnection) removeRTPTransceiver(t *RTPTransceiver) {
+	pc.rtpTransceivers = slices.DeleteFunc(pc.rtpTransceivers, func(transceiver *RTPTransceiver) bool {
+		return transceiver == t
+	})
+	_ = t.Stop()
+}
+
 // CurrentLocalDescription represents the local description that was
 // successfully negotiated the last time the PeerConnection transitioned
 // into the stable state plus any local candidates that have been generated
@@ -2641,7 +3868,7 @@ func (pc *PeerConnection) CurrentRemoteDescription() *SessionDescription {
 	pc.mu.RLock()
 	defer pc.mu.RUnlock()
 
-	return pc.currentRemoteDescription
+	return pc.currentRemoteDescription.clone()
 }
 
 // PendingRemoteDescription represents a remote description that is in the
@@ -2653,7 +3880,7 @@ func (pc *PeerConnection) PendingRemoteDescription() *SessionDescription {
 	pc.mu.RLock()
 	defer pc.mu.RUnlock()
 
-	return pc.pendingRemoteDescription
+	return pc.pendingRemoteDescription.clone()
 }
 
 // CanTrickleICECandidates reports whether the remote endpoint indicated
@@ -2688,6 +3915,27 @@ func (pc *PeerConnection) ICEGatheringState() ICEGatheringState {
 	}
 }
 
+// ICEGatheringProgress returns a snapshot of the gathering of local candidates, with
+// the progress of every ICE server, see ICEGatherer.GatheringProgress.
+func (pc *PeerConnection) ICEGatheringProgress() ICEGatheringProgress {
+	return pc.iceGatherer.GatheringProgress()
+}
+
+// GetLocalCandidates returns the local ICE candidates gathered so far, as they are
+// or will be emitted by OnICECandidate. After an ICE restart only the candidates of
+// the current generation are returned.
+func (pc *PeerConnection) GetLocalCandidates() ([]ICECandidate, error) {
+	if pc.isClosed.Load() {
+		return nil, &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	if pc.ICEGatheringState() == ICEGatheringStateNew {
+		return []ICECandidate{}, nil
+	}
+
+	return pc.iceGatherer.GetLocalCandidates()
+}
+
 // ConnectionState attribute returns the connection state of the
 // PeerConnection instance.
 func (pc *PeerConnection) ConnectionState() PeerConnectionState {
@@ -2714,7 +3962,7 @@ func (pc *PeerConnection) GetStats() StatsReport {
 		pc.iceGatherer.collectStats(statsCollector)
 	}
 	if pc.iceTransport != nil {
-		pc.iceTransport.collectStats(statsCollector)
+		pc.iceTransport.collectStats(statsCollector, pc.dtlsTransport)
 	}
 
 	pc.sctpTransport.lock.Lock()
@@ -2759,6 +4007,10 @@ func (pc *PeerConnection) GetStats() StatsReport {
 		receiver.collectStats(statsCollector, pc.statsGetter)
 	}
 
+	for _, sender := range pc.GetSenders() {
+		sender.collectStats(statsCollector, pc.statsGetter)
+	}
+
 	pc.api.mediaEngine.collectStats(statsCollector)
 
 	return statsCollector.Ready()
@@ -2818,6 +4070,12 @@ func (pc *PeerConnection) startRTP(
 	remoteDesc *SessionDescription,
 	currentTransceivers []*RTPTransceiver,
 ) {
+	select {
+	case <-pc.transportsStarted:
+	case <-pc.isCloseDone:
+		return
+	}
+
 	if !isRenegotiation {
 		pc.undeclaredMediaProcessor()
 	}
@@ -2835,7 +4093,7 @@ func (pc *PeerConnection) startRTP(
 //nolint:cyclop
 func (pc *PeerConnection) generateUnmatchedSDP(
 	transceivers []*RTPTransceiver,
-	useIdentity bool,
+	useIdentity, disableVoiceActivityDetection bool,
 ) (*sdp.SessionDescription, error) {
 	desc, err := sdp.NewJSEPSessionDescription(useIdentity)
 	if err != nil {
@@ -2900,13 +4158,17 @@ func (pc *PeerConnection) generateUnmatchedSDP(
 
 		if pc.configuration.AlwaysNegotiateDataChannels || pc.sctpTransport.dataChannelsRequested != 0 {
 			mediaSections = append(mediaSections, mediaSection{
-				id:       strconv.Itoa(len(mediaSections)),
+				id:       newApplicationMid(mediaSections),
 				data:     true,
 				sctpInit: localSctpInit,
 			})
 		}
 	}
 
+	for i := range mediaSections {
+		mediaSections[i].disableVoiceActivityDetection = disableVoiceActivityDetection
+	}
+
 	dtlsFingerprints, err := pc.configuration.Certificates[0].GetFingerprints()
 	if err != nil {
 		return nil, err
@@ -2928,6 +4190,7 @@ func (pc *PeerConnection) generateUnmatchedSDP(
 		nil,
 		pc.api.settingEngine.getSCTPMaxMessageSize(),
 		false,
+		pc.api.settingEngine.compatibilityProfile,
 	)
 }
 
@@ -2939,7 +4202,7 @@ func (pc *PeerConnection) generateMatchedSDP(
 	transceivers []*RTPTransceiver,
 	useIdentity, includeUnmatched bool,
 	connectionRole sdp.ConnectionRole,
-	ignoreRidPauseForRecv bool,
+	ignoreRidPauseForRecv, disableVoiceActivityDetection bool,
 ) (*sdp.SessionDescription, error) {
 	desc, err := sdp.NewJSEPSessionDescription(useIdentity)
 	if err != nil {
@@ -2975,6 +4238,22 @@ func (pc *PeerConnection) generateMatchedSDP(
 	var localSctpInit []byte
 	for _, media := range remoteDescription.parsed.MediaDescriptions {
 		midValue := getMidValue(media)
+		if media.MediaName.Media != mediaSectionApplication && !detectedPlanB {
+			// A rejected media section stays as a placeholder, so does the one of a stopped transceiver
+			rejected := isRejectedMediaSection(media)
+			if idx := slices.IndexFunc(localTransceivers, func(t *RTPTransceiver) bool {
+				return midValue != "" && t.Mid() == midValue
+			}); idx != -1 && (rejected || localTransceivers[idx].stopped.Load()) {
+				rejected = true
+				localTransceivers = slices.Delete(localTransceivers, idx, idx+1)
+			}
+			if rejected {
+				mediaSections = append(mediaSections, mediaSection{rejected: true, media: media.MediaName.Media})
+
+				continue
+			}
+		}
+
 		if midValue == "" {
 			return nil, errPeerConnRemoteDescriptionWithoutMidValue
 		}
@@ -2999,6 +4278,10 @@ func (pc *PeerConnection) generateMatchedSDP(
 			continue
 		}
 
+		// When answering reduced-size RTCP is only accepted if it was offered
+		_, offeredReducedSize := media.Attribute(sdp.AttrKeyRTCPRsize)
+		rtcpReducedSizeRejected := !includeUnmatched && !offeredReducedSize
+
 		sdpSemantics := pc.configuration.SDPSemantics
 
 		switch {
@@ -3028,7 +4311,11 @@ func (pc *PeerConnection) generateMatchedSDP(
 				}
 				mediaTransceivers = append(mediaTransceivers, transceiver)
 			}
-			mediaSections = append(mediaSections, mediaSection{id: midValue, transceivers: mediaTransceivers})
+			mediaSections = append(mediaSections, mediaSection{
+				id:                      midValue,
+				transceivers:            mediaTransceivers,
+				rtcpReducedSizeRejected: rtcpReducedSizeRejected,
+			})
 		case sdpSemantics == SDPSemanticsUnifiedPlan || sdpSemantics == SDPSemanticsUnifiedPlanWithFallback:
 			if detectedPlanB {
 				return nil, &rtcerr.TypeError{
@@ -3048,10 +4335,26 @@ func (pc *PeerConnection) generateMatchedSDP(
 			mediaTransceivers := []*RTPTransceiver{transceiver}
 
 			extensions, _ := rtpExtensionsFromMediaDescription(media)
-			mediaSections = append(
-				mediaSections,
-				mediaSection{id: midValue, transceivers: mediaTransceivers, matchExtensions: extensions, rids: getRids(media)},
-			)
+			// Remote codecs are only needed to echo the H264 profile, don't parse them otherwise
+			var remoteCodecs []RTPCodecParameters
+			if pc.api.settingEngine.compatibilityProfile.EchoH264ProfileLevelID {
+				remoteCodecs, _ = codecsFromMediaDescription(media)
+			}
+			// When answering the remote description is the offer
+			offeredDirection := RTPTransceiverDirectionUnknown
+			if !includeUnmatched {
+				offeredDirection = direction
+			}
+			mediaSections = append(mediaSections, mediaSection{
+				id:               midValue,
+				transceivers:     mediaTransceivers,
+				matchExtensions:  extensions,
+				rids:             getRids(media),
+				remoteCodecs:     remoteCodecs,
+				offeredDirection: offeredDirection,
+
+				rtcpReducedSizeRejected: rtcpReducedSizeRejected,
+			})
 		}
 	}
 
@@ -3076,7 +4379,7 @@ func (pc *PeerConnection) generateMatchedSDP(
 				mediaSections = append(mediaSections, mediaSection{id: "data", data: true})
 			} else {
 				mediaSections = append(mediaSections, mediaSection{
-					id:       strconv.Itoa(len(mediaSections)),
+					id:       newApplicationMid(mediaSections),
 					data:     true,
 					sctpInit: localSctpInit,
 				})
@@ -3092,6 +4395,10 @@ func (pc *PeerConnection) generateMatchedSDP(
 		pc.log.Info("Plan-B Offer detected; responding with Plan-B Answer")
 	}
 
+	for i := range mediaSections {
+		mediaSections[i].disableVoiceActivityDetection = disableVoiceActivityDetection
+	}
+
 	dtlsFingerprints, err := pc.configuration.Certificates[0].GetFingerprints()
 	if err != nil {
 		return nil, err
@@ -3113,6 +4420,7 @@ func (pc *PeerConnection) generateMatchedSDP(
 		bundleGroup,
 		pc.api.settingEngine.getSCTPMaxMessageSize(),
 		ignoreRidPauseForRecv,
+		pc.api.settingEngine.compatibilityProfile,
 	)
 }
 
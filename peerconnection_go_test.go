@@ -124,7 +124,7 @@ func TestNew_Go(t *testing.T) {
 						},
 					},
 				})
-			}, &rtcerr.InvalidAccessError{Err: ErrNoTurnCredentials}},
+			}, iceServerURLError(1, "turns:google.de?transport=tcp", ErrNoTurnCredentials)},
 		}
 
 		for i, testCase := range testCases {
@@ -282,7 +282,7 @@ func TestPeerConnection_SetConfiguration_Go(t *testing.T) {
 					},
 				},
 			},
-			wantErr: &rtcerr.InvalidAccessError{Err: ErrNoTurnCredentials},
+			wantErr: iceServerURLError(1, "turns:google.de?transport=tcp", ErrNoTurnCredentials),
 		},
 	} {
 		pc, err := test.init()
@@ -307,6 +307,99 @@ func TestPeerConnection_GetConfiguration_Go(t *testing.T) {
 	assert.NoError(t, pc.Close())
 }
 
+func TestPeerConnection_AddICEServers(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{
+		ICEServers: []ICEServer{{URLs: []string{"stun:stun.l.google.com:19302"}}},
+	})
+	assert.NoError(t, err)
+
+	assert.NoError(t, pc.AddICEServers([]ICEServer{{URLs: []string{"stun:stun1.l.google.com:19302"}}}))
+	assert.Len(t, pc.GetConfiguration().ICEServers, 2)
+	assert.Equal(t, 2, pc.iceGatherer.validatedServersCount())
+
+	assert.ErrorIs(t, pc.AddICEServers([]ICEServer{{URLs: []string{"turn:127.0.0.1"}}}), ErrNoTurnCredentials)
+	assert.Len(t, pc.GetConfiguration().ICEServers, 2)
+
+	// Once gathering started the next offer restarts ICE
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	gatherComplete := GatheringCompletePromise(pc)
+	assert.NoError(t, pc.SetLocalDescription(offer))
+	<-gatherComplete
+	params, err := pc.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+	assert.NoError(t, pc.AddICEServers([]ICEServer{{URLs: []string{"stun:stun2.l.google.com:19302"}}}))
+	assert.Len(t, pc.GetConfiguration().ICEServers, 3)
+	assert.Equal(t, 3, pc.iceGatherer.validatedServersCount())
+	assert.True(t, pc.iceRestartNeeded.Load())
+
+	// The restart is needed until the answer to the offer is applied
+	_, err = pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.True(t, pc.iceRestartNeeded.Load())
+	restartParams, err := pc.iceGatherer.GetLocalParameters()
+	assert.NoError(t, err)
+	assert.NotEqual(t, params.UsernameFragment, restartParams.UsernameFragment)
+
+	assert.NoError(t, pc.Close())
+
+	var invalidStateErr *rtcerr.InvalidStateError
+	assert.ErrorAs(t, pc.AddICEServers([]ICEServer{{URLs: []string{"stun:stun2.l.google.com:19302"}}}), &invalidStateErr)
+}
+
+func TestPeerConnection_WaitForPendingOperations(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	// OnNegotiationNeeded runs on the operations queue
+	waitErr := make(chan error, 1)
+	pc.OnNegotiationNeeded(func() {
+		waitErr <- pc.WaitForPendingOperations(context.Background())
+	})
+
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+
+	err = <-waitErr
+	var invalidStateErr *rtcerr.InvalidStateError
+	assert.ErrorAs(t, err, &invalidStateErr)
+	assert.ErrorIs(t, err, ErrWaitInOperation)
+
+	assert.NoError(t, pc.WaitForPendingOperations(context.Background()))
+
+	assert.NoError(t, pc.Close())
+	assert.NoError(t, pc.WaitForPendingOperations(context.Background()))
+}
+
+func TestPeerConnection_WaitForPendingOperations_OtherGoroutine(t *testing.T) {
+	pc, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	handlerRunning, releaseHandler := make(chan struct{}), make(chan struct{})
+	pc.OnNegotiationNeeded(func() {
+		close(handlerRunning)
+		<-releaseHandler
+	})
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+	<-handlerRunning
+
+	// Only the handler itself can't wait, other goroutines wait for it to return
+	waitErr := make(chan error, 1)
+	go func() {
+		waitErr <- pc.WaitForPendingOperations(context.Background())
+	}()
+	select {
+	case err = <-waitErr:
+		assert.Fail(t, "WaitForPendingOperations returned while the handler runs", "%v", err)
+	case <-time.After(20 * time.Millisecond):
+	}
+	close(releaseHandler)
+	assert.NoError(t, <-waitErr)
+
+	assert.NoError(t, pc.Close())
+}
+
 func TestPeerConnection_EventHandlers_Go(t *testing.T) {
 	lim := test.TimeOut(time.Second * 5)
 	defer lim.Stop()
@@ -1061,6 +1154,74 @@ func TestICERestart_Error_Handling(t *testing.T) {
 	closePairNow(t, offerPeerConnection, answerPeerConnection)
 }
 
+func TestPeerConnection_AddICECandidate_PreviousGeneration(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	candidates, err := pcOffer.iceGatherer.GetLocalCandidates()
+	assert.NoError(t, err)
+	require.NotEmpty(t, candidates)
+	oldGeneration := candidates[0].ToJSON()
+	assert.NotNil(t, oldGeneration.UsernameFragment)
+	assert.Contains(t, pcOffer.LocalDescription().SDP, "a=ice-ufrag:"+*oldGeneration.UsernameFragment)
+
+	restartCandidates := make(chan ICECandidateInit, 16)
+	pcOffer.OnICECandidate(func(c *ICECandidate) {
+		if c != nil {
+			restartCandidates <- c.ToJSON()
+		}
+	})
+	offer, err := pcOffer.CreateOffer(&OfferOptions{ICERestart: true})
+	assert.NoError(t, err)
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+	newGeneration := <-restartCandidates
+	assert.NotNil(t, newGeneration.UsernameFragment)
+	assert.NotEqual(t, *oldGeneration.UsernameFragment, *newGeneration.UsernameFragment)
+
+	// The candidate of the current generation only identifies its media by SDPMid.
+	assert.NoError(t, pcAnswer.AddICECandidate(ICECandidateInit{
+		Candidate:        "candidate:1 1 udp 2130706431 10.99.0.1 5000 typ host",
+		SDPMid:           oldGeneration.SDPMid,
+		SDPMLineIndex:    oldGeneration.SDPMLineIndex,
+		UsernameFragment: oldGeneration.UsernameFragment,
+	}))
+	assert.NoError(t, pcAnswer.AddICECandidate(ICECandidateInit{
+		Candidate:        "candidate:2 1 udp 2130706431 10.99.0.2 5000 typ host",
+		SDPMid:           newGeneration.SDPMid,
+		UsernameFragment: newGeneration.UsernameFragment,
+	}))
+
+	remoteAddresses := func() (addresses []string) {
+		candidates, candidatesErr := pcAnswer.iceGatherer.getAgent().GetRemoteCandidates()
+		assert.NoError(t, candidatesErr)
+		for _, candidate := range candidates {
+			addresses = append(addresses, candidate.Address())
+		}
+
+		return addresses
+	}
+	assert.Eventually(t, func() bool {
+		return slices.Contains(remoteAddresses(), "10.99.0.2")
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NotContains(t, remoteAddresses(), "10.99.0.1")
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 func TestPeerConnection_ICERestart_SetConfiguration_NewServers(t *testing.T) {
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
@@ -1195,6 +1356,183 @@ func TestPeerConnection_ICERestart_SetConfiguration_NewServers(t *testing.T) {
 	closePairNow(t, offerPC, answerPC)
 }
 
+// Assert that a TURN server added once connected over host candidates is gathered from
+// by the ICE restart of the next negotiation, and its relay candidates are checked.
+func TestPeerConnection_AddICEServers_RestartAnswered(t *testing.T) {
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	assert.NoError(t, pcOffer.AddICEServers([]ICEServer{{URLs: []string{"stun:127.0.0.1:3478"}}}))
+	assert.True(t, pcOffer.iceRestartNeeded.Load())
+
+	// An offer that is rolled back doesn't restart ICE for the servers
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.NoError(t, pcOffer.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.True(t, pcOffer.iceRestartNeeded.Load())
+
+	offer, err = pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcOffer.SetLocalDescription(offer))
+	assert.True(t, pcOffer.iceRestartNeeded.Load())
+
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+	assert.False(t, pcOffer.iceRestartNeeded.Load())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_AddICEServers_AfterGathering(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		offerIP  = "1.2.3.4"
+		answerIP = "1.2.3.5"
+		turnIP   = "1.2.3.100"
+		turnPort = 3478
+	)
+
+	loggerFactory := logging.NewDefaultLoggerFactory()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: loggerFactory,
+	})
+	assert.NoError(t, err)
+
+	offerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{offerIP}})
+	assert.NoError(t, err)
+	answerNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{answerIP}})
+	assert.NoError(t, err)
+	turnNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{turnIP}})
+	assert.NoError(t, err)
+
+	assert.NoError(t, wan.AddNet(offerNet))
+	assert.NoError(t, wan.AddNet(answerNet))
+	assert.NoError(t, wan.AddNet(turnNet))
+	assert.NoError(t, wan.Start())
+
+	turnListener, err := turnNet.ListenPacket("udp4", fmt.Sprintf("%s:%d", turnIP, turnPort))
+	assert.NoError(t, err)
+	turnServer, err := turn.NewServer(turn.ServerConfig{
+		Realm:         "pion.ly",
+		LoggerFactory: loggerFactory,
+		AuthHandler: func(u, r string, _ net.Addr) ([]byte, bool) {
+			return turn.GenerateAuthKey(u, r, "password"), true
+		},
+		PacketConnConfigs: []turn.PacketConnConfig{
+			{
+				PacketConn: turnListener,
+				RelayAddressGenerator: &turn.RelayAddressGeneratorStatic{
+					RelayAddress: net.ParseIP(turnIP),
+					Address:      "0.0.0.0",
+					Net:          turnNet,
+				},
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	offerSettingEngine := SettingEngine{}
+	offerSettingEngine.SetNet(offerNet)
+	offerSettingEngine.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+	answerSettingEngine := SettingEngine{}
+	answerSettingEngine.SetNet(answerNet)
+	answerSettingEngine.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+
+	offerPC, err := NewAPI(WithSettingEngine(offerSettingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	answerPC, err := NewAPI(WithSettingEngine(answerSettingEngine)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	var relayCandidates atomic.Int32
+	offerPC.OnICECandidate(func(candidate *ICECandidate) {
+		if candidate != nil && candidate.Typ == ICECandidateTypeRelay {
+			relayCandidates.Add(1)
+		}
+	})
+	connected := make(chan struct{})
+	offerPC.OnICEConnectionStateChange(func(state ICEConnectionState) {
+		if state == ICEConnectionStateConnected {
+			select {
+			case <-connected:
+			default:
+				close(connected)
+			}
+		}
+	})
+
+	_, err = offerPC.CreateDataChannel("test", nil)
+	assert.NoError(t, err)
+
+	negotiate := func() {
+		offer, offerErr := offerPC.CreateOffer(nil)
+		assert.NoError(t, offerErr)
+		offerGatherComplete := GatheringCompletePromise(offerPC)
+		assert.NoError(t, offerPC.SetLocalDescription(offer))
+		<-offerGatherComplete
+
+		assert.NoError(t, answerPC.SetRemoteDescription(*offerPC.LocalDescription()))
+		answer, answerErr := answerPC.CreateAnswer(nil)
+		assert.NoError(t, answerErr)
+		answerGatherComplete := GatheringCompletePromise(answerPC)
+		assert.NoError(t, answerPC.SetLocalDescription(answer))
+		<-answerGatherComplete
+		assert.NoError(t, offerPC.SetRemoteDescription(*answerPC.LocalDescription()))
+	}
+
+	// Connect over the host candidates
+	negotiate()
+	<-connected
+	assert.Zero(t, relayCandidates.Load())
+
+	negotiationNeeded := make(chan struct{}, 1)
+	offerPC.OnNegotiationNeeded(func() {
+		negotiationNeeded <- struct{}{}
+	})
+	assert.NoError(t, offerPC.AddICEServers([]ICEServer{{
+		URLs:       []string{fmt.Sprintf("turn:%s:%d", turnIP, turnPort)},
+		Username:   "user",
+		Credential: "password",
+	}}))
+	<-negotiationNeeded
+
+	negotiate()
+	assert.NotZero(t, relayCandidates.Load())
+	assert.Contains(t, offerPC.LocalDescription().SDP, "typ relay")
+
+	// Connectivity checks of the relay candidates begin
+	assert.Eventually(t, func() bool {
+		report := offerPC.GetStats()
+		for _, s := range report {
+			pair, ok := s.(ICECandidatePairStats)
+			if !ok || pair.RequestsSent == 0 {
+				continue
+			}
+			if local, ok := report[pair.LocalCandidateID].(ICECandidateStats); ok &&
+				local.CandidateType == ICECandidateTypeRelay {
+				return true
+			}
+		}
+
+		return false
+	}, 10*time.Second, 50*time.Millisecond)
+
+	closePairNow(t, offerPC, answerPC)
+	assert.NoError(t, turnServer.Close())
+	assert.NoError(t, wan.Stop())
+}
+
 type trackRecords struct {
 	mu               sync.Mutex
 	trackIDs         map[string]struct{}
@@ -2058,6 +2396,146 @@ func TestICETricklingSupported(t *testing.T) {
 	assert.NoError(t, pcAnswer.Close())
 }
 
+func TestVoiceActivityDetection(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newVADPeerConnection := func() *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		for _, codec := range []RTPCodecParameters{
+			{
+				RTPCodecCapability: RTPCodecCapability{
+					MimeTypeOpus, 48000, 2, "minptime=10;useinbandfec=1;usedtx=1", nil,
+				},
+				PayloadType: 111,
+			},
+			{RTPCodecCapability: RTPCodecCapability{"audio/G729", 8000, 0, "", nil}, PayloadType: 18},
+			{RTPCodecCapability: RTPCodecCapability{"audio/CN", 8000, 0, "", nil}, PayloadType: 13},
+		} {
+			assert.NoError(t, mediaEngine.RegisterCodec(codec, RTPCodecTypeAudio))
+		}
+
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		return pc
+	}
+
+	assertVAD := func(sdp string, enabled bool) {
+		t.Helper()
+
+		if enabled {
+			assert.Contains(t, sdp, "m=audio 9 UDP/TLS/RTP/SAVPF 111 18 13")
+			assert.Contains(t, sdp, "a=rtpmap:13 CN/8000")
+			assert.Contains(t, sdp, "usedtx=1")
+			assert.NotContains(t, sdp, "annexb")
+		} else {
+			assert.Contains(t, sdp, "m=audio 9 UDP/TLS/RTP/SAVPF 111 18\r\n")
+			assert.NotContains(t, sdp, "CN/8000")
+			assert.Contains(t, sdp, "a=fmtp:111 minptime=10;useinbandfec=1\r\n")
+			assert.Contains(t, sdp, "a=fmtp:18 annexb=no")
+		}
+	}
+
+	pcOffer := newVADPeerConnection()
+	_, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	assert.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assertVAD(offer.SDP, true)
+
+	offer, err = pcOffer.CreateOffer(&OfferOptions{
+		OfferAnswerOptions: OfferAnswerOptions{VoiceActivityDetection: true},
+	})
+	assert.NoError(t, err)
+	assertVAD(offer.SDP, true)
+
+	// Options that leave it out don't disable it
+	offer, err = pcOffer.CreateOffer(&OfferOptions{ICERestart: true})
+	assert.NoError(t, err)
+	assertVAD(offer.SDP, true)
+
+	offer, err = pcOffer.CreateOffer(&OfferOptions{
+		OfferAnswerOptions: OfferAnswerOptions{DisableVoiceActivityDetection: true},
+	})
+	assert.NoError(t, err)
+	assertVAD(offer.SDP, false)
+
+	pcAnswer := newVADPeerConnection()
+	offer, err = pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assertVAD(answer.SDP, true)
+
+	answer, err = pcAnswer.CreateAnswer(&AnswerOptions{})
+	assert.NoError(t, err)
+	assertVAD(answer.SDP, true)
+
+	answer, err = pcAnswer.CreateAnswer(&AnswerOptions{
+		OfferAnswerOptions: OfferAnswerOptions{DisableVoiceActivityDetection: true},
+	})
+	assert.NoError(t, err)
+	assertVAD(answer.SDP, false)
+
+	// The comfort noise codecs that weren't answered aren't negotiated
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+	for _, codec := range pcAnswer.api.mediaEngine.getCodecsByKind(RTPCodecTypeAudio) {
+		assert.NotEqual(t, "audio/CN", codec.MimeType)
+	}
+	assert.Len(t, pcAnswer.api.mediaEngine.getCodecsByKind(RTPCodecTypeAudio), 2)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestOfferToReceive(t *testing.T) {
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	// A sendonly transceiver doesn't receive, a recvonly one does
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeAudio, RTPTransceiverInit{Direction: RTPTransceiverDirectionSendonly})
+	assert.NoError(t, err)
+	_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{Direction: RTPTransceiverDirectionRecvonly})
+	assert.NoError(t, err)
+
+	countReceiving := func(kind RTPCodecType) (count int) {
+		for _, transceiver := range pc.GetTransceivers() {
+			if transceiver.Kind() == kind && transceiver.Direction().hasRecv() {
+				count++
+			}
+		}
+
+		return count
+	}
+
+	options := &OfferOptions{OfferToReceiveAudio: 2, OfferToReceiveVideo: 3}
+	for range 2 {
+		offer, err := pc.CreateOffer(options)
+		assert.NoError(t, err)
+
+		assert.Len(t, pc.GetTransceivers(), 6)
+		assert.Equal(t, 2, countReceiving(RTPCodecTypeAudio))
+		assert.Equal(t, 3, countReceiving(RTPCodecTypeVideo))
+		assert.Equal(t, 3, strings.Count(offer.SDP, "m=audio"))
+		assert.Equal(t, 3, strings.Count(offer.SDP, "m=video"))
+		assert.Equal(t, 5, strings.Count(offer.SDP, "a=recvonly"))
+		assert.Equal(t, 1, strings.Count(offer.SDP, "a=sendonly"))
+	}
+
+	// Zero leaves the transceivers as they are
+	_, err = pc.CreateOffer(&OfferOptions{})
+	assert.NoError(t, err)
+	assert.Len(t, pc.GetTransceivers(), 6)
+
+	assert.NoError(t, pc.Close())
+}
+
 func TestICERenominationAdvertised(t *testing.T) {
 	report := test.CheckRoutines(t)
 	defer report()
@@ -2479,43 +2957,6 @@ func TestAddICECandidate__DroppingOldGenerationCandidates(t *testing.T) {
 	closePairNow(t, pc, remotePC)
 }
 
-func TestPeerConnectionCanTrickleICECandidatesGo(t *testing.T) {
-	offerPC, answerPC, wan := createVNetPair(t, nil)
-	var err error
-	defer func() {
-		assert.NoError(t, wan.Stop())
-		closePairNow(t, offerPC, answerPC)
-	}()
-
-	_, err = offerPC.CreateDataChannel("trickle", nil)
-	assert.NoError(t, err)
-
-	offer, err := offerPC.CreateOffer(&OfferOptions{
-		OfferAnswerOptions: OfferAnswerOptions{ICETricklingSupported: true},
-	})
-	assert.NoError(t, err)
-	assert.NoError(t, offerPC.SetLocalDescription(offer))
-	assert.Equal(t, ICETrickleCapabilityUnknown, answerPC.CanTrickleICECandidates())
-	assert.NoError(t, answerPC.SetRemoteDescription(offer))
-	assert.Equal(t, ICETrickleCapabilitySupported, answerPC.CanTrickleICECandidates())
-
-	noTrickleOfferPC, noTrickleAnswerPC, noTrickleWAN := createVNetPair(t, nil)
-	defer func() {
-		assert.NoError(t, noTrickleWAN.Stop())
-		closePairNow(t, noTrickleOfferPC, noTrickleAnswerPC)
-	}()
-
-	_, err = noTrickleOfferPC.CreateDataChannel("notrickle", nil)
-	assert.NoError(t, err)
-
-	noTrickleOffer, err := noTrickleOfferPC.CreateOffer(nil)
-	assert.NoError(t, err)
-	assert.NoError(t, noTrickleOfferPC.SetLocalDescription(noTrickleOffer))
-	assert.Equal(t, ICETrickleCapabilityUnknown, noTrickleAnswerPC.CanTrickleICECandidates())
-	assert.NoError(t, noTrickleAnswerPC.SetRemoteDescription(noTrickleOffer))
-	assert.Equal(t, ICETrickleCapabilityUnsupported, noTrickleAnswerPC.CanTrickleICECandidates())
-}
-
 func TestCreateAnswerActiveOfferPassiveAnswer(t *testing.T) {
 	pc, err := NewPeerConnection(Configuration{})
 	assert.NoError(t, err)
@@ -2712,3 +3153,131 @@ func TestSctpSnap(t *testing.T) {
 
 	closePairNow(t, offer, answer)
 }
+
+func BenchmarkCreateOffer(b *testing.B) {
+	newPCWithTransceivers := func(b *testing.B) *PeerConnection {
+		b.Helper()
+
+		pc, err := NewPeerConnection(Configuration{})
+		require.NoError(b, err)
+		for i := 0; i < 10; i++ {
+			_, err = pc.AddTransceiverFromKind(RTPCodecTypeAudio)
+			require.NoError(b, err)
+			_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+			require.NoError(b, err)
+		}
+
+		return pc
+	}
+
+	b.Run("Initial", func(b *testing.B) {
+		pc := newPCWithTransceivers(b)
+		defer func() { require.NoError(b, pc.Close()) }()
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := pc.CreateOffer(nil)
+			require.NoError(b, err)
+		}
+	})
+
+	b.Run("Renegotiation", func(b *testing.B) {
+		pcOffer := newPCWithTransceivers(b)
+		pcAnswer, err := NewPeerConnection(Configuration{})
+		require.NoError(b, err)
+		defer closePairNow(b, pcOffer, pcAnswer)
+		require.NoError(b, signalPair(pcOffer, pcAnswer))
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			_, err := pcOffer.CreateOffer(nil)
+			require.NoError(b, err)
+		}
+	})
+}
+
+func TestPeerConnection_GetLocalCandidates(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	candidates, err := pcOffer.GetLocalCandidates()
+	assert.NoError(t, err)
+	assert.Empty(t, candidates)
+
+	var emittedLock sync.Mutex
+	var emitted []ICECandidate
+	pcOffer.OnICECandidate(func(c *ICECandidate) {
+		if c != nil {
+			emittedLock.Lock()
+			emitted = append(emitted, *c)
+			emittedLock.Unlock()
+		}
+	})
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+
+	gatherRound := func(options *OfferOptions) {
+		emittedLock.Lock()
+		emitted = nil
+		emittedLock.Unlock()
+
+		offer, offerErr := pcOffer.CreateOffer(options)
+		assert.NoError(t, offerErr)
+		gatheringComplete := GatheringCompletePromise(pcOffer)
+		assert.NoError(t, pcOffer.SetLocalDescription(offer))
+		<-gatheringComplete
+
+		candidates, err = pcOffer.GetLocalCandidates()
+		assert.NoError(t, err)
+
+		emittedLock.Lock()
+		assert.NotEmpty(t, emitted)
+		assert.ElementsMatch(t, emitted, candidates)
+		emittedLock.Unlock()
+		for _, candidate := range candidates {
+			assert.Equal(t, "0", candidate.SDPMid)
+			assert.Equal(t, uint16(0), candidate.SDPMLineIndex)
+		}
+
+		assert.NoError(t, pcAnswer.SetRemoteDescription(*pcOffer.LocalDescription()))
+		answer, answerErr := pcAnswer.CreateAnswer(nil)
+		assert.NoError(t, answerErr)
+		assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+		assert.NoError(t, pcOffer.SetRemoteDescription(answer))
+	}
+
+	gatherRound(nil)
+	firstRound := candidates
+
+	hosts, err := pcOffer.iceGatherer.GetLocalCandidatesByType(ICECandidateTypeHost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, hosts)
+	for _, candidate := range firstRound {
+		if candidate.Typ == ICECandidateTypeHost {
+			assert.Contains(t, hosts, candidate)
+		}
+	}
+	relays, err := pcOffer.iceGatherer.GetLocalCandidatesByType(ICECandidateTypeRelay)
+	assert.NoError(t, err)
+	assert.Empty(t, relays)
+
+	// Only the candidates of the current generation are returned after a restart
+	gatherRound(&OfferOptions{ICERestart: true})
+	for _, candidate := range candidates {
+		assert.NotContains(t, firstRound, candidate)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+	_, err = pcOffer.GetLocalCandidates()
+	var stateErr *rtcerr.InvalidStateError
+	assert.ErrorAs(t, err, &stateErr)
+}
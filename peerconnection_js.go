@@ -703,6 +703,7 @@ func valueToICECandidate(val js.Value) *ICECandidate {
 		Component:      stringToComponentIDOrZero(val.Get("component").String()),
 		RelatedAddress: val.Get("relatedAddress").String(),
 		RelatedPort:    valueToUint16OrZero(val.Get("relatedPort")),
+		RelayProtocol:  valueToStringOrZero(val.Get("relayProtocol")),
 	}
 }
 
@@ -742,10 +743,18 @@ func offerOptionsToValue(offerOptions *OfferOptions) js.Value {
 	if offerOptions == nil {
 		return js.Undefined()
 	}
-	return js.ValueOf(map[string]any{
+	options := map[string]any{
 		"iceRestart":             offerOptions.ICERestart,
 		"voiceActivityDetection": offerOptions.VoiceActivityDetection,
-	})
+	}
+	if offerOptions.OfferToReceiveAudio > 0 {
+		options["offerToReceiveAudio"] = offerOptions.OfferToReceiveAudio
+	}
+	if offerOptions.OfferToReceiveVideo > 0 {
+		options["offerToReceiveVideo"] = offerOptions.OfferToReceiveVideo
+	}
+
+	return js.ValueOf(options)
 }
 
 func answerOptionsToValue(answerOptions *AnswerOptions) js.Value {
@@ -13,7 +13,10 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"net"
 	"regexp"
+	"slices"
+	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -623,7 +626,7 @@ func TestUndeclaredSSRC(t *testing.T) {
 				},
 			},
 		}
-		peer := &PeerConnection{}
+		peer := &PeerConnection{api: NewAPI()}
 
 		video, ok := peer.findMediaSectionByPayloadType(96, parsed)
 		assert.True(t, ok)
@@ -638,6 +641,147 @@ func TestUndeclaredSSRC(t *testing.T) {
 		missing, ok := peer.findMediaSectionByPayloadType(42, parsed)
 		assert.False(t, ok)
 		assert.Nil(t, missing)
+
+		// A payload type shared by reordered sections is matched by the kind of its codec
+		parsed.parsed.MediaDescriptions[0], parsed.parsed.MediaDescriptions[1] =
+			parsed.parsed.MediaDescriptions[1], parsed.parsed.MediaDescriptions[0]
+		parsed.parsed.MediaDescriptions[0].MediaName.Formats = []string{"96", "8"}
+
+		video, ok = peer.findMediaSectionByPayloadType(96, parsed)
+		assert.True(t, ok)
+		assert.Equal(t, "video", video.MediaName.Media)
+	})
+}
+
+func TestUnknownSSRCHandler(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// writeUnsignaled sends RTP packets for a SSRC that hasn't been negotiated yet.
+	writeUnsignaled := func(t *testing.T, pc *PeerConnection, ssrc SSRC, payloadType PayloadType, count int) {
+		t.Helper()
+
+		srtpSession, err := pc.dtlsTransport.getSRTPSession()
+		require.NoError(t, err)
+
+		writeStream, err := srtpSession.OpenWriteStream()
+		require.NoError(t, err)
+
+		for i := range count {
+			_, err = writeStream.WriteRTP(&rtp.Header{
+				Version:        2,
+				SSRC:           uint32(ssrc),
+				PayloadType:    uint8(payloadType),
+				SequenceNumber: uint16(i), //nolint:gosec // G115
+			}, []byte{0x10, 0x00, 0x00})
+			require.NoError(t, err)
+		}
+	}
+
+	t.Run("Attach before renegotiation", func(t *testing.T) {
+		const unsignaledPackets = 20
+
+		handlerCalled := make(chan SSRC, 1)
+		settingEngine := SettingEngine{}
+		settingEngine.SetUnknownSSRCHandler(func(ssrc uint32, _ uint8, _, _ string) UnknownSSRCAction {
+			handlerCalled <- SSRC(ssrc)
+
+			return UnknownSSRCAction{Type: UnknownSSRCActionTypeAttachToTransceiver, MID: "1"}
+		})
+
+		pcOffer, err := NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, err)
+
+		sender, err := pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		ssrc := sender.GetParameters().Encodings[0].SSRC
+		payloadType := sender.GetParameters().Codecs[0].PayloadType
+		writeUnsignaled(t, pcOffer, ssrc, payloadType, unsignaledPackets)
+		assert.Equal(t, ssrc, <-handlerCalled)
+
+		// The packets are buffered in the background, the SSRC is resolved before the transceiver exists
+		assert.Eventually(t, func() bool {
+			unresolved, _ := pcAnswer.dtlsTransport.probeStreamCounts()
+
+			return unresolved == 0
+		}, time.Second, time.Millisecond)
+
+		readDone := make(chan struct{})
+		pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+			defer close(readDone)
+
+			assert.Equal(t, ssrc, trackRemote.SSRC())
+			assert.Equal(t, "pion", trackRemote.StreamID())
+			for i := range unsignaledPackets + 5 {
+				pkt, _, readErr := trackRemote.ReadRTP()
+				if !assert.NoError(t, readErr) {
+					return
+				}
+				assert.Equal(t, uint16(i), pkt.SequenceNumber) //nolint:gosec // G115
+			}
+		})
+
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		func() {
+			ticker := time.NewTicker(time.Millisecond * 20)
+			defer ticker.Stop()
+
+			for sequenceNumber := uint16(unsignaledPackets); ; sequenceNumber++ {
+				select {
+				case <-readDone:
+					return
+				case <-ticker.C:
+					assert.NoError(t, track.WriteRTP(&rtp.Packet{
+						Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+						Payload: []byte{0x10, 0x00, 0x00},
+					}))
+				}
+			}
+		}()
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("Drop", func(t *testing.T) {
+		handlerCalled := make(chan SSRC, 1)
+		settingEngine := SettingEngine{}
+		settingEngine.SetUnknownSSRCHandler(func(ssrc uint32, _ uint8, _, _ string) UnknownSSRCAction {
+			handlerCalled <- SSRC(ssrc)
+
+			return UnknownSSRCAction{Type: UnknownSSRCActionTypeDrop}
+		})
+
+		pcOffer, err := NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		pcAnswer.OnTrack(func(*TrackRemote, *RTPReceiver) {
+			assert.Fail(t, "OnTrack must not fire for a dropped SSRC")
+		})
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		writeUnsignaled(t, pcOffer, 5000, 96, 5)
+		assert.Equal(t, SSRC(5000), <-handlerCalled)
+
+		closePairNow(t, pcOffer, pcAnswer)
 	})
 }
 
@@ -890,6 +1034,237 @@ func TestAddTransceiverAddTrack_Reuse(t *testing.T) {
 	})
 }
 
+func TestAddTrack_Answerer(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newVP8Track := func(t *testing.T, id string) *TrackLocalStaticSample {
+		t.Helper()
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, id, id)
+		require.NoError(t, err)
+
+		return track
+	}
+
+	// The offered media section is reused, the track is sent without renegotiating
+	for _, testCase := range []struct {
+		offerDirection  RTPTransceiverDirection
+		answerDirection RTPTransceiverDirection
+	}{
+		{RTPTransceiverDirectionRecvonly, RTPTransceiverDirectionSendonly},
+		{RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionSendrecv},
+	} {
+		for _, afterOffer := range []bool{false, true} {
+			name := fmt.Sprintf("%s/AddTrackAfterOffer=%t", testCase.offerDirection, afterOffer)
+			t.Run(name, func(t *testing.T) {
+				pcOffer, pcAnswer, err := newPair()
+				require.NoError(t, err)
+
+				if testCase.offerDirection == RTPTransceiverDirectionRecvonly {
+					_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+						Direction: RTPTransceiverDirectionRecvonly,
+					})
+				} else {
+					_, err = pcOffer.AddTrack(newVP8Track(t, "offerer"))
+				}
+				require.NoError(t, err)
+
+				track := newVP8Track(t, "answerer")
+				addTrack := func() {
+					_, addErr := pcAnswer.AddTrack(track)
+					assert.NoError(t, addErr)
+				}
+				if !afterOffer {
+					addTrack()
+				}
+
+				onTrack, onTrackFired := context.WithCancel(context.Background())
+				pcOffer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+					if remote.ID() == track.ID() {
+						onTrackFired()
+					}
+				})
+
+				require.NoError(t, signalPairWithOptions(pcOffer, pcAnswer, withBeforeAnswer(func() {
+					if afterOffer {
+						addTrack()
+					}
+				})))
+
+				transceivers := pcAnswer.GetTransceivers()
+				require.Len(t, transceivers, 1)
+				assert.Equal(t, "0", transceivers[0].Mid())
+				assert.Equal(t, track, transceivers[0].Sender().Track())
+
+				media := getByMid("0", pcAnswer.CurrentLocalDescription())
+				require.NotNil(t, media)
+				assert.Equal(t, testCase.answerDirection, getPeerDirection(media))
+				msid, ok := media.Attribute(sdp.AttrKeyMsid)
+				assert.True(t, ok)
+				assert.Equal(t, track.StreamID()+" "+track.ID(), msid)
+
+				sendVideoUntilDone(t, onTrack.Done(), []*TrackLocalStaticSample{track})
+				closePairNow(t, pcOffer, pcAnswer)
+			})
+		}
+	}
+
+	t.Run("PreferOfferedTransceiver", func(t *testing.T) {
+		pcOffer, pcAnswer, err := newPair()
+		require.NoError(t, err)
+
+		_, err = pcOffer.AddTrack(newVP8Track(t, "offerer"))
+		require.NoError(t, err)
+
+		// The inactive transceiver isn't matched with the offer, but could be reused
+		unassociated, err := pcAnswer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+			Direction: RTPTransceiverDirectionRecvonly,
+		})
+		require.NoError(t, err)
+		unassociated.setDirection(RTPTransceiverDirectionInactive)
+		_, err = pcAnswer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+			Direction: RTPTransceiverDirectionRecvonly,
+		})
+		require.NoError(t, err)
+
+		var sender *RTPSender
+		require.NoError(t, signalPairWithOptions(pcOffer, pcAnswer, withBeforeAnswer(func() {
+			sender, err = pcAnswer.AddTrack(newVP8Track(t, "answerer"))
+			assert.NoError(t, err)
+		})))
+
+		assert.Nil(t, unassociated.Sender())
+		assert.Equal(t, "", unassociated.Mid())
+		media := getByMid("0", pcAnswer.CurrentLocalDescription())
+		require.NotNil(t, media)
+		assert.Equal(t, RTPTransceiverDirectionSendrecv, getPeerDirection(media))
+		assert.Equal(t, sender, pcAnswer.GetTransceiverByMid("0").Sender())
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("PlaceholderTransceivers", func(t *testing.T) {
+		pcOffer, pcAnswer, err := newPair()
+		require.NoError(t, err)
+
+		// Every media section of the offer gets a sendonly transceiver without a sender
+		const sections = 3
+		for range sections {
+			_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+				Direction: RTPTransceiverDirectionRecvonly,
+			})
+			require.NoError(t, err)
+		}
+
+		var tracks []*TrackLocalStaticSample
+		var onTrackMu sync.Mutex
+		onTracks := map[string]bool{}
+		onTrack, onTrackFired := context.WithCancel(context.Background())
+		pcOffer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+			onTrackMu.Lock()
+			defer onTrackMu.Unlock()
+
+			if onTracks[remote.ID()] = true; len(onTracks) == sections {
+				onTrackFired()
+			}
+		})
+		require.NoError(t, signalPairWithOptions(pcOffer, pcAnswer, withBeforeAnswer(func() {
+			for _, transceiver := range pcAnswer.GetTransceivers() {
+				assert.Equal(t, RTPTransceiverDirectionSendonly, transceiver.Direction())
+				assert.Nil(t, transceiver.Sender())
+			}
+			for i := range sections {
+				track := newVP8Track(t, fmt.Sprintf("answerer-%d", i))
+				_, addErr := pcAnswer.AddTrack(track)
+				assert.NoError(t, addErr)
+				tracks = append(tracks, track)
+			}
+		})))
+
+		transceivers := pcAnswer.GetTransceivers()
+		require.Len(t, transceivers, sections)
+		for i, transceiver := range transceivers {
+			mid := strconv.Itoa(i)
+			assert.Equal(t, mid, transceiver.Mid())
+			assert.Equal(t, tracks[i], transceiver.Sender().Track())
+
+			media := getByMid(mid, pcAnswer.CurrentLocalDescription())
+			require.NotNil(t, media)
+			assert.Equal(t, RTPTransceiverDirectionSendonly, getPeerDirection(media))
+			_, ok := media.Attribute(sdp.AttrKeySSRC)
+			assert.True(t, ok)
+		}
+		sendVideoUntilDone(t, onTrack.Done(), tracks)
+
+		// The media sections keep their order in the next offer
+		offer, err := pcAnswer.CreateOffer(nil)
+		require.NoError(t, err)
+		var mids []string
+		for _, media := range offer.parsed.MediaDescriptions {
+			if media.MediaName.Media != mediaSectionApplication {
+				mids = append(mids, getMidValue(media))
+			}
+		}
+		assert.Equal(t, []string{"0", "1", "2"}, mids)
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("NewTransceiver", func(t *testing.T) {
+		pcOffer, pcAnswer, err := newPair()
+		require.NoError(t, err)
+
+		// The offerer only sends, the answerer can't send on the offered media section
+		_, err = pcOffer.AddTransceiverFromTrack(newVP8Track(t, "offerer"), RTPTransceiverInit{
+			Direction: RTPTransceiverDirectionSendonly,
+		})
+		require.NoError(t, err)
+
+		negotiationNeeded, negotiationNeededFired := context.WithCancel(context.Background())
+		var signalingState atomic.Value
+		pcAnswer.OnNegotiationNeeded(func() {
+			signalingState.Store(pcAnswer.SignalingState())
+			negotiationNeededFired()
+		})
+
+		track := newVP8Track(t, "answerer")
+		require.NoError(t, signalPairWithOptions(pcOffer, pcAnswer, withBeforeAnswer(func() {
+			_, addErr := pcAnswer.AddTrack(track)
+			assert.NoError(t, addErr)
+		})))
+
+		media := getByMid("0", pcAnswer.CurrentLocalDescription())
+		require.NotNil(t, media)
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, getPeerDirection(media))
+		_, ok := media.Attribute(sdp.AttrKeyMsid)
+		assert.False(t, ok)
+
+		transceivers := pcAnswer.GetTransceivers()
+		require.Len(t, transceivers, 2)
+		assert.Equal(t, "", transceivers[1].Mid())
+		assert.Equal(t, track, transceivers[1].Sender().Track())
+
+		<-negotiationNeeded.Done()
+		assert.Equal(t, SignalingStateStable, signalingState.Load())
+
+		// The answerer negotiates the new transceiver, the offerer then receives the track
+		onTrack, onTrackFired := context.WithCancel(context.Background())
+		pcOffer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+			if remote.ID() == track.ID() {
+				onTrackFired()
+			}
+		})
+		require.NoError(t, signalPairWithOptions(pcAnswer, pcOffer, withDisableInitialDataChannel(true)))
+		sendVideoUntilDone(t, onTrack.Done(), []*TrackLocalStaticSample{track})
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+}
+
 func TestAddTransceiverFromRemoteDescription(t *testing.T) {
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
@@ -1302,7 +1677,7 @@ func TestPeerConnection_Start_Only_Negotiated_Senders(t *testing.T) {
 	assert.NoError(t, pcOffer.SetRemoteDescription(*pcAnswer.LocalDescription()))
 
 	// Wait for senders to be started by startTransports spawned goroutine
-	pcOffer.ops.Done()
+	assert.NoError(t, pcOffer.WaitForPendingOperations(context.Background()))
 
 	// sender1 should be started but sender2 should not be started
 	assert.True(t, sender1.hasSent(), "sender1 is not started but should be started")
@@ -1665,6 +2040,114 @@ func TestPeerConnection_Simulcast_Probe(t *testing.T) {
 
 		closePairNow(t, pcOffer, pcAnswer)
 	})
+
+	// Assert that a flood of undeclared SSRCs is bounded by the probe stream limit,
+	// and that SSRCs carrying a RID are still resolved
+	t.Run("StreamLimit", func(t *testing.T) {
+		const (
+			probeStreamLimit = 8
+			sprayedSSRCs     = 300
+		)
+
+		var evicted atomic.Uint32
+		settingEngine := SettingEngine{}
+		settingEngine.SetSimulcastProbeStreamLimit(probeStreamLimit)
+		settingEngine.SetSimulcastProbeStreamLimitHandler(func(SSRC) {
+			evicted.Add(1)
+		})
+
+		offerer, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		answerer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		rids := []string{"layer_1", "layer_2", "layer_3"}
+		var ridsLock sync.Mutex
+		seenRIDs := map[string]bool{}
+		answerer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+			ridsLock.Lock()
+			defer ridsLock.Unlock()
+			seenRIDs[remote.RID()] = true
+		})
+		allRIDsSeen := func() bool {
+			ridsLock.Lock()
+			defer ridsLock.Unlock()
+
+			return len(seenRIDs) == len(rids)
+		}
+
+		var tracks []*TrackLocalStaticRTP
+		for _, rid := range rids {
+			track, err := NewTrackLocalStaticRTP(
+				RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID(rid),
+			)
+			assert.NoError(t, err)
+			tracks = append(tracks, track)
+		}
+
+		sender, err := offerer.AddTrack(tracks[0])
+		assert.NoError(t, err)
+		assert.NoError(t, sender.AddEncoding(tracks[1]))
+		assert.NoError(t, sender.AddEncoding(tracks[2]))
+
+		peerConnectionConnected := untilConnectionState(PeerConnectionStateConnected, offerer, answerer)
+		assert.NoError(t, signalPair(offerer, answerer))
+		peerConnectionConnected.Wait()
+
+		var midID, ridID uint8
+		for _, extension := range sender.GetParameters().HeaderExtensions {
+			switch extension.URI {
+			case sdp.SDESMidURI:
+				midID = uint8(extension.ID) //nolint:gosec // G115
+			case sdp.SDESRTPStreamIDURI:
+				ridID = uint8(extension.ID) //nolint:gosec // G115
+			}
+		}
+		assert.NotZero(t, midID)
+		assert.NotZero(t, ridID)
+
+		writeRTP := func(header *rtp.Header) {
+			tracks[0].mu.Lock()
+			defer tracks[0].mu.Unlock()
+
+			_, err := tracks[0].bindings[0].writeStream.WriteRTP(header, []byte{0, 1, 2, 3, 4, 5})
+			assert.NoError(t, err)
+		}
+
+		ridSSRCs := []uint32{util.RandUint32(), util.RandUint32(), util.RandUint32()}
+		for sprayed := 0; sprayed < sprayedSSRCs || !allRIDsSeen(); sprayed++ {
+			writeRTP(&rtp.Header{Version: 2, SSRC: util.RandUint32()})
+
+			if sprayed%10 == 0 {
+				for i, rid := range rids {
+					header := &rtp.Header{
+						Version:          2,
+						SSRC:             ridSSRCs[i],
+						SequenceNumber:   uint16(sprayed), //nolint:gosec // G115
+						PayloadType:      96,
+						Extension:        true,
+						ExtensionProfile: 0x1000,
+					}
+					assert.NoError(t, header.SetExtension(midID, []byte("0")))
+					assert.NoError(t, header.SetExtension(ridID, []byte(rid)))
+					writeRTP(header)
+				}
+			}
+
+			unresolved, _ := answerer.dtlsTransport.probeStreamCounts()
+			assert.LessOrEqual(t, unresolved, uint32(probeStreamLimit))
+
+			time.Sleep(time.Millisecond)
+		}
+
+		stats, ok := answerer.GetStats()["iceTransport"].(TransportStats)
+		assert.True(t, ok)
+		assert.LessOrEqual(t, stats.ProbeStreams, uint32(probeStreamLimit))
+		assert.NotZero(t, stats.ProbeStreamsEvicted)
+		assert.NotZero(t, evicted.Load())
+
+		closePairNow(t, offerer, answerer)
+	})
 }
 
 // Assert that CreateOffer returns an error for a RTPSender with no codecs
@@ -1966,60 +2449,530 @@ func (s *simulcastTestTrackLocal) WriteRTP(pkt *rtp.Packet) error {
 	return util.FlattenErrs(writeErrs)
 }
 
-func TestPeerConnection_Simulcast_RTX(t *testing.T) { //nolint:cyclop
+// Mids are opaque strings, the ones chosen by a gateway don't have to be numbers nor fit into
+// the one-byte form of the mid RTP header extension.
+func TestPeerConnection_NonNumericMids(t *testing.T) { //nolint:cyclop
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
 
 	report := test.CheckRoutines(t)
 	defer report()
 
-	rids := []string{"a", "b"}
+	const (
+		audioMid = "audio-stream-1"
+		videoMid = "video-0f8fad5b-d9cb-469f-a165-70867728950"
+	)
+
 	pcOffer, pcAnswer, err := newPair()
-	assert.NoError(t, err)
+	require.NoError(t, err)
 
-	vp8WriterAStatic, err := NewTrackLocalStaticRTP(
-		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion2", WithRTPStreamID(rids[0]),
-	)
-	assert.NoError(t, err)
+	audioTrack, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(audioTrack)
+	require.NoError(t, err)
 
-	vp8WriterBStatic, err := NewTrackLocalStaticRTP(
-		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion2", WithRTPStreamID(rids[1]),
+	// The first layer has the full mid in a two-byte header extension, the second one a mid
+	// truncated to the one-byte form
+	videoTrackA, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("a"), WithAutoExtensionStamping(),
 	)
-	assert.NoError(t, err)
+	require.NoError(t, err)
+	videoTrackB, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID("b"),
+	)
+	require.NoError(t, err)
+	videoSender, err := pcOffer.AddTrack(videoTrackA)
+	require.NoError(t, err)
+	require.NoError(t, videoSender.AddEncoding(videoTrackB))
 
-	vp8WriterA, vp8WriterB := &simulcastTestTrackLocal{vp8WriterAStatic}, &simulcastTestTrackLocal{vp8WriterBStatic}
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
 
-	sender, err := pcOffer.AddTrack(vp8WriterA)
-	assert.NoError(t, err)
-	assert.NotNil(t, sender)
+	require.NoError(t, pcOffer.GetTransceivers()[0].SetMid(audioMid))
+	require.NoError(t, pcOffer.GetTransceivers()[1].SetMid(videoMid))
 
-	assert.NoError(t, sender.AddEncoding(vp8WriterB))
+	var midID, ridID uint8
+	for _, extension := range videoSender.GetParameters().HeaderExtensions {
+		switch extension.URI {
+		case sdp.SDESMidURI:
+			midID = uint8(extension.ID) //nolint:gosec // G115
+		case sdp.SDESRTPStreamIDURI:
+			ridID = uint8(extension.ID) //nolint:gosec // G115
+		}
+	}
 
-	var ridMapLock sync.RWMutex
-	ridMap := map[string]int{}
+	var receivedLock sync.Mutex
+	received := map[string]bool{}
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, receiver *RTPReceiver) {
+		for _, transceiver := range pcAnswer.GetTransceivers() {
+			if transceiver.Receiver() == receiver {
+				receivedLock.Lock()
+				received[transceiver.Mid()+"/"+trackRemote.RID()] = true
+				receivedLock.Unlock()
+			}
+		}
+	})
+	receivedAll := func() bool {
+		receivedLock.Lock()
+		defer receivedLock.Unlock()
 
-	assertRidCorrect := func(t *testing.T) {
-		t.Helper()
+		return len(received) == 3
+	}
 
-		ridMapLock.Lock()
-		defer ridMapLock.Unlock()
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
 
-		for _, rid := range rids {
-			assert.Equal(t, ridMap[rid], 1)
-		}
-		assert.Equal(t, len(ridMap), 2)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for sequenceNumber := uint16(0); !receivedAll(); sequenceNumber++ {
+		<-ticker.C
+
+		header := rtp.Header{Version: 2, SequenceNumber: sequenceNumber}
+		assert.NoError(t, audioTrack.WriteRTP(&rtp.Packet{Header: header, Payload: []byte{0x00}}))
+		assert.NoError(t, videoTrackA.WriteRTP(&rtp.Packet{Header: header, Payload: []byte{0x00}}))
+
+		packet := &rtp.Packet{Header: header, Payload: []byte{0x00}}
+		assert.NoError(t, packet.SetExtension(midID, []byte(videoMid[:maxOneByteHeaderExtensionSize])))
+		assert.NoError(t, packet.SetExtension(ridID, []byte("b")))
+		assert.NoError(t, videoTrackB.WriteRTP(packet))
 	}
+	assert.Equal(t, map[string]bool{audioMid + "/": true, videoMid + "/a": true, videoMid + "/b": true}, received)
 
-	ridsFullfilled := func() bool {
-		ridMapLock.Lock()
-		defer ridMapLock.Unlock()
+	// Renegotiation keeps the mids, a new transceiver gets a mid none of the sections has
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
 
-		ridCount := len(ridMap)
+	for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+		mids := []string{}
+		for _, media := range pc.CurrentLocalDescription().parsed.MediaDescriptions {
+			mids = append(mids, getMidValue(media))
+		}
+		assert.Equal(t, []string{audioMid, videoMid, "2", "3"}, mids)
 
-		return ridCount == 2
+		bundle, _ := pc.CurrentLocalDescription().parsed.Attribute(sdp.AttrKeyGroup)
+		assert.Equal(t, "BUNDLE "+strings.Join(mids, " "), bundle)
 	}
 
-	var rtxPacketRead atomic.Int32
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_Simulcast_PauseLayer(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	var writers []*TrackLocalStaticRTP
+	for _, rid := range []string{"a", "b"} {
+		writer, writerErr := NewTrackLocalStaticRTP(
+			RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID(rid),
+		)
+		require.NoError(t, writerErr)
+		writers = append(writers, writer)
+	}
+
+	sender, err := pcOffer.AddTrack(writers[0])
+	require.NoError(t, err)
+	require.NoError(t, sender.AddEncoding(writers[1]))
+
+	var midID, ridID uint8
+	for _, extension := range sender.GetParameters().HeaderExtensions {
+		switch extension.URI {
+		case sdp.SDESMidURI:
+			midID = uint8(extension.ID) //nolint:gosec // G115
+		case sdp.SDESRTPStreamIDURI:
+			ridID = uint8(extension.ID) //nolint:gosec // G115
+		}
+	}
+	layerSSRC := uint32(sender.GetParameters().Encodings[0].SSRC)
+
+	tracks := make(chan *TrackRemote, 2)
+	var receiver atomic.Pointer[RTPReceiver]
+	pcAnswer.OnTrack(func(track *TrackRemote, r *RTPReceiver) {
+		receiver.Store(r)
+		tracks <- track
+	})
+
+	plis := make(chan uint32, 10)
+	go func() {
+		for {
+			pkts, _, readErr := sender.ReadSimulcastRTCP("a")
+			if readErr != nil {
+				return
+			}
+			for _, pkt := range pkts {
+				if pli, ok := pkt.(*rtcp.PictureLossIndication); ok {
+					plis <- pli.MediaSSRC
+				}
+			}
+		}
+	}()
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	done := make(chan struct{})
+	sendingDone := make(chan struct{})
+	go func() {
+		defer close(sendingDone)
+
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+
+			for _, writer := range writers {
+				pkt := &rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 96},
+					Payload: []byte{0x00},
+				}
+				assert.NoError(t, pkt.Header.SetExtension(midID, []byte("0")))
+				assert.NoError(t, pkt.Header.SetExtension(ridID, []byte(writer.RID())))
+				assert.NoError(t, writer.WriteRTP(pkt))
+			}
+		}
+	}()
+
+	var trackA *TrackRemote
+	for trackA == nil {
+		if track := <-tracks; track.RID() == "a" {
+			trackA = track
+		}
+	}
+	_, _, err = trackA.ReadRTP()
+	require.NoError(t, err)
+
+	rtpReceiver := receiver.Load()
+	assert.ErrorIs(t, rtpReceiver.PauseSimulcastLayer("z"), errRTPReceiverForRIDTrackStreamNotFound)
+	require.NoError(t, rtpReceiver.PauseSimulcastLayer("a"))
+
+	// The packets of the paused layer are discarded instead of being read
+	assert.NoError(t, trackA.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+	_, _, err = trackA.ReadRTP()
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	assert.True(t, netErr.Timeout())
+	assert.Eventually(t, func() bool {
+		return trackA.PausedPacketsDiscarded() > 20
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.NoError(t, trackA.SetReadDeadline(time.Time{}))
+	assert.Empty(t, plis)
+
+	// Resuming the layer requests a keyframe for it
+	require.NoError(t, rtpReceiver.ResumeSimulcastLayer("a"))
+	discarded := trackA.PausedPacketsDiscarded()
+	_, _, err = trackA.ReadRTP()
+	assert.NoError(t, err)
+	assert.Equal(t, layerSSRC, <-plis)
+	assert.Equal(t, discarded, trackA.PausedPacketsDiscarded())
+
+	close(done)
+	<-sendingDone
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_Simulcast_ProvisionedEncodings(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+		Direction:     RTPTransceiverDirectionSendonly,
+		SendEncodings: []RTPEncodingParameters{{RTPCodingParameters{RID: "q"}}, {RTPCodingParameters{RID: "q"}}},
+	})
+	assert.ErrorIs(t, err, errRTPSenderRIDCollision)
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+		Direction:     RTPTransceiverDirectionSendonly,
+		SendEncodings: []RTPEncodingParameters{{RTPCodingParameters{RID: "q"}}, {}},
+	})
+	assert.ErrorIs(t, err, errRTPSenderRidNil)
+
+	// The encodings are provisioned before any track exists
+	rids := []string{"q", "h", "f"}
+	transceiver, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+		Direction: RTPTransceiverDirectionSendonly,
+		SendEncodings: []RTPEncodingParameters{
+			{RTPCodingParameters{RID: rids[0]}},
+			{RTPCodingParameters{RID: rids[1]}},
+			{RTPCodingParameters{RID: rids[2], SSRC: 1234}},
+		},
+	})
+	require.NoError(t, err)
+	sender := transceiver.Sender()
+
+	parameters := sender.GetParameters()
+	require.Len(t, parameters.Encodings, len(rids))
+	ssrcs := map[string]SSRC{}
+	for i, encoding := range parameters.Encodings {
+		assert.Equal(t, rids[i], encoding.RID)
+		assert.NotZero(t, encoding.SSRC)
+		assert.NotZero(t, encoding.RTX.SSRC)
+		ssrcs[encoding.RID] = encoding.SSRC
+	}
+	assert.Len(t, ssrcs, len(rids))
+	assert.Equal(t, SSRC(1234), ssrcs["f"])
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	for _, rid := range rids {
+		assert.Contains(t, offer.SDP, "a=rid:"+rid+" send")
+	}
+	assert.Contains(t, offer.SDP, "a=simulcast:send q;h;f")
+
+	remoteTracks := make(chan *TrackRemote, len(rids))
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		remoteTracks <- track
+	})
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	// The tracks arrive afterwards, without RIDs of their own
+	audio, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	require.NoError(t, err)
+	assert.ErrorIs(t, sender.ReplaceTrackForRID("q", audio), ErrRTPSenderNewTrackHasIncorrectKind)
+
+	var midID, ridID uint8
+	for _, extension := range sender.GetParameters().HeaderExtensions {
+		switch extension.URI {
+		case sdp.SDESMidURI:
+			midID = uint8(extension.ID) //nolint:gosec // G115
+		case sdp.SDESRTPStreamIDURI:
+			ridID = uint8(extension.ID) //nolint:gosec // G115
+		}
+	}
+
+	writers := map[string]*TrackLocalStaticRTP{}
+	for _, rid := range rids {
+		writer, writerErr := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, writerErr)
+		require.NoError(t, sender.ReplaceTrackForRID(rid, writer))
+		writers[rid] = writer
+	}
+	assert.ErrorIs(t, sender.ReplaceTrackForRID("x", writers["q"]), errRTPSenderNoTrackForRID)
+
+	done := make(chan struct{})
+	sendingDone := make(chan struct{})
+	go func() {
+		defer close(sendingDone)
+
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Millisecond):
+			}
+
+			for rid, writer := range writers {
+				pkt := &rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 96},
+					Payload: []byte{0x00, rid[0]},
+				}
+				assert.NoError(t, pkt.Header.SetExtension(midID, []byte(transceiver.Mid())))
+				assert.NoError(t, pkt.Header.SetExtension(ridID, []byte(rid)))
+				assert.NoError(t, writer.WriteRTP(pkt))
+			}
+		}
+	}()
+
+	// Every layer arrives with the SSRC of its encoding and the media of its track
+	for range rids {
+		track := <-remoteTracks
+		pkt, _, readErr := track.ReadRTP()
+		require.NoError(t, readErr)
+		assert.Equal(t, ssrcs[track.RID()], track.SSRC())
+		assert.Equal(t, []byte{0x00, track.RID()[0]}, pkt.Payload)
+	}
+
+	close(done)
+	<-sendingDone
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_ExplicitSSRC(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	plain, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "plain", "pion", WithSSRC(1111), WithRTXSSRC(1112),
+	)
+	require.NoError(t, err)
+	plainSender, err := pcOffer.AddTrack(plain)
+	require.NoError(t, err)
+
+	var layers []*TrackLocalStaticRTP
+	for i, rid := range []string{"a", "b"} {
+		layer, layerErr := NewTrackLocalStaticRTP(
+			RTPCodecCapability{MimeType: MimeTypeVP8}, "simulcast", "pion",
+			WithRTPStreamID(rid), WithSSRC(SSRC(2221+i)),
+		)
+		require.NoError(t, layerErr)
+		layers = append(layers, layer)
+	}
+	simulcastSender, err := pcOffer.AddTrack(layers[0])
+	require.NoError(t, err)
+	require.NoError(t, simulcastSender.AddEncoding(layers[1]))
+
+	parameters := plainSender.GetParameters()
+	assert.Equal(t, SSRC(1111), parameters.Encodings[0].SSRC)
+	assert.Equal(t, SSRC(1112), parameters.Encodings[0].RTX.SSRC)
+	parameters = simulcastSender.GetParameters()
+	assert.Equal(t, SSRC(2221), parameters.Encodings[0].SSRC)
+	assert.Equal(t, SSRC(2222), parameters.Encodings[1].SSRC)
+	assert.NotZero(t, parameters.Encodings[1].RTX.SSRC)
+
+	var midID, ridID uint8
+	for _, extension := range parameters.HeaderExtensions {
+		switch extension.URI {
+		case sdp.SDESMidURI:
+			midID = uint8(extension.ID) //nolint:gosec // G115
+		case sdp.SDESRTPStreamIDURI:
+			ridID = uint8(extension.ID) //nolint:gosec // G115
+		}
+	}
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	for _, line := range []string{
+		"a=ssrc:1111 ", "a=ssrc:1112 ", "a=ssrc-group:FID 1111 1112", "a=ssrc:2221 ", "a=ssrc:2222 ",
+	} {
+		assert.Contains(t, offer.SDP, line)
+	}
+
+	// The remote can't send with an SSRC of the senders
+	pcConflict, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	conflicting, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithSSRC(2222),
+	)
+	require.NoError(t, err)
+	_, err = pcConflict.AddTrack(conflicting)
+	require.NoError(t, err)
+	conflictOffer, err := pcConflict.CreateOffer(nil)
+	require.NoError(t, err)
+	assert.ErrorIs(t, pcOffer.SetRemoteDescription(conflictOffer), ErrSSRCConflict)
+	assert.NoError(t, pcConflict.Close())
+
+	// The packets are sent with the SSRCs
+	ssrcs := make(chan SSRC, 3)
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		pkt, _, readErr := track.ReadRTP()
+		if assert.NoError(t, readErr) {
+			assert.Equal(t, uint32(track.SSRC()), pkt.SSRC)
+		}
+		ssrcs <- track.SSRC()
+	})
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	received := map[SSRC]bool{}
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for sequenceNumber := uint16(0); len(received) < 3; sequenceNumber++ {
+		select {
+		case ssrc := <-ssrcs:
+			received[ssrc] = true
+		case <-ticker.C:
+		}
+
+		assert.NoError(t, plain.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 96},
+			Payload: []byte{0x00},
+		}))
+		for _, layer := range layers {
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 96},
+				Payload: []byte{0x00},
+			}
+			assert.NoError(t, pkt.Header.SetExtension(midID, []byte("1")))
+			assert.NoError(t, pkt.Header.SetExtension(ridID, []byte(layer.RID())))
+			assert.NoError(t, layer.WriteRTP(pkt))
+		}
+	}
+	assert.Equal(t, map[SSRC]bool{1111: true, 2221: true, 2222: true}, received)
+
+	// SSRCs must be unique within the PeerConnection
+	duplicate, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion", WithSSRC(1112),
+	)
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(duplicate)
+	require.NoError(t, err)
+	_, err = pcOffer.CreateOffer(nil)
+	assert.ErrorIs(t, err, ErrSSRCConflict)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_Simulcast_RTX(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	rids := []string{"a", "b"}
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	vp8WriterAStatic, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion2", WithRTPStreamID(rids[0]),
+	)
+	assert.NoError(t, err)
+
+	vp8WriterBStatic, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion2", WithRTPStreamID(rids[1]),
+	)
+	assert.NoError(t, err)
+
+	vp8WriterA, vp8WriterB := &simulcastTestTrackLocal{vp8WriterAStatic}, &simulcastTestTrackLocal{vp8WriterBStatic}
+
+	sender, err := pcOffer.AddTrack(vp8WriterA)
+	assert.NoError(t, err)
+	assert.NotNil(t, sender)
+
+	assert.NoError(t, sender.AddEncoding(vp8WriterB))
+
+	var ridMapLock sync.RWMutex
+	ridMap := map[string]int{}
+
+	assertRidCorrect := func(t *testing.T) {
+		t.Helper()
+
+		ridMapLock.Lock()
+		defer ridMapLock.Unlock()
+
+		for _, rid := range rids {
+			assert.Equal(t, ridMap[rid], 1)
+		}
+		assert.Equal(t, len(ridMap), 2)
+	}
+
+	ridsFullfilled := func() bool {
+		ridMapLock.Lock()
+		defer ridMapLock.Unlock()
+
+		ridCount := len(ridMap)
+
+		return ridCount == 2
+	}
+
+	var rtxPacketRead atomic.Int32
 	var wg sync.WaitGroup
 	wg.Add(2)
 
@@ -2751,3 +3704,755 @@ func TestPeerConnection_Simulcast_Probe_PacketLoss(t *testing.T) { //nolint:cycl
 	assert.NoError(t, wan.Stop())
 	closePairNow(t, pcOffer, pcAnswer)
 }
+
+func TestPeerConnection_PaddingStats(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	paddingSizes := make(chan any, 100)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		for {
+			pkt, attributes, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			assert.NotEmpty(t, pkt.Payload, "padding only packet returned by ReadRTP")
+
+			select {
+			case paddingSizes <- attributes.Get(AttributePaddingSize):
+			default:
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// Media with padding, until the answerer reads it.
+	func() {
+		for {
+			require.NoError(t, track.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, Padding: true, PaddingSize: 10},
+				Payload: []byte{0x10, 0x00},
+			}))
+
+			select {
+			case paddingSize := <-paddingSizes:
+				assert.Equal(t, uint8(10), paddingSize)
+
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+	}()
+
+	findOutboundStats := func() OutboundRTPStreamStats {
+		for _, s := range pcOffer.GetStats() {
+			if stats, ok := s.(OutboundRTPStreamStats); ok && stats.SSRC == sender.trackEncodings[0].ssrc {
+				return stats
+			}
+		}
+
+		return OutboundRTPStreamStats{}
+	}
+	findInboundStats := func() InboundRTPStreamStats {
+		inbound := findInboundRTPStatsBySSRC(pcAnswer.GetStats(), sender.trackEncodings[0].ssrc)
+		require.Len(t, inbound, 1)
+
+		return inbound[0]
+	}
+
+	outboundBefore, inboundBefore := findOutboundStats(), findInboundStats()
+	assert.NotZero(t, outboundBefore.PaddingBytesSent)
+	assert.Zero(t, outboundBefore.PaddingPacketsSent)
+	assert.NotZero(t, inboundBefore.PaddingBytesReceived)
+	assert.Zero(t, inboundBefore.PaddingPacketsReceived)
+
+	// Padding only probes on the RTX stream.
+	track.mu.RLock()
+	binding := track.bindings[0]
+	track.mu.RUnlock()
+	require.NotZero(t, binding.ssrcRTX)
+
+	const probes = 5
+	for i := range probes {
+		_, err = binding.writeStream.WriteRTP(&rtp.Header{
+			Version:        2,
+			Padding:        true,
+			PaddingSize:    255,
+			SequenceNumber: uint16(i), //nolint:gosec
+			SSRC:           uint32(binding.ssrcRTX),
+			PayloadType:    uint8(binding.payloadTypeRTX),
+		}, nil)
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		return findInboundStats().PaddingPacketsReceived == probes
+	}, 5*time.Second, 20*time.Millisecond)
+
+	outbound, inbound := findOutboundStats(), findInboundStats()
+	assert.Equal(t, uint64(probes), outbound.PaddingPacketsSent)
+	assert.Equal(t, outboundBefore.PaddingBytesSent+probes*255, outbound.PaddingBytesSent)
+	assert.Equal(t, outboundBefore.PacketsSent, outbound.PacketsSent)
+	assert.Equal(t, outboundBefore.BytesSent, outbound.BytesSent)
+	assert.Equal(t, inboundBefore.PaddingBytesReceived+probes*255, inbound.PaddingBytesReceived)
+	assert.Equal(t, inboundBefore.PacketsReceived, inbound.PacketsReceived)
+	assert.Equal(t, inboundBefore.BytesReceived, inbound.BytesReceived)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+// Assert that more than 14 header extensions are negotiated with two-byte IDs, and that
+// packets are sent with two-byte header extensions so every negotiated ID can be used.
+func TestPeerConnection_TwoByteHeaderExtensions(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newPCWithExtensions := func() *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		for i := 1; i <= 16; i++ {
+			require.NoError(t, mediaEngine.RegisterHeaderExtension(
+				RTPHeaderExtensionCapability{URI: fmt.Sprintf("urn:pion:test:extension-%d", i)}, RTPCodecTypeVideo,
+			))
+		}
+
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+	pcOffer, pcAnswer := newPCWithExtensions(), newPCWithExtensions()
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	assert.Contains(t, offer.SDP, "a=extmap-allow-mixed\r\n")
+	assert.Contains(t, offer.SDP, "a=extmap:15 ")
+	assert.Contains(t, offer.SDP, "a=extmap:16 ")
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// The default interceptors negotiate more extensions, which all have to get IDs.
+	headerExtensions := []RTPHeaderExtensionParameter{}
+	lowID := uint8(maxTwoByteHeaderExtensionID)
+	for _, ext := range sender.GetParameters().HeaderExtensions {
+		if strings.HasPrefix(ext.URI, "urn:pion:test:") {
+			headerExtensions = append(headerExtensions, ext)
+		}
+		lowID = min(lowID, uint8(ext.ID))
+	}
+	require.Len(t, headerExtensions, 16)
+	require.Len(t, sender.GetParameters().HeaderExtensions, 20)
+	extensionValue := func(id uint8) []byte {
+		return bytes.Repeat([]byte{id}, int(id)+4)
+	}
+
+	// A packet with a one-byte extension has to be converted, the other one uses every negotiated ID.
+	oneByte := &rtp.Packet{Header: rtp.Header{Version: 2}, Payload: []byte{0x00}}
+	require.NoError(t, oneByte.Header.SetExtension(lowID, []byte{0xAA}))
+	require.Equal(t, uint16(rtp.ExtensionProfileOneByte), oneByte.Header.ExtensionProfile)
+	allIDs := &rtp.Packet{Header: rtp.Header{Version: 2, Marker: true}, Payload: []byte{0x00}}
+	for _, ext := range headerExtensions {
+		require.NoError(t, allIDs.Header.SetExtensionWithProfile(
+			uint8(ext.ID), extensionValue(uint8(ext.ID)), rtp.ExtensionProfileTwoByte,
+		))
+	}
+
+	receivedOneByte, receivedAllIDs := make(chan struct{}), make(chan struct{})
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		var oneByteOnce, allIDsOnce sync.Once
+		for {
+			pkt, _, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			assert.Equal(t, uint16(rtp.ExtensionProfileTwoByte), pkt.Header.ExtensionProfile)
+
+			if !pkt.Header.Marker {
+				assert.Equal(t, []byte{0xAA}, pkt.Header.GetExtension(lowID))
+				oneByteOnce.Do(func() { close(receivedOneByte) })
+
+				continue
+			}
+			for _, ext := range headerExtensions {
+				assert.Equal(t, extensionValue(uint8(ext.ID)), pkt.Header.GetExtension(uint8(ext.ID)))
+			}
+			allIDsOnce.Do(func() { close(receivedAllIDs) })
+		}
+	})
+
+	func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+
+		var sequenceNumber uint16
+		for range ticker.C {
+			select {
+			case <-receivedOneByte:
+				select {
+				case <-receivedAllIDs:
+					return
+				default:
+				}
+			default:
+			}
+
+			for _, pkt := range []*rtp.Packet{oneByte, allIDs} {
+				sequenceNumber++
+				pkt.SequenceNumber = sequenceNumber
+				raw, marshalErr := pkt.Marshal()
+				require.NoError(t, marshalErr)
+				_, writeErr := track.Write(raw)
+				require.NoError(t, writeErr)
+			}
+		}
+	}()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+// Header extensions registered for one direction are offered with it in their extmap, and
+// the answerer only uses them in the other direction.
+func TestPeerConnection_HeaderExtensionDirections(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		recvURI = "urn:pion:test:recv"
+		sendURI = "urn:pion:test:send"
+		bothURI = "urn:pion:test:both"
+	)
+	newPC := func(register func(*MediaEngine)) *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		register(mediaEngine)
+
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+		_, err = pc.AddTransceiverFromKind(RTPCodecTypeVideo)
+		require.NoError(t, err)
+
+		return pc
+	}
+	pcOffer := newPC(func(m *MediaEngine) {
+		require.NoError(t, m.RegisterHeaderExtension(
+			RTPHeaderExtensionCapability{URI: recvURI}, RTPCodecTypeVideo, RTPTransceiverDirectionRecvonly,
+		))
+		require.NoError(t, m.RegisterHeaderExtension(
+			RTPHeaderExtensionCapability{URI: sendURI}, RTPCodecTypeVideo, RTPTransceiverDirectionSendonly,
+		))
+		require.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: bothURI}, RTPCodecTypeVideo))
+	})
+	pcAnswer := newPC(func(m *MediaEngine) {
+		for _, uri := range []string{recvURI, sendURI, bothURI} {
+			require.NoError(t, m.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: uri}, RTPCodecTypeVideo))
+		}
+	})
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	assert.Regexp(t, `a=extmap:\d+/recvonly `+recvURI+"\r\n", offer.SDP)
+	assert.Regexp(t, `a=extmap:\d+/sendonly `+sendURI+"\r\n", offer.SDP)
+	assert.Regexp(t, `a=extmap:\d+ `+bothURI+"\r\n", offer.SDP)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	require.NoError(t, err)
+	assert.Regexp(t, `a=extmap:\d+/sendonly `+recvURI+"\r\n", answer.SDP)
+	assert.Regexp(t, `a=extmap:\d+/recvonly `+sendURI+"\r\n", answer.SDP)
+	assert.Regexp(t, `a=extmap:\d+ `+bothURI+"\r\n", answer.SDP)
+	require.NoError(t, pcAnswer.SetLocalDescription(answer))
+	require.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	testURIs := func(extensions []RTPHeaderExtensionParameter) []string {
+		uris := []string{}
+		for _, extension := range extensions {
+			if strings.HasPrefix(extension.URI, "urn:pion:test:") {
+				uris = append(uris, extension.URI)
+			}
+		}
+
+		return uris
+	}
+	offerTransceiver, answerTransceiver := pcOffer.GetTransceivers()[0], pcAnswer.GetTransceivers()[0]
+	assert.ElementsMatch(
+		t, []string{sendURI, bothURI}, testURIs(offerTransceiver.Sender().GetParameters().HeaderExtensions),
+	)
+	assert.ElementsMatch(
+		t, []string{recvURI, bothURI}, testURIs(offerTransceiver.Receiver().GetParameters().HeaderExtensions),
+	)
+	assert.ElementsMatch(
+		t, []string{recvURI, bothURI}, testURIs(answerTransceiver.Sender().GetParameters().HeaderExtensions),
+	)
+	assert.ElementsMatch(
+		t, []string{sendURI, bothURI}, testURIs(answerTransceiver.Receiver().GetParameters().HeaderExtensions),
+	)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+// Endpoints can negotiate one RTX payload type per H264 profile, the retransmissions
+// on any of them repair the track while the ones of other codecs are dropped.
+func TestPeerConnection_RTX_MultiplePayloadTypes(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newMediaEngine := func() *MediaEngine {
+		mediaEngine := &MediaEngine{}
+		for _, codec := range []RTPCodecParameters{
+			{
+				RTPCodecCapability: RTPCodecCapability{
+					MimeTypeH264, 90000, 0, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", nil,
+				},
+				PayloadType: 102,
+			},
+			{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=102", nil}, PayloadType: 103},
+			{
+				RTPCodecCapability: RTPCodecCapability{
+					MimeTypeH264, 90000, 0, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=640032", nil,
+				},
+				PayloadType: 104,
+			},
+			{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=104", nil}, PayloadType: 105},
+			{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 96},
+			{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=96", nil}, PayloadType: 97},
+		} {
+			assert.NoError(t, mediaEngine.RegisterCodec(codec, RTPCodecTypeVideo))
+		}
+
+		return mediaEngine
+	}
+
+	pcOffer, err := NewAPI(WithMediaEngine(newMediaEngine())).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithMediaEngine(newMediaEngine())).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	trackStatic, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeH264}, "video", "pion")
+	assert.NoError(t, err)
+	track := &simulcastTestTrackLocal{trackStatic}
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	onTrack := make(chan struct{})
+	type repairedPacket struct {
+		rtxPayloadType uint8
+		packet         *rtp.Packet
+	}
+	repaired := make(chan repairedPacket, 10)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		close(onTrack)
+
+		for {
+			packet, attributes, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			if rtxPayloadType, ok := attributes.Get(AttributeRtxPayloadType).(byte); ok {
+				repaired <- repairedPacket{rtxPayloadType, packet}
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	encoding := sender.GetParameters().Encodings[0]
+	assert.NotZero(t, encoding.RTX.SSRC)
+
+	// RTX packets are read along the packets of the track, keep it flowing
+	done := make(chan struct{})
+	sendingDone := make(chan struct{})
+	go func() {
+		defer close(sendingDone)
+
+		for sequenceNumber := uint16(0); ; sequenceNumber++ {
+			select {
+			case <-done:
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 102, SSRC: uint32(encoding.SSRC)},
+				Payload: []byte{0x00},
+			}))
+		}
+	}()
+	<-onTrack
+
+	// The payload of RTX packets starts with the original sequence number
+	for i, rtxPayloadType := range []uint8{103, 105, 97, 103} {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header: rtp.Header{
+				Version: 2, SequenceNumber: uint16(i), PayloadType: rtxPayloadType, SSRC: uint32(encoding.RTX.SSRC), //nolint:gosec
+			},
+			Payload: []byte{0x03, byte(0xe8 + i), 0x00},
+		}))
+	}
+
+	for _, expected := range []struct {
+		rtxPayloadType uint8
+		sequenceNumber uint16
+	}{{103, 1000}, {105, 1001}, {103, 1003}} {
+		got := <-repaired
+		assert.Equal(t, expected.rtxPayloadType, got.rtxPayloadType)
+		assert.Equal(t, uint8(102), got.packet.PayloadType)
+		assert.Equal(t, expected.sequenceNumber, got.packet.SequenceNumber)
+		assert.Equal(t, uint32(encoding.SSRC), got.packet.SSRC)
+	}
+
+	close(done)
+	<-sendingDone
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+// Assert that the answer and the sent RTP use the payload types of the offer, also when
+// the codec preferences of the answerer have others.
+func TestPeerConnection_Answer_RemotePayloadTypes(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	for _, withPreferences := range []bool{false, true} {
+		t.Run(fmt.Sprintf("CodecPreferences=%t", withPreferences), func(t *testing.T) {
+			// VP8 and its RTX on 127 and 126, H264 on the 96 of VP8 in the default codecs
+			mediaEngine := &MediaEngine{}
+			for _, codec := range []RTPCodecParameters{
+				{
+					RTPCodecCapability: RTPCodecCapability{
+						MimeTypeH264, 90000, 0, "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f", nil,
+					},
+					PayloadType: 96,
+				},
+				{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 127},
+				{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=127", nil}, PayloadType: 126},
+			} {
+				require.NoError(t, mediaEngine.RegisterCodec(codec, RTPCodecTypeVideo))
+			}
+
+			pcOffer, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+			require.NoError(t, err)
+			pcAnswer, err := NewPeerConnection(Configuration{})
+			require.NoError(t, err)
+
+			_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+				Direction: RTPTransceiverDirectionRecvonly,
+			})
+			require.NoError(t, err)
+
+			track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+			require.NoError(t, err)
+			transceiver, err := pcAnswer.AddTransceiverFromTrack(track)
+			require.NoError(t, err)
+			if withPreferences {
+				// The default payload types, VP8 on 96 and its RTX on 97
+				require.NoError(t, transceiver.SetCodecPreferences([]RTPCodecParameters{
+					{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 96},
+					{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=96", nil}, PayloadType: 97},
+				}))
+			}
+
+			onTrack, onTrackFunc := context.WithCancel(context.Background())
+			pcOffer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+				pkt, _, readErr := track.ReadRTP()
+				assert.NoError(t, readErr)
+				assert.Equal(t, uint8(127), pkt.PayloadType)
+				assert.Equal(t, PayloadType(127), track.PayloadType())
+				assert.Equal(t, MimeTypeVP8, track.Codec().MimeType)
+
+				onTrackFunc()
+			})
+
+			require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+			answer := pcAnswer.LocalDescription().SDP
+			assert.Contains(t, answer, "a=rtpmap:127 VP8/90000")
+			assert.Contains(t, answer, "a=rtpmap:126 rtx/90000")
+			assert.Contains(t, answer, "a=fmtp:126 apt=127")
+			assert.NotContains(t, answer, "a=rtpmap:96 VP8/90000")
+			assert.NotContains(t, answer, "apt=96")
+
+			codecs := transceiver.Sender().GetParameters().Codecs
+			vp8 := slices.IndexFunc(codecs, func(c RTPCodecParameters) bool { return c.MimeType == MimeTypeVP8 })
+			require.NotEqual(t, -1, vp8)
+			assert.Equal(t, PayloadType(127), codecs[vp8].PayloadType)
+
+			sendVideoUntilDone(t, onTrack.Done(), []*TrackLocalStaticSample{track})
+
+			closePairNow(t, pcOffer, pcAnswer)
+		})
+	}
+}
+
+func TestPeerConnection_RTCPReducedSize(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	for _, reducedSize := range []bool{true, false} {
+		t.Run(fmt.Sprintf("ReducedSize=%t", reducedSize), func(t *testing.T) {
+			// Only the offerer disables it, the answerer doesn't accept what isn't offered
+			offerMediaEngine := &MediaEngine{}
+			require.NoError(t, offerMediaEngine.RegisterDefaultCodecs())
+			offerMediaEngine.SetRTCPReducedSize(reducedSize)
+
+			pcOffer, err := NewAPI(WithMediaEngine(offerMediaEngine)).NewPeerConnection(Configuration{})
+			require.NoError(t, err)
+			pcAnswer, err := NewPeerConnection(Configuration{})
+			require.NoError(t, err)
+
+			track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+			require.NoError(t, err)
+			sender, err := pcOffer.AddTrack(track)
+			require.NoError(t, err)
+
+			onTrack := make(chan *TrackRemote, 1)
+			pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+				onTrack <- trackRemote
+			})
+
+			require.NoError(t, signalPair(pcOffer, pcAnswer))
+			assert.Equal(t, reducedSize, strings.Contains(pcOffer.LocalDescription().SDP, "a=rtcp-rsize"))
+			assert.Equal(t, reducedSize, strings.Contains(pcAnswer.LocalDescription().SDP, "a=rtcp-rsize"))
+			assert.Equal(t, reducedSize, sender.GetParameters().RTCP.ReducedSize)
+			assert.Equal(t, reducedSize, pcAnswer.GetTransceivers()[0].Receiver().GetParameters().RTCP.ReducedSize)
+
+			done := make(chan struct{})
+			sent := make(chan struct{})
+			go func() {
+				sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+				close(sent)
+			}()
+			trackRemote := <-onTrack
+			close(done)
+			<-sent
+
+			// A bare PLI arrives as is, or led by a receiver report and the CNAME of its sender
+			pli := &rtcp.PictureLossIndication{MediaSSRC: uint32(trackRemote.SSRC())}
+			require.NoError(t, pcAnswer.WriteRTCP([]rtcp.Packet{pli}))
+
+			for {
+				pkts, _, readErr := sender.ReadRTCP()
+				require.NoError(t, readErr)
+
+				idx := slices.IndexFunc(pkts, func(pkt rtcp.Packet) bool {
+					_, ok := pkt.(*rtcp.PictureLossIndication)
+
+					return ok
+				})
+				if idx == -1 {
+					continue
+				}
+
+				if reducedSize {
+					assert.Len(t, pkts, 1)
+				} else {
+					require.Len(t, pkts, 3)
+					rr, ok := pkts[0].(*rtcp.ReceiverReport)
+					require.True(t, ok)
+					assert.NotZero(t, rr.SSRC)
+					sdes, ok := pkts[1].(*rtcp.SourceDescription)
+					require.True(t, ok)
+					require.Len(t, sdes.Chunks, 1)
+					assert.Equal(t, rr.SSRC, sdes.Chunks[0].Source)
+					assert.Equal(t, rtcp.SDESCNAME, sdes.Chunks[0].Items[0].Type)
+					assert.NotEmpty(t, sdes.Chunks[0].Items[0].Text)
+				}
+
+				break
+			}
+
+			closePairNow(t, pcOffer, pcAnswer)
+		})
+	}
+}
+
+func TestPeerConnection_RecvonlySSRC(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetCompatibilityProfile(CompatibilityProfile{RecvonlySSRC: true})
+
+	pcOffer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTransceiverFromTrack(track, RTPTransceiverInit{
+		Direction: RTPTransceiverDirectionSendonly,
+	})
+	require.NoError(t, err)
+
+	onTrack := make(chan *TrackRemote, 1)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		onTrack <- trackRemote
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	recvonlySSRC, _, err := pcAnswer.GetTransceivers()[0].getRecvonlySource()
+	require.NoError(t, err)
+	assert.Contains(t, pcAnswer.LocalDescription().SDP, fmt.Sprintf("a=ssrc:%d ", recvonlySSRC))
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+		close(sent)
+	}()
+	trackRemote := <-onTrack
+
+	pli := &rtcp.PictureLossIndication{MediaSSRC: uint32(trackRemote.SSRC())}
+	require.NoError(t, pcAnswer.WriteRTCP([]rtcp.Packet{pli}))
+
+	// The feedback and the receiver reports are sent from the announced SSRC
+	var havePLI, haveReport bool
+	for !havePLI || !haveReport {
+		pkts, _, readErr := sender.Sender().ReadRTCP()
+		require.NoError(t, readErr)
+
+		for _, pkt := range pkts {
+			switch pkt := pkt.(type) {
+			case *rtcp.PictureLossIndication:
+				assert.Equal(t, uint32(recvonlySSRC), pkt.SenderSSRC)
+				havePLI = true
+			case *rtcp.ReceiverReport:
+				if len(pkt.Reports) > 0 {
+					assert.Equal(t, uint32(recvonlySSRC), pkt.SSRC)
+					haveReport = true
+				}
+			}
+		}
+	}
+	assert.Equal(t, uint32(0), pli.SenderSSRC, "the written packet is not modified")
+
+	close(done)
+	<-sent
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestPeerConnection_RTCPMaxPacketSize(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const maxSize, reporterSSRC = 400, 0xBEEF
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetRTCPMaxPacketSize(maxSize)
+
+	pcOffer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	onTrack := make(chan *TrackRemote, 1)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		onTrack <- trackRemote
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+		close(sent)
+	}()
+	trackRemote := <-onTrack
+	close(done)
+	<-sent
+
+	// A report about 40 receive streams doesn't fit in a single packet
+	reports := make([]rtcp.ReceptionReport, 40)
+	for i := range reports {
+		reports[i] = rtcp.ReceptionReport{SSRC: uint32(trackRemote.SSRC()), LastSequenceNumber: uint32(i)} //nolint:gosec
+	}
+	require.NoError(t, pcAnswer.WriteRTCP([]rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: reporterSSRC, Reports: reports},
+		&rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{
+			Source: reporterSSRC,
+			Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: "pion"}},
+		}}},
+	}))
+
+	// The datagrams are at most maxSize bytes once protected
+	overhead := int(pcAnswer.dtlsTransport.srtcpOverhead.Load())
+	require.NotZero(t, overhead)
+
+	var received []rtcp.ReceptionReport
+	datagrams := 0
+	buf := make([]byte, receiveMTU)
+	for len(received) < len(reports) {
+		n, _, readErr := sender.Read(buf)
+		require.NoError(t, readErr)
+
+		pkts, readErr := rtcp.Unmarshal(buf[:n])
+		require.NoError(t, readErr)
+		if rr, ok := pkts[0].(*rtcp.ReceiverReport); !ok || rr.SSRC != reporterSSRC {
+			continue
+		}
+
+		assert.LessOrEqual(t, n+overhead, maxSize)
+		received = append(received, pkts[0].(*rtcp.ReceiverReport).Reports...) //nolint:forcetypeassert
+		datagrams++
+	}
+	assert.Equal(t, reports, received)
+	assert.Greater(t, datagrams, 1)
+
+	transportStats, ok := pcAnswer.GetStats()["iceTransport"].(TransportStats)
+	require.True(t, ok)
+	assert.Equal(t, uint64(1), transportStats.RTCPPacketsSplit)
+
+	// A single packet that can't be split is refused
+	nack := &rtcp.TransportLayerNack{MediaSSRC: uint32(trackRemote.SSRC())}
+	for i := range 200 {
+		nack.Nacks = append(nack.Nacks, rtcp.NackPair{PacketID: uint16(i * 20)}) //nolint:gosec
+	}
+	assert.ErrorIs(t, pcAnswer.WriteRTCP([]rtcp.Packet{nack}), ErrRTCPPacketTooLarge)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"errors"
+
+	"github.com/pion/interceptor"
+)
+
+// peerConnectionOptions contains the options of a single PeerConnection.
+type peerConnectionOptions struct {
+	disableInterceptors bool
+	interceptors        []interceptor.Factory
+}
+
+// PeerConnectionOption configures a PeerConnection created with NewPeerConnectionWithOptions.
+type PeerConnectionOption func(*peerConnectionOptions)
+
+// WithoutInterceptors creates the PeerConnection with a no-op interceptor chain, regardless of
+// the interceptor.Registry of the API, only the ones added with WithInterceptors are kept. RTP
+// and RTCP are handed between the tracks and the SRTP streams directly, which lowers the per
+// packet cost for applications that relay raw RTP and handle the feedback themselves.
+//
+// Nothing the interceptors provide is available then: no Sender or Receiver Reports are sent,
+// NACKs are neither generated nor answered, no TWCC feedback is sent and the RTP stream stats
+// of GetStats stay empty. The feedback is still negotiated as configured by the MediaEngine, so
+// the remote may expect the application to answer it.
+func WithoutInterceptors() PeerConnectionOption {
+	return func(o *peerConnectionOptions) {
+		o.disableInterceptors = true
+	}
+}
+
+// WithInterceptors adds interceptors to the PeerConnection only, so connections created from
+// the same API can use different interceptors without a MediaEngine or interceptor.Registry
+// of their own. The interceptors are built with the id of the PeerConnection, in the given
+// order, after the ones of the interceptor.Registry of the API. Combined with
+// WithoutInterceptors, only these interceptors are used.
+//
+// Being later in the chain, they see outgoing RTP and RTCP before the interceptors of the
+// registry and incoming RTP and RTCP after them. With the default interceptors this means
+// that a packet written by them is the one the NACK responder keeps for retransmissions,
+// retransmissions and the RTCP reports and feedback generated by the registry don't go
+// through them, and incoming packets have already been recorded for NACKs, TWCC feedback
+// and the stats when they read them.
+func WithInterceptors(factories ...interceptor.Factory) PeerConnectionOption {
+	return func(o *peerConnectionOptions) {
+		o.interceptors = append(o.interceptors, factories...)
+	}
+}
+
+// buildInterceptor builds the interceptor chain of the PeerConnection id.
+func (api *API) buildInterceptor(id string, options []PeerConnectionOption) (interceptor.Interceptor, error) {
+	var pcOptions peerConnectionOptions
+	for _, option := range options {
+		option(&pcOptions)
+	}
+
+	extra := &interceptor.Registry{}
+	for _, factory := range pcOptions.interceptors {
+		extra.Add(factory)
+	}
+
+	if pcOptions.disableInterceptors {
+		return extra.Build(id)
+	}
+
+	built, err := api.interceptorRegistry.Build(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(pcOptions.interceptors) != 0 {
+		extraBuilt, extraErr := extra.Build(id)
+		if extraErr != nil {
+			return nil, errors.Join(extraErr, built.Close())
+		}
+		built = interceptor.NewChain([]interceptor.Interceptor{built, extraBuilt})
+	}
+
+	// Opt-in, see SettingEngine.EnableRTCPReportBatching for what it costs
+	if !api.settingEngine.enableRTCPReportBatching {
+		return built, nil
+	}
+
+	scheduler := newRTCPReportScheduler(
+		api.settingEngine.rtcpReportInterval,
+		api.settingEngine.getRTCPMaxBatchSize(),
+		api.settingEngine.LoggerFactory,
+	)
+
+	return interceptor.NewChain([]interceptor.Interceptor{scheduler, built}), nil
+}
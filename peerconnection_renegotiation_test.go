@@ -10,6 +10,7 @@ import (
 	"context"
 	"errors"
 	"io"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -18,6 +19,7 @@ import (
 	"time"
 
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v4/test"
 	"github.com/pion/webrtc/v4/internal/util"
 	"github.com/pion/webrtc/v4/pkg/media"
@@ -199,12 +201,12 @@ func TestPeerConnection_Renegotiation_AddTrack(t *testing.T) {
 
 	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
 
-	pcOffer.ops.Done()
+	assert.NoError(t, pcOffer.WaitForPendingOperations(context.Background()))
 	assert.Equal(t, 0, len(vp8Track.rtpTrack.bindings))
 
 	assert.NoError(t, pcOffer.SetRemoteDescription(answer))
 
-	pcOffer.ops.Done()
+	assert.NoError(t, pcOffer.WaitForPendingOperations(context.Background()))
 	assert.Equal(t, 1, len(vp8Track.rtpTrack.bindings))
 
 	sendVideoUntilDone(t, onTrackFired.Done(), []*TrackLocalStaticSample{vp8Track})
@@ -585,6 +587,158 @@ func TestPeerConnection_Renegotiation_RemoveTrack(t *testing.T) {
 	closePairNow(t, pcOffer, pcAnswer)
 }
 
+func TestPeerConnection_Renegotiation_RejectedMediaSection(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	vp8Track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "foo", "bar")
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(vp8Track)
+	assert.NoError(t, err)
+
+	onTrackFired, onTrackFiredFunc := context.WithCancel(context.Background())
+	trackClosed, trackClosedFunc := context.WithCancel(context.Background())
+
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		onTrackFiredFunc()
+
+		for {
+			if _, _, err := track.ReadRTP(); errors.Is(err, io.EOF) {
+				trackClosedFunc()
+
+				return
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	sendVideoUntilDone(t, onTrackFired.Done(), []*TrackLocalStaticSample{vp8Track})
+
+	// Re-offer with the video section rejected, like a browser does for a stopped transceiver
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	parsed, err := offer.Unmarshal()
+	assert.NoError(t, err)
+	videoIndex := slices.IndexFunc(parsed.MediaDescriptions, func(m *sdp.MediaDescription) bool {
+		return m.MediaName.Media == RTPCodecTypeVideo.String()
+	})
+	require.NotEqual(t, -1, videoIndex)
+	parsed.MediaDescriptions[videoIndex].MediaName.Port = sdp.RangedPort{Value: 0}
+	rejectedOffer, err := parsed.Marshal()
+	assert.NoError(t, err)
+
+	assert.NoError(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeOffer, SDP: string(rejectedOffer)}))
+	<-trackClosed.Done()
+
+	transceivers := pcAnswer.GetTransceivers()
+	require.Len(t, transceivers, 1)
+	assert.True(t, transceivers[0].stopped.Load())
+	assert.Equal(t, RTPTransceiverDirectionInactive, transceivers[0].Direction())
+
+	assertPlaceholder := func(desc SessionDescription) {
+		t.Helper()
+
+		parsed, err := desc.Unmarshal()
+		assert.NoError(t, err)
+		require.Len(t, parsed.MediaDescriptions, 2)
+
+		video := parsed.MediaDescriptions[videoIndex]
+		assert.Equal(t, RTPCodecTypeVideo.String(), video.MediaName.Media)
+		assert.Equal(t, 0, video.MediaName.Port.Value)
+		assert.Empty(t, video.Attributes)
+
+		application := parsed.MediaDescriptions[1-videoIndex]
+		assert.Equal(t, mediaSectionApplication, application.MediaName.Media)
+		assert.NotEqual(t, 0, application.MediaName.Port.Value)
+	}
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assertPlaceholder(answer)
+	assert.NoError(t, pcAnswer.SetLocalDescription(answer))
+
+	// Subsequent offers keep the rejected section to preserve the order of the media sections
+	nextOffer, err := pcAnswer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assertPlaceholder(nextOffer)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+// GetTransceivers follows the media sections of the current local description, not the order the
+// transceivers were created in, and appends the ones that weren't negotiated yet.
+func TestPeerConnection_Renegotiation_TransceiverOrder(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	mids := func(transceivers []*RTPTransceiver) (mids []string) {
+		for _, transceiver := range transceivers {
+			mids = append(mids, transceiver.Mid())
+		}
+
+		return mids
+	}
+
+	// The answerer creates its camera transceiver before the audio section of the offer
+	camera, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "camera", "camera")
+	require.NoError(t, err)
+	cameraSender, err := pcAnswer.AddTrack(camera)
+	require.NoError(t, err)
+
+	offerAudio, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	require.NoError(t, err)
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+		Direction: RTPTransceiverDirectionRecvonly,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	assert.Equal(t, []string{"0", "1"}, mids(pcOffer.GetTransceivers()))
+	assert.Equal(t, []string{"0", "1"}, mids(pcAnswer.GetTransceivers()))
+	cameraTransceiver := pcAnswer.GetTransceiverByMid("1")
+	require.NotNil(t, cameraTransceiver)
+	assert.Equal(t, cameraSender, cameraTransceiver.Sender())
+	assert.Equal(t, RTPTransceiverDirectionSendonly, cameraTransceiver.CurrentDirection())
+
+	// Reject the audio section and add a screen share section, the answerer adds an unnegotiated transceiver
+	require.NoError(t, offerAudio.Stop())
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo, RTPTransceiverInit{
+		Direction: RTPTransceiverDirectionRecvonly,
+	})
+	require.NoError(t, err)
+	microphone, err := pcAnswer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	require.NoError(t, err)
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	assert.Equal(t, []string{"0", "1", "3"}, mids(pcOffer.GetTransceivers()))
+	assert.Equal(t, []string{"0", "1", "3", ""}, mids(pcAnswer.GetTransceivers()))
+	assert.Equal(t, cameraTransceiver, pcAnswer.GetTransceiverByMid("1"))
+	assert.Equal(t, RTPCodecTypeVideo, pcAnswer.GetTransceiverByMid("3").Kind())
+	assert.Nil(t, pcAnswer.GetTransceiverByMid("2"), "the data channel section has no transceiver")
+	assert.Nil(t, pcAnswer.GetTransceiverByMid(""))
+
+	// The current direction is the negotiated one, not the preferred Direction
+	assert.Equal(t, RTPTransceiverDirectionInactive, offerAudio.CurrentDirection())
+	assert.Equal(t, RTPTransceiverDirectionRecvonly, pcOffer.GetTransceiverByMid("3").CurrentDirection())
+	assert.Equal(t, RTPTransceiverDirectionSendrecv, microphone.Direction())
+	assert.Equal(t, RTPTransceiverDirectionUnknown, microphone.CurrentDirection())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
 func TestPeerConnection_RoleSwitch(t *testing.T) {
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
@@ -1095,7 +1249,7 @@ func TestPeerConnection_Renegotiation_Simulcast(t *testing.T) {
 		}
 	}
 
-	assertTracksClosed := func(t *testing.T) {
+	assertTracksClosed := func(t *testing.T, expected error) {
 		t.Helper()
 
 		trackMapLock.Lock()
@@ -1103,7 +1257,7 @@ func TestPeerConnection_Renegotiation_Simulcast(t *testing.T) {
 
 		for _, track := range trackMap {
 			_, _, err := track.ReadRTP()
-			assert.Equal(t, err, io.EOF)
+			assert.Equal(t, expected, err)
 		}
 	}
 
@@ -1137,7 +1291,7 @@ func TestPeerConnection_Renegotiation_Simulcast(t *testing.T) {
 			return sessionDescription
 		}))
 
-		assertTracksClosed(t)
+		assertTracksClosed(t, ErrTrackEnded)
 		closePairNow(t, pcOffer, pcAnswer)
 	})
 
@@ -1180,7 +1334,7 @@ func TestPeerConnection_Renegotiation_Simulcast(t *testing.T) {
 			return signalWithRids(sessionDescription, newRids)
 		}))
 
-		assertTracksClosed(t)
+		assertTracksClosed(t, ErrReceiverRestarted)
 		closePairNow(t, pcOffer, pcAnswer)
 	})
 }
@@ -1425,3 +1579,315 @@ func TestNegotiationNotNeededAfterReplaceTrackNil(t *testing.T) {
 	assert.NoError(t, pcOffer.Close())
 	assert.NoError(t, pcAnswer.Close())
 }
+
+// Assert that transceivers, candidates and codecs stay associated by mid when the remote
+// swaps the order of its media sections in a subsequent offer.
+func TestPeerConnection_Renegotiation_ReorderedMediaSections(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	audioTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(audioTrack)
+	require.NoError(t, err)
+	videoTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(videoTrack)
+	require.NoError(t, err)
+
+	var audioPackets, videoPackets atomic.Uint32
+	var readers sync.WaitGroup
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		readers.Add(1)
+		defer readers.Done()
+
+		packets := &videoPackets
+		if track.Kind() == RTPCodecTypeAudio {
+			packets = &audioPackets
+		}
+		for {
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+			packets.Add(1)
+		}
+	})
+
+	done := make(chan struct{})
+	var sending sync.WaitGroup
+	sending.Add(1)
+	go func() {
+		defer sending.Done()
+
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{audioTrack, videoTrack})
+	}()
+
+	mediaFlows := func() {
+		audioPackets.Store(0)
+		videoPackets.Store(0)
+		assert.Eventually(t, func() bool {
+			return audioPackets.Load() > 10 && videoPackets.Load() > 10
+		}, 10*time.Second, 20*time.Millisecond)
+	}
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	mediaFlows()
+
+	// Swap the audio and video sections of the next offer, as some SFUs do
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	parsed, err := offer.Unmarshal()
+	require.NoError(t, err)
+	require.Equal(t, "audio", parsed.MediaDescriptions[0].MediaName.Media)
+	parsed.MediaDescriptions[0], parsed.MediaDescriptions[1] = parsed.MediaDescriptions[1], parsed.MediaDescriptions[0]
+	raw, err := parsed.Marshal()
+	require.NoError(t, err)
+
+	require.NoError(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeOffer, SDP: string(raw)}))
+
+	// Local candidates point at the new position of the bundled section right away
+	bundleMid, ok := pcAnswer.iceGatherer.sdpMid.Load().(string)
+	require.True(t, ok)
+	assert.Equal(t, "0", bundleMid)
+	assert.Equal(t, uint32(1), pcAnswer.iceGatherer.sdpMLineIndex.Load())
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcAnswer.SetLocalDescription(answer))
+	require.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	// The answer follows the new order, with the codecs of each section
+	answerParsed, err := answer.Unmarshal()
+	require.NoError(t, err)
+	for i, expected := range []struct{ mid, media, mimeType string }{
+		{"1", "video", MimeTypeVP8},
+		{"0", "audio", MimeTypeOpus},
+	} {
+		media := answerParsed.MediaDescriptions[i]
+		assert.Equal(t, expected.mid, getMidValue(media))
+		assert.Equal(t, expected.media, media.MediaName.Media)
+		codecs, codecsErr := codecsFromMediaDescription(media)
+		require.NoError(t, codecsErr)
+		assert.True(t, strings.EqualFold(expected.mimeType, codecs[0].MimeType))
+	}
+
+	// Transceivers keep their mid, kind and codec
+	transceivers := pcAnswer.GetTransceivers()
+	require.Len(t, transceivers, 2)
+	for _, transceiver := range transceivers {
+		track := transceiver.Receiver().Track()
+		switch transceiver.Mid() {
+		case "0":
+			assert.Equal(t, RTPCodecTypeAudio, transceiver.Kind())
+			assert.Equal(t, MimeTypeOpus, track.Codec().MimeType)
+		case "1":
+			assert.Equal(t, RTPCodecTypeVideo, transceiver.Kind())
+			assert.Equal(t, MimeTypeVP8, track.Codec().MimeType)
+		default:
+			assert.Failf(t, "unexpected mid", "%q", transceiver.Mid())
+		}
+	}
+
+	// Candidates are matched by mid, a stale index is ignored
+	staleIndex := uint16(0)
+	mid := "0"
+	media, sdpMid, sdpMLineIndex := remoteMediaForCandidate(
+		pcAnswer.RemoteDescription().parsed, ICECandidateInit{SDPMid: &mid, SDPMLineIndex: &staleIndex},
+	)
+	assert.Equal(t, "audio", media.MediaName.Media)
+	assert.Equal(t, "0", sdpMid)
+	assert.Equal(t, uint16(1), sdpMLineIndex)
+
+	unknownMid := "5"
+	media, _, _ = remoteMediaForCandidate(
+		pcAnswer.RemoteDescription().parsed, ICECandidateInit{SDPMid: &unknownMid, SDPMLineIndex: &staleIndex},
+	)
+	assert.Nil(t, media)
+
+	mediaFlows()
+
+	close(done)
+	sending.Wait()
+	closePairNow(t, pcOffer, pcAnswer)
+	readers.Wait()
+}
+
+func TestPeerConnection_Renegotiation_DirectionFlip(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	offerTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "offer")
+	require.NoError(t, err)
+	offerSender, err := pcOffer.AddTrack(offerTrack)
+	require.NoError(t, err)
+
+	answerTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "answer")
+	require.NoError(t, err)
+	answerSender, err := pcAnswer.AddTrack(answerTrack)
+	require.NoError(t, err)
+
+	// Count the packets of every track the offerer receives
+	var readers sync.WaitGroup
+	offerTracks, endedTracks := make(chan *TrackRemote, 10), make(chan *TrackRemote, 10)
+	var offerPackets atomic.Int64
+	pcOffer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		offerTracks <- track
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				if _, _, readErr := track.ReadRTP(); readErr != nil {
+					endedTracks <- track
+
+					return
+				}
+				offerPackets.Add(1)
+			}
+		}()
+	})
+
+	answerSSRCs := make(chan SSRC, 10)
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		answerSSRCs <- track.SSRC()
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				if _, _, readErr := track.ReadRTP(); readErr != nil {
+					return
+				}
+			}
+		}()
+	})
+
+	done := make(chan struct{})
+	var sending sync.WaitGroup
+	sending.Add(1)
+	go func() {
+		defer sending.Done()
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{offerTrack, answerTrack})
+	}()
+
+	offerTransceiver := pcOffer.GetTransceivers()[0]
+	offerSSRC := offerSender.GetParameters().Encodings[0].SSRC
+	setDirection := func(direction RTPTransceiverDirection) {
+		require.NoError(t, pcOffer.UpdateTracks(func(tx *TrackUpdate) error {
+			return tx.SetDirection(offerTransceiver, direction)
+		}))
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+	}
+	waitReceiving := func() *TrackRemote {
+		track := <-offerTracks
+		for start := offerPackets.Load(); offerPackets.Load() < start+5; {
+			time.Sleep(10 * time.Millisecond)
+		}
+
+		return track
+	}
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	assert.Equal(t, offerSSRC, <-answerSSRCs)
+	previous := waitReceiving()
+
+	for i := 0; i < 2; i++ {
+		setDirection(RTPTransceiverDirectionSendonly)
+		assert.Equal(t, RTPTransceiverDirectionSendonly, offerTransceiver.getCurrentDirection())
+		assert.Same(t, previous, <-endedTracks)
+		// The answerer stops sending without stopping its sender
+		assert.True(t, answerSender.sendPaused.Load())
+
+		setDirection(RTPTransceiverDirectionSendrecv)
+		assert.Equal(t, RTPTransceiverDirectionSendrecv, offerTransceiver.getCurrentDirection())
+		assert.False(t, answerSender.sendPaused.Load())
+
+		// Reception resumes on a new track, the sender of the offerer kept its SSRC
+		track := waitReceiving()
+		assert.NotSame(t, previous, track)
+		assert.Equal(t, previous.SSRC(), track.SSRC())
+		previous = track
+		assert.Equal(t, offerSSRC, offerSender.GetParameters().Encodings[0].SSRC)
+	}
+
+	// The answerer kept receiving the same stream
+	assert.Empty(t, answerSSRCs)
+
+	close(done)
+	sending.Wait()
+	closePairNow(t, pcOffer, pcAnswer)
+	readers.Wait()
+}
+
+func TestPeerConnection_Renegotiation_SendonlyEndsTracks(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	offerTransceiver, err := pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+	answerTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "answer")
+	require.NoError(t, err)
+	_, err = pcAnswer.AddTrack(answerTrack)
+	require.NoError(t, err)
+
+	trackStarted, readErr := make(chan struct{}), make(chan error, 1)
+	pcOffer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		close(trackStarted)
+		for {
+			if _, _, err := track.ReadRTP(); err != nil {
+				readErr <- err
+
+				return
+			}
+		}
+	})
+
+	done := make(chan struct{})
+	var sending sync.WaitGroup
+	sending.Add(1)
+	go func() {
+		defer sending.Done()
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{answerTrack})
+	}()
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-trackStarted
+
+	// The answer claims the remote still sends, the track of the sendonly transceiver ends anyway
+	require.NoError(t, pcOffer.UpdateTracks(func(tx *TrackUpdate) error {
+		return tx.SetDirection(offerTransceiver, RTPTransceiverDirectionSendonly)
+	}))
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	answer, err := pcAnswer.CreateAnswer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcAnswer.SetLocalDescription(answer))
+	require.True(t, strings.Contains(answer.SDP, "a=recvonly"))
+	answer.SDP = strings.ReplaceAll(answer.SDP, "a=recvonly", "a=sendrecv")
+	require.NoError(t, pcOffer.SetRemoteDescription(answer))
+
+	assert.ErrorIs(t, <-readErr, ErrTrackEnded)
+
+	close(done)
+	sending.Wait()
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -35,6 +35,7 @@ func newPair() (pcOffer *PeerConnection, pcAnswer *PeerConnection, err error) {
 type signalPairOptions struct {
 	disableInitialDataChannel bool
 	modificationFunc          func(string) string
+	beforeAnswer              func()
 }
 
 func withModificationFunc(f func(string) string) func(*signalPairOptions) {
@@ -49,6 +50,13 @@ func withDisableInitialDataChannel(disable bool) func(*signalPairOptions) {
 	}
 }
 
+// withBeforeAnswer calls f once the answerer applied the offer, before it creates the answer.
+func withBeforeAnswer(f func()) func(*signalPairOptions) {
+	return func(o *signalPairOptions) {
+		o.beforeAnswer = f
+	}
+}
+
 func signalPairWithOptions(
 	pcOffer *PeerConnection,
 	pcAnswer *PeerConnection,
@@ -88,6 +96,9 @@ func signalPairWithOptions(
 	if err = pcAnswer.SetRemoteDescription(offer); err != nil {
 		return err
 	}
+	if options.beforeAnswer != nil {
+		options.beforeAnswer()
+	}
 
 	answer, err := pcAnswer.CreateAnswer(nil)
 	if err != nil {
@@ -813,6 +824,145 @@ func TestSetRemoteDescriptionInvalid(t *testing.T) {
 	})
 }
 
+func TestPeerConnection_DescriptionAccessors(t *testing.T) {
+	offerer, answerer, err := newPair()
+	assert.NoError(t, err)
+
+	_, err = offerer.CreateDataChannel("data", nil)
+	assert.NoError(t, err)
+
+	var offer, answer SessionDescription
+
+	// Expected Type of the pending/current local and remote descriptions, zero means none
+	type descriptions struct {
+		pendingLocal, currentLocal, pendingRemote, currentRemote SDPType
+	}
+
+	assertDescription := func(t *testing.T, name string, expected SDPType, desc *SessionDescription) {
+		t.Helper()
+
+		if expected == SDPType(0) {
+			assert.Nil(t, desc, name)
+
+			return
+		}
+		if assert.NotNil(t, desc, name) {
+			assert.Equal(t, expected, desc.Type, name)
+		}
+	}
+
+	assertDescriptions := func(t *testing.T, pc *PeerConnection, expected descriptions) {
+		t.Helper()
+
+		assertDescription(t, "PendingLocalDescription", expected.pendingLocal, pc.PendingLocalDescription())
+		assertDescription(t, "CurrentLocalDescription", expected.currentLocal, pc.CurrentLocalDescription())
+		assertDescription(t, "PendingRemoteDescription", expected.pendingRemote, pc.PendingRemoteDescription())
+		assertDescription(t, "CurrentRemoteDescription", expected.currentRemote, pc.CurrentRemoteDescription())
+
+		local, remote := expected.pendingLocal, expected.pendingRemote
+		if local == SDPType(0) {
+			local = expected.currentLocal
+		}
+		if remote == SDPType(0) {
+			remote = expected.currentRemote
+		}
+		assertDescription(t, "LocalDescription", local, pc.LocalDescription())
+		assertDescription(t, "RemoteDescription", remote, pc.RemoteDescription())
+	}
+
+	for _, step := range []struct {
+		name              string
+		apply             func(t *testing.T)
+		offerer, answerer descriptions
+	}{
+		{
+			name:  "initial",
+			apply: func(*testing.T) {},
+		},
+		{
+			name: "offerer SetLocalDescription(offer)",
+			apply: func(t *testing.T) {
+				offer, err = offerer.CreateOffer(nil)
+				assert.NoError(t, err)
+				assert.NoError(t, offerer.SetLocalDescription(offer))
+			},
+			offerer: descriptions{pendingLocal: SDPTypeOffer},
+		},
+		{
+			name: "answerer SetRemoteDescription(offer)",
+			apply: func(t *testing.T) {
+				assert.NoError(t, answerer.SetRemoteDescription(offer))
+			},
+			offerer:  descriptions{pendingLocal: SDPTypeOffer},
+			answerer: descriptions{pendingRemote: SDPTypeOffer},
+		},
+		{
+			name: "answerer SetLocalDescription(answer)",
+			apply: func(t *testing.T) {
+				answer, err = answerer.CreateAnswer(nil)
+				assert.NoError(t, err)
+				assert.NoError(t, answerer.SetLocalDescription(answer))
+			},
+			offerer:  descriptions{pendingLocal: SDPTypeOffer},
+			answerer: descriptions{currentLocal: SDPTypeAnswer, currentRemote: SDPTypeOffer},
+		},
+		{
+			name: "offerer SetRemoteDescription(answer)",
+			apply: func(t *testing.T) {
+				assert.NoError(t, offerer.SetRemoteDescription(answer))
+			},
+			offerer:  descriptions{currentLocal: SDPTypeOffer, currentRemote: SDPTypeAnswer},
+			answerer: descriptions{currentLocal: SDPTypeAnswer, currentRemote: SDPTypeOffer},
+		},
+		{
+			name: "offerer SetLocalDescription(offer) again",
+			apply: func(t *testing.T) {
+				offer, err = offerer.CreateOffer(nil)
+				assert.NoError(t, err)
+				assert.NoError(t, offerer.SetLocalDescription(offer))
+			},
+			offerer:  descriptions{pendingLocal: SDPTypeOffer, currentLocal: SDPTypeOffer, currentRemote: SDPTypeAnswer},
+			answerer: descriptions{currentLocal: SDPTypeAnswer, currentRemote: SDPTypeOffer},
+		},
+		{
+			name: "offerer SetLocalDescription(rollback)",
+			apply: func(t *testing.T) {
+				assert.NoError(t, offerer.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+			},
+			offerer:  descriptions{currentLocal: SDPTypeOffer, currentRemote: SDPTypeAnswer},
+			answerer: descriptions{currentLocal: SDPTypeAnswer, currentRemote: SDPTypeOffer},
+		},
+	} {
+		t.Run(step.name, func(t *testing.T) {
+			step.apply(t)
+			assertDescriptions(t, offerer, step.offerer)
+			assertDescriptions(t, answerer, step.answerer)
+		})
+	}
+
+	t.Run("returned descriptions are copies", func(t *testing.T) {
+		for _, get := range []func() *SessionDescription{
+			offerer.CurrentLocalDescription,
+			offerer.CurrentRemoteDescription,
+			offerer.LocalDescription,
+			offerer.RemoteDescription,
+		} {
+			desc := get()
+			assert.NotNil(t, desc)
+
+			mediaCount := len(desc.parsed.MediaDescriptions)
+			desc.SDP = ""
+			desc.parsed.MediaDescriptions = nil
+
+			desc = get()
+			assert.NotEmpty(t, desc.SDP)
+			assert.Len(t, desc.parsed.MediaDescriptions, mediaCount)
+		}
+	})
+
+	closePairNow(t, offerer, answerer)
+}
+
 func TestAddTransceiver(t *testing.T) {
 	lim := test.TimeOut(time.Second * 30)
 	defer lim.Stop()
@@ -0,0 +1,238 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"errors"
+	"sync"
+)
+
+// Signaler sends the messages of a PerfectNegotiator to the remote peer. The messages must
+// be delivered in order. The methods are called while the PerfectNegotiator is locked, so
+// they must not wait for the remote PerfectNegotiator to handle the message.
+type Signaler interface {
+	SendDescription(desc SessionDescription) error
+	SendCandidate(candidate ICECandidateInit) error
+}
+
+// PerfectNegotiator negotiates a PeerConnection with the perfect negotiation pattern,
+// https://w3c.github.io/webrtc-pc/#perfect-negotiation-example. Both peers can change the
+// session at any time: when their offers collide, the impolite peer ignores the offer of
+// the polite peer, which rolls back its own offer and answers instead.
+//
+// It handles OnNegotiationNeeded and OnICECandidate of the PeerConnection, the messages of
+// the remote peer are passed to HandleRemoteDescription and HandleRemoteCandidate.
+type PerfectNegotiator struct {
+	pc       *PeerConnection
+	polite   bool
+	signaler Signaler
+
+	mu          sync.Mutex
+	ignoreOffer bool
+
+	// restartICE is set until local ICE credentials other than restartUfrag, the ones of
+	// when the restart was asked for, were negotiated. A restart by the remote peer counts.
+	restartICE   bool
+	restartUfrag string
+
+	// pendingCandidates arrived before the first remote description
+	pendingCandidates []ICECandidateInit
+
+	// The local candidates gathered while a local description is set are held back until it
+	// was sent, the remote peer drops candidates of an ICE generation it doesn't know yet.
+	candidatesMu   sync.Mutex
+	holdCandidates bool
+	heldCandidates []ICECandidateInit
+}
+
+// NewPerfectNegotiator creates a PerfectNegotiator for pc. Exactly one of the two peers must
+// be polite.
+func NewPerfectNegotiator(pc *PeerConnection, polite bool, signaler Signaler) *PerfectNegotiator {
+	negotiator := &PerfectNegotiator{pc: pc, polite: polite, signaler: signaler}
+
+	pc.OnNegotiationNeeded(func() {
+		// The handler runs on the operations queue, which SetLocalDescription adds to
+		go negotiator.negotiate()
+	})
+	pc.OnICECandidate(func(candidate *ICECandidate) {
+		if candidate == nil {
+			return
+		}
+
+		negotiator.candidatesMu.Lock()
+		defer negotiator.candidatesMu.Unlock()
+
+		if negotiator.holdCandidates {
+			negotiator.heldCandidates = append(negotiator.heldCandidates, candidate.ToJSON())
+
+			return
+		}
+		negotiator.sendCandidate(candidate.ToJSON())
+	})
+
+	return negotiator
+}
+
+// HandleRemoteDescription applies a description sent by the remote peer, and answers it
+// if it is an offer. An offer that collides with a local offer is ignored by the impolite
+// peer.
+func (n *PerfectNegotiator) HandleRemoteDescription(desc SessionDescription) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	offerCollision := desc.Type == SDPTypeOffer && n.pc.SignalingState() != SignalingStateStable
+	n.ignoreOffer = !n.polite && offerCollision
+	if n.ignoreOffer {
+		return nil
+	}
+
+	if offerCollision {
+		if err := n.pc.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}); err != nil {
+			return err
+		}
+	}
+
+	if err := n.pc.SetRemoteDescription(desc); err != nil {
+		return err
+	}
+	n.addPendingCandidates()
+
+	if desc.Type != SDPTypeOffer {
+		n.checkRestarted()
+
+		return nil
+	}
+
+	answer, err := n.pc.CreateAnswer(nil)
+	if err != nil {
+		return err
+	}
+	if err = n.setLocalDescription(answer); err != nil {
+		return err
+	}
+
+	// A restart that was rolled back is offered again
+	if n.checkRestarted(); n.restartICE {
+		go n.negotiate()
+	}
+
+	return nil
+}
+
+// HandleRemoteCandidate adds a candidate sent by the remote peer. The candidates of an
+// ignored offer may fail to be added, these errors aren't returned.
+func (n *PerfectNegotiator) HandleRemoteCandidate(candidate ICECandidateInit) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.pc.remoteDescription() == nil {
+		n.pendingCandidates = append(n.pendingCandidates, candidate)
+
+		return nil
+	}
+
+	if err := n.pc.AddICECandidate(candidate); err != nil && !n.ignoreOffer {
+		return err
+	}
+
+	return nil
+}
+
+// RestartICE negotiates an ICE restart. A restart that collides with an offer of the remote
+// peer is offered once that negotiation is done, unless that offer restarted ICE already.
+func (n *PerfectNegotiator) RestartICE() {
+	n.mu.Lock()
+	if !n.restartICE {
+		n.restartICE, n.restartUfrag = true, n.localUfrag()
+	}
+	n.mu.Unlock()
+
+	go n.negotiate()
+}
+
+// negotiate sends an offer, unless a negotiation is already in progress. The PeerConnection
+// asks again once it's back in the stable state.
+func (n *PerfectNegotiator) negotiate() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	// The answer to a remote offer may have negotiated the changes already
+	if n.pc.SignalingState() != SignalingStateStable || (!n.restartICE && !n.pc.isNegotiationNeeded.Load()) {
+		return
+	}
+
+	// The credentials of a restart that was rolled back are offered again, restarting once more
+	// could collide with the gathering of the first restart
+	offer, err := n.pc.CreateOffer(&OfferOptions{ICERestart: n.restartICE && n.localUfrag() == n.restartUfrag})
+	if err == nil {
+		err = n.setLocalDescription(offer)
+	}
+	if err != nil && !errors.Is(err, ErrConnectionClosed) {
+		n.pc.log.Warnf("Failed to send offer: %v", err)
+	}
+}
+
+// setLocalDescription sets desc as local description and sends it, followed by the
+// candidates gathered meanwhile.
+func (n *PerfectNegotiator) setLocalDescription(desc SessionDescription) error {
+	n.candidatesMu.Lock()
+	n.holdCandidates = true
+	n.candidatesMu.Unlock()
+
+	defer func() {
+		n.candidatesMu.Lock()
+		defer n.candidatesMu.Unlock()
+
+		for _, candidate := range n.heldCandidates {
+			n.sendCandidate(candidate)
+		}
+		n.holdCandidates, n.heldCandidates = false, nil
+	}()
+
+	if err := n.pc.SetLocalDescription(desc); err != nil {
+		return err
+	}
+
+	return n.signaler.SendDescription(desc)
+}
+
+// checkRestarted clears restartICE once the current local description has new credentials.
+func (n *PerfectNegotiator) checkRestarted() {
+	current := n.pc.negotiatedLocalDescription()
+	if !n.restartICE || current == nil {
+		return
+	}
+
+	if details, err := extractICEDetails(current.parsed, n.pc.log); err == nil && details.Ufrag != n.restartUfrag {
+		n.restartICE = false
+	}
+}
+
+// localUfrag returns the current username fragment of the local ICE agent.
+func (n *PerfectNegotiator) localUfrag() string {
+	parameters, err := n.pc.iceGatherer.GetLocalParameters()
+	if err != nil {
+		return ""
+	}
+
+	return parameters.UsernameFragment
+}
+
+func (n *PerfectNegotiator) sendCandidate(candidate ICECandidateInit) {
+	if err := n.signaler.SendCandidate(candidate); err != nil {
+		n.pc.log.Warnf("Failed to send ICE candidate: %v", err)
+	}
+}
+
+// addPendingCandidates adds the candidates that arrived before the remote description.
+func (n *PerfectNegotiator) addPendingCandidates() {
+	for _, candidate := range n.pendingCandidates {
+		if err := n.pc.AddICECandidate(candidate); err != nil {
+			n.pc.log.Warnf("Failed to add ICE candidate: %v", err)
+		}
+	}
+	n.pendingCandidates = nil
+}
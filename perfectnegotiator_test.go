@@ -0,0 +1,279 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// negotiatorMessage is a description or candidate sent by a PerfectNegotiator.
+type negotiatorMessage struct {
+	desc      *SessionDescription
+	candidate *ICECandidateInit
+}
+
+// chanSignaler delivers the messages of a PerfectNegotiator to the remote one in order, each
+// after a delay so offers sent at the same time collide.
+type chanSignaler struct {
+	messages chan negotiatorMessage
+	done     chan struct{}
+	inFlight *atomic.Int32
+	offers   atomic.Int32
+}
+
+func (s *chanSignaler) send(msg negotiatorMessage) error {
+	s.inFlight.Add(1)
+	select {
+	case s.messages <- msg:
+	case <-s.done:
+		s.inFlight.Add(-1)
+	}
+
+	return nil
+}
+
+func (s *chanSignaler) SendDescription(desc SessionDescription) error {
+	if desc.Type == SDPTypeOffer {
+		s.offers.Add(1)
+	}
+
+	return s.send(negotiatorMessage{desc: &desc})
+}
+
+func (s *chanSignaler) SendCandidate(candidate ICECandidateInit) error {
+	return s.send(negotiatorMessage{candidate: &candidate})
+}
+
+func (s *chanSignaler) deliver(t *testing.T, remote *PerfectNegotiator) {
+	t.Helper()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case msg := <-s.messages:
+			time.Sleep(10 * time.Millisecond)
+			if msg.desc != nil {
+				assert.NoError(t, remote.HandleRemoteDescription(*msg.desc))
+			} else {
+				assert.NoError(t, remote.HandleRemoteCandidate(*msg.candidate))
+			}
+			s.inFlight.Add(-1)
+		}
+	}
+}
+
+// newPerfectNegotiatorPair connects a polite and an impolite PeerConnection. The returned
+// function stops the signaling.
+func newPerfectNegotiatorPair(
+	t *testing.T, polite, impolite *PeerConnection, toImpolite, toPolite *chanSignaler,
+) (*PerfectNegotiator, *PerfectNegotiator, func()) {
+	t.Helper()
+
+	done := make(chan struct{})
+	toImpolite.messages, toImpolite.done = make(chan negotiatorMessage, 100), done
+	toPolite.messages, toPolite.done = make(chan negotiatorMessage, 100), done
+	politeNegotiator := NewPerfectNegotiator(polite, true, toImpolite)
+	impoliteNegotiator := NewPerfectNegotiator(impolite, false, toPolite)
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		toImpolite.deliver(t, impoliteNegotiator)
+	}()
+	go func() {
+		defer wg.Done()
+		toPolite.deliver(t, politeNegotiator)
+	}()
+
+	return politeNegotiator, impoliteNegotiator, func() {
+		close(done)
+		wg.Wait()
+	}
+}
+
+func (n *PerfectNegotiator) restarting() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.restartICE
+}
+
+// negotiated returns true once no messages are in flight and the PeerConnections are stable
+// without changes left to negotiate.
+func negotiated(inFlight *atomic.Int32, pcs ...*PeerConnection) bool {
+	if inFlight.Load() != 0 {
+		return false
+	}
+
+	for _, pc := range pcs {
+		if pc.SignalingState() != SignalingStateStable || pc.checkNegotiationNeeded() {
+			return false
+		}
+	}
+
+	return true
+}
+
+func TestPerfectNegotiator(t *testing.T) {
+	lim := test.TimeOut(time.Second * 60)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcPolite, pcImpolite, err := newPair()
+	require.NoError(t, err)
+
+	var inFlight atomic.Int32
+	toImpolite, toPolite := &chanSignaler{inFlight: &inFlight}, &chanSignaler{inFlight: &inFlight}
+	politeNegotiator, impoliteNegotiator, stop := newPerfectNegotiatorPair(t, pcPolite, pcImpolite, toImpolite, toPolite)
+
+	// Both peers add a track at the same time, so both offer
+	var tracks []*TrackLocalStaticSample
+	onTrack := make(chan struct{}, 2)
+	for _, pc := range []*PeerConnection{pcPolite, pcImpolite} {
+		track, trackErr := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, trackErr)
+		tracks = append(tracks, track)
+
+		pc.OnTrack(func(*TrackRemote, *RTPReceiver) {
+			onTrack <- struct{}{}
+		})
+	}
+	connected := untilConnectionState(PeerConnectionStateConnected, pcPolite, pcImpolite)
+	_, err = pcPolite.AddTrack(tracks[0])
+	require.NoError(t, err)
+	_, err = pcImpolite.AddTrack(tracks[1])
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, tracks)
+		close(sent)
+	}()
+	<-onTrack
+	<-onTrack
+	connected.Wait()
+
+	require.Eventually(t, func() bool {
+		return negotiated(&inFlight, pcPolite, pcImpolite)
+	}, 10*time.Second, 20*time.Millisecond)
+	assert.Positive(t, toImpolite.offers.Load())
+	assert.Positive(t, toPolite.offers.Load())
+
+	t.Run("RapidGlare", func(t *testing.T) {
+		for range 5 {
+			var wg sync.WaitGroup
+			for _, pc := range []*PeerConnection{pcPolite, pcImpolite} {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, addErr := pc.AddTransceiverFromKind(RTPCodecTypeAudio)
+					assert.NoError(t, addErr)
+				}()
+			}
+			wg.Wait()
+			time.Sleep(5 * time.Millisecond)
+		}
+
+		require.Eventually(t, func() bool {
+			return negotiated(&inFlight, pcPolite, pcImpolite)
+		}, 20*time.Second, 20*time.Millisecond)
+
+		// Every transceiver was negotiated, the ones of both peers are paired by their mids. An
+		// offered transceiver may be reused by the answerer, so there are 6 to 11 of them
+		assert.GreaterOrEqual(t, len(pcPolite.GetTransceivers()), 6)
+		assert.Len(t, pcImpolite.GetTransceivers(), len(pcPolite.GetTransceivers()))
+		for _, transceiver := range pcPolite.GetTransceivers() {
+			assert.NotEmpty(t, transceiver.Mid())
+			assert.NotNil(t, getByMid(transceiver.Mid(), pcImpolite.CurrentLocalDescription()))
+		}
+		assert.Equal(t, PeerConnectionStateConnected, pcPolite.ConnectionState())
+		assert.Equal(t, PeerConnectionStateConnected, pcImpolite.ConnectionState())
+	})
+
+	t.Run("RestartICE", func(t *testing.T) {
+		politeParameters, err := pcPolite.iceGatherer.GetLocalParameters()
+		require.NoError(t, err)
+		impoliteParameters, err := pcImpolite.iceGatherer.GetLocalParameters()
+		require.NoError(t, err)
+
+		// The restarts collide, the one of the polite peer is offered after the other one
+		politeNegotiator.RestartICE()
+		impoliteNegotiator.RestartICE()
+
+		require.Eventually(t, func() bool {
+			return !politeNegotiator.restarting() && !impoliteNegotiator.restarting() &&
+				negotiated(&inFlight, pcPolite, pcImpolite) &&
+				pcPolite.ICEConnectionState() == ICEConnectionStateConnected &&
+				pcImpolite.ICEConnectionState() == ICEConnectionStateConnected
+		}, 20*time.Second, 20*time.Millisecond)
+
+		restartedPolite, err := pcPolite.iceGatherer.GetLocalParameters()
+		require.NoError(t, err)
+		restartedImpolite, err := pcImpolite.iceGatherer.GetLocalParameters()
+		require.NoError(t, err)
+		assert.NotEqual(t, politeParameters.UsernameFragment, restartedPolite.UsernameFragment)
+		assert.NotEqual(t, impoliteParameters.UsernameFragment, restartedImpolite.UsernameFragment)
+	})
+
+	close(done)
+	<-sent
+	stop()
+	closePairNow(t, pcPolite, pcImpolite)
+}
+
+func TestPeerConnection_Rollback(t *testing.T) {
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeAudio)
+	require.NoError(t, err)
+	_, err = pcAnswer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+
+	// Rolling back in stable state fails
+	assert.Error(t, pcAnswer.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+
+	offer, err := pcAnswer.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcAnswer.SetLocalDescription(offer))
+	assert.Equal(t, "0", pcAnswer.GetTransceivers()[0].Mid())
+
+	require.NoError(t, pcAnswer.SetLocalDescription(SessionDescription{Type: SDPTypeRollback}))
+	assert.Equal(t, SignalingStateStable, pcAnswer.SignalingState())
+	assert.Nil(t, pcAnswer.LocalDescription())
+	assert.Empty(t, pcAnswer.GetTransceivers()[0].Mid())
+
+	// The mid of the rolled back offer is used by the remote offer for another kind
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	transceivers := pcAnswer.GetTransceivers()
+	require.Len(t, transceivers, 2)
+	assert.Equal(t, RTPCodecTypeAudio, transceivers[0].Kind())
+	assert.Equal(t, "0", transceivers[0].Mid())
+	assert.Equal(t, RTPCodecTypeVideo, transceivers[1].Kind())
+	assert.NotEqual(t, "0", transceivers[1].Mid())
+
+	// A remote offer can't be rolled back
+	offer, err = pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	assert.ErrorIs(t, pcAnswer.SetRemoteDescription(SessionDescription{Type: SDPTypeRollback}),
+		errPeerConnRemoteRollbackUnsupported)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,310 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package media
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+)
+
+const (
+	defaultAudioSelectorInterval   = 300 * time.Millisecond
+	defaultAudioSelectorHysteresis = 6
+	defaultAudioSelectorClockRate  = 48000
+
+	// silentAudioLevel is the audio level, in -dBov, of an input that sent no packets.
+	silentAudioLevel = 127
+)
+
+var (
+	errAudioSelectorClosed         = errors.New("audio selector is closed")
+	errAudioSelectorDuplicateInput = errors.New("audio selector already has an input with this id")
+)
+
+// RTPReader is a source of RTP packets, it is implemented by webrtc.TrackRemote.
+type RTPReader interface {
+	ReadRTP() (*rtp.Packet, interceptor.Attributes, error)
+}
+
+// RTPWriter is a sink of RTP packets, it is implemented by webrtc.TrackLocalStaticRTP.
+type RTPWriter interface {
+	WriteRTP(packet *rtp.Packet) error
+}
+
+// AudioSelector forwards the packets of the loudest of several audio inputs to a single
+// output, a lightweight alternative to mixing that does not need to decode the audio.
+//
+// The loudness of the inputs is read from the audio level header extension (RFC 6464).
+// At every interval the input with the highest average level becomes the active speaker,
+// if it is louder than the current one by at least the hysteresis. The sequence numbers
+// and timestamps of the forwarded packets are rewritten so the output is one continuous
+// stream.
+type AudioSelector struct {
+	mu sync.Mutex
+
+	output     RTPWriter
+	interval   time.Duration
+	hysteresis uint8
+	clockRate  uint32
+
+	inputs        map[string]*audioSelectorInput
+	active        *audioSelectorInput
+	lastSelection time.Time
+	closed        bool
+
+	onActiveSpeakerChange func(id string)
+
+	// State of the rewriting of the forwarded packets
+	started              bool
+	switched             bool
+	lastSequenceNumber   uint16
+	lastTimestamp        uint32
+	lastWrite            time.Time
+	sequenceNumberOffset uint16
+	timestampOffset      uint32
+
+	now func() time.Time
+}
+
+type audioSelectorInput struct {
+	id          string
+	extensionID uint8
+
+	// Sum of the audio levels of the packets of the current interval
+	levelSum, packets int
+	// Average audio level of the last interval
+	level int
+}
+
+// AudioSelectorOption configures an AudioSelector.
+type AudioSelectorOption func(s *AudioSelector)
+
+// WithAudioSelectorInterval sets how often the active speaker is selected, 300ms by default.
+func WithAudioSelectorInterval(interval time.Duration) AudioSelectorOption {
+	return func(s *AudioSelector) {
+		s.interval = interval
+	}
+}
+
+// WithAudioSelectorHysteresis sets by how many dB an input must be louder than the
+// active speaker to replace it, 6dB by default.
+func WithAudioSelectorHysteresis(hysteresis uint8) AudioSelectorOption {
+	return func(s *AudioSelector) {
+		s.hysteresis = hysteresis
+	}
+}
+
+// WithAudioSelectorClockRate sets the clock rate of the inputs, used to advance the
+// timestamps of the output on a switch of speaker. It is 48000, the one of Opus, by default.
+func WithAudioSelectorClockRate(clockRate uint32) AudioSelectorOption {
+	return func(s *AudioSelector) {
+		s.clockRate = clockRate
+	}
+}
+
+// NewAudioSelector creates an AudioSelector writing to output.
+func NewAudioSelector(output RTPWriter, opts ...AudioSelectorOption) *AudioSelector {
+	selector := &AudioSelector{
+		output:     output,
+		interval:   defaultAudioSelectorInterval,
+		hysteresis: defaultAudioSelectorHysteresis,
+		clockRate:  defaultAudioSelectorClockRate,
+		inputs:     map[string]*audioSelectorInput{},
+		now:        time.Now,
+	}
+
+	for _, opt := range opts {
+		opt(selector)
+	}
+
+	return selector
+}
+
+// OnActiveSpeakerChange sets a handler that is called with the id of the new active speaker
+// when it changes, or with an empty id when the active speaker ended without replacement.
+func (s *AudioSelector) OnActiveSpeakerChange(f func(id string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.onActiveSpeakerChange = f
+}
+
+// ActiveSpeaker returns the id of the input being forwarded, or an empty id if there is none.
+func (s *AudioSelector) ActiveSpeaker() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.active == nil {
+		return ""
+	}
+
+	return s.active.id
+}
+
+// AddInput reads the packets of input until it returns an error. audioLevelExtensionID is
+// the negotiated ID of the audio level header extension of the input, see the header
+// extensions of the parameters of the webrtc.RTPReceiver.
+func (s *AudioSelector) AddInput(id string, input RTPReader, audioLevelExtensionID uint8) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	switch {
+	case s.closed:
+		return errAudioSelectorClosed
+	case s.inputs[id] != nil:
+		return errAudioSelectorDuplicateInput
+	}
+
+	selectorInput := &audioSelectorInput{id: id, extensionID: audioLevelExtensionID, level: silentAudioLevel}
+	s.inputs[id] = selectorInput
+
+	go func() {
+		for {
+			packet, _, err := input.ReadRTP()
+			if err != nil {
+				s.removeInput(selectorInput)
+
+				return
+			}
+
+			s.handlePacket(selectorInput, packet)
+		}
+	}()
+
+	return nil
+}
+
+// Close stops forwarding packets. The inputs are read until they return an error.
+func (s *AudioSelector) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.closed = true
+	s.active = nil
+
+	return nil
+}
+
+func (s *AudioSelector) removeInput(input *audioSelectorInput) {
+	s.mu.Lock()
+	delete(s.inputs, input.id)
+	if s.active != input {
+		s.mu.Unlock()
+
+		return
+	}
+
+	s.active = nil
+	handler := s.onActiveSpeakerChange
+	s.mu.Unlock()
+
+	if handler != nil {
+		handler("")
+	}
+}
+
+func (s *AudioSelector) handlePacket(input *audioSelectorInput, packet *rtp.Packet) {
+	s.mu.Lock()
+	if s.closed {
+		s.mu.Unlock()
+
+		return
+	}
+
+	if payload := packet.GetExtension(input.extensionID); payload != nil {
+		var audioLevel rtp.AudioLevelExtension
+		if audioLevel.Unmarshal(payload) == nil {
+			input.levelSum += int(audioLevel.Level)
+			input.packets++
+		}
+	}
+
+	now := s.now()
+	changed := false
+	if s.lastSelection.IsZero() {
+		s.lastSelection = now
+	} else if now.Sub(s.lastSelection) >= s.interval {
+		s.lastSelection = now
+		changed = s.selectActiveSpeaker()
+	}
+
+	if input == s.active {
+		s.forward(packet, now)
+	}
+
+	var activeID string
+	if s.active != nil {
+		activeID = s.active.id
+	}
+	handler := s.onActiveSpeakerChange
+	s.mu.Unlock()
+
+	if changed && handler != nil {
+		handler(activeID)
+	}
+}
+
+// selectActiveSpeaker closes the current interval and reports if the active speaker changed.
+func (s *AudioSelector) selectActiveSpeaker() bool {
+	var loudest *audioSelectorInput
+	for _, input := range s.inputs {
+		input.level = silentAudioLevel
+		if input.packets > 0 {
+			input.level = input.levelSum / input.packets
+		}
+		input.levelSum, input.packets = 0, 0
+
+		// Lower levels are louder, the active speaker wins ties
+		if input.level != silentAudioLevel && (loudest == nil || input.level < loudest.level ||
+			(input.level == loudest.level && input == s.active)) {
+			loudest = input
+		}
+	}
+
+	if loudest == nil || loudest == s.active ||
+		(s.active != nil && s.active.level-loudest.level < int(s.hysteresis)) {
+		return false
+	}
+
+	s.active = loudest
+	s.switched = true
+
+	return true
+}
+
+// forward rewrites packet to continue the output and writes it.
+func (s *AudioSelector) forward(packet *rtp.Packet, now time.Time) {
+	if s.switched {
+		s.switched = false
+
+		if s.started {
+			ticks := uint32(now.Sub(s.lastWrite).Seconds() * float64(s.clockRate))
+			if ticks == 0 {
+				ticks = 1
+			}
+
+			s.sequenceNumberOffset = s.lastSequenceNumber + 1 - packet.SequenceNumber
+			s.timestampOffset = s.lastTimestamp + ticks - packet.Timestamp
+			packet.Marker = true
+		}
+	}
+
+	packet.SequenceNumber += s.sequenceNumberOffset
+	packet.Timestamp += s.timestampOffset
+
+	// Reordered packets of the active speaker do not move the output back
+	if !s.started || int16(packet.SequenceNumber-s.lastSequenceNumber) > 0 { //nolint:gosec // G115
+		s.lastSequenceNumber = packet.SequenceNumber
+		s.lastTimestamp = packet.Timestamp
+		s.lastWrite = now
+	}
+	s.started = true
+
+	// A failed write only loses this packet
+	_ = s.output.WriteRTP(packet)
+}
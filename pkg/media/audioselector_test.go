@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package media
+
+import (
+	"io"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAudioLevelExtensionID = 1
+
+type recordingRTPWriter struct {
+	mu      sync.Mutex
+	packets []*rtp.Packet
+}
+
+func (w *recordingRTPWriter) WriteRTP(packet *rtp.Packet) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.packets = append(w.packets, packet)
+
+	return nil
+}
+
+// syntheticAudioInput generates the 20ms packets of an input with a given audio level.
+type syntheticAudioInput struct {
+	ssrc           uint32
+	sequenceNumber uint16
+	timestamp      uint32
+}
+
+func (i *syntheticAudioInput) next(t *testing.T, level uint8) *rtp.Packet {
+	t.Helper()
+
+	payload, err := rtp.AudioLevelExtension{Level: level, Voice: true}.Marshal()
+	require.NoError(t, err)
+
+	packet := &rtp.Packet{Header: rtp.Header{
+		Version:        2,
+		SSRC:           i.ssrc,
+		SequenceNumber: i.sequenceNumber,
+		Timestamp:      i.timestamp,
+	}, Payload: []byte{0x00}}
+	require.NoError(t, packet.SetExtension(testAudioLevelExtensionID, payload))
+
+	i.sequenceNumber++
+	i.timestamp += 960
+
+	return packet
+}
+
+func TestAudioSelector(t *testing.T) {
+	output := &recordingRTPWriter{}
+	now := time.Unix(1000, 0)
+	selector := NewAudioSelector(output,
+		WithAudioSelectorInterval(100*time.Millisecond),
+		WithAudioSelectorHysteresis(6),
+	)
+	selector.now = func() time.Time { return now }
+
+	var speakers []string
+	selector.OnActiveSpeakerChange(func(id string) {
+		speakers = append(speakers, id)
+	})
+
+	inputA := &audioSelectorInput{id: "a", extensionID: testAudioLevelExtensionID, level: silentAudioLevel}
+	inputB := &audioSelectorInput{id: "b", extensionID: testAudioLevelExtensionID, level: silentAudioLevel}
+	selector.inputs["a"], selector.inputs["b"] = inputA, inputB
+	packetsA := &syntheticAudioInput{ssrc: 1, sequenceNumber: 65500, timestamp: 5000}
+	packetsB := &syntheticAudioInput{ssrc: 2, sequenceNumber: 40000, timestamp: 900000}
+
+	// Both inputs send a packet every 20ms for duration at the given levels
+	speak := func(duration time.Duration, levelA, levelB uint8) {
+		for end := now.Add(duration); now.Before(end); now = now.Add(20 * time.Millisecond) {
+			selector.handlePacket(inputA, packetsA.next(t, levelA))
+			selector.handlePacket(inputB, packetsB.next(t, levelB))
+		}
+	}
+
+	// Nothing is forwarded before the first interval selected a speaker
+	speak(100*time.Millisecond, 30, 50)
+	assert.Empty(t, output.packets)
+	assert.Empty(t, selector.ActiveSpeaker())
+
+	speak(100*time.Millisecond, 30, 50)
+	assert.Equal(t, "a", selector.ActiveSpeaker())
+
+	// B is louder but not by the hysteresis
+	speak(200*time.Millisecond, 30, 27)
+	assert.Equal(t, "a", selector.ActiveSpeaker())
+	forwardedA := len(output.packets)
+
+	speak(200*time.Millisecond, 30, 10)
+	assert.Equal(t, "b", selector.ActiveSpeaker())
+	assert.Equal(t, []string{"a", "b"}, speakers)
+
+	// The output is continuous across the switch
+	require.Greater(t, len(output.packets), forwardedA+1)
+	for i, packet := range output.packets {
+		expectedSSRC := uint32(1)
+		if i >= forwardedA+5 {
+			expectedSSRC = 2
+		}
+		assert.Equal(t, expectedSSRC, packet.SSRC)
+		assert.Equal(t, i == forwardedA+5, packet.Marker)
+
+		if i > 0 {
+			previous := output.packets[i-1]
+			assert.Equal(t, previous.SequenceNumber+1, packet.SequenceNumber)
+			assert.Equal(t, previous.Timestamp+960, packet.Timestamp)
+		}
+	}
+
+	// A silent active speaker is replaced by any louder input, a fully silent one is kept
+	speak(200*time.Millisecond, 30, silentAudioLevel)
+	assert.Equal(t, "a", selector.ActiveSpeaker())
+	speak(200*time.Millisecond, silentAudioLevel, silentAudioLevel)
+	assert.Equal(t, "a", selector.ActiveSpeaker())
+	assert.Equal(t, []string{"a", "b", "a"}, speakers)
+
+	assert.NoError(t, selector.Close())
+	assert.Empty(t, selector.ActiveSpeaker())
+}
+
+// sliceRTPReader returns its packets and then io.EOF, advancing a clock with every packet.
+type sliceRTPReader struct {
+	packets []*rtp.Packet
+	advance func()
+}
+
+func (r *sliceRTPReader) ReadRTP() (*rtp.Packet, interceptor.Attributes, error) {
+	if len(r.packets) == 0 {
+		return nil, nil, io.EOF
+	}
+
+	packet := r.packets[0]
+	r.packets = r.packets[1:]
+	r.advance()
+
+	return packet, nil, nil
+}
+
+func TestAudioSelector_AddInput(t *testing.T) {
+	output := &recordingRTPWriter{}
+	selector := NewAudioSelector(output, WithAudioSelectorInterval(100*time.Millisecond))
+
+	var mu sync.Mutex
+	now := time.Unix(1000, 0)
+	selector.now = func() time.Time {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return now
+	}
+
+	speakers := make(chan string, 2)
+	selector.OnActiveSpeakerChange(func(id string) {
+		speakers <- id
+	})
+
+	packets := &syntheticAudioInput{ssrc: 1}
+	reader := &sliceRTPReader{advance: func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		now = now.Add(20 * time.Millisecond)
+	}}
+	for i := 0; i < 10; i++ {
+		reader.packets = append(reader.packets, packets.next(t, 40))
+	}
+
+	require.NoError(t, selector.AddInput("a", reader, testAudioLevelExtensionID))
+	assert.ErrorIs(t, selector.AddInput("a", reader, testAudioLevelExtensionID), errAudioSelectorDuplicateInput)
+
+	// The input is selected, forwarded and removed once it ends
+	assert.Equal(t, "a", <-speakers)
+	assert.Equal(t, "", <-speakers)
+	assert.Empty(t, selector.ActiveSpeaker())
+
+	output.mu.Lock()
+	assert.Len(t, output.packets, 5)
+	output.mu.Unlock()
+
+	assert.NoError(t, selector.Close())
+	assert.ErrorIs(t, selector.AddInput("b", reader, testAudioLevelExtensionID), errAudioSelectorClosed)
+}
@@ -17,11 +17,18 @@ type Sample struct {
 	Duration           time.Duration
 	PacketTimestamp    uint32
 	PrevDroppedPackets uint16
+	// PrevPaddingPackets is how many of PrevDroppedPackets were padding packets,
+	// the others were dropped because their Sample couldn't be completed.
+	PrevPaddingPackets uint16
 	Metadata           any
 
 	// RTP headers of RTP packets forming this Sample. (Optional)
 	// Useful for accessing RTP extensions associated to the Sample.
 	RTPHeaders []*rtp.Header
+
+	// AllowZeroDuration permits a Sample without Duration. Its packets have the
+	// timestamp of the previous Sample, which allows writing a frame in several parts.
+	AllowZeroDuration bool
 }
 
 // Writer defines an interface to handle
@@ -163,8 +163,10 @@ func (s *SampleBuilder) purgeBuffers(flush bool) {
 
 		if s.active.hasData() && (s.active.head == s.filled.head) {
 			// attempt to force the active packet to be consumed even though
-			// outstanding data may be pending arrival
-			if s.buildSample(true) != nil {
+			// outstanding data may be pending arrival, the packets of a sample
+			// without its head are dropped by buildSample
+			head := s.active.head
+			if s.buildSample(true) != nil || s.active.head != head {
 				continue
 			}
 
@@ -313,6 +315,7 @@ func (s *SampleBuilder) buildSample(purgingBuffers bool) *media.Sample {
 		Duration:           time.Duration((float64(samples)/float64(s.sampleRate))*secondToNanoseconds) * time.Nanosecond,
 		PacketTimestamp:    sampleTimestamp,
 		PrevDroppedPackets: s.droppedPackets,
+		PrevPaddingPackets: s.paddingPackets,
 		Metadata:           metadata,
 		RTPHeaders:         rtpHeaders,
 	}
@@ -231,6 +231,7 @@ func TestSampleBuilder(t *testing.T) { //nolint:maintidx
 			},
 			samples: []*media.Sample{
 				{Data: []byte{0x04, 0x05}, Duration: time.Second * time.Duration(2), PacketTimestamp: 4000, PrevDroppedPackets: 13},
+				{Data: []byte{0x04}, Duration: time.Second, PacketTimestamp: 7000, PrevDroppedPackets: 1},
 			},
 			withHeadChecker:  true,
 			headBytes:        []byte{0x04},
@@ -261,6 +262,8 @@ func TestSampleBuilder(t *testing.T) { //nolint:maintidx
 			headBytes:       []byte{1},
 			samples: []*media.Sample{
 				{Data: []byte{1, 2, 3}, Duration: 0, PacketTimestamp: 1, PrevDroppedPackets: 0}, // first sample
+				// second sample, after the padding packets
+				{Data: []byte{1, 7}, Duration: time.Second, PacketTimestamp: 3, PrevDroppedPackets: 2, PrevPaddingPackets: 2},
 			},
 			maxLate:          50,
 			maxLateTimestamp: 2000,
@@ -345,6 +348,27 @@ func TestSampleBuilder(t *testing.T) { //nolint:maintidx
 	})
 }
 
+func TestSampleBuilderPaddingPackets(t *testing.T) {
+	s := New(50, &fakeDepacketizer{headChecker: true, headBytes: []byte{1}}, 1)
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5000, Timestamp: 1, Marker: true}, Payload: []byte{1}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5001, Timestamp: 1}, Payload: []byte{}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5002, Timestamp: 2}, Payload: []byte{1}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5003, Timestamp: 2, Marker: true}, Payload: []byte{2}})
+	s.Push(&rtp.Packet{Header: rtp.Header{SequenceNumber: 5004, Timestamp: 3}, Payload: []byte{1}})
+
+	samples := []*media.Sample{}
+	for range 3 {
+		if sample := s.Pop(); sample != nil {
+			samples = append(samples, sample)
+		}
+	}
+
+	assert.Equal(t, []*media.Sample{
+		{Data: []byte{1}, PacketTimestamp: 1},
+		{Data: []byte{1, 2}, Duration: time.Second, PacketTimestamp: 2, PrevDroppedPackets: 1, PrevPaddingPackets: 1},
+	}, samples)
+}
+
 // SampleBuilder should respect maxLate if we popped successfully but then have a gap larger then maxLate.
 func TestSampleBuilderMaxLate(t *testing.T) {
 	assert := assert.New(t)
@@ -0,0 +1,174 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+// Package signaltest provides helpers to connect PeerConnections with each other in tests.
+// It is test-support API: the helpers favor simplicity over flexibility, and they are not
+// meant to be used for signaling in production.
+package signaltest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/webrtc/v4"
+)
+
+// DefaultGatheringTimeout is how long SignalPair waits for ICE gathering to complete,
+// unless WithGatheringTimeout is used.
+const DefaultGatheringTimeout = 10 * time.Second
+
+var (
+	errGatheringTimeout        = errors.New("signaltest: ICE gathering did not complete")
+	errLocalDescriptionMissing = errors.New("signaltest: local description is missing")
+)
+
+type options struct {
+	modification              func(sdp string) string
+	disableInitialDataChannel bool
+	gatheringTimeout          time.Duration
+}
+
+// Option configures SignalPair.
+type Option func(*options)
+
+// WithModification modifies the SDP of the offer before it is handed to the answerer.
+func WithModification(modification func(sdp string) string) Option {
+	return func(o *options) {
+		o.modification = modification
+	}
+}
+
+// WithoutInitialDataChannel skips the data channel SignalPair creates on the offerer,
+// the offer must then have another reason to gather ICE candidates, like a track.
+func WithoutInitialDataChannel() Option {
+	return func(o *options) {
+		o.disableInitialDataChannel = true
+	}
+}
+
+// WithGatheringTimeout sets how long SignalPair waits for ICE gathering to complete.
+func WithGatheringTimeout(timeout time.Duration) Option {
+	return func(o *options) {
+		o.gatheringTimeout = timeout
+	}
+}
+
+// NewPair creates an offerer and an answerer PeerConnection with the default configuration,
+// both are created with an API built from apiOptions.
+func NewPair(apiOptions ...func(*webrtc.API)) (offerer, answerer *webrtc.PeerConnection, err error) {
+	api := webrtc.NewAPI(apiOptions...)
+	if offerer, err = api.NewPeerConnection(webrtc.Configuration{}); err != nil {
+		return nil, nil, err
+	}
+	if answerer, err = api.NewPeerConnection(webrtc.Configuration{}); err != nil {
+		return nil, nil, errors.Join(err, offerer.Close())
+	}
+
+	return offerer, answerer, nil
+}
+
+// SignalPair negotiates a session between offerer and answerer. The descriptions are
+// exchanged once ICE gathering completed, so they contain every candidate.
+//
+// Unless WithoutInitialDataChannel is used, a data channel is created on the offerer first,
+// so the offer has an application section and ICE gathering starts in every environment.
+func SignalPair(offerer, answerer *webrtc.PeerConnection, opts ...Option) error {
+	options := options{gatheringTimeout: DefaultGatheringTimeout}
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	if !options.disableInitialDataChannel {
+		if _, err := offerer.CreateDataChannel("initial_data_channel", nil); err != nil {
+			return err
+		}
+	}
+
+	offer, err := offerer.CreateOffer(nil)
+	if err != nil {
+		return err
+	}
+	if offer, err = setLocalDescription(offerer, offer, options.gatheringTimeout); err != nil {
+		return fmt.Errorf("offerer: %w", err)
+	}
+
+	if options.modification != nil {
+		offer.SDP = options.modification(offer.SDP)
+	}
+	if err = answerer.SetRemoteDescription(offer); err != nil {
+		return err
+	}
+
+	answer, err := answerer.CreateAnswer(nil)
+	if err != nil {
+		return err
+	}
+	if answer, err = setLocalDescription(answerer, answer, options.gatheringTimeout); err != nil {
+		return fmt.Errorf("answerer: %w", err)
+	}
+
+	return offerer.SetRemoteDescription(answer)
+}
+
+// SignalPairWithModification is like SignalPair, the SDP of the offer is passed through
+// modification before it is handed to the answerer.
+func SignalPairWithModification(
+	offerer, answerer *webrtc.PeerConnection,
+	modification func(sdp string) string,
+) error {
+	return SignalPair(offerer, answerer, WithModification(modification))
+}
+
+// setLocalDescription applies description, and returns the local description of pc once
+// ICE gathering completed.
+func setLocalDescription(
+	pc *webrtc.PeerConnection,
+	description webrtc.SessionDescription,
+	gatheringTimeout time.Duration,
+) (webrtc.SessionDescription, error) {
+	gatheringComplete := webrtc.GatheringCompletePromise(pc)
+	if err := pc.SetLocalDescription(description); err != nil {
+		return webrtc.SessionDescription{}, err
+	}
+
+	timeout := time.NewTimer(gatheringTimeout)
+	defer timeout.Stop()
+	select {
+	case <-gatheringComplete:
+	case <-timeout.C:
+		return webrtc.SessionDescription{}, errGatheringTimeout
+	}
+
+	local := pc.LocalDescription()
+	if local == nil {
+		return webrtc.SessionDescription{}, errLocalDescriptionMissing
+	}
+
+	return *local, nil
+}
+
+// UntilConnectionState returns a WaitGroup that is done once every PeerConnection of
+// peers reached state. It replaces the OnConnectionStateChange handlers of peers.
+func UntilConnectionState(state webrtc.PeerConnectionState, peers ...*webrtc.PeerConnection) *sync.WaitGroup {
+	var triggered sync.WaitGroup
+	triggered.Add(len(peers))
+
+	for _, pc := range peers {
+		var done atomic.Bool
+		pc.OnConnectionStateChange(func(current webrtc.PeerConnectionState) {
+			if current == state && done.CompareAndSwap(false, true) {
+				triggered.Done()
+			}
+		})
+	}
+
+	return &triggered
+}
+
+// ClosePair closes offerer and answerer, the errors of both are returned.
+func ClosePair(offerer, answerer *webrtc.PeerConnection) error {
+	return errors.Join(offerer.Close(), answerer.Close())
+}
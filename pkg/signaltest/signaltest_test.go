@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package signaltest
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/pion/webrtc/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignalPair(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerer, answerer, err := NewPair()
+	require.NoError(t, err)
+
+	onDataChannel := make(chan string, 1)
+	answerer.OnDataChannel(func(d *webrtc.DataChannel) {
+		onDataChannel <- d.Label()
+	})
+
+	connected := UntilConnectionState(webrtc.PeerConnectionStateConnected, offerer, answerer)
+	require.NoError(t, SignalPair(offerer, answerer))
+	connected.Wait()
+
+	assert.Equal(t, "initial_data_channel", <-onDataChannel)
+	assert.Contains(t, answerer.RemoteDescription().SDP, "a=candidate")
+	assert.Contains(t, offerer.RemoteDescription().SDP, "a=candidate")
+
+	assert.NoError(t, ClosePair(offerer, answerer))
+}
+
+func TestSignalPairWithModification(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerer, answerer, err := NewPair()
+	require.NoError(t, err)
+
+	require.NoError(t, SignalPairWithModification(offerer, answerer, func(sdp string) string {
+		return strings.Replace(sdp, "s=-", "s=modified", 1)
+	}))
+	assert.Contains(t, answerer.RemoteDescription().SDP, "s=modified")
+	assert.NotContains(t, offerer.LocalDescription().SDP, "s=modified")
+
+	assert.NoError(t, ClosePair(offerer, answerer))
+}
+
+func TestSignalPair_Errors(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerer, answerer, err := NewPair()
+	require.NoError(t, err)
+
+	// The offer can't be parsed by the answerer
+	assert.Error(t, SignalPairWithModification(offerer, answerer, func(string) string {
+		return "invalid"
+	}))
+
+	// Nothing to negotiate without the initial data channel
+	assert.NoError(t, ClosePair(offerer, answerer))
+	assert.Error(t, SignalPair(offerer, answerer, WithoutInitialDataChannel()))
+}
+
+func TestNewVNetPair(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerer, answerer, wan, err := NewVNetPair(webrtc.SettingEngine{})
+	require.NoError(t, err)
+
+	connected := UntilConnectionState(webrtc.PeerConnectionStateConnected, offerer, answerer)
+	require.NoError(t, SignalPair(offerer, answerer, WithGatheringTimeout(5*time.Second)))
+	connected.Wait()
+
+	pair, err := offerer.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+	require.NoError(t, err)
+	assert.Equal(t, VNetOffererIP, pair.Local.Address)
+	assert.Equal(t, VNetAnswererIP, pair.Remote.Address)
+
+	assert.NoError(t, ClosePair(offerer, answerer))
+	assert.NoError(t, wan.Stop())
+}
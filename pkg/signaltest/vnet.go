@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package signaltest
+
+import (
+	"errors"
+	"time"
+
+	"github.com/pion/logging"
+	"github.com/pion/transport/v4/vnet"
+	"github.com/pion/webrtc/v4"
+)
+
+// The addresses of the PeerConnections created by NewVNetPair.
+const (
+	VNetOffererIP  = "1.2.3.4"
+	VNetAnswererIP = "1.2.3.5"
+)
+
+// NewVNetPair creates an offerer and an answerer PeerConnection connected by a virtual
+// network, so tests don't depend on the interfaces of the host and have no packet loss.
+// Each PeerConnection gets a copy of settingEngine with its own vnet.Net, and an API built
+// from apiOptions, which must not include webrtc.WithSettingEngine.
+//
+// The returned router is started, it must be stopped once the PeerConnections are closed.
+func NewVNetPair(
+	settingEngine webrtc.SettingEngine,
+	apiOptions ...func(*webrtc.API),
+) (offerer, answerer *webrtc.PeerConnection, wan *vnet.Router, err error) {
+	wan, err = vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	newPeerConnection := func(ip string) (*webrtc.PeerConnection, error) {
+		vnetNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		if err != nil {
+			return nil, err
+		}
+		if err = wan.AddNet(vnetNet); err != nil {
+			return nil, err
+		}
+
+		settingEngine := settingEngine
+		settingEngine.SetNet(vnetNet)
+		settingEngine.SetICETimeouts(time.Second, time.Second, time.Millisecond*200)
+
+		options := append([]func(*webrtc.API){webrtc.WithSettingEngine(settingEngine)}, apiOptions...)
+
+		return webrtc.NewAPI(options...).NewPeerConnection(webrtc.Configuration{})
+	}
+
+	if offerer, err = newPeerConnection(VNetOffererIP); err != nil {
+		return nil, nil, nil, err
+	}
+	if answerer, err = newPeerConnection(VNetAnswererIP); err != nil {
+		return nil, nil, nil, errors.Join(err, offerer.Close())
+	}
+	if err = wan.Start(); err != nil {
+		return nil, nil, nil, errors.Join(err, ClosePair(offerer, answerer))
+	}
+
+	return offerer, answerer, wan, nil
+}
@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtp"
+)
+
+// PlayoutDelayURI is the URI of the playout-delay RTP header extension, which tells the
+// receiver the range of delay to keep in its jitter buffer before rendering a frame.
+//
+// https://webrtc.googlesource.com/src/+/refs/heads/main/docs/native-code/rtp-hdrext/playout-delay
+const PlayoutDelayURI = "http://www.webrtc.org/experiments/rtp-hdrext/playout-delay"
+
+const (
+	playoutDelayGranularity = 10 * time.Millisecond
+	playoutDelayMaxValue    = (1 << 12) - 1
+)
+
+// playoutDelayWriter adds the playout delay of an RTPSender to every packet.
+type playoutDelayWriter struct {
+	id    uint8
+	delay *atomic.Pointer[rtp.PlayoutDelayExtension]
+}
+
+// apply returns header with the playout delay added if one is set.
+func (w *playoutDelayWriter) apply(header *rtp.Header) *rtp.Header {
+	delay := w.delay.Load()
+	if delay == nil {
+		return header
+	}
+
+	payload, err := delay.Marshal()
+	if err != nil {
+		return header
+	}
+
+	return withHeaderExtension(header, w.id, payload)
+}
+
+// SetPlayoutDelay sets the range of delay the receiver should keep before rendering
+// the video, sent with the playout-delay RTP header extension, see
+// ConfigurePlayoutDelayHeaderExtension. The delay is added to every packet once the
+// extension is negotiated, and can be changed at any time while sending. The values
+// are truncated to the 10ms granularity of the extension and are at most 40.95s,
+// a minimum and maximum of zero ask the receiver to render frames as soon as possible.
+func (r *RTPSender) SetPlayoutDelay(minDelay, maxDelay time.Duration) error {
+	if minDelay < 0 || maxDelay < minDelay || maxDelay > playoutDelayMaxValue*playoutDelayGranularity {
+		return errPlayoutDelayInvalid
+	}
+
+	r.playoutDelay.Store(&rtp.PlayoutDelayExtension{
+		MinDelay: uint16(minDelay / playoutDelayGranularity), //nolint:gosec // checked above
+		MaxDelay: uint16(maxDelay / playoutDelayGranularity), //nolint:gosec // checked above
+	})
+
+	return nil
+}
+
+// configurePlayoutDelay installs the writer of the playout delay on e if the header
+// extension is negotiated.
+func (r *RTPSender) configurePlayoutDelay(e *trackEncoding) {
+	for _, extension := range e.headerExtensions {
+		if extension.URI != PlayoutDelayURI {
+			continue
+		}
+
+		e.writeStream.playoutDelay.Store(&playoutDelayWriter{
+			id:    uint8(extension.ID), //nolint:gosec // header extension IDs are at most 255
+			delay: &r.playoutDelay,
+		})
+
+		return
+	}
+
+	e.writeStream.playoutDelay.Store(nil)
+}
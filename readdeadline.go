@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pion/transport/v4/deadline"
+	"github.com/pion/transport/v4/packetio"
+)
+
+// errReadTimeout is returned by reads whose deadline expired while waiting for
+// the stream to be bound. Like the timeouts of bound streams it is a net.Error.
+var errReadTimeout net.Error = &readTimeoutError{packetio.ErrTimeout} // nolint:gochecknoglobals
+
+type readTimeoutError struct {
+	err error
+}
+
+func (e *readTimeoutError) Error() string   { return e.err.Error() }
+func (e *readTimeoutError) Unwrap() error   { return e.err }
+func (e *readTimeoutError) Timeout() bool   { return true }
+func (e *readTimeoutError) Temporary() bool { return true }
+
+// readDeadline is the read deadline of a stream that might not be bound yet.
+// Reads waiting for the stream select on done, and the deadline is applied to
+// the stream once it is bound. The zero value has no deadline.
+type readDeadline struct {
+	once     sync.Once
+	deadline *deadline.Deadline
+}
+
+func (r *readDeadline) get() *deadline.Deadline {
+	r.once.Do(func() {
+		r.deadline = deadline.New()
+	})
+
+	return r.deadline
+}
+
+func (r *readDeadline) set(t time.Time) {
+	r.get().Set(t)
+}
+
+// done is closed once the deadline expires.
+func (r *readDeadline) done() <-chan struct{} {
+	return r.get().Done()
+}
+
+// value returns the deadline, ok is false if there is none.
+func (r *readDeadline) value() (t time.Time, ok bool) {
+	return r.get().Deadline()
+}
+
+// apply sets the deadline on a newly bound stream, if there is one.
+func (r *readDeadline) apply(stream interface{ SetReadDeadline(time.Time) error }) error {
+	if t, ok := r.value(); ok {
+		return stream.SetReadDeadline(t)
+	}
+
+	return nil
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+// RetransmissionFlushPolicy configures when a RTPSender flushes its retransmission
+// history automatically, see RTPSender.FlushRetransmissionHistory.
+type RetransmissionFlushPolicy struct {
+	// OnReplaceTrack flushes the history when the track of the RTPSender is replaced,
+	// so packets of the previous track are never retransmitted.
+	OnReplaceTrack bool
+
+	// OnKeyframe flushes the history before the first packet of every keyframe is sent,
+	// for codecs whose keyframes can be detected.
+	OnKeyframe bool
+}
+
+const sequenceNumberValid = 1 << 16
+
+// flushBoundaryLifetime is how far the sequence numbers move past a flush before its boundary
+// is cleared. The largest NACK history has no flushed packet left by then, and the wrap-around
+// comparison with the boundary would take newer sequence numbers for flushed ones.
+const flushBoundaryLifetime = 1 << 15
+
+// retransmissionHistory tracks the flushes of the retransmission history of a trackEncoding.
+type retransmissionHistory struct {
+	// mu serializes flushes with other bindings of the interceptors
+	mu sync.Mutex
+
+	// lastSequenceNumber and flushBoundary are flagged with sequenceNumberValid once set
+	lastSequenceNumber atomic.Uint32
+	flushBoundary      atomic.Uint32
+
+	flushedNACKs atomic.Uint64
+}
+
+// written records the sequence number of a packet sent on the media SSRC.
+func (h *retransmissionHistory) written(sequenceNumber uint16) {
+	last := h.lastSequenceNumber.Load()
+	// Retransmissions without RTX are sent on the media SSRC too, only move forward
+	if last&sequenceNumberValid != 0 && !isNewerSequenceNumber(sequenceNumber, uint16(last)) { //nolint:gosec // G115
+		return
+	}
+	h.lastSequenceNumber.Store(sequenceNumberValid | uint32(sequenceNumber))
+
+	boundary := h.flushBoundary.Load()
+	passed := sequenceNumber - uint16(boundary) //nolint:gosec // G115
+	if boundary&sequenceNumberValid != 0 && passed >= flushBoundaryLifetime {
+		// A concurrent flush stores a new boundary, which must be kept
+		h.flushBoundary.CompareAndSwap(boundary, 0)
+	}
+}
+
+// flushed marks everything written so far as flushed, h.mu must be held.
+func (h *retransmissionHistory) flushed() {
+	if last := h.lastSequenceNumber.Load(); last&sequenceNumberValid != 0 {
+		h.flushBoundary.Store(sequenceNumberValid | uint32(uint16(last)+1)) //nolint:gosec // G115
+	}
+}
+
+// filterFlushedNACKs removes the flushed sequence numbers from the NACKs of ssrc in buf, so
+// the NACK responder never retransmits them, and counts them. It returns the length of the
+// RTCP left in buf, which is 0 if it only NACKed flushed packets.
+func (h *retransmissionHistory) filterFlushedNACKs(ssrc SSRC, buf []byte) int {
+	boundary := h.flushBoundary.Load()
+	if boundary&sequenceNumberValid == 0 {
+		return len(buf)
+	}
+
+	pkts, err := rtcp.Unmarshal(buf)
+	if err != nil {
+		return len(buf)
+	}
+
+	filtered := make([]rtcp.Packet, 0, len(pkts))
+	changed := false
+	for _, pkt := range pkts {
+		nack, ok := pkt.(*rtcp.TransportLayerNack)
+		if !ok || nack.MediaSSRC != uint32(ssrc) {
+			filtered = append(filtered, pkt)
+
+			continue
+		}
+
+		var sequenceNumbers []uint16
+		for _, pair := range nack.Nacks {
+			pair.Range(func(sequenceNumber uint16) bool {
+				if isNewerSequenceNumber(uint16(boundary), sequenceNumber) { //nolint:gosec // G115
+					h.flushedNACKs.Add(1)
+					changed = true
+				} else {
+					sequenceNumbers = append(sequenceNumbers, sequenceNumber)
+				}
+
+				return true
+			})
+		}
+		if len(sequenceNumbers) != 0 {
+			nack.Nacks = rtcp.NackPairsFromSequenceNumbers(sequenceNumbers)
+			filtered = append(filtered, nack)
+		}
+	}
+
+	if !changed {
+		return len(buf)
+	}
+	if len(filtered) == 0 {
+		return 0
+	}
+
+	// The NACKs of a subset of the sequence numbers never need more pairs
+	out, err := rtcp.Marshal(filtered)
+	if err != nil || len(out) > len(buf) {
+		return len(buf)
+	}
+
+	return copy(buf, out)
+}
+
+// isNewerSequenceNumber reports if a follows b, taking wrap-around into account.
+func isNewerSequenceNumber(a, b uint16) bool {
+	return a != b && a-b < 1<<15
+}
+
+// keyframeFlush flushes the retransmission history before a keyframe is written.
+type keyframeFlush struct {
+	isKeyframeStart keyframeDetector
+	flush           func()
+
+	// lastTimestamp is flagged with bit 32, a keyframe can start with multiple
+	// packets that look like its start, like a SPS and an IDR.
+	lastTimestamp atomic.Uint64
+}
+
+func (k *keyframeFlush) observe(header *rtp.Header, payload []byte) {
+	if !k.isKeyframeStart(payload) {
+		return
+	}
+
+	timestamp := 1<<32 | uint64(header.Timestamp)
+	if k.lastTimestamp.Swap(timestamp) != timestamp {
+		k.flush()
+	}
+}
+
+// FlushRetransmissionHistory drops the packets kept for retransmissions, packets written
+// before the flush are never sent again, even if the receiver asks for them with a NACK.
+// These NACKs are counted in OutboundRTPStreamStats.FlushedNACKCount.
+func (r *RTPSender) FlushRetransmissionHistory() {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, e := range r.trackEncodings {
+		r.flushRetransmissionHistory(e)
+	}
+}
+
+// SetRetransmissionFlushPolicy configures when the retransmission history is flushed automatically.
+func (r *RTPSender) SetRetransmissionFlushPolicy(policy RetransmissionFlushPolicy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.retransmissionFlushPolicy = policy
+	for _, e := range r.trackEncodings {
+		r.configureKeyframeFlush(e)
+	}
+}
+
+// flushRetransmissionHistory marks the packets written so far as flushed, the NACKs for them
+// are removed before the NACK responder reads them. The interceptors stay bound, so the
+// counters of the Sender Reports aren't reset.
+func (r *RTPSender) flushRetransmissionHistory(e *trackEncoding) {
+	e.retransmissions.mu.Lock()
+	defer e.retransmissions.mu.Unlock()
+
+	if e.writeStream == nil {
+		return
+	}
+
+	e.retransmissions.flushed()
+}
+
+// configureKeyframeFlush installs the keyframe flush of e if the policy asks for it.
+func (r *RTPSender) configureKeyframeFlush(e *trackEncoding) {
+	if e.writeStream == nil {
+		return
+	}
+
+	var isKeyframeStart keyframeDetector
+	if r.retransmissionFlushPolicy.OnKeyframe && len(e.context.params.Codecs) != 0 {
+		isKeyframeStart = keyframeDetectorForMimeType(e.context.params.Codecs[0].MimeType)
+	}
+	if isKeyframeStart == nil {
+		e.writeStream.keyframeFlush.Store(nil)
+
+		return
+	}
+
+	e.writeStream.keyframeFlush.Store(&keyframeFlush{
+		isKeyframeStart: isKeyframeStart,
+		flush: func() {
+			r.flushRetransmissionHistory(e)
+		},
+	})
+}
@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRetransmissionHistoryFlushedNACKs(t *testing.T) {
+	history := &retransmissionHistory{}
+	marshalNACK := func(ssrc uint32, sequenceNumbers ...uint16) []byte {
+		buf, err := (&rtcp.TransportLayerNack{
+			MediaSSRC: ssrc,
+			Nacks:     rtcp.NackPairsFromSequenceNumbers(sequenceNumbers),
+		}).Marshal()
+		assert.NoError(t, err)
+
+		return buf
+	}
+
+	// Nothing is counted or removed before a flush
+	history.written(65534)
+	buf := marshalNACK(5000, 65534)
+	assert.Equal(t, len(buf), history.filterFlushedNACKs(5000, buf))
+	assert.Zero(t, history.flushedNACKs.Load())
+
+	history.written(65535)
+	history.flushed()
+
+	// Retransmissions on the media SSRC don't move the boundary back
+	history.written(0)
+	history.written(65535)
+	history.written(1)
+
+	// Only the sequence numbers written after the flush are left for the NACK responder
+	buf = marshalNACK(5000, 65533, 65534, 65535, 0, 1)
+	n := history.filterFlushedNACKs(5000, buf)
+	assert.Equal(t, uint64(3), history.flushedNACKs.Load())
+	pkts, err := rtcp.Unmarshal(buf[:n])
+	assert.NoError(t, err)
+	assert.Equal(t, []rtcp.Packet{&rtcp.TransportLayerNack{
+		MediaSSRC: 5000,
+		Nacks:     rtcp.NackPairsFromSequenceNumbers([]uint16{0, 1}),
+	}}, pkts)
+
+	// RTCP that only NACKs flushed packets is dropped
+	assert.Zero(t, history.filterFlushedNACKs(5000, marshalNACK(5000, 65534)))
+	assert.Equal(t, uint64(4), history.flushedNACKs.Load())
+
+	buf = marshalNACK(6000, 65535)
+	assert.Equal(t, len(buf), history.filterFlushedNACKs(5000, buf))
+	assert.Equal(t, uint64(4), history.flushedNACKs.Load())
+}
+
+func TestRetransmissionHistoryFlushBoundaryCleared(t *testing.T) {
+	history := &retransmissionHistory{}
+	buf, err := (&rtcp.TransportLayerNack{
+		MediaSSRC: 5000,
+		Nacks:     rtcp.NackPairsFromSequenceNumbers([]uint16{100}),
+	}).Marshal()
+	assert.NoError(t, err)
+
+	history.written(100)
+	history.flushed()
+	assert.Zero(t, history.filterFlushedNACKs(5000, buf))
+	assert.Equal(t, uint64(1), history.flushedNACKs.Load())
+
+	// Once the sequence numbers wrapped past the flush, the NACKs for them are kept
+	sequenceNumber := uint16(100)
+	for range 1 << 16 {
+		sequenceNumber++
+		history.written(sequenceNumber)
+	}
+	assert.Equal(t, uint16(100), sequenceNumber)
+	assert.Equal(t, len(buf), history.filterFlushedNACKs(5000, buf))
+	assert.Equal(t, uint64(1), history.flushedNACKs.Load())
+}
+
+func TestKeyframeFlush(t *testing.T) {
+	flushes := 0
+	flush := &keyframeFlush{
+		isKeyframeStart: func(payload []byte) bool { return payload[0] == 1 },
+		flush:           func() { flushes++ },
+	}
+
+	flush.observe(&rtp.Header{Timestamp: 0}, []byte{0})
+	assert.Equal(t, 0, flushes)
+
+	flush.observe(&rtp.Header{Timestamp: 0}, []byte{1})
+	assert.Equal(t, 1, flushes)
+
+	// Another packet of the same keyframe
+	flush.observe(&rtp.Header{Timestamp: 0}, []byte{1})
+	assert.Equal(t, 1, flushes)
+
+	flush.observe(&rtp.Header{Timestamp: 3000}, []byte{1})
+	assert.Equal(t, 2, flushes)
+}
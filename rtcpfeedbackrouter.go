@@ -0,0 +1,259 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/pion/rtcp"
+)
+
+// FeedbackPolicy configures how a RTCPFeedbackRouter routes RTCP packets.
+type FeedbackPolicy struct {
+	// Rules maps feedback to the action taken for it, e.g.
+	// RTCPFeedback{Type: TypeRTCPFBNACK, Parameter: "pli"} for Picture Loss Indications.
+	// Only NACK, PLI and FIR can be forwarded upstream.
+	Rules map[RTCPFeedback]FeedbackAction
+
+	// Default is the action for feedback without a rule and for other RTCP packets,
+	// like Receiver Reports. They are dropped if it is unset.
+	Default FeedbackAction
+
+	// KeyframeRequestInterval is the minimum interval between the keyframe requests (PLI and FIR)
+	// forwarded upstream, requests in between are rate limited. Defaults to 500ms.
+	KeyframeRequestInterval time.Duration
+}
+
+// DefaultFeedbackPolicy answers NACKs locally from the packet cache of the
+// NACK responder and forwards keyframe requests upstream.
+func DefaultFeedbackPolicy() FeedbackPolicy {
+	return FeedbackPolicy{
+		Rules: map[RTCPFeedback]FeedbackAction{
+			{Type: TypeRTCPFBNACK}:                   FeedbackActionAnswerLocally,
+			{Type: TypeRTCPFBNACK, Parameter: "pli"}: FeedbackActionForwardUpstream,
+			{Type: TypeRTCPFBCCM, Parameter: "fir"}:  FeedbackActionForwardUpstream,
+		},
+		Default: FeedbackActionAnswerLocally,
+	}
+}
+
+// RTCPFeedbackRouterStats counts the decisions of a RTCPFeedbackRouter.
+type RTCPFeedbackRouterStats struct {
+	AnsweredLocally   uint64
+	ForwardedUpstream uint64
+	Dropped           uint64
+	// RateLimited are the keyframe requests that weren't forwarded because
+	// of FeedbackPolicy.KeyframeRequestInterval.
+	RateLimited uint64
+}
+
+// RTCPFeedbackRouter routes the RTCP feedback of the RTPSenders forwarding a
+// TrackRemote to other PeerConnections, either answering it locally, forwarding
+// it to the PeerConnection receiving the track, or dropping it.
+type RTCPFeedbackRouter struct {
+	mu sync.Mutex
+
+	upstream *TrackRemote
+	policy   FeedbackPolicy
+
+	lastKeyframeRequest time.Time
+	firSequenceNumber   uint8
+
+	stats RTCPFeedbackRouterStats
+}
+
+// NewRTCPFeedbackRouter creates a RTCPFeedbackRouter for feedback about the media of upstream.
+func NewRTCPFeedbackRouter(upstream *TrackRemote, policy FeedbackPolicy) (*RTCPFeedbackRouter, error) {
+	if upstream == nil {
+		return nil, errRTCPFeedbackRouterTrackNil
+	}
+
+	if err := validateFeedbackAction(policy.Default, false); err != nil {
+		return nil, err
+	}
+	for feedback, action := range policy.Rules {
+		if err := validateFeedbackAction(action, isForwardableFeedback(feedback)); err != nil {
+			return nil, err
+		}
+	}
+
+	if policy.KeyframeRequestInterval == 0 {
+		policy.KeyframeRequestInterval = defaultKeyframeRequestInterval
+	}
+
+	return &RTCPFeedbackRouter{upstream: upstream, policy: policy}, nil
+}
+
+func validateFeedbackAction(action FeedbackAction, forwardable bool) error {
+	switch action {
+	case FeedbackActionUnknown, FeedbackActionAnswerLocally, FeedbackActionDrop:
+		return nil
+	case FeedbackActionForwardUpstream:
+		if !forwardable {
+			return errRTCPFeedbackRouterCannotForward
+		}
+
+		return nil
+	default:
+		return errRTCPFeedbackRouterInvalidAction
+	}
+}
+
+func isForwardableFeedback(feedback RTCPFeedback) bool {
+	switch feedback {
+	case RTCPFeedback{Type: TypeRTCPFBNACK},
+		RTCPFeedback{Type: TypeRTCPFBNACK, Parameter: "pli"},
+		RTCPFeedback{Type: TypeRTCPFBCCM, Parameter: "fir"}:
+		return true
+	default:
+		return false
+	}
+}
+
+// rtcpFeedbackOf returns the feedback a RTCP packet carries, ok is false if it isn't feedback.
+func rtcpFeedbackOf(pkt rtcp.Packet) (feedback RTCPFeedback, ok bool) {
+	switch pkt.(type) {
+	case *rtcp.TransportLayerNack:
+		return RTCPFeedback{Type: TypeRTCPFBNACK}, true
+	case *rtcp.PictureLossIndication:
+		return RTCPFeedback{Type: TypeRTCPFBNACK, Parameter: "pli"}, true
+	case *rtcp.FullIntraRequest:
+		return RTCPFeedback{Type: TypeRTCPFBCCM, Parameter: "fir"}, true
+	case *rtcp.ReceiverEstimatedMaximumBitrate:
+		return RTCPFeedback{Type: TypeRTCPFBGoogREMB}, true
+	case *rtcp.TransportLayerCC:
+		return RTCPFeedback{Type: TypeRTCPFBTransportCC}, true
+	default:
+		return RTCPFeedback{}, false
+	}
+}
+
+// RouteRTCP routes the RTCP packets of sender until sender is stopped. The packets are routed
+// as they are read, before the interceptors of sender see them, so only the packets answered
+// locally reach the interceptors, like the NACK responder, and the application. RouteRTCP reads
+// the RTCP of sender itself, the application must not call ReadRTCP on sender meanwhile.
+// It returns the first error of reading or forwarding.
+func (r *RTCPFeedbackRouter) RouteRTCP(sender *RTPSender) error {
+	sender.feedbackRouter.Store(r)
+	defer sender.feedbackRouter.CompareAndSwap(r, nil)
+
+	for {
+		if _, _, err := sender.ReadRTCP(); err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, io.ErrClosedPipe) {
+				return nil
+			}
+
+			return err
+		}
+	}
+}
+
+// routeRTCP routes the RTCP packets in buf, the packets answered locally are left in buf.
+// It returns their length, which is 0 if none are left.
+func (r *RTCPFeedbackRouter) routeRTCP(buf []byte) (int, error) {
+	pkts, err := rtcp.Unmarshal(buf)
+	if err != nil {
+		return len(buf), nil //nolint:nilerr // the interceptors handle malformed RTCP
+	}
+
+	local, err := r.route(pkts)
+	switch {
+	case len(local) == len(pkts):
+		return len(buf), err
+	case len(local) == 0:
+		return 0, err
+	}
+
+	// A subset of the packets never needs more space
+	out, marshalErr := rtcp.Marshal(local)
+	if marshalErr != nil || len(out) > len(buf) {
+		return len(buf), err
+	}
+
+	return copy(buf, out), err
+}
+
+// route routes RTCP packets read from a RTPSender forwarding the upstream track, and returns
+// the packets answered locally. Forwarded packets are addressed to the upstream media source
+// and written to the PeerConnection receiving it.
+func (r *RTCPFeedbackRouter) route(pkts []rtcp.Packet) ([]rtcp.Packet, error) {
+	upstreamSSRC := uint32(r.upstream.SSRC())
+
+	r.mu.Lock()
+	local := make([]rtcp.Packet, 0, len(pkts))
+	forward := []rtcp.Packet{}
+	for _, pkt := range pkts {
+		action := r.policy.Default
+		if feedback, ok := rtcpFeedbackOf(pkt); ok {
+			if ruleAction, hasRule := r.policy.Rules[feedback]; hasRule {
+				action = ruleAction
+			}
+		}
+
+		switch action {
+		case FeedbackActionAnswerLocally:
+			local = append(local, pkt)
+			r.stats.AnsweredLocally++
+		case FeedbackActionForwardUpstream:
+			if upstreamPkt := r.upstreamPacket(pkt, upstreamSSRC); upstreamPkt != nil {
+				forward = append(forward, upstreamPkt)
+				r.stats.ForwardedUpstream++
+			} else {
+				r.stats.RateLimited++
+			}
+		default:
+			r.stats.Dropped++
+		}
+	}
+	r.mu.Unlock()
+
+	if len(forward) == 0 {
+		return local, nil
+	}
+
+	_, err := r.upstream.receiver.Transport().WriteRTCP(forward)
+
+	return local, err
+}
+
+// upstreamPacket returns pkt addressed to the upstream media source, or nil if it
+// is a keyframe request that is rate limited.
+func (r *RTCPFeedbackRouter) upstreamPacket(pkt rtcp.Packet, upstreamSSRC uint32) rtcp.Packet {
+	switch pkt := pkt.(type) {
+	case *rtcp.TransportLayerNack:
+		return &rtcp.TransportLayerNack{MediaSSRC: upstreamSSRC, Nacks: pkt.Nacks}
+	case *rtcp.PictureLossIndication, *rtcp.FullIntraRequest:
+		now := time.Now()
+		if !r.lastKeyframeRequest.IsZero() && now.Sub(r.lastKeyframeRequest) < r.policy.KeyframeRequestInterval {
+			return nil
+		}
+		r.lastKeyframeRequest = now
+
+		if _, ok := pkt.(*rtcp.FullIntraRequest); ok {
+			r.firSequenceNumber++
+
+			return &rtcp.FullIntraRequest{
+				MediaSSRC: upstreamSSRC,
+				FIR:       []rtcp.FIREntry{{SSRC: upstreamSSRC, SequenceNumber: r.firSequenceNumber}},
+			}
+		}
+
+		return &rtcp.PictureLossIndication{MediaSSRC: upstreamSSRC}
+	default:
+		return nil
+	}
+}
+
+// Stats returns the number of routing decisions made so far.
+func (r *RTCPFeedbackRouter) Stats() RTCPFeedbackRouterStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return r.stats
+}
@@ -0,0 +1,275 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	mock_interceptor "github.com/pion/interceptor/pkg/mock"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewRTCPFeedbackRouter(t *testing.T) {
+	track := newTrackRemote(RTPCodecTypeVideo, 1, 0, "", nil)
+
+	_, err := NewRTCPFeedbackRouter(nil, DefaultFeedbackPolicy())
+	assert.ErrorIs(t, err, errRTCPFeedbackRouterTrackNil)
+
+	_, err = NewRTCPFeedbackRouter(track, FeedbackPolicy{Default: FeedbackActionForwardUpstream})
+	assert.ErrorIs(t, err, errRTCPFeedbackRouterCannotForward)
+
+	_, err = NewRTCPFeedbackRouter(track, FeedbackPolicy{
+		Rules: map[RTCPFeedback]FeedbackAction{{Type: TypeRTCPFBGoogREMB}: FeedbackActionForwardUpstream},
+	})
+	assert.ErrorIs(t, err, errRTCPFeedbackRouterCannotForward)
+
+	_, err = NewRTCPFeedbackRouter(track, FeedbackPolicy{
+		Rules: map[RTCPFeedback]FeedbackAction{{Type: TypeRTCPFBNACK}: FeedbackAction(42)},
+	})
+	assert.ErrorIs(t, err, errRTCPFeedbackRouterInvalidAction)
+
+	router, err := NewRTCPFeedbackRouter(track, DefaultFeedbackPolicy())
+	assert.NoError(t, err)
+	assert.Equal(t, defaultKeyframeRequestInterval, router.policy.KeyframeRequestInterval)
+}
+
+// Assert that in a publisher -> SFU -> subscriber chain NACKs of the subscriber are
+// answered by the SFU, while its PLIs are forwarded to the publisher at most once
+// per KeyframeRequestInterval.
+func TestRTCPFeedbackRouter_Forwarding(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const nackedSequenceNumber = 0xBEEF
+
+	publisher, sfuIn, err := newPair()
+	require.NoError(t, err)
+	sfuOut, subscriber, err := newPair()
+	require.NoError(t, err)
+
+	publisherTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	publisherSender, err := publisher.AddTrack(publisherTrack)
+	require.NoError(t, err)
+
+	sfuTrack, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "sfu")
+	require.NoError(t, err)
+	sfuSender, err := sfuOut.AddTrack(sfuTrack)
+	require.NoError(t, err)
+
+	var upstreamPLIs, upstreamNACKs atomic.Int32
+	go func() {
+		for {
+			pkts, _, readErr := publisherSender.ReadRTCP()
+			if readErr != nil {
+				return
+			}
+			for _, pkt := range pkts {
+				switch pkt := pkt.(type) {
+				case *rtcp.PictureLossIndication:
+					upstreamPLIs.Add(1)
+				case *rtcp.TransportLayerNack:
+					for _, nack := range pkt.Nacks {
+						if nack.PacketID == nackedSequenceNumber {
+							upstreamNACKs.Add(1)
+						}
+					}
+				}
+			}
+		}
+	}()
+
+	routerCreated := make(chan *RTCPFeedbackRouter, 1)
+	sfuIn.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		policy := DefaultFeedbackPolicy()
+		policy.Default = FeedbackActionDrop
+		policy.KeyframeRequestInterval = time.Minute
+		router, routerErr := NewRTCPFeedbackRouter(track, policy)
+		assert.NoError(t, routerErr)
+		routerCreated <- router
+
+		go func() {
+			assert.NoError(t, router.RouteRTCP(sfuSender))
+		}()
+
+		for {
+			pkt, _, readErr := track.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			if writeErr := sfuTrack.WriteRTP(pkt); writeErr != nil {
+				return
+			}
+		}
+	})
+
+	subscriberTrack := make(chan *TrackRemote, 1)
+	subscriber.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		subscriberTrack <- track
+	})
+
+	require.NoError(t, signalPair(publisher, sfuIn))
+	require.NoError(t, signalPair(sfuOut, subscriber))
+
+	var router *RTCPFeedbackRouter
+	var track *TrackRemote
+	func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+
+		for router == nil || track == nil {
+			select {
+			case router = <-routerCreated:
+			case track = <-subscriberTrack:
+			case <-ticker.C:
+				assert.NoError(t, publisherTrack.WriteSample(media.Sample{Data: []byte{0x00}, Duration: time.Second}))
+			}
+		}
+	}()
+
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, subscriber.WriteRTCP([]rtcp.Packet{
+			&rtcp.PictureLossIndication{MediaSSRC: uint32(track.SSRC())},
+			&rtcp.TransportLayerNack{
+				MediaSSRC: uint32(track.SSRC()),
+				Nacks:     []rtcp.NackPair{{PacketID: nackedSequenceNumber}},
+			},
+		}))
+	}
+
+	assert.Eventually(t, func() bool {
+		stats := router.Stats()
+
+		return stats.ForwardedUpstream+stats.RateLimited == 5 && stats.AnsweredLocally == 5
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Eventually(t, func() bool {
+		return upstreamPLIs.Load() == 1
+	}, 5*time.Second, 10*time.Millisecond)
+
+	stats := router.Stats()
+	assert.Equal(t, uint64(1), stats.ForwardedUpstream)
+	assert.Equal(t, uint64(4), stats.RateLimited)
+	assert.Equal(t, uint64(5), stats.AnsweredLocally)
+	assert.Equal(t, int32(1), upstreamPLIs.Load())
+	assert.Equal(t, int32(0), upstreamNACKs.Load())
+
+	closePairNow(t, sfuOut, subscriber)
+	closePairNow(t, publisher, sfuIn)
+}
+
+// Assert that dropped NACKs never reach the NACK responder, so nothing is retransmitted.
+func TestRTCPFeedbackRouter_DropNACK(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Registered first, so it sees the packets the NACK responder retransmits
+	retransmitted := make(chan uint16, 100)
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	ir := &interceptor.Registry{}
+	ir.Add(&mock_interceptor.Factory{
+		NewInterceptorFn: func(_ string) (interceptor.Interceptor, error) {
+			return &mock_interceptor.Interceptor{
+				BindLocalStreamFn: func(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+					return interceptor.RTPWriterFunc(
+						func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+							if header.SSRC == info.SSRCRetransmission && len(payload) >= 2 {
+								retransmitted <- binary.BigEndian.Uint16(payload)
+							}
+
+							return writer.Write(header, payload, attributes)
+						},
+					)
+				},
+			}, nil
+		},
+	})
+	assert.NoError(t, RegisterDefaultInterceptors(mediaEngine, ir))
+
+	sender, err := NewAPI(WithMediaEngine(mediaEngine), WithInterceptorRegistry(ir)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	receiver, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	rtpSender, err := sender.AddTrack(track)
+	assert.NoError(t, err)
+
+	router, err := NewRTCPFeedbackRouter(newTrackRemote(RTPCodecTypeVideo, 1, 0, "", nil), FeedbackPolicy{
+		Rules:   map[RTCPFeedback]FeedbackAction{{Type: TypeRTCPFBNACK}: FeedbackActionDrop},
+		Default: FeedbackActionAnswerLocally,
+	})
+	assert.NoError(t, err)
+	routed := make(chan error, 1)
+	go func() {
+		routed <- router.RouteRTCP(rtpSender)
+	}()
+
+	received := make(chan struct{}, 100)
+	receiver.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(sender, receiver))
+
+	sequenceNumber := uint16(0)
+	func() {
+		ticker := time.NewTicker(time.Millisecond * 20)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-received:
+				return
+			case <-ticker.C:
+				sequenceNumber++
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+					Payload: []byte{0x10, 0x00},
+				}))
+			}
+		}
+	}()
+
+	assert.NoError(t, receiver.WriteRTCP([]rtcp.Packet{&rtcp.TransportLayerNack{
+		MediaSSRC: uint32(rtpSender.GetParameters().Encodings[0].SSRC),
+		Nacks:     rtcp.NackPairsFromSequenceNumbers([]uint16{sequenceNumber}),
+	}}))
+
+	assert.Eventually(t, func() bool {
+		return router.Stats().Dropped == 1
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Never(t, func() bool {
+		return len(retransmitted) != 0
+	}, 200*time.Millisecond, 10*time.Millisecond)
+
+	closePairNow(t, sender, receiver)
+	assert.NoError(t, <-routed)
+}
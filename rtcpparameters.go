@@ -0,0 +1,12 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// RTCPParameters contains the negotiated RTCP settings of a RTPSender or RTPReceiver.
+//
+// https://w3c.github.io/webrtc-pc/#dom-rtcrtcpparameters
+type RTCPParameters struct {
+	// ReducedSize is set if reduced-size RTCP was negotiated, see RFC 5506.
+	ReducedSize bool
+}
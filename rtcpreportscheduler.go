@@ -0,0 +1,369 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/logging"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+)
+
+const (
+	// rtcpReportMinInterval is the smallest interval of the RFC 3550 rules, and matches the
+	// default interval of the report interceptors.
+	rtcpReportMinInterval = time.Second
+
+	// rtcpReportBandwidthFraction is the share of the session bandwidth used by RTCP.
+	rtcpReportBandwidthFraction = 0.05
+
+	// rtcpReportCompensation compensates the randomization of the RFC 3550 interval, see
+	// RFC 3550 Appendix A.7.
+	rtcpReportCompensation = 2.71828 - 1.5
+
+	// rtcpReportInitialSize is the assumed size of a single report before any was sent.
+	rtcpReportInitialSize = 60
+
+	// rtcpReportOverhead is the size of the IP and UDP headers, which RFC 3550 counts.
+	rtcpReportOverhead = 28
+)
+
+// immediateRTCPKey marks RTCP written by the application, which is never held back.
+type immediateRTCPKey struct{}
+
+// rtcpReportID identifies the stream a report is about, so a newer report replaces an older
+// one that wasn't sent yet.
+type rtcpReportID struct {
+	sender bool
+	ssrc   uint32
+}
+
+// pendingRTCPReport is a report held back by the rtcpReportScheduler, queued is when it was
+// generated.
+type pendingRTCPReport struct {
+	pkt    rtcp.Packet
+	queued time.Time
+}
+
+// rtcpReportScheduler holds back the Sender and Receiver Reports generated by the interceptors
+// and sends them as compound packets at a randomized interval, see RFC 3550 Section 6.2. It is
+// the first interceptor of the chain, so it sees every RTCP write and the RTP of all streams.
+// The timestamps of the reports are advanced by the time they were held back when they are
+// sent, so the round-trip time both peers compute from them doesn't include it.
+type rtcpReportScheduler struct {
+	interceptor.NoOp
+
+	interval     time.Duration
+	maxBatchSize int
+	log          logging.LeveledLogger
+
+	mu            sync.Mutex
+	pending       []pendingRTCPReport
+	streams       int
+	avgReportSize float64
+	// clockRates are the clock rates of the local streams by SSRC, which advance the RTP
+	// timestamps of their Sender Reports.
+	clockRates map[uint32]uint32
+
+	// rtpBytes is the RTP sent and received since the last reports, rtpSince is when the
+	// first of it was counted. Media that starts late in an interval doesn't look like a
+	// low bandwidth session this way.
+	rtpBytes atomic.Uint64
+	rtpSince atomic.Int64
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	done      chan struct{}
+	wg        sync.WaitGroup
+}
+
+// newRTCPReportScheduler creates a rtcpReportScheduler. Leave interval 0 to use the rules of
+// RFC 3550. The reports sent together are at most maxBatchSize bytes.
+func newRTCPReportScheduler(
+	interval time.Duration, maxBatchSize int, loggerFactory logging.LoggerFactory,
+) *rtcpReportScheduler {
+	return &rtcpReportScheduler{
+		interval:      interval,
+		maxBatchSize:  maxBatchSize,
+		log:           loggerFactory.NewLogger("rtcp_reports"),
+		avgReportSize: rtcpReportInitialSize,
+		clockRates:    map[uint32]uint32{},
+		done:          make(chan struct{}),
+	}
+}
+
+// BindRTCPWriter holds back the reports and sends everything else right away.
+func (s *rtcpReportScheduler) BindRTCPWriter(writer interceptor.RTCPWriter) interceptor.RTCPWriter {
+	s.startOnce.Do(func() {
+		s.wg.Add(1)
+		go s.loop(writer)
+	})
+
+	return interceptor.RTCPWriterFunc(func(pkts []rtcp.Packet, attributes interceptor.Attributes) (int, error) {
+		if _, immediate := attributes[immediateRTCPKey{}]; immediate || !isRTCPReports(pkts) {
+			return writer.Write(pkts, attributes)
+		}
+
+		s.enqueue(pkts)
+
+		return 0, nil
+	})
+}
+
+// BindLocalStream counts the sent RTP, which makes up the session bandwidth.
+func (s *rtcpReportScheduler) BindLocalStream(
+	info *interceptor.StreamInfo, writer interceptor.RTPWriter,
+) interceptor.RTPWriter {
+	s.mu.Lock()
+	s.streams++
+	s.clockRates[info.SSRC] = info.ClockRate
+	s.mu.Unlock()
+
+	return interceptor.RTPWriterFunc(
+		func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+			n, err := writer.Write(header, payload, attributes)
+			if n > 0 {
+				s.countRTP(n)
+			}
+
+			return n, err
+		},
+	)
+}
+
+// UnbindLocalStream removes the stream from the members of the session.
+func (s *rtcpReportScheduler) UnbindLocalStream(info *interceptor.StreamInfo) {
+	s.mu.Lock()
+	s.streams--
+	delete(s.clockRates, info.SSRC)
+	s.mu.Unlock()
+}
+
+// BindRemoteStream counts the received RTP, which makes up the session bandwidth.
+func (s *rtcpReportScheduler) BindRemoteStream(
+	_ *interceptor.StreamInfo, reader interceptor.RTPReader,
+) interceptor.RTPReader {
+	s.mu.Lock()
+	s.streams++
+	s.mu.Unlock()
+
+	return interceptor.RTPReaderFunc(
+		func(b []byte, attributes interceptor.Attributes) (int, interceptor.Attributes, error) {
+			n, attributes, err := reader.Read(b, attributes)
+			if n > 0 {
+				s.countRTP(n)
+			}
+
+			return n, attributes, err
+		},
+	)
+}
+
+// UnbindRemoteStream removes the stream from the members of the session.
+func (s *rtcpReportScheduler) UnbindRemoteStream(*interceptor.StreamInfo) {
+	s.mu.Lock()
+	s.streams--
+	s.mu.Unlock()
+}
+
+// Close stops sending reports. Reports that are still held back are dropped.
+func (s *rtcpReportScheduler) Close() error {
+	s.closeOnce.Do(func() {
+		close(s.done)
+	})
+	s.wg.Wait()
+
+	return nil
+}
+
+// countRTP adds a RTP packet of n bytes to the session bandwidth.
+func (s *rtcpReportScheduler) countRTP(n int) {
+	if s.rtpSince.Load() == 0 {
+		s.rtpSince.CompareAndSwap(0, time.Now().UnixNano())
+	}
+	s.rtpBytes.Add(uint64(n + rtcpReportOverhead)) //nolint:gosec // n is positive
+}
+
+func (s *rtcpReportScheduler) enqueue(pkts []rtcp.Packet) {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, pkt := range pkts {
+		s.pending = appendRTCPReport(s.pending, pendingRTCPReport{pkt: pkt, queued: now})
+	}
+}
+
+func (s *rtcpReportScheduler) loop(writer interceptor.RTCPWriter) {
+	defer s.wg.Done()
+
+	timer := time.NewTimer(s.nextInterval())
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-s.done:
+			return
+		case <-timer.C:
+			s.flush(writer)
+			timer.Reset(s.nextInterval())
+		}
+	}
+}
+
+// nextInterval returns the randomized time until the next reports are sent. The session
+// bandwidth is measured over the RTP since the last ones.
+func (s *rtcpReportScheduler) nextInterval() time.Duration {
+	factor := rand.Float64() + 0.5 //nolint:gosec // timing jitter doesn't need to be secure
+	if s.interval != 0 {
+		return time.Duration(float64(s.interval) * factor)
+	}
+
+	var sessionBandwidth float64
+	since, bytes := s.rtpSince.Swap(0), s.rtpBytes.Swap(0)
+	if elapsed := time.Since(time.Unix(0, since)); since != 0 && elapsed > 0 {
+		sessionBandwidth = float64(bytes) / elapsed.Seconds()
+	}
+
+	s.mu.Lock()
+	members, avgReportSize := max(s.streams, 2), s.avgReportSize
+	s.mu.Unlock()
+
+	interval := rtcpReportInterval(members, sessionBandwidth, avgReportSize)
+
+	return time.Duration(float64(interval) * factor / rtcpReportCompensation)
+}
+
+// flush sends the held back reports, as few compound packets as fit in a datagram each.
+func (s *rtcpReportScheduler) flush(writer interceptor.RTCPWriter) {
+	now := time.Now()
+	s.mu.Lock()
+	pending := s.pending
+	s.pending = nil
+	for _, report := range pending {
+		s.advanceReport(report.pkt, now.Sub(report.queued))
+	}
+	s.mu.Unlock()
+
+	var batch []rtcp.Packet
+	size := 0
+	for _, report := range pending {
+		pkt := report.pkt
+		pktSize := pkt.MarshalSize()
+		if len(batch) > 0 && size+pktSize > s.maxBatchSize {
+			s.write(writer, batch, size)
+			batch, size = nil, 0
+		}
+		batch = append(batch, pkt)
+		size += pktSize
+	}
+
+	if len(batch) > 0 {
+		s.write(writer, batch, size)
+	}
+}
+
+// advanceReport moves the timestamps of a report held back for held to the time it is sent.
+// The NTP and RTP timestamps of a Sender Report keep matching each other, and the delay since
+// the last Sender Report of every reception report grows by held. s.mu must be held.
+func (s *rtcpReportScheduler) advanceReport(pkt rtcp.Packet, held time.Duration) {
+	var reports []rtcp.ReceptionReport
+	switch pkt := pkt.(type) {
+	case *rtcp.SenderReport:
+		// A zero NTP timestamp means the sender has no wallclock, see RFC 3550 Section 6.4.1
+		if pkt.NTPTime != 0 {
+			pkt.NTPTime += uint64(held.Seconds() * (1 << 32))
+			pkt.RTPTime += uint32(held.Seconds() * float64(s.clockRates[pkt.SSRC]))
+		}
+		reports = pkt.Reports
+	case *rtcp.ReceiverReport:
+		reports = pkt.Reports
+	}
+
+	for i := range reports {
+		// The delay is in units of 1/65536 seconds, and 0 if no Sender Report was received
+		if reports[i].LastSenderReport != 0 {
+			reports[i].Delay += uint32(held.Seconds() * (1 << 16))
+		}
+	}
+}
+
+func (s *rtcpReportScheduler) write(writer interceptor.RTCPWriter, batch []rtcp.Packet, size int) {
+	if _, err := writer.Write(batch, interceptor.Attributes{}); err != nil {
+		s.log.Warnf("Failed to send RTCP reports: %v", err)
+
+		return
+	}
+
+	// Every report of the batch stands for one member, which RFC 3550 expects to send its own
+	// compound packet
+	s.mu.Lock()
+	s.avgReportSize += (float64(size+rtcpReportOverhead)/float64(len(batch)) - s.avgReportSize) / 16
+	s.mu.Unlock()
+}
+
+// rtcpReportInterval computes the deterministic interval of RFC 3550 Section 6.3.1. The
+// reports of all members share 5% of the session bandwidth, which is given in bytes per second,
+// and avgReportSize is the average size of the report of a single member.
+func rtcpReportInterval(members int, sessionBandwidth, avgReportSize float64) time.Duration {
+	rtcpBandwidth := sessionBandwidth * rtcpReportBandwidthFraction
+	if rtcpBandwidth <= 0 {
+		return rtcpReportMinInterval
+	}
+
+	interval := time.Duration(avgReportSize * float64(members) / rtcpBandwidth * float64(time.Second))
+
+	return max(interval, rtcpReportMinInterval)
+}
+
+// isRTCPReports returns true if pkts only contains Sender and Receiver Reports.
+func isRTCPReports(pkts []rtcp.Packet) bool {
+	for _, pkt := range pkts {
+		switch pkt.(type) {
+		case *rtcp.SenderReport, *rtcp.ReceiverReport:
+		default:
+			return false
+		}
+	}
+
+	return len(pkts) != 0
+}
+
+// appendRTCPReport appends report to pending, replacing a report about the same stream.
+func appendRTCPReport(pending []pendingRTCPReport, report pendingRTCPReport) []pendingRTCPReport {
+	id, ok := rtcpReportIDOf(report.pkt)
+	if !ok {
+		return append(pending, report)
+	}
+
+	for i := range pending {
+		if pendingID, pendingOK := rtcpReportIDOf(pending[i].pkt); pendingOK && pendingID == id {
+			pending[i] = report
+
+			return pending
+		}
+	}
+
+	return append(pending, report)
+}
+
+func rtcpReportIDOf(pkt rtcp.Packet) (rtcpReportID, bool) {
+	switch pkt := pkt.(type) {
+	case *rtcp.SenderReport:
+		return rtcpReportID{sender: true, ssrc: pkt.SSRC}, true
+	case *rtcp.ReceiverReport:
+		if len(pkt.Reports) == 1 {
+			return rtcpReportID{ssrc: pkt.Reports[0].SSRC}, true
+		}
+	}
+
+	return rtcpReportID{}, false
+}
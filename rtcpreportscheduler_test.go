@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/logging"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTCPReportInterval(t *testing.T) {
+	// Unknown bandwidth and plenty of bandwidth both use the minimum
+	assert.Equal(t, rtcpReportMinInterval, rtcpReportInterval(2, 0, 100))
+	assert.Equal(t, rtcpReportMinInterval, rtcpReportInterval(2, 125000, 100))
+
+	// 50 members reporting 100 bytes each over 5% of 20 kB/s take five seconds
+	assert.Equal(t, 5*time.Second, rtcpReportInterval(50, 20000, 100))
+}
+
+func TestRTCPReportScheduler(t *testing.T) {
+	var writesLock sync.Mutex
+	var writes [][]rtcp.Packet
+	scheduler := newRTCPReportScheduler(50*time.Millisecond, defaultRTCPMaxPacketSize, logging.NewDefaultLoggerFactory())
+	writer := scheduler.BindRTCPWriter(interceptor.RTCPWriterFunc(
+		func(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+			writesLock.Lock()
+			defer writesLock.Unlock()
+
+			writes = append(writes, pkts)
+
+			return 0, nil
+		},
+	))
+
+	receiverReport := func(ssrc uint32, lost uint8) []rtcp.Packet {
+		return []rtcp.Packet{&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{SSRC: ssrc, FractionLost: lost}}}}
+	}
+
+	// Feedback and reports written by the application are sent right away
+	_, err := writer.Write([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: 1}}, interceptor.Attributes{})
+	require.NoError(t, err)
+	_, err = writer.Write(receiverReport(1, 0), interceptor.Attributes{immediateRTCPKey{}: true})
+	require.NoError(t, err)
+
+	// Reports are held back, and a newer one replaces the older one about the same stream
+	for _, pkts := range [][]rtcp.Packet{
+		receiverReport(1, 10), receiverReport(2, 0), receiverReport(1, 20), {&rtcp.SenderReport{SSRC: 3}},
+	} {
+		_, err = writer.Write(pkts, interceptor.Attributes{})
+		require.NoError(t, err)
+	}
+
+	assert.Eventually(t, func() bool {
+		writesLock.Lock()
+		defer writesLock.Unlock()
+
+		return len(writes) == 3
+	}, time.Second, 10*time.Millisecond)
+	require.NoError(t, scheduler.Close())
+
+	assert.Equal(t, []rtcp.Packet{
+		receiverReport(1, 20)[0], receiverReport(2, 0)[0], &rtcp.SenderReport{SSRC: 3},
+	}, writes[2])
+}
+
+func TestRTCPReportScheduler_AdvanceTimestamps(t *testing.T) {
+	written := make(chan []rtcp.Packet, 1)
+	scheduler := newRTCPReportScheduler(50*time.Millisecond, defaultRTCPMaxPacketSize, logging.NewDefaultLoggerFactory())
+	writer := scheduler.BindRTCPWriter(interceptor.RTCPWriterFunc(
+		func(pkts []rtcp.Packet, _ interceptor.Attributes) (int, error) {
+			written <- pkts
+
+			return 0, nil
+		},
+	))
+	scheduler.BindLocalStream(&interceptor.StreamInfo{SSRC: 1, ClockRate: 90000}, interceptor.RTPWriterFunc(
+		func(_ *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+			return len(payload), nil
+		},
+	))
+
+	const (
+		ntpTime = uint64(1) << 40
+		rtpTime = 1000
+	)
+	_, err := writer.Write([]rtcp.Packet{
+		&rtcp.SenderReport{SSRC: 1, NTPTime: ntpTime, RTPTime: rtpTime},
+		&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{SSRC: 2, LastSenderReport: 1, Delay: 100}}},
+		&rtcp.ReceiverReport{Reports: []rtcp.ReceptionReport{{SSRC: 3}}},
+	}, interceptor.Attributes{})
+	require.NoError(t, err)
+
+	pkts := <-written
+	require.NoError(t, scheduler.Close())
+	require.Len(t, pkts, 3)
+
+	// The reports are sent as if they were generated when they are sent
+	senderReport, ok := pkts[0].(*rtcp.SenderReport)
+	require.True(t, ok)
+	held := float64(senderReport.NTPTime-ntpTime) / (1 << 32)
+	assert.GreaterOrEqual(t, held, 0.025)
+	assert.InDelta(t, held*90000, float64(senderReport.RTPTime-rtpTime), 1)
+
+	receiverReport, ok := pkts[1].(*rtcp.ReceiverReport)
+	require.True(t, ok)
+	assert.InDelta(t, held*(1<<16), float64(receiverReport.Reports[0].Delay-100), 1)
+
+	// Without a Sender Report from the remote there is no delay to advance
+	receiverReport, ok = pkts[2].(*rtcp.ReceiverReport)
+	require.True(t, ok)
+	assert.Zero(t, receiverReport.Reports[0].Delay)
+}
+
+func TestRTCPReportScheduler_LateMedia(t *testing.T) {
+	scheduler := newRTCPReportScheduler(0, defaultRTCPMaxPacketSize, logging.NewDefaultLoggerFactory())
+	writer := scheduler.BindLocalStream(&interceptor.StreamInfo{}, interceptor.RTPWriterFunc(
+		func(_ *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
+			return len(payload), nil
+		},
+	))
+
+	// Without RTP the randomized minimum interval is used
+	minInterval := float64(rtcpReportMinInterval)
+	maxInterval := time.Duration(minInterval * 1.5 / rtcpReportCompensation)
+	assert.LessOrEqual(t, scheduler.nextInterval(), maxInterval)
+
+	// Media starting right before the reports is measured over the time it was sent, and
+	// doesn't look like a session of a few bytes per second
+	time.Sleep(100 * time.Millisecond)
+	for range 5 {
+		_, err := writer.Write(&rtp.Header{}, make([]byte, 100), interceptor.Attributes{})
+		require.NoError(t, err)
+	}
+	assert.LessOrEqual(t, scheduler.nextInterval(), maxInterval)
+	require.NoError(t, scheduler.Close())
+}
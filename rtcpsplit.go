@@ -0,0 +1,301 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/srtp/v3"
+)
+
+const (
+	// defaultRTCPMaxPacketSize keeps RTCP within the path MTU of most networks.
+	defaultRTCPMaxPacketSize = 1200
+
+	// rtcpMaxCount is the largest number of report blocks or chunks of a RTCP packet.
+	rtcpMaxCount = 31
+
+	rtcpHeaderSize        = 4
+	rtcpReportBlockSize   = 24
+	rtcpEmptyReceiverSize = 8
+
+	// srtcpIndexSize is the size of the E flag and SRTCP index, RFC 3711 Section 3.4.
+	srtcpIndexSize = 4
+)
+
+// srtcpOverhead returns how many bytes protecting RTCP with profile adds: its authentication
+// tag and the SRTCP index.
+func srtcpOverhead(profile srtp.ProtectionProfile) int {
+	authTagLen, _ := profile.AuthTagRTCPLen()
+	aeadAuthTagLen, _ := profile.AEADAuthTagLen()
+
+	return authTagLen + aeadAuthTagLen + srtcpIndexSize
+}
+
+// rtcpSource is the sender of compound RTCP, RFC 3550 Section 6.1.
+type rtcpSource struct {
+	ssrc  uint32
+	cname string
+}
+
+// description returns the Source Description with the CNAME of s.
+func (s *rtcpSource) description() *rtcp.SourceDescription {
+	return &rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{
+		Source: s.ssrc,
+		Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: s.cname}},
+	}}}
+}
+
+// compoundRTCP returns pkts as a compound packet of source, RFC 3550 Section 6.1: led by a
+// report, an empty Receiver Report if pkts don't start with one, and with a Source
+// Description of the CNAME of source after the reports if pkts have none.
+func compoundRTCP(pkts []rtcp.Packet, source *rtcpSource) []rtcp.Packet {
+	if len(pkts) == 0 {
+		return pkts
+	}
+
+	reports := 0
+	for reports < len(pkts) && isRTCPReport(pkts[reports]) {
+		reports++
+	}
+
+	compound := make([]rtcp.Packet, 0, len(pkts)+2)
+	if reports == 0 {
+		compound = append(compound, &rtcp.ReceiverReport{SSRC: source.ssrc})
+	}
+	compound = append(compound, pkts[:reports]...)
+	if !slices.ContainsFunc(pkts, isRTCPSourceDescription) {
+		compound = append(compound, source.description())
+	}
+
+	return append(compound, pkts[reports:]...)
+}
+
+// splitRTCP spreads pkts across datagrams of at most maxSize bytes. A packet that doesn't fit
+// alone is split: the report blocks of Sender and Receiver Reports go to Receiver Reports of
+// the same SSRC, the chunks of Source Descriptions and the blocks of Extended Reports to
+// packets of their own. With a source, every datagram is a compound packet of it, see
+// compoundRTCP.
+func splitRTCP(pkts []rtcp.Packet, maxSize int, source *rtcpSource) ([][]rtcp.Packet, error) {
+	budget := maxSize
+	if source != nil {
+		budget -= rtcpEmptyReceiverSize + source.description().MarshalSize()
+	}
+
+	var fragments []rtcp.Packet
+	for _, pkt := range pkts {
+		split, err := splitRTCPPacket(pkt, budget)
+		if err != nil {
+			return nil, err
+		}
+		fragments = append(fragments, split...)
+	}
+
+	var datagrams [][]rtcp.Packet
+	var datagram []rtcp.Packet
+	size := 0
+	flush := func() {
+		if source != nil {
+			datagram = compoundRTCP(datagram, source)
+		}
+		datagrams = append(datagrams, datagram)
+		datagram, size = nil, 0
+	}
+	for _, pkt := range fragments {
+		pktSize := rtcpPacketSize(pkt)
+		if len(datagram) > 0 && size+pktSize > budget {
+			flush()
+		}
+		datagram = append(datagram, pkt)
+		size += pktSize
+	}
+	if len(datagram) > 0 {
+		flush()
+	}
+
+	return datagrams, nil
+}
+
+// splitRTCPPacket splits pkt in packets of at most budget bytes.
+//
+//nolint:cyclop
+func splitRTCPPacket(pkt rtcp.Packet, budget int) ([]rtcp.Packet, error) {
+	tooLarge := func(size int) error {
+		return fmt.Errorf("%w: %T of %d bytes, at most %d", ErrRTCPPacketTooLarge, pkt, size, budget)
+	}
+
+	size := rtcpPacketSize(pkt)
+	switch pkt := pkt.(type) {
+	case *rtcp.SenderReport:
+		if size <= budget && len(pkt.Reports) <= rtcpMaxCount {
+			return []rtcp.Packet{pkt}, nil
+		}
+
+		count, headerSize := reportBlocksThatFit(size, len(pkt.Reports), budget)
+		if headerSize > budget {
+			return nil, tooLarge(headerSize)
+		}
+		first := *pkt
+		first.Reports = pkt.Reports[:count]
+
+		return append([]rtcp.Packet{&first}, splitReportBlocks(pkt.SSRC, pkt.Reports[count:], budget)...), nil
+	case *rtcp.ReceiverReport:
+		if size <= budget && len(pkt.Reports) <= rtcpMaxCount {
+			return []rtcp.Packet{pkt}, nil
+		}
+
+		count, headerSize := reportBlocksThatFit(size, len(pkt.Reports), budget)
+		if headerSize > budget {
+			return nil, tooLarge(headerSize)
+		}
+		first := *pkt
+		first.Reports = pkt.Reports[:count]
+
+		return append([]rtcp.Packet{&first}, splitReportBlocks(pkt.SSRC, pkt.Reports[count:], budget)...), nil
+	case *rtcp.SourceDescription:
+		if size <= budget && len(pkt.Chunks) <= rtcpMaxCount {
+			return []rtcp.Packet{pkt}, nil
+		}
+
+		var split []rtcp.Packet
+		current := &rtcp.SourceDescription{}
+		for _, chunk := range pkt.Chunks {
+			next := &rtcp.SourceDescription{
+				Chunks: append(append([]rtcp.SourceDescriptionChunk{}, current.Chunks...), chunk),
+			}
+			if next.MarshalSize() <= budget && len(next.Chunks) <= rtcpMaxCount {
+				current = next
+
+				continue
+			}
+			if len(current.Chunks) == 0 {
+				return nil, tooLarge(next.MarshalSize())
+			}
+			split = append(split, current)
+			current = &rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{chunk}}
+			if current.MarshalSize() > budget {
+				return nil, tooLarge(current.MarshalSize())
+			}
+		}
+
+		return append(split, current), nil
+	case *rtcp.ExtendedReport:
+		if size <= budget {
+			return []rtcp.Packet{pkt}, nil
+		}
+
+		var split []rtcp.Packet
+		current := &rtcp.ExtendedReport{SenderSSRC: pkt.SenderSSRC}
+		for _, block := range pkt.Reports {
+			next := &rtcp.ExtendedReport{
+				SenderSSRC: pkt.SenderSSRC,
+				Reports:    append(append([]rtcp.ReportBlock{}, current.Reports...), block),
+			}
+			if rtcpPacketSize(next) <= budget {
+				current = next
+
+				continue
+			}
+			if len(current.Reports) == 0 {
+				return nil, tooLarge(rtcpPacketSize(next))
+			}
+			split = append(split, current)
+			current = &rtcp.ExtendedReport{SenderSSRC: pkt.SenderSSRC, Reports: []rtcp.ReportBlock{block}}
+			if rtcpPacketSize(current) > budget {
+				return nil, tooLarge(rtcpPacketSize(current))
+			}
+		}
+
+		return append(split, current), nil
+	default:
+		if size > budget {
+			return nil, tooLarge(size)
+		}
+
+		return []rtcp.Packet{pkt}, nil
+	}
+}
+
+// reportBlocksThatFit returns how many of the blocks of a report of size bytes fit in budget
+// bytes, and the size of the report without its blocks.
+func reportBlocksThatFit(size, blocks, budget int) (count, headerSize int) {
+	headerSize = size - blocks*rtcpReportBlockSize
+	if headerSize > budget {
+		return 0, headerSize
+	}
+
+	return min((budget-headerSize)/rtcpReportBlockSize, blocks, rtcpMaxCount), headerSize
+}
+
+// splitReportBlocks returns Receiver Reports of ssrc with reports, each of at most budget bytes.
+func splitReportBlocks(ssrc uint32, reports []rtcp.ReceptionReport, budget int) []rtcp.Packet {
+	count := max(min((budget-rtcpEmptyReceiverSize)/rtcpReportBlockSize, rtcpMaxCount), 1)
+
+	var split []rtcp.Packet
+	for len(reports) > 0 {
+		n := min(count, len(reports))
+		split = append(split, &rtcp.ReceiverReport{SSRC: ssrc, Reports: reports[:n]})
+		reports = reports[n:]
+	}
+
+	return split
+}
+
+// rtcpPacketSize returns the marshaled size of pkt. MarshalSize of Extended Reports doesn't
+// count their header.
+func rtcpPacketSize(pkt rtcp.Packet) int {
+	switch pkt.(type) {
+	case *rtcp.ExtendedReport:
+		return pkt.MarshalSize() + rtcpHeaderSize
+	default:
+		return pkt.MarshalSize()
+	}
+}
+
+func isRTCPSourceDescription(pkt rtcp.Packet) bool {
+	_, ok := pkt.(*rtcp.SourceDescription)
+
+	return ok
+}
+
+func isRTCPReport(pkt rtcp.Packet) bool {
+	switch pkt.(type) {
+	case *rtcp.SenderReport, *rtcp.ReceiverReport:
+		return true
+	default:
+		return false
+	}
+}
+
+// rtcpSenderSSRC returns the SSRC of the sender of pkt, 0 if it isn't known.
+func rtcpSenderSSRC(pkt rtcp.Packet) uint32 {
+	switch pkt := pkt.(type) {
+	case *rtcp.SenderReport:
+		return pkt.SSRC
+	case *rtcp.ReceiverReport:
+		return pkt.SSRC
+	case *rtcp.SourceDescription:
+		if len(pkt.Chunks) != 0 {
+			return pkt.Chunks[0].Source
+		}
+	case *rtcp.ExtendedReport:
+		return pkt.SenderSSRC
+	case *rtcp.PictureLossIndication:
+		return pkt.SenderSSRC
+	case *rtcp.FullIntraRequest:
+		return pkt.SenderSSRC
+	case *rtcp.TransportLayerNack:
+		return pkt.SenderSSRC
+	case *rtcp.TransportLayerCC:
+		return pkt.SenderSSRC
+	case *rtcp.ReceiverEstimatedMaximumBitrate:
+		return pkt.SenderSSRC
+	}
+
+	return 0
+}
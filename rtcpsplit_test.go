@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/pion/dtls/v3"
+	"github.com/pion/rtcp"
+	"github.com/pion/srtp/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// marshalDatagrams checks that every datagram is valid RTCP of at most maxSize bytes, and a
+// compound packet if source is set.
+func marshalDatagrams(t *testing.T, datagrams [][]rtcp.Packet, maxSize int, source *rtcpSource) [][]rtcp.Packet {
+	t.Helper()
+
+	parsed := make([][]rtcp.Packet, 0, len(datagrams))
+	for _, datagram := range datagrams {
+		raw, err := rtcp.Marshal(datagram)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, len(raw), maxSize)
+
+		pkts, err := rtcp.Unmarshal(raw)
+		require.NoError(t, err)
+		if source != nil {
+			assert.True(t, isRTCPReport(pkts[0]), "%T", pkts[0])
+			assert.True(t, slices.ContainsFunc(pkts, isRTCPSourceDescription))
+		}
+		parsed = append(parsed, pkts)
+	}
+
+	return parsed
+}
+
+func receptionReports(count int) []rtcp.ReceptionReport {
+	reports := make([]rtcp.ReceptionReport, count)
+	for i := range reports {
+		reports[i] = rtcp.ReceptionReport{SSRC: uint32(1000 + i), LastSequenceNumber: uint32(i)} //nolint:gosec
+	}
+
+	return reports
+}
+
+func TestSplitRTCP_ReportBlocks(t *testing.T) {
+	const maxSize = 400
+
+	sdes := &rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{
+		Source: 1,
+		Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: "pion"}},
+	}}}
+	pkts := []rtcp.Packet{
+		&rtcp.SenderReport{SSRC: 1, NTPTime: 2, RTPTime: 3, PacketCount: 4, OctetCount: 5, Reports: receptionReports(20)},
+		&rtcp.ReceiverReport{SSRC: 1, Reports: receptionReports(40)[20:]},
+		sdes,
+	}
+
+	source := &rtcpSource{ssrc: 1, cname: "pion"}
+	datagrams, err := splitRTCP(pkts, maxSize, source)
+	require.NoError(t, err)
+	parsed := marshalDatagrams(t, datagrams, maxSize, source)
+	assert.Greater(t, len(parsed), 1)
+
+	// The sender info stays in the first datagram, every report block is sent once in order
+	sr, ok := parsed[0][0].(*rtcp.SenderReport)
+	require.True(t, ok)
+	assert.Equal(t, uint64(2), sr.NTPTime)
+
+	var reports []rtcp.ReceptionReport
+	var descriptions int
+	for _, datagram := range parsed {
+		for _, pkt := range datagram {
+			switch pkt := pkt.(type) {
+			case *rtcp.SenderReport:
+				reports = append(reports, pkt.Reports...)
+			case *rtcp.ReceiverReport:
+				assert.Equal(t, uint32(1), pkt.SSRC)
+				reports = append(reports, pkt.Reports...)
+			case *rtcp.SourceDescription:
+				assert.Equal(t, sdes, pkt)
+				descriptions++
+			}
+		}
+	}
+	assert.Equal(t, receptionReports(40), reports)
+	// The datagrams without the description are given the one of the source
+	assert.Equal(t, len(parsed), descriptions)
+}
+
+func TestSplitRTCP_TooManyBlocks(t *testing.T) {
+	// More blocks than a packet can count, even though they fit in the size
+	datagrams, err := splitRTCP([]rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: 1, Reports: receptionReports(40)},
+	}, 1200, nil)
+	require.NoError(t, err)
+	require.Len(t, datagrams, 1)
+	parsed := marshalDatagrams(t, datagrams, 1200, nil)
+	require.Len(t, parsed[0], 2)
+	assert.Len(t, parsed[0][0].(*rtcp.ReceiverReport).Reports, rtcpMaxCount) //nolint:forcetypeassert
+}
+
+func TestSplitRTCP_Items(t *testing.T) {
+	const maxSize = 100
+
+	var chunks []rtcp.SourceDescriptionChunk
+	for i := range 8 {
+		chunks = append(chunks, rtcp.SourceDescriptionChunk{
+			Source: uint32(i + 1), //nolint:gosec
+			Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: "0123456789"}},
+		})
+	}
+	var blocks []rtcp.ReportBlock
+	for i := range 8 {
+		blocks = append(blocks, &rtcp.ReceiverReferenceTimeReportBlock{NTPTimestamp: uint64(i)}) //nolint:gosec
+	}
+
+	for _, source := range []*rtcpSource{{ssrc: 9, cname: "pion"}, nil} {
+		datagrams, err := splitRTCP([]rtcp.Packet{
+			&rtcp.SourceDescription{Chunks: chunks},
+			&rtcp.ExtendedReport{SenderSSRC: 5, Reports: blocks},
+		}, maxSize, source)
+		require.NoError(t, err)
+		parsed := marshalDatagrams(t, datagrams, maxSize, source)
+		assert.Greater(t, len(parsed), 2)
+
+		var parsedChunks []rtcp.SourceDescriptionChunk
+		var parsedBlocks []rtcp.ReportBlock
+		for _, datagram := range parsed {
+			for _, pkt := range datagram {
+				switch pkt := pkt.(type) {
+				case *rtcp.ReceiverReport:
+					// The datagrams are led by an empty report of the source
+					require.NotNil(t, source)
+					assert.Equal(t, source.ssrc, pkt.SSRC)
+					assert.Empty(t, pkt.Reports)
+				case *rtcp.SourceDescription:
+					if source != nil && pkt.Chunks[0].Source == source.ssrc {
+						assert.Equal(t, source.description(), pkt)
+
+						continue
+					}
+					parsedChunks = append(parsedChunks, pkt.Chunks...)
+				case *rtcp.ExtendedReport:
+					assert.Equal(t, uint32(5), pkt.SenderSSRC)
+					parsedBlocks = append(parsedBlocks, pkt.Reports...)
+				}
+			}
+		}
+		assert.Equal(t, chunks, parsedChunks)
+		assert.Equal(t, blocks, parsedBlocks)
+	}
+}
+
+func TestSplitRTCP_TooLarge(t *testing.T) {
+	nack := &rtcp.TransportLayerNack{SenderSSRC: 1, MediaSSRC: 2}
+	for i := range 100 {
+		nack.Nacks = append(nack.Nacks, rtcp.NackPair{PacketID: uint16(i * 20)}) //nolint:gosec
+	}
+
+	_, err := splitRTCP([]rtcp.Packet{nack}, 200, nil)
+	assert.ErrorIs(t, err, ErrRTCPPacketTooLarge)
+
+	_, err = splitRTCP([]rtcp.Packet{&rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{
+		Source: 1,
+		Items:  []rtcp.SourceDescriptionItem{{Type: rtcp.SDESCNAME, Text: string(make([]byte, 250))}},
+	}}}}, 200, nil)
+	assert.ErrorIs(t, err, ErrRTCPPacketTooLarge)
+}
+
+func TestCompoundRTCP(t *testing.T) {
+	source := &rtcpSource{ssrc: 1, cname: "pion"}
+	pli := &rtcp.PictureLossIndication{MediaSSRC: 2}
+
+	// Feedback alone is led by an empty report and the description of the source
+	assert.Equal(t, []rtcp.Packet{
+		&rtcp.ReceiverReport{SSRC: 1}, source.description(), pli,
+	}, compoundRTCP([]rtcp.Packet{pli}, source))
+
+	// The description goes after the reports
+	sr := &rtcp.SenderReport{SSRC: 1}
+	assert.Equal(t, []rtcp.Packet{
+		sr, source.description(), pli,
+	}, compoundRTCP([]rtcp.Packet{sr, pli}, source))
+
+	// A description that is written is kept
+	sdes := &rtcp.SourceDescription{Chunks: []rtcp.SourceDescriptionChunk{{Source: 1}}}
+	assert.Equal(t, []rtcp.Packet{
+		sr, sdes, pli,
+	}, compoundRTCP([]rtcp.Packet{sr, sdes, pli}, source))
+
+	assert.Empty(t, compoundRTCP(nil, source))
+}
+
+func TestSRTCPOverhead(t *testing.T) {
+	assert.Equal(t, 14, srtcpOverhead(srtp.ProtectionProfileAes128CmHmacSha1_80))
+	assert.Equal(t, 20, srtcpOverhead(srtp.ProtectionProfileAeadAes128Gcm))
+
+	// The batches leave room for the largest overhead of the profiles that can be negotiated
+	settingEngine := SettingEngine{}
+	settingEngine.SetRTCPMaxPacketSize(400)
+	assert.Equal(t, 380, settingEngine.getRTCPMaxBatchSize())
+
+	settingEngine.SetSRTPProtectionProfiles(dtls.SRTP_AES128_CM_HMAC_SHA1_80)
+	assert.Equal(t, 386, settingEngine.getRTCPMaxBatchSize())
+}
@@ -5,6 +5,7 @@ package webrtc
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"strings"
 
@@ -92,6 +93,7 @@ type RTPCodecParameters struct {
 type RTPParameters struct {
 	HeaderExtensions []RTPHeaderExtensionParameter
 	Codecs           []RTPCodecParameters
+	RTCP             RTCPParameters
 }
 
 type codecMatchType int
@@ -108,6 +110,16 @@ const (
 func codecParametersFuzzySearch(
 	needle RTPCodecParameters,
 	haystack []RTPCodecParameters,
+) (RTPCodecParameters, codecMatchType) {
+	return codecParametersFuzzySearchWithPreference(needle, haystack, CodecMatchStrict)
+}
+
+// codecParametersFuzzySearchWithPreference is codecParametersFuzzySearch, with CodecMatchLoose
+// a codec with a compatible fmtp when ignoring the profile is an exact match too.
+func codecParametersFuzzySearchWithPreference(
+	needle RTPCodecParameters,
+	haystack []RTPCodecParameters,
+	preference CodecMatchPreference,
 ) (RTPCodecParameters, codecMatchType) {
 	needleFmtp := fmtp.Parse(
 		needle.RTPCodecCapability.MimeType,
@@ -115,19 +127,29 @@ func codecParametersFuzzySearch(
 		needle.RTPCodecCapability.Channels,
 		needle.RTPCodecCapability.SDPFmtpLine)
 
-	// First attempt to match on MimeType + ClockRate + Channels + SDPFmtpLine
-	for _, c := range haystack {
-		cfmtp := fmtp.Parse(
+	parseFmtp := func(c RTPCodecParameters) fmtp.FMTP {
+		return fmtp.Parse(
 			c.RTPCodecCapability.MimeType,
 			c.RTPCodecCapability.ClockRate,
 			c.RTPCodecCapability.Channels,
 			c.RTPCodecCapability.SDPFmtpLine)
+	}
 
-		if needleFmtp.Match(cfmtp) {
+	// First attempt to match on MimeType + ClockRate + Channels + SDPFmtpLine
+	for _, c := range haystack {
+		if needleFmtp.Match(parseFmtp(c)) {
 			return c, codecMatchExact
 		}
 	}
 
+	if preference == CodecMatchLoose {
+		for _, c := range haystack {
+			if fmtp.MatchLoose(needleFmtp, parseFmtp(c)) {
+				return c, codecMatchExact
+			}
+		}
+	}
+
 	// Fallback to just MimeType + ClockRate + Channels
 	for _, c := range haystack {
 		if strings.EqualFold(c.RTPCodecCapability.MimeType, needle.RTPCodecCapability.MimeType) &&
@@ -144,6 +166,38 @@ func codecParametersFuzzySearch(
 	return RTPCodecParameters{}, codecMatchNone
 }
 
+// codecCapabilityMismatch returns why needle can't be sent with the codecs of haystack
+// that have its MimeType, or nil if one of them matches or none has its MimeType.
+// A clock rate or channel count that isn't set in needle matches any codec.
+func codecCapabilityMismatch(needle RTPCodecCapability, haystack []RTPCodecParameters) error {
+	var clockRateErr, channelsErr error
+	for _, c := range haystack {
+		if !strings.EqualFold(c.MimeType, needle.MimeType) {
+			continue
+		}
+
+		switch {
+		case needle.ClockRate != 0 && !fmtp.ClockRateEqual(c.MimeType, c.ClockRate, needle.ClockRate):
+			if clockRateErr == nil {
+				clockRateErr = fmt.Errorf("%w: track %d, registered %d", ErrClockRateMismatch, needle.ClockRate, c.ClockRate)
+			}
+		case needle.Channels != 0 && !fmtp.ChannelsEqual(c.MimeType, c.Channels, needle.Channels):
+			if channelsErr == nil {
+				channelsErr = fmt.Errorf("%w: track %d, registered %d", ErrChannelsMismatch, needle.Channels, c.Channels)
+			}
+		default:
+			return nil
+		}
+	}
+
+	// A codec with the same clock rate is the closer match
+	if channelsErr != nil {
+		return channelsErr
+	}
+
+	return clockRateErr
+}
+
 // Given a CodecParameters find the RTX CodecParameters if one exists.
 func findRTXPayloadType(needle PayloadType, haystack []RTPCodecParameters) PayloadType {
 	aptStr := fmt.Sprintf("apt=%d", needle)
@@ -156,44 +210,81 @@ func findRTXPayloadType(needle PayloadType, haystack []RTPCodecParameters) Paylo
 	return PayloadType(0)
 }
 
-// Given needle CodecParameters, returns if needle is RTX and
-// if primary codec corresponding to that needle is in the haystack of codecs.
-func primaryPayloadTypeForRTXExists(needle RTPCodecParameters, haystack []RTPCodecParameters) (
-	isRTX bool, primaryExists bool,
-) {
-	if !strings.EqualFold(needle.MimeType, MimeTypeRTX) {
-		return
-	}
+// associatedPayloadTypes returns the payload types a RTX, RED or FEC codec depends on,
+// from its apt parameter or the redundant encodings of RED. It returns nil for other codecs,
+// and ErrUnknownAssociatedPayloadType if the association can't be parsed.
+func associatedPayloadTypes(codec RTPCodecParameters) ([]PayloadType, error) {
+	parsed := fmtp.Parse(codec.MimeType, codec.ClockRate, codec.Channels, codec.SDPFmtpLine)
+	apt, hasApt := parsed.Parameter("apt")
+	isRED := strings.HasSuffix(strings.ToLower(codec.MimeType), "/red")
 
-	isRTX = true
-	parsed := fmtp.Parse(needle.MimeType, needle.ClockRate, needle.Channels, needle.SDPFmtpLine)
-	aptPayload, ok := parsed.Parameter("apt")
-	if !ok {
-		return
+	var values []string
+	switch {
+	case hasApt:
+		values = []string{apt}
+	case strings.EqualFold(codec.MimeType, MimeTypeRTX):
+		return nil, ErrUnknownAssociatedPayloadType
+	case isRED && codec.SDPFmtpLine != "":
+		// RFC 2198, the redundant encodings are listed as "<pt>/<pt>/..."
+		values = strings.Split(codec.SDPFmtpLine, "/")
+	default:
+		return nil, nil
 	}
 
-	primaryPayloadType, err := strconv.Atoi(aptPayload)
-	if err != nil || primaryPayloadType < 0 || primaryPayloadType > 255 {
-		return
+	payloadTypes := make([]PayloadType, 0, len(values))
+	for _, value := range values {
+		payloadType, err := strconv.ParseUint(strings.TrimSpace(value), 10, 8)
+		if err != nil {
+			return nil, ErrUnknownAssociatedPayloadType
+		}
+		payloadTypes = append(payloadTypes, PayloadType(payloadType))
 	}
 
+	return payloadTypes, nil
+}
+
+// findAssociatedCodec returns the codec of haystack with the MimeType and ClockRate of needle
+// that depends on the payload types associated.
+func findAssociatedCodec(
+	needle RTPCodecParameters, associated []PayloadType, haystack []RTPCodecParameters,
+) (RTPCodecParameters, bool) {
 	for _, c := range haystack {
-		if c.PayloadType == PayloadType(primaryPayloadType) {
-			primaryExists = true
+		if !strings.EqualFold(c.MimeType, needle.MimeType) ||
+			!fmtp.ClockRateEqual(c.MimeType, c.ClockRate, needle.ClockRate) {
+			continue
+		}
 
-			return
+		if payloadTypes, err := associatedPayloadTypes(c); err == nil && slices.Equal(payloadTypes, associated) {
+			return c, true
 		}
 	}
 
-	return
+	return RTPCodecParameters{}, false
 }
 
-// Filter out RTX codecs that do not have a primary codec.
-func filterUnattachedRTX(codecs []RTPCodecParameters) []RTPCodecParameters {
+// codecAssociationsExist returns true if every payload type codec depends on is in codecs.
+func codecAssociationsExist(codec RTPCodecParameters, codecs []RTPCodecParameters) bool {
+	payloadTypes, err := associatedPayloadTypes(codec)
+	if err != nil {
+		return false
+	}
+
+	for _, payloadType := range payloadTypes {
+		if !slices.ContainsFunc(codecs, func(c RTPCodecParameters) bool {
+			return c.PayloadType == payloadType
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Filter out RTX, RED and FEC codecs that do not have their primary codec.
+func filterUnattachedCodecs(codecs []RTPCodecParameters) []RTPCodecParameters {
 	for i := len(codecs) - 1; i >= 0; i-- {
-		c := codecs[i]
-		if isRTX, primaryExists := primaryPayloadTypeForRTXExists(c, codecs); isRTX && !primaryExists {
-			// no primary for RTX, remove the RTX
+		if !codecAssociationsExist(codecs[i], codecs) {
+			// no primary for the codec, remove it
 			codecs = append(codecs[:i], codecs[i+1:]...)
 		}
 	}
@@ -212,6 +303,49 @@ func findFECPayloadType(haystack []RTPCodecParameters) PayloadType {
 	return PayloadType(0)
 }
 
+// opusFmtpIntersection returns the Opus fmtp line remote with the preferences of local applied.
+// stereo and useinbandfec are only kept if both sides want them, the lowest maxaveragebitrate
+// of both sides is used, and the other parameters of remote are kept as they are.
+func opusFmtpIntersection(local, remote string) string {
+	localParameters := map[string]string{}
+	for parameter := range strings.SplitSeq(local, ";") {
+		key, value, _ := strings.Cut(strings.TrimSpace(parameter), "=")
+		localParameters[strings.ToLower(key)] = value
+	}
+	localBitrate, hasLocalBitrate := localParameters["maxaveragebitrate"]
+
+	parameters := []string{}
+	for parameter := range strings.SplitSeq(remote, ";") {
+		parameter = strings.TrimSpace(parameter)
+		key, value, _ := strings.Cut(parameter, "=")
+		switch strings.ToLower(key) {
+		case "":
+			continue
+		case "stereo", "useinbandfec":
+			if value != "1" || localParameters[strings.ToLower(key)] != "1" {
+				continue
+			}
+		case "maxaveragebitrate":
+			if !hasLocalBitrate {
+				continue
+			}
+			remoteBitrate, remoteErr := strconv.ParseUint(value, 10, 32)
+			bitrate, localErr := strconv.ParseUint(localBitrate, 10, 32)
+			if localErr == nil && remoteErr == nil && remoteBitrate < bitrate {
+				bitrate = remoteBitrate
+			}
+			hasLocalBitrate = false
+			parameter = fmt.Sprintf("%s=%d", key, bitrate)
+		}
+		parameters = append(parameters, parameter)
+	}
+	if hasLocalBitrate {
+		parameters = append(parameters, "maxaveragebitrate="+localBitrate)
+	}
+
+	return strings.Join(parameters, ";")
+}
+
 func rtcpFeedbackIntersection(a, b []RTCPFeedback) (out []RTCPFeedback) {
 	for _, aFeedback := range a {
 		for _, bFeeback := range b {
@@ -9,195 +9,29 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-func TestFindPrimaryPayloadTypeForRTX(t *testing.T) {
+func TestAssociatedPayloadTypes(t *testing.T) {
 	for _, test := range []struct {
-		Name                string
-		Needle              RTPCodecParameters
-		Haystack            []RTPCodecParameters
-		ResultIsRTX         bool
-		ResultPrimaryExists bool
+		Name         string
+		MimeType     string
+		SDPFmtpLine  string
+		PayloadTypes []PayloadType
+		Err          error
 	}{
-		{
-			Name: "not RTX",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeH264,
-					ClockRate:   90000,
-					SDPFmtpLine: "apt=2",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         false,
-			ResultPrimaryExists: false,
-		},
-		{
-			Name: "incorrect fmtp",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeRTX,
-					ClockRate:   90000,
-					SDPFmtpLine: "incorrect-fmtp",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         true,
-			ResultPrimaryExists: false,
-		},
-		{
-			Name: "incomplete fmtp",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeRTX,
-					ClockRate:   90000,
-					SDPFmtpLine: "apt=",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         true,
-			ResultPrimaryExists: false,
-		},
-		{
-			Name: "primary payload type outside range (negative)",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeRTX,
-					ClockRate:   90000,
-					SDPFmtpLine: "apt=-10",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         true,
-			ResultPrimaryExists: false,
-		},
-		{
-			Name: "primary payload type outside range (high positive)",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeRTX,
-					ClockRate:   90000,
-					SDPFmtpLine: "apt=1000",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         true,
-			ResultPrimaryExists: false,
-		},
-		{
-			Name: "non-matching needle",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeRTX,
-					ClockRate:   90000,
-					SDPFmtpLine: "apt=23",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         true,
-			ResultPrimaryExists: false,
-		},
-		{
-			Name: "matching needle",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeRTX,
-					ClockRate:   90000,
-					SDPFmtpLine: "apt=1",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         true,
-			ResultPrimaryExists: true,
-		},
-		{
-			Name: "matching fmtp is a substring",
-			Needle: RTPCodecParameters{
-				PayloadType: 2,
-				RTPCodecCapability: RTPCodecCapability{
-					MimeType:    MimeTypeRTX,
-					ClockRate:   90000,
-					SDPFmtpLine: "apt=1;rtx-time:2000",
-				},
-			},
-			Haystack: []RTPCodecParameters{
-				{
-					PayloadType: 1,
-					RTPCodecCapability: RTPCodecCapability{
-						MimeType:  MimeTypeH264,
-						ClockRate: 90000,
-					},
-				},
-			},
-			ResultIsRTX:         true,
-			ResultPrimaryExists: true,
-		},
+		{"primary codec", MimeTypeVP8, "", nil, nil},
+		{"rtx", MimeTypeRTX, "apt=96;rtx-time=3000", []PayloadType{96}, nil},
+		{"rtx without apt", MimeTypeRTX, "", nil, ErrUnknownAssociatedPayloadType},
+		{"rtx with invalid apt", MimeTypeRTX, "apt=foo", nil, ErrUnknownAssociatedPayloadType},
+		{"audio red", "audio/red", "111/111", []PayloadType{111, 111}, nil},
+		{"audio red with invalid encodings", "audio/red", "111/opus", nil, ErrUnknownAssociatedPayloadType},
+		{"video red", "video/red", "", nil, nil},
+		{"fec with apt", MimeTypeFlexFEC03, "apt=96;repair-window=10000000", []PayloadType{96}, nil},
 	} {
 		t.Run(test.Name, func(t *testing.T) {
-			isRTX, primaryExists := primaryPayloadTypeForRTXExists(test.Needle, test.Haystack)
-			assert.Equal(t, test.ResultIsRTX, isRTX)
-			assert.Equal(t, test.ResultPrimaryExists, primaryExists)
+			payloadTypes, err := associatedPayloadTypes(RTPCodecParameters{
+				RTPCodecCapability: RTPCodecCapability{MimeType: test.MimeType, SDPFmtpLine: test.SDPFmtpLine},
+			})
+			assert.ErrorIs(t, err, test.Err)
+			assert.Equal(t, test.PayloadTypes, payloadTypes)
 		})
 	}
 }
@@ -276,3 +110,63 @@ func TestFindFECPayloadType(t *testing.T) {
 		assert.Equal(t, test.ResultPayloadType, findFECPayloadType(test.Haystack))
 	}
 }
+
+func TestOpusFmtpIntersection(t *testing.T) {
+	for _, test := range []struct {
+		Name     string
+		Local    string
+		Remote   string
+		Expected string
+	}{
+		{
+			Name:     "both sides",
+			Local:    "minptime=10;useinbandfec=1;stereo=1",
+			Remote:   "minptime=10;useinbandfec=1;stereo=1",
+			Expected: "minptime=10;useinbandfec=1;stereo=1",
+		},
+		{
+			Name:     "only local",
+			Local:    "minptime=10;useinbandfec=1;stereo=1",
+			Remote:   "minptime=10",
+			Expected: "minptime=10",
+		},
+		{
+			Name:     "only remote",
+			Local:    "minptime=10",
+			Remote:   "minptime=10;useinbandfec=1;stereo=1;sprop-stereo=1",
+			Expected: "minptime=10;sprop-stereo=1",
+		},
+		{
+			Name:     "disabled by remote",
+			Local:    "useinbandfec=1;stereo=1",
+			Remote:   "useinbandfec=0;stereo=0",
+			Expected: "",
+		},
+		{
+			Name:     "lower local bitrate",
+			Local:    "maxaveragebitrate=20000",
+			Remote:   "minptime=10;maxaveragebitrate=64000",
+			Expected: "minptime=10;maxaveragebitrate=20000",
+		},
+		{
+			Name:     "lower remote bitrate",
+			Local:    "maxaveragebitrate=64000",
+			Remote:   "maxaveragebitrate=20000;minptime=10",
+			Expected: "maxaveragebitrate=20000;minptime=10",
+		},
+		{
+			Name:     "bitrate only local",
+			Local:    "maxaveragebitrate=20000",
+			Remote:   "minptime=10",
+			Expected: "minptime=10;maxaveragebitrate=20000",
+		},
+		{
+			Name:     "bitrate only remote",
+			Local:    "minptime=10",
+			Remote:   "minptime=10;maxaveragebitrate=20000",
+			Expected: "minptime=10",
+		},
+	} {
+		assert.Equal(t, test.Expected, opusFmtpIntersection(test.Local, test.Remote), test.Name)
+	}
+}
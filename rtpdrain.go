@@ -0,0 +1,78 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync/atomic"
+
+	"github.com/pion/rtp"
+)
+
+// rtpDrainValid marks the values of rtpDrain that were set.
+const rtpDrainValid = 1 << 32
+
+// rtpDrain follows the packets of a stream through the interceptors, so RTPSender.StopWithFlush
+// can wait for the ones queued by pacers to be sent. Interceptors keep the order of the
+// packets of a stream, so the stream is flushed once the last queued sequence number is sent.
+type rtpDrain struct {
+	draining atomic.Bool
+
+	// timestamp of the last accepted packet, the packets of the sample being written while
+	// the drain starts are still accepted
+	timestamp atomic.Uint64
+
+	queued, sent atomic.Uint64
+}
+
+// start rejects the packets of new samples, see accept.
+func (d *rtpDrain) start() {
+	d.draining.Store(true)
+}
+
+// accept returns ErrTrackLocalDraining if header starts a new sample after the drain started.
+func (d *rtpDrain) accept(header *rtp.Header) error {
+	if d.draining.Load() {
+		if d.timestamp.Load() != rtpDrainValid|uint64(header.Timestamp) {
+			return ErrTrackLocalDraining
+		}
+
+		return nil
+	}
+
+	d.timestamp.Store(rtpDrainValid | uint64(header.Timestamp))
+
+	return nil
+}
+
+// queue records a packet that was handed to the interceptors.
+func (d *rtpDrain) queue(sequenceNumber uint16) {
+	d.queued.Store(rtpDrainValid | uint64(sequenceNumber))
+}
+
+// send records a packet that left the interceptors, retransmissions of older packets are ignored.
+func (d *rtpDrain) send(sequenceNumber uint16) {
+	for {
+		sent := d.sent.Load()
+		if sent != 0 && int16(sequenceNumber-uint16(sent)) <= 0 { //nolint:gosec // wrap around is intended
+			return
+		}
+		if d.sent.CompareAndSwap(sent, rtpDrainValid|uint64(sequenceNumber)) {
+			return
+		}
+	}
+}
+
+// flushed returns true if every queued packet was sent.
+func (d *rtpDrain) flushed() bool {
+	queued := d.queued.Load()
+	if queued == 0 {
+		return true
+	}
+
+	sent := d.sent.Load()
+
+	return sent != 0 && int16(uint16(queued)-uint16(sent)) <= 0 //nolint:gosec // wrap around is intended
+}
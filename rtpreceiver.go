@@ -7,9 +7,12 @@ package webrtc
 
 import (
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"io"
 	"math"
+	"slices"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -68,13 +71,30 @@ type RTPReceiver struct {
 	closedChan, received chan any
 	mu                   sync.RWMutex
 
+	// The error reading the tracks returns once stopped, see readErr
+	stopErr error
+
+	rtcpReadDeadline readDeadline
+
+	// Serializes PauseSimulcastLayer and ResumeSimulcastLayer
+	layerPauseMu sync.Mutex
+
 	tr *RTPTransceiver
 
+	// The sender SSRC of RTCP written when the transceiver has no RTPSender
+	rtcpSSRC atomic.Uint32
+
 	// A reference to the associated api object
 	api *API
 
 	rtxPool sync.Pool
 
+	// The codecs of the media section of the receiver in the remote description,
+	// with the payload types of the remote, to unwrap the RTX packets
+	remoteCodecs atomic.Value // []RTPCodecParameters
+
+	userData userData
+
 	log logging.LeveledLogger
 }
 
@@ -116,7 +136,17 @@ func (r *RTPReceiver) Transport() *DTLSTransport {
 }
 
 func (r *RTPReceiver) getParameters() RTPParameters {
-	parameters := r.api.mediaEngine.getRTPParametersByKind(
+	if r.tr != nil {
+		if negotiated := r.tr.getNegotiatedParameters(); negotiated != nil {
+			negotiated.HeaderExtensions = r.api.mediaEngine.headerExtensionsForDirection(
+				negotiated.HeaderExtensions, r.kind, RTPTransceiverDirectionRecvonly,
+			)
+
+			return *negotiated
+		}
+	}
+
+	parameters := r.api.getRTPParametersByKind(
 		r.kind,
 		[]RTPTransceiverDirection{RTPTransceiverDirectionRecvonly},
 	)
@@ -128,7 +158,9 @@ func (r *RTPReceiver) getParameters() RTPParameters {
 }
 
 // GetParameters describes the current configuration for the encoding and
-// transmission of media on the receiver's track.
+// transmission of media on the receiver's track. Once an answer was applied these
+// are the codecs, header extensions and RTCP settings of its media section, otherwise
+// the ones the receiver supports.
 func (r *RTPReceiver) GetParameters() RTPParameters {
 	r.mu.RLock()
 	defer r.mu.RUnlock()
@@ -136,6 +168,21 @@ func (r *RTPReceiver) GetParameters() RTPParameters {
 	return r.getParameters()
 }
 
+// SetUserData stores an application value under key on the RTPReceiver, a nil value
+// removes it. Like for context.WithValue, the key must be comparable,
+// ErrUserDataKeyNotComparable is returned otherwise, and should be of an unexported type to
+// avoid collisions. The value is stored as given: a struct is copied, pointers, maps and
+// slices are shared. The values stay across renegotiations and are released once the
+// RTPReceiver is stopped, values set afterwards are dropped.
+func (r *RTPReceiver) SetUserData(key, value any) error {
+	return r.userData.set(key, value)
+}
+
+// UserData returns the value stored under key with SetUserData, nil if there is none.
+func (r *RTPReceiver) UserData(key any) any {
+	return r.userData.get(key)
+}
+
 // Track returns the RtpTransceiver TrackRemote.
 func (r *RTPReceiver) Track() *TrackRemote {
 	r.mu.RLock()
@@ -162,6 +209,57 @@ func (r *RTPReceiver) Tracks() []*TrackRemote {
 	return tracks
 }
 
+// usesCodec reports if a track of the receiver received packets of a codec of mimeType.
+func (r *RTPReceiver) usesCodec(mimeType string) bool {
+	return slices.ContainsFunc(r.Tracks(), func(track *TrackRemote) bool {
+		return strings.EqualFold(track.Codec().MimeType, mimeType)
+	})
+}
+
+// setRemoteCodecs sets the codecs of the media section of the receiver in the remote description.
+func (r *RTPReceiver) setRemoteCodecs(codecs []RTPCodecParameters) {
+	r.remoteCodecs.Store(codecs)
+}
+
+// repairedPayloadType returns the payload type to restore in a packet received with the RTX payload
+// type rtxPayloadType, for a track receiving trackPayloadType. Endpoints can negotiate several RTX
+// payload types whose primary codecs are the same codec, as with one per H264 profile, so every RTX
+// payload type associated to a codec the track can depacketize repairs it. Retransmissions of
+// other codecs and on payload types that aren't RTX in the media section are not for the track.
+func (r *RTPReceiver) repairedPayloadType(rtxPayloadType, trackPayloadType PayloadType) (PayloadType, bool) {
+	codecs, ok := r.remoteCodecs.Load().([]RTPCodecParameters)
+	if !ok {
+		// Without remote description, as with the ORTC API, the RTX stream only repairs the track
+		return trackPayloadType, true
+	}
+
+	rtxCodec := findCodecByPayload(codecs, rtxPayloadType)
+	if rtxCodec == nil || !strings.EqualFold(rtxCodec.MimeType, MimeTypeRTX) {
+		return 0, false
+	}
+	payloadTypes, err := associatedPayloadTypes(*rtxCodec)
+	if err != nil || len(payloadTypes) != 1 {
+		return 0, false
+	}
+
+	primaryPayloadType := payloadTypes[0]
+	if trackPayloadType == 0 || primaryPayloadType == trackPayloadType {
+		return primaryPayloadType, true
+	}
+
+	primaryCodec := findCodecByPayload(codecs, primaryPayloadType)
+	trackCodec := findCodecByPayload(codecs, trackPayloadType)
+	if primaryCodec == nil || trackCodec == nil {
+		return 0, false
+	}
+	trackCodecs := []RTPCodecParameters{*trackCodec}
+	if _, matchType := codecParametersFuzzySearch(*primaryCodec, trackCodecs); matchType == codecMatchNone {
+		return 0, false
+	}
+
+	return trackPayloadType, true
+}
+
 // RTPTransceiver returns the RTPTransceiver this
 // RTPReceiver belongs too, or nil if none.
 func (r *RTPReceiver) RTPTransceiver() *RTPTransceiver {
@@ -241,6 +339,13 @@ func (r *RTPReceiver) startReceive(parameters RTPReceiveParameters) error { //no
 		streams.rtpInterceptor = result.rtpInterceptor
 		streams.rtcpReadStream = result.rtcpReadStream
 		streams.rtcpInterceptor = result.rtcpInterceptor
+		if err = r.applyReadDeadlines(streams); err != nil {
+			return err
+		}
+		r.watchGoodbye(parameters.Encodings[i].SSRC)
+		if err = r.startPreBind(streams); err != nil {
+			return err
+		}
 
 		if rtxSsrc := parameters.Encodings[i].RTX.SSRC; rtxSsrc != 0 {
 			// See RFC 4588 section 6.3,
@@ -295,9 +400,20 @@ func (r *RTPReceiver) Read(b []byte) (n int, a interceptor.Attributes, err error
 			r.log.Errorf(useReadSimulcast)
 		}
 
-		return r.tracks[0].rtcpInterceptor.Read(b, a)
+		n, a, err = r.tracks[0].rtcpInterceptor.Read(b, a)
+		if errors.Is(err, io.EOF) {
+			err = r.readRTCPErr(err)
+		}
+
+		return n, a, err
 	case <-r.closedChan:
-		return 0, nil, io.ErrClosedPipe
+		return 0, nil, r.readRTCPErr(io.ErrClosedPipe)
+	case <-r.rtcpReadDeadline.done():
+		if r.haveClosed() {
+			return 0, nil, r.readRTCPErr(io.ErrClosedPipe)
+		}
+
+		return 0, nil, errReadTimeout
 	}
 }
 
@@ -319,10 +435,15 @@ func (r *RTPReceiver) ReadSimulcast(b []byte, rid string) (n int, a interceptor.
 			return 0, nil, fmt.Errorf("%w: %s", errRTPReceiverForRIDTrackStreamNotFound, rid)
 		}
 
-		return rtcpInterceptor.Read(b, a)
+		n, a, err = rtcpInterceptor.Read(b, a)
+		if errors.Is(err, io.EOF) {
+			err = r.readRTCPErr(err)
+		}
+
+		return n, a, err
 
 	case <-r.closedChan:
-		return 0, nil, io.ErrClosedPipe
+		return 0, nil, r.readRTCPErr(io.ErrClosedPipe)
 	}
 }
 
@@ -343,6 +464,47 @@ func (r *RTPReceiver) ReadRTCP() ([]rtcp.Packet, interceptor.Attributes, error)
 	return pkts, attributes, nil
 }
 
+// WriteRTCP sends RTCP about the tracks of this RTPReceiver to the remote, through the
+// configured interceptors. A zero media SSRC of feedback, like a PLI, is set to the SSRC of
+// the first track. A zero sender SSRC is set to the SSRC of the RTPSender of the transceiver,
+// or to a random SSRC of the receiver if there is none. The packets are modified in place.
+func (r *RTPReceiver) WriteRTCP(pkts []rtcp.Packet) error {
+	if r.closed.Load() {
+		return io.ErrClosedPipe
+	}
+
+	r.mu.RLock()
+	transport, transceiver := r.transport, r.tr
+	var mediaSSRC uint32
+	if len(r.tracks) != 0 {
+		mediaSSRC = uint32(r.tracks[0].track.SSRC())
+	}
+	r.mu.RUnlock()
+
+	setRTCPSSRCs(pkts, r.rtcpSenderSSRC(transceiver), mediaSSRC)
+	_, err := transport.writeRTCPThroughInterceptors(pkts)
+
+	return err
+}
+
+// rtcpSenderSSRC returns the sender SSRC of the RTCP written by WriteRTCP.
+func (r *RTPReceiver) rtcpSenderSSRC(transceiver *RTPTransceiver) uint32 {
+	if transceiver != nil {
+		if sender := transceiver.Sender(); sender != nil {
+			if ssrcs := sender.ssrcs(); len(ssrcs) != 0 {
+				return uint32(ssrcs[0])
+			}
+		}
+	}
+
+	for {
+		if ssrc := r.rtcpSSRC.Load(); ssrc != 0 {
+			return ssrc
+		}
+		r.rtcpSSRC.CompareAndSwap(0, util.RandUint32())
+	}
+}
+
 // ReadSimulcastRTCP is a convenience method that wraps ReadSimulcast and unmarshal for you.
 func (r *RTPReceiver) ReadSimulcastRTCP(rid string) ([]rtcp.Packet, interceptor.Attributes, error) {
 	b := make([]byte, r.api.settingEngine.getReceiveMTU())
@@ -356,6 +518,366 @@ func (r *RTPReceiver) ReadSimulcastRTCP(rid string) ([]rtcp.Packet, interceptor.
 	return pkts, attributes, err
 }
 
+// PauseSimulcastLayer stops handing the packets of the simulcast layer rid to the reader of its
+// TrackRemote, so an SFU can stop processing the layers it doesn't forward. The packets of the
+// layer are still read through the interceptors, which keep generating the receiver reports and
+// the congestion control feedback for them, and then discarded without being buffered. They are
+// counted by TrackRemote.PausedPacketsDiscarded. Since SRTP decrypts every packet before it is
+// demultiplexed to its stream, pausing a layer does not save the decryption.
+// Reading the track blocks until the layer is resumed.
+func (r *RTPReceiver) PauseSimulcastLayer(rid string) error {
+	r.layerPauseMu.Lock()
+	defer r.layerPauseMu.Unlock()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	streams := r.streamsForRID(rid)
+	if streams == nil {
+		return fmt.Errorf("%w: %s", errRTPReceiverForRIDTrackStreamNotFound, rid)
+	}
+
+	track := streams.track
+	track.mu.Lock()
+	defer track.mu.Unlock()
+
+	if track.layerPause != nil {
+		return nil
+	}
+
+	track.layerPause = &layerPause{
+		resumed:  make(chan struct{}),
+		drained:  make(chan struct{}),
+		released: make(chan struct{}),
+	}
+	track.pausedPacketsDiscarded.Add(uint64(len(track.peekedPackets)))
+	track.peekedPackets = nil
+
+	return r.startLayerDrain(streams)
+}
+
+// ResumeSimulcastLayer resumes a simulcast layer paused by PauseSimulcastLayer. A PLI is sent
+// for the layer so its reader starts with a keyframe, unless it is disabled with
+// SettingEngine.DisableSimulcastResumeKeyframeRequest. With keyframe gating enabled the track
+// withholds packets until the keyframe arrives, see SettingEngine.EnableKeyframeGating.
+func (r *RTPReceiver) ResumeSimulcastLayer(rid string) error {
+	r.layerPauseMu.Lock()
+	defer r.layerPauseMu.Unlock()
+
+	r.mu.Lock()
+	streams := r.streamsForRID(rid)
+	if streams == nil {
+		r.mu.Unlock()
+
+		return fmt.Errorf("%w: %s", errRTPReceiverForRIDTrackStreamNotFound, rid)
+	}
+
+	track := streams.track
+	track.mu.Lock()
+	pause := track.layerPause
+	if pause != nil {
+		close(pause.resumed)
+	}
+	track.mu.Unlock()
+	rtpReadStream := streams.rtpReadStream
+	r.mu.Unlock()
+
+	if pause == nil {
+		return nil
+	}
+
+	if pause.draining {
+		// Interrupt the read of the drain
+		if err := rtpReadStream.SetReadDeadline(time.Now()); err != nil {
+			return err
+		}
+		<-pause.drained
+	}
+
+	r.mu.Lock()
+	track.mu.Lock()
+	track.layerPause = nil
+	track.mu.Unlock()
+	err := r.restoreLayerReadDeadline(streams)
+	r.mu.Unlock()
+	close(pause.released)
+	if err != nil {
+		return err
+	}
+
+	writeRTCP := func(pkts []rtcp.Packet) error {
+		_, err := r.transport.WriteRTCP(pkts)
+
+		return err
+	}
+	if gating := r.api.settingEngine.keyframeGating; gating.enabled && track.Kind() == RTPCodecTypeVideo {
+		track.enableKeyframeGating(gating.timeout, writeRTCP)
+	} else if !r.api.settingEngine.disableSimulcastResumeKeyframeRequest {
+		track.sendPLI(writeRTCP, track.SSRC())
+	}
+
+	return nil
+}
+
+// streamsForRID returns the streams of the track of the simulcast layer rid.
+func (r *RTPReceiver) streamsForRID(rid string) *trackStreams {
+	for i := range r.tracks {
+		if r.tracks[i].track != nil && r.tracks[i].track.RID() == rid {
+			return &r.tracks[i]
+		}
+	}
+
+	return nil
+}
+
+// startLayerDrain starts discarding the packets of a paused layer once its stream is bound.
+// It must be called with r.mu and the lock of the track held.
+func (r *RTPReceiver) startLayerDrain(streams *trackStreams) error {
+	pause := streams.track.layerPause
+	if pause == nil || pause.draining || streams.rtpInterceptor == nil {
+		return nil
+	}
+
+	select {
+	case <-pause.resumed:
+		return nil
+	default:
+	}
+
+	// A deadline set for the reads of the track would end the drain
+	if err := streams.rtpReadStream.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	pause.draining = true
+	go r.drainLayer(streams.track, streams.rtpInterceptor, pause)
+
+	return nil
+}
+
+// drainLayer reads and discards the packets of a paused layer until it is resumed.
+func (r *RTPReceiver) drainLayer(track *TrackRemote, rtpInterceptor interceptor.RTPReader, pause *layerPause) {
+	defer close(pause.drained)
+
+	b := make([]byte, r.api.settingEngine.getReceiveMTU())
+	for {
+		select {
+		case <-pause.resumed:
+			return
+		default:
+		}
+
+		if _, _, err := rtpInterceptor.Read(b, nil); err != nil {
+			if errors.Is(err, io.EOF) || r.haveClosed() {
+				return
+			}
+
+			continue
+		}
+		track.pausedPacketsDiscarded.Add(1)
+	}
+}
+
+// restoreLayerReadDeadline sets the read deadline of the track back on the stream of a resumed
+// layer. It must be called with r.mu held.
+func (r *RTPReceiver) restoreLayerReadDeadline(streams *trackStreams) error {
+	if streams.rtpReadStream == nil {
+		return nil
+	}
+
+	if err := streams.rtpReadStream.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	return streams.track.readDeadline.apply(streams.rtpReadStream)
+}
+
+// startPreBind starts reading the packets of a signaled SSRC ahead of the application once its
+// stream is bound, see SettingEngine.SetReceiverPreBindBufferSize. It must be called with r.mu held.
+func (r *RTPReceiver) startPreBind(streams *trackStreams) error {
+	// The bandwidth probes of SSRC 0 aren't read by the application
+	size := r.api.settingEngine.getReceiverPreBindBufferSize()
+	if size == 0 || streams.rtpInterceptor == nil || streams.track.SSRC() == 0 {
+		return nil
+	}
+
+	// A deadline set for the reads of the track would end the reads of the buffer
+	if err := streams.rtpReadStream.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	buffer := &preBindBuffer{
+		size:     size,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		buffered: make(chan struct{}, 1),
+	}
+	streams.track.mu.Lock()
+	streams.track.preBind = buffer
+	streams.track.mu.Unlock()
+
+	go r.readPreBind(streams.track, streams.rtpInterceptor, buffer)
+
+	return nil
+}
+
+// readPreBind buffers the packets of track until the application reads it.
+func (r *RTPReceiver) readPreBind(track *TrackRemote, rtpInterceptor interceptor.RTPReader, buffer *preBindBuffer) {
+	defer close(buffer.stopped)
+
+	for {
+		select {
+		case <-buffer.stop:
+			return
+		default:
+		}
+
+		b := make([]byte, r.api.settingEngine.getReceiveMTU())
+		n, attributes, err := rtpInterceptor.Read(b, nil)
+		if err != nil {
+			if errors.Is(err, io.EOF) || r.haveClosed() {
+				return
+			}
+
+			continue
+		}
+		attributes = track.accountPadding(b[:n], attributes)
+
+		track.mu.Lock()
+		if len(track.peekedPackets) >= buffer.size {
+			track.peekedPackets = track.peekedPackets[1:]
+			track.preBindPacketsDiscarded.Add(1)
+		}
+		track.peekedPackets = append(track.peekedPackets, &peekedPacket{payload: b[:n], attributes: attributes})
+		track.mu.Unlock()
+
+		select {
+		case buffer.buffered <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// stopPreBind stops reading track ahead of the application, the packets already buffered
+// are read first. The read deadline of the track is then set on its stream.
+func (r *RTPReceiver) stopPreBind(track *TrackRemote) error {
+	track.mu.Lock()
+	buffer := track.preBind
+	track.preBind = nil
+	track.mu.Unlock()
+	if buffer == nil {
+		return nil
+	}
+	close(buffer.stop)
+
+	r.mu.RLock()
+	streams := r.streamsForTrack(track)
+	var rtpReadStream *srtp.ReadStreamSRTP
+	if streams != nil {
+		rtpReadStream = streams.rtpReadStream
+	}
+	r.mu.RUnlock()
+
+	// Interrupt the read of the buffer, the stream is already closed without rtpReadStream
+	if rtpReadStream != nil {
+		if err := rtpReadStream.SetReadDeadline(time.Now()); err != nil {
+			return err
+		}
+	}
+	<-buffer.stopped
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if streams = r.streamsForTrack(track); streams == nil || streams.rtpReadStream == nil {
+		return nil
+	}
+	if err := streams.rtpReadStream.SetReadDeadline(time.Time{}); err != nil {
+		return err
+	}
+
+	return track.readDeadline.apply(streams.rtpReadStream)
+}
+
+// goodbyeEndTrackDelay is how long a track is still read after a RTCP BYE for its SSRC.
+// SRTP and SRTCP are decrypted separately, the RTP packets sent right before the BYE may
+// not be buffered yet when it is read.
+const goodbyeEndTrackDelay = 100 * time.Millisecond
+
+// watchGoodbye ends the track with ssrc once a RTCP BYE for it is received, reading RTP from
+// the track returns ErrTrackEnded afterwards. The BYE is handled whether RTCP is read or not.
+func (r *RTPReceiver) watchGoodbye(ssrc SSRC) {
+	if r.transport == nil {
+		return
+	}
+
+	r.transport.onGoodbye(ssrc, func() {
+		time.AfterFunc(goodbyeEndTrackDelay, func() {
+			if !r.haveClosed() {
+				r.endTrack(ssrc)
+			}
+		})
+	})
+}
+
+// endTrack closes the RTP streams of the track with ssrc, so reading it returns ErrTrackEnded.
+// The BYE of a RTX SSRC alone doesn't end the track.
+func (r *RTPReceiver) endTrack(ssrc SSRC) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.tracks {
+		streams := &r.tracks[i]
+		if streams.track == nil || streams.track.SSRC() != ssrc || streams.rtpReadStream == nil {
+			continue
+		}
+		streams.track.ended.Store(true)
+
+		if err := streams.rtpReadStream.Close(); err != nil {
+			r.log.Warnf("Failed to close RTP stream of SSRC %d after RTCP BYE: %v", ssrc, err)
+		}
+		streams.rtpReadStream = nil
+
+		if streams.repairReadStream != nil {
+			if err := streams.repairReadStream.Close(); err != nil {
+				r.log.Warnf("Failed to close RTX stream of SSRC %d after RTCP BYE: %v", ssrc, err)
+			}
+			streams.repairReadStream = nil
+		}
+	}
+}
+
+// readErr returns the error of reading track once its RTP streams were closed: the reason
+// the RTPReceiver was stopped, ErrTrackEnded if a RTCP BYE ended the track, io.EOF otherwise.
+// Stop holds the lock while closing the streams, so the reason is set when the reads wake up.
+func (r *RTPReceiver) readErr(track *TrackRemote) error {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	switch {
+	case r.stopErr != nil:
+		return r.stopErr
+	case track != nil && track.ended.Load():
+		return ErrTrackEnded
+	default:
+		return io.EOF
+	}
+}
+
+// readRTCPErr returns the error of reading RTCP once its stream is closed: the reason the
+// RTPReceiver was stopped if the remote caused it, io.ErrClosedPipe if it was stopped locally
+// and err otherwise.
+func (r *RTPReceiver) readRTCPErr(err error) error {
+	reason := r.readErr(nil)
+	switch {
+	case errors.Is(reason, ErrTrackEnded), errors.Is(reason, ErrReceiverRestarted):
+		return reason
+	case r.haveClosed():
+		return io.ErrClosedPipe
+	default:
+		return err
+	}
+}
+
 func (r *RTPReceiver) haveReceived() bool {
 	select {
 	case <-r.received:
@@ -369,8 +891,15 @@ func (r *RTPReceiver) haveClosed() bool {
 	return r.closed.Load()
 }
 
-// Stop irreversibly stops the RTPReceiver.
-func (r *RTPReceiver) Stop() error { //nolint:cyclop
+// Stop irreversibly stops the RTPReceiver. Reading its tracks returns io.EOF and reading
+// RTCP returns io.ErrClosedPipe afterwards.
+func (r *RTPReceiver) Stop() error {
+	return r.stop(io.EOF)
+}
+
+// stop stops the RTPReceiver, reading its tracks returns reason afterwards. Only the reason
+// of the first stop is kept.
+func (r *RTPReceiver) stop(reason error) error { //nolint:cyclop
 	r.mu.Lock()
 	defer r.mu.Unlock()
 	var err error
@@ -381,6 +910,9 @@ func (r *RTPReceiver) Stop() error { //nolint:cyclop
 	default:
 	}
 
+	// Set before the streams are closed, which wakes up the reads
+	r.stopErr = reason
+
 	select {
 	case <-r.received:
 		for i := range r.tracks {
@@ -410,6 +942,10 @@ func (r *RTPReceiver) Stop() error { //nolint:cyclop
 				r.api.interceptor.UnbindRemoteStream(r.tracks[i].repairStreamInfo)
 			}
 
+			if r.tracks[i].track != nil && r.transport != nil {
+				r.transport.onGoodbye(r.tracks[i].track.SSRC(), nil)
+			}
+
 			err = util.FlattenErrs(errs)
 		}
 	default:
@@ -418,6 +954,13 @@ func (r *RTPReceiver) Stop() error { //nolint:cyclop
 	close(r.closedChan)
 	r.closed.Store(true)
 
+	r.userData.clear()
+	for i := range r.tracks {
+		if r.tracks[i].track != nil {
+			r.tracks[i].track.userData.clear()
+		}
+	}
+
 	return err
 }
 
@@ -451,15 +994,17 @@ func (r *RTPReceiver) collectStats(collector *statsReportCollector, statsGetter
 		}
 
 		inboundStats := InboundRTPStreamStats{
-			Rid:         remoteTrack.RID(),
-			Mid:         mid,
-			Timestamp:   now,
-			Type:        StatsTypeInboundRTP,
-			ID:          inboundID,
-			SSRC:        remoteTrack.SSRC(),
-			Kind:        r.kind.String(),
-			TransportID: "iceTransport",
-			CodecID:     codecID,
+			Rid:                    remoteTrack.RID(),
+			Mid:                    mid,
+			Timestamp:              now,
+			Type:                   StatsTypeInboundRTP,
+			ID:                     inboundID,
+			SSRC:                   remoteTrack.SSRC(),
+			Kind:                   r.kind.String(),
+			TransportID:            "iceTransport",
+			CodecID:                codecID,
+			PaddingPacketsReceived: remoteTrack.paddingPacketsReceived.Load(),
+			PaddingBytesReceived:   remoteTrack.paddingBytesReceived.Load(),
 		}
 		r.populateInboundStats(&inboundStats, statsGetter, remoteTrack)
 
@@ -503,6 +1048,10 @@ func (r *RTPReceiver) populateInboundStats(
 	inboundStats.FIRCount = stats.InboundRTPStreamStats.FIRCount
 	inboundStats.PLICount = stats.InboundRTPStreamStats.PLICount
 	inboundStats.NACKCount = stats.InboundRTPStreamStats.NACKCount
+	if r.transport != nil {
+		inboundStats.PacketsDiscarded = r.transport.receiveBuffers.discardedPackets(remoteTrack.SSRC())
+	}
+	inboundStats.PacketsDiscarded += uint32(remoteTrack.preBindPacketsDiscarded.Load()) //nolint:gosec
 }
 
 func (r *RTPReceiver) collectAudioPlayoutStats(
@@ -532,11 +1081,22 @@ func (r *RTPReceiver) readRTP(b []byte, reader *TrackRemote) (n int, a intercept
 	select {
 	case <-r.received:
 	case <-r.closedChan:
-		return 0, nil, io.EOF
+		return 0, nil, r.readErr(reader)
+	case <-reader.readDeadline.done():
+		if r.haveClosed() {
+			return 0, nil, r.readErr(reader)
+		}
+
+		return 0, nil, errReadTimeout
 	}
 
 	if t := r.streamsForTrack(reader); t != nil {
-		return t.rtpInterceptor.Read(b, a)
+		n, a, err = t.rtpInterceptor.Read(b, a)
+		if errors.Is(err, io.EOF) {
+			err = r.readErr(reader)
+		}
+
+		return n, a, err
 	}
 
 	return 0, nil, fmt.Errorf("%w: %d", errRTPReceiverWithSSRCTrackStreamNotFound, reader.SSRC())
@@ -576,6 +1136,17 @@ func (r *RTPReceiver) receiveForRid(
 			r.tracks[i].rtpInterceptor = rtpInterceptor
 			r.tracks[i].rtcpReadStream = rtcpReadStream
 			r.tracks[i].rtcpInterceptor = rtcpInterceptor
+			if err := r.applyReadDeadlines(&r.tracks[i]); err != nil {
+				return nil, err
+			}
+			r.watchGoodbye(SSRC(streamInfo.SSRC))
+
+			r.tracks[i].track.mu.Lock()
+			err := r.startLayerDrain(&r.tracks[i])
+			r.tracks[i].track.mu.Unlock()
+			if err != nil {
+				return nil, err
+			}
 
 			return r.tracks[i].track, nil
 		}
@@ -584,6 +1155,53 @@ func (r *RTPReceiver) receiveForRid(
 	return nil, fmt.Errorf("%w: %s", errRTPReceiverForRIDTrackStreamNotFound, rid)
 }
 
+// receiveForSSRC binds a stream that wasn't signaled to a receiver that hasn't received yet.
+func (r *RTPReceiver) receiveForSSRC(
+	ssrc SSRC,
+	rid string,
+	params RTPParameters,
+	streamInfo *interceptor.StreamInfo,
+	rtpReadStream *srtp.ReadStreamSRTP,
+	rtpInterceptor interceptor.RTPReader,
+	rtcpReadStream *srtp.ReadStreamSRTCP,
+	rtcpInterceptor interceptor.RTCPReader,
+	peekedPackets []*peekedPacket,
+) (*TrackRemote, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.haveClosed() {
+		return nil, io.EOF
+	}
+
+	select {
+	case <-r.received:
+		return nil, errRTPReceiverReceiveAlreadyCalled
+	default:
+	}
+
+	track := newTrackRemote(r.kind, ssrc, 0, rid, r)
+	track.codec = params.Codecs[0]
+	track.params = params
+	track.peekedPackets = peekedPackets
+
+	r.tracks = append(r.tracks, trackStreams{
+		track:           track,
+		streamInfo:      streamInfo,
+		rtpReadStream:   rtpReadStream,
+		rtpInterceptor:  rtpInterceptor,
+		rtcpReadStream:  rtcpReadStream,
+		rtcpInterceptor: rtcpInterceptor,
+	})
+	if err := r.applyReadDeadlines(&r.tracks[len(r.tracks)-1]); err != nil {
+		return nil, err
+	}
+	r.watchGoodbye(ssrc)
+	close(r.received)
+
+	return track, nil
+}
+
 // receiveForRtx starts a routine that processes the repair stream.
 func (r *RTPReceiver) receiveForRtx(
 	ssrc SSRC,
@@ -678,6 +1296,16 @@ func (r *RTPReceiver) receiveForRtxInternal(
 
 			if i-int(headerLength)-paddingLength < 2 {
 				// BWE probe packet, ignore
+				if hasPadding {
+					track.track.countPaddingReceived(paddingLength, true)
+				}
+				r.rtxPool.Put(b) // nolint:staticcheck
+
+				continue
+			}
+
+			payloadType, ok := r.repairedPayloadType(PayloadType(b[1]&0x7F), track.track.PayloadType())
+			if !ok {
 				r.rtxPool.Put(b) // nolint:staticcheck
 
 				continue
@@ -690,7 +1318,7 @@ func (r *RTPReceiver) receiveForRtxInternal(
 			attributes.Set(AttributeRtxSequenceNumber, binary.BigEndian.Uint16(b[2:4]))
 			attributes.Set(AttributeRtxSsrc, binary.BigEndian.Uint32(b[8:12]))
 
-			b[1] = (b[1] & 0x80) | uint8(track.track.PayloadType())
+			b[1] = (b[1] & 0x80) | uint8(payloadType)
 			b[2] = b[headerLength]
 			b[3] = b[headerLength+1]
 			binary.BigEndian.PutUint32(b[8:12], uint32(track.track.SSRC()))
@@ -712,10 +1340,19 @@ func (r *RTPReceiver) receiveForRtxInternal(
 }
 
 // SetReadDeadline sets the max amount of time the RTCP stream will block before returning. 0 is forever.
+// Once the deadline is exceeded Read returns an error that is a net.Error with Timeout true.
+// The deadline also applies while Read waits for Receive to be called.
 func (r *RTPReceiver) SetReadDeadline(t time.Time) error {
+	r.rtcpReadDeadline.set(t)
+
 	r.mu.RLock()
 	defer r.mu.RUnlock()
 
+	if len(r.tracks) == 0 || r.tracks[0].rtcpReadStream == nil {
+		// applied once the stream is bound
+		return nil
+	}
+
 	return r.tracks[0].rtcpReadStream.SetReadDeadline(t)
 }
 
@@ -741,12 +1378,33 @@ func (r *RTPReceiver) setRTPReadDeadline(deadline time.Time, reader *TrackRemote
 	defer r.mu.RUnlock()
 
 	if t := r.streamsForTrack(reader); t != nil {
+		reader.mu.RLock()
+		paused := reader.layerPause != nil || reader.preBind != nil
+		reader.mu.RUnlock()
+
+		if t.rtpReadStream == nil || paused {
+			// applied once the stream is bound, the layer resumed or the track is read
+			return nil
+		}
+
 		return t.rtpReadStream.SetReadDeadline(deadline)
 	}
 
 	return fmt.Errorf("%w: %d", errRTPReceiverWithSSRCTrackStreamNotFound, reader.SSRC())
 }
 
+// applyReadDeadlines applies the deadlines set before streams were bound to them.
+func (r *RTPReceiver) applyReadDeadlines(streams *trackStreams) error {
+	if err := streams.track.readDeadline.apply(streams.rtpReadStream); err != nil {
+		return err
+	}
+	if streams == &r.tracks[0] {
+		return r.rtcpReadDeadline.apply(streams.rtcpReadStream)
+	}
+
+	return nil
+}
+
 // readRTX returns an RTX packet if one is available on the RTX track, otherwise returns nil.
 func (r *RTPReceiver) readRTX(reader *TrackRemote) *rtxPacketWithAttributes {
 	if !reader.HasRTX() || r.haveClosed() {
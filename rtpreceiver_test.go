@@ -9,6 +9,9 @@ import (
 	"context"
 	"io"
 	"math"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -17,61 +20,15 @@ import (
 	mock_interceptor "github.com/pion/interceptor/pkg/mock"
 	"github.com/pion/interceptor/pkg/stats"
 	"github.com/pion/logging"
+	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v4/test"
 	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
-// Assert that SetReadDeadline works as expected
-// This test uses VNet since we must have zero loss.
-func Test_RTPReceiver_SetReadDeadline(t *testing.T) {
-	lim := test.TimeOut(time.Second * 30)
-	defer lim.Stop()
-
-	report := test.CheckRoutines(t)
-	defer report()
-
-	sender, receiver, wan := createVNetPair(t, &interceptor.Registry{})
-
-	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
-	assert.NoError(t, err)
-
-	_, err = sender.AddTrack(track)
-	assert.NoError(t, err)
-
-	seenPacket, seenPacketCancel := context.WithCancel(context.Background())
-	receiver.OnTrack(func(trackRemote *TrackRemote, r *RTPReceiver) {
-		// Set Deadline for both RTP and RTCP Stream
-		assert.NoError(t, r.SetReadDeadline(time.Now().Add(time.Second)))
-		assert.NoError(t, trackRemote.SetReadDeadline(time.Now().Add(time.Second)))
-
-		// First call will not error because we cache for probing
-		_, _, readErr := trackRemote.ReadRTP()
-		assert.NoError(t, readErr)
-
-		_, _, readErr = trackRemote.ReadRTP()
-		assert.Error(t, readErr)
-
-		_, _, readErr = r.ReadRTCP()
-		assert.Error(t, readErr)
-
-		seenPacketCancel()
-	})
-
-	peerConnectionsConnected := untilConnectionState(PeerConnectionStateConnected, sender, receiver)
-
-	assert.NoError(t, signalPair(sender, receiver))
-
-	peerConnectionsConnected.Wait()
-	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xAA}, Duration: time.Second}))
-
-	<-seenPacket.Done()
-	assert.NoError(t, wan.Stop())
-	closePairNow(t, sender, receiver)
-}
-
 func TestRTPReceiver_ClosedReceiveForRIDAndRTX(t *testing.T) {
 	lim := test.TimeOut(time.Second * 5)
 	defer lim.Stop()
@@ -646,3 +603,509 @@ func TestRTPReceiver_CollectStats_RID(t *testing.T) {
 
 	assert.Equal(t, rid, inbound.Rid)
 }
+
+func TestRTPReceiver_Goodbye_Simulcast(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	writers := map[string]*TrackLocalStaticRTP{}
+	for _, rid := range []string{"a", "b"} {
+		writers[rid], err = NewTrackLocalStaticRTP(
+			RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithRTPStreamID(rid),
+		)
+		require.NoError(t, err)
+	}
+
+	sender, err := pcOffer.AddTrack(writers["a"])
+	require.NoError(t, err)
+	require.NoError(t, sender.AddEncoding(writers["b"]))
+
+	var midID, ridID uint8
+	for _, extension := range sender.GetParameters().HeaderExtensions {
+		switch extension.URI {
+		case sdp.SDESMidURI:
+			midID = uint8(extension.ID) //nolint:gosec // G115
+		case sdp.SDESRTPStreamIDURI:
+			ridID = uint8(extension.ID) //nolint:gosec // G115
+		}
+	}
+
+	var tracksLock sync.Mutex
+	tracks := map[string]*TrackRemote{}
+	var receiver *RTPReceiver
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, r *RTPReceiver) {
+		tracksLock.Lock()
+		defer tracksLock.Unlock()
+		tracks[trackRemote.RID()] = trackRemote
+		receiver = r
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var sequenceNumber uint16
+	writePackets := func(rids ...string) {
+		sequenceNumber++
+		for _, rid := range rids {
+			pkt := &rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 96},
+				Payload: []byte{0x00},
+			}
+			assert.NoError(t, pkt.Header.SetExtension(midID, []byte("0")))
+			assert.NoError(t, pkt.Header.SetExtension(ridID, []byte(rid)))
+			assert.NoError(t, writers[rid].WriteRTP(pkt))
+		}
+	}
+
+	for {
+		tracksLock.Lock()
+		received := len(tracks)
+		tracksLock.Unlock()
+		if received == 2 {
+			break
+		}
+
+		writePackets("a", "b")
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	// A BYE for the SSRC of one layer only ends that layer
+	ssrcA := sender.GetParameters().Encodings[0].SSRC
+	require.NoError(t, pcOffer.WriteRTCP([]rtcp.Packet{&rtcp.Goodbye{Sources: []uint32{uint32(ssrcA)}}}))
+
+	for goodbye := false; !goodbye; {
+		pkts, _, readErr := receiver.ReadSimulcastRTCP("a")
+		require.NoError(t, readErr)
+		for _, pkt := range pkts {
+			if _, ok := pkt.(*rtcp.Goodbye); ok {
+				goodbye = true
+			}
+		}
+	}
+
+	for {
+		if _, _, err = tracks["a"].ReadRTP(); err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, io.EOF)
+
+	writePackets("b")
+	pkt, _, err := tracks["b"].ReadRTP()
+	assert.NoError(t, err)
+	assert.NotNil(t, pkt)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestRTPReceiver_ReceiveBufferSize(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The burst is larger than the default buffer of 1MB
+	const (
+		burstPackets = 1500
+		payloadSize  = 1000
+	)
+
+	run := func(t *testing.T, settingEngine SettingEngine) (received int, discarded uint32) {
+		t.Helper()
+
+		// The packets must wait in the receive buffer, not in the one of the track
+		settingEngine.SetReceiverPreBindBufferSize(-1)
+
+		pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).newPair(Configuration{})
+		require.NoError(t, err)
+		defer closePairNow(t, pcOffer, pcAnswer)
+
+		track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, err)
+		_, err = pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		onTrack := make(chan *TrackRemote, 1)
+		pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+			onTrack <- trackRemote
+		})
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		var sequenceNumber uint16
+		writePacket := func() {
+			sequenceNumber++
+			assert.NoError(t, track.WriteRTP(&rtp.Packet{
+				Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, PayloadType: 96},
+				Payload: make([]byte, payloadSize),
+			}))
+		}
+
+		var trackRemote *TrackRemote
+		for trackRemote == nil {
+			writePacket()
+			select {
+			case trackRemote = <-onTrack:
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+
+		// Nothing reads the track during the burst, writes are paced so sockets don't drop packets
+		for i := range burstPackets {
+			writePacket()
+			if i%50 == 0 {
+				time.Sleep(time.Millisecond * 5)
+			}
+		}
+		time.Sleep(time.Millisecond * 200)
+
+		assert.NoError(t, trackRemote.SetReadDeadline(time.Now().Add(time.Millisecond*500)))
+		for {
+			pkt, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				break
+			}
+			if len(pkt.Payload) == payloadSize {
+				received++
+			}
+		}
+
+		for _, s := range pcAnswer.GetStats() {
+			if inbound, ok := s.(InboundRTPStreamStats); ok {
+				discarded = inbound.PacketsDiscarded
+			}
+		}
+
+		return received, discarded
+	}
+
+	t.Run("Default", func(t *testing.T) {
+		received, discarded := run(t, SettingEngine{})
+		assert.Less(t, received, burstPackets)
+		assert.NotZero(t, discarded)
+	})
+
+	t.Run("Configured", func(t *testing.T) {
+		settingEngine := SettingEngine{}
+		settingEngine.SetSRTPReceiveBufferSize(4 * 1000 * 1000)
+		received, discarded := run(t, settingEngine)
+		assert.GreaterOrEqual(t, received, burstPackets)
+		assert.Zero(t, discarded)
+	})
+}
+
+func TestRTPReceiver_PreBindBuffer(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// run sends a keyframe, then count packets that aren't read before the delay, and reads them
+	run := func(
+		t *testing.T, settingEngine SettingEngine, count int, delay time.Duration,
+	) (packets []*rtp.Packet, discarded uint32) {
+		t.Helper()
+
+		pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).newPair(Configuration{})
+		require.NoError(t, err)
+		defer closePairNow(t, pcOffer, pcAnswer)
+
+		track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, err)
+		_, err = pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		onTrack := make(chan *TrackRemote, 1)
+		pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+			onTrack <- trackRemote
+		})
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		// The keyframe is only sent once, the other packets carry padding
+		require.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: 1, PayloadType: 96, Marker: true},
+			Payload: []byte{0x10, 0x00, 0x00, 0x9d, 0x01, 0x2a},
+		}))
+		trackRemote := <-onTrack
+		for i := range count {
+			require.NoError(t, track.WriteRTP(&rtp.Packet{
+				Header: rtp.Header{
+					Version: 2, SequenceNumber: uint16(i + 2), PayloadType: 96, //nolint:gosec // i < count
+					Padding: true, PaddingSize: 4,
+				},
+				Payload: []byte{0x00},
+			}))
+		}
+		time.Sleep(delay)
+
+		assert.NoError(t, trackRemote.SetReadDeadline(time.Now().Add(time.Millisecond*200)))
+		for {
+			pkt, attributes, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				break
+			}
+			if pkt.Padding {
+				assert.Equal(t, uint8(4), attributes.Get(AttributePaddingSize))
+			}
+			packets = append(packets, pkt)
+		}
+
+		for _, s := range pcAnswer.GetStats() {
+			if inbound, ok := s.(InboundRTPStreamStats); ok {
+				discarded = inbound.PacketsDiscarded
+			}
+		}
+
+		return packets, discarded
+	}
+
+	sequenceNumbers := func(packets []*rtp.Packet) (sequenceNumbers []uint16) {
+		for _, pkt := range packets {
+			sequenceNumbers = append(sequenceNumbers, pkt.SequenceNumber)
+		}
+
+		return sequenceNumbers
+	}
+
+	t.Run("DelayedRead", func(t *testing.T) {
+		packets, discarded := run(t, SettingEngine{}, 20, time.Millisecond*500)
+		assert.Zero(t, discarded)
+		require.Len(t, packets, 21)
+		assert.Equal(t, []byte{0x10, 0x00, 0x00, 0x9d, 0x01, 0x2a}, packets[0].Payload)
+		for i, sequenceNumber := range sequenceNumbers(packets) {
+			assert.Equal(t, uint16(i+1), sequenceNumber) //nolint:gosec // i < 21
+		}
+	})
+
+	t.Run("Bounded", func(t *testing.T) {
+		settingEngine := SettingEngine{}
+		settingEngine.SetReceiverPreBindBufferSize(10)
+
+		// The oldest packets are dropped
+		packets, discarded := run(t, settingEngine, 30, time.Millisecond*200)
+		assert.Equal(t, uint32(21), discarded)
+		require.Len(t, packets, 10)
+		assert.Equal(t, uint16(22), packets[0].SequenceNumber)
+		assert.Equal(t, uint16(31), packets[9].SequenceNumber)
+	})
+}
+
+func TestRTPReceiver_repairedPayloadType(t *testing.T) {
+	receiver, err := NewAPI().NewRTPReceiver(RTPCodecTypeVideo, &DTLSTransport{})
+	require.NoError(t, err)
+
+	// Without remote codecs the retransmissions repair the track
+	payloadType, ok := receiver.repairedPayloadType(97, 96)
+	assert.True(t, ok)
+	assert.Equal(t, PayloadType(96), payloadType)
+
+	receiver.setRemoteCodecs([]RTPCodecParameters{
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil}, PayloadType: 96},
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=96", nil}, PayloadType: 97},
+		{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, "packetization-mode=1;profile-level-id=42e01f", nil},
+			PayloadType:        102,
+		},
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=102", nil}, PayloadType: 103},
+		{
+			RTPCodecCapability: RTPCodecCapability{MimeTypeH264, 90000, 0, "packetization-mode=1;profile-level-id=640032", nil},
+			PayloadType:        104,
+		},
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=104", nil}, PayloadType: 105},
+		{RTPCodecCapability: RTPCodecCapability{MimeTypeRTX, 90000, 0, "apt=110", nil}, PayloadType: 111},
+	})
+
+	for _, testCase := range []struct {
+		name                             string
+		rtxPayloadType, trackPayloadType PayloadType
+		expected                         PayloadType
+		expectedOK                       bool
+	}{
+		{"RTX of the track codec", 103, 102, 102, true},
+		{"RTX of another profile of the track codec", 105, 102, 102, true},
+		{"RTX of another codec", 97, 102, 0, false},
+		{"Not RTX", 104, 102, 0, false},
+		{"Unknown payload type", 120, 102, 0, false},
+		{"RTX without primary codec", 111, 102, 0, false},
+		{"Track without packets yet", 105, 0, 104, true},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			payloadType, ok := receiver.repairedPayloadType(testCase.rtxPayloadType, testCase.trackPayloadType)
+			assert.Equal(t, testCase.expectedOK, ok)
+			assert.Equal(t, testCase.expected, payloadType)
+		})
+	}
+}
+
+func TestRTPReceiver_WriteRTCP(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	offerTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "offer")
+	require.NoError(t, err)
+	offerSender, err := pcOffer.AddTrack(offerTrack)
+	require.NoError(t, err)
+
+	answerTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "answer")
+	require.NoError(t, err)
+	answerSender, err := pcAnswer.AddTrack(answerTrack)
+	require.NoError(t, err)
+
+	onTrack := make(chan *RTPReceiver, 1)
+	pcAnswer.OnTrack(func(_ *TrackRemote, receiver *RTPReceiver) {
+		onTrack <- receiver
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{offerTrack})
+		close(sent)
+	}()
+	receiver := <-onTrack
+	close(done)
+	<-sent
+
+	offerSSRC := uint32(offerSender.GetParameters().Encodings[0].SSRC)
+	answerSSRC := uint32(answerSender.GetParameters().Encodings[0].SSRC)
+
+	// The SSRCs of a PLI written through the receiver are filled in
+	require.NoError(t, receiver.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{}}))
+	var pli *rtcp.PictureLossIndication
+	for pli == nil {
+		pkts, _, readErr := offerSender.ReadRTCP()
+		require.NoError(t, readErr)
+		for _, pkt := range pkts {
+			if p, ok := pkt.(*rtcp.PictureLossIndication); ok {
+				pli = p
+			}
+		}
+	}
+	assert.Equal(t, offerSSRC, pli.MediaSSRC)
+	assert.Equal(t, answerSSRC, pli.SenderSSRC)
+
+	// A Sender Report written through the sender carries its SSRC
+	require.NoError(t, offerSender.WriteRTCP([]rtcp.Packet{&rtcp.SenderReport{NTPTime: 42}}))
+	var senderReport *rtcp.SenderReport
+	for senderReport == nil {
+		pkts, _, readErr := receiver.ReadRTCP()
+		require.NoError(t, readErr)
+		for _, pkt := range pkts {
+			if sr, ok := pkt.(*rtcp.SenderReport); ok && sr.NTPTime == 42 {
+				senderReport = sr
+			}
+		}
+	}
+	assert.Equal(t, offerSSRC, senderReport.SSRC)
+
+	closePairNow(t, pcOffer, pcAnswer)
+	assert.ErrorIs(t, receiver.WriteRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{}}), io.ErrClosedPipe)
+	assert.ErrorIs(t, offerSender.WriteRTCP([]rtcp.Packet{&rtcp.SenderReport{}}), io.ErrClosedPipe)
+}
+
+func TestRTPReceiver_GetParameters_Negotiated(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+
+	// assertAnswered asserts that the parameters match the media section in answer exactly
+	assertAnswered := func(answer string, parameters RTPParameters) {
+		t.Helper()
+
+		parsed := &sdp.SessionDescription{}
+		require.NoError(t, parsed.UnmarshalString(answer))
+		media := parsed.MediaDescriptions[0]
+
+		require.Len(t, parameters.Codecs, len(media.MediaName.Formats))
+		for i, format := range media.MediaName.Formats {
+			codec := parameters.Codecs[i]
+			assert.Equal(t, format, strconv.Itoa(int(codec.PayloadType)))
+
+			for _, attribute := range media.Attributes {
+				if rtpmap, ok := strings.CutPrefix(attribute.Value, format+" "); ok && attribute.Key == "rtpmap" {
+					name, _, _ := strings.Cut(rtpmap, "/")
+					assert.True(t, strings.EqualFold("video/"+name, codec.MimeType), "%s %s", name, codec.MimeType)
+				}
+			}
+		}
+
+		extensions := map[int]string{}
+		for _, attribute := range media.Attributes {
+			if attribute.Key == sdp.AttrKeyExtMap {
+				fields := strings.Fields(attribute.Value)
+				id, convErr := strconv.Atoi(fields[0])
+				require.NoError(t, convErr)
+				extensions[id] = fields[1]
+			}
+		}
+		require.Len(t, parameters.HeaderExtensions, len(extensions))
+		for _, extension := range parameters.HeaderExtensions {
+			assert.Equal(t, extensions[extension.ID], extension.URI)
+		}
+
+		_, reducedSize := media.Attribute(sdp.AttrKeyRTCPRsize)
+		assert.Equal(t, reducedSize, parameters.RTCP.ReducedSize)
+	}
+
+	hasCodec := func(codecs []RTPCodecParameters, mimeType string) bool {
+		return slices.ContainsFunc(codecs, func(codec RTPCodecParameters) bool {
+			return strings.EqualFold(codec.MimeType, mimeType)
+		})
+	}
+
+	// Before an answer the receiver reports what it supports
+	assert.Nil(t, pcOffer.GetTransceivers()[0].NegotiatedCodecs())
+	assert.Empty(t, pcOffer.GetRemoteCapabilities())
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	answer := pcAnswer.LocalDescription().SDP
+
+	offerParameters := pcOffer.GetTransceivers()[0].Receiver().GetParameters()
+	assertAnswered(answer, offerParameters)
+	assertAnswered(answer, pcAnswer.GetTransceivers()[0].Receiver().GetParameters())
+	assert.True(t, hasCodec(offerParameters.Codecs, MimeTypeH264))
+	assert.True(t, offerParameters.RTCP.ReducedSize)
+	assert.Equal(t, offerParameters.Codecs, pcOffer.GetTransceivers()[0].NegotiatedCodecs())
+	assert.Equal(t, map[string]RTPParameters{"0": offerParameters}, pcOffer.GetRemoteCapabilities())
+
+	// Renegotiate without H264, the parameters follow the new answer
+	vp8 := pcAnswer.api.mediaEngine.getCodecsByKind(RTPCodecTypeVideo)[0]
+	require.Equal(t, MimeTypeVP8, vp8.MimeType)
+	require.NoError(t, pcAnswer.GetTransceivers()[0].SetCodecPreferences([]RTPCodecParameters{vp8}))
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	answer = pcAnswer.LocalDescription().SDP
+
+	offerParameters = pcOffer.GetTransceivers()[0].Receiver().GetParameters()
+	assertAnswered(answer, offerParameters)
+	assertAnswered(answer, pcAnswer.GetTransceivers()[0].Receiver().GetParameters())
+	assert.False(t, hasCodec(offerParameters.Codecs, MimeTypeH264))
+	assert.True(t, hasCodec(offerParameters.Codecs, MimeTypeVP8))
+	assert.Equal(t, offerParameters.Codecs, pcOffer.GetTransceivers()[0].NegotiatedCodecs())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
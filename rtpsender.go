@@ -6,12 +6,18 @@
 package webrtc
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"math"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/stats"
+	"github.com/pion/logging"
 	"github.com/pion/randutil"
 	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
@@ -20,15 +26,46 @@ import (
 
 type trackEncoding struct {
 	track TrackLocal
+	// rid is the RID of the encoding, it doesn't change when the track is replaced.
+	rid string
 
 	srtpStream *srtpWriterFuture
 
-	rtcpInterceptor interceptor.RTCPReader
-	streamInfo      interceptor.StreamInfo
+	rtcpInterceptor  interceptor.RTCPReader
+	streamInfo       interceptor.StreamInfo
+	headerExtensions []RTPHeaderExtensionParameter
 
-	context *baseTrackLocalContext
+	context     *baseTrackLocalContext
+	writeStream *interceptorToTrackLocalWriter
+
+	retransmissions retransmissionHistory
+
+	// silence is running while the track is replaced with nil, see RTPSender.SetSilenceGeneration.
+	silence *silenceGenerator
 
 	ssrc, ssrcRTX, ssrcFEC SSRC
+
+	paddingPacketsSent, paddingBytesSent atomic.Uint64
+
+	// stallFillerPacketsSent counts the sent packets flagged with AttributeSourceStallFiller.
+	stallFillerPacketsSent atomic.Uint64
+
+	// rtxSequenceNumber is the last sequence number of the RTX stream, flagged with
+	// sequenceNumberValid once set, and with rtxPadded once padding was sent on it
+	rtxSequenceNumber atomic.Uint32
+
+	// packetSendDelay is the total time in nanoseconds the packets spent between being
+	// packetized and sent, see AttributePacketizedAt.
+	packetSendDelay atomic.Int64
+}
+
+// accountPadding counts the padding of a packet written for this encoding.
+func (t *trackEncoding) accountPadding(header *rtp.Header, payload []byte) {
+	paddingSize, paddingOnly := rtpPadding(header, payload)
+	if paddingOnly {
+		t.paddingPacketsSent.Add(1)
+	}
+	t.paddingBytesSent.Add(uint64(paddingSize)) //nolint:gosec // paddingSize is at most 255
 }
 
 // RTPSender allows an application to control how a given Track is encoded and transmitted to a remote peer.
@@ -46,14 +83,42 @@ type RTPSender struct {
 	// transceiver negotiation status
 	negotiated bool
 
+	silenceGeneration         bool
+	retransmissionFlushPolicy RetransmissionFlushPolicy
+
+	// sendPaused is set while the negotiated direction doesn't allow sending, the packets
+	// written by the track are dropped then.
+	sendPaused atomic.Bool
+
+	// videoLayersAllocation is set by SetVideoLayersAllocation.
+	videoLayersAllocation atomic.Pointer[VideoLayersAllocation]
+
+	// playoutDelay is set by SetPlayoutDelay.
+	playoutDelay atomic.Pointer[rtp.PlayoutDelayExtension]
+
+	// packetSent is set by OnPacketSent.
+	packetSent atomic.Pointer[packetSentHandler]
+
+	// padding paces the packets of SendPadding.
+	padding paddingPacer
+
+	// feedbackRouter routes the RTCP read before the interceptors, see RTCPFeedbackRouter.RouteRTCP.
+	feedbackRouter atomic.Pointer[RTCPFeedbackRouter]
+
 	// A reference to the associated api object
 	api *API
 	id  string
 
 	rtpTransceiver *RTPTransceiver
 
+	log logging.LeveledLogger
+
 	mu                     sync.RWMutex
 	sendCalled, stopCalled chan struct{}
+
+	readDeadline readDeadline
+
+	userData userData
 }
 
 // NewRTPSender constructs a new RTPSender.
@@ -76,9 +141,10 @@ func (api *API) NewRTPSender(track TrackLocal, transport *DTLSTransport) (*RTPSe
 		stopCalled: make(chan struct{}),
 		id:         id,
 		kind:       track.Kind(),
+		log:        api.settingEngine.LoggerFactory.NewLogger("RTPSender"),
 	}
 
-	r.addEncoding(track)
+	r.addEncoding(track, track.RID())
 
 	return r, nil
 }
@@ -119,13 +185,9 @@ func (r *RTPSender) GetParameters() RTPSendParameters {
 
 	var encodings []RTPEncodingParameters
 	for _, trackEncoding := range r.trackEncodings {
-		var rid string
-		if trackEncoding.track != nil {
-			rid = trackEncoding.track.RID()
-		}
 		encodings = append(encodings, RTPEncodingParameters{
 			RTPCodingParameters: RTPCodingParameters{
-				RID:         rid,
+				RID:         trackEncoding.rid,
 				SSRC:        trackEncoding.ssrc,
 				RTX:         RTPRtxParameters{SSRC: trackEncoding.ssrcRTX},
 				FEC:         RTPFecParameters{SSRC: trackEncoding.ssrcFEC},
@@ -134,7 +196,7 @@ func (r *RTPSender) GetParameters() RTPSendParameters {
 		})
 	}
 	sendParameters := RTPSendParameters{
-		RTPParameters: r.api.mediaEngine.getRTPParametersByKind(
+		RTPParameters: r.api.getRTPParametersByKind(
 			r.kind,
 			[]RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
 		),
@@ -142,6 +204,9 @@ func (r *RTPSender) GetParameters() RTPSendParameters {
 	}
 	if r.rtpTransceiver != nil {
 		sendParameters.Codecs = r.rtpTransceiver.getCodecs()
+		if negotiated := r.rtpTransceiver.getNegotiatedParameters(); negotiated != nil {
+			sendParameters.RTCP = negotiated.RTCP
+		}
 	} else {
 		sendParameters.Codecs = r.api.mediaEngine.getCodecsByKind(r.kind)
 	}
@@ -171,10 +236,10 @@ func (r *RTPSender) AddEncoding(track TrackLocal) error { //nolint:cyclop
 	}
 
 	var refTrack TrackLocal
-	if len(r.trackEncodings) != 0 {
+	if len(r.trackEncodings) != 0 && r.trackEncodings[0].rid != "" {
 		refTrack = r.trackEncodings[0].track
 	}
-	if refTrack == nil || refTrack.RID() == "" {
+	if refTrack == nil {
 		return errRTPSenderNoBaseEncoding
 	}
 
@@ -183,28 +248,30 @@ func (r *RTPSender) AddEncoding(track TrackLocal) error { //nolint:cyclop
 	}
 
 	for _, encoding := range r.trackEncodings {
-		if encoding.track == nil {
-			continue
-		}
-
-		if encoding.track.RID() == track.RID() {
+		if encoding.rid == track.RID() {
 			return errRTPSenderRIDCollision
 		}
 	}
 
-	r.addEncoding(track)
+	r.addEncoding(track, track.RID())
 
 	return nil
 }
 
-func (r *RTPSender) addEncoding(track TrackLocal) {
+func (r *RTPSender) addEncoding(track TrackLocal, rid string) {
+	var ssrc, ssrcRTX SSRC
+	if ssrcTrack, ok := track.(interface{ requestedSSRCs() (SSRC, SSRC) }); ok {
+		ssrc, ssrcRTX = ssrcTrack.requestedSSRCs()
+	}
+
 	trackEncoding := &trackEncoding{
 		track: track,
-		ssrc:  SSRC(util.RandUint32()),
+		rid:   rid,
+		ssrc:  randomSSRCIfZero(ssrc),
 	}
 
-	if r.api.mediaEngine.isRTXEnabled(r.kind, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}) {
-		trackEncoding.ssrcRTX = SSRC(util.RandUint32())
+	if r.isRTXEnabled() {
+		trackEncoding.ssrcRTX = randomSSRCIfZero(ssrcRTX)
 	}
 
 	if r.api.mediaEngine.isFECEnabled(r.kind, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}) {
@@ -214,6 +281,47 @@ func (r *RTPSender) addEncoding(track TrackLocal) {
 	r.trackEncodings = append(r.trackEncodings, trackEncoding)
 }
 
+// randomSSRCIfZero returns ssrc, or a random SSRC if it is zero.
+func randomSSRCIfZero(ssrc SSRC) SSRC {
+	if ssrc == 0 {
+		return SSRC(util.RandUint32())
+	}
+
+	return ssrc
+}
+
+// ssrcs returns the SSRCs the encodings of the sender are sent with.
+func (r *RTPSender) ssrcs() []SSRC {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	var ssrcs []SSRC
+	for _, encoding := range r.trackEncodings {
+		for _, ssrc := range []SSRC{encoding.ssrc, encoding.ssrcRTX, encoding.ssrcFEC} {
+			if ssrc != 0 {
+				ssrcs = append(ssrcs, ssrc)
+			}
+		}
+	}
+
+	return ssrcs
+}
+
+// SetUserData stores an application value under key on the RTPSender, a nil value
+// removes it. Like for context.WithValue, the key must be comparable,
+// ErrUserDataKeyNotComparable is returned otherwise, and should be of an unexported type to
+// avoid collisions. The value is stored as given: a struct is copied, pointers, maps and
+// slices are shared. The values stay across renegotiations and track replacements, and are
+// released once the RTPSender is stopped, values set afterwards are dropped.
+func (r *RTPSender) SetUserData(key, value any) error {
+	return r.userData.set(key, value)
+}
+
+// UserData returns the value stored under key with SetUserData, nil if there is none.
+func (r *RTPSender) UserData(key any) any {
+	return r.userData.get(key)
+}
+
 // Track returns the RTCRtpTransceiver track, or nil.
 func (r *RTPSender) Track() TrackLocal {
 	r.mu.RLock()
@@ -226,6 +334,26 @@ func (r *RTPSender) Track() TrackLocal {
 	return r.trackEncodings[0].track
 }
 
+// usesCodec reports if a track of the sender is bound to a codec of mimeType.
+func (r *RTPSender) usesCodec(mimeType string) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, trackEncoding := range r.trackEncodings {
+		if trackEncoding.track == nil || trackEncoding.context == nil {
+			continue
+		}
+
+		for _, codec := range trackEncoding.context.params.Codecs {
+			if strings.EqualFold(codec.MimeType, mimeType) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // ReplaceTrack replaces the track currently being used as the sender's source with a new TrackLocal.
 // The new track must be of the same media kind (audio, video, etc) and switching the track should not
 // require negotiation.
@@ -242,6 +370,8 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error { //nolint:cyclop
 		return ErrRTPSenderNewTrackHasIncorrectEnvelope
 	}
 
+	r.stopSilenceGeneration()
+
 	var replacedTrack TrackLocal
 	var context *baseTrackLocalContext
 	for _, e := range r.trackEncodings {
@@ -257,18 +387,96 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error { //nolint:cyclop
 		if !r.hasSent() || track == nil {
 			e.track = track
 		}
+		if track != nil && track.RID() != "" {
+			e.rid = track.RID()
+		}
+
+		if r.hasSent() && !r.hasStopped() && r.retransmissionFlushPolicy.OnReplaceTrack {
+			r.flushRetransmissionHistory(e)
+		}
 	}
 
-	if !r.hasSent() || track == nil {
+	if !r.hasSent() {
 		return nil
 	}
 
-	params := r.api.mediaEngine.getRTPParametersByKind(
+	if track == nil {
+		r.startSilenceGeneration()
+
+		return nil
+	}
+
+	// If we reach this point in the routine, there is only 1 track encoding
+	return r.bindEncodingTrack(r.trackEncodings[0], replacedTrack, track)
+}
+
+// ReplaceTrackForRID replaces the track of the encoding with rid, the other encodings keep
+// theirs. The tracks of the encodings provisioned with RTPTransceiverInit.SendEncodings are
+// attached with it once they exist. The track is sent with the SSRCs and the RID of the
+// encoding, whatever RID it has itself, and switching the track doesn't require negotiation.
+func (r *RTPSender) ReplaceTrackForRID(rid string, track TrackLocal) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	switch {
+	case track == nil:
+		return errRTPSenderTrackNil
+	case r.kind != track.Kind():
+		return ErrRTPSenderNewTrackHasIncorrectKind
+	case r.hasStopped():
+		return errRTPSenderStopped
+	}
+
+	var encoding *trackEncoding
+	for _, e := range r.trackEncodings {
+		if e.rid == rid {
+			encoding = e
+		}
+	}
+	if encoding == nil {
+		return fmt.Errorf("%w: %s", errRTPSenderNoTrackForRID, rid)
+	}
+
+	if !r.hasSent() {
+		encoding.track = track
+
+		return nil
+	}
+
+	if encoding.silence != nil {
+		encoding.silence.stop()
+		encoding.silence = nil
+	}
+	replacedTrack := encoding.track
+	if replacedTrack != nil {
+		if err := replacedTrack.Unbind(encoding.context); err != nil {
+			return err
+		}
+	}
+	if r.retransmissionFlushPolicy.OnReplaceTrack {
+		r.flushRetransmissionHistory(encoding)
+	}
+
+	return r.bindEncodingTrack(encoding, replacedTrack, track)
+}
+
+// bindEncodingTrack binds track to the stream of the sent encoding e, instead of replacedTrack
+// which was unbound already. replacedTrack is bound again if track fails to bind. r.mu must
+// be held.
+func (r *RTPSender) bindEncodingTrack(e *trackEncoding, replacedTrack, track TrackLocal) error {
+	context := e.context
+	writeStream := e.writeStream
+	writeStream.continuity.newSource()
+
+	params := r.api.getRTPParametersByKind(
 		track.Kind(),
 		[]RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
 	)
+	// The track can be sent with any codec negotiated for the transceiver
+	if r.rtpTransceiver != nil {
+		params.Codecs = r.rtpTransceiver.getCodecs()
+	}
 
-	// If we reach this point in the routine, there is only 1 track encoding
 	codec, err := track.Bind(&baseTrackLocalContext{
 		id:              context.ID(),
 		params:          params,
@@ -280,23 +488,76 @@ func (r *RTPSender) ReplaceTrack(track TrackLocal) error { //nolint:cyclop
 	})
 	if err != nil {
 		// Re-bind the original track
-		if _, reBindErr := replacedTrack.Bind(context); reBindErr != nil {
-			return reBindErr
+		if replacedTrack != nil {
+			if _, reBindErr := replacedTrack.Bind(context); reBindErr != nil {
+				return reBindErr
+			}
 		}
 
 		return err
 	}
 
-	// Codec has changed
+	// Codec has changed, the stream keeps its SSRC and switches the payload type
 	if r.payloadType != codec.PayloadType {
 		context.params.Codecs = []RTPCodecParameters{codec}
+		r.rebindLocalStream(e, codec, params.Codecs)
+		r.payloadType = codec.PayloadType
 	}
+	writeStream.continuity.setClockRate(codec.ClockRate)
 
-	r.trackEncodings[0].track = track
+	e.track = track
+	r.configureSDESExtensions(e)
 
 	return nil
 }
 
+// SetSilenceGeneration enables keep-alive media while the track of the RTPSender is
+// replaced with nil, so remote jitter buffers don't grow and the stream doesn't freeze.
+// Opus, PCMU and PCMA senders write a silence frame every 20ms, senders of other codecs
+// write padding-only packets at the same cadence. The sequence numbers and timestamps
+// of the track replacing nil continue those of the generated packets.
+func (r *RTPSender) SetSilenceGeneration(enabled bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.silenceGeneration = enabled
+	r.stopSilenceGeneration()
+	for _, e := range r.trackEncodings {
+		if e.writeStream != nil {
+			e.writeStream.continuity.enabled.Store(enabled)
+		}
+	}
+
+	if r.hasSent() && r.trackEncodings[0].track == nil {
+		r.startSilenceGeneration()
+	}
+}
+
+// startSilenceGeneration starts writing keep-alive media if it is enabled, r.mu must be held.
+func (r *RTPSender) startSilenceGeneration() {
+	if !r.silenceGeneration || r.hasStopped() {
+		return
+	}
+
+	for _, e := range r.trackEncodings {
+		if e.writeStream == nil || len(e.context.params.Codecs) == 0 {
+			continue
+		}
+
+		e.silence = startSilenceGenerator(e.writeStream, e.ssrc, e.context.params.Codecs[0])
+	}
+}
+
+// stopSilenceGeneration stops writing keep-alive media, r.mu must be held.
+func (r *RTPSender) stopSilenceGeneration() {
+	for _, e := range r.trackEncodings {
+		if e.silence != nil {
+			e.silence.stop()
+			e.silence = nil
+		}
+	}
+}
+
 // Send Attempts to set the parameters controlling the sending of media.
 func (r *RTPSender) Send(parameters RTPSendParameters) error {
 	r.mu.Lock()
@@ -312,22 +573,53 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 	for idx := range r.trackEncodings {
 		trackEncoding := r.trackEncodings[idx]
 		srtpStream := &srtpWriterFuture{ssrc: parameters.Encodings[idx].SSRC, rtpSender: r}
-		writeStream := &interceptorToTrackLocalWriter{}
-		rtpParameters := r.api.mediaEngine.getRTPParametersByKind(
+		if deadline, ok := r.readDeadline.value(); ok && idx == 0 {
+			srtpStream.readDeadline.set(deadline)
+		}
+		writeStream := &interceptorToTrackLocalWriter{paused: &r.sendPaused}
+		writeStream.continuity.enabled.Store(r.silenceGeneration)
+		for _, ext := range parameters.HeaderExtensions {
+			if ext.ID > maxOneByteHeaderExtensionID {
+				writeStream.twoByteHeaderExtensions = true
+			}
+		}
+		rtpParameters := r.api.getRTPParametersByKind(
 			trackEncoding.track.Kind(),
 			[]RTPTransceiverDirection{RTPTransceiverDirectionSendonly},
 		)
 
 		trackEncoding.srtpStream = srtpStream
+		trackEncoding.writeStream = writeStream
 		trackEncoding.ssrc = parameters.Encodings[idx].SSRC
 		trackEncoding.ssrcRTX = parameters.Encodings[idx].RTX.SSRC
 		trackEncoding.ssrcFEC = parameters.Encodings[idx].FEC.SSRC
 		trackEncoding.rtcpInterceptor = r.api.interceptor.BindRTCPReader(
 			interceptor.RTCPReaderFunc(
 				func(in []byte, a interceptor.Attributes) (n int, attributes interceptor.Attributes, err error) {
-					n, err = trackEncoding.srtpStream.Read(in)
-
-					return n, a, err
+					for {
+						n, err = trackEncoding.srtpStream.Read(in)
+						if err != nil {
+							return n, a, err
+						}
+						if r.transport != nil {
+							r.transport.quality.handleRTCP(trackEncoding.ssrc, in[:n], time.Now())
+						}
+						// RTCP that only NACKed flushed packets isn't passed on
+						if n = trackEncoding.retransmissions.filterFlushedNACKs(trackEncoding.ssrc, in[:n]); n == 0 {
+							continue
+						}
+						// Neither is RTCP that the feedback router forwarded or dropped
+						if router := r.feedbackRouter.Load(); router != nil {
+							if n, err = router.routeRTCP(in[:n]); err != nil {
+								return 0, a, err
+							}
+							if n == 0 {
+								continue
+							}
+						}
+
+						return n, a, nil
+					}
 				},
 			),
 		)
@@ -346,27 +638,16 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 			return err
 		}
 		trackEncoding.context.params.Codecs = []RTPCodecParameters{codec}
-
-		trackEncoding.streamInfo = *createStreamInfo(
-			r.id,
-			parameters.Encodings[idx].SSRC,
-			parameters.Encodings[idx].RTX.SSRC,
-			parameters.Encodings[idx].FEC.SSRC,
-			codec.PayloadType,
-			findRTXPayloadType(codec.PayloadType, rtpParameters.Codecs),
-			findFECPayloadType(rtpParameters.Codecs),
-			codec.RTPCodecCapability,
-			parameters.HeaderExtensions,
-		)
-
-		rtpInterceptor := r.api.interceptor.BindLocalStream(
-			&trackEncoding.streamInfo,
-			interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, _ interceptor.Attributes) (int, error) {
-				return srtpStream.WriteRTP(header, payload)
-			}),
-		)
-
-		writeStream.interceptor.Store(rtpInterceptor)
+		writeStream.continuity.setClockRate(codec.ClockRate)
+
+		trackEncoding.headerExtensions = parameters.HeaderExtensions
+		r.bindLocalStream(trackEncoding, codec, rtpParameters.Codecs)
+		r.configureKeyframeFlush(trackEncoding)
+		r.configureVideoLayersAllocation(trackEncoding, idx)
+		r.configurePlayoutDelay(trackEncoding)
+		r.configureVideoOrientation(trackEncoding)
+		r.configureSDESExtensions(trackEncoding)
+		r.payloadType = codec.PayloadType
 	}
 
 	close(r.sendCalled)
@@ -374,6 +655,84 @@ func (r *RTPSender) Send(parameters RTPSendParameters) error {
 	return nil
 }
 
+// bindLocalStream binds the interceptors of trackEncoding for sending codec, the payload
+// types of RTX and FEC are taken from codecs.
+func (r *RTPSender) bindLocalStream(
+	trackEncoding *trackEncoding,
+	codec RTPCodecParameters,
+	codecs []RTPCodecParameters,
+) {
+	trackEncoding.streamInfo = *createStreamInfo(
+		r.id,
+		trackEncoding.ssrc,
+		trackEncoding.ssrcRTX,
+		trackEncoding.ssrcFEC,
+		codec.PayloadType,
+		findRTXPayloadType(codec.PayloadType, codecs),
+		findFECPayloadType(codecs),
+		codec.RTPCodecCapability,
+		trackEncoding.headerExtensions,
+	)
+	r.bindInterceptor(trackEncoding)
+}
+
+// rebindLocalStream replaces the interceptors of trackEncoding for sending codec.
+func (r *RTPSender) rebindLocalStream(
+	trackEncoding *trackEncoding,
+	codec RTPCodecParameters,
+	codecs []RTPCodecParameters,
+) {
+	trackEncoding.retransmissions.mu.Lock()
+	r.api.interceptor.UnbindLocalStream(&trackEncoding.streamInfo)
+	r.bindLocalStream(trackEncoding, codec, codecs)
+	trackEncoding.retransmissions.mu.Unlock()
+
+	r.configureKeyframeFlush(trackEncoding)
+}
+
+// bindInterceptor binds the interceptors for the streamInfo of trackEncoding.
+func (r *RTPSender) bindInterceptor(trackEncoding *trackEncoding) {
+	srtpStream := trackEncoding.srtpStream
+	ssrc, ssrcRTX := uint32(trackEncoding.ssrc), uint32(trackEncoding.ssrcRTX)
+	rtpInterceptor := r.api.interceptor.BindLocalStream(
+		&trackEncoding.streamInfo,
+		interceptor.RTPWriterFunc(func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+			if ssrcRTX != 0 && header.SSRC == ssrcRTX {
+				// Retransmissions and padding share the sequence numbers of the RTX stream
+				if sequenceNumber, padded := trackEncoding.rtxWritten(header.SequenceNumber); padded {
+					rtx := *header
+					rtx.SequenceNumber = sequenceNumber
+					header = &rtx
+				}
+
+				// Retransmissions carry the repaired rid instead of the rid
+				if writer := trackEncoding.writeStream.sdesExtensions.Load(); writer != nil {
+					header = writer.applyRepair(header)
+				}
+			}
+			n, err := srtpStream.WriteRTP(header, payload)
+			if header.SSRC == ssrc {
+				// A packet that failed to be sent won't be sent anymore either
+				trackEncoding.writeStream.drain.send(header.SequenceNumber)
+			}
+			if err == nil {
+				trackEncoding.accountPadding(header, payload)
+				if header.SSRC == ssrc {
+					trackEncoding.retransmissions.written(header.SequenceNumber)
+				}
+			}
+			// Nothing is written until SRTP is ready
+			if err == nil && n > 0 {
+				r.packetWritten(trackEncoding, header, n, attributes)
+			}
+
+			return n, err
+		}),
+	)
+
+	trackEncoding.writeStream.interceptor.Store(rtpInterceptor)
+}
+
 // Stop irreversibly stops the RTPSender.
 func (r *RTPSender) Stop() error {
 	r.mu.Lock()
@@ -386,6 +745,7 @@ func (r *RTPSender) Stop() error {
 
 	close(r.stopCalled)
 	r.mu.Unlock()
+	r.userData.clear()
 
 	if !r.hasSent() {
 		return nil
@@ -395,6 +755,8 @@ func (r *RTPSender) Stop() error {
 		return err
 	}
 
+	r.sendGoodbye()
+
 	errs := []error{}
 	for _, trackEncoding := range r.trackEncodings {
 		r.api.interceptor.UnbindLocalStream(&trackEncoding.streamInfo)
@@ -406,6 +768,92 @@ func (r *RTPSender) Stop() error {
 	return util.FlattenErrs(errs)
 }
 
+// StopWithFlush stops the RTPSender like Stop, after the packets already written by its track
+// were sent. Samples written after it was called fail with ErrTrackLocalDraining, except for the
+// packets of the sample that was being written. The packets held back by WithPacing are written
+// right away, and the packets queued by interceptors, like the ones of a pacer, are drained to
+// the wire before the RTCP BYE is sent and the track is unbound.
+// If ctx is done before, the RTPSender is stopped anyway and the error of ctx is returned.
+func (r *RTPSender) StopWithFlush(ctx context.Context) error {
+	r.mu.Lock()
+	if r.hasStopped() || !r.hasSent() {
+		r.mu.Unlock()
+
+		return r.Stop()
+	}
+
+	writeStreams := make([]*interceptorToTrackLocalWriter, 0, len(r.trackEncodings))
+	for _, trackEncoding := range r.trackEncodings {
+		writeStreams = append(writeStreams, trackEncoding.writeStream)
+	}
+	r.mu.Unlock()
+
+	// The paced packets of the samples written so far must pass the drain before it starts
+	for _, writeStream := range writeStreams {
+		if pacer := writeStream.pacer.Load(); pacer != nil {
+			pacer.drain(writeStream.drain.start)
+		} else {
+			writeStream.drain.start()
+		}
+	}
+
+	ticker := time.NewTicker(rtpSenderFlushInterval)
+	defer ticker.Stop()
+
+	var flushErr error
+	for !r.flushed() && flushErr == nil {
+		select {
+		case <-ctx.Done():
+			flushErr = ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	if err := r.Stop(); err != nil {
+		return err
+	}
+
+	return flushErr
+}
+
+// flushed returns true if the packets written by the tracks of all encodings were sent.
+func (r *RTPSender) flushed() bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for _, trackEncoding := range r.trackEncodings {
+		if !trackEncoding.writeStream.drain.flushed() {
+			return false
+		}
+		if pacer := trackEncoding.writeStream.pacer.Load(); pacer != nil && pacer.pending() {
+			return false
+		}
+	}
+
+	return true
+}
+
+// sendGoodbye sends a RTCP BYE for the SSRCs of all encodings, so the remote
+// can end the tracks without waiting for a renegotiation.
+func (r *RTPSender) sendGoodbye() {
+	goodbye := &rtcp.Goodbye{}
+	for _, trackEncoding := range r.trackEncodings {
+		for _, ssrc := range []SSRC{trackEncoding.ssrc, trackEncoding.ssrcRTX, trackEncoding.ssrcFEC} {
+			if ssrc != 0 {
+				goodbye.Sources = append(goodbye.Sources, uint32(ssrc))
+			}
+		}
+	}
+
+	if len(goodbye.Sources) == 0 {
+		return
+	}
+
+	if _, err := r.transport.WriteRTCP([]rtcp.Packet{goodbye}); err != nil {
+		r.log.Debugf("Failed to send RTCP BYE: %v", err)
+	}
+}
+
 // Read reads incoming RTCP for this RTPSender.
 func (r *RTPSender) Read(b []byte) (n int, a interceptor.Attributes, err error) {
 	select {
@@ -413,6 +861,12 @@ func (r *RTPSender) Read(b []byte) (n int, a interceptor.Attributes, err error)
 		return r.trackEncodings[0].rtcpInterceptor.Read(b, a)
 	case <-r.stopCalled:
 		return 0, nil, io.ErrClosedPipe
+	case <-r.readDeadline.done():
+		if r.hasStopped() {
+			return 0, nil, io.ErrClosedPipe
+		}
+
+		return 0, nil, errReadTimeout
 	}
 }
 
@@ -432,13 +886,34 @@ func (r *RTPSender) ReadRTCP() ([]rtcp.Packet, interceptor.Attributes, error) {
 	return pkts, attributes, nil
 }
 
+// WriteRTCP sends RTCP about the media of this RTPSender to the remote, like a Sender Report
+// or SDES, through the configured interceptors. A zero sender SSRC is set to the SSRC of the
+// first encoding. The packets are modified in place.
+func (r *RTPSender) WriteRTCP(pkts []rtcp.Packet) error {
+	if r.hasStopped() {
+		return io.ErrClosedPipe
+	}
+
+	r.mu.RLock()
+	var senderSSRC uint32
+	if len(r.trackEncodings) != 0 {
+		senderSSRC = uint32(r.trackEncodings[0].ssrc)
+	}
+	r.mu.RUnlock()
+
+	setRTCPSSRCs(pkts, senderSSRC, 0)
+	_, err := r.transport.writeRTCPThroughInterceptors(pkts)
+
+	return err
+}
+
 // ReadSimulcast reads incoming RTCP for this RTPSender for given rid.
 func (r *RTPSender) ReadSimulcast(b []byte, rid string) (n int, a interceptor.Attributes, err error) {
 	select {
 	case <-r.sendCalled:
 		r.mu.Lock()
 		for _, t := range r.trackEncodings {
-			if t.track != nil && t.track.RID() == rid {
+			if t.rid == rid {
 				reader := t.rtcpInterceptor
 				r.mu.Unlock()
 
@@ -467,13 +942,20 @@ func (r *RTPSender) ReadSimulcastRTCP(rid string) ([]rtcp.Packet, interceptor.At
 }
 
 // SetReadDeadline sets the deadline for the Read operation.
-// Setting to zero means no deadline.
+// Setting to zero means no deadline. Once the deadline is exceeded Read returns an
+// error that is a net.Error with Timeout true, also while Read waits for Send to be called.
 func (r *RTPSender) SetReadDeadline(t time.Time) error {
-	if r.trackEncodings[0].srtpStream == nil {
-		return errRTPSenderSendNotCalled
+	r.readDeadline.set(t)
+
+	r.mu.RLock()
+	srtpStream := r.trackEncodings[0].srtpStream
+	r.mu.RUnlock()
+	if srtpStream == nil {
+		// applied once Send is called
+		return nil
 	}
 
-	return r.trackEncodings[0].srtpStream.SetReadDeadline(t)
+	return srtpStream.SetReadDeadline(t)
 }
 
 // SetReadDeadlineSimulcast sets the max amount of time the RTCP stream for a given rid
@@ -483,7 +965,7 @@ func (r *RTPSender) SetReadDeadlineSimulcast(deadline time.Time, rid string) err
 	defer r.mu.RUnlock()
 
 	for _, t := range r.trackEncodings {
-		if t.track != nil && t.track.RID() == rid {
+		if t.rid == rid {
 			return t.srtpStream.SetReadDeadline(deadline)
 		}
 	}
@@ -491,6 +973,86 @@ func (r *RTPSender) SetReadDeadlineSimulcast(deadline time.Time, rid string) err
 	return fmt.Errorf("%w: %s", errRTPSenderNoTrackForRID, rid)
 }
 
+func (r *RTPSender) collectStats(collector *statsReportCollector, statsGetter stats.Getter) {
+	if statsGetter == nil {
+		return
+	}
+
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if !r.hasSent() {
+		return
+	}
+
+	// Emit outbound-rtp stats for each encoding
+	mid := ""
+	if r.rtpTransceiver != nil {
+		mid = r.rtpTransceiver.Mid()
+	}
+	now := statsTimestampNow()
+	for _, trackEncoding := range r.trackEncodings {
+		collector.Collecting()
+
+		outboundID := fmt.Sprintf("outbound-rtp-%d", uint32(trackEncoding.ssrc))
+		codecID := ""
+		if codecs := trackEncoding.context.params.Codecs; len(codecs) != 0 {
+			codecID = codecs[0].statsID
+		}
+		outboundStats := OutboundRTPStreamStats{
+			Rid:                trackEncoding.rid,
+			Mid:                mid,
+			Timestamp:          now,
+			Type:               StatsTypeOutboundRTP,
+			ID:                 outboundID,
+			SSRC:               trackEncoding.ssrc,
+			Kind:               r.kind.String(),
+			TransportID:        "iceTransport",
+			CodecID:            codecID,
+			PaddingPacketsSent: trackEncoding.paddingPacketsSent.Load(),
+			PaddingBytesSent:   trackEncoding.paddingBytesSent.Load(),
+			FlushedNACKCount:   trackEncoding.retransmissions.flushedNACKs.Load(),
+
+			SourceStallFillerPacketsSent: trackEncoding.stallFillerPacketsSent.Load(),
+
+			TotalPacketSendDelay: time.Duration(trackEncoding.packetSendDelay.Load()).Seconds(),
+		}
+		r.populateOutboundStats(&outboundStats, statsGetter, trackEncoding.ssrc)
+
+		collector.Collect(outboundID, outboundStats)
+	}
+}
+
+func (r *RTPSender) populateOutboundStats(
+	outboundStats *OutboundRTPStreamStats,
+	statsGetter stats.Getter,
+	ssrc SSRC,
+) {
+	stats := statsGetter.Get(uint32(ssrc))
+	if stats == nil {
+		return
+	}
+
+	// Wrap-around casting by design, with warnings if overflow is detected.
+	ps := stats.OutboundRTPStreamStats.PacketsSent
+	if ps > math.MaxUint32 {
+		r.log.Warnf("Outbound PacketsSent exceeds uint32 and will wrap: %d", ps)
+	}
+	outboundStats.PacketsSent = uint32(ps) //nolint:gosec
+
+	outboundStats.BytesSent = stats.OutboundRTPStreamStats.BytesSent
+	outboundStats.HeaderBytesSent = stats.OutboundRTPStreamStats.HeaderBytesSent
+	outboundStats.FIRCount = stats.OutboundRTPStreamStats.FIRCount
+	outboundStats.PLICount = stats.OutboundRTPStreamStats.PLICount
+	outboundStats.NACKCount = stats.OutboundRTPStreamStats.NACKCount
+}
+
+// setSendPaused pauses or resumes sending, as the negotiated direction of the transceiver
+// changes. The sender keeps its SSRCs and the state of its stream while it is paused.
+func (r *RTPSender) setSendPaused(paused bool) {
+	r.sendPaused.Store(paused)
+}
+
 // hasSent tells if data has been ever sent for this instance.
 func (r *RTPSender) hasSent() bool {
 	select {
@@ -511,6 +1073,13 @@ func (r *RTPSender) hasStopped() bool {
 	}
 }
 
+// isRTXEnabled reports if the MediaEngine has RTX for the kind of the sender, and the
+// SettingEngine doesn't disable it.
+func (r *RTPSender) isRTXEnabled() bool {
+	return !r.api.settingEngine.isRTXDisabled(r.kind) &&
+		r.api.mediaEngine.isRTXEnabled(r.kind, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly})
+}
+
 // Set a SSRC for FEC and RTX if MediaEngine has them enabled
 // If the remote doesn't support FEC or RTX we disable locally.
 func (r *RTPSender) configureRTXAndFEC() {
@@ -518,7 +1087,7 @@ func (r *RTPSender) configureRTXAndFEC() {
 	defer r.mu.Unlock()
 
 	for _, trackEncoding := range r.trackEncodings {
-		if !r.api.mediaEngine.isRTXEnabled(r.kind, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}) {
+		if !r.isRTXEnabled() {
 			trackEncoding.ssrcRTX = SSRC(0)
 		}
 
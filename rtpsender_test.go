@@ -6,17 +6,26 @@
 package webrtc
 
 import (
+	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"io"
+	"net"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/pion/interceptor"
+	mock_interceptor "github.com/pion/interceptor/pkg/mock"
+	"github.com/pion/interceptor/pkg/pacing"
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v4/test"
 	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_RTPSender_ReplaceTrack(t *testing.T) { //nolint:cyclop
@@ -100,6 +109,85 @@ func Test_RTPSender_ReplaceTrack(t *testing.T) { //nolint:cyclop
 	closePairNow(t, sender, receiver)
 }
 
+func Test_RTPSender_ReplaceTrack_CodecSwitch(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The new track starts with its own sequence numbers
+	s := SettingEngine{}
+	s.DisableSRTPReplayProtection(true)
+
+	sender, receiver, err := NewAPI(WithSettingEngine(s)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	trackA, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	trackB, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeH264}, "video", "pion")
+	assert.NoError(t, err)
+
+	rtpSender, err := sender.AddTrack(trackA)
+	assert.NoError(t, err)
+
+	remoteCodecs := make(chan RTPCodecParameters, 100)
+	receiver.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			if _, _, err := track.ReadRTP(); err != nil {
+				return
+			}
+			select {
+			case remoteCodecs <- track.Codec():
+			default:
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(sender, receiver))
+
+	writeUntilCodec := func(track *TrackLocalStaticSample, mimeType string) {
+		ticker := time.NewTicker(time.Millisecond * 20)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case codec := <-remoteCodecs:
+				if codec.MimeType == mimeType {
+					return
+				}
+			case <-ticker.C:
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xAA}, Duration: time.Second}))
+			}
+		}
+	}
+
+	writeUntilCodec(trackA, MimeTypeVP8)
+	ssrc := rtpSender.GetParameters().Encodings[0].SSRC
+	vp8PayloadType := rtpSender.GetParameters().Encodings[0].PayloadType
+
+	assert.NoError(t, rtpSender.ReplaceTrack(trackB))
+	writeUntilCodec(trackB, MimeTypeH264)
+
+	// Same stream, but the payload type and RTX apt mapping follow H264
+	parameters := rtpSender.GetParameters()
+	assert.Equal(t, ssrc, parameters.Encodings[0].SSRC)
+	assert.NotEqual(t, vp8PayloadType, parameters.Encodings[0].PayloadType)
+
+	streamInfo := rtpSender.trackEncodings[0].streamInfo
+	assert.Equal(t, uint32(ssrc), streamInfo.SSRC)
+	assert.Equal(t, uint8(parameters.Encodings[0].PayloadType), streamInfo.PayloadType)
+	assert.Equal(t, MimeTypeH264, streamInfo.MimeType)
+	assert.Equal(t,
+		uint8(findRTXPayloadType(parameters.Encodings[0].PayloadType, parameters.Codecs)),
+		streamInfo.PayloadTypeRetransmission,
+	)
+	assert.NotZero(t, streamInfo.PayloadTypeRetransmission)
+
+	closePairNow(t, sender, receiver)
+}
+
 func Test_RTPSender_GetParameters(t *testing.T) {
 	lim := test.TimeOut(time.Second * 10)
 	defer lim.Stop()
@@ -553,7 +641,804 @@ func Test_RTPSender_SetReadDeadline_Crash(t *testing.T) {
 	rtpSender, err := stackA.api.NewRTPSender(track, stackA.dtls)
 	assert.NoError(t, err)
 
-	assert.Error(t, rtpSender.SetReadDeadline(time.Time{}), errRTPSenderSendNotCalled)
+	// The deadline is kept until Send is called, Read waiting for Send honors it.
+	assert.NoError(t, rtpSender.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+	_, _, err = rtpSender.ReadRTCP()
+	var netErr net.Error
+	assert.True(t, errors.As(err, &netErr) && netErr.Timeout())
+
+	assert.NoError(t, rtpSender.SetReadDeadline(time.Time{}))
+	assert.NoError(t, rtpSender.Stop())
+	_, _, err = rtpSender.ReadRTCP()
+	assert.ErrorIs(t, err, io.ErrClosedPipe)
+
 	assert.NoError(t, stackA.close())
 	assert.NoError(t, stackB.close())
 }
+
+func Test_RTPSender_SilenceGeneration(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	sender, receiver, err := newPair()
+	assert.NoError(t, err)
+
+	trackA, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	assert.NoError(t, err)
+
+	trackB, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	assert.NoError(t, err)
+
+	rtpSender, err := sender.AddTrack(trackA)
+	assert.NoError(t, err)
+	rtpSender.SetSilenceGeneration(true)
+
+	packets := make(chan *rtp.Packet, 1000)
+	receiver.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			pkt, _, err := track.ReadRTP()
+			if err != nil {
+				close(packets)
+
+				return
+			}
+			packets <- pkt
+		}
+	})
+
+	assert.NoError(t, signalPair(sender, receiver))
+
+	// writeUntilSeen writes samples of track until one of them is received, and
+	// returns the packets received before it.
+	writeUntilSeen := func(track *TrackLocalStaticSample, data byte) (received []*rtp.Packet) {
+		ticker := time.NewTicker(time.Millisecond * 20)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case pkt := <-packets:
+				received = append(received, pkt)
+				if bytes.Equal(pkt.Payload, []byte{data}) {
+					return received
+				}
+			case <-ticker.C:
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{data}, Duration: time.Millisecond * 20}))
+			}
+		}
+	}
+
+	writeUntilSeen(trackA, 0xAA)
+
+	assert.NoError(t, rtpSender.ReplaceTrack(nil))
+
+	// Wait for keep-alive media, the stream continues without gaps
+	var silence []*rtp.Packet
+	for len(silence) < 10 {
+		pkt := <-packets
+		if !bytes.Equal(pkt.Payload, opusSilenceFrame) {
+			continue
+		}
+		if len(silence) != 0 {
+			previous := silence[len(silence)-1]
+			assert.Equal(t, previous.SequenceNumber+1, pkt.SequenceNumber)
+			assert.Equal(t, previous.Timestamp+960, pkt.Timestamp)
+			assert.Equal(t, previous.PayloadType, pkt.PayloadType)
+			assert.Equal(t, previous.SSRC, pkt.SSRC)
+		}
+		silence = append(silence, pkt)
+	}
+
+	// The new track continues after the last generated packet
+	assert.NoError(t, rtpSender.ReplaceTrack(trackB))
+	received := writeUntilSeen(trackB, 0xBB)
+	last := silence[len(silence)-1]
+	if len(received) > 1 {
+		last = received[len(received)-2]
+	}
+	first := received[len(received)-1]
+	assert.Equal(t, last.SequenceNumber+1, first.SequenceNumber)
+	assert.Greater(t, first.Timestamp-last.Timestamp, uint32(0))
+	assert.Less(t, first.Timestamp-last.Timestamp, uint32(48000))
+	assert.Equal(t, last.SSRC, first.SSRC)
+
+	closePairNow(t, sender, receiver)
+}
+
+func Test_RTPSender_FlushRetransmissionHistory(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// Registered first, so it sees the packets the NACK responder retransmits
+	retransmitted := make(chan uint16, 100)
+	var unbinds atomic.Int32
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	ir := &interceptor.Registry{}
+	ir.Add(&mock_interceptor.Factory{
+		NewInterceptorFn: func(_ string) (interceptor.Interceptor, error) {
+			return &mock_interceptor.Interceptor{
+				BindLocalStreamFn: func(info *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+					return interceptor.RTPWriterFunc(
+						func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+							if header.SSRC == info.SSRCRetransmission && len(payload) >= 2 {
+								retransmitted <- binary.BigEndian.Uint16(payload)
+							}
+
+							return writer.Write(header, payload, attributes)
+						},
+					)
+				},
+				UnbindLocalStreamFn: func(*interceptor.StreamInfo) {
+					unbinds.Add(1)
+				},
+			}, nil
+		},
+	})
+	assert.NoError(t, RegisterDefaultInterceptors(mediaEngine, ir))
+
+	sender, err := NewAPI(WithMediaEngine(mediaEngine), WithInterceptorRegistry(ir)).NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	receiver, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	rtpSender, err := sender.AddTrack(track)
+	assert.NoError(t, err)
+
+	go func() {
+		for {
+			if _, _, readErr := rtpSender.ReadRTCP(); readErr != nil {
+				return
+			}
+		}
+	}()
+
+	received := make(chan struct{}, 100)
+	receiver.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		for {
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+			select {
+			case received <- struct{}{}:
+			default:
+			}
+		}
+	})
+
+	assert.NoError(t, signalPair(sender, receiver))
+
+	sequenceNumber := uint16(0)
+	writeUntilReceived := func() {
+		ticker := time.NewTicker(time.Millisecond * 20)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-received:
+				return
+			case <-ticker.C:
+				sequenceNumber++
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+					Payload: []byte{0x10, 0x00},
+				}))
+			}
+		}
+	}
+	ssrc := uint32(rtpSender.GetParameters().Encodings[0].SSRC)
+	nack := func(sequenceNumber uint16) {
+		assert.NoError(t, receiver.WriteRTCP([]rtcp.Packet{&rtcp.TransportLayerNack{
+			MediaSSRC: ssrc,
+			Nacks:     rtcp.NackPairsFromSequenceNumbers([]uint16{sequenceNumber}),
+		}}))
+	}
+	flushedNACKCount := func() uint64 {
+		for _, s := range sender.GetStats() {
+			if stats, ok := s.(OutboundRTPStreamStats); ok && uint32(stats.SSRC) == ssrc {
+				return stats.FlushedNACKCount
+			}
+		}
+
+		return 0
+	}
+
+	// Before the flush NACKed packets are retransmitted
+	writeUntilReceived()
+	nack(sequenceNumber)
+	assert.Equal(t, sequenceNumber, <-retransmitted)
+
+	rtpSender.FlushRetransmissionHistory()
+	flushedSequenceNumber := sequenceNumber
+
+	// Packets written after the flush are retransmitted, the ones before aren't
+	writeUntilReceived()
+	nack(flushedSequenceNumber)
+	for flushedNACKCount() == 0 {
+		time.Sleep(time.Millisecond * 10)
+	}
+	assert.Equal(t, uint64(1), flushedNACKCount())
+
+	nack(sequenceNumber)
+	assert.Equal(t, sequenceNumber, <-retransmitted)
+	assert.Empty(t, retransmitted)
+	assert.Equal(t, uint64(1), flushedNACKCount())
+
+	// The stream stays bound, so the counters of the Sender Reports aren't reset
+	assert.Zero(t, unbinds.Load())
+
+	closePairNow(t, sender, receiver)
+}
+
+func Test_RTPSender_Stop_Goodbye(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	assert.NoError(t, err)
+
+	onTrack := make(chan *TrackRemote, 1)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		onTrack <- trackRemote
+	})
+
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+		close(sent)
+	}()
+	trackRemote := <-onTrack
+	close(done)
+	<-sent
+
+	expectedSources := []uint32{}
+	for _, encoding := range sender.GetParameters().Encodings {
+		expectedSources = append(expectedSources, uint32(encoding.SSRC))
+		if encoding.RTX.SSRC != 0 {
+			expectedSources = append(expectedSources, uint32(encoding.RTX.SSRC))
+		}
+	}
+
+	assert.NoError(t, pcOffer.RemoveTrack(sender))
+
+	// The BYE arrives before any renegotiation
+	receiver := pcAnswer.GetTransceivers()[0].Receiver()
+	var goodbye *rtcp.Goodbye
+	for goodbye == nil {
+		pkts, _, readErr := receiver.ReadRTCP()
+		if !assert.NoError(t, readErr) {
+			break
+		}
+		for _, pkt := range pkts {
+			if bye, ok := pkt.(*rtcp.Goodbye); ok {
+				goodbye = bye
+			}
+		}
+	}
+	if assert.NotNil(t, goodbye) {
+		assert.ElementsMatch(t, expectedSources, goodbye.Sources)
+	}
+
+	for {
+		if _, _, err = trackRemote.ReadRTP(); err != nil {
+			break
+		}
+	}
+	assert.ErrorIs(t, err, io.EOF)
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func Test_RTPSender_StopWithFlush(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The pacer queues the burst for about a second
+	interceptorRegistry := &interceptor.Registry{}
+	interceptorRegistry.Add(pacing.NewInterceptor(pacing.InitialRate(100_000)))
+
+	pcOffer, err := NewAPI(WithInterceptorRegistry(interceptorRegistry)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	const burstSamples = 40
+	var burstReceived atomic.Int32
+	onTrack := make(chan struct{})
+	ended := make(chan error, 1)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, receiver *RTPReceiver) {
+		close(onTrack)
+
+		// The RTCP BYE ends the track once the sender stopped
+		go func() {
+			for {
+				if _, _, readErr := receiver.ReadRTCP(); readErr != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			pkt, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				ended <- readErr
+
+				return
+			}
+			if pkt.Payload[0] == 1 {
+				burstReceived.Add(1)
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for waiting := true; waiting; {
+		select {
+		case <-onTrack:
+			waiting = false
+		case <-ticker.C:
+			assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: 20 * time.Millisecond}))
+		}
+	}
+
+	for i := range burstSamples {
+		data := make([]byte, 300)
+		data[0], data[1] = 1, byte(i)
+		assert.NoError(t, track.WriteSample(media.Sample{Data: data, Duration: 20 * time.Millisecond}))
+	}
+
+	stopped := make(chan error, 1)
+	go func() {
+		stopped <- sender.StopWithFlush(context.Background())
+	}()
+
+	// Samples written while the sender drains are rejected
+	for {
+		err = track.WriteSample(media.Sample{Data: []byte{0x02}, Duration: 20 * time.Millisecond})
+		if errors.Is(err, ErrTrackLocalDraining) {
+			break
+		}
+		assert.NoError(t, err)
+		time.Sleep(time.Millisecond)
+	}
+
+	assert.NoError(t, <-stopped)
+	assert.ErrorIs(t, <-ended, io.EOF)
+	assert.Equal(t, int32(burstSamples), burstReceived.Load())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func Test_RTPSender_StopWithFlush_Pacing(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	// All but the first packet are held back by the pacer
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithPacing(0, time.Hour),
+	)
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	var received atomic.Int32
+	ended := make(chan error, 1)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, receiver *RTPReceiver) {
+		go func() {
+			for {
+				if _, _, readErr := receiver.ReadRTCP(); readErr != nil {
+					return
+				}
+			}
+		}()
+
+		for {
+			if _, _, readErr := trackRemote.ReadRTP(); readErr != nil {
+				ended <- readErr
+
+				return
+			}
+			received.Add(1)
+		}
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 6000), Duration: 20 * time.Millisecond}))
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 6000), Duration: 20 * time.Millisecond}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, sender.StopWithFlush(ctx))
+	assert.ErrorIs(t, <-ended, io.EOF)
+	assert.Equal(t, int32(12), received.Load())
+
+	assert.NoError(t, track.Close())
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func Test_RTPSender_StopWithFlush_Deadline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The pacer doesn't send anything within the deadline
+	interceptorRegistry := &interceptor.Registry{}
+	interceptorRegistry.Add(pacing.NewInterceptor(pacing.InitialRate(1_000)))
+
+	pcOffer, err := NewAPI(WithInterceptorRegistry(interceptorRegistry)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeOpus}, "audio", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	for range 10 {
+		assert.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 300), Duration: 20 * time.Millisecond}))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, sender.StopWithFlush(ctx), context.DeadlineExceeded)
+	assert.True(t, sender.hasStopped())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func Test_RTPSender_VideoLayersAllocation(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newPeerConnection := func() *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		require.NoError(t, ConfigureVideoLayersAllocationHeaderExtension(mediaEngine))
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+	pcOffer, pcAnswer := newPeerConnection(), newPeerConnection()
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	allocation := VideoLayersAllocation{
+		ResolutionAndFrameRateValid: true,
+		ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+			{SpatialID: 0, TargetBitratesKbps: []uint32{100, 150}, Width: 320, Height: 180, FrameRate: 15},
+			{SpatialID: 1, TargetBitratesKbps: []uint32{400, 600}, Width: 640, Height: 360, FrameRate: 30},
+			{SpatialID: 2, TargetBitratesKbps: []uint32{1200, 1800}, Width: 1280, Height: 720, FrameRate: 30},
+		},
+	}
+	require.NoError(t, sender.SetVideoLayersAllocation(allocation))
+	assert.ErrorIs(t, sender.SetVideoLayersAllocation(VideoLayersAllocation{
+		ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{{}},
+	}), errVideoLayersAllocationInvalid)
+
+	done := make(chan struct{})
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		var lastTimestamp uint32
+		framesWithAllocation, packetsWithoutAllocation := 0, 0
+		for {
+			pkt, attributes, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			received, ok := attributes.Get(AttributeVideoLayersAllocation).(VideoLayersAllocation)
+			switch {
+			case ok:
+				assert.NotEqual(t, lastTimestamp, pkt.Timestamp, "allocation must only be sent once per frame")
+				assert.Equal(t, allocation, received)
+				framesWithAllocation++
+			case pkt.Timestamp == lastTimestamp:
+				packetsWithoutAllocation++
+			}
+			lastTimestamp = pkt.Timestamp
+
+			if framesWithAllocation >= 3 && packetsWithoutAllocation >= 3 {
+				close(done)
+
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// Frames larger than the MTU are split into multiple packets
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-ticker.C:
+			assert.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 3000), Duration: 20 * time.Millisecond}))
+		}
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func Test_RTPSender_PlayoutDelay(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	newPeerConnection := func(playoutDelay bool) *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		if playoutDelay {
+			require.NoError(t, ConfigurePlayoutDelayHeaderExtension(mediaEngine))
+		}
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+
+	for _, negotiated := range []bool{true, false} {
+		pcOffer, pcAnswer := newPeerConnection(true), newPeerConnection(negotiated)
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, err)
+		sender, err := pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		assert.ErrorIs(t, sender.SetPlayoutDelay(-time.Millisecond, 0), errPlayoutDelayInvalid)
+		assert.ErrorIs(t, sender.SetPlayoutDelay(time.Second, time.Millisecond), errPlayoutDelayInvalid)
+		assert.ErrorIs(t, sender.SetPlayoutDelay(0, 41*time.Second), errPlayoutDelayInvalid)
+		require.NoError(t, sender.SetPlayoutDelay(0, 0))
+
+		// The ID the offerer picked, the extension must be absent if the answer didn't include it
+		offer, err := pcOffer.CreateOffer(nil)
+		require.NoError(t, err)
+		parsed, err := offer.Unmarshal()
+		require.NoError(t, err)
+		var offeredID uint8
+		for _, media := range parsed.MediaDescriptions {
+			for _, attr := range media.Attributes {
+				var extMap sdp.ExtMap
+				if attr.Key == sdp.AttrKeyExtMap && extMap.Unmarshal(attr.String()) == nil &&
+					extMap.URI.String() == PlayoutDelayURI {
+					offeredID = uint8(extMap.Value) //nolint:gosec // header extension IDs are at most 255
+				}
+			}
+		}
+		require.NotZero(t, offeredID)
+
+		received := make(chan rtp.PlayoutDelayExtension, 1)
+		pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+			for {
+				pkt, _, readErr := trackRemote.ReadRTP()
+				if readErr != nil {
+					return
+				}
+
+				payload := pkt.GetExtension(offeredID)
+				if negotiated != (payload != nil) {
+					assert.Fail(t, "playout delay must be sent exactly when negotiated")
+
+					continue
+				}
+				var delay rtp.PlayoutDelayExtension
+				if payload != nil {
+					assert.NoError(t, delay.Unmarshal(payload))
+				}
+
+				select {
+				case received <- delay:
+				default:
+				}
+			}
+		})
+
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		waitForDelay := func(expected rtp.PlayoutDelayExtension) {
+			ticker := time.NewTicker(20 * time.Millisecond)
+			defer ticker.Stop()
+			for {
+				select {
+				case delay := <-received:
+					if delay == expected {
+						return
+					}
+				case <-ticker.C:
+					assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: 20 * time.Millisecond}))
+				}
+			}
+		}
+
+		if negotiated {
+			waitForDelay(rtp.PlayoutDelayExtension{MinDelay: 0, MaxDelay: 0})
+
+			// The delay can be relaxed while sending
+			require.NoError(t, sender.SetPlayoutDelay(105*time.Millisecond, 500*time.Millisecond))
+			waitForDelay(rtp.PlayoutDelayExtension{MinDelay: 10, MaxDelay: 50})
+		} else {
+			for _, extension := range sender.GetParameters().HeaderExtensions {
+				assert.NotEqual(t, PlayoutDelayURI, extension.URI)
+			}
+			waitForDelay(rtp.PlayoutDelayExtension{})
+		}
+
+		closePairNow(t, pcOffer, pcAnswer)
+	}
+}
+
+func Test_RTPSender_OnPacketSent(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// The interceptors see when the packets they wrote were sent
+	var timedPackets, untimedPackets atomic.Int32
+	ir := &interceptor.Registry{}
+	ir.Add(&mock_interceptor.Factory{
+		NewInterceptorFn: func(string) (interceptor.Interceptor, error) {
+			return &mock_interceptor.Interceptor{
+				BindLocalStreamFn: func(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+					return interceptor.RTPWriterFunc(
+						func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+							n, err := writer.Write(header, payload, attributes)
+							packetizedAt, _ := attributes[AttributePacketizedAt].(time.Time)
+							sentAt, ok := attributes[AttributeSentAt].(time.Time)
+							switch {
+							case n == 0:
+							case ok && !sentAt.Before(packetizedAt):
+								timedPackets.Add(1)
+							default:
+								untimedPackets.Add(1)
+							}
+
+							return n, err
+						},
+					)
+				},
+			}, nil
+		},
+	})
+
+	require.NoError(t, ConfigureStatsInterceptor(ir))
+
+	pcOffer, pcAnswer, err := NewAPI(WithInterceptorRegistry(ir)).newPair(Configuration{})
+	require.NoError(t, err)
+
+	const window = 100 * time.Millisecond
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithPacing(0, window),
+	)
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	onTrack := make(chan struct{})
+	pcAnswer.OnTrack(func(*TrackRemote, *RTPReceiver) {
+		close(onTrack)
+	})
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	func() {
+		ticker := time.NewTicker(20 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-onTrack:
+				return
+			case <-ticker.C:
+				assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x00}, Duration: 20 * time.Millisecond}))
+			}
+		}
+	}()
+	time.Sleep(2 * window)
+
+	sendDelay := func() time.Duration {
+		for _, s := range pcOffer.GetStats() {
+			if stats, ok := s.(OutboundRTPStreamStats); ok {
+				return time.Duration(stats.TotalPacketSendDelay * float64(time.Second))
+			}
+		}
+
+		return 0
+	}
+	initialDelay := sendDelay()
+
+	type sentPacket struct {
+		sequenceNumber uint16
+		size           int
+		sentAt         time.Time
+	}
+	sent := make(chan sentPacket, 10)
+	sender.OnPacketSent(2, func(sequenceNumber uint16, size int, sentAt time.Time) {
+		sent <- sentPacket{sequenceNumber, size, sentAt}
+	})
+
+	// A keyframe of 10 packets, paced one by one over the window
+	const packets = 10
+	writtenAt := time.Now()
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 11000), Duration: window}))
+
+	var reported []sentPacket
+	for len(reported) < packets/2 {
+		reported = append(reported, <-sent)
+	}
+	for i := 1; i < len(reported); i++ {
+		assert.Equal(t, reported[0].sequenceNumber+uint16(2*i), reported[i].sequenceNumber) //nolint:gosec
+		assert.Greater(t, reported[i].size, 1000)
+		assert.Greater(t, reported[i].sentAt.Sub(reported[i-1].sentAt), window/packets)
+	}
+
+	// Every packet waits for its slot in the window, the last one isn't reported
+	expectedDelay := window * (packets - 1) / 2
+	require.Eventually(t, func() bool {
+		return sendDelay()-initialDelay >= expectedDelay
+	}, time.Second, 10*time.Millisecond)
+	// No packet waited longer than since the sample was written
+	delay := sendDelay() - initialDelay
+	assert.LessOrEqual(t, delay, packets*time.Since(writtenAt))
+
+	assert.Positive(t, timedPackets.Load())
+	assert.Zero(t, untimedPackets.Load())
+
+	sender.OnPacketSent(1, nil)
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/internal/util"
+)
+
+// paddingPacer spaces the padding packets so they don't exceed rtpPaddingMaxBitrate.
+type paddingPacer struct {
+	mu   sync.Mutex
+	next time.Time
+}
+
+// wait waits until a packet of size bytes can be sent, p.mu must be held.
+func (p *paddingPacer) wait(size int) {
+	now := time.Now()
+	if p.next.After(now) {
+		time.Sleep(p.next.Sub(now))
+		now = p.next
+	}
+	p.next = now.Add(time.Duration(size) * 8 * time.Second / rtpPaddingMaxBitrate)
+}
+
+// rtxPadded is set in trackEncoding.rtxSequenceNumber once padding was sent on the RTX stream.
+const rtxPadded = 1 << 17
+
+// rtxWritten records sequenceNumber of a packet of the RTX stream. Once padding was sent on
+// it, the sequence numbers of the RTX interceptor collide with the ones of the padding, and
+// the one of the packet is replaced by the next sequence number of the stream.
+func (t *trackEncoding) rtxWritten(sequenceNumber uint16) (next uint16, padded bool) {
+	for {
+		last := t.rtxSequenceNumber.Load()
+		if last&rtxPadded != 0 {
+			return t.nextRTXSequenceNumber(), true
+		}
+		if t.rtxSequenceNumber.CompareAndSwap(last, sequenceNumberValid|uint32(sequenceNumber)) {
+			return sequenceNumber, false
+		}
+	}
+}
+
+// padRTX flags the RTX stream as padded, its sequence numbers are rewritten from then on.
+func (t *trackEncoding) padRTX() {
+	t.rtxSequenceNumber.Or(rtxPadded)
+}
+
+// nextRTXSequenceNumber returns the sequence number of the next packet of the RTX stream.
+func (t *trackEncoding) nextRTXSequenceNumber() uint16 {
+	for {
+		last := t.rtxSequenceNumber.Load()
+		next := uint16(last) + 1 //nolint:gosec // G115
+		if last&sequenceNumberValid == 0 {
+			next = uint16(util.RandUint32()) //nolint:gosec // G115
+		}
+		if t.rtxSequenceNumber.CompareAndSwap(last, rtxPadded|sequenceNumberValid|uint32(next)) {
+			return next
+		}
+	}
+}
+
+// SendPadding sends bytes of padding on the first encoding, to probe the bandwidth available
+// for ramping the bitrate up. The padding is split into padding-only packets of at most 255
+// bytes, sent at up to 10 Mbit/s, SendPadding returns once they are all sent.
+//
+// The packets are sent on the RTX stream if RTX is negotiated, which has its own sequence
+// numbers. Otherwise they are sent on the media stream after the last written packet, and
+// the sequence numbers of the packets written next are moved after them. The packets pass
+// through the interceptors, so the transport-wide congestion control feedback covers them.
+func (r *RTPSender) SendPadding(bytes int) error {
+	r.mu.RLock()
+	switch {
+	case r.hasStopped():
+		r.mu.RUnlock()
+
+		return errRTPSenderStopped
+	case !r.hasSent():
+		r.mu.RUnlock()
+
+		return errRTPSenderNotSending
+	}
+	encoding := r.trackEncodings[0]
+	header := rtp.Header{
+		Version:     2,
+		Padding:     true,
+		PayloadType: uint8(encoding.streamInfo.PayloadTypeRetransmission),
+		SSRC:        uint32(encoding.ssrcRTX),
+	}
+	if header.SSRC == 0 || header.PayloadType == 0 {
+		header.PayloadType = uint8(encoding.streamInfo.PayloadType)
+		header.SSRC = uint32(encoding.ssrc)
+	}
+	writeStream := encoding.writeStream
+	r.mu.RUnlock()
+
+	// Nothing is sent while the direction doesn't allow it
+	if r.sendPaused.Load() {
+		return nil
+	}
+
+	r.padding.mu.Lock()
+	defer r.padding.mu.Unlock()
+
+	if header.SSRC == uint32(encoding.ssrcRTX) {
+		encoding.padRTX()
+	}
+
+	for ; bytes > 0; bytes -= rtpPaddingMaxSize {
+		packet := header
+		packet.PaddingSize = byte(min(bytes, rtpPaddingMaxSize))
+		r.padding.wait(packet.MarshalSize() + int(packet.PaddingSize))
+
+		var err error
+		if packet.SSRC == uint32(encoding.ssrc) {
+			var ok bool
+			if packet.SequenceNumber, packet.Timestamp, ok = writeStream.continuity.pad(); !ok {
+				return errRTPSenderPaddingBeforeMedia
+			}
+			_, err = writeStream.write(&packet, nil, interceptor.Attributes{})
+		} else {
+			// The sequence number is set with the ones of the retransmissions
+			packet.Timestamp = writeStream.continuity.timestamp()
+			_, err = writeStream.writeRepair(&packet, nil, interceptor.Attributes{})
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
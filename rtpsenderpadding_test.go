@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRTPSender_SendPadding(t *testing.T) {
+	defer test.TimeOut(time.Second * 30).Stop()
+	defer test.CheckRoutines(t)()
+
+	t.Run("RTX", func(t *testing.T) {
+		pair, track, sender, received := newSendPaddingPair(t, true)
+
+		writeSendPaddingMedia(t, track, 1, 5)
+		require.NoError(t, sender.SendPadding(1000))
+		writeSendPaddingMedia(t, track, 6, 10)
+
+		// The padding is sent on the RTX stream, the media stream is untouched
+		for sequenceNumber := uint16(1); sequenceNumber <= 10; sequenceNumber++ {
+			pkt := <-received
+			assert.Equal(t, sequenceNumber, pkt.SequenceNumber)
+			assert.False(t, pkt.Padding)
+		}
+
+		// 255, 255, 255 and 235 bytes of padding
+		assert.Eventually(t, func() bool {
+			for _, s := range pair.Answerer.GetStats() {
+				if inbound, ok := s.(InboundRTPStreamStats); ok {
+					return inbound.PaddingPacketsReceived == 4 && inbound.PaddingBytesReceived == 1000
+				}
+			}
+
+			return false
+		}, 5*time.Second, 10*time.Millisecond)
+
+		require.NoError(t, pair.Close())
+	})
+
+	t.Run("Media stream", func(t *testing.T) {
+		pair, track, sender, received := newSendPaddingPair(t, false)
+
+		require.ErrorIs(t, sender.SendPadding(100), errRTPSenderPaddingBeforeMedia)
+		writeSendPaddingMedia(t, track, 1, 5)
+		require.NoError(t, sender.SendPadding(600))
+		writeSendPaddingMedia(t, track, 6, 10)
+
+		var transportCCID uint8
+		for _, extension := range sender.GetParameters().HeaderExtensions {
+			if extension.URI == sdp.TransportCCURI {
+				transportCCID = uint8(extension.ID) //nolint:gosec // header extension IDs are at most 255
+			}
+		}
+		require.NotZero(t, transportCCID)
+
+		// The media packets written after the padding are moved after it
+		for sequenceNumber := uint16(1); sequenceNumber <= 13; sequenceNumber++ {
+			pkt := <-received
+			assert.Equal(t, sequenceNumber, pkt.SequenceNumber)
+			isPadding := sequenceNumber >= 6 && sequenceNumber <= 8
+			assert.Equal(t, isPadding, pkt.Padding)
+			if isPadding {
+				assert.Empty(t, pkt.Payload)
+				assert.NotNil(t, pkt.GetExtension(transportCCID), "padding isn't covered by TWCC")
+			}
+		}
+
+		require.NoError(t, pair.Close())
+	})
+}
+
+func newSendPaddingPair(t *testing.T, rtx bool) (
+	*LoopbackPeerConnectionPair, *TrackLocalStaticRTP, *RTPSender, chan *rtp.Packet,
+) {
+	t.Helper()
+
+	mediaEngine := &MediaEngine{}
+	if rtx {
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	} else {
+		require.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000},
+			PayloadType:        96,
+		}, RTPCodecTypeVideo))
+	}
+	registry := &interceptor.Registry{}
+	require.NoError(t, ConfigureTWCCHeaderExtensionSender(mediaEngine, registry))
+	require.NoError(t, RegisterDefaultInterceptors(mediaEngine, registry))
+
+	pair, err := NewAPI(WithMediaEngine(mediaEngine), WithInterceptorRegistry(registry)).
+		NewLoopbackPeerConnectionPair(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pair.Offerer.AddTrack(track)
+	require.NoError(t, err)
+
+	received := make(chan *rtp.Packet, 100)
+	pair.Answerer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		for {
+			pkt, _, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			received <- pkt
+		}
+	})
+
+	assert.ErrorIs(t, sender.SendPadding(100), errRTPSenderNotSending)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pair.Offerer, pair.Answerer)
+	require.NoError(t, pair.Negotiate())
+	connected.Wait()
+
+	return pair, track, sender, received
+}
+
+func writeSendPaddingMedia(t *testing.T, track *TrackLocalStaticRTP, from, to uint16) {
+	t.Helper()
+
+	for sequenceNumber := from; sequenceNumber <= to; sequenceNumber++ {
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, Timestamp: 3000},
+			Payload: []byte{0x10, 0x00},
+		}))
+	}
+}
@@ -7,13 +7,16 @@ package webrtc
 
 import (
 	"fmt"
+	"slices"
 	"strings"
 	"sync"
 	"sync/atomic"
 
+	"github.com/pion/randutil"
 	"github.com/pion/rtp"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/webrtc/v4/internal/fmtp"
+	"github.com/pion/webrtc/v4/internal/util"
 )
 
 // RTPTransceiver represents a combination of an RTPSender and an RTPReceiver that share a common mid.
@@ -25,10 +28,21 @@ type RTPTransceiver struct {
 	currentDirection       atomic.Value // RTPTransceiverDirection
 	currentRemoteDirection atomic.Value // RTPTransceiverDirection
 
-	codecs []RTPCodecParameters // User provided codecs via SetCodecPreferences
+	// stopped is set once the remote rejected the media section of the transceiver
+	stopped atomic.Bool
+
+	codecs         []RTPCodecParameters // User provided codecs via SetCodecPreferences
+	resolvedCodecs atomic.Pointer[resolvedCodecs]
+
+	// negotiatedParameters are the parameters of the media section in the last applied answer
+	negotiatedParameters atomic.Pointer[RTPParameters]
 
 	kind RTPCodecType
 
+	// Announced in recvonly media sections, see CompatibilityProfile.RecvonlySSRC
+	recvonlySSRC  SSRC
+	recvonlyCNAME string
+
 	api *API
 	mu  sync.RWMutex
 }
@@ -51,6 +65,8 @@ func newRTPTransceiver(
 
 // SetCodecPreferences sets preferred list of supported codecs
 // if codecs is empty or nil we reset to default from MediaEngine.
+// The codecs are sent with the payload types of the MediaEngine, once negotiated
+// these are the ones of the remote description.
 func (t *RTPTransceiver) SetCodecPreferences(codecs []RTPCodecParameters) error {
 	t.mu.Lock()
 	defer t.mu.Unlock()
@@ -63,33 +79,92 @@ func (t *RTPTransceiver) SetCodecPreferences(codecs []RTPCodecParameters) error
 		}
 	}
 
-	t.codecs = filterUnattachedRTX(codecs)
+	t.codecs = filterUnattachedCodecs(codecs)
+	t.resolvedCodecs.Store(nil)
 
 	return nil
 }
 
 // getCodecs returns list of supported codecs.
+// resolvedCodecs caches the result of getCodecs for a generation of the MediaEngine.
+type resolvedCodecs struct {
+	mediaEngineGeneration uint64
+	codecs                []RTPCodecParameters
+}
+
 func (t *RTPTransceiver) getCodecs() []RTPCodecParameters {
 	t.mu.RLock()
 	defer t.mu.RUnlock()
 
+	generation := t.api.mediaEngine.getGeneration()
+	if resolved := t.resolvedCodecs.Load(); resolved != nil && resolved.mediaEngineGeneration == generation {
+		return slices.Clone(resolved.codecs)
+	}
+
+	codecs := t.resolveCodecs()
+	if t.api.settingEngine.isRTXDisabled(t.kind) {
+		codecs = removeCodecs(codecs, isRTXCodec)
+	}
+	t.resolvedCodecs.Store(&resolvedCodecs{mediaEngineGeneration: generation, codecs: codecs})
+
+	return slices.Clone(codecs)
+}
+
+// resolveCodecs intersects the codec preferences with the MediaEngine, t.mu must be held.
+func (t *RTPTransceiver) resolveCodecs() []RTPCodecParameters {
 	mediaEngineCodecs := t.api.mediaEngine.getCodecsByKind(t.kind)
 	if len(t.codecs) == 0 {
-		return filterUnattachedRTX(mediaEngineCodecs)
+		// filterUnattachedCodecs modifies its input, don't touch the MediaEngine
+		return filterUnattachedCodecs(slices.Clone(mediaEngineCodecs))
 	}
 
-	filteredCodecs := []RTPCodecParameters{}
-	for _, codec := range t.codecs {
+	// The payload types of the MediaEngine are used, once negotiated they are the ones of the
+	// remote description. The RTX, RED and FEC codecs are matched by the codecs they depend on.
+	resolved := make([]*RTPCodecParameters, len(t.codecs))
+	payloadTypes := map[PayloadType]PayloadType{}
+	for i, codec := range t.codecs {
+		if associated, err := associatedPayloadTypes(codec); err != nil || associated != nil {
+			continue
+		}
+
 		if c, matchType := codecParametersFuzzySearch(codec, mediaEngineCodecs); matchType != codecMatchNone {
-			if codec.PayloadType == 0 {
-				codec.PayloadType = c.PayloadType
+			if codec.PayloadType != 0 {
+				payloadTypes[codec.PayloadType] = c.PayloadType
 			}
+			codec.PayloadType = c.PayloadType
+			codec.RTCPFeedback = rtcpFeedbackIntersection(codec.RTCPFeedback, c.RTCPFeedback)
+			codec.statsID = c.statsID
+			resolved[i] = &codec
+		}
+	}
+	for i, codec := range t.codecs {
+		associated, err := associatedPayloadTypes(codec)
+		if err != nil || associated == nil {
+			continue
+		}
+
+		for j := range associated {
+			if payloadType, ok := payloadTypes[associated[j]]; ok {
+				associated[j] = payloadType
+			}
+		}
+		if c, ok := findAssociatedCodec(codec, associated, mediaEngineCodecs); ok {
+			codec.PayloadType = c.PayloadType
+			codec.SDPFmtpLine = c.SDPFmtpLine
 			codec.RTCPFeedback = rtcpFeedbackIntersection(codec.RTCPFeedback, c.RTCPFeedback)
-			filteredCodecs = append(filteredCodecs, codec)
+			codec.statsID = c.statsID
+			resolved[i] = &codec
 		}
 	}
 
-	return filterUnattachedRTX(filteredCodecs)
+	filteredCodecs := []RTPCodecParameters{}
+	for _, codec := range resolved {
+		if codec != nil {
+			filteredCodecs = append(filteredCodecs, *codec)
+		}
+	}
+
+	return filterUnattachedCodecs(filteredCodecs)
 }
 
 // match codecs from remote description, used when remote is offerer and creating a transceiver
@@ -107,6 +182,7 @@ func (t *RTPTransceiver) setCodecPreferencesFromRemoteDescription(media *sdp.Med
 	// the transceivers codecs and use payload type registered to
 	// media engine.
 	payloadMapping := make(map[PayloadType]PayloadType) // for RTX re-mapping later
+	preference := t.api.mediaEngine.getCodecMatchPreference(t.kind)
 	filterByMatchType := func(matchFilter codecMatchType) []RTPCodecParameters {
 		filteredCodecs := []RTPCodecParameters{}
 		for remoteCodecIdx := len(remoteCodecs) - 1; remoteCodecIdx >= 0; remoteCodecIdx-- {
@@ -115,14 +191,18 @@ func (t *RTPTransceiver) setCodecPreferencesFromRemoteDescription(media *sdp.Med
 				continue
 			}
 
-			matchCodec, matchType := codecParametersFuzzySearch(
+			matchCodec, matchType := codecParametersFuzzySearchWithPreference(
 				remoteCodec,
 				leftCodecs,
+				preference,
 			)
 			if matchType == matchFilter {
 				payloadMapping[remoteCodec.PayloadType] = matchCodec.PayloadType
 
 				remoteCodec.PayloadType = matchCodec.PayloadType
+				if strings.EqualFold(remoteCodec.MimeType, MimeTypeOpus) {
+					remoteCodec.SDPFmtpLine = opusFmtpIntersection(matchCodec.SDPFmtpLine, remoteCodec.SDPFmtpLine)
+				}
 				filteredCodecs = append([]RTPCodecParameters{remoteCodec}, filteredCodecs...)
 
 				// removed matched codec for next round
@@ -182,6 +262,36 @@ func (t *RTPTransceiver) setCodecPreferencesFromRemoteDescription(media *sdp.Med
 	_ = t.SetCodecPreferences(filteredCodecs)
 }
 
+// NegotiatedCodecs returns the codecs of the media section of the RTPTransceiver in the last
+// applied answer, with the payload types of the answer. It returns nil before an answer was applied.
+func (t *RTPTransceiver) NegotiatedCodecs() []RTPCodecParameters {
+	parameters := t.getNegotiatedParameters()
+	if parameters == nil {
+		return nil
+	}
+
+	return parameters.Codecs
+}
+
+// getNegotiatedParameters returns a copy of the negotiated parameters, or nil before an answer
+// was applied.
+func (t *RTPTransceiver) getNegotiatedParameters() *RTPParameters {
+	parameters := t.negotiatedParameters.Load()
+	if parameters == nil {
+		return nil
+	}
+
+	return &RTPParameters{
+		HeaderExtensions: slices.Clone(parameters.HeaderExtensions),
+		Codecs:           slices.Clone(parameters.Codecs),
+		RTCP:             parameters.RTCP,
+	}
+}
+
+func (t *RTPTransceiver) setNegotiatedParameters(parameters RTPParameters) {
+	t.negotiatedParameters.Store(&parameters)
+}
+
 // Sender returns the RTPTransceiver's RTPSender if it has one.
 func (t *RTPTransceiver) Sender() *RTPSender {
 	if v, ok := t.sender.Load().(*RTPSender); ok {
@@ -252,6 +362,14 @@ func (t *RTPTransceiver) Direction() RTPTransceiverDirection {
 	return RTPTransceiverDirection(0)
 }
 
+// CurrentDirection returns the direction negotiated for the RTPTransceiver by the last
+// offer/answer exchange, unlike Direction which is the preferred one. It is
+// RTPTransceiverDirectionUnknown until the RTPTransceiver is negotiated, and
+// RTPTransceiverDirectionInactive once it is stopped.
+func (t *RTPTransceiver) CurrentDirection() RTPTransceiverDirection {
+	return t.getCurrentDirection()
+}
+
 // Stop irreversibly stops the RTPTransceiver.
 func (t *RTPTransceiver) Stop() error {
 	if sender := t.Sender(); sender != nil {
@@ -271,6 +389,30 @@ func (t *RTPTransceiver) Stop() error {
 	return nil
 }
 
+// stopRejected stops the transceiver because the remote rejected its media section,
+// it is then only kept as a placeholder in descriptions.
+func (t *RTPTransceiver) stopRejected() error {
+	if t.stopped.Swap(true) {
+		return nil
+	}
+
+	if err := t.endRemoteTrack(); err != nil {
+		return err
+	}
+
+	return t.Stop()
+}
+
+// endRemoteTrack stops the RTPReceiver because the remote ended its track, reading the track
+// returns ErrTrackEnded afterwards.
+func (t *RTPTransceiver) endRemoteTrack() error {
+	if receiver := t.Receiver(); receiver != nil {
+		return receiver.stop(ErrTrackEnded)
+	}
+
+	return nil
+}
+
 func (t *RTPTransceiver) setReceiver(r *RTPReceiver) {
 	if r != nil {
 		r.setRTPTransceiver(t)
@@ -311,7 +453,7 @@ func (t *RTPTransceiver) getCurrentRemoteDirection() RTPTransceiverDirection {
 	return RTPTransceiverDirectionUnknown
 }
 
-func (t *RTPTransceiver) setSendingTrack(track TrackLocal) error { //nolint:cyclop
+func (t *RTPTransceiver) setSendingTrack(track TrackLocal) error {
 	if err := t.Sender().ReplaceTrack(track); err != nil {
 		return err
 	}
@@ -319,20 +461,26 @@ func (t *RTPTransceiver) setSendingTrack(track TrackLocal) error { //nolint:cycl
 		t.setSender(nil)
 	}
 
+	return t.updateDirectionForSendingTrack(track != nil)
+}
+
+// updateDirectionForSendingTrack adjusts the direction after a sending track
+// was attached (hasTrack) or detached.
+func (t *RTPTransceiver) updateDirectionForSendingTrack(hasTrack bool) error { //nolint:cyclop
 	switch {
-	case track != nil && t.Direction() == RTPTransceiverDirectionRecvonly:
+	case hasTrack && t.Direction() == RTPTransceiverDirectionRecvonly:
 		t.setDirection(RTPTransceiverDirectionSendrecv)
-	case track != nil && t.Direction() == RTPTransceiverDirectionInactive:
+	case hasTrack && t.Direction() == RTPTransceiverDirectionInactive:
 		t.setDirection(RTPTransceiverDirectionSendonly)
-	case track == nil && t.Direction() == RTPTransceiverDirectionSendrecv:
+	case !hasTrack && t.Direction() == RTPTransceiverDirectionSendrecv:
 		t.setDirection(RTPTransceiverDirectionRecvonly)
-	case track != nil && t.Direction() == RTPTransceiverDirectionSendonly:
+	case hasTrack && t.Direction() == RTPTransceiverDirectionSendonly:
 		// Handle the case where a sendonly transceiver was added by a negotiation
 		// initiated by remote peer. For example a remote peer added a transceiver
 		// with direction recvonly.
-	case track != nil && t.Direction() == RTPTransceiverDirectionSendrecv:
+	case hasTrack && t.Direction() == RTPTransceiverDirectionSendrecv:
 		// Similar to above, but for sendrecv transceiver.
-	case track == nil && t.Direction() == RTPTransceiverDirectionSendonly:
+	case !hasTrack && t.Direction() == RTPTransceiverDirectionSendonly:
 		t.setDirection(RTPTransceiverDirectionInactive)
 	default:
 		return errRTPTransceiverSetSendingInvalidState
@@ -342,7 +490,7 @@ func (t *RTPTransceiver) setSendingTrack(track TrackLocal) error { //nolint:cycl
 }
 
 func (t *RTPTransceiver) isSendAllowed(kind RTPCodecType) bool {
-	if t.kind != kind || t.Sender() != nil {
+	if t.kind != kind || t.Sender() != nil || t.stopped.Load() {
 		return false
 	}
 
@@ -454,3 +602,84 @@ func handleUnknownRTPPacket(
 
 	return mid, rid, rsid, false, nil
 }
+
+// rtpPadding returns the number of padding bytes of an RTP packet, and whether it
+// carries no payload besides its padding. The padding is either described by the
+// header or still at the end of payload.
+func rtpPadding(header *rtp.Header, payload []byte) (paddingSize int, paddingOnly bool) {
+	switch {
+	case !header.Padding:
+		return 0, false
+	case header.PaddingSize != 0:
+		return int(header.PaddingSize), len(payload) == 0
+	case len(payload) == 0:
+		return 0, true
+	}
+
+	paddingSize = min(int(payload[len(payload)-1]), len(payload))
+
+	return paddingSize, paddingSize == len(payload)
+}
+
+// recvonlySenderSSRC returns the SSRC announced for the media section of the transceiver
+// if it is currently recvonly and receives a track with mediaSSRC.
+func (t *RTPTransceiver) recvonlySenderSSRC(mediaSSRC SSRC) (SSRC, bool) {
+	t.mu.RLock()
+	ssrc := t.recvonlySSRC
+	t.mu.RUnlock()
+	if ssrc == 0 || t.getCurrentDirection() != RTPTransceiverDirectionRecvonly {
+		return 0, false
+	}
+
+	receiver := t.Receiver()
+	if receiver == nil {
+		return 0, false
+	}
+	for _, track := range receiver.Tracks() {
+		if track.SSRC() == mediaSSRC {
+			return ssrc, true
+		}
+	}
+
+	return 0, false
+}
+
+// localCNAME returns the CNAME announced for the local ssrc, empty if the transceiver
+// doesn't announce it.
+func (t *RTPTransceiver) localCNAME(ssrc SSRC) string {
+	t.mu.RLock()
+	recvonlySSRC, recvonlyCNAME := t.recvonlySSRC, t.recvonlyCNAME
+	t.mu.RUnlock()
+	if ssrc == recvonlySSRC {
+		return recvonlyCNAME
+	}
+
+	sender := t.Sender()
+	if sender == nil || !slices.Contains(sender.ssrcs(), ssrc) {
+		return ""
+	}
+	if track := sender.Track(); track != nil {
+		return track.StreamID()
+	}
+
+	return ""
+}
+
+// getRecvonlySource returns the SSRC and CNAME announced for a recvonly media section.
+// They are generated once so they stay stable across renegotiations.
+func (t *RTPTransceiver) getRecvonlySource() (SSRC, string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.recvonlySSRC == 0 {
+		cname, err := randutil.GenerateCryptoRandomString(16, "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ")
+		if err != nil {
+			return 0, "", err
+		}
+
+		t.recvonlySSRC = SSRC(util.RandUint32())
+		t.recvonlyCNAME = cname
+	}
+
+	return t.recvonlySSRC, t.recvonlyCNAME, nil
+}
@@ -26,6 +26,12 @@ func (r *RTPTransceiver) Direction() RTPTransceiverDirection {
 	return NewRTPTransceiverDirection(r.underlying.Get("direction").String())
 }
 
+// CurrentDirection returns the direction negotiated for the RTPTransceiver, unlike
+// Direction which is the preferred one
+func (r *RTPTransceiver) CurrentDirection() RTPTransceiverDirection {
+	return NewRTPTransceiverDirection(r.underlying.Get("currentDirection").String())
+}
+
 // Sender returns the RTPTransceiver's RTPSender if it has one
 func (r *RTPTransceiver) Sender() *RTPSender {
 	underlying := r.underlying.Get("sender")
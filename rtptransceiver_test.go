@@ -143,8 +143,9 @@ func Test_RTPTransceiver_SetCodecPreferences_PayloadType(t *testing.T) {
 	answer, err := answerPC.CreateAnswer(nil)
 	assert.NoError(t, err)
 
-	// VP8 with proper PayloadType
-	assert.NotEqual(t, -1, strings.Index(answer.SDP, "a=rtpmap:54 VP8/90000"))
+	// VP8 with the PayloadType of the offer, not the one of the preferences
+	assert.NotEqual(t, -1, strings.Index(answer.SDP, "a=rtpmap:96 VP8/90000"))
+	assert.Equal(t, -1, strings.Index(answer.SDP, "a=rtpmap:54 VP8/90000"))
 
 	// testCodec1 and testCodec1RTX should be included as they are in the offer
 	assert.NotEqual(t, -1, strings.Index(answer.SDP, "a=rtpmap:52 offeredCodec/90000"))
@@ -264,8 +265,9 @@ func Test_RTPTransceiver_UnattachedRTX(t *testing.T) {
 	answer, err := answerPC.CreateAnswer(nil)
 	assert.NoError(t, err)
 
-	// VP8 with proper PayloadType
-	assert.NotEqual(t, -1, strings.Index(answer.SDP, "a=rtpmap:52 VP8/90000"))
+	// VP8 with the PayloadType of the offer, not the one of the preferences
+	assert.NotEqual(t, -1, strings.Index(answer.SDP, "a=rtpmap:96 VP8/90000"))
+	assert.Equal(t, -1, strings.Index(answer.SDP, "a=rtpmap:52 VP8/90000"))
 
 	// testCodec is ignored since offerer doesn't support
 	assert.Equal(t, -1, strings.Index(answer.SDP, "testCodec"))
@@ -273,3 +275,39 @@ func Test_RTPTransceiver_UnattachedRTX(t *testing.T) {
 
 	closePairNow(t, offerPC, answerPC)
 }
+
+func Test_RTPTransceiver_ResolvedCodecsInvalidation(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	api := NewAPI(WithMediaEngine(mediaEngine))
+	assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeVP8, 90000, 0, "", nil},
+		PayloadType:        96,
+	}, RTPCodecTypeVideo))
+
+	tr := RTPTransceiver{kind: RTPCodecTypeVideo, api: api}
+	codecs := tr.getCodecs()
+	assert.Len(t, codecs, 1)
+
+	// Modifying the returned codecs must not change the cached ones
+	codecs[0].PayloadType = 100
+	assert.Equal(t, PayloadType(96), tr.getCodecs()[0].PayloadType)
+
+	// Registering a codec in the MediaEngine invalidates the resolved codecs
+	assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeVP9, 90000, 0, "profile-id=0", nil},
+		PayloadType:        98,
+	}, RTPCodecTypeVideo))
+	assert.Len(t, tr.getCodecs(), 2)
+
+	// So do codec preferences
+	assert.NoError(t, tr.SetCodecPreferences([]RTPCodecParameters{{
+		RTPCodecCapability: RTPCodecCapability{MimeTypeVP9, 90000, 0, "profile-id=0", nil},
+		PayloadType:        98,
+	}}))
+	codecs = tr.getCodecs()
+	assert.Len(t, codecs, 1)
+	assert.Equal(t, MimeTypeVP9, codecs[0].MimeType)
+
+	assert.NoError(t, tr.SetCodecPreferences(nil))
+	assert.Len(t, tr.getCodecs(), 2)
+}
@@ -93,3 +93,29 @@ func haveRTPTransceiverDirectionIntersection(
 
 	return false
 }
+
+func (t RTPTransceiverDirection) hasSend() bool {
+	return t == RTPTransceiverDirectionSendrecv || t == RTPTransceiverDirectionSendonly
+}
+
+func (t RTPTransceiverDirection) hasRecv() bool {
+	return t == RTPTransceiverDirectionSendrecv || t == RTPTransceiverDirectionRecvonly
+}
+
+// answerDirection returns the direction to answer an offered direction with, see JSEP 5.3.1.
+// We only send if the remote receives, and only receive if the remote sends.
+func answerDirection(local, offered RTPTransceiverDirection) RTPTransceiverDirection {
+	send := local.hasSend() && offered.hasRecv()
+	recv := local.hasRecv() && offered.hasSend()
+
+	switch {
+	case send && recv:
+		return RTPTransceiverDirectionSendrecv
+	case send:
+		return RTPTransceiverDirectionSendonly
+	case recv:
+		return RTPTransceiverDirectionRecvonly
+	default:
+		return RTPTransceiverDirectionInactive
+	}
+}
@@ -50,3 +50,25 @@ func TestRTPTransceiverDirection_String(t *testing.T) {
 		)
 	}
 }
+
+func TestRTPTransceiverDirection_AnswerDirection(t *testing.T) {
+	testCases := []struct {
+		local, offered, expected RTPTransceiverDirection
+	}{
+		{RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionSendrecv},
+		{RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionSendonly, RTPTransceiverDirectionRecvonly},
+		{RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionRecvonly, RTPTransceiverDirectionSendonly},
+		{RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionInactive, RTPTransceiverDirectionInactive},
+		{RTPTransceiverDirectionSendonly, RTPTransceiverDirectionSendonly, RTPTransceiverDirectionInactive},
+		{RTPTransceiverDirectionRecvonly, RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionRecvonly},
+		{RTPTransceiverDirectionInactive, RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionInactive},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expected,
+			answerDirection(testCase.local, testCase.offered),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
@@ -6,7 +6,11 @@ package webrtc
 // RTPTransceiverInit dictionary is used when calling the WebRTC function addTransceiver()
 // to provide configuration options for the new transceiver.
 type RTPTransceiverInit struct {
-	Direction     RTPTransceiverDirection
+	Direction RTPTransceiverDirection
+	// SendEncodings provisions the encodings of the sender. Encodings with RIDs make a
+	// simulcast sender whose RIDs and SSRCs are offered right away, the tracks of the
+	// encodings are attached with RTPSender.ReplaceTrackForRID. A single encoding without
+	// RID sets the SSRCs of the sender.
 	SendEncodings []RTPEncodingParameters
 	// Streams       []*Track
 }
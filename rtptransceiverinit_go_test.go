@@ -75,4 +75,30 @@ func Test_RTPTransceiverInit_SSRC(t *testing.T) {
 		sendVideoUntilDone(t, ctx.Done(), []*TrackLocalStaticSample{track})
 		closePairNow(t, offerer, answerer)
 	})
+
+	t.Run("RTX SSRC", func(t *testing.T) {
+		pc, err := NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		videoTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "a", "b")
+		assert.NoError(t, err)
+
+		transceiver, err := pc.AddTransceiverFromTrack(videoTrack, RTPTransceiverInit{
+			Direction: RTPTransceiverDirectionSendonly,
+			SendEncodings: []RTPEncodingParameters{
+				{
+					RTPCodingParameters: RTPCodingParameters{
+						SSRC: 5000,
+						RTX:  RTPRtxParameters{SSRC: 5001},
+					},
+				},
+			},
+		})
+		assert.NoError(t, err)
+
+		encoding := transceiver.Sender().GetParameters().Encodings[0]
+		assert.Equal(t, SSRC(5000), encoding.SSRC)
+		assert.Equal(t, SSRC(5001), encoding.RTX.SSRC)
+		assert.NoError(t, pc.Close())
+	})
 }
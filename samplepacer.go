@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/logging"
+	"github.com/pion/rtp"
+)
+
+// pacingClock is the time source of a samplePacer, replaced in tests.
+type pacingClock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+type systemPacingClock struct{}
+
+func (systemPacingClock) Now() time.Time                         { return time.Now() }
+func (systemPacingClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// pacedPacket is a packet of a binding waiting to be sent at sendAt.
+type pacedPacket struct {
+	sendAt     time.Time
+	header     rtp.Header
+	payload    []byte
+	attributes interceptor.Attributes
+}
+
+// samplePacer spreads the packets of the samples written to a single binding of a
+// TrackLocalStaticSample, see WithPacing. Every binding has its own pacer, so a slow
+// PeerConnection doesn't delay the others.
+type samplePacer struct {
+	writeStream TrackLocalWriter
+	burstBytes  int
+	clock       pacingClock
+	log         logging.LeveledLogger
+
+	// writeMu keeps the packets in order if flush and loop write at the same time
+	writeMu sync.Mutex
+
+	mu     sync.Mutex
+	queue  []pacedPacket
+	closed bool
+	// draining is set once the RTPSender started draining, new samples are rejected
+	draining bool
+	// writing is set while packets taken from the queue are written
+	writing bool
+	// nextStart is the end of the window of the last sample, the next one starts after it
+	nextStart time.Time
+
+	wake    chan struct{}
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newSamplePacer(
+	writeStream TrackLocalWriter, burstBytes int, clock pacingClock, log logging.LeveledLogger,
+) *samplePacer {
+	p := &samplePacer{
+		writeStream: writeStream,
+		burstBytes:  burstBytes,
+		clock:       clock,
+		log:         log,
+		wake:        make(chan struct{}, 1),
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+	go p.loop()
+
+	return p
+}
+
+// enqueue schedules the packets of a sample in bursts of at most burstBytes, evenly spread
+// over window. The first burst is sent right away, unless the window of the previous sample
+// didn't end yet. It returns false once the pacer is closed, and ErrTrackLocalDraining once
+// it is draining.
+func (p *samplePacer) enqueue(packets []pacedPacket, window time.Duration) (bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.draining {
+		return false, ErrTrackLocalDraining
+	}
+	if p.closed {
+		return false, nil
+	}
+
+	var bursts []int // the index of the first packet of each burst
+	size := 0
+	for i := range packets {
+		packetSize := packets[i].header.MarshalSize() + len(packets[i].payload)
+		if i == 0 || size+packetSize > p.burstBytes {
+			bursts = append(bursts, i)
+			size = 0
+		}
+		size += packetSize
+	}
+
+	start := p.clock.Now()
+	if start.Before(p.nextStart) {
+		start = p.nextStart
+	}
+	p.nextStart = start.Add(window)
+
+	for burst, first := range bursts {
+		sendAt := start.Add(window * time.Duration(burst) / time.Duration(len(bursts)))
+		last := len(packets)
+		if burst+1 < len(bursts) {
+			last = bursts[burst+1]
+		}
+		for i := first; i < last; i++ {
+			packets[i].sendAt = sendAt
+			p.queue = append(p.queue, packets[i])
+		}
+	}
+
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+
+	return true, nil
+}
+
+func (p *samplePacer) loop() {
+	defer close(p.stopped)
+
+	for {
+		p.writeMu.Lock()
+		p.mu.Lock()
+		now := p.clock.Now()
+		n := 0
+		for n < len(p.queue) && !p.queue[n].sendAt.After(now) {
+			n++
+		}
+		due := p.queue[:n:n]
+		p.queue = p.queue[n:]
+		p.writing = len(due) != 0
+		p.mu.Unlock()
+
+		if len(due) != 0 {
+			p.write(due)
+			p.writeMu.Unlock()
+
+			continue
+		}
+		p.writeMu.Unlock()
+
+		// Nothing is due, wait for the next burst or a new sample
+		var wait <-chan time.Time
+		p.mu.Lock()
+		if len(p.queue) != 0 {
+			wait = p.clock.After(p.queue[0].sendAt.Sub(p.clock.Now()))
+		}
+		p.mu.Unlock()
+
+		select {
+		case <-p.done:
+			p.flush()
+
+			return
+		case <-p.wake:
+		case <-wait:
+		}
+	}
+}
+
+// flush writes the packets that are still held back right away.
+func (p *samplePacer) flush() {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	p.mu.Lock()
+	remaining := p.queue
+	p.queue = nil
+	p.writing = len(remaining) != 0
+	p.mu.Unlock()
+
+	p.write(remaining)
+}
+
+// drain rejects new samples, writes the packets that are still held back right away and
+// calls start before any other packet can be written, so start can begin draining the
+// stream without dropping packets of samples that were already accepted.
+func (p *samplePacer) drain(start func()) {
+	p.writeMu.Lock()
+	defer p.writeMu.Unlock()
+
+	p.mu.Lock()
+	p.draining = true
+	remaining := p.queue
+	p.queue = nil
+	p.writing = len(remaining) != 0
+	p.mu.Unlock()
+
+	p.write(remaining)
+	start()
+}
+
+// pending returns true while packets are held back or being written.
+func (p *samplePacer) pending() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return len(p.queue) != 0 || p.writing
+}
+
+// write writes the packets taken from the queue, the writeMu must be held.
+func (p *samplePacer) write(packets []pacedPacket) {
+	for i := range packets {
+		var err error
+		if writer, ok := p.writeStream.(attributesRTPWriter); ok && packets[i].attributes != nil {
+			_, err = writer.writeRTPWithAttributes(&packets[i].header, packets[i].payload, packets[i].attributes)
+		} else {
+			_, err = p.writeStream.WriteRTP(&packets[i].header, packets[i].payload)
+		}
+		if err != nil {
+			p.log.Debugf("Failed to write paced packet: %v", err)
+		}
+	}
+
+	p.mu.Lock()
+	p.writing = false
+	p.mu.Unlock()
+}
+
+// close writes the packets that are still held back and stops the pacer.
+func (p *samplePacer) close() {
+	p.mu.Lock()
+	if p.closed {
+		p.mu.Unlock()
+		<-p.stopped
+
+		return
+	}
+	p.closed = true
+	p.mu.Unlock()
+
+	close(p.done)
+	<-p.stopped
+}
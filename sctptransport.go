@@ -7,6 +7,7 @@ package webrtc
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"net"
 	"sync"
@@ -20,6 +21,17 @@ import (
 
 const sctpMaxChannels = uint16(65535)
 
+// sctpRTTPollInterval is how often the smoothed RTT of the association is checked for
+// OnRTTUpdate, as the association doesn't report its updates.
+const sctpRTTPollInterval = 100 * time.Millisecond
+
+// The range of IDs of the DataChannels of EnsureDataChannel, above the IDs allocated
+// for DCEP and below the 1024 streams of most browsers.
+const (
+	ensuredDataChannelIDBase  = uint16(512)
+	ensuredDataChannelIDCount = uint16(512)
+)
+
 // SCTPTransport provides details about the SCTP transport.
 type SCTPTransport struct {
 	lock sync.RWMutex
@@ -37,14 +49,22 @@ type SCTPTransport struct {
 	// be used simultaneously.
 	maxChannels *uint16
 
-	// OnStateChange  func()
+	onStateChangeHandler         func(SCTPTransportState)
+	internalOnStateChangeHandler func(SCTPTransportState)
+	onErrorHandler               func(error)
+	onCloseHandler               func(error)
+	onRTTUpdateHandler           func(time.Duration)
+
+	// The error that ended the association when it wasn't stopped, it isn't re-established
+	lostErr error
 
-	onErrorHandler func(error)
-	onCloseHandler func(error)
+	// Closed to stop watching the RTT of the association for onRTTUpdateHandler
+	rttWatchDone chan struct{}
 
 	sctpAssociation            *sctp.Association
 	onDataChannelHandler       func(*DataChannel)
 	onDataChannelOpenedHandler func(*DataChannel)
+	onDataChannelOpenRequest   func(DataChannelParameters) bool
 
 	// DataChannels
 	dataChannels          []*DataChannel
@@ -132,8 +152,10 @@ func (r *SCTPTransport) Start(capabilities SCTPCapabilities) error {
 	r.lock.Lock()
 	r.sctpAssociation = sctpAssociation
 	r.state = SCTPTransportStateConnected
+	r.startRTTWatch()
 	dataChannels := append([]*DataChannel{}, r.dataChannels...)
 	r.lock.Unlock()
+	r.onStateChange(SCTPTransportStateConnected)
 
 	var openedDCCount uint32
 	for _, d := range dataChannels {
@@ -208,19 +230,50 @@ func (r *SCTPTransport) optionalSCTPClientOptions() []sctp.ClientOption {
 // Stop stops the SCTPTransport.
 func (r *SCTPTransport) Stop() error {
 	r.lock.Lock()
-	defer r.lock.Unlock()
 	if r.sctpAssociation == nil {
+		r.lock.Unlock()
+
 		return nil
 	}
 
 	r.sctpAssociation.Abort("")
 
+	r.stopRTTWatch()
 	r.sctpAssociation = nil
 	r.state = SCTPTransportStateClosed
+	r.lock.Unlock()
+	r.onStateChange(SCTPTransportStateClosed)
 
 	return nil
 }
 
+// associationLost closes the SCTPTransport when assoc ended without Stop, because of
+// an ABORT of the remote or the closure of the DTLS transport. It returns false if
+// the SCTPTransport was stopped or started another association.
+func (r *SCTPTransport) associationLost(assoc *sctp.Association, err error) bool {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if r.sctpAssociation != assoc {
+		return false
+	}
+
+	r.stopRTTWatch()
+	r.sctpAssociation = nil
+	r.lostErr = err
+	r.state = SCTPTransportStateClosed
+
+	return true
+}
+
+// failed returns true if the association was lost while it carried DataChannels.
+func (r *SCTPTransport) failed() bool {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	return r.lostErr != nil && len(r.dataChannels) != 0
+}
+
 //nolint:cyclop
 func (r *SCTPTransport) acceptDataChannels(
 	assoc *sctp.Association,
@@ -253,6 +306,7 @@ ACCEPT:
 			LoggerFactory: r.api.settingEngine.LoggerFactory,
 		}, dataChannels...)
 		if err != nil {
+			lost := r.associationLost(assoc, err)
 			if !errors.Is(err, io.EOF) {
 				r.log.Errorf("Failed to accept data channel: %v", err)
 				r.onError(err)
@@ -260,6 +314,9 @@ ACCEPT:
 			} else {
 				r.onClose(nil)
 			}
+			if lost {
+				r.onStateChange(SCTPTransportStateClosed)
+			}
 
 			return
 		}
@@ -297,7 +354,7 @@ ACCEPT:
 		}
 
 		sid := dc.StreamIdentifier()
-		rtcDC, err := r.api.newDataChannel(&DataChannelParameters{
+		params := &DataChannelParameters{
 			ID:                &sid,
 			Label:             dc.Config.Label,
 			Protocol:          dc.Config.Protocol,
@@ -305,7 +362,17 @@ ACCEPT:
 			Ordered:           ordered,
 			MaxPacketLifeTime: maxPacketLifeTime,
 			MaxRetransmits:    maxRetransmits,
-		}, r, r.api.settingEngine.LoggerFactory.NewLogger("ortc"))
+		}
+		if !r.acceptDataChannelOpenRequest(*params) {
+			r.log.Debugf("Refused data channel %d with label %q and protocol %q", sid, params.Label, params.Protocol)
+			if err = dc.Close(); err != nil {
+				r.log.Errorf("Failed to close refused data channel: %v", err)
+			}
+
+			continue ACCEPT
+		}
+
+		rtcDC, err := r.api.newDataChannel(params, r, r.api.settingEngine.LoggerFactory.NewLogger("ortc"))
 		if err != nil {
 			// This data channel is invalid. Close it and log an error.
 			if err1 := dc.Close(); err1 != nil {
@@ -331,6 +398,33 @@ ACCEPT:
 	}
 }
 
+// OnStateChange sets an event handler which is invoked when the state of the
+// SCTPTransport changes. It becomes connected when the association is established,
+// and closed when it is stopped or lost because of an ABORT of the remote or the
+// closure of the DTLS transport. A lost association isn't re-established, the
+// DataChannels are closed and fire OnError when it ended with an error, and the
+// PeerConnection is closed, or failed with SettingEngine.DisableCloseByDTLS.
+// The association is kept by ICE restarts, as the DTLS transport is.
+func (r *SCTPTransport) OnStateChange(f func(SCTPTransportState)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.onStateChangeHandler = f
+}
+
+func (r *SCTPTransport) onStateChange(state SCTPTransportState) {
+	r.lock.RLock()
+	handler := r.onStateChangeHandler
+	internalHandler := r.internalOnStateChangeHandler
+	r.lock.RUnlock()
+
+	if handler != nil {
+		go handler(state)
+	}
+	if internalHandler != nil {
+		internalHandler(state)
+	}
+}
+
 // OnError sets an event handler which is invoked when the SCTP Association errors.
 func (r *SCTPTransport) OnError(f func(err error)) {
 	r.lock.Lock()
@@ -381,6 +475,28 @@ func (r *SCTPTransport) OnDataChannelOpened(f func(*DataChannel)) {
 	r.onDataChannelOpenedHandler = f
 }
 
+// OnDataChannelOpenRequest sets a handler which is invoked with the parameters of every data
+// channel the remote peer opens, before the DataChannel is created and OnDataChannel fires.
+// Returning false refuses the channel: its stream is reset, which closes the channel of the
+// remote peer, and it is never exposed to the application.
+//
+// The channels are accepted one at a time, the handler must not block.
+func (r *SCTPTransport) OnDataChannelOpenRequest(f func(params DataChannelParameters) bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.onDataChannelOpenRequest = f
+}
+
+// acceptDataChannelOpenRequest returns false if the OnDataChannelOpenRequest handler refuses
+// the data channel of params.
+func (r *SCTPTransport) acceptDataChannelOpenRequest(params DataChannelParameters) bool {
+	r.lock.RLock()
+	handler := r.onDataChannelOpenRequest
+	r.lock.RUnlock()
+
+	return handler == nil || handler(params)
+}
+
 func (r *SCTPTransport) onDataChannel(dc *DataChannel) (done chan struct{}) {
 	r.lock.Lock()
 	r.dataChannels = append(r.dataChannels, dc)
@@ -429,6 +545,95 @@ func (r *SCTPTransport) MaxChannels() uint16 {
 	return *r.maxChannels
 }
 
+// SmoothedRTT returns the smoothed round-trip time measured by the SCTP association from the
+// acknowledgements of its DATA and HEARTBEAT chunks, false if there is no measurement yet.
+// It is also available for DataChannel only connections, which have no RTCP to measure it.
+// When little data flows the measurements include the delay of the acknowledgements.
+func (r *SCTPTransport) SmoothedRTT() (time.Duration, bool) {
+	association := r.association()
+	if association == nil {
+		return 0, false
+	}
+
+	return smoothedRTT(association)
+}
+
+func smoothedRTT(association *sctp.Association) (time.Duration, bool) {
+	srtt := association.SRTT()
+	if srtt <= 0 {
+		return 0, false
+	}
+
+	return time.Duration(srtt * float64(time.Millisecond)), true
+}
+
+// OnRTTUpdate sets an event handler which is called with SmoothedRTT for its first
+// measurement, and then when it changed by at least the threshold set with
+// SettingEngine.SetSCTPRTTUpdateThreshold since the last call. The smoothed RTT
+// is checked every 100ms.
+func (r *SCTPTransport) OnRTTUpdate(f func(srtt time.Duration)) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	r.onRTTUpdateHandler = f
+	r.startRTTWatch()
+}
+
+// startRTTWatch starts watching the RTT of the association if there is a handler for it;
+// caller of this method should hold the lock.
+func (r *SCTPTransport) startRTTWatch() {
+	if r.onRTTUpdateHandler == nil || r.sctpAssociation == nil || r.rttWatchDone != nil {
+		return
+	}
+
+	r.rttWatchDone = make(chan struct{})
+	go r.watchRTT(r.sctpAssociation, r.rttWatchDone)
+}
+
+// stopRTTWatch stops watching the RTT of the association; caller of this method should hold the lock.
+func (r *SCTPTransport) stopRTTWatch() {
+	if r.rttWatchDone != nil {
+		close(r.rttWatchDone)
+		r.rttWatchDone = nil
+	}
+}
+
+func (r *SCTPTransport) watchRTT(association *sctp.Association, done chan struct{}) {
+	ticker := time.NewTicker(sctpRTTPollInterval)
+	defer ticker.Stop()
+
+	threshold := r.api.settingEngine.sctp.rttUpdateThreshold
+	var last time.Duration
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		srtt, ok := smoothedRTT(association)
+		if !ok {
+			continue
+		}
+
+		change := srtt - last
+		if change < 0 {
+			change = -change
+		}
+		if last != 0 && (change == 0 || change < threshold) {
+			continue
+		}
+		last = srtt
+
+		r.lock.RLock()
+		handler := r.onRTTUpdateHandler
+		r.lock.RUnlock()
+		if handler != nil {
+			handler(srtt)
+		}
+	}
+}
+
 // State returns the current state of the SCTPTransport.
 func (r *SCTPTransport) State() SCTPTransportState {
 	r.lock.RLock()
@@ -464,6 +669,48 @@ func (r *SCTPTransport) collectStats(collector *statsReportCollector) {
 	collector.Collect(stats.ID, stats)
 }
 
+// addDataChannel adds a locally created DataChannel. Its ID, if it has one, must be
+// smaller than MaxChannels and not be used by another DataChannel that isn't closed.
+func (r *SCTPTransport) addDataChannel(dataChannel *DataChannel) error {
+	maxVal := r.MaxChannels()
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	if id := dataChannel.ID(); id != nil {
+		if *id >= maxVal {
+			return &rtcerr.TypeError{Err: ErrMaxDataChannelID}
+		}
+
+		for _, existing := range r.dataChannels {
+			if existingID := existing.ID(); existingID != nil && *existingID == *id &&
+				existing.ReadyState() != DataChannelStateClosed {
+				return &rtcerr.OperationError{Err: fmt.Errorf("%w: %d", ErrDataChannelIDInUse, *id)}
+			}
+		}
+		r.dataChannelIDsUsed[*id] = struct{}{}
+	}
+
+	r.dataChannels = append(r.dataChannels, dataChannel)
+	r.dataChannelsRequested++
+
+	return nil
+}
+
+// dataChannelByID returns the DataChannel with the given ID that isn't closed, if any.
+func (r *SCTPTransport) dataChannelByID(id uint16) *DataChannel {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+
+	for _, dc := range r.dataChannels {
+		if dcID := dc.ID(); dcID != nil && *dcID == id && dc.ReadyState() != DataChannelStateClosed {
+			return dc
+		}
+	}
+
+	return nil
+}
+
 func (r *SCTPTransport) generateAndSetDataChannelID(dtlsRole DTLSRole, idOut **uint16) error {
 	var id uint16
 	if dtlsRole != DTLSRoleClient {
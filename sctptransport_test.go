@@ -9,11 +9,17 @@ import (
 	"bufio"
 	"context"
 	"net"
+	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/sctp"
+	"github.com/pion/transport/v4/test"
+	"github.com/pion/transport/v4/vnet"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -62,6 +68,41 @@ func TestGenerateDataChannelID(t *testing.T) {
 	}
 }
 
+func TestSCTPTransport_AddDataChannel(t *testing.T) {
+	transport := &SCTPTransport{dataChannelIDsUsed: make(map[uint16]struct{})}
+	newDataChannel := func(id uint16) *DataChannel {
+		dataChannel := &DataChannel{id: &id}
+		dataChannel.setReadyState(DataChannelStateOpen)
+
+		return dataChannel
+	}
+
+	first := newDataChannel(1)
+	assert.NoError(t, transport.addDataChannel(first))
+	assert.Contains(t, transport.dataChannelIDsUsed, uint16(1))
+
+	// The ID of a DataChannel that isn't closed can't be used again
+	var operationErr *rtcerr.OperationError
+	err := transport.addDataChannel(newDataChannel(1))
+	assert.ErrorAs(t, err, &operationErr)
+	assert.ErrorIs(t, err, ErrDataChannelIDInUse)
+
+	first.setReadyState(DataChannelStateClosed)
+	assert.NoError(t, transport.addDataChannel(newDataChannel(1)))
+
+	// IDs must be smaller than MaxChannels
+	var typeErr *rtcerr.TypeError
+	err = transport.addDataChannel(newDataChannel(transport.MaxChannels()))
+	assert.ErrorAs(t, err, &typeErr)
+	assert.ErrorIs(t, err, ErrMaxDataChannelID)
+
+	// Generated IDs skip the ones in use
+	idPtr := new(uint16)
+	assert.NoError(t, transport.generateAndSetDataChannelID(DTLSRoleServer, &idPtr))
+	assert.Equal(t, uint16(3), *idPtr)
+	assert.Len(t, transport.dataChannels, 2)
+}
+
 func TestSCTPTransport_sctpClientOptions_IncludesOptionalOptions(t *testing.T) {
 	clientConn, serverConn := net.Pipe()
 	defer func() {
@@ -282,6 +323,145 @@ func TestSCTPTransportOnCloseImmediate(t *testing.T) {
 	}
 }
 
+// A path that breaks mid-transfer and is restored with an ICE restart keeps the association,
+// the messages sent during the outage are delivered.
+func TestSCTPTransportOnStateChangePathLoss(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, wan := createVNetPair(t, nil)
+
+	var keepPackets atomic.Bool
+	keepPackets.Store(true)
+	wan.AddChunkFilter(func(vnet.Chunk) bool {
+		return keepPackets.Load()
+	})
+
+	sctpStates := make(chan SCTPTransportState, 10)
+	offerPC.SCTP().OnStateChange(func(state SCTPTransportState) {
+		sctpStates <- state
+	})
+	answerPC.SCTP().OnStateChange(func(state SCTPTransportState) {
+		sctpStates <- state
+	})
+
+	const messageCount = 100
+	received := make(chan string, messageCount)
+	answerPC.OnDataChannel(func(dc *DataChannel) {
+		dc.OnMessage(func(msg DataChannelMessage) {
+			received <- string(msg.Data)
+		})
+	})
+
+	dc, err := offerPC.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	require.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+	<-opened
+	for range 2 {
+		assert.Equal(t, SCTPTransportStateConnected, <-sctpStates)
+	}
+
+	// Break the path in the middle of the messages
+	failed := untilConnectionState(PeerConnectionStateFailed, offerPC, answerPC)
+	for i := range messageCount {
+		if i == messageCount/2 {
+			keepPackets.Store(false)
+		}
+		require.NoError(t, dc.SendText(strconv.Itoa(i)))
+	}
+	failed.Wait()
+	assert.Equal(t, SCTPTransportStateConnected, offerPC.SCTP().State())
+	assert.Equal(t, SCTPTransportStateConnected, answerPC.SCTP().State())
+
+	keepPackets.Store(true)
+	reconnected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	offer, err := offerPC.CreateOffer(&OfferOptions{ICERestart: true})
+	require.NoError(t, err)
+	offerGatheringComplete := GatheringCompletePromise(offerPC)
+	require.NoError(t, offerPC.SetLocalDescription(offer))
+	<-offerGatheringComplete
+	require.NoError(t, answerPC.SetRemoteDescription(*offerPC.LocalDescription()))
+	answer, err := answerPC.CreateAnswer(nil)
+	require.NoError(t, err)
+	answerGatheringComplete := GatheringCompletePromise(answerPC)
+	require.NoError(t, answerPC.SetLocalDescription(answer))
+	<-answerGatheringComplete
+	require.NoError(t, offerPC.SetRemoteDescription(*answerPC.LocalDescription()))
+	reconnected.Wait()
+
+	for i := range messageCount {
+		assert.Equal(t, strconv.Itoa(i), <-received)
+	}
+	assert.Empty(t, sctpStates)
+
+	require.NoError(t, wan.Stop())
+	closePairNow(t, offerPC, answerPC)
+}
+
+// An association lost to an ABORT is closed and fails the DataChannels, and the PeerConnection
+// when it isn't closed with the DTLS transport.
+func TestSCTPTransportOnStateChangeAbort(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.DisableCloseByDTLS(true)
+	api := NewAPI(WithSettingEngine(settingEngine))
+	offerPC, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	answerPC, err := api.NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	sctpStates := make(chan SCTPTransportState, 10)
+	offerPC.SCTP().OnStateChange(func(state SCTPTransportState) {
+		sctpStates <- state
+	})
+
+	dc, err := offerPC.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	opened := make(chan struct{})
+	dc.OnOpen(func() {
+		close(opened)
+	})
+	dcErr := make(chan error, 1)
+	dc.OnError(func(err error) {
+		dcErr <- err
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	require.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+	<-opened
+	assert.Equal(t, SCTPTransportStateConnected, <-sctpStates)
+
+	failed := untilConnectionState(PeerConnectionStateFailed, offerPC, answerPC)
+	answerPC.SCTP().association().Abort("test")
+	failed.Wait()
+
+	assert.Equal(t, SCTPTransportStateClosed, <-sctpStates)
+	assert.Equal(t, SCTPTransportStateClosed, offerPC.SCTP().State())
+	assert.ErrorIs(t, <-dcErr, sctp.ErrChunk)
+	assert.Equal(t, DataChannelStateClosed, dc.ReadyState())
+
+	// Stopping the lost SCTPTransport doesn't change its state again
+	require.NoError(t, offerPC.SCTP().Stop())
+	closePairNow(t, offerPC, answerPC)
+	assert.Empty(t, sctpStates)
+}
+
 func TestSCTPTransportOutOfBandNegotiatedDataChannelDetach(t *testing.T) { //nolint:cyclop
 	// nolint:varnamelen
 	const N = 10
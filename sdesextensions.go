@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+)
+
+// autoExtensionStamper is implemented by the tracks that can ask the RTPSender to add the
+// mid and rid header extensions to their packets, see WithAutoExtensionStamping.
+type autoExtensionStamper interface {
+	autoExtensionStamping() bool
+}
+
+// WithAutoExtensionStamping makes the RTPSenders of the track add the mid, rid and repaired
+// rid RTP header extensions to every packet, with the IDs negotiated by their PeerConnection,
+// the mid of their transceiver and the RID of their encoding. The extensions already present
+// with these IDs are replaced, so packets forwarded from another PeerConnection can be written
+// as they are to any number of PeerConnections. Retransmissions carry the repaired rid
+// instead of the rid. Extensions the packets carry with other IDs are left as they are.
+func WithAutoExtensionStamping() func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.stampExtensions = true
+	}
+}
+
+func (s *TrackLocalStaticRTP) autoExtensionStamping() bool { return s.stampExtensions }
+
+func (s *TrackLocalStaticSample) autoExtensionStamping() bool { return s.rtpTrack.stampExtensions }
+
+// sdesExtensionsWriter adds the mid and rid of an encoding to its packets.
+type sdesExtensionsWriter struct {
+	midID, ridID, repairedRIDID uint8
+	mid, rid                    string
+}
+
+// apply returns header with the mid and rid set.
+func (w *sdesExtensionsWriter) apply(header *rtp.Header) *rtp.Header {
+	if w.midID != 0 && w.mid != "" {
+		header = withHeaderExtension(header, w.midID, []byte(w.mid))
+	}
+	if w.ridID != 0 && w.rid != "" {
+		header = withHeaderExtension(header, w.ridID, []byte(w.rid))
+	}
+
+	return header
+}
+
+// applyRepair returns header of a retransmission with the rid replaced by the repaired rid.
+func (w *sdesExtensionsWriter) applyRepair(header *rtp.Header) *rtp.Header {
+	if w.ridID == 0 || header.GetExtension(w.ridID) == nil {
+		return header
+	}
+
+	// The header is kept in the retransmission history, so don't modify it.
+	repaired := *header
+	repaired.Extensions = append([]rtp.Extension(nil), header.Extensions...)
+	if err := repaired.DelExtension(w.ridID); err != nil {
+		return header
+	}
+	if w.repairedRIDID != 0 {
+		return withHeaderExtension(&repaired, w.repairedRIDID, []byte(w.rid))
+	}
+
+	return &repaired
+}
+
+// configureSDESExtensions installs the writer of the mid and rid on e if its track asks for
+// them with WithAutoExtensionStamping. r.mu must be held.
+func (r *RTPSender) configureSDESExtensions(e *trackEncoding) {
+	stamper, ok := e.track.(autoExtensionStamper)
+	if !ok || !stamper.autoExtensionStamping() {
+		e.writeStream.sdesExtensions.Store(nil)
+
+		return
+	}
+
+	writer := &sdesExtensionsWriter{rid: e.rid}
+	if r.rtpTransceiver != nil {
+		writer.mid = r.rtpTransceiver.Mid()
+	}
+	for _, extension := range e.headerExtensions {
+		id := uint8(extension.ID) //nolint:gosec // header extension IDs are at most 255
+		switch extension.URI {
+		case sdp.SDESMidURI:
+			writer.midID = id
+		case sdp.SDESRTPStreamIDURI:
+			writer.ridID = id
+		case sdp.SDESRepairRTPStreamIDURI:
+			writer.repairedRIDID = id
+		}
+	}
+
+	e.writeStream.sdesExtensions.Store(writer)
+}
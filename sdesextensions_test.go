@@ -0,0 +1,161 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/sdp/v3"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSDESExtensionsWriter(t *testing.T) {
+	writer := &sdesExtensionsWriter{midID: 1, ridID: 2, repairedRIDID: 3, mid: "0", rid: "f"}
+
+	header := &rtp.Header{}
+	require.NoError(t, header.SetExtension(2, []byte("stale")))
+	require.NoError(t, header.SetExtension(4, []byte("other")))
+
+	stamped := writer.apply(header)
+	assert.Equal(t, []byte("stale"), header.GetExtension(2), "the original header must not be modified")
+	assert.Equal(t, []byte("0"), stamped.GetExtension(1))
+	assert.Equal(t, []byte("f"), stamped.GetExtension(2))
+	assert.Equal(t, []byte("other"), stamped.GetExtension(4))
+	assert.Len(t, stamped.Extensions, 3)
+
+	repaired := writer.applyRepair(stamped)
+	assert.Equal(t, []byte("f"), stamped.GetExtension(2), "the original header must not be modified")
+	assert.Equal(t, []byte("0"), repaired.GetExtension(1))
+	assert.Nil(t, repaired.GetExtension(2))
+	assert.Equal(t, []byte("f"), repaired.GetExtension(3))
+
+	// Without the repaired rid negotiated the rid is only removed
+	writer.repairedRIDID = 0
+	repaired = writer.applyRepair(stamped)
+	assert.Nil(t, repaired.GetExtension(2))
+	assert.Nil(t, repaired.GetExtension(3))
+}
+
+func TestTrackLocalStaticRTP_AutoExtensionStamping(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	track, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion",
+		WithRTPStreamID("f"), WithAutoExtensionStamping(),
+	)
+	require.NoError(t, err)
+
+	// The second pair negotiates other IDs for the extensions, and another mid for the track
+	newPair := func(extraExtensions ...string) *LoopbackPeerConnectionPair {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		for _, uri := range extraExtensions {
+			require.NoError(t, mediaEngine.RegisterHeaderExtension(RTPHeaderExtensionCapability{URI: uri}, RTPCodecTypeVideo))
+		}
+		require.NoError(t, ConfigureSimulcastExtensionHeaders(mediaEngine))
+
+		pair, pairErr := NewAPI(WithMediaEngine(mediaEngine)).NewLoopbackPeerConnectionPair(Configuration{})
+		require.NoError(t, pairErr)
+		if len(extraExtensions) != 0 {
+			_, pairErr = pair.Offerer.AddTransceiverFromKind(RTPCodecTypeAudio)
+			require.NoError(t, pairErr)
+		}
+		_, pairErr = pair.Offerer.AddTrack(track)
+		require.NoError(t, pairErr)
+
+		return pair
+	}
+	pairs := []*LoopbackPeerConnectionPair{
+		newPair(),
+		newPair(PlayoutDelayURI, VideoOrientationURI, sdp.TransportCCURI),
+	}
+
+	type received struct {
+		mid, rid  []byte
+		midID     int
+		duplicate bool
+	}
+	receivedPackets := make([]chan received, len(pairs))
+	for i, pair := range pairs {
+		receivedPackets[i] = make(chan received, 1)
+		pair.Answerer.OnTrack(func(trackRemote *TrackRemote, receiver *RTPReceiver) {
+			var midID, ridID uint8
+			for _, extension := range receiver.GetParameters().HeaderExtensions {
+				id := uint8(extension.ID) //nolint:gosec // header extension IDs are at most 255
+				switch extension.URI {
+				case sdp.SDESMidURI:
+					midID = id
+				case sdp.SDESRTPStreamIDURI:
+					ridID = id
+				}
+			}
+
+			for {
+				pkt, _, readErr := trackRemote.ReadRTP()
+				if readErr != nil {
+					return
+				}
+
+				r := received{mid: pkt.GetExtension(midID), rid: pkt.GetExtension(ridID), midID: int(midID)}
+				ids := map[uint8]bool{}
+				for _, id := range pkt.GetExtensionIDs() {
+					r.duplicate = r.duplicate || ids[id]
+					ids[id] = true
+				}
+				select {
+				case receivedPackets[i] <- r:
+				default:
+				}
+			}
+		})
+		require.NoError(t, pair.Negotiate())
+	}
+
+	// The forwarded packets carry the extensions of the source PeerConnection
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	sequenceNumber := uint16(0)
+	var results []received
+	for i := range pairs {
+		for result := (received{}); result.mid == nil; {
+			select {
+			case result = <-receivedPackets[i]:
+			case <-ticker.C:
+				sequenceNumber++
+				packet := &rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+					Payload: []byte{0x10, 0x00},
+				}
+				require.NoError(t, packet.SetExtension(1, []byte("stale-mid")))
+				require.NoError(t, packet.SetExtension(2, []byte("stale-rid")))
+				require.NoError(t, track.WriteRTP(packet))
+			}
+			if result.mid != nil {
+				results = append(results, result)
+			}
+		}
+	}
+
+	assert.NotEqual(t, results[0].midID, results[1].midID)
+	for i, result := range results {
+		assert.Equal(t, pairs[i].Offerer.GetTransceivers()[i].Mid(), string(result.mid))
+		assert.Equal(t, "f", string(result.rid))
+		assert.False(t, result.duplicate)
+	}
+	assert.NotEqual(t, results[0].mid, results[1].mid)
+
+	for _, pair := range pairs {
+		assert.NoError(t, pair.Close())
+	}
+}
@@ -32,6 +32,9 @@ type trackDetails struct {
 	rtxSsrc  *SSRC
 	fecSsrc  *SSRC
 	rids     []string
+
+	// Identifiers as announced by the remote, before the trackIdentifierPolicy sanitized them.
+	originalStreamID, originalID string
 }
 
 func trackDetailsForSSRC(trackDetails []trackDetails, ssrc SSRC) *trackDetails {
@@ -106,69 +109,49 @@ func trackDetailsFromSDP(
 			continue
 		}
 
+		// Legacy endpoints can place the media level msid after the ssrc lines
+		if msid, ok := media.Attribute(sdp.AttrKeyMsid); ok {
+			if split := strings.Split(msid, " "); len(split) == 2 {
+				streamID, trackID = split[0], split[1]
+			}
+		}
+
+		// The primary SSRCs of the ssrc groups, in order of appearance
+		groupSsrcs := []uint64{}
+
 		for _, attr := range media.Attributes {
 			switch attr.Key {
 			case sdp.AttrKeySSRCGroup:
-				split := strings.Split(attr.Value, " ")
-				if split[0] == sdp.SemanticTokenFlowIdentification { //nolint:nestif
-					// Add rtx ssrcs to blacklist, to avoid adding them as tracks
-					// Essentially lines like `a=ssrc-group:FID 2231627014 632943048` are processed by this section
-					// as this declares that the second SSRC (632943048) is a rtx repair flow (RFC4588) for the first
-					// (2231627014) as specified in RFC5576
-					if len(split) == 3 {
-						baseSsrc, err := strconv.ParseUint(split[1], 10, 32)
-						if err != nil {
-							log.Warnf("Failed to parse SSRC: %v", err)
-
-							continue
-						}
-						rtxRepairFlow, err := strconv.ParseUint(split[2], 10, 32)
-						if err != nil {
-							log.Warnf("Failed to parse SSRC: %v", err)
+				// Lines like `a=ssrc-group:FID 2231627014 632943048` declare that the second SSRC
+				// (632943048) is a rtx repair flow (RFC4588) for the first (2231627014) as specified
+				// in RFC5576, `a=ssrc-group:FEC-FR aaaaa bbbbb` that bbbbb is a FEC flow for aaaaa.
+				// Repair flows are not added as tracks.
+				semantics, baseSsrc, repairFlow, ok := repairFlowFromSSRCGroup(log, attr.Value)
+				if !ok {
+					continue
+				}
+				groupSsrcs = append(groupSsrcs, baseSsrc)
 
-							continue
-						}
-						rtxRepairFlows[rtxRepairFlow] = baseSsrc
-						tracksInMediaSection = filterTrackWithSSRC(
-							tracksInMediaSection,
-							SSRC(rtxRepairFlow),
-						) // Remove if rtx was added as track before
-						for i := range tracksInMediaSection {
-							if tracksInMediaSection[i].ssrcs[0] == SSRC(baseSsrc) {
-								repairSsrc := SSRC(rtxRepairFlow)
-								tracksInMediaSection[i].rtxSsrc = &repairSsrc
-							}
-						}
+				repairSsrc := SSRC(repairFlow) //nolint:gosec // G115
+				tracksInMediaSection = filterTrackWithSSRC(
+					tracksInMediaSection,
+					repairSsrc,
+				) // Remove if the repair flow was added as track before
+				for i := range tracksInMediaSection {
+					if tracksInMediaSection[i].ssrcs[0] != SSRC(baseSsrc) { //nolint:gosec // G115
+						continue
 					}
-				} else if split[0] == sdp.SemanticTokenForwardErrorCorrectionFramework {
-					// Similar to above, lines like `a=ssrc-group:FEC-FR aaaaa bbbbb`
-					// means for video ssrc aaaaa, there's a FEC track bbbbb
-					if len(split) == 3 {
-						baseSsrc, err := strconv.ParseUint(split[1], 10, 32)
-						if err != nil {
-							log.Warnf("Failed to parse SSRC: %v", err)
-
-							continue
-						}
-						fecRepairFlow, err := strconv.ParseUint(split[2], 10, 32)
-						if err != nil {
-							log.Warnf("Failed to parse SSRC: %v", err)
-
-							continue
-						}
-						fecRepairFlows[fecRepairFlow] = baseSsrc
-						tracksInMediaSection = filterTrackWithSSRC(
-							tracksInMediaSection,
-							SSRC(fecRepairFlow),
-						) // Remove if fec was added as track before
-						for i := range tracksInMediaSection {
-							if tracksInMediaSection[i].ssrcs[0] == SSRC(baseSsrc) {
-								repairSsrc := SSRC(fecRepairFlow)
-								tracksInMediaSection[i].fecSsrc = &repairSsrc
-							}
-						}
+					if semantics == sdp.SemanticTokenFlowIdentification {
+						tracksInMediaSection[i].rtxSsrc = &repairSsrc
+					} else {
+						tracksInMediaSection[i].fecSsrc = &repairSsrc
 					}
 				}
+				if semantics == sdp.SemanticTokenFlowIdentification {
+					rtxRepairFlows[repairFlow] = baseSsrc
+				} else {
+					fecRepairFlows[repairFlow] = baseSsrc
+				}
 
 			// Handle `a=msid:<stream_id> <track_label>` for Unified plan. The first value is the same as MediaStream.id
 			// in the browser and can be used to figure out which tracks belong to the same stream. The browser should
@@ -237,6 +220,38 @@ func trackDetailsFromSDP(
 			}
 		}
 
+		// Some endpoints only describe their SSRCs in the ssrc groups, without any ssrc line.
+		// The primary SSRCs are tracks of the media section then, so the repair flows can
+		// be associated to them instead of being handled as undeclared SSRCs.
+		for _, baseSsrc := range groupSsrcs {
+			_, isRTX := rtxRepairFlows[baseSsrc]
+			_, isFEC := fecRepairFlows[baseSsrc]
+			if isRTX || isFEC || trackDetailsForSSRC(tracksInMediaSection, SSRC(baseSsrc)) != nil { //nolint:gosec // G115
+				continue
+			}
+
+			trackDetails := trackDetails{
+				mid:      midValue,
+				kind:     codecType,
+				streamID: streamID,
+				id:       trackID,
+				ssrcs:    []SSRC{SSRC(baseSsrc)}, //nolint:gosec // G115
+			}
+			for r, base := range rtxRepairFlows {
+				if base == baseSsrc {
+					repairSsrc := SSRC(r) //nolint:gosec // G115
+					trackDetails.rtxSsrc = &repairSsrc
+				}
+			}
+			for r, base := range fecRepairFlows {
+				if base == baseSsrc {
+					fecSsrc := SSRC(r) //nolint:gosec // G115
+					trackDetails.fecSsrc = &fecSsrc
+				}
+			}
+			tracksInMediaSection = append(tracksInMediaSection, trackDetails)
+		}
+
 		if rids := getRids(media); len(rids) != 0 && trackID != "" && streamID != "" {
 			simulcastTrack := trackDetails{
 				mid:      midValue,
@@ -258,6 +273,50 @@ func trackDetailsFromSDP(
 	return incomingTracks
 }
 
+// repairFlowFromSSRCGroup parses the value of an `a=ssrc-group` attribute declaring a repair
+// flow, FID or FEC-FR. Other and malformed groups are logged and skipped, the SSRCs they
+// reference are still handled through their ssrc lines.
+func repairFlowFromSSRCGroup(
+	log logging.LeveledLogger,
+	value string,
+) (semantics string, baseSsrc, repairFlow uint64, ok bool) {
+	split := strings.Fields(value)
+	if len(split) == 0 {
+		log.Warnf("Ignoring empty ssrc-group")
+
+		return "", 0, 0, false
+	}
+
+	switch semantics = strings.ToUpper(split[0]); semantics {
+	case sdp.SemanticTokenFlowIdentification, sdp.SemanticTokenForwardErrorCorrectionFramework:
+	default:
+		log.Debugf("Ignoring ssrc-group with unsupported semantics: %s", value)
+
+		return "", 0, 0, false
+	}
+
+	if len(split) != 3 {
+		log.Warnf("Ignoring %s ssrc-group without exactly two SSRCs: %s", semantics, value)
+
+		return "", 0, 0, false
+	}
+
+	baseSsrc, err := strconv.ParseUint(split[1], 10, 32)
+	if err != nil {
+		log.Warnf("Failed to parse SSRC: %v", err)
+
+		return "", 0, 0, false
+	}
+	repairFlow, err = strconv.ParseUint(split[2], 10, 32)
+	if err != nil {
+		log.Warnf("Failed to parse SSRC: %v", err)
+
+		return "", 0, 0, false
+	}
+
+	return semantics, baseSsrc, repairFlow, true
+}
+
 func trackDetailsToRTPReceiveParameters(trackDetails *trackDetails) RTPReceiveParameters {
 	encodingSize := max(len(trackDetails.rids), len(trackDetails.ssrcs))
 
@@ -416,33 +475,32 @@ func populateLocalCandidates(
 	i *ICEGatherer,
 	iceGatheringState ICEGatheringState,
 ) *SessionDescription {
-	if sessionDescription == nil || i == nil {
-		return sessionDescription
+	// The candidates are added to a copy, the description itself is kept as it was set
+	populated := sessionDescription.clone()
+	if populated == nil || populated.parsed == nil || i == nil {
+		return populated
 	}
 
 	candidates, err := i.GetLocalCandidates()
 	if err != nil {
-		return sessionDescription
+		return populated
 	}
 
-	parsed := sessionDescription.parsed
+	parsed := populated.parsed
 	if len(parsed.MediaDescriptions) > 0 {
 		mediaDescr := parsed.MediaDescriptions[0]
 		if err = addCandidatesToMediaDescriptions(candidates, mediaDescr, iceGatheringState); err != nil {
-			return sessionDescription
+			return sessionDescription.clone()
 		}
 	}
 
 	sdp, err := parsed.Marshal()
 	if err != nil {
-		return sessionDescription
+		return sessionDescription.clone()
 	}
+	populated.SDP = string(sdp)
 
-	return &SessionDescription{
-		SDP:    string(sdp),
-		Type:   sessionDescription.Type,
-		parsed: parsed,
-	}
+	return populated
 }
 
 //nolint:gocognit,cyclop
@@ -547,6 +605,7 @@ func addTransceiverSDP(
 	iceGatheringState ICEGatheringState,
 	mediaSection mediaSection,
 	ignoreRidPauseForRecv bool,
+	compatibilityProfile CompatibilityProfile,
 ) (bool, error) {
 	transceivers := mediaSection.transceivers
 	if len(transceivers) < 1 {
@@ -558,14 +617,26 @@ func addTransceiverSDP(
 		WithValueAttribute(sdp.AttrKeyConnectionSetup, dtlsRole.String()).
 		WithValueAttribute(sdp.AttrKeyMID, midValue).
 		WithICECredentials(iceParams.UsernameFragment, iceParams.Password).
-		WithPropertyAttribute(sdp.AttrKeyRTCPMux).
-		WithPropertyAttribute(sdp.AttrKeyRTCPRsize)
+		WithPropertyAttribute(sdp.AttrKeyRTCPMux)
+	if mediaEngine.rtcpReducedSize() && !mediaSection.rtcpReducedSizeRejected {
+		media.WithPropertyAttribute(sdp.AttrKeyRTCPRsize)
+	}
+	if compatibilityProfile.RTCPMuxOnly {
+		media.WithPropertyAttribute(sdpAttributeRTCPMuxOnly)
+	}
 
 	codecs := transceiver.getCodecs()
+	if mediaSection.disableVoiceActivityDetection {
+		codecs = withoutVoiceActivityDetection(codecs)
+	}
 	for _, codec := range codecs {
 		name := strings.TrimPrefix(codec.MimeType, "audio/")
 		name = strings.TrimPrefix(name, "video/")
-		media.WithCodec(uint8(codec.PayloadType), name, codec.ClockRate, codec.Channels, codec.SDPFmtpLine)
+		fmtpLine := codec.SDPFmtpLine
+		if compatibilityProfile.EchoH264ProfileLevelID {
+			fmtpLine = echoH264ProfileLevelID(fmtpLine, codec.PayloadType, mediaSection.remoteCodecs)
+		}
+		media.WithCodec(uint8(codec.PayloadType), name, codec.ClockRate, codec.Channels, fmtpLine)
 
 		for _, feedback := range codec.RTPCodecCapability.RTCPFeedback {
 			if feedback.Parameter == "" {
@@ -582,27 +653,7 @@ func addTransceiverSDP(
 		}
 
 		// Explicitly reject track if we don't have the codec
-		// We need to include connection information even if we're rejecting a track, otherwise Firefox will fail to
-		// parse the SDP with an error like:
-		// SIPCC Failed to parse SDP: SDP Parse Error on line 50:  c= connection line not specified for every media level,
-		// validation failed.
-		// In addition this makes our SDP compliant with RFC 4566 Section 5.7:
-		// https://datatracker.ietf.org/doc/html/rfc4566#section-5.7
-		descr.WithMedia(&sdp.MediaDescription{
-			MediaName: sdp.MediaName{
-				Media:   transceiver.kind.String(),
-				Port:    sdp.RangedPort{Value: 0},
-				Protos:  []string{"UDP", "TLS", "RTP", "SAVPF"},
-				Formats: []string{"0"},
-			},
-			ConnectionInformation: &sdp.ConnectionInformation{
-				NetworkType: "IN",
-				AddressType: "IP4",
-				Address: &sdp.Address{
-					Address: "0.0.0.0",
-				},
-			},
-		})
+		addRejectedMediaSection(descr, transceiver.kind.String())
 
 		return false, nil
 	}
@@ -616,6 +667,9 @@ func addTransceiverSDP(
 	}
 
 	parameters := mediaEngine.getRTPParametersByKind(transceiver.kind, directions)
+	if compatibilityProfile.OmitExtmapAllowMixed {
+		parameters = withoutTwoByteHeaderExtensionIDs(parameters)
+	}
 	for _, rtpExtension := range parameters.HeaderExtensions {
 		if mediaSection.matchExtensions != nil {
 			if _, enabled := mediaSection.matchExtensions[rtpExtension.URI]; !enabled {
@@ -626,7 +680,16 @@ func addTransceiverSDP(
 		if err != nil {
 			return false, err
 		}
-		media.WithExtMap(sdp.ExtMap{Value: rtpExtension.ID, URI: extURL})
+		extMap := sdp.ExtMap{Value: rtpExtension.ID, URI: extURL}
+		// The extensions restricted to a direction are qualified with it, see RFC 8285 section 6
+		switch mediaEngine.headerExtensionDirection(rtpExtension.URI, transceiver.kind) {
+		case RTPTransceiverDirectionSendonly:
+			extMap.Direction = sdp.DirectionSendOnly
+		case RTPTransceiverDirectionRecvonly:
+			extMap.Direction = sdp.DirectionRecvOnly
+		default:
+		}
+		media.WithExtMap(extMap)
 	}
 
 	if len(mediaSection.rids) > 0 {
@@ -646,7 +709,19 @@ func addTransceiverSDP(
 
 	addSenderSDP(mediaSection, isPlanB, media)
 
-	media = media.WithPropertyAttribute(transceiver.Direction().String())
+	direction := transceiver.Direction()
+	if mediaSection.offeredDirection != RTPTransceiverDirectionUnknown {
+		direction = answerDirection(direction, mediaSection.offeredDirection)
+	}
+	if compatibilityProfile.RecvonlySSRC && direction == RTPTransceiverDirectionRecvonly {
+		ssrc, cname, err := transceiver.getRecvonlySource()
+		if err != nil {
+			return false, err
+		}
+		media.WithValueAttribute(sdp.AttrKeySSRC, fmt.Sprintf("%d cname:%s", ssrc, cname))
+	}
+
+	media = media.WithPropertyAttribute(direction.String())
 
 	for _, fingerprint := range dtlsFingerprints {
 		media = media.WithFingerprint(fingerprint.Algorithm, strings.ToUpper(fingerprint.Value))
@@ -663,6 +738,58 @@ func addTransceiverSDP(
 	return true, nil
 }
 
+// addRejectedMediaSection adds a media section with port 0 and no attributes, which rejects it.
+// We need to include connection information even if we're rejecting a track, otherwise Firefox will fail to
+// parse the SDP with an error like:
+// SIPCC Failed to parse SDP: SDP Parse Error on line 50:  c= connection line not specified for every media level,
+// validation failed.
+// In addition this makes our SDP compliant with RFC 4566 Section 5.7:
+// https://datatracker.ietf.org/doc/html/rfc4566#section-5.7
+func addRejectedMediaSection(descr *sdp.SessionDescription, media string) {
+	descr.WithMedia(&sdp.MediaDescription{
+		MediaName: sdp.MediaName{
+			Media:   media,
+			Port:    sdp.RangedPort{Value: 0},
+			Protos:  []string{"UDP", "TLS", "RTP", "SAVPF"},
+			Formats: []string{"0"},
+		},
+		ConnectionInformation: &sdp.ConnectionInformation{
+			NetworkType: "IN",
+			AddressType: "IP4",
+			Address: &sdp.Address{
+				Address: "0.0.0.0",
+			},
+		},
+	})
+}
+
+// isRejectedMediaSection returns if the media section was rejected with port 0, JSEP 5.2.2.
+// Sections with port 0 that are bundle-only aren't rejected, RFC 8843 Section 6.
+func isRejectedMediaSection(media *sdp.MediaDescription) bool {
+	if media.MediaName.Port.Value != 0 {
+		return false
+	}
+	_, bundleOnly := media.Attribute(sdpAttributeBundleOnly)
+
+	return !bundleOnly
+}
+
+// mediaSectionSends returns if the media section of mid in desc exists, isn't rejected and
+// sends media. A section without a direction attribute is sendrecv.
+func mediaSectionSends(desc *sdp.SessionDescription, mid string) bool {
+	for _, media := range desc.MediaDescriptions {
+		if getMidValue(media) != mid || isRejectedMediaSection(media) {
+			continue
+		}
+
+		direction := getPeerDirection(media)
+
+		return direction == RTPTransceiverDirectionUnknown || direction.hasSend()
+	}
+
+	return false
+}
+
 type simulcastRid struct {
 	id        string
 	attrValue string
@@ -676,6 +803,32 @@ type mediaSection struct {
 	sctpInit        []byte
 	matchExtensions map[string]int
 	rids            []*simulcastRid
+	remoteCodecs    []RTPCodecParameters
+
+	// offeredDirection is the direction of the remote offer when answering, it limits the answered direction
+	offeredDirection RTPTransceiverDirection
+
+	// rtcpReducedSizeRejected is set when answering an offer without a=rtcp-rsize, RFC 5506 Section 5
+	rtcpReducedSizeRejected bool
+
+	// disableVoiceActivityDetection leaves out the comfort noise codecs, see OfferAnswerOptions
+	disableVoiceActivityDetection bool
+
+	// rejected sections are placeholders of the given media, they keep the order of the media sections
+	rejected bool
+	media    string
+}
+
+// newApplicationMid returns the mid of an application media section added after mediaSections.
+// It is numeric like the mids of new transceivers, but mustn't collide with the mids chosen by
+// the remote, which can be anything.
+func newApplicationMid(mediaSections []mediaSection) string {
+	for i := len(mediaSections); ; i++ {
+		mid := strconv.Itoa(i)
+		if !slices.ContainsFunc(mediaSections, func(m mediaSection) bool { return m.id == mid }) {
+			return mid
+		}
+	}
 }
 
 func bundleMatchFromRemote(matchBundleGroup *string) func(mid string) bool {
@@ -710,6 +863,7 @@ func populateSDP(
 	matchBundleGroup *string,
 	sctpMaxMessageSize uint32,
 	ignoreRidPauseForRecv bool,
+	compatibilityProfile CompatibilityProfile,
 ) (*sdp.SessionDescription, error) {
 	var err error
 	mediaDtlsFingerprints := []DTLSFingerprint{}
@@ -727,15 +881,21 @@ func populateSDP(
 		bundleCount++
 	}
 
-	for i, section := range mediaSections {
-		if section.data && len(section.transceivers) != 0 {
+	candidatesAdded := false
+	for _, section := range mediaSections {
+		if section.rejected {
+			addRejectedMediaSection(descr, section.media)
+
+			continue
+		} else if section.data && len(section.transceivers) != 0 {
 			return nil, errSDPMediaSectionMediaDataChanInvalid
 		} else if !isPlanB && len(section.transceivers) > 1 {
 			return nil, errSDPMediaSectionMultipleTrackInvalid
 		}
 
 		shouldAddID := true
-		shouldAddCandidates := i == 0
+		shouldAddCandidates := !candidatesAdded
+		candidatesAdded = true
 		if section.data {
 			if err = addDataMediaSection(
 				descr,
@@ -765,6 +925,7 @@ func populateSDP(
 				iceGatheringState,
 				section,
 				ignoreRidPauseForRecv,
+				compatibilityProfile,
 			)
 			if err != nil {
 				return nil, err
@@ -791,7 +952,7 @@ func populateSDP(
 		descr = descr.WithValueAttribute(sdp.AttrKeyICELite, "")
 	}
 
-	if isExtmapAllowMixed {
+	if isExtmapAllowMixed && !compatibilityProfile.OmitExtmapAllowMixed {
 		descr = descr.WithPropertyAttribute(sdp.AttrKeyExtMapAllowMixed)
 	}
 
@@ -1103,19 +1264,33 @@ func codecsFromMediaDescription(mediaDescr *sdp.MediaDescription) (out []RTPCode
 		MediaDescriptions: []*sdp.MediaDescription{mediaDescr},
 	}
 
+	payloadTypes := make([]uint8, 0, len(mediaDescr.MediaName.Formats))
 	for _, payloadStr := range mediaDescr.MediaName.Formats {
 		payloadType, err := strconv.ParseUint(payloadStr, 10, 8)
 		if err != nil {
 			return nil, err
 		}
+		payloadTypes = append(payloadTypes, uint8(payloadType))
+	}
 
-		codec, err := s.GetCodecForPayloadType(uint8(payloadType))
-		if err != nil {
+	// Look up all codecs at once, every lookup parses the whole media description.
+	sdpCodecs, err := s.GetCodecsForPayloadTypes(payloadTypes)
+	if err != nil {
+		return nil, err
+	}
+	codecsByPayloadType := make(map[uint8]sdp.Codec, len(sdpCodecs))
+	for _, codec := range sdpCodecs {
+		codecsByPayloadType[codec.PayloadType] = codec
+	}
+
+	for _, payloadType := range payloadTypes {
+		codec, ok := codecsByPayloadType[payloadType]
+		if !ok {
 			if payloadType == 0 {
 				continue
 			}
 
-			return nil, err
+			return nil, errSDPPayloadTypeNotFound
 		}
 
 		channels := uint16(0)
@@ -1167,6 +1342,56 @@ func rtpExtensionsFromMediaDescription(m *sdp.MediaDescription) (map[string]int,
 	return out, nil
 }
 
+// rtpExtensionDirectionsFromMediaDescription returns the directions of the header extensions
+// whose extmap in m has one, from the point of view of the endpoint that wrote m.
+func rtpExtensionDirectionsFromMediaDescription(m *sdp.MediaDescription) (map[string]RTPTransceiverDirection, error) {
+	out := map[string]RTPTransceiverDirection{}
+
+	for _, a := range m.Attributes {
+		if a.Key == sdp.AttrKeyExtMap {
+			e := sdp.ExtMap{}
+			if err := e.Unmarshal(a.String()); err != nil {
+				return nil, err
+			}
+
+			if direction := NewRTPTransceiverDirection(e.Direction.String()); direction != RTPTransceiverDirectionUnknown {
+				out[e.URI.String()] = direction
+			}
+		}
+	}
+
+	return out, nil
+}
+
+// negotiatedParametersFromMediaDescription returns the codecs, header extensions and RTCP settings
+// of a media section of an answer, which are the ones both endpoints negotiated.
+func negotiatedParametersFromMediaDescription(media *sdp.MediaDescription) (RTPParameters, error) {
+	codecs, err := codecsFromMediaDescription(media)
+	if err != nil {
+		return RTPParameters{}, err
+	}
+
+	extensions, err := rtpExtensionsFromMediaDescription(media)
+	if err != nil {
+		return RTPParameters{}, err
+	}
+	headerExtensions := make([]RTPHeaderExtensionParameter, 0, len(extensions))
+	for uri, id := range extensions {
+		headerExtensions = append(headerExtensions, RTPHeaderExtensionParameter{URI: uri, ID: id})
+	}
+	slices.SortFunc(headerExtensions, func(a, b RTPHeaderExtensionParameter) int {
+		return a.ID - b.ID
+	})
+
+	_, reducedSize := media.Attribute(sdp.AttrKeyRTCPRsize)
+
+	return RTPParameters{
+		HeaderExtensions: headerExtensions,
+		Codecs:           codecs,
+		RTCP:             RTCPParameters{ReducedSize: reducedSize},
+	}, nil
+}
+
 // updateSDPOrigin saves sdp.Origin in PeerConnection when creating 1st local SDP;
 // for subsequent calling, it updates Origin for SessionDescription from saved one
 // and increments session version by one.
@@ -1205,6 +1430,66 @@ func isExtMapAllowMixedSet(desc *sdp.SessionDescription) bool {
 	return false
 }
 
+// withoutVoiceActivityDetection returns codecs without the comfort noise codecs, and with the
+// VAD of the codecs that signal it in their fmtp turned off.
+func withoutVoiceActivityDetection(codecs []RTPCodecParameters) []RTPCodecParameters {
+	codecs = removeCodecs(codecs, isComfortNoiseCodec)
+	for i := range codecs {
+		name := strings.ToLower(strings.TrimPrefix(codecs[i].MimeType, "audio/"))
+		if name == "g729" || name == "opus" {
+			codecs[i].SDPFmtpLine = disableFmtpVoiceActivityDetection(name, codecs[i].SDPFmtpLine)
+		}
+	}
+
+	return codecs
+}
+
+func isComfortNoiseCodec(codec RTPCodecParameters) bool {
+	return strings.EqualFold(codec.MimeType, "audio/CN")
+}
+
+// haveComfortNoise reports if an audio media section of desc has a comfort noise codec.
+func haveComfortNoise(desc *sdp.SessionDescription) bool {
+	for _, media := range desc.MediaDescriptions {
+		if media.MediaName.Media != RTPCodecTypeAudio.String() {
+			continue
+		}
+		for _, attr := range media.Attributes {
+			_, encoding, _ := strings.Cut(attr.Value, " ")
+			if attr.Key == "rtpmap" && strings.HasPrefix(strings.ToUpper(encoding), "CN/") {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// disableFmtpVoiceActivityDetection returns the fmtp parameters of a codec with its VAD turned off.
+func disableFmtpVoiceActivityDetection(name, params string) string {
+	split := strings.Split(params, ";")
+	out := make([]string, 0, len(split)+1)
+	haveAnnexB := false
+	for _, param := range split {
+		key, _, _ := strings.Cut(strings.TrimSpace(param), "=")
+		switch {
+		case key == "":
+			continue
+		case name == "g729" && strings.EqualFold(key, "annexb"):
+			param = "annexb=no"
+			haveAnnexB = true
+		case name == "opus" && strings.EqualFold(key, "usedtx"):
+			continue
+		}
+		out = append(out, param)
+	}
+	if name == "g729" && !haveAnnexB {
+		out = append(out, "annexb=no")
+	}
+
+	return strings.Join(out, ";")
+}
+
 func getMaxMessageSize(desc *sdp.MediaDescription) uint32 {
 	for _, a := range desc.Attributes {
 		if strings.TrimSpace(a.Key) == "max-message-size" {
@@ -1231,3 +1516,22 @@ func getSctpInit(desc *sdp.MediaDescription) ([]byte, error) {
 
 	return nil, nil
 }
+
+// ssrcsFromSDP returns the SSRCs of the ssrc attributes of the media sections of desc.
+func ssrcsFromSDP(desc *sdp.SessionDescription) []SSRC {
+	var ssrcs []SSRC
+	for _, media := range desc.MediaDescriptions {
+		for _, attr := range media.Attributes {
+			if attr.Key != sdp.AttrKeySSRC {
+				continue
+			}
+
+			value, _, _ := strings.Cut(attr.Value, " ")
+			if ssrc, err := strconv.ParseUint(value, 10, 32); err == nil {
+				ssrcs = append(ssrcs, SSRC(ssrc))
+			}
+		}
+	}
+
+	return ssrcs
+}
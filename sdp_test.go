@@ -10,9 +10,11 @@ import (
 	"crypto/elliptic"
 	"crypto/rand"
 	"encoding/base64"
+	"slices"
 	"strings"
 	"testing"
 
+	"github.com/pion/logging"
 	"github.com/pion/sdp/v3"
 	"github.com/pion/transport/v4/test"
 	"github.com/stretchr/testify/assert"
@@ -659,6 +661,102 @@ func TestTrackDetailsFromSDP(t *testing.T) {
 	})
 }
 
+// SDP of legacy gateways, with ssrc groups and attributes in unusual orders.
+func TestTrackDetailsFromSDPLegacyEndpoints(t *testing.T) {
+	const header = `v=0
+o=- 3854220361 1 IN IP4 10.0.0.1
+s=-
+t=0 0
+a=fingerprint:sha-256 11:3F:1C:8D:D4:1D:8D:E7:E1:3E:AF:38:06:0D:1D:40:22:DC:FE:C9:93:E4:80:D8:0B:17:9F:2E:C1:CA:C8:3D
+a=group:BUNDLE 0
+m=video 9 UDP/TLS/RTP/SAVPF 96 97
+c=IN IP4 0.0.0.0
+a=setup:actpass
+a=ice-ufrag:yIgpPUMarFReduuM
+a=ice-pwd:VmnVaqCByWiOTatFoDBbMGhSFGlsxviz
+a=rtcp-mux
+a=rtpmap:96 VP8/90000
+a=rtcp-fb:96 nack
+a=rtcp-fb:96 nack pli
+a=rtpmap:97 rtx/90000
+a=fmtp:97 apt=96
+a=sendrecv
+`
+
+	for _, testCase := range []struct {
+		name     string
+		media    string
+		ssrc     SSRC
+		rtxSsrc  SSRC
+		streamID string
+		trackID  string
+	}{
+		{
+			name: "ssrc before mid and msid, RTX SSRC without ssrc lines",
+			media: `a=ssrc:1111 cname:gw-7f3a
+a=ssrc-group:FID 1111 2222
+a=mid:0
+a=msid:gw-stream gw-video
+`,
+			ssrc: 1111, rtxSsrc: 2222, streamID: "gw-stream", trackID: "gw-video",
+		},
+		{
+			name: "Only ssrc groups",
+			media: `a=mid:0
+a=msid:gw-stream gw-video
+a=ssrc-group:FID 1111 2222
+`,
+			ssrc: 1111, rtxSsrc: 2222, streamID: "gw-stream", trackID: "gw-video",
+		},
+		{
+			name: "Unsupported semantics",
+			media: `a=mid:0
+a=ssrc-group:SIM 1111
+a=ssrc-group:DUP 1111 3333
+a=ssrc-group:FID 1111 2222
+a=ssrc:1111 cname:gw-7f3a
+a=ssrc:1111 msid:gw-stream gw-video
+`,
+			ssrc: 1111, rtxSsrc: 2222, streamID: "gw-stream", trackID: "gw-video",
+		},
+		{
+			name: "Lowercase and malformed groups",
+			media: `a=mid:0
+a=ssrc-group:FID 1111
+a=ssrc-group:FID 1111 abc
+a=ssrc-group:
+a=ssrc-group:fid 1111 2222
+a=ssrc:1111 cname:gw-7f3a
+`,
+			ssrc: 1111, rtxSsrc: 2222,
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			parsed := &sdp.SessionDescription{}
+			require.NoError(t, parsed.UnmarshalString(header+testCase.media))
+
+			tracks := trackDetailsFromSDP(logging.NewDefaultLoggerFactory().NewLogger("test"), parsed)
+			require.Len(t, tracks, 1)
+			assert.Equal(t, "0", tracks[0].mid)
+			assert.Equal(t, []SSRC{testCase.ssrc}, tracks[0].ssrcs)
+			require.NotNil(t, tracks[0].rtxSsrc)
+			assert.Equal(t, testCase.rtxSsrc, *tracks[0].rtxSsrc)
+			assert.Equal(t, testCase.streamID, tracks[0].streamID)
+			assert.Equal(t, testCase.trackID, tracks[0].id)
+
+			pc, err := NewPeerConnection(Configuration{})
+			require.NoError(t, err)
+			assert.NoError(t, pc.SetRemoteDescription(SessionDescription{
+				Type: SDPTypeOffer,
+				SDP:  header + testCase.media,
+			}))
+			_, err = pc.CreateAnswer(nil)
+			assert.NoError(t, err)
+			assert.NoError(t, pc.Close())
+		})
+	}
+}
+
 func TestHaveApplicationMediaSection(t *testing.T) {
 	t.Run("Audio only", func(t *testing.T) {
 		descr := &SessionDescription{
@@ -761,6 +859,7 @@ func TestMediaDescriptionFingerprints(t *testing.T) {
 				nil,
 				0,
 				false,
+				CompatibilityProfile{},
 			)
 			assert.NoError(t, err)
 
@@ -816,6 +915,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			se.ignoreRidPauseForRecv,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -878,6 +978,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			se.ignoreRidPauseForRecv,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -938,6 +1039,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -979,6 +1081,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -1038,6 +1141,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.NoError(t, err)
 
@@ -1072,6 +1176,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -1103,6 +1208,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -1148,6 +1254,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -1189,6 +1296,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			&matchedBundle,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -1232,6 +1340,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			&matchedBundle,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -1266,6 +1375,7 @@ func TestPopulateSDP(t *testing.T) { //nolint:gocyclo,cyclop,maintidx
 			nil,
 			se.getSCTPMaxMessageSize(),
 			false,
+			CompatibilityProfile{},
 		)
 		assert.Nil(t, err)
 
@@ -1380,6 +1490,26 @@ func TestRtpExtensionsFromMediaDescription(t *testing.T) {
 	assert.Equal(t, extensions[sdp.SDESMidURI], 3)
 }
 
+func TestRtpExtensionDirectionsFromMediaDescription(t *testing.T) {
+	directions, err := rtpExtensionDirectionsFromMediaDescription(&sdp.MediaDescription{
+		MediaName: sdp.MediaName{
+			Media:   "video",
+			Formats: []string{"96"},
+		},
+		Attributes: []sdp.Attribute{
+			{Key: "extmap", Value: "1 " + sdp.ABSSendTimeURI},
+			{Key: "extmap", Value: "3/recvonly " + sdp.TransportCCURI},
+			{Key: "extmap", Value: "4/sendonly " + sdp.SDESMidURI},
+		},
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]RTPTransceiverDirection{
+		sdp.TransportCCURI: RTPTransceiverDirectionRecvonly,
+		sdp.SDESMidURI:     RTPTransceiverDirectionSendonly,
+	}, directions)
+}
+
 // Assert that FEC and RTX SSRCes are present if they are enabled in the MediaEngine.
 func Test_SSRC_Groups(t *testing.T) {
 	const offerWithRTX = `v=0
@@ -1585,3 +1715,166 @@ func TestSctpInit(t *testing.T) {
 		assert.ErrorAs(t, err, &corruptInputError)
 	})
 }
+
+func TestPopulateSDPCompatibilityProfile(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterCodec(RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{
+			MimeType:    MimeTypeH264,
+			ClockRate:   90000,
+			SDPFmtpLine: "level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+		},
+		PayloadType: 102,
+	}, RTPCodecTypeVideo))
+	api := NewAPI(WithMediaEngine(mediaEngine))
+
+	remoteCodecs := []RTPCodecParameters{{
+		RTPCodecCapability: RTPCodecCapability{
+			MimeType:    MimeTypeH264,
+			ClockRate:   90000,
+			SDPFmtpLine: "packetization-mode=1;profile-level-id=42e034",
+		},
+		PayloadType: 102,
+	}}
+
+	generate := func(profile CompatibilityProfile) []string {
+		transceiver := &RTPTransceiver{
+			kind:          RTPCodecTypeVideo,
+			api:           api,
+			codecs:        mediaEngine.videoCodecs,
+			recvonlySSRC:  1234,
+			recvonlyCNAME: "remote-compat",
+		}
+		transceiver.setDirection(RTPTransceiverDirectionRecvonly)
+
+		descr, err := populateSDP(
+			&sdp.SessionDescription{},
+			false,
+			[]DTLSFingerprint{},
+			false,
+			false,
+			true,
+			mediaEngine,
+			connectionRoleFromDtlsRole(defaultDtlsRoleOffer),
+			[]ICECandidate{},
+			ICEParameters{},
+			[]mediaSection{{id: "0", transceivers: []*RTPTransceiver{transceiver}, remoteCodecs: remoteCodecs}},
+			ICEGatheringStateComplete,
+			nil,
+			0,
+			false,
+			profile,
+		)
+		require.NoError(t, err)
+
+		raw, err := descr.Marshal()
+		require.NoError(t, err)
+
+		return strings.Split(strings.TrimSpace(string(raw)), "\r\n")
+	}
+
+	// diff returns the lines only present in a prefixed with "-" followed by the
+	// lines only present in b prefixed with "+".
+	diff := func(a, b []string) []string {
+		out := []string{}
+		for _, line := range a {
+			if !slices.Contains(b, line) {
+				out = append(out, "-"+line)
+			}
+		}
+		for _, line := range b {
+			if !slices.Contains(a, line) {
+				out = append(out, "+"+line)
+			}
+		}
+
+		return out
+	}
+
+	baseline := generate(CompatibilityProfile{})
+
+	for _, test := range []struct {
+		name    string
+		profile CompatibilityProfile
+		diff    []string
+	}{
+		{
+			name:    "None",
+			profile: CompatibilityProfile{},
+			diff:    []string{},
+		},
+		{
+			name:    "RTCPMuxOnly",
+			profile: CompatibilityProfile{RTCPMuxOnly: true},
+			diff:    []string{"+a=rtcp-mux-only"},
+		},
+		{
+			name:    "RecvonlySSRC",
+			profile: CompatibilityProfile{RecvonlySSRC: true},
+			diff:    []string{"+a=ssrc:1234 cname:remote-compat"},
+		},
+		{
+			name:    "OmitExtmapAllowMixed",
+			profile: CompatibilityProfile{OmitExtmapAllowMixed: true},
+			diff:    []string{"-a=extmap-allow-mixed"},
+		},
+		{
+			name:    "EchoH264ProfileLevelID",
+			profile: CompatibilityProfile{EchoH264ProfileLevelID: true},
+			diff: []string{
+				"-a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+				"+a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e034",
+			},
+		},
+		{
+			name:    "Strict",
+			profile: CompatibilityProfileStrict,
+			diff:    []string{"+a=rtcp-mux-only"},
+		},
+		{
+			name:    "LegacyTelepresence",
+			profile: CompatibilityProfileLegacyTelepresence,
+			diff: []string{
+				"-a=extmap-allow-mixed",
+				"-a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e01f",
+				"+a=fmtp:102 level-asymmetry-allowed=1;packetization-mode=1;profile-level-id=42e034",
+				"+a=ssrc:1234 cname:remote-compat",
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.diff, diff(baseline, generate(test.profile)))
+		})
+	}
+}
+
+func TestEchoH264ProfileLevelID(t *testing.T) {
+	remoteCodecs := []RTPCodecParameters{
+		{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000},
+			PayloadType:        96,
+		},
+		{
+			RTPCodecCapability: RTPCodecCapability{
+				MimeType:    MimeTypeH264,
+				ClockRate:   90000,
+				SDPFmtpLine: "profile-level-id=640c1f",
+			},
+			PayloadType: 102,
+		},
+	}
+	local := "packetization-mode=1; profile-level-id=42e01f"
+
+	assert.Equal(t, "packetization-mode=1;profile-level-id=640c1f", echoH264ProfileLevelID(local, 102, remoteCodecs))
+	assert.Equal(t, local, echoH264ProfileLevelID(local, 96, remoteCodecs))
+	assert.Equal(t, local, echoH264ProfileLevelID(local, 104, remoteCodecs))
+	assert.Equal(t, "packetization-mode=1", echoH264ProfileLevelID("packetization-mode=1", 102, remoteCodecs))
+}
+
+func TestNewApplicationMid(t *testing.T) {
+	assert.Equal(t, "0", newApplicationMid(nil))
+	assert.Equal(t, "2", newApplicationMid([]mediaSection{{id: "0"}, {id: "1"}}))
+	assert.Equal(t, "2", newApplicationMid([]mediaSection{{id: "audio-stream-1"}, {id: "video"}}))
+	// The remote chose mids the numbering would collide with
+	assert.Equal(t, "4", newApplicationMid([]mediaSection{{id: "2"}, {id: "audio"}, {id: "3"}}))
+}
@@ -56,6 +56,24 @@ func (sd *SessionDescription) Unmarshal() (*sdp.SessionDescription, error) {
 	return sd.parsed, nil
 }
 
+// clone returns a deep copy of sd, so the description held by the PeerConnection can't be
+// modified through it.
+func (sd *SessionDescription) clone() *SessionDescription {
+	if sd == nil {
+		return nil
+	}
+
+	clone := &SessionDescription{Type: sd.Type, SDP: sd.SDP}
+	if sd.parsed != nil {
+		parsed := &sdp.SessionDescription{}
+		if err := parsed.UnmarshalString(sd.SDP); err == nil {
+			clone.parsed = parsed
+		}
+	}
+
+	return clone
+}
+
 func hasICETrickleOption(desc *sdp.SessionDescription) bool {
 	if value, ok := desc.Attribute(sdp.AttrKeyICEOptions); ok && hasTrickleOptionValue(value) {
 		return true
@@ -9,8 +9,11 @@ import (
 	"context"
 	"crypto/x509"
 	"errors"
+	"fmt"
 	"io"
 	"net"
+	"net/netip"
+	"slices"
 	"time"
 
 	"github.com/pion/dtls/v3"
@@ -35,6 +38,10 @@ type SettingEngine struct {
 	detach struct {
 		DataChannels bool
 	}
+	dscp struct {
+		enabled            bool
+		audio, video, data uint8
+	}
 	timeout struct {
 		ICEDisconnectedTimeout    *time.Duration
 		ICEFailedTimeout          *time.Duration
@@ -44,9 +51,18 @@ type SettingEngine struct {
 		ICEPrflxAcceptanceMinWait *time.Duration
 		ICERelayAcceptanceMinWait *time.Duration
 		ICESTUNGatherTimeout      *time.Duration
+		ICEMulticastDNSTimeout    *time.Duration
+	}
+	renomination   renominationSettings
+	keyframeGating struct {
+		enabled bool
+		timeout time.Duration
 	}
-	renomination renominationSettings
-	candidates   struct {
+	jitterBuffer struct {
+		enabled bool
+		latency time.Duration
+	}
+	candidates struct {
 		ICELite                  bool
 		ICENetworkTypes          []NetworkType
 		InterfaceFilter          func(string) (keep bool)
@@ -57,9 +73,14 @@ type SettingEngine struct {
 		addressRewriteRules      []ice.AddressRewriteRule
 		MulticastDNSMode         ice.MulticastDNSMode
 		MulticastDNSHostName     string
+		MulticastDNSResolver     ICEMulticastDNSResolver
 		UsernameFragment         string
 		Password                 string //nolint:gosec // not a secret.
 		IncludeLoopbackCandidate bool
+		restartCredentials       func() (usernameFragment, password string)
+		staticLocalCandidates    []ICECandidate
+		disableDynamicGathering  bool
+		priorityFunction         func(ICECandidateType, string, string, uint32) uint32
 	}
 	replayProtection struct {
 		DTLS  *uint
@@ -88,6 +109,7 @@ type SettingEngine struct {
 		maxReceiveBufferSize uint32
 		enableZeroChecksum   bool
 		rtoMax               time.Duration
+		rttUpdateThreshold   time.Duration
 		maxMessageSize       uint32
 		minCwnd              uint32
 		fastRtxWnd           uint32
@@ -96,6 +118,7 @@ type SettingEngine struct {
 	}
 	sdpMediaLevelFingerprints                 bool
 	answeringDTLSRole                         DTLSRole
+	iceRole                                   ICERole
 	disableCertificateFingerprintVerification bool
 	disableSRTPReplayProtection               bool
 	disableSRTCPReplayProtection              bool
@@ -107,16 +130,44 @@ type SettingEngine struct {
 	iceProxyDialer                            proxy.Dialer
 	iceDisableActiveTCP                       bool
 	iceBindingRequestHandler                  func(m *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool //nolint:lll
+	stunBindingRequestHandler                 STUNBindingRequestHandler
+	iceBindingRequestFilter                   func(m *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool //nolint:lll
 	disableMediaEngineCopy                    bool
 	disableMediaEngineMultipleCodecs          bool
+	disableSimulcastResumeKeyframeRequest     bool
+	enableRTCPReportBatching                  bool
+	disabledRTXKinds                          []RTPCodecType
 	srtpProtectionProfiles                    []dtls.SRTPProtectionProfile
 	receiveMTU                                uint
+	srtpReceiveBufferSize                     int
+	srtcpReceiveBufferSize                    int
+	receiverPreBindBufferSize                 int
+	rtcpMaxPacketSize                         int
 	iceMaxBindingRequests                     *uint16
+	iceCheckInterval                          *time.Duration
+	iceNominationMode                         ICENominationMode
+	iceGatheringPolicy                        ICEGatheringPolicy
+	iceNetworkMonitorInterval                 *time.Duration
 	fireOnTrackBeforeFirstRTP                 bool
+	trackFirstPacketTimeout                   time.Duration
 	disableCloseByDTLS                        bool
 	dataChannelBlockWrite                     bool
 	handleUndeclaredSSRCWithoutAnswer         bool
 	ignoreRidPauseForRecv                     bool
+	handshakeConcurrencyLimit                 int
+	rtcpReportInterval                        time.Duration
+	disableTWCCFeedback                       bool
+	twccFeedbackInterval                      time.Duration
+	compatibilityProfile                      CompatibilityProfile
+	trackIdentifierPolicy                     trackIdentifierPolicy
+	unknownSSRC                               struct {
+		handler             UnknownSSRCHandler
+		bufferedPacketLimit int
+	}
+	simulcastProbe struct {
+		streamLimit  int
+		limitHandler func(evicted SSRC)
+	}
 }
 
 type renominationSettings struct {
@@ -192,6 +243,10 @@ func (e *SettingEngine) SetICERenomination(options ...RenominationOption) error
 	return nil
 }
 
+func (e *SettingEngine) isRTXDisabled(kind RTPCodecType) bool {
+	return slices.Contains(e.disabledRTXKinds, kind)
+}
+
 func (e *SettingEngine) getSCTPMaxMessageSize() uint32 {
 	if e.sctp.maxMessageSize != 0 {
 		return e.sctp.maxMessageSize
@@ -200,6 +255,33 @@ func (e *SettingEngine) getSCTPMaxMessageSize() uint32 {
 	return defaultMaxSCTPMessageSize
 }
 
+func (e *SettingEngine) getUnknownSSRCBufferedPacketLimit() int {
+	if e.unknownSSRC.bufferedPacketLimit > 0 {
+		return e.unknownSSRC.bufferedPacketLimit
+	}
+
+	return defaultUnknownSSRCBufferedPacketLimit
+}
+
+func (e *SettingEngine) getSimulcastProbeStreamLimit() int {
+	if e.simulcastProbe.streamLimit > 0 {
+		return e.simulcastProbe.streamLimit
+	}
+
+	return defaultSimulcastProbeStreamLimit
+}
+
+func (e *SettingEngine) getICEMulticastDNSTimeout() time.Duration {
+	switch {
+	case e.timeout.ICEMulticastDNSTimeout != nil:
+		return *e.timeout.ICEMulticastDNSTimeout
+	case e.timeout.ICESTUNGatherTimeout != nil:
+		return *e.timeout.ICESTUNGatherTimeout
+	default:
+		return defaultICEMulticastDNSTimeout
+	}
+}
+
 // getReceiveMTU returns the configured MTU. If SettingEngine's MTU is configured to 0 it returns the default.
 func (e *SettingEngine) getReceiveMTU() uint {
 	if e.receiveMTU != 0 {
@@ -296,6 +378,20 @@ func (e *SettingEngine) SetLite(lite bool) {
 	e.candidates.ICELite = lite
 }
 
+// SetICERole forces the ICE role of the agent, overriding the role passed to ICETransport.Start
+// that is derived from the offer/answer exchange and ICE lite. The DTLS role is still derived
+// from the offer/answer exchange. If both agents force the same role the conflict is resolved
+// as described in RFC 8445 Section 7.3.1.1, ICETransport.Role returns the resulting role.
+func (e *SettingEngine) SetICERole(role ICERole) error {
+	if role != ICERoleControlling && role != ICERoleControlled {
+		return errSettingEngineSetICERole
+	}
+
+	e.iceRole = role
+
+	return nil
+}
+
 // SetNetworkTypes configures what types of candidate networks are supported
 // during local and server reflexive gathering.
 func (e *SettingEngine) SetNetworkTypes(candidateTypes []NetworkType) {
@@ -318,6 +414,20 @@ func (e *SettingEngine) SetIPFilter(filter func(net.IP) (keep bool)) {
 	e.candidates.IPFilter = filter
 }
 
+// SetCandidatePriorityFunction sets a function computing the priority of local ICE candidates.
+// It is called with the candidate type, network (e.g. udp4), interface name and the priority
+// computed by the standard formula, and returns the priority to use. The interface name is
+// empty if it can't be determined, e.g. for mDNS candidates.
+//
+// The priority is used for the candidates emitted by OnICECandidate and in the SDP, so the
+// remote agent's pair priorities are based on it. Remote candidates keep their signaled priorities.
+// The local agent's own connectivity checks still use the priority from the standard formula.
+func (e *SettingEngine) SetCandidatePriorityFunction(
+	priorityFunction func(candidateType ICECandidateType, network string, intf string, defaultPriority uint32) uint32,
+) {
+	e.candidates.priorityFunction = priorityFunction
+}
+
 // SetRemoteIPFilter sets the filtering function for remote candidate IP addresses.
 // This can be used to whitelist or blacklist remote candidate IPs before they are
 // added to the ICE agent.
@@ -422,6 +532,27 @@ func (e *SettingEngine) SetNet(net transport.Net) {
 	e.net = net
 }
 
+// SetDSCP sets the DSCP the sockets of the ICE agent mark the packets with, by the kind of
+// traffic, e.g. 46 (EF) for audio and 34 (AF41) for video. The sockets are those of the Net
+// passed to pion/ice, the value is set with IP_TOS or IPV6_TCLASS and is only applied when they
+// are sockets of the operating system. The sockets of a UDPMux or TCPMux are owned by the
+// application and are not marked.
+//
+// A PeerConnection sends all its traffic through the same sockets, so they are marked with
+// the highest value of the kinds it sends: audio and video for the transceivers that send,
+// data once a data channel is negotiated. This is updated as descriptions are set, until then
+// the highest of the three values is used.
+func (e *SettingEngine) SetDSCP(audio, video, data uint8) error {
+	if audio > maxDSCP || video > maxDSCP || data > maxDSCP {
+		return errSettingEngineSetDSCP
+	}
+
+	e.dscp.enabled = true
+	e.dscp.audio, e.dscp.video, e.dscp.data = audio, video, data
+
+	return nil
+}
+
 // SetICEMulticastDNSMode controls if pion/ice queries and generates mDNS ICE Candidates.
 func (e *SettingEngine) SetICEMulticastDNSMode(multicastDNSMode ice.MulticastDNSMode) {
 	e.candidates.MulticastDNSMode = multicastDNSMode
@@ -435,15 +566,93 @@ func (e *SettingEngine) SetMulticastDNSHostName(hostName string) {
 	e.candidates.MulticastDNSHostName = hostName
 }
 
+// SetICEMulticastDNSTimeout sets how long resolving the host name of a remote mDNS candidate
+// may take before the candidate is reported with OnICECandidateError. It defaults to the
+// STUN gather timeout.
+func (e *SettingEngine) SetICEMulticastDNSTimeout(t time.Duration) {
+	e.timeout.ICEMulticastDNSTimeout = &t
+}
+
+// SetICEMulticastDNSResolver sets a resolver for the host names of remote mDNS candidates,
+// which is used instead of sending mDNS queries. It is also used if the MulticastDNSMode
+// is ice.MulticastDNSModeDisabled, so names can be resolved without multicast.
+func (e *SettingEngine) SetICEMulticastDNSResolver(resolver ICEMulticastDNSResolver) {
+	e.candidates.MulticastDNSResolver = resolver
+}
+
 // SetICECredentials sets a staic uFrag/uPwd to be used by pion/ice
 //
 // This is useful if you want to do signalless WebRTC session,
 // or having a reproducible environment with static credentials.
+// The values must follow RFC 8445 Section 5.3: the uFrag must be 4 to 256 and the
+// uPwd 22 to 256 characters of ALPHA, DIGIT, "+" or "/". This is validated when a
+// PeerConnection is constructed. An empty value is generated randomly instead.
+//
+// The credentials are only used for the initial gathering. An ICE restart rotates
+// them to random values, unless SetICERestartCredentials is used.
 func (e *SettingEngine) SetICECredentials(usernameFragment, password string) {
 	e.candidates.UsernameFragment = usernameFragment
 	e.candidates.Password = password
 }
 
+// SetICERestartCredentials sets a callback that provides the uFrag/uPwd used after an ICE restart.
+// The returned values are validated like the ones given to SetICECredentials, empty values are
+// generated randomly.
+func (e *SettingEngine) SetICERestartCredentials(f func() (usernameFragment, password string)) {
+	e.candidates.restartCredentials = f
+}
+
+// iceCredentialsForRestart returns the uFrag/uPwd to use for an ICE restart.
+func (e *SettingEngine) iceCredentialsForRestart() (string, string, error) {
+	if e.candidates.restartCredentials == nil {
+		return "", "", nil
+	}
+
+	usernameFragment, password := e.candidates.restartCredentials()
+	if err := validateICECredentials(usernameFragment, password); err != nil {
+		return "", "", err
+	}
+
+	return usernameFragment, password, nil
+}
+
+const (
+	iceUsernameFragmentMinLength = 4
+	icePasswordMinLength         = 22
+	iceCredentialMaxLength       = 256
+)
+
+// validateICECredentials validates ice-ufrag and ice-pwd as defined in RFC 8445 Section 5.3.
+// Empty values are allowed, they are generated by the ICE Agent.
+func validateICECredentials(usernameFragment, password string) error {
+	if usernameFragment != "" && !isValidICECredential(usernameFragment, iceUsernameFragmentMinLength) {
+		return fmt.Errorf("%w: %q", errSettingEngineICEUsernameFragment, usernameFragment)
+	}
+
+	if password != "" && !isValidICECredential(password, icePasswordMinLength) {
+		return errSettingEngineICEPassword
+	}
+
+	return nil
+}
+
+// isValidICECredential checks the length and that all characters are ice-char.
+func isValidICECredential(value string, minLength int) bool {
+	if len(value) < minLength || len(value) > iceCredentialMaxLength {
+		return false
+	}
+
+	for _, c := range value {
+		isAlpha := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+		isDigit := c >= '0' && c <= '9'
+		if !isAlpha && !isDigit && c != '+' && c != '/' {
+			return false
+		}
+	}
+
+	return true
+}
+
 // DisableCertificateFingerprintVerification disables fingerprint verification after DTLS Handshake has finished.
 func (e *SettingEngine) DisableCertificateFingerprintVerification(isDisabled bool) {
 	e.disableCertificateFingerprintVerification = isDisabled
@@ -455,6 +664,8 @@ func (e *SettingEngine) SetDTLSReplayProtectionWindow(n uint) {
 }
 
 // SetSRTPReplayProtectionWindow sets a replay attack protection window size of SRTP session.
+// Received packets that were already received, or are older than the window, are discarded
+// and counted in TransportStats.SRTPPacketsReplayDiscarded. The default window is 64 packets.
 func (e *SettingEngine) SetSRTPReplayProtectionWindow(n uint) {
 	e.disableSRTPReplayProtection = false
 	e.replayProtection.SRTP = &n
@@ -466,7 +677,8 @@ func (e *SettingEngine) SetSRTCPReplayProtectionWindow(n uint) {
 	e.replayProtection.SRTCP = &n
 }
 
-// DisableSRTPReplayProtection disables SRTP replay protection.
+// DisableSRTPReplayProtection disables SRTP replay protection. Use it to inject previously
+// sent packets again, like recordings replayed with their original sequence numbers.
 func (e *SettingEngine) DisableSRTPReplayProtection(isDisabled bool) {
 	e.disableSRTPReplayProtection = isDisabled
 }
@@ -497,6 +709,99 @@ func (e *SettingEngine) SetICEUDPMux(udpMux ice.UDPMux) {
 	e.iceUDPMux = udpMux
 }
 
+// SetStaticLocalCandidates sets the UDP host or server reflexive candidates that are
+// published instead of the host candidates of the UDPMux, for deployments where the
+// public address of the UDPMux is known ahead of time, like a static port mapping.
+// Only the address and the port of the candidates are used: they are published as host
+// candidates, with the priority and foundation of the ICE Agent. Their port must be the
+// one the UDPMux listens on, set with SetICEUDPMux. This is validated when a PeerConnection
+// is constructed. The candidates can't be combined with SetICEAddressRewriteRules or
+// SetNAT1To1IPs.
+//
+// Use DisableDynamicGathering to only publish these candidates. The UDPMux must then only
+// listen on IP families that have static local candidates.
+func (e *SettingEngine) SetStaticLocalCandidates(candidates []ICECandidate) error {
+	for i, candidate := range candidates {
+		if reason := invalidStaticLocalCandidate(candidate); reason != "" {
+			return fmt.Errorf("%w: candidates[%d] %s", errSettingEngineStaticLocalCandidate, i, reason)
+		}
+	}
+
+	e.candidates.staticLocalCandidates = slices.Clone(candidates)
+
+	return nil
+}
+
+// invalidStaticLocalCandidate returns why candidate can't be a static local candidate, or
+// an empty string if it can.
+func invalidStaticLocalCandidate(candidate ICECandidate) string {
+	switch {
+	case candidate.Typ != ICECandidateTypeHost && candidate.Typ != ICECandidateTypeSrflx:
+		return fmt.Sprintf("has unsupported type %s", candidate.Typ)
+	case candidate.Protocol != ICEProtocolUDP:
+		return fmt.Sprintf("has unsupported protocol %s", candidate.Protocol)
+	case candidate.Port == 0:
+		return "has no port"
+	}
+
+	if addr, err := netip.ParseAddr(candidate.Address); err != nil || addr.IsUnspecified() {
+		return fmt.Sprintf("has no IP address %q", candidate.Address)
+	}
+
+	return ""
+}
+
+// DisableDynamicGathering stops the ICE Agent from gathering candidates itself: no sockets
+// are opened besides the UDPMux, and no STUN or TURN servers are used. Only the candidates
+// set with SetStaticLocalCandidates are published, which is validated when a PeerConnection
+// is constructed, and gathering completes right away.
+func (e *SettingEngine) DisableDynamicGathering(isDisabled bool) {
+	e.candidates.disableDynamicGathering = isDisabled
+}
+
+// validateStaticLocalCandidates validates the static local candidates against the UDPMux.
+func (e *SettingEngine) validateStaticLocalCandidates() error {
+	candidates := e.candidates.staticLocalCandidates
+	if len(candidates) == 0 {
+		if e.candidates.disableDynamicGathering {
+			return errSettingEngineDynamicGatheringDisabled
+		}
+
+		return nil
+	}
+
+	switch {
+	case e.iceUDPMux == nil:
+		return errSettingEngineStaticLocalCandidatesUDPMux
+	case len(e.candidates.NAT1To1IPs) != 0 || len(e.candidates.addressRewriteRules) != 0:
+		return errAddressRewriteWithStaticCandidates
+	}
+
+	for _, addr := range e.iceUDPMux.GetListenAddresses() {
+		udpAddr, ok := addr.(*net.UDPAddr)
+		if !ok {
+			return fmt.Errorf("%w: UDPMux listens on %s", errSettingEngineStaticLocalCandidatePort, addr)
+		}
+
+		isIPv4 := udpAddr.IP.To4() != nil
+		hasFamily := false
+		for i, candidate := range candidates {
+			if int(candidate.Port) != udpAddr.Port {
+				return fmt.Errorf("%w: candidates[%d] has port %d, the UDPMux listens on %s",
+					errSettingEngineStaticLocalCandidatePort, i, candidate.Port, udpAddr)
+			}
+			hasFamily = hasFamily || netip.MustParseAddr(candidate.Address).Unmap().Is4() == isIPv4
+		}
+
+		// The host candidates of the UDPMux are only replaced by candidates of the same family
+		if !hasFamily && e.candidates.disableDynamicGathering {
+			return fmt.Errorf("%w: the UDPMux listens on %s", errSettingEngineStaticLocalCandidateFamily, udpAddr)
+		}
+	}
+
+	return nil
+}
+
 // SetICEProxyDialer sets the proxy dialer interface based on golang.org/x/net/proxy.
 func (e *SettingEngine) SetICEProxyDialer(d proxy.Dialer) {
 	e.iceProxyDialer = d
@@ -504,10 +809,76 @@ func (e *SettingEngine) SetICEProxyDialer(d proxy.Dialer) {
 
 // SetICEMaxBindingRequests sets the maximum amount of binding requests
 // that can be sent on a candidate before it is considered invalid.
+// It must be at least 1, this is validated when a PeerConnection is constructed.
 func (e *SettingEngine) SetICEMaxBindingRequests(d uint16) {
 	e.iceMaxBindingRequests = &d
 }
 
+// SetICECheckInterval sets how often the ICE Agent sends connectivity checks while
+// connecting. Default is 200 milliseconds. Lower values speed up connection setup
+// at the cost of more STUN traffic. It must be greater than zero, this is validated
+// when a PeerConnection is constructed.
+func (e *SettingEngine) SetICECheckInterval(interval time.Duration) {
+	e.iceCheckInterval = &interval
+}
+
+// SetICENominationMode selects between regular and aggressive nomination.
+// Aggressive nomination removes the candidate type acceptance minimum waits, so the
+// first candidate pair that succeeds is nominated. Acceptance waits set explicitly
+// with SetHostAcceptanceMinWait and friends still take precedence.
+func (e *SettingEngine) SetICENominationMode(mode ICENominationMode) {
+	e.iceNominationMode = mode
+}
+
+// EnableContinualGathering selects whether candidates are gathered once, or continually
+// as network interfaces change. With ICEGatheringPolicyGatherContinually the ICE agent
+// polls the interfaces of the configured Net, and trickles candidates for new addresses
+// through OnICECandidate. Gathering never completes in that mode, so OnICECandidate is
+// never called with nil and GatheringCompletePromise never resolves. Candidates of
+// addresses that disappear are pruned from the LocalDescription and fired by
+// PeerConnection.OnICECandidateRemoved, so they can be withdrawn from the remote.
+func (e *SettingEngine) EnableContinualGathering(policy ICEGatheringPolicy) {
+	e.iceGatheringPolicy = policy
+}
+
+// SetICENetworkMonitorInterval sets how often the network interfaces are polled when
+// gathering continually. Default is 2 seconds. It must be greater than zero, this is
+// validated when a PeerConnection is constructed.
+func (e *SettingEngine) SetICENetworkMonitorInterval(interval time.Duration) {
+	e.iceNetworkMonitorInterval = &interval
+}
+
+// validateICEGathering validates the ICE gathering policy settings.
+func (e *SettingEngine) validateICEGathering() error {
+	if e.iceGatheringPolicy != ICEGatheringPolicyGatherOnce &&
+		e.iceGatheringPolicy != ICEGatheringPolicyGatherContinually {
+		return errSettingEngineICEGatheringPolicy
+	}
+
+	if e.iceNetworkMonitorInterval != nil && *e.iceNetworkMonitorInterval <= 0 {
+		return errSettingEngineICENetworkMonitorInterval
+	}
+
+	return nil
+}
+
+// validateICEConnectivityChecks validates the ICE connectivity check settings.
+func (e *SettingEngine) validateICEConnectivityChecks() error {
+	if e.iceMaxBindingRequests != nil && *e.iceMaxBindingRequests == 0 {
+		return errSettingEngineICEMaxBindingRequests
+	}
+
+	if e.iceCheckInterval != nil && *e.iceCheckInterval <= 0 {
+		return errSettingEngineICECheckInterval
+	}
+
+	if e.iceNominationMode != ICENominationModeRegular && e.iceNominationMode != ICENominationModeAggressive {
+		return errSettingEngineICENominationMode
+	}
+
+	return nil
+}
+
 // DisableActiveTCP disables using active TCP for ICE. Active TCP is enabled by default.
 func (e *SettingEngine) DisableActiveTCP(isDisabled bool) {
 	e.iceDisableActiveTCP = isDisabled
@@ -536,6 +907,92 @@ func (e *SettingEngine) SetReceiveMTU(receiveMTU uint) {
 	e.receiveMTU = receiveMTU
 }
 
+// SetSRTPReceiveBufferSize sets the size in bytes of the buffer that holds the RTP packets
+// of each SSRC until they are read. Packets that arrive while the buffer is full are
+// dropped and counted in InboundRTPStreamStats.PacketsDiscarded. Leave this 0 for the
+// default of 1MB. It is ignored when BufferFactory is set.
+func (e *SettingEngine) SetSRTPReceiveBufferSize(bytes int) {
+	e.srtpReceiveBufferSize = bytes
+}
+
+// SetSRTCPReceiveBufferSize sets the size in bytes of the buffer that holds the RTCP packets
+// of each SSRC until they are read. Leave this 0 for the default of 100KB. It is ignored
+// when BufferFactory is set.
+func (e *SettingEngine) SetSRTCPReceiveBufferSize(bytes int) {
+	e.srtcpReceiveBufferSize = bytes
+}
+
+// SetReceiverPreBindBufferSize sets how many RTP packets of a signaled SSRC are read ahead
+// of the application. Once the stream of the SSRC is bound, its packets are read through the
+// interceptors and kept until TrackRemote.Read is first called, so the packets that arrive
+// before OnTrack is handled aren't delayed for the interceptors. The oldest packets are
+// dropped once the buffer is full, they are counted in InboundRTPStreamStats.PacketsDiscarded.
+// Leave this 0 for the default of 512 packets, a negative size disables the buffer.
+func (e *SettingEngine) SetReceiverPreBindBufferSize(packets int) {
+	e.receiverPreBindBufferSize = packets
+}
+
+func (e *SettingEngine) getReceiverPreBindBufferSize() int {
+	if e.receiverPreBindBufferSize == 0 {
+		return defaultReceiverPreBindBufferSize
+	}
+
+	return max(e.receiverPreBindBufferSize, 0)
+}
+
+// SetRTCPMaxPacketSize sets the largest RTCP datagram that is sent, its SRTCP authentication
+// tag and index included, so RTCP isn't dropped by a path with a smaller MTU. Larger RTCP is
+// split across datagrams: the report blocks, the chunks of Source Descriptions and the blocks
+// of Extended Reports are spread, and each datagram is a valid compound packet unless
+// reduced-size RTCP was negotiated. The splits are counted in TransportStats.RTCPPacketsSplit.
+// Leave this 0 for the default of 1200 bytes.
+func (e *SettingEngine) SetRTCPMaxPacketSize(bytes int) {
+	e.rtcpMaxPacketSize = bytes
+}
+
+func (e *SettingEngine) getRTCPMaxPacketSize() int {
+	if e.rtcpMaxPacketSize > 0 {
+		return e.rtcpMaxPacketSize
+	}
+
+	return defaultRTCPMaxPacketSize
+}
+
+// getRTCPMaxBatchSize returns the largest RTCP that is batched before it is protected: the
+// SRTCP overhead of every protection profile that can be negotiated fits in
+// getRTCPMaxPacketSize.
+func (e *SettingEngine) getRTCPMaxBatchSize() int {
+	profiles := e.srtpProtectionProfiles
+	if len(profiles) == 0 {
+		profiles = defaultSrtpProtectionProfiles()
+	}
+
+	overhead := srtcpIndexSize
+	for _, dtlsProfile := range profiles {
+		if profile, err := srtpProtectionProfileFromDTLS(dtlsProfile); err == nil {
+			overhead = max(overhead, srtcpOverhead(profile))
+		}
+	}
+
+	return e.getRTCPMaxPacketSize() - overhead
+}
+
+func (e *SettingEngine) getSRTPReceiveBufferSize() int {
+	if e.srtpReceiveBufferSize > 0 {
+		return e.srtpReceiveBufferSize
+	}
+
+	return defaultSRTPReceiveBufferSize
+}
+
+func (e *SettingEngine) getSRTCPReceiveBufferSize() int {
+	if e.srtcpReceiveBufferSize > 0 {
+		return e.srtcpReceiveBufferSize
+	}
+
+	return defaultSRTCPReceiveBufferSize
+}
+
 // SetDTLSRetransmissionInterval sets the retranmission interval for DTLS.
 func (e *SettingEngine) SetDTLSRetransmissionInterval(interval time.Duration) {
 	e.dtls.retransmissionInterval = interval
@@ -668,6 +1125,12 @@ func (e *SettingEngine) SetSCTPRTOMax(rtoMax time.Duration) {
 	e.sctp.rtoMax = rtoMax
 }
 
+// SetSCTPRTTUpdateThreshold sets by how much the smoothed RTT of the SCTP association must
+// change before SCTPTransport.OnRTTUpdate is called again. Leave this 0 to report every change.
+func (e *SettingEngine) SetSCTPRTTUpdateThreshold(threshold time.Duration) {
+	e.sctp.rttUpdateThreshold = threshold
+}
+
 // SetSCTPMinCwnd sets the minimum congestion window size. The congestion window
 // will not be smaller than this value during congestion control.
 func (e *SettingEngine) SetSCTPMinCwnd(minCwnd uint32) {
@@ -689,12 +1152,47 @@ func (e *SettingEngine) SetSCTPCwndCAStep(cwndCAStep uint32) {
 // - Log incoming Binding Requests for debugging
 // - Implement draft-thatcher-ice-renomination
 // - Implement custom CandidatePair switching logic.
+// Returning true makes the pair of the request the selected one. The handler is fired once
+// the ICE Agent answered the request, so it can't reject it. Use SetICEBindingRequestFilter
+// to answer requests with a 403 (Forbidden) error response instead, or SetSTUNBindingRequestHandler
+// to drop them.
+// A panic of the handler is logged and doesn't switch the selected pair.
 func (e *SettingEngine) SetICEBindingRequestHandler(
 	bindingRequestHandler func(m *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool,
 ) {
 	e.iceBindingRequestHandler = bindingRequestHandler
 }
 
+// SetSTUNBindingRequestHandler sets a callback that is fired for every inbound STUN BindingRequest
+// on the UDP sockets, UDPMux and TCPMux connections of this API, before the ICE Agent authenticates it.
+// Returning true drops the request, as if it was never received, the remote never gets a response
+// and the candidate pair of the request never succeeds.
+// This allows users to do things like
+// - Rate limit Binding Requests
+// - Route or authenticate sessions by the USERNAME or custom attributes.
+// Unlike SetICEBindingRequestHandler it also sees requests the ICE Agent would discard.
+// A panic of the handler is logged and drops the request.
+func (e *SettingEngine) SetSTUNBindingRequestHandler(handler STUNBindingRequestHandler) {
+	e.stunBindingRequestHandler = handler
+}
+
+// SetICEBindingRequestFilter sets a callback that is fired for every inbound STUN BindingRequest
+// like the one of SetSTUNBindingRequestHandler, before it, with the arguments of the handler of
+// SetICEBindingRequestHandler. It is fired before the ICE Agent checks the request, so local and
+// remote are the candidates of the ICE Agent with the addresses of the request, remote is a new
+// peer reflexive candidate if the ICE Agent doesn't know it yet, and pair only pairs them.
+// Returning false rejects the request: it is answered with a 403 (Forbidden) error response,
+// authenticated with the local ICE password, and the ICE Agent never sees it. Unlike a dropped
+// request, the remote learns right away that the candidate pair failed, see RFC 8445
+// Section 7.2.5.2.4. SetICEBindingRequestHandler can't reject requests, its return value
+// switches the selected pair and the ICE Agent fires it once it answered the request.
+// A panic of the handler is logged and drops the request.
+func (e *SettingEngine) SetICEBindingRequestFilter(
+	filter func(m *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool,
+) {
+	e.iceBindingRequestFilter = filter
+}
+
 // SetFireOnTrackBeforeFirstRTP sets if firing the OnTrack event should happen
 // before any RTP packets are received. Setting this to true will
 // have the Track's Codec and PayloadTypes be initially set to their
@@ -704,9 +1202,19 @@ func (e *SettingEngine) SetFireOnTrackBeforeFirstRTP(fireOnTrackBeforeFirstRTP b
 	e.fireOnTrackBeforeFirstRTP = fireOnTrackBeforeFirstRTP
 }
 
+// SetTrackFirstPacketTimeout sets how long a TrackRemote waits for its first packet once
+// OnTrack fired before it, see SetFireOnTrackBeforeFirstRTP. For each timeout without a
+// packet Read and ReadRTP return ErrTrackStarvation once and TrackRemote.OnStarvation is
+// fired, the following reads keep waiting. This tells a remote that never forwards the
+// track apart from a failed connection. 0, the default, waits forever.
+func (e *SettingEngine) SetTrackFirstPacketTimeout(timeout time.Duration) {
+	e.trackFirstPacketTimeout = timeout
+}
+
 // DisableCloseByDTLS sets if the connection should be closed when dtls transport is closed.
 // Setting this to true will keep the connection open when dtls transport is closed
-// and relies on the ice failed state to detect the connection is interrupted.
+// and relies on the ice failed state to detect the connection is interrupted, or on
+// the loss of the SCTP association when there are DataChannels.
 func (e *SettingEngine) DisableCloseByDTLS(isEnabled bool) {
 	e.disableCloseByDTLS = isEnabled
 }
@@ -717,8 +1225,161 @@ func (e *SettingEngine) SetHandleUndeclaredSSRCWithoutAnswer(handleUndeclaredSSR
 	e.handleUndeclaredSSRCWithoutAnswer = handleUndeclaredSSRCWithoutAnswer
 }
 
+// SetHandshakeConcurrencyLimit limits how many DTLS handshakes run at the same time
+// across all PeerConnections created from the API this SettingEngine is passed to.
+// Handshakes over the limit wait in FIFO order, while their ICE Agent keeps the
+// connection alive. This protects the CPU during reconnect storms. The DTLS connect
+// context only starts once a handshake leaves the queue. Both ends of a connection
+// should not be limited by the same API, as they could wait on each other until the
+// handshake times out. Zero or less means no limit.
+// API.HandshakeQueueDepth reports how many handshakes are waiting.
+func (e *SettingEngine) SetHandshakeConcurrencyLimit(n int) {
+	e.handshakeConcurrencyLimit = n
+}
+
+// EnableKeyframeGating controls if remote video tracks withhold packets from Read until the
+// first packet of a keyframe is observed. A PLI is sent when the track is bound and repeated
+// while waiting. If no keyframe arrives before timeout the packets are released anyway, and
+// TrackRemote.GatingBypassed is incremented. A timeout of 0 uses the default of 3 seconds.
+// Tracks with codecs that keyframes can't be detected for are not gated.
+func (e *SettingEngine) EnableKeyframeGating(isEnabled bool, timeout time.Duration) {
+	if timeout <= 0 {
+		timeout = defaultKeyframeGatingTimeout
+	}
+
+	e.keyframeGating.enabled = isEnabled
+	e.keyframeGating.timeout = timeout
+}
+
+// EnableJitterBuffer controls if remote tracks can be read by frames with TrackRemote.ReadSample.
+// The packets are reordered, and the frames missing packets are waited for up to maxLatency
+// of media before they are dropped. A maxLatency of 0 uses the default of 200 milliseconds.
+func (e *SettingEngine) EnableJitterBuffer(isEnabled bool, maxLatency time.Duration) {
+	if maxLatency <= 0 {
+		maxLatency = defaultJitterBufferLatency
+	}
+
+	e.jitterBuffer.enabled = isEnabled
+	e.jitterBuffer.latency = maxLatency
+}
+
+// DisableSimulcastResumeKeyframeRequest controls if RTPReceiver.ResumeSimulcastLayer sends a
+// PLI for the resumed layer. Disable it when the application requests keyframes itself.
+func (e *SettingEngine) DisableSimulcastResumeKeyframeRequest(isDisabled bool) {
+	e.disableSimulcastResumeKeyframeRequest = isDisabled
+}
+
+// SetRTCPReportInterval sets how often Sender and Receiver Reports are sent. With
+// EnableRTCPReportBatching every interval is randomized between 0.5 and 1.5 times the given
+// value, so the reports of many PeerConnections don't fire at once, and leaving this 0 computes
+// the interval with the rules of RFC 3550 Section 6.2, which keep the reports below 5% of the
+// session bandwidth and at least one second apart.
+//
+// The interval is applied to the report interceptors registered by NewAPI. When a custom
+// interceptor.Registry is used, configure them with report.ReceiverInterval and
+// report.SenderInterval instead.
+func (e *SettingEngine) SetRTCPReportInterval(interval time.Duration) {
+	e.rtcpReportInterval = interval
+}
+
+// EnableRTCPReportBatching controls if Sender and Receiver Reports are held back and sent as
+// compound packets at the interval set by SetRTCPReportInterval, which saves datagrams when
+// many streams are sent. The timestamps of the reports are advanced when they are sent, so
+// the round-trip time isn't inflated by the time they were held back. By default every report
+// is sent in its own packet as soon as the report interceptors generate it.
+//
+// Batching is opt-in because it trades report freshness for fewer datagrams: reports are held
+// back for up to an interval, and with the interval of RFC 3550 low bandwidth sessions send
+// them less often than the report interceptors do every second, so the round-trip time and loss
+// of GetStats and of congestion control based on them lag behind. The batching also counts the
+// RTP of every stream to compute that interval, which adds to the per packet cost.
+func (e *SettingEngine) EnableRTCPReportBatching(isEnabled bool) {
+	e.enableRTCPReportBatching = isEnabled
+}
+
+// DisableTWCCFeedback controls if transport-wide congestion control feedback is sent for the
+// received RTP packets. When disabled the transport-cc header extension and RTCP feedback are not
+// negotiated either, so the remote doesn't stamp packets nobody reports on.
+//
+// Like SetRTCPReportInterval, it only applies to the interceptors registered by NewAPI.
+func (e *SettingEngine) DisableTWCCFeedback(isDisabled bool) {
+	e.disableTWCCFeedback = isDisabled
+}
+
+// SetTWCCFeedbackInterval sets how often transport-wide congestion control feedback is sent.
+// One feedback packet covers all the streams of the transport. Leave this 0 for the default of
+// the twcc interceptor, 100ms.
+//
+// Like SetRTCPReportInterval, it only applies to the interceptors registered by NewAPI. When a
+// custom interceptor.Registry is used, configure them with twcc.SendInterval instead.
+func (e *SettingEngine) SetTWCCFeedbackInterval(interval time.Duration) {
+	e.twccFeedbackInterval = interval
+}
+
 // SetIgnoreRidPauseForRecv controls if SDP `a=simulcast:recv` will include the paused attribute of a RID
 // (simulcast layer).
 func (e *SettingEngine) SetIgnoreRidPauseForRecv(ignoreRidPauseForRecv bool) {
 	e.ignoreRidPauseForRecv = ignoreRidPauseForRecv
 }
+
+// SetCompatibilityProfile sets the SDP shaping toggles applied to every offer and answer.
+// Use CompatibilityProfileStrict, CompatibilityProfileLegacyTelepresence or a custom
+// CompatibilityProfile to interoperate with remotes that have known quirks.
+func (e *SettingEngine) SetCompatibilityProfile(profile CompatibilityProfile) {
+	e.compatibilityProfile = profile
+}
+
+// SetUnknownSSRCHandler sets a handler that decides what happens to incoming RTP streams
+// whose SSRC was not signaled, instead of always probing them for MID and RID header extensions.
+// Streams attached to a transceiver that doesn't exist yet are buffered until a RemoteDescription
+// adds it, see SetUnknownSSRCBufferedPacketLimit.
+func (e *SettingEngine) SetUnknownSSRCHandler(handler UnknownSSRCHandler) {
+	e.unknownSSRC.handler = handler
+}
+
+// SetUnknownSSRCBufferedPacketLimit sets how many RTP packets of an unknown SSRC are buffered
+// while waiting for the transceiver it is attached to. Packets beyond the limit are dropped,
+// the first ones are kept so the initial keyframe isn't lost.
+// Leave this 0 for the default limit.
+func (e *SettingEngine) SetUnknownSSRCBufferedPacketLimit(limit int) {
+	e.unknownSSRC.bufferedPacketLimit = limit
+}
+
+// SetSimulcastProbeStreamLimit sets how many streams of undeclared SSRCs are kept open while
+// they are probed for a MID and RID. When a new SSRC arrives past the limit, the stream of the
+// oldest unresolved SSRC is closed. Resolved SSRCs don't count towards the limit.
+// Leave this 0 for the default limit.
+func (e *SettingEngine) SetSimulcastProbeStreamLimit(limit int) {
+	e.simulcastProbe.streamLimit = limit
+}
+
+// SetSimulcastProbeStreamLimitHandler sets a handler that is called with the SSRC of each
+// stream evicted because the simulcast probe stream limit was reached.
+func (e *SettingEngine) SetSimulcastProbeStreamLimitHandler(handler func(evicted SSRC)) {
+	e.simulcastProbe.limitHandler = handler
+}
+
+// DisableRTX leaves the RTX codecs of the given kinds out of the codecs of each PeerConnection,
+// the MediaEngine isn't modified. RTX is then neither offered nor accepted, and the RTPSenders of
+// these kinds have no RTX SSRC.
+// NACKs are still answered on the media SSRC, unless the codecs don't negotiate NACK feedback.
+func (e *SettingEngine) DisableRTX(kinds ...RTPCodecType) {
+	e.disabledRTXKinds = kinds
+}
+
+// SetTrackIdentifierPolicy enables validation of the track and stream identifiers of remote
+// descriptions. Identifiers longer than maxLen bytes, with control characters, or reused by
+// tracks of the same stream in different media sections are invalid. Leave maxLen 0 for the
+// default of 64 bytes.
+//
+// If rejectInvalid is true SetRemoteDescription fails with an InvalidAccessError wrapping
+// ErrTrackIdentifierTooLong, ErrTrackIdentifierInvalidCharacter or ErrTrackIdentifierDuplicate.
+// Otherwise the identifiers are sanitized, and the original ones are available with
+// TrackRemote.OriginalID and TrackRemote.OriginalStreamID.
+func (e *SettingEngine) SetTrackIdentifierPolicy(maxLen int, rejectInvalid bool) {
+	e.trackIdentifierPolicy = trackIdentifierPolicy{
+		enabled:       true,
+		maxLength:     maxLen,
+		rejectInvalid: rejectInvalid,
+	}
+}
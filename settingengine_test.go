@@ -10,6 +10,8 @@ import (
 	"context"
 	"crypto/x509"
 	"net"
+	"slices"
+	"sync"
 	"testing"
 	"time"
 
@@ -211,6 +213,58 @@ func TestSetAnsweringDTLSRole(t *testing.T) {
 	)
 }
 
+func TestSetICERole(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	s := SettingEngine{}
+	assert.Error(t, s.SetICERole(ICERoleUnknown))
+
+	newForcedPair := func(t *testing.T, offerRole, answerRole ICERole) (*PeerConnection, *PeerConnection) {
+		t.Helper()
+
+		offerSettingEngine, answerSettingEngine := SettingEngine{}, SettingEngine{}
+		assert.NoError(t, offerSettingEngine.SetICERole(offerRole))
+		assert.NoError(t, answerSettingEngine.SetICERole(answerRole))
+
+		pcOffer, err := NewAPI(WithSettingEngine(offerSettingEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		pcAnswer, err := NewAPI(WithSettingEngine(answerSettingEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		_, err = pcOffer.CreateDataChannel("data", nil)
+		assert.NoError(t, err)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		return pcOffer, pcAnswer
+	}
+
+	t.Run("Forced", func(t *testing.T) {
+		pcOffer, pcAnswer := newForcedPair(t, ICERoleControlled, ICERoleControlling)
+
+		assert.Equal(t, ICERoleControlled, pcOffer.iceTransport.Role())
+		assert.Equal(t, ICERoleControlling, pcAnswer.iceTransport.Role())
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("Conflict", func(t *testing.T) {
+		pcOffer, pcAnswer := newForcedPair(t, ICERoleControlling, ICERoleControlling)
+
+		assert.Eventually(t, func() bool {
+			return pcOffer.iceTransport.Role() != pcAnswer.iceTransport.Role()
+		}, 5*time.Second, 10*time.Millisecond)
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+}
+
 func TestSetReplayProtection(t *testing.T) {
 	settingEngine := SettingEngine{}
 
@@ -427,6 +481,108 @@ func TestSetICEBindingRequestHandler(t *testing.T) {
 	closePairNow(t, pcOffer, pcAnswer)
 }
 
+func TestSetICEBindingRequestHandlerPanic(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetICEBindingRequestHandler(func(*stun.Message, ice.Candidate, ice.Candidate, *ice.CandidatePair) bool {
+		panic("handler")
+	})
+
+	pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).newPair(Configuration{})
+	assert.NoError(t, err)
+
+	connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+	assert.NoError(t, signalPair(pcOffer, pcAnswer))
+	connected.Wait()
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestSetSTUNBindingRequestHandlerMux(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	runPair := func(t *testing.T, networkType NetworkType, setMux func(*SettingEngine)) []net.Addr {
+		t.Helper()
+
+		var (
+			mu     sync.Mutex
+			locals []net.Addr
+		)
+		newSettingEngine := func() SettingEngine {
+			settingEngine := SettingEngine{}
+			settingEngine.SetNetworkTypes([]NetworkType{networkType})
+			settingEngine.SetIncludeLoopbackCandidate(true)
+			settingEngine.SetICEMulticastDNSMode(ice.MulticastDNSModeDisabled)
+
+			return settingEngine
+		}
+
+		answerSettingEngine := newSettingEngine()
+		setMux(&answerSettingEngine)
+		answerSettingEngine.SetSTUNBindingRequestHandler(func(_ *stun.Message, local, _ net.Addr) bool {
+			mu.Lock()
+			defer mu.Unlock()
+			locals = append(locals, local)
+
+			return false
+		})
+
+		pcOffer, err := NewAPI(WithSettingEngine(newSettingEngine())).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+		pcAnswer, err := NewAPI(WithSettingEngine(answerSettingEngine)).NewPeerConnection(Configuration{})
+		assert.NoError(t, err)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		assert.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+		closePairNow(t, pcOffer, pcAnswer)
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		return locals
+	}
+
+	t.Run("UDPMux", func(t *testing.T) {
+		conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		assert.NoError(t, err)
+		udpMux := NewICEUDPMux(nil, conn)
+		defer func() {
+			assert.NoError(t, udpMux.Close())
+		}()
+
+		locals := runPair(t, NetworkTypeUDP4, func(s *SettingEngine) { s.SetICEUDPMux(udpMux) })
+		assert.NotEmpty(t, locals)
+		for _, local := range locals {
+			assert.Equal(t, conn.LocalAddr().String(), local.String())
+		}
+	})
+
+	t.Run("TCPMux", func(t *testing.T) {
+		listener, err := net.ListenTCP("tcp4", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+		assert.NoError(t, err)
+		tcpMux := NewICETCPMux(nil, listener, 8)
+		defer func() {
+			assert.NoError(t, tcpMux.Close())
+		}()
+
+		locals := runPair(t, NetworkTypeTCP4, func(s *SettingEngine) { s.SetICETCPMux(tcpMux) })
+		assert.NotEmpty(t, locals)
+		for _, local := range locals {
+			assert.Equal(t, listener.Addr().String(), local.String())
+		}
+	})
+}
+
 func TestSetHooks(t *testing.T) {
 	settingEngine := SettingEngine{}
 
@@ -599,6 +755,17 @@ func TestSettingEngine_getReceiveMTU_Custom(t *testing.T) {
 	assert.Equal(t, uint(1234), got)
 }
 
+func TestSettingEngine_ReceiveBufferSizes(t *testing.T) {
+	var se SettingEngine
+	assert.Equal(t, defaultSRTPReceiveBufferSize, se.getSRTPReceiveBufferSize())
+	assert.Equal(t, defaultSRTCPReceiveBufferSize, se.getSRTCPReceiveBufferSize())
+
+	se.SetSRTPReceiveBufferSize(4096)
+	se.SetSRTCPReceiveBufferSize(2048)
+	assert.Equal(t, 4096, se.getSRTPReceiveBufferSize())
+	assert.Equal(t, 2048, se.getSRTCPReceiveBufferSize())
+}
+
 func TestSettingEngine_ICEAcceptanceAndSTUNSetters(t *testing.T) {
 	var se SettingEngine
 
@@ -760,3 +927,175 @@ func TestSettingEngine_HandleUndeclaredSSRCWithoutAnswer(t *testing.T) {
 	se.SetHandleUndeclaredSSRCWithoutAnswer(true)
 	assert.True(t, se.handleUndeclaredSSRCWithoutAnswer)
 }
+
+func TestSettingEngine_EnableKeyframeGating(t *testing.T) {
+	var se SettingEngine
+
+	se.EnableKeyframeGating(true, 0)
+	assert.True(t, se.keyframeGating.enabled)
+	assert.Equal(t, defaultKeyframeGatingTimeout, se.keyframeGating.timeout)
+
+	se.EnableKeyframeGating(true, time.Second)
+	assert.Equal(t, time.Second, se.keyframeGating.timeout)
+
+	se.EnableKeyframeGating(false, 0)
+	assert.False(t, se.keyframeGating.enabled)
+}
+
+func TestSettingEngine_ICEConnectivityChecks(t *testing.T) {
+	var se SettingEngine
+	assert.NoError(t, se.validateICEConnectivityChecks())
+
+	se.SetICECheckInterval(50 * time.Millisecond)
+	se.SetICEMaxBindingRequests(3)
+	se.SetICENominationMode(ICENominationModeAggressive)
+	assert.Equal(t, 50*time.Millisecond, *se.iceCheckInterval)
+	assert.Equal(t, uint16(3), *se.iceMaxBindingRequests)
+	assert.Equal(t, ICENominationModeAggressive, se.iceNominationMode)
+	assert.NoError(t, se.validateICEConnectivityChecks())
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			apply    func(*SettingEngine)
+			expected error
+		}{
+			{"CheckInterval", func(se *SettingEngine) { se.SetICECheckInterval(0) }, errSettingEngineICECheckInterval},
+			{
+				"MaxBindingRequests",
+				func(se *SettingEngine) { se.SetICEMaxBindingRequests(0) },
+				errSettingEngineICEMaxBindingRequests,
+			},
+			{
+				"NominationMode",
+				func(se *SettingEngine) { se.SetICENominationMode(ICENominationMode(42)) },
+				errSettingEngineICENominationMode,
+			},
+		} {
+			se := SettingEngine{}
+			test.apply(&se)
+
+			_, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+			assert.ErrorIs(t, err, test.expected, test.name)
+		}
+	})
+}
+
+func TestSettingEngine_ICEGathering(t *testing.T) {
+	var se SettingEngine
+	assert.Equal(t, ICEGatheringPolicyGatherOnce, se.iceGatheringPolicy)
+	assert.NoError(t, se.validateICEGathering())
+
+	se.EnableContinualGathering(ICEGatheringPolicyGatherContinually)
+	se.SetICENetworkMonitorInterval(time.Second)
+	assert.Equal(t, ICEGatheringPolicyGatherContinually, se.iceGatheringPolicy)
+	assert.Equal(t, time.Second, *se.iceNetworkMonitorInterval)
+	assert.NoError(t, se.validateICEGathering())
+
+	t.Run("Invalid", func(t *testing.T) {
+		for _, test := range []struct {
+			name     string
+			apply    func(*SettingEngine)
+			expected error
+		}{
+			{
+				"GatheringPolicy",
+				func(se *SettingEngine) { se.EnableContinualGathering(ICEGatheringPolicy(42)) },
+				errSettingEngineICEGatheringPolicy,
+			},
+			{
+				"NetworkMonitorInterval",
+				func(se *SettingEngine) { se.SetICENetworkMonitorInterval(0) },
+				errSettingEngineICENetworkMonitorInterval,
+			},
+		} {
+			se := SettingEngine{}
+			test.apply(&se)
+
+			_, err := NewAPI(WithSettingEngine(se)).NewPeerConnection(Configuration{})
+			assert.ErrorIs(t, err, test.expected, test.name)
+		}
+	})
+}
+
+func TestSettingEngine_SetCompatibilityProfile(t *testing.T) {
+	s := SettingEngine{}
+	assert.Equal(t, CompatibilityProfile{}, s.compatibilityProfile)
+
+	s.SetCompatibilityProfile(CompatibilityProfileLegacyTelepresence)
+	assert.Equal(t, CompatibilityProfileLegacyTelepresence, s.compatibilityProfile)
+}
+
+func TestSettingEngine_UnknownSSRC(t *testing.T) {
+	s := SettingEngine{}
+	assert.Nil(t, s.unknownSSRC.handler)
+	assert.Equal(t, defaultUnknownSSRCBufferedPacketLimit, s.getUnknownSSRCBufferedPacketLimit())
+
+	s.SetUnknownSSRCHandler(func(uint32, uint8, string, string) UnknownSSRCAction {
+		return UnknownSSRCAction{Type: UnknownSSRCActionTypeDrop}
+	})
+	assert.NotNil(t, s.unknownSSRC.handler)
+
+	s.SetUnknownSSRCBufferedPacketLimit(16)
+	assert.Equal(t, 16, s.getUnknownSSRCBufferedPacketLimit())
+}
+
+func TestSettingEngine_DisableRTX(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+
+	settingEngine := SettingEngine{}
+	settingEngine.DisableRTX(RTPCodecTypeVideo)
+	api := NewAPI(WithMediaEngine(mediaEngine), WithSettingEngine(settingEngine))
+
+	pc, err := api.NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+	sender, err := pc.AddTrack(track)
+	assert.NoError(t, err)
+
+	offer, err := pc.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, offer.SDP, "rtx/90000")
+	assert.NotContains(t, offer.SDP, "a=ssrc-group:FID")
+	assert.Equal(t, SSRC(0), sender.GetParameters().Encodings[0].RTX.SSRC)
+
+	// The MediaEngine of the API is left untouched
+	assert.True(t, mediaEngine.isRTXEnabled(RTPCodecTypeVideo, []RTPTransceiverDirection{RTPTransceiverDirectionSendonly}))
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestSettingEngine_DisableRTX_SharedMediaEngine(t *testing.T) {
+	mediaEngine := &MediaEngine{}
+	assert.NoError(t, mediaEngine.RegisterDefaultCodecs())
+
+	settingEngine := SettingEngine{}
+	settingEngine.DisableRTX(RTPCodecTypeVideo)
+	settingEngine.DisableMediaEngineCopy(true)
+
+	pcOffer, err := NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+	pcAnswer, err := NewAPI(WithMediaEngine(mediaEngine), WithSettingEngine(settingEngine)).
+		NewPeerConnection(Configuration{})
+	assert.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	assert.NoError(t, err)
+	offer, err := pcOffer.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.Contains(t, offer.SDP, "rtx/90000")
+
+	assert.NoError(t, pcAnswer.SetRemoteDescription(offer))
+	answer, err := pcAnswer.CreateAnswer(nil)
+	assert.NoError(t, err)
+	assert.NotContains(t, answer.SDP, "rtx/90000")
+
+	// The MediaEngine shared with the PeerConnection keeps its RTX codecs
+	assert.True(t, slices.ContainsFunc(mediaEngine.videoCodecs, isRTXCodec))
+
+	assert.NoError(t, pcOffer.Close())
+	assert.NoError(t, pcAnswer.Close())
+}
@@ -129,6 +129,22 @@ func TestSignalingState_Transitions(t *testing.T) {
 			SDPTypeAnswer,
 			nil,
 		},
+		{
+			"have-local-offer->SetLocal(rollback)->stable",
+			SignalingStateHaveLocalOffer,
+			SignalingStateStable,
+			stateChangeOpSetLocal,
+			SDPTypeRollback,
+			nil,
+		},
+		{
+			"(invalid) have-remote-offer->SetLocal(rollback)->stable",
+			SignalingStateHaveRemoteOffer,
+			SignalingStateStable,
+			stateChangeOpSetLocal,
+			SDPTypeRollback,
+			&rtcerr.InvalidModificationError{},
+		},
 		{
 			"(invalid) stable->SetRemote(pranswer)->have-remote-pranswer",
 			SignalingStateStable,
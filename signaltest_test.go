@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc_test
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/transport/v4/test"
+	"github.com/pion/webrtc/v4"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/pion/webrtc/v4/pkg/signaltest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// The tests of this file only use the public API, they are built with the helpers of
+// pkg/signaltest rather than the ones private to the tests of the package.
+
+func TestPeerConnectionCanTrickleICECandidatesGo(t *testing.T) {
+	offerPC, answerPC, wan, err := signaltest.NewVNetPair(webrtc.SettingEngine{})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, wan.Stop())
+		assert.NoError(t, signaltest.ClosePair(offerPC, answerPC))
+	}()
+
+	_, err = offerPC.CreateDataChannel("trickle", nil)
+	assert.NoError(t, err)
+
+	offer, err := offerPC.CreateOffer(&webrtc.OfferOptions{
+		OfferAnswerOptions: webrtc.OfferAnswerOptions{ICETricklingSupported: true},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, offerPC.SetLocalDescription(offer))
+	assert.Equal(t, webrtc.ICETrickleCapabilityUnknown, answerPC.CanTrickleICECandidates())
+	assert.NoError(t, answerPC.SetRemoteDescription(offer))
+	assert.Equal(t, webrtc.ICETrickleCapabilitySupported, answerPC.CanTrickleICECandidates())
+
+	noTrickleOfferPC, noTrickleAnswerPC, noTrickleWAN, err := signaltest.NewVNetPair(webrtc.SettingEngine{})
+	require.NoError(t, err)
+	defer func() {
+		assert.NoError(t, noTrickleWAN.Stop())
+		assert.NoError(t, signaltest.ClosePair(noTrickleOfferPC, noTrickleAnswerPC))
+	}()
+
+	_, err = noTrickleOfferPC.CreateDataChannel("notrickle", nil)
+	assert.NoError(t, err)
+
+	noTrickleOffer, err := noTrickleOfferPC.CreateOffer(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, noTrickleOfferPC.SetLocalDescription(noTrickleOffer))
+	assert.Equal(t, webrtc.ICETrickleCapabilityUnknown, noTrickleAnswerPC.CanTrickleICECandidates())
+	assert.NoError(t, noTrickleAnswerPC.SetRemoteDescription(noTrickleOffer))
+	assert.Equal(t, webrtc.ICETrickleCapabilityUnsupported, noTrickleAnswerPC.CanTrickleICECandidates())
+}
+
+// Assert that SetReadDeadline works as expected
+// This test uses VNet since we must have zero loss.
+func Test_RTPReceiver_SetReadDeadline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	sender, receiver, wan, err := signaltest.NewVNetPair(
+		webrtc.SettingEngine{}, webrtc.WithInterceptorRegistry(&interceptor.Registry{}),
+	)
+	require.NoError(t, err)
+
+	track, err := webrtc.NewTrackLocalStaticSample(webrtc.RTPCodecCapability{MimeType: webrtc.MimeTypeVP8}, "video", "pion")
+	assert.NoError(t, err)
+
+	_, err = sender.AddTrack(track)
+	assert.NoError(t, err)
+
+	seenPacket, seenPacketCancel := context.WithCancel(context.Background())
+	receiver.OnTrack(func(trackRemote *webrtc.TrackRemote, r *webrtc.RTPReceiver) {
+		// Set Deadline for both RTP and RTCP Stream
+		assert.NoError(t, r.SetReadDeadline(time.Now().Add(time.Second)))
+		assert.NoError(t, trackRemote.SetReadDeadline(time.Now().Add(time.Second)))
+
+		// First call will not error because we cache for probing
+		_, _, readErr := trackRemote.ReadRTP()
+		assert.NoError(t, readErr)
+
+		_, _, readErr = trackRemote.ReadRTP()
+		assert.Error(t, readErr)
+
+		_, _, readErr = r.ReadRTCP()
+		assert.Error(t, readErr)
+
+		seenPacketCancel()
+	})
+
+	peerConnectionsConnected := signaltest.UntilConnectionState(webrtc.PeerConnectionStateConnected, sender, receiver)
+
+	assert.NoError(t, signaltest.SignalPair(sender, receiver))
+
+	peerConnectionsConnected.Wait()
+	assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0xAA}, Duration: time.Second}))
+
+	<-seenPacket.Done()
+	assert.NoError(t, wan.Stop())
+	assert.NoError(t, signaltest.ClosePair(sender, receiver))
+}
+
+// Assert that a Session Description that doesn't follow
+// draft-ietf-mmusic-sctp-sdp is still accepted.
+func TestDataChannel_NonStandardSessionDescription(t *testing.T) {
+	to := test.TimeOut(time.Second * 20)
+	defer to.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := signaltest.NewPair()
+	assert.NoError(t, err)
+
+	_, err = offerPC.CreateDataChannel("foo", nil)
+	assert.NoError(t, err)
+
+	onDataChannelCalled := make(chan struct{})
+	answerPC.OnDataChannel(func(*webrtc.DataChannel) {
+		close(onDataChannelCalled)
+	})
+
+	// Replace with old values
+	const (
+		oldApplication = "m=application 63743 DTLS/SCTP 5000\r"
+		oldAttribute   = "a=sctpmap:5000 webrtc-datachannel 256\r"
+	)
+
+	assert.NoError(t, signaltest.SignalPair(offerPC, answerPC, signaltest.WithoutInitialDataChannel(),
+		signaltest.WithModification(func(sdp string) string {
+			sdp = regexp.MustCompile(`m=application (.*?)\r`).ReplaceAllString(sdp, oldApplication)
+			sdp = regexp.MustCompile(`a=sctp-port(.*?)\r`).ReplaceAllString(sdp, oldAttribute)
+
+			// Assert that replace worked
+			assert.True(t, strings.Contains(sdp, oldApplication))
+			assert.True(t, strings.Contains(sdp, oldAttribute))
+
+			return sdp
+		}),
+	))
+
+	<-onDataChannelCalled
+	assert.NoError(t, signaltest.ClosePair(offerPC, answerPC))
+}
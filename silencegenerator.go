@@ -0,0 +1,286 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"bytes"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/internal/util"
+)
+
+// opusSilenceFrame is a 20ms Opus frame of silence, see RFC 6716 Section 3.1.
+var opusSilenceFrame = []byte{0xf8, 0xff, 0xfe} //nolint:gochecknoglobals
+
+const (
+	// continuityWritten flags a packet in rtpContinuity.passthrough.
+	continuityWritten = 1 << 48
+	// continuityPadded is set in rtpContinuity.passthrough once padding was inserted into
+	// the stream, which is rewritten from then on.
+	continuityPadded = 1 << 49
+)
+
+// rtpContinuity rewrites the sequence numbers and timestamps of the packets written
+// to a stream, so the stream stays continuous when its source changes.
+type rtpContinuity struct {
+	enabled atomic.Bool
+
+	// passthrough holds the last packet written while the stream isn't rewritten, which
+	// doesn't take mu: its sequence number, its timestamp shifted by 16 and continuityWritten.
+	// It is adopted by the next call that takes mu.
+	passthrough atomic.Uint64
+
+	mu sync.Mutex
+
+	clockRate uint32
+
+	written   bool
+	generated bool
+	resync    bool
+
+	lastSequenceNumber uint16
+	lastTimestamp      uint32
+	lastWritten        time.Time
+
+	sequenceNumberOffset uint16
+	timestampOffset      uint32
+}
+
+func (c *rtpContinuity) setClockRate(clockRate uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.clockRate = clockRate
+}
+
+// newSource continues the stream after the last written packet, once the next
+// packet of a new source is written.
+func (c *rtpContinuity) newSource() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.adoptPassthrough(0)
+	c.resync = true
+}
+
+// apply returns header moved into the sequence number and timestamp space of the stream
+// if the stream is rewritten, as a copy. Otherwise header is the stream, it is recorded
+// so generated packets can continue it.
+func (c *rtpContinuity) apply(header *rtp.Header) *rtp.Header {
+	for !c.enabled.Load() {
+		last := c.passthrough.Load()
+		if last&continuityPadded != 0 {
+			break
+		}
+		written := continuityWritten | uint64(header.Timestamp)<<16 | uint64(header.SequenceNumber)
+		if c.passthrough.CompareAndSwap(last, written) {
+			return header
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// The header might be shared with other bindings of the track, so don't modify it.
+	rewritten := *header
+	c.rewriteLocked(&rewritten)
+
+	return &rewritten
+}
+
+// adoptPassthrough continues the stream after the packet written last without being
+// rewritten, if there is one, and sets flags in c.passthrough. c.mu must be held.
+func (c *rtpContinuity) adoptPassthrough(flags uint64) {
+	for {
+		last := c.passthrough.Load()
+		if !c.passthrough.CompareAndSwap(last, last&^continuityWritten|flags) {
+			continue
+		}
+
+		if last&continuityWritten != 0 {
+			c.sequenceNumberOffset, c.timestampOffset, c.resync = 0, 0, false
+			c.record(uint16(last), uint32(last>>16), time.Now()) //nolint:gosec // G115
+			c.generated = false
+		}
+
+		return
+	}
+}
+
+// rewrite moves header from the sequence number and timestamp space of the
+// current source into the one of the stream.
+func (c *rtpContinuity) rewrite(header *rtp.Header) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.rewriteLocked(header)
+}
+
+// rewriteLocked is rewrite, c.mu must be held.
+func (c *rtpContinuity) rewriteLocked(header *rtp.Header) {
+	c.adoptPassthrough(0)
+
+	now := time.Now()
+	if c.resync && c.written {
+		c.sequenceNumberOffset = c.lastSequenceNumber + 1 - header.SequenceNumber
+		c.timestampOffset = c.lastTimestamp + max(c.elapsedTicks(now), 1) - header.Timestamp
+	}
+	c.resync = false
+
+	header.SequenceNumber += c.sequenceNumberOffset
+	header.Timestamp += c.timestampOffset
+	c.record(header.SequenceNumber, header.Timestamp, now)
+	c.generated = false
+}
+
+// next returns the sequence number and timestamp of a generated packet that
+// continues the stream. Consecutive generated packets are frameTicks apart.
+func (c *rtpContinuity) next(frameTicks uint32) (sequenceNumber uint16, timestamp uint32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.adoptPassthrough(0)
+	now := time.Now()
+	switch {
+	case !c.written:
+		sequenceNumber, timestamp = uint16(util.RandUint32()), util.RandUint32() //nolint:gosec // G115
+	case c.generated:
+		sequenceNumber, timestamp = c.lastSequenceNumber+1, c.lastTimestamp+frameTicks
+	default:
+		sequenceNumber, timestamp = c.lastSequenceNumber+1, c.lastTimestamp+max(c.elapsedTicks(now), frameTicks)
+	}
+
+	c.record(sequenceNumber, timestamp, now)
+	c.generated = true
+	c.resync = true
+
+	return sequenceNumber, timestamp
+}
+
+// pad returns the sequence number and timestamp of a padding-only packet inserted after
+// the last written packet, the packets written next are moved after it. ok is false if
+// no packet was written yet.
+func (c *rtpContinuity) pad() (sequenceNumber uint16, timestamp uint32, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.adoptPassthrough(0)
+	if !c.written {
+		return 0, 0, false
+	}
+	// A packet written since is adopted as the stream is switched to be rewritten
+	c.adoptPassthrough(continuityPadded)
+
+	// The offsets are computed from the last written packet once the source changed
+	if !c.resync {
+		c.sequenceNumberOffset++
+	}
+	c.lastSequenceNumber++
+
+	return c.lastSequenceNumber, c.lastTimestamp, true
+}
+
+// timestamp returns the timestamp of the last written packet.
+func (c *rtpContinuity) timestamp() uint32 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.adoptPassthrough(0)
+
+	return c.lastTimestamp
+}
+
+func (c *rtpContinuity) record(sequenceNumber uint16, timestamp uint32, now time.Time) {
+	c.lastSequenceNumber = sequenceNumber
+	c.lastTimestamp = timestamp
+	c.lastWritten = now
+	c.written = true
+}
+
+func (c *rtpContinuity) elapsedTicks(now time.Time) uint32 {
+	return uint32(now.Sub(c.lastWritten).Seconds() * float64(c.clockRate))
+}
+
+// silenceGenerator writes keep-alive media to a stream while no track is bound to it.
+type silenceGenerator struct {
+	done   chan struct{}
+	closed chan struct{}
+}
+
+// startSilenceGenerator writes a silence frame or a padding-only packet for codec
+// every silenceFrameDuration until stop is called.
+func startSilenceGenerator(
+	writer *interceptorToTrackLocalWriter,
+	ssrc SSRC,
+	codec RTPCodecParameters,
+) *silenceGenerator {
+	generator := &silenceGenerator{
+		done:   make(chan struct{}),
+		closed: make(chan struct{}),
+	}
+
+	payload, padding := silencePayload(codec.RTPCodecCapability)
+	frameTicks := uint32(silenceFrameDuration.Seconds() * float64(codec.ClockRate))
+
+	go func() {
+		defer close(generator.closed)
+
+		ticker := time.NewTicker(silenceFrameDuration)
+		defer ticker.Stop()
+
+		for {
+			sequenceNumber, timestamp := writer.continuity.next(frameTicks)
+			header := &rtp.Header{
+				Version:        2,
+				PayloadType:    uint8(codec.PayloadType),
+				SequenceNumber: sequenceNumber,
+				Timestamp:      timestamp,
+				SSRC:           uint32(ssrc),
+			}
+			if padding {
+				header.Padding = true
+				header.PaddingSize = silencePaddingSize
+			}
+			_, _ = writer.write(header, payload, interceptor.Attributes{})
+
+			select {
+			case <-generator.done:
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+
+	return generator
+}
+
+// stop stops the generator and waits until it doesn't write anymore.
+func (g *silenceGenerator) stop() {
+	close(g.done)
+	<-g.closed
+}
+
+// silencePayload returns the payload of a silence frame of codec, or that
+// padding-only packets have to be sent if the codec has none.
+func silencePayload(codec RTPCodecCapability) (payload []byte, padding bool) {
+	frameSamples := int(silenceFrameDuration.Seconds() * float64(codec.ClockRate))
+
+	switch {
+	case strings.EqualFold(codec.MimeType, MimeTypeOpus):
+		return opusSilenceFrame, false
+	case strings.EqualFold(codec.MimeType, MimeTypePCMU):
+		return bytes.Repeat([]byte{0xff}, frameSamples), false
+	case strings.EqualFold(codec.MimeType, MimeTypePCMA):
+		return bytes.Repeat([]byte{0xd5}, frameSamples), false
+	default:
+		return nil, true
+	}
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/pion/rtp"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSilencePayload(t *testing.T) {
+	payload, padding := silencePayload(RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 48000})
+	assert.Equal(t, opusSilenceFrame, payload)
+	assert.False(t, padding)
+
+	payload, padding = silencePayload(RTPCodecCapability{MimeType: "audio/pcmu", ClockRate: 8000})
+	assert.Len(t, payload, 160)
+	assert.Equal(t, byte(0xff), payload[0])
+	assert.False(t, padding)
+
+	payload, padding = silencePayload(RTPCodecCapability{MimeType: MimeTypePCMA, ClockRate: 8000})
+	assert.Len(t, payload, 160)
+	assert.Equal(t, byte(0xd5), payload[0])
+	assert.False(t, padding)
+
+	payload, padding = silencePayload(RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000})
+	assert.Empty(t, payload)
+	assert.True(t, padding)
+}
+
+func TestRTPContinuity(t *testing.T) {
+	continuity := &rtpContinuity{}
+	continuity.setClockRate(48000)
+
+	// Packets of the first source are written unchanged
+	header := &rtp.Header{SequenceNumber: 100, Timestamp: 5000}
+	continuity.rewrite(header)
+	assert.Equal(t, uint16(100), header.SequenceNumber)
+	assert.Equal(t, uint32(5000), header.Timestamp)
+
+	// Generated packets continue the stream
+	sequenceNumber, timestamp := continuity.next(960)
+	assert.Equal(t, uint16(101), sequenceNumber)
+	assert.GreaterOrEqual(t, timestamp, uint32(5960))
+
+	nextSequenceNumber, nextTimestamp := continuity.next(960)
+	assert.Equal(t, sequenceNumber+1, nextSequenceNumber)
+	assert.Equal(t, timestamp+960, nextTimestamp)
+
+	// So does the next source
+	header = &rtp.Header{SequenceNumber: 65535, Timestamp: 1}
+	continuity.rewrite(header)
+	assert.Equal(t, nextSequenceNumber+1, header.SequenceNumber)
+	assert.Greater(t, header.Timestamp, nextTimestamp)
+
+	// Following packets of the source keep the offsets
+	timestamp = header.Timestamp
+	header = &rtp.Header{SequenceNumber: 0, Timestamp: 961}
+	continuity.rewrite(header)
+	assert.Equal(t, nextSequenceNumber+2, header.SequenceNumber)
+	assert.Equal(t, timestamp+960, header.Timestamp)
+
+	// A replaced track continues after the last written packet
+	continuity.newSource()
+	header = &rtp.Header{SequenceNumber: 10, Timestamp: 10}
+	continuity.rewrite(header)
+	assert.Equal(t, nextSequenceNumber+3, header.SequenceNumber)
+}
+
+func TestRTPContinuity_Padding(t *testing.T) {
+	continuity := &rtpContinuity{}
+	continuity.setClockRate(90000)
+
+	// Packets of a stream that isn't rewritten are written as they are
+	header := &rtp.Header{SequenceNumber: 100, Timestamp: 5000}
+	assert.Same(t, header, continuity.apply(header))
+	header = &rtp.Header{SequenceNumber: 101, Timestamp: 5000}
+	assert.Same(t, header, continuity.apply(header))
+
+	// Padding is inserted after the last written packet, the stream is rewritten from then on
+	sequenceNumber, timestamp, ok := continuity.pad()
+	assert.True(t, ok)
+	assert.Equal(t, uint16(102), sequenceNumber)
+	assert.Equal(t, uint32(5000), timestamp)
+
+	rewritten := continuity.apply(&rtp.Header{SequenceNumber: 102, Timestamp: 8000})
+	assert.Equal(t, uint16(103), rewritten.SequenceNumber)
+	assert.Equal(t, uint32(8000), rewritten.Timestamp)
+}
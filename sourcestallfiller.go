@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pion/webrtc/v4/pkg/media"
+)
+
+// sourceStallFiller writes filler samples to a TrackLocalStaticSample when the application
+// wrote no sample for timeout. The filler samples are written on the cadence of the last
+// sample, in the time slots the missing samples would have used, so the timestamps follow
+// the wall clock.
+type sourceStallFiller struct {
+	timeout time.Duration
+	filler  func(codec RTPCodecCapability) media.Sample
+
+	// fill writes a filler sample, it is called with the generation it was scheduled for.
+	fill func(generation uint64)
+
+	// Serializes the samples of the application and the filler samples, a filler sample is
+	// never written in between the packets of another sample.
+	writeMu sync.Mutex
+
+	// The state below is guarded by writeMu.
+	// generation changes with every sample of the application, which cancels the filler
+	// scheduled before it.
+	generation uint64
+	lastWrite  time.Time
+	cadence    time.Duration
+	slot       int64 // The slot of the last written sample, 0 is the one of the application
+	timer      *time.Timer
+}
+
+func newSourceStallFiller(
+	timeout time.Duration,
+	filler func(codec RTPCodecCapability) media.Sample,
+) *sourceStallFiller {
+	return &sourceStallFiller{
+		timeout: timeout,
+		filler:  filler,
+	}
+}
+
+// observe records a sample of the application and schedules the first filler sample, writeMu
+// must be held. The filler has to be cancelled before the sample is written.
+func (f *sourceStallFiller) observe(duration time.Duration) {
+	f.lastWrite = time.Now()
+	f.slot = 0
+	if duration > 0 {
+		f.cadence = duration
+	}
+	if f.cadence <= 0 {
+		return
+	}
+
+	// The first filler sample uses the first slot after the timeout
+	firstSlot := max(1, int64((f.timeout+f.cadence-1)/f.cadence))
+	f.schedule(firstSlot)
+}
+
+// next returns the slot of the next filler sample and how many slots were skipped since the
+// last sample, as the filler may run late. writeMu must be held.
+func (f *sourceStallFiller) next() (slot, skipped int64) {
+	slot = max(f.slot+1, int64(time.Since(f.lastWrite)/f.cadence))
+
+	return slot, slot - f.slot - 1
+}
+
+// schedule calls fill at the start of slot, writeMu must be held.
+func (f *sourceStallFiller) schedule(slot int64) {
+	generation := f.generation
+	f.timer = time.AfterFunc(time.Until(f.lastWrite.Add(time.Duration(slot)*f.cadence)), func() {
+		f.fill(generation)
+	})
+}
+
+// cancel stops the scheduled filler sample, writeMu must be held.
+func (f *sourceStallFiller) cancel() {
+	f.generation++
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+}
@@ -22,6 +22,7 @@ type srtpWriterFuture struct {
 	rtpSender      *RTPSender
 	rtcpReadStream atomic.Value // *srtp.ReadStreamSRTCP
 	rtpWriteStream atomic.Value // *srtp.WriteStreamSRTP
+	readDeadline   readDeadline
 	mu             sync.Mutex
 	closed         bool
 }
@@ -40,6 +41,8 @@ func (s *srtpWriterFuture) init(returnWhenNoSRTP bool) error { //nolint:cyclop
 		case <-s.rtpSender.stopCalled:
 			return io.ErrClosedPipe
 		case <-s.rtpSender.transport.srtpReady:
+		case <-s.readDeadline.done():
+			return errReadTimeout
 		}
 	}
 
@@ -59,6 +62,9 @@ func (s *srtpWriterFuture) init(returnWhenNoSRTP bool) error { //nolint:cyclop
 	if err != nil {
 		return err
 	}
+	if err = s.readDeadline.apply(rtcpReadStream); err != nil {
+		return err
+	}
 
 	srtpSession, err := s.rtpSender.transport.getSRTPSession()
 	if err != nil {
@@ -105,11 +111,13 @@ func (s *srtpWriterFuture) Read(b []byte) (n int, err error) {
 }
 
 func (s *srtpWriterFuture) SetReadDeadline(t time.Time) error {
+	s.readDeadline.set(t)
 	if value, ok := s.rtcpReadStream.Load().(*srtp.ReadStreamSRTCP); ok {
 		return value.SetReadDeadline(t)
 	}
 
-	if err := s.init(false); err != nil || s.rtcpReadStream.Load() == nil {
+	// Don't block until SRTP is ready, init applies the deadline once it is.
+	if err := s.init(true); err != nil || s.rtcpReadStream.Load() == nil {
 		return err
 	}
 
@@ -0,0 +1,144 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"errors"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pion/rtcp"
+	"github.com/pion/transport/v4/packetio"
+)
+
+const (
+	defaultSRTPReceiveBufferSize  = 1000 * 1000
+	defaultSRTCPReceiveBufferSize = 100 * 1000
+
+	defaultReceiverPreBindBufferSize = 512
+)
+
+// srtpReceiveBuffers creates the buffers between the SRTP/SRTCP sessions and the
+// readers of each SSRC, and counts the RTP packets dropped because a buffer was full.
+type srtpReceiveBuffers struct {
+	rtpSize, rtcpSize int
+
+	mu        sync.Mutex
+	discarded map[uint32]*atomic.Uint32
+}
+
+func newSRTPReceiveBuffers(rtpSize, rtcpSize int) *srtpReceiveBuffers {
+	return &srtpReceiveBuffers{
+		rtpSize:   rtpSize,
+		rtcpSize:  rtcpSize,
+		discarded: map[uint32]*atomic.Uint32{},
+	}
+}
+
+func (b *srtpReceiveBuffers) newBuffer(packetType packetio.BufferPacketType, ssrc uint32) io.ReadWriteCloser {
+	buffer := packetio.NewBuffer()
+	if packetType == packetio.RTCPBufferPacket {
+		buffer.SetLimitSize(b.rtcpSize)
+
+		return buffer
+	}
+	buffer.SetLimitSize(b.rtpSize)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	discarded, ok := b.discarded[ssrc]
+	if !ok {
+		discarded = &atomic.Uint32{}
+		b.discarded[ssrc] = discarded
+	}
+
+	return &countingBuffer{Buffer: buffer, discarded: discarded}
+}
+
+// discardedPackets returns the number of RTP packets of ssrc dropped because its buffer was full.
+func (b *srtpReceiveBuffers) discardedPackets(ssrc SSRC) uint32 {
+	if b == nil {
+		return 0
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if discarded, ok := b.discarded[uint32(ssrc)]; ok {
+		return discarded.Load()
+	}
+
+	return 0
+}
+
+// countingBuffer counts the packets that don't fit in the buffer, they are dropped by the session.
+type countingBuffer struct {
+	*packetio.Buffer
+	discarded *atomic.Uint32
+}
+
+func (b *countingBuffer) Write(packet []byte) (int, error) {
+	n, err := b.Buffer.Write(packet)
+	if errors.Is(err, packetio.ErrFull) {
+		b.discarded.Add(1)
+	}
+
+	return n, err
+}
+
+// goodbyeBuffer calls onGoodbye when a RTCP BYE for its SSRC is written by the session, so
+// the track of the SSRC ends even if its RTCP isn't read.
+type goodbyeBuffer struct {
+	io.ReadWriteCloser
+	ssrc      uint32
+	onGoodbye func(SSRC)
+}
+
+func (b *goodbyeBuffer) Write(packet []byte) (int, error) {
+	if hasGoodbye(packet, b.ssrc) {
+		b.onGoodbye(SSRC(b.ssrc))
+	}
+
+	return b.ReadWriteCloser.Write(packet)
+}
+
+// SetReadDeadline sets the deadline of the wrapped buffer, if it supports one.
+func (b *goodbyeBuffer) SetReadDeadline(t time.Time) error {
+	if buffer, ok := b.ReadWriteCloser.(interface{ SetReadDeadline(time.Time) error }); ok {
+		return buffer.SetReadDeadline(t)
+	}
+
+	return nil
+}
+
+// hasGoodbye returns true if a compound RTCP packet contains a BYE for ssrc.
+func hasGoodbye(buf []byte, ssrc uint32) bool {
+	for len(buf) >= 4 {
+		var header rtcp.Header
+		if err := header.Unmarshal(buf); err != nil {
+			return false
+		}
+
+		size := (int(header.Length) + 1) * 4
+		if size > len(buf) {
+			return false
+		}
+
+		if header.Type == rtcp.TypeGoodbye {
+			goodbye := rtcp.Goodbye{}
+			if goodbye.Unmarshal(buf[:size]) == nil && slices.Contains(goodbye.Sources, ssrc) {
+				return true
+			}
+		}
+		buf = buf[size:]
+	}
+
+	return false
+}
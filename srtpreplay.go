@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync/atomic"
+
+	"github.com/pion/srtp/v3"
+	"github.com/pion/transport/v4/replaydetector"
+)
+
+const (
+	// The replay protection windows of the srtp sessions
+	defaultSRTPReplayProtectionWindow  = 64
+	defaultSRTCPReplayProtectionWindow = 64
+
+	// The highest packet indexes of SRTP, the rollover counter and the sequence number, and SRTCP
+	maxSRTPIndex  = 1<<48 - 1
+	maxSRTCPIndex = 0x7FFFFFFF
+)
+
+// countingReplayDetector counts the packets its ReplayDetector discards.
+type countingReplayDetector struct {
+	replaydetector.ReplayDetector
+	discarded *atomic.Uint64
+}
+
+func (d *countingReplayDetector) Check(seq uint64) (func() bool, bool) {
+	accept, ok := d.ReplayDetector.Check(seq)
+	if !ok {
+		d.discarded.Add(1)
+	}
+
+	return accept, ok
+}
+
+// replayProtectionOptions returns the options of the remote srtp contexts for the replay
+// protection configured in the SettingEngine. The packets discarded as replayed are counted
+// by the transport.
+func (t *DTLSTransport) replayProtectionOptions() []srtp.ContextOption {
+	settingEngine := t.api.settingEngine
+
+	var options []srtp.ContextOption
+	if settingEngine.disableSRTPReplayProtection {
+		options = append(options, srtp.SRTPNoReplayProtection())
+	} else {
+		window := uint(defaultSRTPReplayProtectionWindow)
+		if settingEngine.replayProtection.SRTP != nil {
+			window = *settingEngine.replayProtection.SRTP
+		}
+		options = append(options, srtp.SRTPReplayDetectorFactory(func() replaydetector.ReplayDetector {
+			return &countingReplayDetector{replaydetector.New(window, maxSRTPIndex), &t.srtpReplayDiscards}
+		}))
+	}
+
+	if settingEngine.disableSRTCPReplayProtection {
+		options = append(options, srtp.SRTCPNoReplayProtection())
+	} else {
+		window := uint(defaultSRTCPReplayProtectionWindow)
+		if settingEngine.replayProtection.SRTCP != nil {
+			window = *settingEngine.replayProtection.SRTCP
+		}
+		options = append(options, srtp.SRTCPReplayDetectorFactory(func() replaydetector.ReplayDetector {
+			return &countingReplayDetector{replaydetector.New(window, maxSRTCPIndex), &t.srtcpReplayDiscards}
+		}))
+	}
+
+	return options
+}
+
+// replayDiscardCounts returns how many SRTP and SRTCP packets were discarded as replayed.
+func (t *DTLSTransport) replayDiscardCounts() (srtpDiscards, srtcpDiscards uint64) {
+	return t.srtpReplayDiscards.Load(), t.srtcpReplayDiscards.Load()
+}
@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSRTPReplayProtection(t *testing.T) {
+	defer test.TimeOut(time.Second * 30).Stop()
+	defer test.CheckRoutines(t)()
+
+	for _, testCase := range []struct {
+		name      string
+		configure func(*SettingEngine)
+		// The packets of the replayed range that are delivered
+		delivered []uint16
+		discarded uint64
+	}{
+		{
+			name:      "Default",
+			configure: func(*SettingEngine) {},
+			discarded: 100,
+		},
+		{
+			name: "Wide window",
+			configure: func(s *SettingEngine) {
+				s.SetSRTPReplayProtectionWindow(256)
+			},
+			delivered: sequenceNumberRange(0, 50),
+			discarded: 50,
+		},
+		{
+			name: "Disabled",
+			configure: func(s *SettingEngine) {
+				s.DisableSRTPReplayProtection(true)
+			},
+			delivered: append(sequenceNumberRange(0, 50), sequenceNumberRange(150, 200)...),
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			settingEngine := SettingEngine{}
+			testCase.configure(&settingEngine)
+			pair, err := NewAPI(WithSettingEngine(settingEngine)).NewLoopbackPeerConnectionPair(Configuration{})
+			require.NoError(t, err)
+
+			track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+			require.NoError(t, err)
+			_, err = pair.Offerer.AddTrack(track)
+			require.NoError(t, err)
+
+			received := make(chan uint16, 300)
+			pair.Answerer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+				for {
+					pkt, _, readErr := trackRemote.ReadRTP()
+					if readErr != nil {
+						return
+					}
+					received <- pkt.SequenceNumber
+				}
+			})
+
+			connected := untilConnectionState(PeerConnectionStateConnected, pair.Offerer, pair.Answerer)
+			require.NoError(t, pair.Negotiate())
+			connected.Wait()
+
+			write := func(sequenceNumbers []uint16) {
+				for _, sequenceNumber := range sequenceNumbers {
+					assert.NoError(t, track.WriteRTP(&rtp.Packet{
+						Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber},
+						Payload: []byte{0x10, 0x00},
+					}))
+				}
+			}
+			read := func(count int) (sequenceNumbers []uint16) {
+				for range count {
+					sequenceNumbers = append(sequenceNumbers, <-received)
+				}
+
+				return sequenceNumbers
+			}
+
+			write(sequenceNumberRange(100, 200))
+			assert.Equal(t, sequenceNumberRange(100, 200), read(100))
+
+			// Packets older than the window that were never received, then replayed ones,
+			// followed by new packets to tell when the replayed range was handled
+			write(append(sequenceNumberRange(0, 50), sequenceNumberRange(150, 200)...))
+			write(sequenceNumberRange(200, 210))
+			assert.Equal(t,
+				append(testCase.delivered, sequenceNumberRange(200, 210)...),
+				read(len(testCase.delivered)+10))
+
+			var stats TransportStats
+			for _, s := range pair.Answerer.GetStats() {
+				if transportStats, ok := s.(TransportStats); ok {
+					stats = transportStats
+				}
+			}
+			assert.Equal(t, testCase.discarded, stats.SRTPPacketsReplayDiscarded)
+
+			require.NoError(t, pair.Close())
+		})
+	}
+}
+
+func sequenceNumberRange(from, to uint16) []uint16 {
+	sequenceNumbers := make([]uint16, 0, to-from)
+	for sequenceNumber := from; sequenceNumber < to; sequenceNumber++ {
+		sequenceNumbers = append(sequenceNumbers, sequenceNumber)
+	}
+
+	return sequenceNumbers
+}
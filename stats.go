@@ -172,6 +172,70 @@ func statsTimestampNow() StatsTimestamp {
 // StatsReport collects Stats objects indexed by their ID.
 type StatsReport map[string]Stats
 
+// GetICECandidateStats is a helper method to return the associated stats for a given ICECandidate.
+func (r StatsReport) GetICECandidateStats(c *ICECandidate) (ICECandidateStats, bool) {
+	statsID := c.statsID
+	stats, ok := r[statsID]
+	if !ok {
+		return ICECandidateStats{}, false
+	}
+
+	candidateStats, ok := stats.(ICECandidateStats)
+	if !ok {
+		return ICECandidateStats{}, false
+	}
+
+	return candidateStats, true
+}
+
+// GetICECandidatePairStats is a helper method to return the associated stats for a given ICECandidatePair.
+func (r StatsReport) GetICECandidatePairStats(c *ICECandidatePair) (ICECandidatePairStats, bool) {
+	statsID := c.statsID
+	stats, ok := r[statsID]
+	if !ok {
+		return ICECandidatePairStats{}, false
+	}
+
+	candidateStats, ok := stats.(ICECandidatePairStats)
+	if !ok {
+		return ICECandidatePairStats{}, false
+	}
+
+	return candidateStats, true
+}
+
+// GetCertificateStats is a helper method to return the associated stats for a given Certificate.
+func (r StatsReport) GetCertificateStats(c *Certificate) (CertificateStats, bool) {
+	statsID := c.statsID
+	stats, ok := r[statsID]
+	if !ok {
+		return CertificateStats{}, false
+	}
+
+	certificateStats, ok := stats.(CertificateStats)
+	if !ok {
+		return CertificateStats{}, false
+	}
+
+	return certificateStats, true
+}
+
+// GetCodecStats is a helper method to return the associated stats for a given Codec.
+func (r StatsReport) GetCodecStats(c *RTPCodecParameters) (CodecStats, bool) {
+	statsID := c.statsID
+	stats, ok := r[statsID]
+	if !ok {
+		return CodecStats{}, false
+	}
+
+	codecStats, ok := stats.(CodecStats)
+	if !ok {
+		return CodecStats{}, false
+	}
+
+	return codecStats, true
+}
+
 type statsReportCollector struct {
 	collectingGroup sync.WaitGroup
 	report          StatsReport
@@ -445,6 +509,9 @@ type InboundRTPStreamStats struct {
 	// PacketsDiscarded is the cumulative number of RTP packets discarded by the jitter
 	// buffer due to late or early-arrival, i.e., these packets are not played out.
 	// RTP packets discarded due to packet duplication are not reported in this metric.
+	// Pion has no jitter buffer, it reports the RTP packets dropped because the receive
+	// buffer of the SSRC was full, see SettingEngine.SetSRTPReceiveBufferSize, and the ones
+	// dropped before the track was read, see SettingEngine.SetReceiverPreBindBufferSize.
 	PacketsDiscarded uint32 `json:"packetsDiscarded"`
 
 	// PacketsRepaired is the cumulative number of lost RTP packets repaired after applying
@@ -529,6 +596,15 @@ type InboundRTPStreamStats struct {
 	// payload over the transport.
 	HeaderBytesReceived uint64 `json:"headerBytesReceived"`
 
+	// PaddingPacketsReceived is the total number of RTP packets without payload besides their
+	// padding received for this SSRC, including the probes on the RTX stream that are never
+	// returned by TrackRemote.Read.
+	PaddingPacketsReceived uint64 `json:"paddingPacketsReceived"`
+
+	// PaddingBytesReceived is the total number of RTP padding bytes received for this SSRC,
+	// including the padding of packets carrying payload.
+	PaddingBytesReceived uint64 `json:"paddingBytesReceived"`
+
 	// AverageRTCPInterval is the average RTCP interval between two consecutive compound RTCP packets.
 	// This is calculated by the sending endpoint when sending compound RTCP reports.
 	// Compound packets must contain at least a RTCP RR or SR packet and an SDES packet
@@ -683,6 +759,18 @@ type OutboundRTPStreamStats struct {
 	// HeaderBytesSent + BytesSent equals the number of bytes sent as payload over the transport.
 	HeaderBytesSent uint64 `json:"headerBytesSent"`
 
+	// PaddingPacketsSent is the total number of RTP packets without payload besides their
+	// padding sent for this SSRC, including the ones sent on the RTX stream.
+	PaddingPacketsSent uint64 `json:"paddingPacketsSent"`
+
+	// PaddingBytesSent is the total number of RTP padding bytes sent for this SSRC,
+	// including the padding of packets carrying payload.
+	PaddingBytesSent uint64 `json:"paddingBytesSent"`
+
+	// SourceStallFillerPacketsSent is the total number of RTP packets sent for this SSRC
+	// that carry the filler samples of TrackLocalStaticSample.SetSourceStallFiller.
+	SourceStallFillerPacketsSent uint64 `json:"sourceStallFillerPacketsSent"`
+
 	// RetransmittedPacketsSent is the total number of packets that were retransmitted for this SSRC.
 	// This is a subset of packetsSent. If RTX is not negotiated, retransmitted packets are sent
 	// over this ssrc. If RTX was negotiated, retransmitted packets are sent over a separate SSRC
@@ -707,6 +795,10 @@ type OutboundRTPStreamStats struct {
 	// received by the sender and is sent by receiver.
 	NACKCount uint32 `json:"nackCount"`
 
+	// FlushedNACKCount counts the sequence numbers NACKed by the receiver after they were
+	// dropped by RTPSender.FlushRetransmissionHistory. These NACKs are ignored.
+	FlushedNACKCount uint64 `json:"flushedNackCount"`
+
 	// SLICount counts the total number of Slice Loss Indication (SLI) packets received
 	// by the sender. This metric is only valid for video and is sent by receiver.
 	SLICount uint32 `json:"sliCount"`
@@ -1998,6 +2090,36 @@ type TransportStats struct {
 	// transport, as defined in the "Profile" column of the IANA DTLS-SRTP protection
 	// profile registry.
 	SRTPCipher string `json:"srtpCipher"`
+
+	// ProbeStreams is the number of streams of undeclared SSRCs that are held open
+	// while they are probed for a MID and RID. It is bounded by the simulcast probe
+	// stream limit of the SettingEngine.
+	ProbeStreams uint32 `json:"probeStreams,omitempty"`
+
+	// ProbeStreamsEvicted is the total number of probe streams that were closed
+	// because the simulcast probe stream limit was reached.
+	ProbeStreamsEvicted uint64 `json:"probeStreamsEvicted,omitempty"`
+
+	// DTLSHandshakeDuration is the time in seconds the DTLS handshake took, see
+	// PeerConnection.ConnectionTimeline.
+	DTLSHandshakeDuration float64 `json:"dtlsHandshakeDuration,omitempty"`
+
+	// ICEFirstCheckToNominated is the time in seconds from the first connectivity check
+	// to the first nominated candidate pair.
+	ICEFirstCheckToNominated float64 `json:"iceFirstCheckToNominated,omitempty"`
+
+	// RTCPPacketsSplit is the total number of RTCP writes that were split across several
+	// datagrams, because they were larger than SettingEngine.SetRTCPMaxPacketSize.
+	RTCPPacketsSplit uint64 `json:"rtcpPacketsSplit,omitempty"`
+
+	// SRTPPacketsReplayDiscarded is the total number of received SRTP packets that were
+	// discarded because they were replayed, or older than the replay protection window.
+	// See SettingEngine.SetSRTPReplayProtectionWindow.
+	SRTPPacketsReplayDiscarded uint64 `json:"srtpPacketsReplayDiscarded,omitempty"`
+
+	// SRTCPPacketsReplayDiscarded is the total number of received SRTCP packets that were
+	// discarded because they were replayed, or older than the replay protection window.
+	SRTCPPacketsReplayDiscarded uint64 `json:"srtcpPacketsReplayDiscarded,omitempty"`
 }
 
 func (s TransportStats) statsMarker() {}
@@ -2307,6 +2429,9 @@ type ICECandidateStats struct {
 	//
 	// DEPRECATED. Although it may still work in some browsers, the networkType property was deprecated for
 	// preserving privacy.
+	//
+	// The type is guessed from the name of the interface, see NetworkInterface. It is unknown if the
+	// name doesn't follow a known naming scheme, and empty if the interface can't be determined.
 	NetworkType string `json:"networkType,omitempty"`
 
 	// IP is the IP address of the candidate, allowing for IPv4 addresses and
@@ -2334,6 +2459,11 @@ type ICECandidateStats struct {
 	// the TURN URL protocol is one of udp, tcp, or tls.
 	RelayProtocol string `json:"relayProtocol"`
 
+	// NetworkInterface is the name of the interface a local candidate was gathered on.
+	// It is empty for remote candidates and if the interface can't be determined,
+	// like for mDNS candidates.
+	NetworkInterface string `json:"networkInterface,omitempty"`
+
 	// Deleted is true if the candidate has been deleted/freed. For host candidates,
 	// this means that any network resources (typically a socket) associated with the
 	// candidate have been released. For TURN candidates, this means the TURN allocation
@@ -16,6 +16,7 @@ import (
 	"time"
 
 	"github.com/pion/ice/v4"
+	"github.com/pion/transport/v4/test"
 	"github.com/pion/webrtc/v4/pkg/media"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -136,6 +137,8 @@ func getStatsSamples() []statSample { //nolint:cyclop,maintidx
 		FrameHeight:                    44,
 		LastPacketReceivedTimestamp:    1689668364374.181,
 		HeaderBytesReceived:            45,
+		PaddingPacketsReceived:         54,
+		PaddingBytesReceived:           55,
 		AverageRTCPInterval:            18,
 		FECPacketsReceived:             19,
 		FECPacketsDiscarded:            46,
@@ -210,6 +213,8 @@ func getStatsSamples() []statSample { //nolint:cyclop,maintidx
   "frameHeight": 44,
   "lastPacketReceivedTimestamp": 1689668364374.181,
   "headerBytesReceived": 45,
+  "paddingPacketsReceived": 54,
+  "paddingBytesReceived": 55,
   "averageRtcpInterval": 18,
   "fecPacketsReceived": 19,
   "fecPacketsDiscarded": 46,
@@ -229,46 +234,50 @@ func getStatsSamples() []statSample { //nolint:cyclop,maintidx
 }
 `
 	outboundRTPStreamStats := OutboundRTPStreamStats{
-		Mid:                      "1",
-		Rid:                      "hi",
-		MediaSourceID:            "SA5",
-		Timestamp:                1688978831527.718,
-		Type:                     StatsTypeOutboundRTP,
-		ID:                       "OT01A2184088143",
-		SSRC:                     2184088143,
-		Kind:                     "audio",
-		TransportID:              "T01",
-		CodecID:                  "COT01_111_minptime=10;useinbandfec=1",
-		HeaderBytesSent:          24,
-		RetransmittedPacketsSent: 25,
-		RetransmittedBytesSent:   26,
-		FIRCount:                 1,
-		PLICount:                 2,
-		NACKCount:                3,
-		SLICount:                 4,
-		QPSum:                    5,
-		PacketsSent:              6,
-		PacketsDiscardedOnSend:   7,
-		FECPacketsSent:           8,
-		BytesSent:                9,
-		BytesDiscardedOnSend:     10,
-		TrackID:                  "d57dbc4b-484b-4b40-9088-d3150e3a2010",
-		SenderID:                 "S01",
-		RemoteID:                 "ROA2184088143",
-		LastPacketSentTimestamp:  11,
-		TargetBitrate:            12,
-		TotalEncodedBytesTarget:  27,
-		FrameWidth:               28,
-		FrameHeight:              29,
-		FramesPerSecond:          30,
-		FramesSent:               31,
-		HugeFramesSent:           32,
-		FramesEncoded:            13,
-		KeyFramesEncoded:         33,
-		TotalEncodeTime:          14,
-		TotalPacketSendDelay:     34,
-		AverageRTCPInterval:      15,
-		QualityLimitationReason:  "cpu",
+		Mid:                          "1",
+		Rid:                          "hi",
+		MediaSourceID:                "SA5",
+		Timestamp:                    1688978831527.718,
+		Type:                         StatsTypeOutboundRTP,
+		ID:                           "OT01A2184088143",
+		SSRC:                         2184088143,
+		Kind:                         "audio",
+		TransportID:                  "T01",
+		CodecID:                      "COT01_111_minptime=10;useinbandfec=1",
+		HeaderBytesSent:              24,
+		PaddingPacketsSent:           36,
+		PaddingBytesSent:             37,
+		SourceStallFillerPacketsSent: 39,
+		RetransmittedPacketsSent:     25,
+		RetransmittedBytesSent:       26,
+		FIRCount:                     1,
+		PLICount:                     2,
+		NACKCount:                    3,
+		FlushedNACKCount:             38,
+		SLICount:                     4,
+		QPSum:                        5,
+		PacketsSent:                  6,
+		PacketsDiscardedOnSend:       7,
+		FECPacketsSent:               8,
+		BytesSent:                    9,
+		BytesDiscardedOnSend:         10,
+		TrackID:                      "d57dbc4b-484b-4b40-9088-d3150e3a2010",
+		SenderID:                     "S01",
+		RemoteID:                     "ROA2184088143",
+		LastPacketSentTimestamp:      11,
+		TargetBitrate:                12,
+		TotalEncodedBytesTarget:      27,
+		FrameWidth:                   28,
+		FrameHeight:                  29,
+		FramesPerSecond:              30,
+		FramesSent:                   31,
+		HugeFramesSent:               32,
+		FramesEncoded:                13,
+		KeyFramesEncoded:             33,
+		TotalEncodeTime:              14,
+		TotalPacketSendDelay:         34,
+		AverageRTCPInterval:          15,
+		QualityLimitationReason:      "cpu",
 		QualityLimitationDurations: map[string]float64{
 			"none":      16,
 			"cpu":       17,
@@ -297,11 +306,15 @@ func getStatsSamples() []statSample { //nolint:cyclop,maintidx
   "transportId": "T01",
   "codecId": "COT01_111_minptime=10;useinbandfec=1",
   "headerBytesSent": 24,
+  "paddingPacketsSent": 36,
+  "paddingBytesSent": 37,
+  "sourceStallFillerPacketsSent": 39,
   "retransmittedPacketsSent": 25,
   "retransmittedBytesSent": 26,
   "firCount": 1,
   "pliCount": 2,
   "nackCount": 3,
+  "flushedNackCount": 38,
   "sliCount": 4,
   "qpSum": 5,
   "packetsSent": 6,
@@ -1540,12 +1553,12 @@ func TestPeerConnection_GetStats(t *testing.T) { //nolint:cyclop // involves mul
 		}
 	}
 	assert.NoError(t, err)
-	for i := range offerPC.api.mediaEngine.videoCodecs {
-		codecStat := getCodecStats(t, reportPCOffer, &(offerPC.api.mediaEngine.videoCodecs[i]))
+	for i := range offerPC.api.mediaEngine.negotiatedVideoCodecs {
+		codecStat := getCodecStats(t, reportPCOffer, &(offerPC.api.mediaEngine.negotiatedVideoCodecs[i]))
 		assert.NotEmpty(t, codecStat)
 	}
-	for i := range offerPC.api.mediaEngine.audioCodecs {
-		codecStat := getCodecStats(t, reportPCOffer, &(offerPC.api.mediaEngine.audioCodecs[i]))
+	for i := range offerPC.api.mediaEngine.negotiatedAudioCodecs {
+		codecStat := getCodecStats(t, reportPCOffer, &(offerPC.api.mediaEngine.negotiatedAudioCodecs[i]))
 		assert.NotEmpty(t, codecStat)
 	}
 
@@ -1606,6 +1619,89 @@ func TestPeerConnection_GetStats_Closed(t *testing.T) {
 	pc.GetStats()
 }
 
+func TestPeerConnection_GetStats_References(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	offerPC, answerPC, err := newPair()
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = offerPC.AddTrack(track)
+	require.NoError(t, err)
+
+	received := make(chan struct{})
+	var receivedOnce sync.Once
+	answerPC.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		for {
+			if _, _, readErr := trackRemote.ReadRTP(); readErr != nil {
+				return
+			}
+			receivedOnce.Do(func() { close(received) })
+		}
+	})
+
+	connected := untilConnectionState(PeerConnectionStateConnected, offerPC, answerPC)
+	require.NoError(t, signalPair(offerPC, answerPC))
+	connected.Wait()
+	sendVideoUntilDone(t, received, []*TrackLocalStaticSample{track})
+
+	// Walks from the RTP stream stats to its codec, transport and certificates
+	walk := func(t *testing.T, stats StatsReport, codecID, transportID string) {
+		t.Helper()
+
+		codec, ok := stats[codecID].(CodecStats)
+		require.True(t, ok, "codec %q", codecID)
+		assert.Equal(t, MimeTypeVP8, codec.MimeType)
+		assert.Equal(t, uint32(90000), codec.ClockRate)
+		assert.NotZero(t, codec.PayloadType)
+		assert.Equal(t, transportID, codec.TransportID)
+
+		transport, ok := stats[transportID].(TransportStats)
+		require.True(t, ok, "transport %q", transportID)
+
+		for _, certificateID := range []string{transport.LocalCertificateID, transport.RemoteCertificateID} {
+			certificate, ok := stats[certificateID].(CertificateStats)
+			require.True(t, ok, "certificate %q", certificateID)
+			assert.Equal(t, "sha-256", certificate.FingerprintAlgorithm)
+			assert.NotEmpty(t, certificate.Fingerprint)
+			assert.NotEmpty(t, certificate.Base64Certificate)
+			assert.Empty(t, certificate.IssuerCertificateID)
+		}
+	}
+
+	answerStats := answerPC.GetStats()
+	inbound := findInboundRTPStats(answerStats)
+	require.Len(t, inbound, 1)
+	walk(t, answerStats, inbound[0].CodecID, inbound[0].TransportID)
+
+	offerStats := offerPC.GetStats()
+	var outbound []OutboundRTPStreamStats
+	for _, s := range offerStats {
+		if stats, ok := s.(OutboundRTPStreamStats); ok {
+			outbound = append(outbound, stats)
+		}
+	}
+	require.Len(t, outbound, 1)
+	walk(t, offerStats, outbound[0].CodecID, outbound[0].TransportID)
+
+	// The remote certificate of each side is the local certificate of the other one
+	offerTransport, ok := offerStats["iceTransport"].(TransportStats)
+	require.True(t, ok)
+	answerTransport, ok := answerStats["iceTransport"].(TransportStats)
+	require.True(t, ok)
+	assert.Equal(t,
+		offerStats[offerTransport.LocalCertificateID].(CertificateStats).Fingerprint,    //nolint:forcetypeassert
+		answerStats[answerTransport.RemoteCertificateID].(CertificateStats).Fingerprint, //nolint:forcetypeassert
+	)
+
+	closePairNow(t, offerPC, answerPC)
+}
+
 func TestUnmarshalStatsJSON_TypeFieldUnmarshalError(t *testing.T) {
 	input := []byte(`{"type":123}`)
 
@@ -0,0 +1,60 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build js && wasm
+// +build js,wasm
+
+package webrtc
+
+import "syscall/js"
+
+// GetStats return data providing statistics about the overall connection.
+// The stats of the browser are converted with UnmarshalStatsJSON, stats of
+// types Pion doesn't know are left out.
+func (pc *PeerConnection) GetStats() StatsReport {
+	report := StatsReport{}
+
+	value, err := awaitPromise(pc.underlying.Call("getStats"))
+	if err != nil {
+		return report
+	}
+
+	stringify := js.Global().Get("JSON").Get("stringify")
+	forEach := js.FuncOf(func(this js.Value, args []js.Value) any {
+		stats, err := UnmarshalStatsJSON([]byte(stringify.Invoke(args[0]).String()))
+		if err == nil {
+			report[args[1].String()] = stats
+		}
+		return js.Undefined()
+	})
+	defer forEach.Release()
+	value.Call("forEach", forEach)
+
+	return report
+}
+
+// GetConnectionStats is a helper method to return the associated stats for a given PeerConnection.
+// The browser reports the stats of a single PeerConnection, with an ID of its own.
+func (r StatsReport) GetConnectionStats(conn *PeerConnection) (PeerConnectionStats, bool) {
+	for _, stats := range r {
+		if pcStats, ok := stats.(PeerConnectionStats); ok {
+			return pcStats, true
+		}
+	}
+
+	return PeerConnectionStats{}, false
+}
+
+// GetDataChannelStats is a helper method to return the associated stats for a given DataChannel.
+// The browser uses IDs of its own, the stats are matched by the label and id of the DataChannel.
+func (r StatsReport) GetDataChannelStats(dc *DataChannel) (DataChannelStats, bool) {
+	id := dc.ID()
+	for _, stats := range r {
+		dcStats, ok := stats.(DataChannelStats)
+		if ok && dcStats.Label == dc.Label() && id != nil && dcStats.DataChannelIdentifier == int32(*id) {
+			return dcStats, true
+		}
+	}
+
+	return DataChannelStats{}, false
+}
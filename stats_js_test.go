@@ -0,0 +1,56 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build js && wasm
+// +build js,wasm
+
+package webrtc
+
+import (
+	"syscall/js"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPeerConnection_GetStats(t *testing.T) {
+	// Mock the RTCStatsReport of the browser, a Map of the stats by their ID
+	statsMap := js.Global().Get("Map").New()
+	for id, stats := range map[string]string{
+		"P1": `{"id":"P1","type":"peer-connection","timestamp":1700000000000,"dataChannelsOpened":1}`,
+		"D1": `{"id":"D1","type":"data-channel","timestamp":1700000000000,"label":"chat",` +
+			`"dataChannelIdentifier":1,"messagesSent":3}`,
+		"X1": `{"id":"X1","type":"not-a-stats-type","timestamp":1700000000000}`,
+	} {
+		statsMap.Call("set", id, js.Global().Get("JSON").Call("parse", stats))
+	}
+
+	getStats := js.FuncOf(func(this js.Value, args []js.Value) any {
+		return js.Global().Get("Promise").Call("resolve", statsMap)
+	})
+	defer getStats.Release()
+	underlying := js.Global().Get("Object").New()
+	underlying.Set("getStats", getStats)
+	pc := &PeerConnection{underlying: underlying}
+
+	report := pc.GetStats()
+	require.Len(t, report, 2)
+
+	pcStats, ok := report.GetConnectionStats(pc)
+	require.True(t, ok)
+	assert.Equal(t, "P1", pcStats.ID)
+	assert.Equal(t, uint32(1), pcStats.DataChannelsOpened)
+
+	dcUnderlying := js.Global().Get("Object").New()
+	dcUnderlying.Set("label", "chat")
+	dcUnderlying.Set("id", 1)
+	dcStats, ok := report.GetDataChannelStats(&DataChannel{underlying: dcUnderlying})
+	require.True(t, ok)
+	assert.Equal(t, "D1", dcStats.ID)
+	assert.Equal(t, uint32(3), dcStats.MessagesSent)
+
+	dcUnderlying.Set("id", 2)
+	_, ok = report.GetDataChannelStats(&DataChannel{underlying: dcUnderlying})
+	assert.False(t, ok)
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"math"
+	"time"
+)
+
+// StatsRates contains the rates derived from the counters of a Stats object
+// between two StatsReports, see StatsReport.DeltaSince.
+type StatsRates struct {
+	// ID is the ID of the Stats object.
+	ID string `json:"id"`
+
+	// Type is the type of the Stats object.
+	Type StatsType `json:"type"`
+
+	// Timestamp is the timestamp of the Stats object in the current report.
+	Timestamp StatsTimestamp `json:"timestamp"`
+
+	// Interval is the time elapsed between the Stats objects of the two reports.
+	Interval time.Duration `json:"interval"`
+
+	// Discontinuity is set when the rates can't be derived because the counters of the
+	// Stats object don't continue those of the previous report: the object is new, a
+	// counter went backwards as after an ICE restart or the recreation of a sender,
+	// or the timestamps didn't advance. All the rates are zero then.
+	Discontinuity bool `json:"discontinuity"`
+
+	// BitsPerSecondSent is the rate of the bytes sent, in bits per second.
+	BitsPerSecondSent float64 `json:"bitsPerSecondSent"`
+
+	// BitsPerSecondReceived is the rate of the bytes received, in bits per second.
+	BitsPerSecondReceived float64 `json:"bitsPerSecondReceived"`
+
+	// PacketsPerSecondSent is the rate of the packets sent.
+	PacketsPerSecondSent float64 `json:"packetsPerSecondSent"`
+
+	// PacketsPerSecondReceived is the rate of the packets received.
+	PacketsPerSecondReceived float64 `json:"packetsPerSecondReceived"`
+
+	// MessagesPerSecondSent is the rate of the messages sent by a DataChannel.
+	MessagesPerSecondSent float64 `json:"messagesPerSecondSent"`
+
+	// MessagesPerSecondReceived is the rate of the messages received by a DataChannel.
+	MessagesPerSecondReceived float64 `json:"messagesPerSecondReceived"`
+}
+
+// StatsRatesReport collects StatsRates indexed by the ID of their Stats object.
+type StatsRatesReport map[string]StatsRates
+
+// statsCounter is a counter of a Stats object, bits is the width of its field.
+type statsCounter struct {
+	value uint64
+	bits  int
+}
+
+type statsCounters struct {
+	id        string
+	typ       StatsType
+	timestamp StatsTimestamp
+
+	bytesSent, bytesReceived       *statsCounter
+	packetsSent, packetsReceived   *statsCounter
+	messagesSent, messagesReceived *statsCounter
+}
+
+func counter64(value uint64) *statsCounter {
+	return &statsCounter{value: value, bits: 64}
+}
+
+func counter32(value uint32) *statsCounter {
+	return &statsCounter{value: uint64(value), bits: 32}
+}
+
+// countersFromStats returns the counters of the Stats objects that have some.
+//
+//nolint:cyclop
+func countersFromStats(stats Stats) (statsCounters, bool) {
+	switch stats := stats.(type) {
+	case InboundRTPStreamStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			bytesReceived:   counter64(stats.BytesReceived),
+			packetsReceived: counter32(stats.PacketsReceived),
+		}, true
+	case OutboundRTPStreamStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			bytesSent:   counter64(stats.BytesSent),
+			packetsSent: counter32(stats.PacketsSent),
+		}, true
+	case RemoteInboundRTPStreamStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			packetsReceived: counter32(stats.PacketsReceived),
+		}, true
+	case RemoteOutboundRTPStreamStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			bytesSent:   counter64(stats.BytesSent),
+			packetsSent: counter32(stats.PacketsSent),
+		}, true
+	case DataChannelStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			bytesSent:        counter64(stats.BytesSent),
+			bytesReceived:    counter64(stats.BytesReceived),
+			messagesSent:     counter32(stats.MessagesSent),
+			messagesReceived: counter32(stats.MessagesReceived),
+		}, true
+	case TransportStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			bytesSent:       counter64(stats.BytesSent),
+			bytesReceived:   counter64(stats.BytesReceived),
+			packetsSent:     counter32(stats.PacketsSent),
+			packetsReceived: counter32(stats.PacketsReceived),
+		}, true
+	case ICECandidatePairStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			bytesSent:       counter64(stats.BytesSent),
+			bytesReceived:   counter64(stats.BytesReceived),
+			packetsSent:     counter32(stats.PacketsSent),
+			packetsReceived: counter32(stats.PacketsReceived),
+		}, true
+	case SCTPTransportStats:
+		return statsCounters{
+			id: stats.ID, typ: stats.Type, timestamp: stats.Timestamp,
+			bytesSent:     counter64(stats.BytesSent),
+			bytesReceived: counter64(stats.BytesReceived),
+		}, true
+	default:
+		return statsCounters{}, false
+	}
+}
+
+// counterDelta returns how much a counter increased, false if it went backwards. A 32 bit
+// counter that decreased by more than half its range is assumed to have wrapped around.
+func counterDelta(previous, current *statsCounter) (uint64, bool) {
+	switch {
+	case current.value >= previous.value:
+		return current.value - previous.value, true
+	case current.bits == 32:
+		if delta := uint64(uint32(current.value - previous.value)); delta < math.MaxUint32/2 { //nolint:gosec // G115
+			return delta, true
+		}
+	}
+
+	return 0, false
+}
+
+// DeltaSince derives the rates of the counters of the Stats objects of the report, such as
+// the bytes and packets sent and received, from their values in a previous report, using
+// the timestamps of the Stats objects. The report contains an entry for every Stats object
+// that has counters, flagged as a discontinuity if it has none in previous or if its counters
+// were reset, see StatsRates. It only computes, so reports can be compared in any context.
+//
+//nolint:cyclop
+func (r StatsReport) DeltaSince(previous StatsReport) StatsRatesReport {
+	rates := StatsRatesReport{}
+
+	for id, stats := range r {
+		current, ok := countersFromStats(stats)
+		if !ok {
+			continue
+		}
+
+		statsRates := StatsRates{ID: current.id, Type: current.typ, Timestamp: current.timestamp}
+		last, ok := countersFromStats(previous[id])
+		if !ok || last.typ != current.typ || current.timestamp <= last.timestamp {
+			statsRates.Discontinuity = true
+			rates[id] = statsRates
+
+			continue
+		}
+
+		statsRates.Interval = current.timestamp.Time().Sub(last.timestamp.Time())
+		seconds := statsRates.Interval.Seconds()
+		for _, counter := range []struct {
+			previous, current *statsCounter
+			rate              *float64
+			scale             float64
+		}{
+			{last.bytesSent, current.bytesSent, &statsRates.BitsPerSecondSent, 8},
+			{last.bytesReceived, current.bytesReceived, &statsRates.BitsPerSecondReceived, 8},
+			{last.packetsSent, current.packetsSent, &statsRates.PacketsPerSecondSent, 1},
+			{last.packetsReceived, current.packetsReceived, &statsRates.PacketsPerSecondReceived, 1},
+			{last.messagesSent, current.messagesSent, &statsRates.MessagesPerSecondSent, 1},
+			{last.messagesReceived, current.messagesReceived, &statsRates.MessagesPerSecondReceived, 1},
+		} {
+			if counter.current == nil {
+				continue
+			}
+
+			delta, ok := counterDelta(counter.previous, counter.current)
+			if !ok {
+				statsRates = StatsRates{
+					ID: statsRates.ID, Type: statsRates.Type, Timestamp: statsRates.Timestamp,
+					Interval: statsRates.Interval, Discontinuity: true,
+				}
+
+				break
+			}
+			*counter.rate = float64(delta) * counter.scale / seconds
+		}
+
+		rates[id] = statsRates
+	}
+
+	return rates
+}
@@ -0,0 +1,154 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsReport_DeltaSince(t *testing.T) {
+	const start = StatsTimestamp(1700000000000)
+
+	for _, testCase := range []struct {
+		name     string
+		previous Stats
+		current  Stats
+		expected StatsRates
+	}{
+		{
+			name: "Outbound RTP",
+			previous: OutboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeOutboundRTP, Timestamp: start, BytesSent: 1000, PacketsSent: 10,
+			},
+			current: OutboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeOutboundRTP, Timestamp: start + 2000, BytesSent: 251000, PacketsSent: 210,
+			},
+			expected: StatsRates{
+				ID: "stream", Type: StatsTypeOutboundRTP, Timestamp: start + 2000, Interval: 2 * time.Second,
+				BitsPerSecondSent: 1000000, PacketsPerSecondSent: 100,
+			},
+		},
+		{
+			name: "Inbound RTP",
+			previous: InboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeInboundRTP, Timestamp: start, BytesReceived: 5000, PacketsReceived: 50,
+			},
+			current: InboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeInboundRTP, Timestamp: start + 500, BytesReceived: 17500, PacketsReceived: 75,
+			},
+			expected: StatsRates{
+				ID: "stream", Type: StatsTypeInboundRTP, Timestamp: start + 500, Interval: 500 * time.Millisecond,
+				BitsPerSecondReceived: 200000, PacketsPerSecondReceived: 50,
+			},
+		},
+		{
+			name: "Data channel",
+			previous: DataChannelStats{
+				ID: "channel", Type: StatsTypeDataChannel, Timestamp: start,
+				MessagesSent: 1, MessagesReceived: 2, BytesSent: 100, BytesReceived: 200,
+			},
+			current: DataChannelStats{
+				ID: "channel", Type: StatsTypeDataChannel, Timestamp: start + 1000,
+				MessagesSent: 11, MessagesReceived: 2, BytesSent: 1100, BytesReceived: 200,
+			},
+			expected: StatsRates{
+				ID: "channel", Type: StatsTypeDataChannel, Timestamp: start + 1000, Interval: time.Second,
+				BitsPerSecondSent: 8000, MessagesPerSecondSent: 10,
+			},
+		},
+		{
+			name: "Packet counter wrap",
+			previous: TransportStats{
+				ID: "transport", Type: StatsTypeTransport, Timestamp: start,
+				PacketsSent: math.MaxUint32 - 9, BytesSent: 1 << 40,
+			},
+			current: TransportStats{
+				ID: "transport", Type: StatsTypeTransport, Timestamp: start + 1000,
+				PacketsSent: 90, BytesSent: 1<<40 + 100000,
+			},
+			expected: StatsRates{
+				ID: "transport", Type: StatsTypeTransport, Timestamp: start + 1000, Interval: time.Second,
+				BitsPerSecondSent: 800000, PacketsPerSecondSent: 100,
+			},
+		},
+		{
+			name: "Counter reset",
+			previous: ICECandidatePairStats{
+				ID: "pair", Type: StatsTypeCandidatePair, Timestamp: start, PacketsReceived: 5000, BytesReceived: 500000,
+			},
+			current: ICECandidatePairStats{
+				ID: "pair", Type: StatsTypeCandidatePair, Timestamp: start + 1000, PacketsReceived: 20, BytesReceived: 2000,
+			},
+			expected: StatsRates{
+				ID: "pair", Type: StatsTypeCandidatePair, Timestamp: start + 1000, Interval: time.Second,
+				Discontinuity: true,
+			},
+		},
+		{
+			name: "Byte counter reset",
+			previous: SCTPTransportStats{
+				ID: "sctp", Type: StatsTypeSCTPTransport, Timestamp: start, BytesSent: 1000, BytesReceived: 1000,
+			},
+			current: SCTPTransportStats{
+				ID: "sctp", Type: StatsTypeSCTPTransport, Timestamp: start + 1000, BytesSent: 2000, BytesReceived: 10,
+			},
+			expected: StatsRates{
+				ID: "sctp", Type: StatsTypeSCTPTransport, Timestamp: start + 1000, Interval: time.Second,
+				Discontinuity: true,
+			},
+		},
+		{
+			name: "New object",
+			current: RemoteInboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeRemoteInboundRTP, Timestamp: start, PacketsReceived: 10,
+			},
+			expected: StatsRates{
+				ID: "stream", Type: StatsTypeRemoteInboundRTP, Timestamp: start, Discontinuity: true,
+			},
+		},
+		{
+			name: "Timestamps not advancing",
+			previous: RemoteOutboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeRemoteOutboundRTP, Timestamp: start, BytesSent: 10,
+			},
+			current: RemoteOutboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeRemoteOutboundRTP, Timestamp: start, BytesSent: 20,
+			},
+			expected: StatsRates{
+				ID: "stream", Type: StatsTypeRemoteOutboundRTP, Timestamp: start, Discontinuity: true,
+			},
+		},
+		{
+			name: "Type changed",
+			previous: InboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeInboundRTP, Timestamp: start,
+			},
+			current: OutboundRTPStreamStats{
+				ID: "stream", Type: StatsTypeOutboundRTP, Timestamp: start + 1000,
+			},
+			expected: StatsRates{
+				ID: "stream", Type: StatsTypeOutboundRTP, Timestamp: start + 1000, Discontinuity: true,
+			},
+		},
+	} {
+		t.Run(testCase.name, func(t *testing.T) {
+			previous := StatsReport{}
+			if testCase.previous != nil {
+				previous["id"] = testCase.previous
+			}
+			current := StatsReport{
+				"id":    testCase.current,
+				"codec": CodecStats{ID: "codec", Type: StatsTypeCodec, Timestamp: start},
+			}
+
+			assert.Equal(t, StatsRatesReport{"id": testCase.expected}, current.DeltaSince(previous))
+		})
+	}
+}
@@ -0,0 +1,383 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"net"
+
+	"github.com/pion/ice/v4"
+	"github.com/pion/logging"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v4"
+)
+
+// STUNBindingRequestHandler is called for every inbound STUN Binding Request before
+// the ICE Agent processes it. Returning true drops the request.
+type STUNBindingRequestHandler func(m *stun.Message, local, remote net.Addr) (drop bool)
+
+// stunBindingRequestFilter applies the ICE binding request filter and the
+// STUNBindingRequestHandler of the SettingEngine to inbound STUN Binding Requests.
+type stunBindingRequestFilter struct {
+	drop  STUNBindingRequestHandler
+	admit func(m *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair) bool
+	// candidates resolves the addresses of a request to the candidates of the ICE Agent
+	candidates func(local, remote net.Addr) (ice.Candidate, ice.Candidate, error)
+	// password returns the local ICE password, which authenticates the error responses
+	password func() (string, bool)
+	log      logging.LeveledLogger
+}
+
+// filter returns true if the packet is a STUN Binding Request that was dropped or rejected.
+// The error response of a rejected request is written to conn.
+func (f *stunBindingRequestFilter) filter(conn net.PacketConn, packet []byte, local, remote net.Addr) (filtered bool) {
+	if !stun.IsMessage(packet) {
+		return false
+	}
+
+	msg := &stun.Message{Raw: append([]byte{}, packet...)}
+	if err := msg.Decode(); err != nil || msg.Type != stun.BindingRequest {
+		return false
+	}
+
+	// A panic of a handler is logged and drops the request, instead of ending the read loop
+	defer func() {
+		if r := recover(); r != nil {
+			f.log.Errorf("STUNBindingRequestHandler panicked, dropping Binding Request from %s: %v", remote, r)
+			filtered = true
+		}
+	}()
+
+	if f.admit != nil && !f.admitted(msg, local, remote) {
+		f.sendForbidden(conn, msg, remote)
+
+		return true
+	}
+
+	return f.drop != nil && f.drop(msg, local, remote)
+}
+
+// admitted calls the ICE binding request filter with the candidates of the request. The ICE
+// Agent didn't check the request yet, so pair only pairs the candidates, it has no state.
+func (f *stunBindingRequestFilter) admitted(msg *stun.Message, local, remote net.Addr) bool {
+	localCandidate, remoteCandidate, err := f.candidates(local, remote)
+	if err != nil {
+		f.log.Warnf("Failed to resolve the candidates of Binding Request from %s, rejecting it: %v", remote, err)
+
+		return false
+	}
+
+	pair := &ice.CandidatePair{Local: localCandidate, Remote: remoteCandidate}
+
+	return f.admit(msg, localCandidate, remoteCandidate, pair)
+}
+
+// sendForbidden answers request with a 403 (Forbidden) error response. It is authenticated
+// with the local ICE password like the success responses of the ICE Agent, RFC 8445 7.3.
+func (f *stunBindingRequestFilter) sendForbidden(conn net.PacketConn, request *stun.Message, remote net.Addr) {
+	setters := []stun.Setter{
+		stun.NewType(stun.MethodBinding, stun.ClassErrorResponse),
+		stun.NewTransactionIDSetter(request.TransactionID),
+		stun.CodeForbidden,
+	}
+	if password, ok := f.password(); ok {
+		setters = append(setters, stun.NewShortTermIntegrity(password))
+	}
+	setters = append(setters, stun.Fingerprint)
+
+	response, err := stun.Build(setters...)
+	if err != nil {
+		f.log.Warnf("Failed to build error response to Binding Request from %s: %v", remote, err)
+
+		return
+	}
+	if _, err = conn.WriteTo(response.Raw, remote); err != nil {
+		f.log.Debugf("Failed to send error response to Binding Request from %s: %v", remote, err)
+	}
+}
+
+// stunFilterPacketConn hides the Binding Requests dropped by the handler from the ICE Agent.
+type stunFilterPacketConn struct {
+	net.PacketConn
+	filter *stunBindingRequestFilter
+}
+
+func (c *stunFilterPacketConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	for {
+		n, addr, err = c.PacketConn.ReadFrom(p)
+		if err != nil || !c.filter.filter(c.PacketConn, p[:n], c.LocalAddr(), addr) {
+			return n, addr, err
+		}
+	}
+}
+
+// stunFilterUDPConn is the transport.UDPConn equivalent of stunFilterPacketConn.
+type stunFilterUDPConn struct {
+	transport.UDPConn
+	filter *stunBindingRequestFilter
+}
+
+func (c *stunFilterUDPConn) ReadFrom(p []byte) (n int, addr net.Addr, err error) {
+	for {
+		n, addr, err = c.UDPConn.ReadFrom(p)
+		if err != nil || !c.filter.filter(c.UDPConn, p[:n], c.LocalAddr(), addr) {
+			return n, addr, err
+		}
+	}
+}
+
+// stunFilterNet wraps the UDP sockets the ICE Agent listens on.
+type stunFilterNet struct {
+	transport.Net
+	filter *stunBindingRequestFilter
+}
+
+func (n *stunFilterNet) ListenPacket(network string, address string) (net.PacketConn, error) {
+	conn, err := n.Net.ListenPacket(network, address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stunFilterPacketConn{PacketConn: conn, filter: n.filter}, nil
+}
+
+func (n *stunFilterNet) ListenUDP(network string, locAddr *net.UDPAddr) (transport.UDPConn, error) {
+	conn, err := n.Net.ListenUDP(network, locAddr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stunFilterUDPConn{UDPConn: conn, filter: n.filter}, nil
+}
+
+// stunFilterUDPMux wraps the connections an ice.UDPMux hands to the ICE Agent.
+type stunFilterUDPMux struct {
+	ice.UDPMux
+	filter          *stunBindingRequestFilter
+	includeLoopback bool
+}
+
+func (m *stunFilterUDPMux) GetConn(ufrag string, addr net.Addr) (net.PacketConn, error) {
+	conn, err := m.UDPMux.GetConn(ufrag, addr)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stunFilterPacketConn{PacketConn: conn, filter: m.filter}, nil
+}
+
+// GetListenAddresses skips loopback addresses of an ice.UDPMuxDefault like the ICE Agent
+// does, since it can't detect the wrapped type.
+func (m *stunFilterUDPMux) GetListenAddresses() []net.Addr {
+	addresses := m.UDPMux.GetListenAddresses()
+	if _, ok := m.UDPMux.(*ice.UDPMuxDefault); !ok || m.includeLoopback {
+		return addresses
+	}
+
+	filtered := make([]net.Addr, 0, len(addresses))
+	for _, addr := range addresses {
+		if udpAddr, ok := addr.(*net.UDPAddr); ok && udpAddr.IP.IsLoopback() {
+			continue
+		}
+		filtered = append(filtered, addr)
+	}
+
+	return filtered
+}
+
+// stunFilterTCPMux wraps the connections an ice.TCPMux hands to the ICE Agent. It keeps the
+// optional methods the ICE Agent looks for on the wrapped TCPMux.
+type stunFilterTCPMux struct {
+	ice.TCPMux
+	filter *stunBindingRequestFilter
+}
+
+func (m *stunFilterTCPMux) GetConnByUfrag(ufrag string, isIPv6 bool, local net.IP) (net.PacketConn, error) {
+	conn, err := m.TCPMux.GetConnByUfrag(ufrag, isIPv6, local)
+	if err != nil {
+		return nil, err
+	}
+
+	return &stunFilterPacketConn{PacketConn: conn, filter: m.filter}, nil
+}
+
+func (m *stunFilterTCPMux) GetAllConns(ufrag string, isIPv6 bool, local net.IP) ([]net.PacketConn, error) {
+	multi, ok := m.TCPMux.(ice.AllConnsGetter)
+	if !ok {
+		conn, err := m.GetConnByUfrag(ufrag, isIPv6, local)
+		if err != nil {
+			return nil, err
+		}
+
+		return []net.PacketConn{conn}, nil
+	}
+
+	conns, err := multi.GetAllConns(ufrag, isIPv6, local)
+	if err != nil {
+		return nil, err
+	}
+	for i := range conns {
+		conns[i] = &stunFilterPacketConn{PacketConn: conns[i], filter: m.filter}
+	}
+
+	return conns, nil
+}
+
+func (m *stunFilterTCPMux) LocalAddr() net.Addr {
+	if addrProvider, ok := m.TCPMux.(interface{ LocalAddr() net.Addr }); ok {
+		return addrProvider.LocalAddr()
+	}
+
+	return nil
+}
+
+// stunBindingRequestOptions routes inbound STUN Binding Requests through the ICE binding
+// request filter and the STUNBindingRequestHandler. They override the Net, UDPMux and TCPMux
+// of baseAgentOptions, agentNet is the Net of baseAgentOptions.
+func (g *ICEGatherer) stunBindingRequestOptions(agentNet transport.Net) []ice.AgentOption {
+	settingEngine := g.api.settingEngine
+	if settingEngine.stunBindingRequestHandler == nil && settingEngine.iceBindingRequestFilter == nil {
+		return nil
+	}
+	filter := &stunBindingRequestFilter{
+		drop:       settingEngine.stunBindingRequestHandler,
+		admit:      settingEngine.iceBindingRequestFilter,
+		candidates: g.bindingRequestCandidates,
+		password:   g.localPassword,
+		log:        g.log,
+	}
+
+	options := []ice.AgentOption{ice.WithNet(&stunFilterNet{Net: agentNet, filter: filter})}
+	if udpMux := settingEngine.iceUDPMux; udpMux != nil {
+		options = append(options, ice.WithUDPMux(&stunFilterUDPMux{
+			UDPMux:          udpMux,
+			filter:          filter,
+			includeLoopback: settingEngine.candidates.IncludeLoopbackCandidate,
+		}))
+	}
+	if tcpMux := settingEngine.iceTCPMux; tcpMux != nil {
+		options = append(options, ice.WithTCPMux(&stunFilterTCPMux{TCPMux: tcpMux, filter: filter}))
+	}
+
+	return options
+}
+
+// localPassword returns the current local ICE password of the agent.
+func (g *ICEGatherer) localPassword() (string, bool) {
+	agent := g.getAgent()
+	if agent == nil {
+		return "", false
+	}
+
+	_, password, err := agent.GetLocalUserCredentials()
+
+	return password, err == nil
+}
+
+// bindingRequestCandidates returns the local and remote candidates of the agent a Binding
+// Request was sent between. A remote the agent doesn't know yet is a peer reflexive candidate,
+// like the agent learns it once it checked the request, RFC 8445 Section 7.3.1.3.
+func (g *ICEGatherer) bindingRequestCandidates(local, remote net.Addr) (ice.Candidate, ice.Candidate, error) {
+	agent := g.getAgent()
+	if agent == nil {
+		return nil, nil, fmt.Errorf("%w: unable to resolve candidates", errICEAgentNotExist)
+	}
+
+	locals, err := agent.GetLocalCandidates()
+	if err != nil {
+		return nil, nil, err
+	}
+	remotes, err := agent.GetRemoteCandidates()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	localCandidate := findCandidate(locals, local)
+	if localCandidate == nil {
+		localCandidate, err = newCandidateForAddr(local, func(network, address string, port int) (ice.Candidate, error) {
+			return ice.NewCandidateHost(&ice.CandidateHostConfig{
+				Network:   network,
+				Address:   address,
+				Port:      port,
+				Component: ice.ComponentRTP,
+			})
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	remoteCandidate := findCandidate(remotes, remote)
+	if remoteCandidate == nil {
+		remoteCandidate, err = newCandidateForAddr(remote, func(network, address string, port int) (ice.Candidate, error) {
+			return ice.NewCandidatePeerReflexive(&ice.CandidatePeerReflexiveConfig{
+				Network:   network,
+				Address:   address,
+				Port:      port,
+				Component: ice.ComponentRTP,
+			})
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	return localCandidate, remoteCandidate, nil
+}
+
+// findCandidate returns the candidate of candidates with the address addr, or nil. The
+// candidates of a mux listening on an unspecified address have the addresses of the
+// interfaces, so only the port of such an address is compared.
+func findCandidate(candidates []ice.Candidate, addr net.Addr) ice.Candidate {
+	ip, port, isTCP, ok := splitCandidateAddr(addr)
+	if !ok {
+		return nil
+	}
+
+	for _, candidate := range candidates {
+		if candidate.Port() != port || candidate.NetworkType().IsTCP() != isTCP {
+			continue
+		}
+		if ip.IsUnspecified() {
+			return candidate
+		}
+		if candidateIP := net.ParseIP(candidate.Address()); candidateIP != nil && candidateIP.Equal(ip) {
+			return candidate
+		}
+	}
+
+	return nil
+}
+
+// newCandidateForAddr creates a candidate with the address addr.
+func newCandidateForAddr(
+	addr net.Addr,
+	newCandidate func(network, address string, port int) (ice.Candidate, error),
+) (ice.Candidate, error) {
+	ip, port, isTCP, ok := splitCandidateAddr(addr)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", errICEBindingRequestAddress, addr)
+	}
+
+	network := "udp"
+	if isTCP {
+		network = "tcp"
+	}
+
+	return newCandidate(network, ip.String(), port)
+}
+
+// splitCandidateAddr returns the IP and port of a UDP or TCP address.
+func splitCandidateAddr(addr net.Addr) (ip net.IP, port int, isTCP bool, ok bool) {
+	switch addr := addr.(type) {
+	case *net.UDPAddr:
+		return addr.IP, addr.Port, false, true
+	case *net.TCPAddr:
+		return addr.IP, addr.Port, true, true
+	default:
+		return nil, 0, false, false
+	}
+}
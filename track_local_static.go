@@ -6,14 +6,34 @@
 package webrtc
 
 import (
+	"fmt"
+	"slices"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/pion/interceptor"
+	"github.com/pion/logging"
 	"github.com/pion/rtp"
 	"github.com/pion/webrtc/v4/internal/util"
 	"github.com/pion/webrtc/v4/pkg/media"
 )
 
+const (
+	// defaultMaxSampleDuration is the Duration above which a written Sample is reported as suspicious.
+	defaultMaxSampleDuration = 5 * time.Second
+
+	// sampleDurationWarningInterval is how often a suspicious Sample Duration is reported at most.
+	sampleDurationWarningInterval = 10 * time.Second
+)
+
+// attributesRTPWriter is implemented by the TrackLocalWriter of a PeerConnection, to hand
+// the interceptor.Attributes of written packets to the interceptors.
+type attributesRTPWriter interface {
+	writeRTPWithAttributes(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error)
+}
+
 // trackBinding is a single bind for a Track
 // Bind can be called multiple times, this stores the
 // result for a single bind call so that it can be used when writing.
@@ -22,6 +42,9 @@ type trackBinding struct {
 	ssrc, ssrcRTX, ssrcFEC      SSRC
 	payloadType, payloadTypeRTX PayloadType
 	writeStream                 TrackLocalWriter
+
+	// pacer is set for the bindings of a TrackLocalStaticSample with WithPacing
+	pacer *samplePacer
 }
 
 // TrackLocalStaticRTP  is a TrackLocal that has a pre-set codec and accepts RTP Packets.
@@ -32,8 +55,23 @@ type TrackLocalStaticRTP struct {
 	codec             RTPCodecCapability
 	payloader         func(RTPCodecCapability) (rtp.Payloader, error)
 	id, rid, streamID string
+	ssrc, ssrcRTX     SSRC
 	initalTimestamp   *uint32
 	initialSeqNumber  *uint16
+	keyframeEnforcer  *keyframeIntervalEnforcer
+	keyframeDetector  keyframeDetector
+	maxSampleDuration time.Duration
+	loggerFactory     logging.LoggerFactory
+
+	pacing           bool
+	pacingBurstBytes int
+	pacingWindow     time.Duration
+	pacingClock      pacingClock
+
+	allowCodecMismatch bool
+	stampExtensions    bool
+
+	userData userData
 }
 
 // NewTrackLocalStaticRTP returns a TrackLocalStaticRTP.
@@ -43,15 +81,19 @@ func NewTrackLocalStaticRTP(
 	options ...func(*TrackLocalStaticRTP),
 ) (*TrackLocalStaticRTP, error) {
 	t := &TrackLocalStaticRTP{
-		codec:    c,
-		bindings: []trackBinding{},
-		id:       id,
-		streamID: streamID,
+		codec:             c,
+		bindings:          []trackBinding{},
+		id:                id,
+		streamID:          streamID,
+		maxSampleDuration: defaultMaxSampleDuration,
 	}
 
 	for _, option := range options {
 		option(t)
 	}
+	if t.keyframeEnforcer != nil {
+		t.keyframeDetector = keyframeDetectorForMimeType(c.MimeType)
+	}
 
 	return t, nil
 }
@@ -63,6 +105,23 @@ func WithRTPStreamID(rid string) func(*TrackLocalStaticRTP) {
 	}
 }
 
+// WithSSRC sets the SSRC the track is sent with, instead of a random one. The SSRCs of the
+// senders of a PeerConnection must be unique and differ from the ones of the remote, creating
+// or applying a description fails with ErrSSRCConflict otherwise.
+func WithSSRC(ssrc SSRC) func(*TrackLocalStaticRTP) {
+	return func(t *TrackLocalStaticRTP) {
+		t.ssrc = ssrc
+	}
+}
+
+// WithRTXSSRC sets the SSRC the retransmissions of the track are sent with when RTX is
+// negotiated, instead of a random one. See WithSSRC.
+func WithRTXSSRC(ssrc SSRC) func(*TrackLocalStaticRTP) {
+	return func(t *TrackLocalStaticRTP) {
+		t.ssrcRTX = ssrc
+	}
+}
+
 // WithPayloader allows the user to override the Payloader.
 func WithPayloader(h func(RTPCodecCapability) (rtp.Payloader, error)) func(*TrackLocalStaticRTP) {
 	return func(s *TrackLocalStaticRTP) {
@@ -84,6 +143,61 @@ func WithRTPSequenceNumber(sequenceNumber uint16) func(*TrackLocalStaticRTP) {
 	}
 }
 
+// WithKeyframeIntervalEnforcement calls request when the track wrote no keyframe for
+// maxInterval, and again every maxInterval until it does. Wire request to the force-keyframe
+// control of the encoder. Time is measured with the Duration of the samples written to a
+// TrackLocalStaticSample, and with the timestamps of the packets written to a
+// TrackLocalStaticRTP, which are observed once the first packet of the next frame is written.
+// Keyframes are only detected for VP8, VP9, H264, H265 and AV1.
+func WithKeyframeIntervalEnforcement(maxInterval time.Duration, request func()) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.keyframeEnforcer = newKeyframeIntervalEnforcer(maxInterval, request)
+	}
+}
+
+// WithCodecMismatchAllowed allows the track to be sent with a codec of the same MimeType
+// whose clock rate or channel count differ from the ones of the track. Without it AddTrack
+// and Bind fail with ErrClockRateMismatch or ErrChannelsMismatch. The timestamps of the
+// track are sent unchanged, so they have to be in the clock rate of the negotiated codec.
+func WithCodecMismatchAllowed() func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.allowCodecMismatch = true
+	}
+}
+
+// WithMaxSampleDuration sets the Duration above which a TrackLocalStaticSample logs a warning
+// for a written Sample, as it is likely a mistake in the timing math of the application.
+// The default is 5 seconds, 0 disables the warning.
+func WithMaxSampleDuration(maxDuration time.Duration) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.maxSampleDuration = maxDuration
+	}
+}
+
+// WithPacing makes a TrackLocalStaticSample spread the RTP packets of a sample over window,
+// instead of writing them back-to-back. A large keyframe sent as a single burst is likely
+// dropped by routers and disturbs the bandwidth estimation of the remote. The packets are
+// sent in bursts of at most maxBurstBytes, but at least one packet, evenly spaced across the
+// window, which is the Duration of the sample if window is 0. The packets of a sample keep
+// their timestamp. Every PeerConnection the track is bound to is paced on its own.
+//
+// Packets that are still held back are written when the track is unbound or closed, see
+// TrackLocalStaticSample.Close.
+func WithPacing(maxBurstBytes int, window time.Duration) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.pacing = true
+		s.pacingBurstBytes = maxBurstBytes
+		s.pacingWindow = window
+	}
+}
+
+// WithTrackLoggerFactory sets the LoggerFactory used by the track.
+func WithTrackLoggerFactory(loggerFactory logging.LoggerFactory) func(*TrackLocalStaticRTP) {
+	return func(s *TrackLocalStaticRTP) {
+		s.loggerFactory = loggerFactory
+	}
+}
+
 // Bind is called by the PeerConnection after negotiation is complete
 // This asserts that the code requested is supported by the remote peer.
 // If so it sets up all the state (SSRC and PayloadType) to have a call.
@@ -91,25 +205,33 @@ func (s *TrackLocalStaticRTP) Bind(trackContext TrackLocalContext) (RTPCodecPara
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	parameters := RTPCodecParameters{RTPCodecCapability: s.codec}
-	if codec, matchType := codecParametersFuzzySearch(
-		parameters,
-		trackContext.CodecParameters(),
-	); matchType != codecMatchNone {
-		s.bindings = append(s.bindings, trackBinding{
-			ssrc:           trackContext.SSRC(),
-			ssrcRTX:        trackContext.SSRCRetransmission(),
-			ssrcFEC:        trackContext.SSRCForwardErrorCorrection(),
-			payloadType:    codec.PayloadType,
-			payloadTypeRTX: findRTXPayloadType(codec.PayloadType, trackContext.CodecParameters()),
-			writeStream:    trackContext.WriteStream(),
-			id:             trackContext.ID(),
-		})
+	codecs := trackContext.CodecParameters()
+	codec, matchType := codecParametersFuzzySearch(RTPCodecParameters{RTPCodecCapability: s.codec}, codecs)
+	if matchType == codecMatchNone {
+		mismatch := codecCapabilityMismatch(s.codec, codecs)
+		if mismatch == nil {
+			return RTPCodecParameters{}, ErrUnsupportedCodec
+		} else if !s.allowCodecMismatch {
+			return RTPCodecParameters{}, fmt.Errorf("%w: %w", ErrUnsupportedCodec, mismatch)
+		}
 
-		return codec, nil
+		idx := slices.IndexFunc(codecs, func(c RTPCodecParameters) bool {
+			return strings.EqualFold(c.MimeType, s.codec.MimeType)
+		})
+		codec = codecs[idx]
 	}
 
-	return RTPCodecParameters{}, ErrUnsupportedCodec
+	s.bindings = append(s.bindings, trackBinding{
+		ssrc:           trackContext.SSRC(),
+		ssrcRTX:        trackContext.SSRCRetransmission(),
+		ssrcFEC:        trackContext.SSRCForwardErrorCorrection(),
+		payloadType:    codec.PayloadType,
+		payloadTypeRTX: findRTXPayloadType(codec.PayloadType, codecs),
+		writeStream:    trackContext.WriteStream(),
+		id:             trackContext.ID(),
+	})
+
+	return codec, nil
 }
 
 // Unbind implements the teardown logic when the track is no longer needed. This happens
@@ -141,6 +263,16 @@ func (s *TrackLocalStaticRTP) StreamID() string { return s.streamID }
 // RID is the RTP stream identifier.
 func (s *TrackLocalStaticRTP) RID() string { return s.rid }
 
+// SetUserData stores an application value under key on the track, a nil value removes it.
+// Like for context.WithValue, the key must be comparable, ErrUserDataKeyNotComparable is
+// returned otherwise, and should be of an unexported type to avoid collisions. The value is
+// stored as given: a struct is copied, pointers, maps and slices are shared. The track has no
+// end of life, the values stay with it across senders and are released with it.
+func (s *TrackLocalStaticRTP) SetUserData(key, value any) error { return s.userData.set(key, value) }
+
+// UserData returns the value stored under key with SetUserData, nil if there is none.
+func (s *TrackLocalStaticRTP) UserData(key any) any { return s.userData.get(key) }
+
 // Kind controls if this TrackLocal is audio or video.
 func (s *TrackLocalStaticRTP) Kind() RTPCodecType {
 	switch {
@@ -158,6 +290,15 @@ func (s *TrackLocalStaticRTP) Codec() RTPCodecCapability {
 	return s.codec
 }
 
+func (s *TrackLocalStaticRTP) codecMismatchAllowed() bool {
+	return s.allowCodecMismatch
+}
+
+// requestedSSRCs returns the SSRCs set with WithSSRC and WithRTXSSRC, zero if they aren't.
+func (s *TrackLocalStaticRTP) requestedSSRCs() (ssrc, ssrcRTX SSRC) {
+	return s.ssrc, s.ssrcRTX
+}
+
 // packetPool is a pool of packets used by WriteRTP and Write below
 // nolint:gochecknoglobals
 var rtpPacketPool = sync.Pool{
@@ -188,11 +329,37 @@ func (s *TrackLocalStaticRTP) WriteRTP(p *rtp.Packet) error {
 
 	*packet = *p
 
-	return s.writeRTP(packet)
+	err := s.writeRTP(packet, nil)
+	s.enforceKeyframeInterval(packet)
+
+	return err
+}
+
+// writeRTPWithAttributes is like WriteRTP, and hands attributes to the interceptors.
+func (s *TrackLocalStaticRTP) writeRTPWithAttributes(p *rtp.Packet, attributes interceptor.Attributes) error {
+	packet := getPacketAllocationFromPool()
+
+	defer resetPacketPoolAllocation(packet)
+
+	*packet = *p
+
+	return s.writeRTP(packet, attributes)
+}
+
+// sendPaused returns true if the track is bound and none of its RTPSenders may send, as the
+// negotiated direction of their transceivers doesn't allow it. s.mu must be held.
+func (s *TrackLocalStaticRTP) sendPaused() bool {
+	for _, b := range s.bindings {
+		if writer, ok := b.writeStream.(*interceptorToTrackLocalWriter); !ok || !writer.isPaused() {
+			return false
+		}
+	}
+
+	return len(s.bindings) != 0
 }
 
 // writeRTP is like WriteRTP, except that it may modify the packet p.
-func (s *TrackLocalStaticRTP) writeRTP(packet *rtp.Packet) error {
+func (s *TrackLocalStaticRTP) writeRTP(packet *rtp.Packet, attributes interceptor.Attributes) error {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -206,7 +373,13 @@ func (s *TrackLocalStaticRTP) writeRTP(packet *rtp.Packet) error {
 		if packet.PaddingSize != 0 && packet.Header.PaddingSize == 0 {
 			packet.Header.PaddingSize = packet.PaddingSize
 		}
-		if _, err := b.writeStream.WriteRTP(&packet.Header, packet.Payload); err != nil {
+		var err error
+		if writer, ok := b.writeStream.(attributesRTPWriter); ok && attributes != nil {
+			_, err = writer.writeRTPWithAttributes(&packet.Header, packet.Payload, attributes)
+		} else {
+			_, err = b.writeStream.WriteRTP(&packet.Header, packet.Payload)
+		}
+		if err != nil {
 			writeErrs = append(writeErrs, err)
 		}
 	}
@@ -214,6 +387,55 @@ func (s *TrackLocalStaticRTP) writeRTP(packet *rtp.Packet) error {
 	return util.FlattenErrs(writeErrs)
 }
 
+// writePaced hands the packets of a sample to the pacers of the bindings. The bindings whose
+// pacer is closed get them right away, the ones whose pacer is draining fail with
+// ErrTrackLocalDraining.
+func (s *TrackLocalStaticRTP) writePaced(
+	packets []*rtp.Packet, window time.Duration, attributes interceptor.Attributes,
+) error {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	writeErrs := []error{}
+	for _, b := range s.bindings {
+		// The pacers write concurrently, so every binding gets its own headers
+		paced := make([]pacedPacket, len(packets))
+		for i, packet := range packets {
+			paced[i] = pacedPacket{header: packet.Header.Clone(), payload: packet.Payload, attributes: attributes}
+			paced[i].header.SSRC = uint32(b.ssrc)
+			paced[i].header.PayloadType = uint8(b.payloadType)
+			if packet.PaddingSize != 0 && paced[i].header.PaddingSize == 0 {
+				paced[i].header.PaddingSize = packet.PaddingSize
+			}
+		}
+
+		if b.pacer != nil {
+			queued, err := b.pacer.enqueue(paced, window)
+			if err != nil {
+				writeErrs = append(writeErrs, err)
+
+				continue
+			}
+			if queued {
+				continue
+			}
+		}
+		for i := range paced {
+			var err error
+			if writer, ok := b.writeStream.(attributesRTPWriter); ok && attributes != nil {
+				_, err = writer.writeRTPWithAttributes(&paced[i].header, paced[i].payload, attributes)
+			} else {
+				_, err = b.writeStream.WriteRTP(&paced[i].header, paced[i].payload)
+			}
+			if err != nil {
+				writeErrs = append(writeErrs, err)
+			}
+		}
+	}
+
+	return util.FlattenErrs(writeErrs)
+}
+
 // Write writes a RTP Packet as a buffer to the TrackLocalStaticRTP
 // If one PeerConnection fails the packets will still be sent to
 // all PeerConnections. The error message will contain the ID of the failed
@@ -227,18 +449,77 @@ func (s *TrackLocalStaticRTP) Write(b []byte) (n int, err error) {
 		return 0, err
 	}
 
-	return len(b), s.writeRTP(packet)
+	err = s.writeRTP(packet, nil)
+	s.enforceKeyframeInterval(packet)
+
+	return len(b), err
+}
+
+// enforceKeyframeInterval hands a packet written with WriteRTP or Write to the keyframe
+// interval enforcement, the packets of a TrackLocalStaticSample are observed by sample.
+func (s *TrackLocalStaticRTP) enforceKeyframeInterval(packet *rtp.Packet) {
+	enforcer := s.keyframeEnforcer
+	if enforcer == nil || s.keyframeDetector == nil || s.codec.ClockRate == 0 {
+		return
+	}
+
+	if enforcer.observePacket(packet.Timestamp, s.codec.ClockRate, s.keyframeDetector(packet.Payload)) {
+		enforcer.request()
+	}
+}
+
+// KeyframeRequested tells the keyframe interval enforcement that the encoder was asked for
+// a keyframe for another reason, like a PLI of the remote peer. Enforcement is paused until
+// the next keyframe, for at most a second, so that the keyframe isn't requested twice.
+func (s *TrackLocalStaticRTP) KeyframeRequested() {
+	if enforcer := s.keyframeEnforcer; enforcer != nil {
+		enforcer.pause()
+	}
+}
+
+// KeyframeEnforcementCount returns how many times the keyframe interval enforcement
+// requested a keyframe.
+func (s *TrackLocalStaticRTP) KeyframeEnforcementCount() uint64 {
+	if enforcer := s.keyframeEnforcer; enforcer != nil {
+		return enforcer.enforcementCount()
+	}
+
+	return 0
+}
+
+// AverageKeyframeInterval returns the average interval between the keyframes written so far,
+// observed by the keyframe interval enforcement. It is zero until two keyframes were written.
+func (s *TrackLocalStaticRTP) AverageKeyframeInterval() time.Duration {
+	if enforcer := s.keyframeEnforcer; enforcer != nil {
+		return enforcer.averageInterval()
+	}
+
+	return 0
 }
 
 // TrackLocalStaticSample is a TrackLocal that has a pre-set codec and accepts Samples.
 // If you wish to send a RTP Packet use TrackLocalStaticRTP.
 type TrackLocalStaticSample struct {
-	mu         sync.Mutex
-	packetizer rtp.Packetizer
-	sequencer  rtp.Sequencer
-	rtpTrack   *TrackLocalStaticRTP
-	clockRate  float64
-	remainder  float64
+	mu               sync.Mutex
+	packetizer       rtp.Packetizer
+	sequencer        rtp.Sequencer
+	keyframeDetector keyframeDetector
+	rtpTrack         *TrackLocalStaticRTP
+	clockRate        float64
+	remainder        float64
+
+	// stallFiller is set by SetSourceStallFiller, stallFillerWritten counts its samples
+	stallFiller        atomic.Pointer[sourceStallFiller]
+	stallFillerWritten atomic.Uint64
+
+	// pacingClosed is set by Close, the bindings made afterwards aren't paced. It is guarded
+	// by the mutex of rtpTrack.
+	pacingClosed bool
+
+	log logging.LeveledLogger
+	// Suspicious Sample Durations are reported at most every sampleDurationWarningInterval
+	lastDurationWarning        time.Time
+	suppressedDurationWarnings int
 }
 
 // NewTrackLocalStaticSample returns a TrackLocalStaticSample.
@@ -252,9 +533,17 @@ func NewTrackLocalStaticSample(
 		return nil, err
 	}
 
-	return &TrackLocalStaticSample{
+	loggerFactory := rtpTrack.loggerFactory
+	if loggerFactory == nil {
+		loggerFactory = logging.NewDefaultLoggerFactory()
+	}
+
+	track := &TrackLocalStaticSample{
 		rtpTrack: rtpTrack,
-	}, nil
+		log:      loggerFactory.NewLogger("track"),
+	}
+
+	return track, nil
 }
 
 // ID is the unique identifier for this Track. This should be unique for the
@@ -268,6 +557,15 @@ func (s *TrackLocalStaticSample) StreamID() string { return s.rtpTrack.StreamID(
 // RID is the RTP stream identifier.
 func (s *TrackLocalStaticSample) RID() string { return s.rtpTrack.RID() }
 
+// SetUserData stores an application value under key on the track, a nil value removes it.
+// See TrackLocalStaticRTP.SetUserData.
+func (s *TrackLocalStaticSample) SetUserData(key, value any) error {
+	return s.rtpTrack.SetUserData(key, value)
+}
+
+// UserData returns the value stored under key with SetUserData, nil if there is none.
+func (s *TrackLocalStaticSample) UserData(key any) any { return s.rtpTrack.UserData(key) }
+
 // Kind controls if this TrackLocal is audio or video.
 func (s *TrackLocalStaticSample) Kind() RTPCodecType { return s.rtpTrack.Kind() }
 
@@ -276,6 +574,14 @@ func (s *TrackLocalStaticSample) Codec() RTPCodecCapability {
 	return s.rtpTrack.Codec()
 }
 
+func (s *TrackLocalStaticSample) codecMismatchAllowed() bool {
+	return s.rtpTrack.codecMismatchAllowed()
+}
+
+func (s *TrackLocalStaticSample) requestedSSRCs() (ssrc, ssrcRTX SSRC) {
+	return s.rtpTrack.requestedSSRCs()
+}
+
 // Bind is called by the PeerConnection after negotiation is complete
 // This asserts that the code requested is supported by the remote peer.
 // If so it setups all the state (SSRC and PayloadType) to have a call.
@@ -290,6 +596,8 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 
 	// We only need one packetizer
 	if s.packetizer != nil {
+		s.startPacing()
+
 		return codec, nil
 	}
 
@@ -326,35 +634,183 @@ func (s *TrackLocalStaticSample) Bind(t TrackLocalContext) (RTPCodecParameters,
 	)
 
 	s.clockRate = float64(codec.RTPCodecCapability.ClockRate)
+	s.keyframeDetector = keyframeDetectorForMimeType(codec.MimeType)
+	s.startPacing()
 
 	return codec, nil
 }
 
+// startPacing creates the pacer of the binding made last, the mutex of rtpTrack must be held.
+func (s *TrackLocalStaticSample) startPacing() {
+	if !s.rtpTrack.pacing || s.pacingClosed {
+		return
+	}
+
+	clock := s.rtpTrack.pacingClock
+	if clock == nil {
+		clock = systemPacingClock{}
+	}
+	binding := &s.rtpTrack.bindings[len(s.rtpTrack.bindings)-1]
+	binding.pacer = newSamplePacer(binding.writeStream, s.rtpTrack.pacingBurstBytes, clock, s.log)
+	if writer, ok := binding.writeStream.(*interceptorToTrackLocalWriter); ok {
+		writer.pacer.Store(binding.pacer)
+	}
+}
+
 // Unbind implements the teardown logic when the track is no longer needed. This happens
 // because a track has been stopped.
 func (s *TrackLocalStaticSample) Unbind(t TrackLocalContext) error {
-	return s.rtpTrack.Unbind(t)
+	var pacer *samplePacer
+	var writeStream TrackLocalWriter
+	s.rtpTrack.mu.RLock()
+	for i := range s.rtpTrack.bindings {
+		if s.rtpTrack.bindings[i].id == t.ID() {
+			pacer, writeStream = s.rtpTrack.bindings[i].pacer, s.rtpTrack.bindings[i].writeStream
+		}
+	}
+	s.rtpTrack.mu.RUnlock()
+
+	if err := s.rtpTrack.Unbind(t); err != nil {
+		return err
+	}
+	if pacer != nil {
+		pacer.close()
+		if writer, ok := writeStream.(*interceptorToTrackLocalWriter); ok {
+			writer.pacer.CompareAndSwap(pacer, nil)
+		}
+	}
+
+	// Stop filling until the next sample once the track isn't sent anymore
+	s.rtpTrack.mu.RLock()
+	unbound := len(s.rtpTrack.bindings) == 0
+	s.rtpTrack.mu.RUnlock()
+	if filler := s.stallFiller.Load(); filler != nil && unbound {
+		filler.writeMu.Lock()
+		filler.cancel()
+		filler.writeMu.Unlock()
+	}
+
+	return nil
 }
 
 // WriteSample writes a Sample to the TrackLocalStaticSample
 // If one PeerConnection fails the packets will still be sent to
 // all PeerConnections. The error message will contain the ID of the failed
 // PeerConnections so you can remove them.
+//
+// The Duration of a Sample advances the timestamp of the next one, so it must be positive
+// unless AllowZeroDuration is set, and ErrInvalidSampleDuration is returned otherwise.
+// A Sample without Data returns ErrEmptySample, unless it accounts for PrevDroppedPackets.
+// Audio silence is written as the silence frame of the codec, like the Opus frame
+// 0xF8 0xFF 0xFE, with the Duration it covers. A Sample without Data is not silence.
+//
+// A VideoOrientation as Metadata is sent with the last packet of the Sample, where the
+// video orientation header extension is negotiated.
 func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
+	if err := s.validateSample(sample); err != nil {
+		return err
+	}
+
+	filler := s.stallFiller.Load()
+	if filler == nil {
+		_, err := s.writeSample(sample, 0, nil)
+
+		return err
+	}
+
+	filler.writeMu.Lock()
+	defer filler.writeMu.Unlock()
+
+	// The source resumed, stop filling before its sample is written
+	filler.cancel()
+	_, err := s.writeSample(sample, 0, nil)
+	if s.bound() {
+		filler.observe(sample.Duration)
+	}
+
+	return err
+}
+
+// bound returns true while the track is bound to a PeerConnection.
+func (s *TrackLocalStaticSample) bound() bool {
+	s.rtpTrack.mu.RLock()
+	defer s.rtpTrack.mu.RUnlock()
+
+	return s.packetizer != nil && len(s.rtpTrack.bindings) != 0
+}
+
+// SetSourceStallFiller makes the track write the samples returned by filler when no sample was
+// written for timeout, like encoded silence or a black keyframe, so the remote keeps receiving
+// a continuous stream while the source stalls. They are written on the cadence of the Duration
+// of the last sample, with the timestamps the missing samples would have had, until the next
+// sample is written. The RTP packets of filler samples carry the interceptor attribute
+// AttributeSourceStallFiller, and are counted by SourceStallFillerPacketsSent of the
+// OutboundRTPStreamStats. A nil filler stops filling.
+func (s *TrackLocalStaticSample) SetSourceStallFiller(
+	timeout time.Duration,
+	filler func(codec RTPCodecCapability) media.Sample,
+) {
+	var stallFiller *sourceStallFiller
+	if filler != nil {
+		stallFiller = newSourceStallFiller(timeout, filler)
+		stallFiller.fill = func(generation uint64) {
+			s.fillStall(stallFiller, generation)
+		}
+	}
+
+	if previous := s.stallFiller.Swap(stallFiller); previous != nil {
+		previous.writeMu.Lock()
+		previous.cancel()
+		previous.writeMu.Unlock()
+	}
+}
+
+// fillStall writes a filler sample, unless a sample was written since it was scheduled for
+// generation.
+func (s *TrackLocalStaticSample) fillStall(filler *sourceStallFiller, generation uint64) {
+	filler.writeMu.Lock()
+	defer filler.writeMu.Unlock()
+
+	if filler.generation != generation {
+		return
+	}
+
+	slot, skipped := filler.next()
+	sample := filler.filler(s.Codec())
+	sample.Duration = filler.cadence
+	if len(sample.Data) != 0 {
+		attributes := interceptor.Attributes{AttributeSourceStallFiller: true}
+		if sent, err := s.writeSample(sample, time.Duration(skipped)*filler.cadence, attributes); err != nil {
+			s.log.Debugf("Failed to write filler sample of track %s: %v", s.ID(), err)
+		} else if sent {
+			s.stallFillerWritten.Add(1)
+		}
+	}
+	filler.slot = slot
+	filler.schedule(slot + 1)
+}
+
+// writeSample packetizes and writes sample, after skipping the timestamps of skip. It returns
+// false if the sample wasn't sent, as the track isn't bound or its RTPSenders are paused.
+func (s *TrackLocalStaticSample) writeSample(
+	sample media.Sample, skip time.Duration, attributes interceptor.Attributes,
+) (bool, error) {
 	s.rtpTrack.mu.RLock()
 	packetizer := s.packetizer
 	clockRate := s.clockRate
 	sequencer := s.sequencer
+	detectKeyframe := s.keyframeDetector
+	paused := s.rtpTrack.sendPaused()
 	s.rtpTrack.mu.RUnlock()
 	if packetizer == nil {
-		return nil
+		return false, nil
 	}
 
 	s.mu.Lock()
 	remainder := s.remainder
 
 	// skip packets by the number of previously dropped packets
-	for i := uint16(0); i < sample.PrevDroppedPackets; i++ {
+	for i := uint16(0); i < sample.PrevDroppedPackets && !paused; i++ {
 		sequencer.NextSequenceNumber()
 	}
 
@@ -367,22 +823,149 @@ func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 		packetizer.SkipSamples(dropTicks)
 	}
 
+	if skip > 0 {
+		skipTotal := skip.Seconds()*clockRate + remainder
+		skipTicks := uint32(skipTotal)
+		remainder = skipTotal - float64(skipTicks)
+		packetizer.SkipSamples(skipTicks)
+	}
+
 	curTotal := tickF + remainder
 	curTicks := uint32(curTotal)
 	remainder = curTotal - float64(curTicks)
 
 	s.remainder = remainder
+	if paused {
+		// Only the timestamps advance while nothing is sent, so the sequence numbers of the
+		// stream continue without a gap once it resumes
+		packetizer.SkipSamples(curTicks)
+		s.mu.Unlock()
+
+		return false, nil
+	}
 	packets := packetizer.Packetize(sample.Data, curTicks)
 	s.mu.Unlock()
 
+	// The send delay of the packets is measured from here, see AttributeSentAt
+	if attributes == nil {
+		attributes = interceptor.Attributes{}
+	}
+	attributes[AttributePacketizedAt] = time.Now()
+	if orientation, ok := sample.Metadata.(VideoOrientation); ok {
+		attributes[AttributeVideoOrientation] = orientation
+	}
+
 	writeErrs := []error{}
-	for _, p := range packets {
-		if err := s.rtpTrack.WriteRTP(p); err != nil {
+	if s.rtpTrack.pacing {
+		window := s.rtpTrack.pacingWindow
+		if window == 0 {
+			window = sample.Duration
+		}
+		if err := s.rtpTrack.writePaced(packets, window, attributes); err != nil {
 			writeErrs = append(writeErrs, err)
 		}
+	} else {
+		for _, p := range packets {
+			if err := s.rtpTrack.writeRTPWithAttributes(p, attributes); err != nil {
+				writeErrs = append(writeErrs, err)
+			}
+		}
 	}
 
-	return util.FlattenErrs(writeErrs)
+	if enforcer := s.rtpTrack.keyframeEnforcer; enforcer != nil && detectKeyframe != nil {
+		keyframe := slices.ContainsFunc(packets, func(p *rtp.Packet) bool {
+			return detectKeyframe(p.Payload)
+		})
+		duration := sample.Duration * time.Duration(1+int(sample.PrevDroppedPackets))
+		if enforcer.observe(keyframe, duration) {
+			enforcer.request()
+		}
+	}
+
+	return true, util.FlattenErrs(writeErrs)
+}
+
+func (s *TrackLocalStaticSample) validateSample(sample media.Sample) error {
+	switch {
+	case len(sample.Data) == 0 && sample.PrevDroppedPackets == 0:
+		return ErrEmptySample
+	case sample.Duration < 0, sample.Duration == 0 && !sample.AllowZeroDuration:
+		return fmt.Errorf("%w: %s", ErrInvalidSampleDuration, sample.Duration)
+	}
+
+	if orientation, ok := sample.Metadata.(VideoOrientation); ok {
+		if _, err := orientation.marshal(); err != nil {
+			return err
+		}
+	}
+
+	if maxDuration := s.rtpTrack.maxSampleDuration; maxDuration > 0 && sample.Duration > maxDuration {
+		s.warnSampleDuration(sample.Duration)
+	}
+
+	return nil
+}
+
+func (s *TrackLocalStaticSample) warnSampleDuration(duration time.Duration) {
+	s.mu.Lock()
+	now := time.Now()
+	if now.Sub(s.lastDurationWarning) < sampleDurationWarningInterval {
+		s.suppressedDurationWarnings++
+		s.mu.Unlock()
+
+		return
+	}
+	suppressed := s.suppressedDurationWarnings
+	s.lastDurationWarning, s.suppressedDurationWarnings = now, 0
+	s.mu.Unlock()
+
+	s.log.Warnf(
+		"Sample Duration %s of track %s exceeds %s, %d more were not reported",
+		duration, s.ID(), s.rtpTrack.maxSampleDuration, suppressed,
+	)
+}
+
+// KeyframeRequested tells the keyframe interval enforcement that the encoder was asked for
+// a keyframe for another reason, like a PLI of the remote peer. Enforcement is paused until
+// the next keyframe, for at most a second, so that the keyframe isn't requested twice.
+func (s *TrackLocalStaticSample) KeyframeRequested() { s.rtpTrack.KeyframeRequested() }
+
+// KeyframeEnforcementCount returns how many times the keyframe interval enforcement
+// requested a keyframe.
+func (s *TrackLocalStaticSample) KeyframeEnforcementCount() uint64 {
+	return s.rtpTrack.KeyframeEnforcementCount()
+}
+
+// AverageKeyframeInterval returns the average interval between the keyframes written so far,
+// observed by the keyframe interval enforcement. It is zero until two keyframes were written.
+func (s *TrackLocalStaticSample) AverageKeyframeInterval() time.Duration {
+	return s.rtpTrack.AverageKeyframeInterval()
+}
+
+// SourceStallFillerCount returns how many filler samples of SetSourceStallFiller were written
+// to the PeerConnections the track is bound to without an error.
+func (s *TrackLocalStaticSample) SourceStallFillerCount() uint64 {
+	return s.stallFillerWritten.Load()
+}
+
+// Close writes the packets held back by WithPacing and stops pacing, samples written
+// afterwards are sent right away. The track can still be used.
+func (s *TrackLocalStaticSample) Close() error {
+	s.rtpTrack.mu.Lock()
+	s.pacingClosed = true
+	var pacers []*samplePacer
+	for i := range s.rtpTrack.bindings {
+		if pacer := s.rtpTrack.bindings[i].pacer; pacer != nil {
+			pacers = append(pacers, pacer)
+		}
+	}
+	s.rtpTrack.mu.Unlock()
+
+	for _, pacer := range pacers {
+		pacer.close()
+	}
+
+	return nil
 }
 
 // GeneratePadding writes padding-only samples to the TrackLocalStaticSample
@@ -392,17 +975,20 @@ func (s *TrackLocalStaticSample) WriteSample(sample media.Sample) error {
 func (s *TrackLocalStaticSample) GeneratePadding(samples uint32) error {
 	s.rtpTrack.mu.RLock()
 	p := s.packetizer
+	paused := s.rtpTrack.sendPaused()
 	s.rtpTrack.mu.RUnlock()
 
-	if p == nil {
+	// Padding would take sequence numbers while nothing is sent
+	if p == nil || paused {
 		return nil
 	}
 
 	packets := p.GeneratePadding(samples)
 
+	// Padding isn't observed by the keyframe interval enforcement, unlike WriteRTP
 	writeErrs := []error{}
 	for _, p := range packets {
-		if err := s.rtpTrack.WriteRTP(p); err != nil {
+		if err := s.rtpTrack.writeRTPWithAttributes(p, nil); err != nil {
 			writeErrs = append(writeErrs, err)
 		}
 	}
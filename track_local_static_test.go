@@ -6,14 +6,19 @@
 package webrtc
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"math"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/pion/interceptor"
+	mock_interceptor "github.com/pion/interceptor/pkg/mock"
+	"github.com/pion/logging"
 	"github.com/pion/rtp"
 	"github.com/pion/transport/v4/test"
 	"github.com/pion/webrtc/v4/pkg/media"
@@ -641,7 +646,7 @@ func Test_TrackLocalStaticRTP_writeRTP_ReturnsError(t *testing.T) {
 
 	pkt := &rtp.Packet{Payload: []byte{0x01, 0x02, 0x03}}
 
-	err = track.writeRTP(pkt)
+	err = track.writeRTP(pkt, nil)
 	require.Error(t, err)
 	require.Contains(t, err.Error(), errWriteBoom.Error())
 }
@@ -1027,3 +1032,666 @@ func (p *countingPacketizer) EnableAbsSendTime(value int)                  {}
 func (p *countingPacketizer) SkipSamples(skippedSamples uint32) {
 	p.totalSamples += uint64(skippedSamples)
 }
+
+func TestTrackLocalStaticSample_KeyframeIntervalEnforcement(t *testing.T) {
+	const frameDuration = 100 * time.Millisecond
+
+	var requests []time.Duration
+	var now time.Duration
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion",
+		WithKeyframeIntervalEnforcement(time.Second, func() {
+			requests = append(requests, now)
+		}),
+	)
+	require.NoError(t, err)
+	_, err = track.Bind(dummyTrackLocalContext{id: "ctx-1"})
+	require.NoError(t, err)
+
+	// The inverse keyframe bit of the VP8 payload header
+	writeFrames := func(count int, keyframe bool) {
+		data := []byte{0x01, 0x00, 0x00}
+		if keyframe {
+			data[0] = 0x00
+		}
+		for range count {
+			assert.NoError(t, track.WriteSample(media.Sample{Data: data, Duration: frameDuration}))
+			now += frameDuration
+		}
+	}
+
+	// Keyframes are missing for 3.6s, requested every second
+	writeFrames(1, true)
+	writeFrames(35, false)
+	assert.Equal(t, []time.Duration{
+		1 * time.Second, 2 * time.Second, 3 * time.Second,
+	}, requests)
+	assert.Equal(t, uint64(3), track.KeyframeEnforcementCount())
+	assert.Equal(t, time.Duration(0), track.AverageKeyframeInterval())
+
+	// The encoder catches up and keeps the interval
+	writeFrames(1, true)
+	writeFrames(9, false)
+	writeFrames(1, true)
+	assert.Len(t, requests, 3)
+	assert.Equal(t, 2300*time.Millisecond, track.AverageKeyframeInterval())
+
+	// A keyframe was requested by a PLI, don't request another one during the grace period
+	writeFrames(9, false)
+	track.KeyframeRequested()
+	writeFrames(10, false)
+	assert.Len(t, requests, 3)
+	writeFrames(1, false)
+	assert.Equal(t, []time.Duration{
+		1 * time.Second, 2 * time.Second, 3 * time.Second, 5600*time.Millisecond + keyframeEnforcementGracePeriod,
+	}, requests)
+	assert.Equal(t, uint64(4), track.KeyframeEnforcementCount())
+}
+
+func TestTrackLocalStaticRTP_KeyframeIntervalEnforcement(t *testing.T) {
+	const frameTicks = 9000 // 100ms at 90kHz
+
+	requests := 0
+	track, err := NewTrackLocalStaticRTP(
+		RTPCodecCapability{MimeType: MimeTypeVP8, ClockRate: 90000}, "video", "pion",
+		WithKeyframeIntervalEnforcement(time.Second, func() {
+			requests++
+		}),
+	)
+	require.NoError(t, err)
+	_, err = track.Bind(dummyTrackLocalContext{id: "ctx-1"})
+	require.NoError(t, err)
+
+	// Every frame has two packets, only the first one has the start bit of the VP8 payload
+	// descriptor. The inverse keyframe bit follows it.
+	timestamp := uint32(1234)
+	writeFrames := func(count int, keyframe bool) {
+		start := []byte{0x10, 0x01, 0x00}
+		if keyframe {
+			start[1] = 0x00
+		}
+		for range count {
+			for _, payload := range [][]byte{start, {0x00, 0x00}} {
+				assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{Timestamp: timestamp}, Payload: payload}))
+			}
+			timestamp += frameTicks
+		}
+	}
+
+	// A frame is observed when the next one starts, the frame written at 1s requests one
+	writeFrames(1, true)
+	writeFrames(10, false)
+	assert.Equal(t, 0, requests)
+	writeFrames(1, false)
+	assert.Equal(t, 1, requests)
+
+	// Reordered packets don't move the time
+	assert.NoError(t, track.WriteRTP(&rtp.Packet{Header: rtp.Header{Timestamp: 1234}, Payload: []byte{0x00}}))
+	writeFrames(9, false)
+	assert.Equal(t, 1, requests)
+	writeFrames(1, false)
+	assert.Equal(t, 2, requests)
+
+	writeFrames(1, true)
+	writeFrames(9, false)
+	writeFrames(1, true)
+	writeFrames(1, false)
+	assert.Equal(t, 2, requests)
+	assert.Equal(t, uint64(2), track.KeyframeEnforcementCount())
+	assert.Equal(t, 1600*time.Millisecond, track.AverageKeyframeInterval())
+}
+
+func Test_TrackLocalStatic_CodecMismatch(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	opus := RTPCodecParameters{
+		RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 48000, Channels: 2},
+		PayloadType:        111,
+	}
+
+	t.Run("AddTrack", func(t *testing.T) {
+		pc, err := NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(
+			RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 44100, Channels: 2}, "audio", "pion",
+		)
+		require.NoError(t, err)
+
+		_, err = pc.AddTrack(track)
+		assert.ErrorIs(t, err, ErrClockRateMismatch)
+		assert.ErrorContains(t, err, "track 44100, registered 48000")
+
+		track, err = NewTrackLocalStaticSample(
+			RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 48000, Channels: 1}, "audio", "pion",
+		)
+		require.NoError(t, err)
+
+		_, err = pc.AddTransceiverFromTrack(track)
+		assert.ErrorIs(t, err, ErrChannelsMismatch)
+		assert.ErrorContains(t, err, "track 1, registered 2")
+		assert.Empty(t, pc.GetTransceivers())
+
+		assert.NoError(t, pc.Close())
+	})
+
+	t.Run("Bind", func(t *testing.T) {
+		track, err := NewTrackLocalStaticRTP(
+			RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 44100, Channels: 2}, "audio", "pion",
+		)
+		require.NoError(t, err)
+
+		_, err = track.Bind(&baseTrackLocalContext{params: RTPParameters{Codecs: []RTPCodecParameters{opus}}})
+		assert.ErrorIs(t, err, ErrUnsupportedCodec)
+		assert.ErrorIs(t, err, ErrClockRateMismatch)
+	})
+
+	t.Run("Override", func(t *testing.T) {
+		offerer, answerer, err := newPair()
+		require.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(
+			RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 44100, Channels: 2}, "audio", "pion",
+			WithCodecMismatchAllowed(),
+		)
+		require.NoError(t, err)
+
+		_, err = offerer.AddTrack(track)
+		require.NoError(t, err)
+		assert.NoError(t, signalPair(offerer, answerer))
+
+		codec, err := track.Bind(&baseTrackLocalContext{params: RTPParameters{Codecs: []RTPCodecParameters{opus}}})
+		assert.NoError(t, err)
+		assert.Equal(t, opus, codec)
+
+		closePairNow(t, offerer, answerer)
+	})
+}
+
+type recordingTrackLocalWriter struct {
+	headers []rtp.Header
+}
+
+func (w *recordingTrackLocalWriter) WriteRTP(header *rtp.Header, _ []byte) (int, error) {
+	w.headers = append(w.headers, *header)
+
+	return 0, nil
+}
+
+func (w *recordingTrackLocalWriter) Write(_ []byte) (int, error) { return 0, nil }
+
+type recordingTrackLocalContext struct {
+	dummyTrackLocalContext
+	writer *recordingTrackLocalWriter
+}
+
+func (r recordingTrackLocalContext) WriteStream() TrackLocalWriter { return r.writer }
+
+func Test_TrackLocalStaticSample_WriteSample_Validation(t *testing.T) {
+	var logs bytes.Buffer
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8},
+		"video",
+		"pion",
+		WithMaxSampleDuration(time.Second),
+		WithTrackLoggerFactory(&logging.DefaultLoggerFactory{Writer: &logs, DefaultLogLevel: logging.LogLevelWarn}),
+	)
+	require.NoError(t, err)
+
+	writer := &recordingTrackLocalWriter{}
+	_, err = track.Bind(recordingTrackLocalContext{dummyTrackLocalContext{id: "b1"}, writer})
+	require.NoError(t, err)
+
+	frame := []byte{0x00}
+	assert.ErrorIs(t, track.WriteSample(media.Sample{Duration: time.Millisecond * 20}), ErrEmptySample)
+	assert.ErrorIs(t, track.WriteSample(media.Sample{Data: frame}), ErrInvalidSampleDuration)
+	assert.ErrorIs(t, track.WriteSample(media.Sample{Data: frame, Duration: -time.Second}), ErrInvalidSampleDuration)
+	assert.ErrorIs(
+		t,
+		track.WriteSample(media.Sample{Data: frame, Duration: -time.Second, AllowZeroDuration: true}),
+		ErrInvalidSampleDuration,
+	)
+	assert.Empty(t, writer.headers)
+
+	// The parts of a frame written with AllowZeroDuration share its timestamp
+	require.NoError(t, track.WriteSample(media.Sample{Data: frame, AllowZeroDuration: true}))
+	require.NoError(t, track.WriteSample(media.Sample{Data: frame, AllowZeroDuration: true}))
+	require.NoError(t, track.WriteSample(media.Sample{Data: frame, Duration: time.Millisecond * 20}))
+	require.NoError(t, track.WriteSample(media.Sample{Data: frame, Duration: time.Millisecond * 20}))
+	require.Len(t, writer.headers, 4)
+	assert.Equal(t, writer.headers[0].Timestamp, writer.headers[1].Timestamp)
+	assert.Equal(t, writer.headers[0].Timestamp, writer.headers[2].Timestamp)
+	assert.Equal(t, writer.headers[2].Timestamp+1800, writer.headers[3].Timestamp)
+
+	// A Sample without Data can account for dropped packets
+	require.NoError(t, track.WriteSample(media.Sample{Duration: time.Millisecond * 20, PrevDroppedPackets: 1}))
+
+	// Durations above the bound are still written, and reported once per interval
+	assert.Empty(t, logs.String())
+	require.NoError(t, track.WriteSample(media.Sample{Data: frame, Duration: time.Second * 2}))
+	require.NoError(t, track.WriteSample(media.Sample{Data: frame, Duration: time.Second * 3}))
+	assert.Equal(t, 1, strings.Count(logs.String(), "exceeds 1s"))
+	assert.Contains(t, logs.String(), "Sample Duration 2s of track video")
+	assert.Len(t, writer.headers, 6)
+
+	track.mu.Lock()
+	track.lastDurationWarning = time.Now().Add(-sampleDurationWarningInterval)
+	track.mu.Unlock()
+	require.NoError(t, track.WriteSample(media.Sample{Data: frame, Duration: time.Second * 4}))
+	assert.Contains(t, logs.String(), "Sample Duration 4s of track video exceeds 1s, 1 more were not reported")
+}
+
+func TestTrackLocalStaticSample_SourceStallFiller(t *testing.T) { //nolint:cyclop,maintidx
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		cadence      = 20 * time.Millisecond
+		stallTimeout = 100 * time.Millisecond
+		cadenceTicks = 90000 * 20 / 1000
+	)
+	realData, fillerData := byte(0x01), byte(0xAA)
+
+	// Record the sequence numbers of the packets flagged as filler
+	var markedLock sync.Mutex
+	marked := map[uint16]bool{}
+	registry := &interceptor.Registry{}
+	registry.Add(&mock_interceptor.Factory{
+		NewInterceptorFn: func(string) (interceptor.Interceptor, error) {
+			return &mock_interceptor.Interceptor{
+				BindLocalStreamFn: func(_ *interceptor.StreamInfo, writer interceptor.RTPWriter) interceptor.RTPWriter {
+					return interceptor.RTPWriterFunc(
+						func(header *rtp.Header, payload []byte, attributes interceptor.Attributes) (int, error) {
+							if filler, ok := attributes.Get(AttributeSourceStallFiller).(bool); ok && filler {
+								markedLock.Lock()
+								marked[header.SequenceNumber] = true
+								markedLock.Unlock()
+							}
+
+							return writer.Write(header, payload, attributes)
+						},
+					)
+				},
+			}, nil
+		},
+	})
+
+	require.NoError(t, ConfigureStatsInterceptor(registry))
+
+	pcOffer, err := NewAPI(WithInterceptorRegistry(registry)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	track.SetSourceStallFiller(stallTimeout, func(codec RTPCodecCapability) media.Sample {
+		assert.Equal(t, MimeTypeVP8, codec.MimeType)
+
+		return media.Sample{Data: []byte{fillerData}}
+	})
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	packets := make(chan *rtp.Packet, 1000)
+	trackDone := make(chan struct{})
+	pcAnswer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+		defer close(trackDone)
+
+		for {
+			pkt, _, readErr := remote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+			packets <- pkt
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	writeSamples := func(count int) {
+		for i := 0; i < count; i++ {
+			assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{realData}, Duration: cadence}))
+			time.Sleep(cadence)
+		}
+	}
+	isFiller := func(pkt *rtp.Packet) bool {
+		// The payload follows the one byte VP8 payload descriptor
+		return pkt.Payload[len(pkt.Payload)-1] == fillerData
+	}
+
+	// Write until the remote receives the track, then stall and resume
+	received := []*rtp.Packet{}
+	for len(received) == 0 {
+		writeSamples(1)
+		select {
+		case pkt := <-packets:
+			received = append(received, pkt)
+		default:
+		}
+	}
+	writeSamples(5)
+	time.Sleep(stallTimeout + 10*cadence)
+	writeSamples(5)
+
+	// The next filler would only follow after the timeout
+	fillerCount := track.SourceStallFillerCount()
+	assert.GreaterOrEqual(t, fillerCount, uint64(8))
+
+	// Read up to the last of the samples written after the stall
+	for resumed := 0; resumed < 5; {
+		pkt := <-packets
+		switch {
+		case isFiller(pkt):
+			resumed = 0
+		case isFiller(received[len(received)-1]) || resumed > 0:
+			resumed++
+		}
+		received = append(received, pkt)
+	}
+
+	// Every filler sample fits in a single packet
+	var fillerPacketsSent uint64
+	for _, stat := range pcOffer.GetStats() {
+		if outbound, ok := stat.(OutboundRTPStreamStats); ok {
+			fillerPacketsSent += outbound.SourceStallFillerPacketsSent
+		}
+	}
+	assert.Equal(t, fillerCount, fillerPacketsSent)
+
+	assert.NoError(t, pcOffer.GracefulClose())
+	assert.NoError(t, pcAnswer.GracefulClose())
+	<-trackDone
+
+	markedLock.Lock()
+	defer markedLock.Unlock()
+
+	var fillers uint64
+	resumed := false
+	for i := 1; i < len(received); i++ {
+		prev, pkt := received[i-1], received[i]
+		assert.Equal(t, prev.SequenceNumber+1, pkt.SequenceNumber)
+		assert.Equal(t, isFiller(pkt), marked[pkt.SequenceNumber])
+
+		step := pkt.Timestamp - prev.Timestamp
+		switch {
+		case isFiller(pkt) && !isFiller(prev):
+			// The first filler takes the slot after the timeout
+			assert.GreaterOrEqual(t, step, uint32(stallTimeout/cadence)*cadenceTicks)
+			assert.Zero(t, step%cadenceTicks)
+			fillers++
+		case isFiller(pkt):
+			// Fillers follow each other on the cadence
+			assert.Equal(t, uint32(cadenceTicks), step)
+			fillers++
+		case isFiller(prev):
+			// Filling stops as soon as the source resumes
+			assert.False(t, resumed)
+			resumed = true
+			assert.Equal(t, uint32(cadenceTicks), step)
+		default:
+			assert.Equal(t, uint32(cadenceTicks), step)
+		}
+	}
+	assert.True(t, resumed)
+	assert.Equal(t, fillerCount, fillers)
+}
+
+// fakePacingClock is a virtual clock, waiting on it advances the time right away.
+type fakePacingClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakePacingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *fakePacingClock) After(d time.Duration) <-chan time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	fired := make(chan time.Time, 1)
+	fired <- c.now
+
+	return fired
+}
+
+type pacedWrite struct {
+	at     time.Time
+	header rtp.Header
+}
+
+// pacedTrackLocalWriter records when the packets are written. The writes block while
+// blocked isn't closed.
+type pacedTrackLocalWriter struct {
+	clock   pacingClock
+	blocked chan struct{}
+
+	mu     sync.Mutex
+	writes []pacedWrite
+}
+
+func (w *pacedTrackLocalWriter) WriteRTP(header *rtp.Header, _ []byte) (int, error) {
+	if w.blocked != nil {
+		<-w.blocked
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	w.writes = append(w.writes, pacedWrite{at: w.clock.Now(), header: *header})
+
+	return 0, nil
+}
+
+func (w *pacedTrackLocalWriter) Write([]byte) (int, error) { return 0, nil }
+
+func (w *pacedTrackLocalWriter) written() []pacedWrite {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	return append([]pacedWrite{}, w.writes...)
+}
+
+type pacedTrackLocalContext struct {
+	dummyTrackLocalContext
+	writer *pacedTrackLocalWriter
+}
+
+func (p pacedTrackLocalContext) WriteStream() TrackLocalWriter { return p.writer }
+
+type writerTrackLocalContext struct {
+	dummyTrackLocalContext
+	writer TrackLocalWriter
+}
+
+func (w writerTrackLocalContext) WriteStream() TrackLocalWriter { return w.writer }
+
+func TestTrackLocalStaticSample_SendPaused(t *testing.T) {
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	var paused atomic.Bool
+	var written []rtp.Header
+	writer := &interceptorToTrackLocalWriter{paused: &paused}
+	writer.interceptor.Store(interceptor.RTPWriter(interceptor.RTPWriterFunc(
+		func(header *rtp.Header, _ []byte, _ interceptor.Attributes) (int, error) {
+			written = append(written, *header)
+
+			return 0, nil
+		},
+	)))
+	_, err = track.Bind(writerTrackLocalContext{dummyTrackLocalContext{id: "ctx-1"}, writer})
+	require.NoError(t, err)
+
+	writeSample := func() {
+		assert.NoError(t, track.WriteSample(media.Sample{Data: []byte{0x01}, Duration: 10 * time.Millisecond}))
+	}
+
+	// Nothing is packetized while paused, the stream resumes without a sequence number gap
+	writeSample()
+	paused.Store(true)
+	writeSample()
+	writeSample()
+	assert.NoError(t, track.GeneratePadding(1))
+	paused.Store(false)
+	writeSample()
+
+	require.Len(t, written, 2)
+	assert.Equal(t, written[0].SequenceNumber+1, written[1].SequenceNumber)
+	assert.Equal(t, written[0].Timestamp+3*900, written[1].Timestamp)
+}
+
+func TestTrackLocalStaticSample_Pacing(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	clock := &fakePacingClock{now: time.Unix(1000, 0)}
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithPacing(2500, 0),
+	)
+	require.NoError(t, err)
+	track.rtpTrack.pacingClock = clock
+
+	writer := &pacedTrackLocalWriter{clock: clock}
+	_, err = track.Bind(pacedTrackLocalContext{dummyTrackLocalContext{id: "b1"}, writer})
+	require.NoError(t, err)
+
+	// 11 packets of about 1200 bytes, two of them fit in a burst
+	start := clock.Now()
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 12000), Duration: 100 * time.Millisecond}))
+	require.Eventually(t, func() bool { return len(writer.written()) == 11 }, time.Second, time.Millisecond)
+
+	writes := writer.written()
+	for i, write := range writes {
+		assert.Equal(t, start.Add(100*time.Millisecond*time.Duration(i/2)/6), write.at, "packet %d", i)
+		assert.Equal(t, writes[0].header.Timestamp, write.header.Timestamp)
+		assert.Equal(t, writes[0].header.SequenceNumber+uint16(i), write.header.SequenceNumber) //nolint:gosec
+	}
+
+	// The next sample starts once the window of the previous one ended
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 12000), Duration: 100 * time.Millisecond}))
+	require.Eventually(t, func() bool { return len(writer.written()) == 22 }, time.Second, time.Millisecond)
+	writes = writer.written()
+	assert.Equal(t, start.Add(100*time.Millisecond), writes[11].at)
+	assert.Equal(t, writes[0].header.Timestamp+9000, writes[11].header.Timestamp)
+
+	assert.NoError(t, track.Close())
+}
+
+func TestTrackLocalStaticSample_Pacing_PerBinding(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithPacing(0, 20*time.Millisecond),
+	)
+	require.NoError(t, err)
+
+	stuck := &pacedTrackLocalWriter{clock: systemPacingClock{}, blocked: make(chan struct{})}
+	_, err = track.Bind(pacedTrackLocalContext{dummyTrackLocalContext{id: "stuck"}, stuck})
+	require.NoError(t, err)
+	writer := &pacedTrackLocalWriter{clock: systemPacingClock{}}
+	_, err = track.Bind(pacedTrackLocalContext{dummyTrackLocalContext{id: "b1"}, writer})
+	require.NoError(t, err)
+
+	// A binding that can't write doesn't hold back the others
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 6000), Duration: 20 * time.Millisecond}))
+	require.Eventually(t, func() bool { return len(writer.written()) == 6 }, 5*time.Second, time.Millisecond)
+	assert.Empty(t, stuck.written())
+
+	writes := writer.written()
+	assert.Greater(t, writes[5].at.Sub(writes[0].at), 10*time.Millisecond)
+
+	close(stuck.blocked)
+	assert.NoError(t, track.Close())
+	assert.Len(t, stuck.written(), 6)
+}
+
+func TestTrackLocalStaticSample_Pacing_Close(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithPacing(0, time.Hour),
+	)
+	require.NoError(t, err)
+
+	unbound := &pacedTrackLocalWriter{clock: systemPacingClock{}}
+	unboundCtx := pacedTrackLocalContext{dummyTrackLocalContext{id: "unbound"}, unbound}
+	_, err = track.Bind(unboundCtx)
+	require.NoError(t, err)
+	writer := &pacedTrackLocalWriter{clock: systemPacingClock{}}
+	_, err = track.Bind(pacedTrackLocalContext{dummyTrackLocalContext{id: "b1"}, writer})
+	require.NoError(t, err)
+
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 6000), Duration: 20 * time.Millisecond}))
+	require.Eventually(t, func() bool { return len(writer.written()) == 1 }, time.Second, time.Millisecond)
+
+	// Unbinding and closing write the held back packets
+	require.NoError(t, track.Unbind(unboundCtx))
+	assert.Len(t, unbound.written(), 6)
+	assert.Len(t, writer.written(), 1)
+	require.NoError(t, track.Close())
+	assert.Len(t, writer.written(), 6)
+
+	// Pacing stopped, samples are written right away
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 6000), Duration: 20 * time.Millisecond}))
+	assert.Len(t, writer.written(), 12)
+	assert.NoError(t, track.Close())
+}
+
+func TestTrackLocalStaticSample_Pacing_Drain(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	track, err := NewTrackLocalStaticSample(
+		RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion", WithPacing(0, time.Hour),
+	)
+	require.NoError(t, err)
+
+	writer := &pacedTrackLocalWriter{clock: systemPacingClock{}}
+	_, err = track.Bind(pacedTrackLocalContext{dummyTrackLocalContext{id: "b1"}, writer})
+	require.NoError(t, err)
+
+	require.NoError(t, track.WriteSample(media.Sample{Data: make([]byte, 6000), Duration: 20 * time.Millisecond}))
+	require.Eventually(t, func() bool { return len(writer.written()) == 1 }, time.Second, time.Millisecond)
+
+	// The held back packets are written before the drain starts
+	var started int
+	track.rtpTrack.mu.RLock()
+	pacer := track.rtpTrack.bindings[0].pacer
+	track.rtpTrack.mu.RUnlock()
+	pacer.drain(func() { started = len(writer.written()) })
+	assert.Equal(t, 6, started)
+
+	// Samples written once draining are rejected instead of being dropped
+	assert.ErrorIs(t, track.WriteSample(media.Sample{Data: make([]byte, 6000), Duration: 20 * time.Millisecond}),
+		ErrTrackLocalDraining)
+	assert.Len(t, writer.written(), 6)
+	assert.NoError(t, track.Close())
+}
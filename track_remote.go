@@ -6,14 +6,18 @@
 package webrtc
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pion/interceptor"
+	"github.com/pion/rtcp"
 	"github.com/pion/rtp"
+	"github.com/pion/webrtc/v4/pkg/media"
 )
 
 type peekedPacket struct {
@@ -21,6 +25,39 @@ type peekedPacket struct {
 	attributes interceptor.Attributes
 }
 
+// keyframeGate withholds packets of a video track until the first packet of a keyframe.
+type keyframeGate struct {
+	deadline  time.Time
+	lastPLI   time.Time
+	writeRTCP func([]rtcp.Packet) error
+}
+
+// layerPause is the state of a simulcast layer paused by RTPReceiver.PauseSimulcastLayer.
+type layerPause struct {
+	resumed  chan struct{} // closed to stop the drain of the stream
+	drained  chan struct{} // closed once the drain of the stream stopped
+	released chan struct{} // closed once the track can be read again
+	draining bool
+}
+
+// preBindBuffer is the state of the reads of a track before the application reads it,
+// see SettingEngine.SetReceiverPreBindBufferSize.
+type preBindBuffer struct {
+	size     int
+	stop     chan struct{} // closed to stop the reads of the stream
+	stopped  chan struct{} // closed once the reads of the stream stopped
+	buffered chan struct{} // signaled when a packet is buffered
+}
+
+// trackStarvation is the state of the windows of SettingEngine.SetTrackFirstPacketTimeout
+// in which a track must receive its first packet.
+type trackStarvation struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	pending bool // the reads of the track were interrupted to return ErrTrackStarvation
+	stopped bool
+}
+
 // TrackRemote represents a single inbound source of media.
 type TrackRemote struct {
 	mu sync.RWMutex
@@ -28,6 +65,8 @@ type TrackRemote struct {
 	id       string
 	streamID string
 
+	originalID, originalStreamID string
+
 	payloadType PayloadType
 	kind        RTPCodecType
 	ssrc        SSRC
@@ -40,6 +79,34 @@ type TrackRemote struct {
 
 	peekedPackets []*peekedPacket
 
+	readDeadline readDeadline
+
+	keyframeGate   *keyframeGate
+	gatingBypassed atomic.Uint32
+
+	layerPause             *layerPause
+	pausedPacketsDiscarded atomic.Uint64
+
+	preBind                 *preBindBuffer
+	preBindPacketsDiscarded atomic.Uint64
+
+	paddingPacketsReceived, paddingBytesReceived atomic.Uint64
+
+	// videoOrientation is the last extension byte received, flagged with videoOrientationReceived.
+	videoOrientation atomic.Uint32
+
+	// Set once a RTCP BYE of the remote ended the track
+	ended atomic.Bool
+
+	starvation          atomic.Pointer[trackStarvation]
+	onStarvationHandler func()
+
+	// Set once the track is read by ReadSample
+	jitterBuffer          atomic.Pointer[jitterBuffer]
+	onFrameDroppedHandler func(droppedPackets uint16)
+
+	userData userData
+
 	audioPlayoutStatsProviders []AudioPlayoutStatsProvider
 }
 
@@ -97,6 +164,30 @@ func (t *TrackRemote) StreamID() string {
 	return t.streamID
 }
 
+// OriginalID is the track id as announced by the remote. It only differs from ID
+// if it was sanitized, see SettingEngine.SetTrackIdentifierPolicy.
+func (t *TrackRemote) OriginalID() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.originalID
+}
+
+// OriginalStreamID is the stream id as announced by the remote. It only differs from
+// StreamID if it was sanitized, see SettingEngine.SetTrackIdentifierPolicy.
+func (t *TrackRemote) OriginalStreamID() string {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return t.originalStreamID
+}
+
+// setIdentifiers sets the identifiers announced by the remote, t.mu must be held.
+func (t *TrackRemote) setIdentifiers(details *trackDetails) {
+	t.id, t.streamID = details.id, details.streamID
+	t.originalID, t.originalStreamID = details.originalID, details.originalStreamID
+}
+
 // SSRC gets the SSRC of the track.
 func (t *TrackRemote) SSRC() SSRC {
 	t.mu.RLock()
@@ -105,6 +196,21 @@ func (t *TrackRemote) SSRC() SSRC {
 	return t.ssrc
 }
 
+// SetUserData stores an application value under key on the track, a nil value removes it.
+// Like for context.WithValue, the key must be comparable, ErrUserDataKeyNotComparable is
+// returned otherwise, and should be of an unexported type to avoid collisions. The value is
+// stored as given: a struct is copied, pointers, maps and slices are shared. The values are
+// released once the RTPReceiver of the track is stopped, when the reads return an error that
+// ends them, see Read. Values set afterwards are dropped.
+func (t *TrackRemote) SetUserData(key, value any) error {
+	return t.userData.set(key, value)
+}
+
+// UserData returns the value stored under key with SetUserData, nil if there is none.
+func (t *TrackRemote) UserData(key any) any {
+	return t.userData.get(key)
+}
+
 // Msid gets the Msid of the track.
 func (t *TrackRemote) Msid() string {
 	return t.StreamID() + " " + t.ID()
@@ -118,10 +224,55 @@ func (t *TrackRemote) Codec() RTPCodecParameters {
 	return t.codec
 }
 
-// Read reads data from the track.
+// Read reads data from the track. Once the track can't be read anymore, Read returns:
+//   - ErrTrackEnded, which wraps io.EOF, if the remote ended the track: its media section
+//     no longer sends, was rejected, or a RTCP BYE was received for its SSRC.
+//   - ErrReceiverRestarted if a renegotiation replaced the RTPReceiver while the remote media
+//     section still sends. The media of the remote comes back in a new TrackRemote by OnTrack.
+//   - io.EOF if the RTPReceiver was stopped locally, e.g. by PeerConnection.Close.
+//
+// RTPReceiver.Read returns the same errors, except io.ErrClosedPipe instead of io.EOF.
+// Once the track is read by ReadSample, Read returns ErrTrackReadBySample.
 func (t *TrackRemote) Read(b []byte) (n int, attributes interceptor.Attributes, err error) {
+	if t.jitterBuffer.Load() != nil {
+		return 0, nil, ErrTrackReadBySample
+	}
+
+	return t.readGated(b)
+}
+
+// readGated reads the next packet of the track which passes the keyframe gate.
+func (t *TrackRemote) readGated(b []byte) (n int, attributes interceptor.Attributes, err error) {
+	for {
+		n, attributes, err = t.read(b)
+		if err != nil || t.passKeyframeGate(b[:n]) {
+			return n, attributes, err
+		}
+	}
+}
+
+func (t *TrackRemote) read(b []byte) (n int, attributes interceptor.Attributes, err error) {
 	t.mu.RLock()
 	receiver := t.receiver
+	pause := t.layerPause
+	t.mu.RUnlock()
+
+	// The reads of a paused simulcast layer wait for it to be resumed
+	if pause != nil {
+		select {
+		case <-pause.released:
+		case <-receiver.closedChan:
+			return 0, nil, receiver.readErr(t)
+		case <-t.readDeadline.done():
+			return 0, nil, errReadTimeout
+		}
+	}
+
+	if err = receiver.stopPreBind(t); err != nil {
+		return 0, nil, err
+	}
+
+	t.mu.RLock()
 	var peekedPkt *peekedPacket
 	if len(t.peekedPackets) != 0 {
 		peekedPkt = t.peekedPackets[0]
@@ -130,7 +281,7 @@ func (t *TrackRemote) Read(b []byte) (n int, attributes interceptor.Attributes,
 	t.mu.RUnlock()
 
 	if receiver.haveClosed() {
-		return 0, nil, io.EOF
+		return 0, nil, receiver.readErr(t)
 	}
 
 	if peekedPkt != nil {
@@ -143,21 +294,62 @@ func (t *TrackRemote) Read(b []byte) (n int, attributes interceptor.Attributes,
 	// If there's a separate RTX track and an RTX packet is available, return that
 	if rtxPacketReceived := receiver.readRTX(t); rtxPacketReceived != nil {
 		n = copy(b, rtxPacketReceived.pkt)
-		attributes = rtxPacketReceived.attributes
+		attributes = t.accountPadding(b[:n], rtxPacketReceived.attributes)
+		attributes = t.readVideoOrientation(b[:n], attributes)
 		rtxPacketReceived.release()
+		t.stopStarvation()
 
 		return n, attributes, nil
 	}
 
 	n, attributes, err = receiver.readRTP(b, t)
 	if err != nil {
+		if !errors.Is(err, io.EOF) && !receiver.haveClosed() && t.takeStarvation() {
+			return 0, nil, ErrTrackStarvation
+		}
+
 		return n, attributes, err
 	}
+	t.stopStarvation()
+	attributes = t.accountPadding(b[:n], attributes)
+	attributes = t.readVideoOrientation(b[:n], attributes)
 	err = t.checkAndUpdateTrack(b)
 
 	return n, attributes, err
 }
 
+// accountPadding counts the padding of a packet read from the track. Packets carrying
+// padding alongside their payload are flagged with AttributePaddingSize.
+func (t *TrackRemote) accountPadding(pkt []byte, attributes interceptor.Attributes) interceptor.Attributes {
+	if len(pkt) == 0 || pkt[0]&rtpPaddingBitmask == 0 {
+		return attributes
+	}
+
+	header := rtp.Header{}
+	headerLen, err := header.Unmarshal(pkt)
+	if err != nil {
+		return attributes
+	}
+
+	paddingSize, paddingOnly := rtpPadding(&header, pkt[headerLen:])
+	t.countPaddingReceived(paddingSize, paddingOnly)
+	if !paddingOnly {
+		if attributes == nil {
+			attributes = make(interceptor.Attributes)
+		}
+		attributes.Set(AttributePaddingSize, uint8(paddingSize)) //nolint:gosec // paddingSize is at most 255
+	}
+
+	return attributes
+}
+
+func (t *TrackRemote) countPaddingReceived(paddingSize int, paddingOnly bool) {
+	if paddingOnly {
+		t.paddingPacketsReceived.Add(1)
+	}
+	t.paddingBytesReceived.Add(uint64(paddingSize)) //nolint:gosec // paddingSize is at most 255
+}
+
 // checkAndUpdateTrack checks payloadType for every incoming packet
 // once a different payloadType is detected the track will be updated.
 func (t *TrackRemote) checkAndUpdateTrack(b []byte) error {
@@ -197,11 +389,109 @@ func (t *TrackRemote) ReadRTP() (*rtp.Packet, interceptor.Attributes, error) {
 		return nil, nil, err
 	}
 
+	if allocation, ok := t.videoLayersAllocation(r); ok {
+		if attributes == nil {
+			attributes = make(interceptor.Attributes)
+		}
+		attributes.Set(AttributeVideoLayersAllocation, allocation)
+	}
+
 	return r, attributes, nil
 }
 
+// ReadSample reads the next frame of the track, it needs SettingEngine.EnableJitterBuffer.
+// The packets are reordered and depacketized for the VP8, VP9, H264, AV1 and Opus codecs,
+// ErrJitterBufferCodecNotSupported is returned for the others. The frames still missing
+// packets once the latency of the jitter buffer is exceeded are dropped, see OnFrameDropped.
+//
+// Once ReadSample was called, Read and ReadRTP return ErrTrackReadBySample. Otherwise
+// ReadSample returns the errors of Read.
+func (t *TrackRemote) ReadSample() (media.Sample, error) {
+	buffer, err := t.sampleJitterBuffer()
+	if err != nil {
+		return media.Sample{}, err
+	}
+
+	buffer.mu.Lock()
+	defer buffer.mu.Unlock()
+
+	for {
+		if sample := buffer.pop(); sample != nil {
+			t.frameDropped(sample)
+
+			return *sample, nil
+		}
+
+		// The packets are kept by the jitter buffer, each one needs its own buffer
+		b := make([]byte, t.receiver.api.settingEngine.getReceiveMTU())
+		n, _, err := t.readGated(b)
+		if err != nil {
+			return media.Sample{}, err
+		}
+
+		packet := &rtp.Packet{}
+		if err = packet.Unmarshal(b[:n]); err != nil {
+			return media.Sample{}, err
+		}
+		if err = buffer.push(packet, t.Codec()); err != nil {
+			return media.Sample{}, err
+		}
+	}
+}
+
+// sampleJitterBuffer returns the jitter buffer of the track, it is created by the first ReadSample.
+func (t *TrackRemote) sampleJitterBuffer() (*jitterBuffer, error) {
+	if buffer := t.jitterBuffer.Load(); buffer != nil {
+		return buffer, nil
+	}
+
+	settings := t.receiver.api.settingEngine.jitterBuffer
+	if !settings.enabled {
+		return nil, ErrJitterBufferDisabled
+	}
+
+	buffer, err := newJitterBuffer(t.Codec(), settings.latency)
+	if err != nil {
+		return nil, err
+	}
+	if !t.jitterBuffer.CompareAndSwap(nil, buffer) {
+		return t.jitterBuffer.Load(), nil
+	}
+
+	return buffer, nil
+}
+
+// OnFrameDropped sets an event handler which is fired by ReadSample when frames were dropped
+// because of lost packets, before it returns the frame following them. droppedPackets is how
+// many packets were skipped since the previous frame, the lost ones included and padding excluded.
+func (t *TrackRemote) OnFrameDropped(f func(droppedPackets uint16)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onFrameDroppedHandler = f
+}
+
+func (t *TrackRemote) frameDropped(sample *media.Sample) {
+	// Padding packets are dropped too, they don't belong to any frame
+	droppedPackets := sample.PrevDroppedPackets - min(sample.PrevPaddingPackets, sample.PrevDroppedPackets)
+	if droppedPackets == 0 {
+		return
+	}
+
+	t.mu.RLock()
+	handler := t.onFrameDroppedHandler
+	t.mu.RUnlock()
+	if handler != nil {
+		handler(droppedPackets)
+	}
+}
+
 // peek is like Read, but it doesn't discard the packet read.
 func (t *TrackRemote) peek(b []byte) (n int, a interceptor.Attributes, err error) {
+	if n, a, ok, err := t.peekPreBind(b); ok {
+		return n, a, err
+	}
+
 	n, a, err = t.Read(b)
 	if err != nil {
 		return
@@ -219,11 +509,238 @@ func (t *TrackRemote) peek(b []byte) (n int, a interceptor.Attributes, err error
 	return
 }
 
+// peekPreBind copies the first packet read ahead of the application, it waits for one to be
+// buffered. ok is false once the track is read by the application.
+func (t *TrackRemote) peekPreBind(b []byte) (n int, a interceptor.Attributes, ok bool, err error) {
+	t.mu.RLock()
+	buffer := t.preBind
+	t.mu.RUnlock()
+
+	for buffer != nil {
+		t.mu.RLock()
+		if len(t.peekedPackets) != 0 {
+			n = copy(b, t.peekedPackets[0].payload)
+			a = t.peekedPackets[0].attributes
+			t.mu.RUnlock()
+
+			return n, a, true, nil
+		}
+		t.mu.RUnlock()
+
+		select {
+		case <-buffer.buffered:
+		case <-buffer.stopped:
+			return 0, nil, false, nil
+		case <-t.receiver.closedChan:
+			return 0, nil, true, t.receiver.readErr(t)
+		case <-t.readDeadline.done():
+			return 0, nil, true, errReadTimeout
+		}
+	}
+
+	return 0, nil, false, nil
+}
+
 // SetReadDeadline sets the max amount of time the RTP stream will block before returning. 0 is forever.
+// Once the deadline is exceeded Read returns an error that is a net.Error with Timeout true.
+// The deadline also applies while the track waits for its stream to be bound.
 func (t *TrackRemote) SetReadDeadline(deadline time.Time) error {
+	t.readDeadline.set(deadline)
+
 	return t.receiver.setRTPReadDeadline(deadline, t)
 }
 
+// OnStarvation sets an event handler which is fired for each window of
+// SettingEngine.SetTrackFirstPacketTimeout in which the track received no packet,
+// for the application to escalate, by sending a PLI or subscribing again.
+func (t *TrackRemote) OnStarvation(f func()) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.onStarvationHandler = f
+}
+
+// watchStarvation starts the windows of timeout in which the track must receive its first packet.
+func (t *TrackRemote) watchStarvation(timeout time.Duration) {
+	if timeout <= 0 {
+		return
+	}
+
+	starvation := &trackStarvation{}
+	starvation.mu.Lock()
+	starvation.timer = time.AfterFunc(timeout, func() {
+		t.starve(starvation, timeout)
+	})
+	starvation.mu.Unlock()
+	t.starvation.Store(starvation)
+}
+
+// starve interrupts the reads of the track at the end of a window without packets,
+// the read blocked on the stream returns with a timeout that read reports as ErrTrackStarvation.
+func (t *TrackRemote) starve(starvation *trackStarvation, timeout time.Duration) {
+	starvation.mu.Lock()
+	if starvation.stopped || t.receiver.haveClosed() {
+		starvation.mu.Unlock()
+
+		return
+	}
+	starvation.pending = true
+	starvation.timer.Reset(timeout)
+	if err := t.receiver.setRTPReadDeadline(time.Now(), t); err != nil {
+		t.receiver.log.Debugf("Failed to interrupt the reads of starved track: %v", err)
+	}
+	starvation.mu.Unlock()
+
+	t.mu.RLock()
+	handler := t.onStarvationHandler
+	t.mu.RUnlock()
+	if handler != nil {
+		go handler()
+	}
+}
+
+// takeStarvation returns true once for every window without packets, and gives the
+// stream of the track its read deadline back.
+func (t *TrackRemote) takeStarvation() bool {
+	starvation := t.starvation.Load()
+	if starvation == nil {
+		return false
+	}
+
+	starvation.mu.Lock()
+	defer starvation.mu.Unlock()
+	if !starvation.pending {
+		return false
+	}
+	starvation.pending = false
+	t.restoreReadDeadline()
+
+	return true
+}
+
+// stopStarvation stops the windows once the track received a packet.
+func (t *TrackRemote) stopStarvation() {
+	starvation := t.starvation.Swap(nil)
+	if starvation == nil {
+		return
+	}
+
+	starvation.mu.Lock()
+	defer starvation.mu.Unlock()
+	starvation.stopped = true
+	starvation.timer.Stop()
+	if starvation.pending {
+		starvation.pending = false
+		t.restoreReadDeadline()
+	}
+}
+
+func (t *TrackRemote) restoreReadDeadline() {
+	deadline, _ := t.readDeadline.value()
+	if err := t.receiver.setRTPReadDeadline(deadline, t); err != nil {
+		t.receiver.log.Debugf("Failed to restore the read deadline of track: %v", err)
+	}
+}
+
+// GatingBypassed returns how many times keyframe gating gave up waiting for a keyframe
+// and released packets anyway. See SettingEngine.EnableKeyframeGating.
+func (t *TrackRemote) GatingBypassed() uint32 {
+	return t.gatingBypassed.Load()
+}
+
+// PausedPacketsDiscarded returns how many packets of the track were discarded while its
+// simulcast layer was paused. See RTPReceiver.PauseSimulcastLayer.
+func (t *TrackRemote) PausedPacketsDiscarded() uint64 {
+	return t.pausedPacketsDiscarded.Load()
+}
+
+// enableKeyframeGating starts withholding packets until a keyframe is observed, and
+// requests one from the remote sender.
+func (t *TrackRemote) enableKeyframeGating(timeout time.Duration, writeRTCP func([]rtcp.Packet) error) {
+	now := time.Now()
+
+	t.mu.Lock()
+	t.keyframeGate = &keyframeGate{
+		deadline:  now.Add(timeout),
+		lastPLI:   now,
+		writeRTCP: writeRTCP,
+	}
+	ssrc := t.ssrc
+	t.mu.Unlock()
+
+	t.sendPLI(writeRTCP, ssrc)
+}
+
+// passKeyframeGate returns true if the packet may be handed to the reader.
+func (t *TrackRemote) passKeyframeGate(pkt []byte) bool {
+	t.mu.Lock()
+	gate := t.keyframeGate
+	if gate == nil {
+		t.mu.Unlock()
+
+		return true
+	}
+
+	detector := keyframeDetectorForMimeType(t.codec.MimeType)
+	header := rtp.Header{}
+	headerLen, err := header.Unmarshal(pkt)
+	if detector == nil || err != nil {
+		t.keyframeGate = nil
+		t.mu.Unlock()
+
+		return true
+	}
+
+	payload := pkt[headerLen:]
+	if header.Padding && len(payload) > 0 {
+		paddingLen := int(payload[len(payload)-1])
+		if paddingLen > len(payload) {
+			paddingLen = len(payload)
+		}
+		payload = payload[:len(payload)-paddingLen]
+	}
+
+	if detector(payload) {
+		t.keyframeGate = nil
+		t.mu.Unlock()
+
+		return true
+	}
+
+	now := time.Now()
+	if !now.Before(gate.deadline) {
+		t.keyframeGate = nil
+		t.mu.Unlock()
+
+		t.gatingBypassed.Add(1)
+		t.receiver.log.Warnf("No keyframe received for SSRC %d before gating timeout, releasing packets", header.SSRC)
+
+		return true
+	}
+
+	sendPLI := now.Sub(gate.lastPLI) >= keyframeGatingPLIInterval
+	if sendPLI {
+		gate.lastPLI = now
+	}
+	t.mu.Unlock()
+
+	if sendPLI {
+		t.sendPLI(gate.writeRTCP, SSRC(header.SSRC))
+	}
+
+	return false
+}
+
+func (t *TrackRemote) sendPLI(writeRTCP func([]rtcp.Packet) error, ssrc SSRC) {
+	if writeRTCP == nil || ssrc == 0 {
+		return
+	}
+
+	if err := writeRTCP([]rtcp.Packet{&rtcp.PictureLossIndication{MediaSSRC: uint32(ssrc)}}); err != nil {
+		t.receiver.log.Debugf("Failed to send PLI: %v", err)
+	}
+}
+
 // RtxSSRC returns the RTX SSRC for a track, or 0 if track does not have a separate RTX stream.
 func (t *TrackRemote) RtxSSRC() SSRC {
 	t.mu.RLock()
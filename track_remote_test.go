@@ -6,9 +6,18 @@
 package webrtc
 
 import (
+	"io"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/rtcp"
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -113,3 +122,541 @@ func TestTrackRemotePullAudioPlayoutStatsNormalizesDefaults(t *testing.T) {
 	assert.Equal(t, string(MediaKindAudio), stats.Kind)
 	assert.NotZero(t, stats.Timestamp)
 }
+
+func newKeyframeGatingPair(t *testing.T, timeout time.Duration) (*PeerConnection, *PeerConnection) {
+	t.Helper()
+
+	settingEngine := SettingEngine{}
+	settingEngine.EnableKeyframeGating(true, timeout)
+
+	pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).newPair(Configuration{})
+	require.NoError(t, err)
+
+	return pcOffer, pcAnswer
+}
+
+func TestTrackRemoteKeyframeGating(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer := newKeyframeGatingPair(t, 10*time.Second)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	keyframeRequested := make(chan struct{})
+	go func() {
+		for {
+			pkts, _, readErr := sender.ReadRTCP()
+			if readErr != nil {
+				return
+			}
+
+			for _, pkt := range pkts {
+				if _, ok := pkt.(*rtcp.PictureLossIndication); ok {
+					select {
+					case <-keyframeRequested:
+					default:
+						close(keyframeRequested)
+					}
+				}
+			}
+		}
+	}()
+
+	firstPacket := make(chan *rtp.Packet, 1)
+	pcAnswer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+		pkt, _, readErr := remote.ReadRTP()
+		if readErr != nil {
+			return
+		}
+		assert.Equal(t, uint32(0), remote.GatingBypassed())
+		firstPacket <- pkt
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	// Start mid-GOP with interframes, and only send a keyframe once it has been requested.
+	sentKeyframe := false
+	for sequenceNumber := uint16(0); ; sequenceNumber++ {
+		payload := []byte{0x10, 0x01, 0xAA}
+		select {
+		case pkt := <-firstPacket:
+			assert.True(t, isVP8KeyframeStart(pkt.Payload))
+			assert.True(t, sentKeyframe)
+			closePairNow(t, pcOffer, pcAnswer)
+
+			return
+		case <-keyframeRequested:
+			if !sentKeyframe {
+				payload = []byte{0x10, 0x00, 0xAA}
+				sentKeyframe = true
+			}
+		case <-ticker.C:
+		}
+
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, Timestamp: uint32(sequenceNumber) * 3000},
+			Payload: payload,
+		}))
+	}
+}
+
+func TestTrackRemoteKeyframeGatingBypassed(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer := newKeyframeGatingPair(t, 200*time.Millisecond)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	bypassed := make(chan uint32, 1)
+	pcAnswer.OnTrack(func(remote *TrackRemote, _ *RTPReceiver) {
+		if _, _, readErr := remote.ReadRTP(); readErr != nil {
+			return
+		}
+		bypassed <- remote.GatingBypassed()
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	for sequenceNumber := uint16(0); ; sequenceNumber++ {
+		select {
+		case count := <-bypassed:
+			assert.Equal(t, uint32(1), count)
+			closePairNow(t, pcOffer, pcAnswer)
+
+			return
+		case <-ticker.C:
+		}
+
+		assert.NoError(t, track.WriteRTP(&rtp.Packet{
+			Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, Timestamp: uint32(sequenceNumber) * 3000},
+			Payload: []byte{0x10, 0x01, 0xAA},
+		}))
+	}
+}
+
+func TestTrackRemote_ReadDeadline(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pc, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	transceiver, err := pc.AddTransceiverFromKind(
+		RTPCodecTypeVideo, RTPTransceiverInit{Direction: RTPTransceiverDirectionRecvonly},
+	)
+	require.NoError(t, err)
+
+	// Configured but never bound, the track stays in the pre-bind stage.
+	receiver := transceiver.Receiver()
+	receiver.configureReceive(RTPReceiveParameters{
+		Encodings: []RTPDecodingParameters{{RTPCodingParameters: RTPCodingParameters{SSRC: 1234}}},
+	})
+	track := receiver.Track()
+	require.NotNil(t, track)
+
+	assertTimeout := func(err error) {
+		t.Helper()
+
+		var netErr net.Error
+		if assert.ErrorAs(t, err, &netErr) {
+			assert.True(t, netErr.Timeout())
+		}
+	}
+
+	t.Run("BeforeFirstPacket", func(t *testing.T) {
+		require.NoError(t, track.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+		_, _, readErr := track.ReadRTP()
+		assertTimeout(readErr)
+
+		require.NoError(t, receiver.SetReadDeadline(time.Now().Add(50*time.Millisecond)))
+		_, _, readErr = receiver.ReadRTCP()
+		assertTimeout(readErr)
+	})
+
+	t.Run("Extension", func(t *testing.T) {
+		require.NoError(t, track.SetReadDeadline(time.Now().Add(100*time.Millisecond)))
+
+		readErr := make(chan error, 1)
+		go func() {
+			_, _, err := track.ReadRTP()
+			readErr <- err
+		}()
+
+		time.Sleep(20 * time.Millisecond)
+		require.NoError(t, track.SetReadDeadline(time.Now().Add(300*time.Millisecond)))
+
+		select {
+		case err := <-readErr:
+			assert.Failf(t, "read returned before extended deadline", "%v", err)
+		case <-time.After(200 * time.Millisecond):
+		}
+		assertTimeout(<-readErr)
+	})
+
+	t.Run("CloseWinsOverTimeout", func(t *testing.T) {
+		require.NoError(t, track.SetReadDeadline(time.Now().Add(-time.Second)))
+		require.NoError(t, receiver.SetReadDeadline(time.Now().Add(-time.Second)))
+		require.NoError(t, receiver.Stop())
+
+		_, _, readErr := track.ReadRTP()
+		assert.ErrorIs(t, readErr, io.EOF)
+		_, _, readErr = receiver.ReadRTCP()
+		assert.ErrorIs(t, readErr, io.ErrClosedPipe)
+	})
+
+	require.NoError(t, pc.Close())
+}
+
+func TestTrackRemote_ReadErrors(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	// newPairWithTrack returns a connected pair, the offerer sending a video track
+	newPairWithTrack := func(t *testing.T) (*PeerConnection, *PeerConnection, *RTPSender, *TrackRemote) {
+		t.Helper()
+
+		pcOffer, pcAnswer, err := newPair()
+		require.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, err)
+		sender, err := pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		onTrack := make(chan *TrackRemote, 1)
+		pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+			onTrack <- trackRemote
+		})
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		done := make(chan struct{})
+		sent := make(chan struct{})
+		go func() {
+			sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+			close(sent)
+		}()
+		trackRemote := <-onTrack
+		close(done)
+		<-sent
+
+		return pcOffer, pcAnswer, sender, trackRemote
+	}
+
+	// readErrors reads the track and RTCP of its receiver until they fail
+	readErrors := func(trackRemote *TrackRemote) (trackErr, rtcpErr error) {
+		for trackErr == nil {
+			_, _, trackErr = trackRemote.ReadRTP()
+		}
+		for rtcpErr == nil {
+			_, _, rtcpErr = trackRemote.receiver.ReadRTCP()
+		}
+
+		return trackErr, rtcpErr
+	}
+
+	t.Run("Renegotiation restart", func(t *testing.T) {
+		pcOffer, pcAnswer, sender, trackRemote := newPairWithTrack(t)
+
+		// The remote announces another SSRC for the track, which replaces the receiver
+		ssrc := strconv.FormatUint(uint64(sender.GetParameters().Encodings[0].SSRC), 10)
+		require.NoError(t, signalPairWithModification(pcOffer, pcAnswer, func(sessionDescription string) string {
+			return strings.ReplaceAll(sessionDescription, ssrc, "1234")
+		}))
+		assert.NotEqual(t, trackRemote.receiver, pcAnswer.GetTransceivers()[0].Receiver())
+
+		trackErr, rtcpErr := readErrors(trackRemote)
+		assert.Equal(t, ErrReceiverRestarted, trackErr)
+		assert.NotErrorIs(t, trackErr, io.EOF)
+		assert.Equal(t, ErrReceiverRestarted, rtcpErr)
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("RemoveTrack on the remote", func(t *testing.T) {
+		pcOffer, pcAnswer, sender, trackRemote := newPairWithTrack(t)
+
+		require.NoError(t, pcOffer.RemoveTrack(sender))
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		trackErr, rtcpErr := readErrors(trackRemote)
+		assert.Equal(t, ErrTrackEnded, trackErr)
+		assert.ErrorIs(t, trackErr, io.EOF)
+		assert.Equal(t, ErrTrackEnded, rtcpErr)
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("RTCP BYE without reading RTCP", func(t *testing.T) {
+		pcOffer, pcAnswer, sender, trackRemote := newPairWithTrack(t)
+
+		// Stopping the sender sends a BYE, the track ends without renegotiation
+		require.NoError(t, sender.Stop())
+
+		var trackErr error
+		for trackErr == nil {
+			_, _, trackErr = trackRemote.ReadRTP()
+		}
+		assert.Equal(t, ErrTrackEnded, trackErr)
+
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("PeerConnection Close", func(t *testing.T) {
+		pcOffer, pcAnswer, _, trackRemote := newPairWithTrack(t)
+
+		closePairNow(t, pcOffer, pcAnswer)
+
+		trackErr, rtcpErr := readErrors(trackRemote)
+		assert.Equal(t, io.EOF, trackErr)
+		assert.Equal(t, io.ErrClosedPipe, rtcpErr)
+	})
+}
+
+func TestTrackRemote_ReadSample(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	t.Run("Reordered", func(t *testing.T) {
+		settingEngine := SettingEngine{}
+		settingEngine.EnableJitterBuffer(true, 100*time.Millisecond)
+		pcOffer, pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).newPair(Configuration{})
+		require.NoError(t, err)
+
+		track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, err)
+		_, err = pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		onTrack := make(chan *TrackRemote, 1)
+		pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+			onTrack <- trackRemote
+		})
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		// The frames are sent by pairs in reverse order, and the packets of a frame in the order
+		// 2, 0, 1. The sequence numbers and timestamps wrap, the second packet of lostFrame is lost.
+		const (
+			lostFrame           = 7
+			packetsPerFrame     = 3
+			frameDuration       = 3000
+			firstSequenceNumber = math.MaxUint16 - 4
+			firstTimestamp      = math.MaxUint32 - 2*frameDuration + 1
+		)
+		done := make(chan struct{})
+		sent := make(chan struct{})
+		go func() {
+			defer close(sent)
+
+			ticker := time.NewTicker(5 * time.Millisecond)
+			defer ticker.Stop()
+			for i := 0; ; i++ {
+				select {
+				case <-done:
+					return
+				case <-ticker.C:
+				}
+
+				frame := i ^ 1
+				for _, packet := range []int{2, 0, 1} {
+					if frame == lostFrame && packet == 1 {
+						continue
+					}
+					descriptor := byte(0)
+					if packet == 0 {
+						descriptor = 0x10
+					}
+					assert.NoError(t, track.WriteRTP(&rtp.Packet{
+						Header: rtp.Header{
+							Version:        2,
+							SequenceNumber: uint16(firstSequenceNumber + frame*packetsPerFrame + packet), //nolint:gosec // G115
+							Timestamp:      uint32(firstTimestamp + frame*frameDuration),                 //nolint:gosec // G115
+							Marker:         packet == packetsPerFrame-1,
+						},
+						Payload: []byte{descriptor, byte(frame), byte(packet)},
+					}))
+				}
+			}
+		}()
+
+		trackRemote := <-onTrack
+		var dropped []uint16
+		trackRemote.OnFrameDropped(func(droppedPackets uint16) {
+			dropped = append(dropped, droppedPackets)
+		})
+
+		for frame := 0; frame <= lostFrame+3; frame++ {
+			if frame == lostFrame {
+				continue
+			}
+
+			sample, err := trackRemote.ReadSample()
+			require.NoError(t, err)
+			assert.Equal(t, uint32(firstTimestamp+frame*frameDuration), sample.PacketTimestamp) //nolint:gosec // G115
+			assert.Equal(t, []byte{byte(frame), 0, byte(frame), 1, byte(frame), 2}, sample.Data)
+			if frame < lostFrame {
+				assert.Empty(t, dropped)
+			} else {
+				assert.Equal(t, []uint16{packetsPerFrame}, dropped)
+			}
+		}
+
+		_, _, err = trackRemote.ReadRTP()
+		assert.ErrorIs(t, err, ErrTrackReadBySample)
+
+		close(done)
+		<-sent
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("Disabled", func(t *testing.T) {
+		pcOffer, pcAnswer, err := newPair()
+		require.NoError(t, err)
+
+		track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+		require.NoError(t, err)
+		_, err = pcOffer.AddTrack(track)
+		require.NoError(t, err)
+
+		onTrack := make(chan *TrackRemote, 1)
+		pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+			onTrack <- trackRemote
+		})
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+		done := make(chan struct{})
+		sent := make(chan struct{})
+		go func() {
+			sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+			close(sent)
+		}()
+		trackRemote := <-onTrack
+
+		_, err = trackRemote.ReadSample()
+		assert.ErrorIs(t, err, ErrJitterBufferDisabled)
+		_, _, err = trackRemote.ReadRTP()
+		assert.NoError(t, err)
+
+		close(done)
+		<-sent
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("Codec not supported", func(t *testing.T) {
+		_, err := newJitterBuffer(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypePCMU, ClockRate: 8000},
+		}, time.Second)
+		assert.ErrorIs(t, err, ErrJitterBufferCodecNotSupported)
+
+		buffer, err := newJitterBuffer(RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypeOpus, ClockRate: 48000},
+		}, time.Second)
+		require.NoError(t, err)
+		assert.ErrorIs(t, buffer.push(&rtp.Packet{}, RTPCodecParameters{
+			RTPCodecCapability: RTPCodecCapability{MimeType: MimeTypePCMA, ClockRate: 8000},
+		}), ErrJitterBufferCodecNotSupported)
+	})
+}
+
+func TestTrackRemote_FirstPacketTimeout(t *testing.T) {
+	lim := test.TimeOut(time.Second * 10)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const timeout = 100 * time.Millisecond
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetFireOnTrackBeforeFirstRTP(true)
+	settingEngine.SetTrackFirstPacketTimeout(timeout)
+	pcOffer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	_, err = pcOffer.AddTransceiverFromKind(RTPCodecTypeVideo)
+	require.NoError(t, err)
+	localTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcAnswer.AddTrack(localTrack)
+	require.NoError(t, err)
+
+	var starvations atomic.Int32
+	remoteTracks := make(chan *TrackRemote, 1)
+	pcOffer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		track.OnStarvation(func() {
+			starvations.Add(1)
+		})
+		remoteTracks <- track
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	remoteTrack := <-remoteTracks
+
+	// The track is signaled but never sent, each window ends the read with ErrTrackStarvation
+	start := time.Now()
+	for i := 1; i <= 3; i++ {
+		_, _, readErr := remoteTrack.ReadRTP()
+		assert.ErrorIs(t, readErr, ErrTrackStarvation)
+		assert.GreaterOrEqual(t, time.Since(start), time.Duration(i)*timeout-10*time.Millisecond)
+	}
+	assert.Eventually(t, func() bool {
+		return starvations.Load() >= 3
+	}, time.Second, 10*time.Millisecond)
+
+	// Once the track is sent the reads return its packets, and the windows stop
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{localTrack})
+		close(sent)
+	}()
+	for {
+		_, _, readErr := remoteTrack.ReadRTP()
+		if readErr == nil {
+			break
+		}
+		assert.ErrorIs(t, readErr, ErrTrackStarvation)
+	}
+	close(done)
+	<-sent
+
+	starved := starvations.Load()
+	time.Sleep(3 * timeout)
+	assert.Equal(t, starved, starvations.Load())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pion/sdp/v3"
+)
+
+// trackIdentifierPolicy limits the track and stream identifiers accepted from remote
+// descriptions, see SettingEngine.SetTrackIdentifierPolicy.
+type trackIdentifierPolicy struct {
+	enabled       bool
+	maxLength     int
+	rejectInvalid bool
+}
+
+func (p trackIdentifierPolicy) getMaxLength() int {
+	if p.maxLength <= 0 {
+		return defaultTrackIdentifierMaxLength
+	}
+
+	return p.maxLength
+}
+
+// check returns an error if id is too long or contains invalid characters.
+func (p trackIdentifierPolicy) check(id string) error {
+	if len(id) > p.getMaxLength() {
+		return fmt.Errorf("%w: %d bytes", ErrTrackIdentifierTooLong, len(id))
+	}
+
+	if !utf8.ValidString(id) || strings.IndexFunc(id, unicode.IsControl) != -1 {
+		return fmt.Errorf("%w: %q", ErrTrackIdentifierInvalidCharacter, id)
+	}
+
+	return nil
+}
+
+// validate checks every msid of the sending media sections of a remote description.
+func (p trackIdentifierPolicy) validate(desc *sdp.SessionDescription) error {
+	if !p.enabled {
+		return nil
+	}
+
+	midByTrack := map[[2]string]string{}
+	for _, media := range desc.MediaDescriptions {
+		if _, ok := media.Attribute(sdp.AttrKeyRecvOnly); ok {
+			continue
+		} else if _, ok := media.Attribute(sdp.AttrKeyInactive); ok {
+			continue
+		}
+
+		mid := getMidValue(media)
+		for _, attr := range media.Attributes {
+			streamID, trackID, ok := msidFromAttribute(attr)
+			if !ok {
+				continue
+			}
+
+			for _, id := range []string{streamID, trackID} {
+				if err := p.check(id); err != nil {
+					return err
+				}
+			}
+
+			track := [2]string{streamID, trackID}
+			if otherMid, ok := midByTrack[track]; ok && otherMid != mid {
+				return fmt.Errorf("%w: %q in media sections %q and %q", ErrTrackIdentifierDuplicate, trackID, otherMid, mid)
+			}
+			midByTrack[track] = mid
+		}
+	}
+
+	return nil
+}
+
+// apply records the identifiers of incomingTracks as their original ones, and sanitizes
+// them unless invalid identifiers are rejected. Duplicate track identifiers get the MID
+// of their media section appended.
+func (p trackIdentifierPolicy) apply(incomingTracks []trackDetails) []trackDetails {
+	midByTrack := map[[2]string]string{}
+	for i := range incomingTracks {
+		details := &incomingTracks[i]
+		details.originalStreamID, details.originalID = details.streamID, details.id
+		if !p.enabled || p.rejectInvalid {
+			continue
+		}
+
+		details.streamID = sanitizeTrackIdentifier(details.streamID, p.getMaxLength())
+		details.id = sanitizeTrackIdentifier(details.id, p.getMaxLength())
+
+		track := [2]string{details.streamID, details.id}
+		if otherMid, ok := midByTrack[track]; ok && otherMid != details.mid {
+			suffix := "-" + sanitizeTrackIdentifier(details.mid, p.getMaxLength()/2)
+			details.id = truncateUTF8(details.id, p.getMaxLength()-len(suffix)) + suffix
+		} else {
+			midByTrack[track] = details.mid
+		}
+	}
+
+	return incomingTracks
+}
+
+// sanitizeTrackIdentifier strips control characters and invalid UTF-8 from id, and truncates
+// it to maxLength bytes.
+func sanitizeTrackIdentifier(id string, maxLength int) string {
+	id = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+
+		return r
+	}, strings.ToValidUTF8(id, ""))
+
+	return truncateUTF8(id, maxLength)
+}
+
+func truncateUTF8(s string, maxLength int) string {
+	for len(s) > maxLength {
+		_, size := utf8.DecodeLastRuneInString(s)
+		s = s[:len(s)-size]
+	}
+
+	return s
+}
+
+// msidFromAttribute returns the stream and track identifiers of `a=msid:<stream> <track>`
+// and `a=ssrc:<ssrc> msid:<stream> <track>` attributes.
+func msidFromAttribute(attr sdp.Attribute) (streamID, trackID string, ok bool) {
+	split := strings.Split(attr.Value, " ")
+	switch {
+	case attr.Key == sdp.AttrKeyMsid && len(split) == 2:
+		return split[0], split[1], true
+	case attr.Key == sdp.AttrKeySSRC && len(split) == 3 && strings.HasPrefix(split[1], "msid:"):
+		return split[1][len("msid:"):], split[2], true
+	default:
+		return "", "", false
+	}
+}
@@ -0,0 +1,251 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/pion/sdp/v3"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTrackIdentifierPolicy_validate(t *testing.T) {
+	mediaWithMsid := func(mid string, attributes ...sdp.Attribute) *sdp.MediaDescription {
+		return &sdp.MediaDescription{
+			MediaName:  sdp.MediaName{Media: "video"},
+			Attributes: append([]sdp.Attribute{{Key: sdp.AttrKeyMID, Value: mid}}, attributes...),
+		}
+	}
+	msid := func(streamID, trackID string) sdp.Attribute {
+		return sdp.Attribute{Key: sdp.AttrKeyMsid, Value: streamID + " " + trackID}
+	}
+	ssrcMsid := func(ssrc, streamID, trackID string) sdp.Attribute {
+		return sdp.Attribute{Key: sdp.AttrKeySSRC, Value: ssrc + " msid:" + streamID + " " + trackID}
+	}
+
+	for _, test := range []struct {
+		name  string
+		media []*sdp.MediaDescription
+		err   error
+	}{
+		{
+			name: "Valid",
+			media: []*sdp.MediaDescription{
+				mediaWithMsid("0", msid("stream", "audio"), ssrcMsid("1", "stream", "audio"), ssrcMsid("2", "stream", "audio")),
+				mediaWithMsid("1", msid("stream", "video")),
+			},
+		},
+		{
+			name:  "Oversized",
+			media: []*sdp.MediaDescription{mediaWithMsid("0", msid("stream", strings.Repeat("a", 65)))},
+			err:   ErrTrackIdentifierTooLong,
+		},
+		{
+			name:  "Oversized in ssrc line",
+			media: []*sdp.MediaDescription{mediaWithMsid("0", ssrcMsid("1", strings.Repeat("a", 65), "video"))},
+			err:   ErrTrackIdentifierTooLong,
+		},
+		{
+			name:  "Control character",
+			media: []*sdp.MediaDescription{mediaWithMsid("0", msid("stream", "vid\x1b[2Jeo"))},
+			err:   ErrTrackIdentifierInvalidCharacter,
+		},
+		{
+			name:  "Invalid UTF-8",
+			media: []*sdp.MediaDescription{mediaWithMsid("0", msid("str\xffeam", "video"))},
+			err:   ErrTrackIdentifierInvalidCharacter,
+		},
+		{
+			name: "Duplicate",
+			media: []*sdp.MediaDescription{
+				mediaWithMsid("0", msid("stream", "video")),
+				mediaWithMsid("1", msid("stream", "video")),
+			},
+			err: ErrTrackIdentifierDuplicate,
+		},
+		{
+			name: "Same track in different streams",
+			media: []*sdp.MediaDescription{
+				mediaWithMsid("0", msid("stream1", "video")),
+				mediaWithMsid("1", msid("stream2", "video")),
+			},
+		},
+		{
+			name: "Recvonly media sections are ignored",
+			media: []*sdp.MediaDescription{
+				mediaWithMsid("0", msid("stream", "video")),
+				mediaWithMsid("1", msid("stream", "video"), sdp.Attribute{Key: sdp.AttrKeyRecvOnly}),
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			desc := &sdp.SessionDescription{MediaDescriptions: test.media}
+
+			assert.NoError(t, trackIdentifierPolicy{}.validate(desc), "disabled policy validated identifiers")
+			for _, rejectInvalid := range []bool{true, false} {
+				err := trackIdentifierPolicy{enabled: true, rejectInvalid: rejectInvalid}.validate(desc)
+				if test.err == nil {
+					assert.NoError(t, err)
+				} else {
+					assert.ErrorIs(t, err, test.err)
+				}
+			}
+		})
+	}
+
+	err := trackIdentifierPolicy{enabled: true, maxLength: 8}.validate(&sdp.SessionDescription{
+		MediaDescriptions: []*sdp.MediaDescription{mediaWithMsid("0", msid("stream", "video-track"))},
+	})
+	assert.ErrorIs(t, err, ErrTrackIdentifierTooLong)
+}
+
+func TestTrackIdentifierPolicy_apply(t *testing.T) {
+	incomingTracks := func() []trackDetails {
+		return []trackDetails{
+			{mid: "0", streamID: "str\x00eam", id: strings.Repeat("a", 100)},
+			{mid: "1", streamID: "stream", id: "vid\x1b[2Jeo"},
+			{mid: "2", streamID: "stream", id: "vid[2Jeo"},
+			{mid: "2", streamID: "stream", id: "vid[2Jeo"},
+		}
+	}
+
+	t.Run("Sanitize", func(t *testing.T) {
+		tracks := trackIdentifierPolicy{enabled: true}.apply(incomingTracks())
+
+		assert.Equal(t, "stream", tracks[0].streamID)
+		assert.Equal(t, strings.Repeat("a", 64), tracks[0].id)
+		assert.Equal(t, "vid[2Jeo", tracks[1].id)
+		assert.Equal(t, "vid[2Jeo-2", tracks[2].id, "duplicate in another media section")
+		assert.Equal(t, "vid[2Jeo-2", tracks[3].id, "same track in the same media section")
+
+		for i, original := range incomingTracks() {
+			assert.Equal(t, original.streamID, tracks[i].originalStreamID)
+			assert.Equal(t, original.id, tracks[i].originalID)
+		}
+	})
+
+	for name, policy := range map[string]trackIdentifierPolicy{
+		"Reject":   {enabled: true, rejectInvalid: true},
+		"Disabled": {},
+	} {
+		t.Run(name, func(t *testing.T) {
+			tracks := policy.apply(incomingTracks())
+			for i, original := range incomingTracks() {
+				assert.Equal(t, original.streamID, tracks[i].streamID)
+				assert.Equal(t, original.id, tracks[i].id)
+				assert.Equal(t, original.streamID, tracks[i].originalStreamID)
+				assert.Equal(t, original.id, tracks[i].originalID)
+			}
+		})
+	}
+}
+
+func TestPeerConnection_TrackIdentifierPolicy(t *testing.T) {
+	remoteOffer := func(t *testing.T, replacer *strings.Replacer) SessionDescription {
+		t.Helper()
+
+		pcOffer, err := NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		for _, trackID := range []string{"video1", "video2"} {
+			track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, trackID, "stream")
+			require.NoError(t, err)
+			_, err = pcOffer.AddTrack(track)
+			require.NoError(t, err)
+		}
+
+		offer, err := pcOffer.CreateOffer(nil)
+		require.NoError(t, err)
+		require.NoError(t, pcOffer.Close())
+
+		offer.SDP = replacer.Replace(offer.SDP)
+
+		return offer
+	}
+
+	oversized := strings.Repeat("s", 100)
+	for _, test := range []struct {
+		name             string
+		replacer         *strings.Replacer
+		err              error
+		streamID, id     string
+		originalStreamID string
+		originalID       string
+	}{
+		{
+			name:             "Oversized",
+			replacer:         strings.NewReplacer(":stream ", ":"+oversized+" "),
+			err:              ErrTrackIdentifierTooLong,
+			streamID:         oversized[:64],
+			id:               "video1",
+			originalStreamID: oversized,
+			originalID:       "video1",
+		},
+		{
+			name:             "Control character",
+			replacer:         strings.NewReplacer("video1", "vid\aeo1"),
+			err:              ErrTrackIdentifierInvalidCharacter,
+			streamID:         "stream",
+			id:               "video1",
+			originalStreamID: "stream",
+			originalID:       "vid\aeo1",
+		},
+		{
+			name:             "Duplicate",
+			replacer:         strings.NewReplacer("video2", "video1"),
+			err:              ErrTrackIdentifierDuplicate,
+			streamID:         "stream",
+			id:               "video1",
+			originalStreamID: "stream",
+			originalID:       "video1",
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			t.Run("Reject", func(t *testing.T) {
+				settingEngine := SettingEngine{}
+				settingEngine.SetTrackIdentifierPolicy(0, true)
+
+				pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+				require.NoError(t, err)
+
+				var invalidAccessErr *rtcerr.InvalidAccessError
+				err = pcAnswer.SetRemoteDescription(remoteOffer(t, test.replacer))
+				assert.ErrorAs(t, err, &invalidAccessErr)
+				assert.ErrorIs(t, err, test.err)
+				assert.Nil(t, pcAnswer.RemoteDescription())
+
+				assert.NoError(t, pcAnswer.Close())
+			})
+
+			t.Run("Sanitize", func(t *testing.T) {
+				settingEngine := SettingEngine{}
+				settingEngine.SetTrackIdentifierPolicy(0, false)
+
+				pcAnswer, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+				require.NoError(t, err)
+				require.NoError(t, pcAnswer.SetRemoteDescription(remoteOffer(t, test.replacer)))
+				answer, err := pcAnswer.CreateAnswer(nil)
+				require.NoError(t, err)
+				require.NoError(t, pcAnswer.SetLocalDescription(answer))
+
+				transceivers := pcAnswer.GetTransceivers()
+				require.Len(t, transceivers, 2)
+
+				track := transceivers[0].Receiver().Track()
+				assert.Equal(t, test.streamID, track.StreamID())
+				assert.Equal(t, test.id, track.ID())
+				assert.Equal(t, test.originalStreamID, track.OriginalStreamID())
+				assert.Equal(t, test.originalID, track.OriginalID())
+				assert.NotEqual(t, track.ID(), transceivers[1].Receiver().Track().ID())
+
+				assert.NoError(t, pcAnswer.Close())
+			})
+		})
+	}
+}
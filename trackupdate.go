@@ -0,0 +1,180 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"slices"
+
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+)
+
+// TrackUpdate collects the track and transceiver changes of a single
+// PeerConnection.UpdateTracks call. It is only valid inside the callback.
+type TrackUpdate struct {
+	pc     *PeerConnection
+	undo   []func()
+	commit []func() error
+}
+
+// UpdateTracks applies a batch of track and transceiver changes atomically.
+// The PeerConnection is locked while update runs, so CreateOffer never sees a
+// partially applied batch, and negotiationneeded fires at most once when the
+// batch completes. If update returns an error every change made through tx is
+// rolled back and the error is returned.
+//
+// Once update returned nil the batch is applied, and the senders removed by it are
+// stopped. Stopping them isn't atomic: if a sender fails to stop, the others are still
+// stopped and the first error is returned, but the batch isn't rolled back, since a
+// stopped RTPSender can't be restarted. negotiationneeded fires for the batch anyway.
+//
+// update must only change the PeerConnection through tx, calling PeerConnection
+// methods such as AddTrack or GetTransceivers from update will deadlock.
+func (pc *PeerConnection) UpdateTracks(update func(tx *TrackUpdate) error) error {
+	if pc.isClosed.Load() {
+		return &rtcerr.InvalidStateError{Err: ErrConnectionClosed}
+	}
+
+	pc.mu.Lock()
+	defer pc.mu.Unlock()
+
+	pc.batchingTrackUpdates.Store(true)
+	defer func() {
+		pc.batchingTrackUpdates.Store(false)
+		pc.batchedNegotiationNeeded.Store(false)
+	}()
+
+	tx := &TrackUpdate{pc: pc}
+	if err := update(tx); err != nil {
+		for i := len(tx.undo) - 1; i >= 0; i-- {
+			tx.undo[i]()
+		}
+
+		return err
+	}
+
+	var commitErr error
+	for _, commit := range tx.commit {
+		if err := commit(); err != nil && commitErr == nil {
+			commitErr = err
+		}
+	}
+
+	pc.batchingTrackUpdates.Store(false)
+	if pc.batchedNegotiationNeeded.Load() {
+		pc.onNegotiationNeeded()
+	}
+
+	return commitErr
+}
+
+// AddTrack adds a Track to the PeerConnection, see PeerConnection.AddTrack.
+func (tx *TrackUpdate) AddTrack(track TrackLocal) (*RTPSender, error) {
+	sender, undo, err := tx.pc.addTrack(track)
+	if err != nil {
+		return nil, err
+	}
+	tx.undo = append(tx.undo, undo)
+
+	return sender, nil
+}
+
+// RemoveTrack removes a Track from the PeerConnection, see PeerConnection.RemoveTrack.
+// The sender is stopped once the batch completes successfully.
+func (tx *TrackUpdate) RemoveTrack(sender *RTPSender) error {
+	transceiver := tx.pc.transceiverForSender(sender)
+	if transceiver == nil {
+		return &rtcerr.InvalidAccessError{Err: ErrSenderNotCreatedByConnection}
+	}
+
+	direction := transceiver.Direction()
+	if err := transceiver.updateDirectionForSendingTrack(false); err != nil {
+		return err
+	}
+	transceiver.setSender(nil)
+	tx.pc.onNegotiationNeeded()
+
+	tx.undo = append(tx.undo, func() {
+		transceiver.setSender(sender)
+		transceiver.setDirection(direction)
+	})
+	tx.commit = append(tx.commit, func() error {
+		if err := sender.Stop(); err != nil {
+			return err
+		}
+
+		return sender.ReplaceTrack(nil)
+	})
+
+	return nil
+}
+
+// AddTransceiverFromKind creates a new RTPTransceiver, see PeerConnection.AddTransceiverFromKind.
+func (tx *TrackUpdate) AddTransceiverFromKind(
+	kind RTPCodecType,
+	init ...RTPTransceiverInit,
+) (*RTPTransceiver, error) {
+	transceiver, err := tx.pc.newTransceiverFromKind(kind, init...)
+	if err != nil {
+		return nil, err
+	}
+	tx.addTransceiver(transceiver)
+
+	return transceiver, nil
+}
+
+// AddTransceiverFromTrack creates a new RTPTransceiver, see PeerConnection.AddTransceiverFromTrack.
+func (tx *TrackUpdate) AddTransceiverFromTrack(
+	track TrackLocal,
+	init ...RTPTransceiverInit,
+) (*RTPTransceiver, error) {
+	direction := RTPTransceiverDirectionSendrecv
+	if len(init) > 1 {
+		return nil, errPeerConnAddTransceiverFromTrackOnlyAcceptsOne
+	} else if len(init) == 1 {
+		direction = init[0].Direction
+	}
+
+	transceiver, err := tx.pc.newTransceiverFromTrack(direction, track, init...)
+	if err != nil {
+		return nil, err
+	}
+	tx.addTransceiver(transceiver)
+
+	return transceiver, nil
+}
+
+func (tx *TrackUpdate) addTransceiver(transceiver *RTPTransceiver) {
+	tx.pc.addRTPTransceiver(transceiver)
+	tx.undo = append(tx.undo, func() { tx.pc.removeRTPTransceiver(transceiver) })
+}
+
+// SetDirection changes the preferred direction of a RTPTransceiver of the
+// PeerConnection. Sending directions require the transceiver to have a sender.
+func (tx *TrackUpdate) SetDirection(transceiver *RTPTransceiver, direction RTPTransceiverDirection) error {
+	if !slices.Contains(tx.pc.rtpTransceivers, transceiver) {
+		return &rtcerr.InvalidAccessError{Err: errRTPTransceiverNotCreatedByConnection}
+	}
+
+	switch direction {
+	case RTPTransceiverDirectionSendrecv, RTPTransceiverDirectionSendonly:
+		if transceiver.Sender() == nil {
+			return &rtcerr.InvalidStateError{Err: errRTPTransceiverNoSenderForDirection}
+		}
+	case RTPTransceiverDirectionRecvonly, RTPTransceiverDirectionInactive:
+	default:
+		return &rtcerr.TypeError{Err: ErrUnknownType}
+	}
+
+	previous := transceiver.Direction()
+	if previous == direction {
+		return nil
+	}
+	transceiver.setDirection(direction)
+	tx.pc.onNegotiationNeeded()
+	tx.undo = append(tx.undo, func() { transceiver.setDirection(previous) })
+
+	return nil
+}
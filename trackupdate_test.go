@@ -0,0 +1,213 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/pion/webrtc/v4/pkg/rtcerr"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sdpMidDirection(t *testing.T, desc SessionDescription, mid string) RTPTransceiverDirection {
+	t.Helper()
+
+	for _, media := range desc.parsed.MediaDescriptions {
+		if cmid, ok := media.Attribute("mid"); ok && cmid == mid {
+			return getPeerDirection(media)
+		}
+	}
+	assert.Failf(t, "mid not found", "mid %s", mid)
+
+	return RTPTransceiverDirectionUnknown
+}
+
+func TestPeerConnection_UpdateTracks(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	trackA, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "a", "pion")
+	require.NoError(t, err)
+	trackB, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "b", "pion")
+	require.NoError(t, err)
+	trackC, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "c", "pion")
+	require.NoError(t, err)
+
+	senderA, err := pcOffer.AddTrack(trackA)
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(trackB)
+	require.NoError(t, err)
+	audio, err := pcOffer.AddTransceiverFromKind(
+		RTPCodecTypeAudio, RTPTransceiverInit{Direction: RTPTransceiverDirectionRecvonly},
+	)
+	require.NoError(t, err)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, pcOffer.WaitForPendingOperations(ctx))
+
+	var negotiationNeeded atomic.Int32
+	negotiationNeededCh := make(chan struct{}, 1)
+	pcOffer.OnNegotiationNeeded(func() {
+		negotiationNeeded.Add(1)
+		negotiationNeededCh <- struct{}{}
+	})
+
+	t.Run("Rollback", func(t *testing.T) {
+		errBatch := errors.New("batch failed")
+		transceiverCount := len(pcOffer.GetTransceivers())
+
+		assert.ErrorIs(t, pcOffer.UpdateTracks(func(tx *TrackUpdate) error {
+			if _, err := tx.AddTransceiverFromKind(RTPCodecTypeVideo); err != nil {
+				return err
+			}
+			if err := tx.RemoveTrack(senderA); err != nil {
+				return err
+			}
+			if err := tx.SetDirection(audio, RTPTransceiverDirectionInactive); err != nil {
+				return err
+			}
+
+			return errBatch
+		}), errBatch)
+
+		require.NoError(t, pcOffer.WaitForPendingOperations(ctx))
+		assert.Equal(t, int32(0), negotiationNeeded.Load())
+		assert.Len(t, pcOffer.GetTransceivers(), transceiverCount)
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, audio.Direction())
+		assert.Equal(t, RTPTransceiverDirectionSendrecv, pcOffer.GetTransceivers()[0].Direction())
+		assert.Equal(t, senderA, pcOffer.GetTransceivers()[0].Sender())
+	})
+
+	t.Run("InvalidDirection", func(t *testing.T) {
+		var rtcErr *rtcerr.InvalidStateError
+		assert.ErrorAs(t, pcOffer.UpdateTracks(func(tx *TrackUpdate) error {
+			return tx.SetDirection(audio, RTPTransceiverDirectionSendonly)
+		}), &rtcErr)
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, audio.Direction())
+	})
+
+	t.Run("Batch", func(t *testing.T) {
+		var senderC *RTPSender
+		require.NoError(t, pcOffer.UpdateTracks(func(tx *TrackUpdate) error {
+			if err := tx.RemoveTrack(senderA); err != nil {
+				return err
+			}
+			var err error
+			if senderC, err = tx.AddTrack(trackC); err != nil {
+				return err
+			}
+
+			return tx.SetDirection(audio, RTPTransceiverDirectionInactive)
+		}))
+
+		select {
+		case <-negotiationNeededCh:
+		case <-ctx.Done():
+			require.FailNow(t, "negotiationneeded not fired")
+		}
+		require.NoError(t, pcOffer.WaitForPendingOperations(ctx))
+		assert.Equal(t, int32(1), negotiationNeeded.Load())
+
+		offer, err := pcOffer.CreateOffer(nil)
+		require.NoError(t, err)
+
+		transceivers := pcOffer.GetTransceivers()
+		require.Len(t, transceivers, 4)
+		assert.Nil(t, transceivers[0].Sender())
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, sdpMidDirection(t, offer, transceivers[0].Mid()))
+		assert.Equal(t, RTPTransceiverDirectionSendrecv, sdpMidDirection(t, offer, transceivers[1].Mid()))
+		assert.Equal(t, RTPTransceiverDirectionInactive, sdpMidDirection(t, offer, audio.Mid()))
+		assert.Equal(t, senderC, transceivers[3].Sender())
+		assert.True(t, sdpMidHasSsrc(offer, transceivers[3].Mid(), senderC.GetParameters().Encodings[0].SSRC))
+	})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+// unbindErrorTrack fails to be unbound, which makes stopping its RTPSender fail.
+type unbindErrorTrack struct {
+	*TrackLocalStaticSample
+	err error
+}
+
+func (t *unbindErrorTrack) Unbind(ctx TrackLocalContext) error {
+	if err := t.TrackLocalStaticSample.Unbind(ctx); err != nil {
+		return err
+	}
+
+	return t.err
+}
+
+func TestPeerConnection_UpdateTracks_StopError(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	errUnbind := errors.New("unbind failed")
+	trackA, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "a", "pion")
+	require.NoError(t, err)
+	trackB, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "b", "pion")
+	require.NoError(t, err)
+
+	senderA, err := pcOffer.AddTrack(&unbindErrorTrack{TrackLocalStaticSample: trackA, err: errUnbind})
+	require.NoError(t, err)
+	senderB, err := pcOffer.AddTrack(trackB)
+	require.NoError(t, err)
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, pcOffer.WaitForPendingOperations(ctx))
+
+	negotiationNeeded := make(chan struct{}, 1)
+	pcOffer.OnNegotiationNeeded(func() {
+		negotiationNeeded <- struct{}{}
+	})
+
+	// The batch stays applied when a removed sender fails to stop
+	assert.ErrorIs(t, pcOffer.UpdateTracks(func(tx *TrackUpdate) error {
+		if err := tx.RemoveTrack(senderA); err != nil {
+			return err
+		}
+
+		return tx.RemoveTrack(senderB)
+	}), errUnbind)
+
+	select {
+	case <-negotiationNeeded:
+	case <-ctx.Done():
+		require.FailNow(t, "negotiationneeded not fired")
+	}
+
+	for _, transceiver := range pcOffer.GetTransceivers() {
+		assert.Nil(t, transceiver.Sender())
+		assert.Equal(t, RTPTransceiverDirectionRecvonly, transceiver.Direction())
+	}
+	assert.True(t, senderA.hasStopped())
+	assert.True(t, senderB.hasStopped())
+	assert.Nil(t, senderB.Track())
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
@@ -0,0 +1,55 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+// UnknownSSRCActionType determines what happens to an incoming RTP stream whose
+// SSRC was not signaled in the RemoteDescription.
+type UnknownSSRCActionType int
+
+const (
+	// UnknownSSRCActionTypeProbe resolves the stream with the default heuristics,
+	// probing packets for the MID and RID header extensions.
+	UnknownSSRCActionTypeProbe UnknownSSRCActionType = iota
+
+	// UnknownSSRCActionTypeDrop ignores the stream. OnTrack is never fired for it.
+	UnknownSSRCActionTypeDrop
+
+	// UnknownSSRCActionTypeAttachToTransceiver binds the stream to the transceiver
+	// with UnknownSSRCAction.MID. If no such transceiver exists yet, packets are
+	// buffered until a RemoteDescription adds it.
+	UnknownSSRCActionTypeAttachToTransceiver
+)
+
+// This is done this way because of a linter.
+const (
+	unknownSSRCActionTypeProbeStr               = "probe"
+	unknownSSRCActionTypeDropStr                = "drop"
+	unknownSSRCActionTypeAttachToTransceiverStr = "attach-to-transceiver"
+)
+
+func (t UnknownSSRCActionType) String() string {
+	switch t {
+	case UnknownSSRCActionTypeProbe:
+		return unknownSSRCActionTypeProbeStr
+	case UnknownSSRCActionTypeDrop:
+		return unknownSSRCActionTypeDropStr
+	case UnknownSSRCActionTypeAttachToTransceiver:
+		return unknownSSRCActionTypeAttachToTransceiverStr
+	default:
+		return ErrUnknownType.Error()
+	}
+}
+
+// UnknownSSRCAction is returned by an UnknownSSRCHandler.
+type UnknownSSRCAction struct {
+	Type UnknownSSRCActionType
+
+	// MID of the transceiver to attach to, used by UnknownSSRCActionTypeAttachToTransceiver.
+	MID string
+}
+
+// UnknownSSRCHandler is called for every incoming RTP stream whose SSRC was not signaled.
+// mid and rid are read from the header extensions of the first packet, and are empty
+// if they are absent or the extensions weren't negotiated.
+type UnknownSSRCHandler func(ssrc uint32, payloadType uint8, mid, rid string) UnknownSSRCAction
@@ -0,0 +1,30 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnknownSSRCActionType_String(t *testing.T) {
+	testCases := []struct {
+		actionType     UnknownSSRCActionType
+		expectedString string
+	}{
+		{UnknownSSRCActionTypeProbe, "probe"},
+		{UnknownSSRCActionTypeDrop, "drop"},
+		{UnknownSSRCActionTypeAttachToTransceiver, "attach-to-transceiver"},
+		{UnknownSSRCActionType(42), ErrUnknownType.Error()},
+	}
+
+	for i, testCase := range testCases {
+		assert.Equal(t,
+			testCase.expectedString,
+			testCase.actionType.String(),
+			"testCase: %d %v", i, testCase,
+		)
+	}
+}
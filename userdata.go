@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"reflect"
+	"sync"
+)
+
+// userData holds the values an application associates with an object through its
+// SetUserData and UserData methods, instead of maps keyed by the object that keep it alive.
+// The values are released by clear once the lifecycle of the object ends, the values set
+// afterwards are dropped. The zero value is empty.
+type userData struct {
+	mu      sync.RWMutex
+	values  map[any]any
+	cleared bool
+}
+
+// set stores value under key, a nil value removes the key. Keys that can't be compared are
+// refused, they would make the map panic.
+func (u *userData) set(key, value any) error {
+	if !reflect.ValueOf(key).Comparable() {
+		return ErrUserDataKeyNotComparable
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	if value == nil {
+		delete(u.values, key)
+
+		return nil
+	}
+
+	// The lifecycle of the object ended, it doesn't hold values anymore
+	if u.cleared {
+		return nil
+	}
+
+	if u.values == nil {
+		u.values = map[any]any{}
+	}
+	u.values[key] = value
+
+	return nil
+}
+
+// get returns the value stored under key, nil if there is none.
+func (u *userData) get(key any) any {
+	if !reflect.ValueOf(key).Comparable() {
+		return nil
+	}
+
+	u.mu.RLock()
+	defer u.mu.RUnlock()
+
+	return u.values[key]
+}
+
+// clear releases all values, once the lifecycle of the object ended.
+func (u *userData) clear() {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	u.values, u.cleared = nil, true
+}
@@ -0,0 +1,204 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/pion/transport/v4/test"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type userDataKey struct{}
+
+type userDataValue struct {
+	name string
+	_    [64]byte
+}
+
+// newReleasedUserDataValue returns a value and a channel closed once the value was garbage collected.
+func newReleasedUserDataValue(name string) (*userDataValue, <-chan struct{}) {
+	released := make(chan struct{})
+	value := &userDataValue{name: name}
+	runtime.SetFinalizer(value, func(*userDataValue) {
+		close(released)
+	})
+
+	return value, released
+}
+
+func assertUserDataReleased(t *testing.T, released ...<-chan struct{}) {
+	t.Helper()
+
+	for _, r := range released {
+		assert.Eventually(t, func() bool {
+			runtime.GC()
+			select {
+			case <-r:
+				return true
+			default:
+				return false
+			}
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+}
+
+func TestUserData(t *testing.T) {
+	var data userData
+	assert.Nil(t, data.get(userDataKey{}))
+
+	require.NoError(t, data.set(userDataKey{}, userDataValue{name: "copied"}))
+	require.NoError(t, data.set("room", &userDataValue{name: "shared"}))
+	value, ok := data.get(userDataKey{}).(userDataValue)
+	require.True(t, ok)
+	assert.Equal(t, "copied", value.name)
+
+	shared, ok := data.get("room").(*userDataValue)
+	require.True(t, ok)
+	shared.name = "changed"
+	sharedAgain, ok := data.get("room").(*userDataValue)
+	require.True(t, ok)
+	assert.Equal(t, "changed", sharedAgain.name)
+
+	require.NoError(t, data.set("room", nil))
+	assert.Nil(t, data.get("room"))
+
+	// Keys that a map can't hold are refused instead of panicking
+	assert.ErrorIs(t, data.set([]string{"room"}, "value"), ErrUserDataKeyNotComparable)
+	assert.ErrorIs(t, data.set(struct{ key any }{map[string]int{}}, "value"), ErrUserDataKeyNotComparable)
+	assert.ErrorIs(t, data.set(nil, "value"), ErrUserDataKeyNotComparable)
+	assert.Nil(t, data.get([]string{"room"}))
+
+	var wg sync.WaitGroup
+	for i := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range 100 {
+				assert.NoError(t, data.set(i, j))
+				data.get(i)
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(t, 99, data.get(3))
+
+	// The values set once the lifecycle ended are dropped
+	data.clear()
+	assert.Nil(t, data.get(userDataKey{}))
+	require.NoError(t, data.set(userDataKey{}, "late"))
+	assert.Nil(t, data.get(userDataKey{}))
+}
+
+func TestUserData_Callbacks(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer, err := newPair()
+	require.NoError(t, err)
+
+	localTrack, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	require.NoError(t, localTrack.SetUserData(userDataKey{}, "camera"))
+	sender, err := pcOffer.AddTrack(localTrack)
+	require.NoError(t, err)
+	senderValue, senderReleased := newReleasedUserDataValue("sender")
+	require.NoError(t, sender.SetUserData(userDataKey{}, senderValue))
+
+	dataChannel, err := pcOffer.CreateDataChannel("chat", nil)
+	require.NoError(t, err)
+	channelValue, channelReleased := newReleasedUserDataValue("user-1")
+	require.NoError(t, dataChannel.SetUserData(userDataKey{}, channelValue))
+	channelValue = nil //nolint:ineffassign,wastedassign // only the DataChannel keeps it
+
+	// The values set as the objects are announced are available to their callbacks
+	var (
+		trackReleased, receiverReleased <-chan struct{}
+		remoteDataChannel               *DataChannel
+	)
+	onTrackSetUp := make(chan *TrackRemote, 1)
+	pcAnswer.OnTrack(func(track *TrackRemote, receiver *RTPReceiver) {
+		var trackValue, receiverValue *userDataValue
+		trackValue, trackReleased = newReleasedUserDataValue("track")
+		receiverValue, receiverReleased = newReleasedUserDataValue("receiver")
+		assert.NoError(t, track.SetUserData(userDataKey{}, trackValue))
+		assert.NoError(t, receiver.SetUserData(userDataKey{}, receiverValue))
+		onTrackSetUp <- track
+	})
+
+	messages := make(chan string, 1)
+	pcAnswer.OnDataChannel(func(d *DataChannel) {
+		if d.Label() != dataChannel.Label() {
+			return
+		}
+		remoteDataChannel = d
+		assert.NoError(t, d.SetUserData(userDataKey{}, "remote-"+d.Label()))
+		d.OnMessage(func(msg DataChannelMessage) {
+			room, _ := d.UserData(userDataKey{}).(string)
+			messages <- room + ":" + string(msg.Data)
+		})
+	})
+
+	opened := make(chan struct{})
+	dataChannel.OnOpen(func() {
+		close(opened)
+	})
+	closeValue := make(chan any, 1)
+	dataChannel.OnClose(func() {
+		closeValue <- dataChannel.UserData(userDataKey{})
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	<-opened
+	require.NoError(t, dataChannel.SendText("hello"))
+	assert.Equal(t, "remote-chat:hello", <-messages)
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{localTrack})
+		close(sent)
+	}()
+	remoteTrack := <-onTrackSetUp
+	close(done)
+	<-sent
+
+	// The values stay with the objects across renegotiations
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+	assert.Equal(t, "camera", localTrack.UserData(userDataKey{}))
+	value, ok := remoteTrack.UserData(userDataKey{}).(*userDataValue)
+	require.True(t, ok)
+	assert.Equal(t, "track", value.name)
+	assert.Equal(t, "remote-chat", remoteDataChannel.UserData(userDataKey{}))
+	value, ok = dataChannel.UserData(userDataKey{}).(*userDataValue)
+	require.True(t, ok)
+	assert.Equal(t, "user-1", value.name)
+	value = nil //nolint:ineffassign,wastedassign // only the objects keep the values
+
+	// Closing ends the lifecycle of the objects, the values are released while the objects are still referenced
+	closePairNow(t, pcOffer, pcAnswer)
+	closedValue, ok := (<-closeValue).(*userDataValue)
+	require.True(t, ok, "OnClose can still use the values")
+	assert.Equal(t, "user-1", closedValue.name)
+	closedValue = nil //nolint:ineffassign,wastedassign // only the DataChannel keeps it
+
+	assertUserDataReleased(t, channelReleased, senderReleased, trackReleased, receiverReleased)
+	assert.Nil(t, dataChannel.UserData(userDataKey{}))
+	assert.Nil(t, sender.UserData(userDataKey{}))
+	assert.Nil(t, remoteTrack.UserData(userDataKey{}))
+
+	// The stopped sender doesn't hold values anymore
+	require.NoError(t, sender.SetUserData(userDataKey{}, "late"))
+	assert.Nil(t, sender.UserData(userDataKey{}))
+	assert.Equal(t, "camera", localTrack.UserData(userDataKey{}))
+}
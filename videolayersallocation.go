@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"encoding/binary"
+	"slices"
+)
+
+// VideoLayersAllocationURI is the URI of the video-layers-allocation RTP header extension, which
+// tells the receiver the simulcast and SVC layers a sender is going to send.
+//
+// https://webrtc.googlesource.com/src/+/refs/heads/main/docs/native-code/rtp-hdrext/video-layers-allocation00
+const VideoLayersAllocationURI = "http://www.webrtc.org/experiments/rtp-hdrext/video-layers-allocation00"
+
+const (
+	videoLayersAllocationMaxRTPStreams    = 4
+	videoLayersAllocationMaxSpatialIDs    = 4
+	videoLayersAllocationMaxTemporalIDs   = 4
+	videoLayersAllocationMaxBitrateKbps   = 1_000_000
+	videoLayersAllocationResolutionLength = 5
+)
+
+// VideoLayersAllocation is the layer structure carried by the video-layers-allocation
+// RTP header extension, see VideoLayersAllocationURI.
+type VideoLayersAllocation struct {
+	// RTPStreamIndex is the index of the RTP stream, like the simulcast encoding, the
+	// allocation is sent on. RTPSender.SetVideoLayersAllocation sets it for every encoding.
+	RTPStreamIndex int
+
+	// ResolutionAndFrameRateValid is set if the Width, Height and FrameRate of the layers
+	// are signaled.
+	ResolutionAndFrameRateValid bool
+
+	// ActiveSpatialLayers are ordered by RTPStreamIndex, then by SpatialID. An allocation
+	// without any tells that nothing is sent.
+	ActiveSpatialLayers []VideoLayersAllocationSpatialLayer
+}
+
+// VideoLayersAllocationSpatialLayer is an active spatial layer of a VideoLayersAllocation.
+type VideoLayersAllocationSpatialLayer struct {
+	RTPStreamIndex int
+	SpatialID      int
+
+	// TargetBitratesKbps are the target bitrates of the temporal layers in kbps, at least
+	// one and at most four. A temporal layer includes the ones below it, and so does its bitrate.
+	TargetBitratesKbps []uint32
+
+	// Width, Height and FrameRate are only signaled with ResolutionAndFrameRateValid.
+	Width     int
+	Height    int
+	FrameRate int
+}
+
+// validate returns errVideoLayersAllocationInvalid if a can't be encoded.
+//
+//nolint:cyclop
+func (a VideoLayersAllocation) validate() error {
+	maxRTPStreamIndex := 0
+	for i, layer := range a.ActiveSpatialLayers {
+		switch {
+		case layer.RTPStreamIndex < 0 || layer.RTPStreamIndex >= videoLayersAllocationMaxRTPStreams,
+			layer.SpatialID < 0 || layer.SpatialID >= videoLayersAllocationMaxSpatialIDs,
+			len(layer.TargetBitratesKbps) == 0 || len(layer.TargetBitratesKbps) > videoLayersAllocationMaxTemporalIDs:
+			return errVideoLayersAllocationInvalid
+		case i > 0 && layer.RTPStreamIndex == a.ActiveSpatialLayers[i-1].RTPStreamIndex &&
+			layer.SpatialID <= a.ActiveSpatialLayers[i-1].SpatialID,
+			i > 0 && layer.RTPStreamIndex < a.ActiveSpatialLayers[i-1].RTPStreamIndex:
+			return errVideoLayersAllocationUnordered
+		case a.ResolutionAndFrameRateValid && (layer.Width <= 0 || layer.Width > 1<<16 ||
+			layer.Height <= 0 || layer.Height > 1<<16 || layer.FrameRate < 0 || layer.FrameRate > 255):
+			return errVideoLayersAllocationInvalid
+		}
+		for _, bitrate := range layer.TargetBitratesKbps {
+			if bitrate > videoLayersAllocationMaxBitrateKbps {
+				return errVideoLayersAllocationInvalid
+			}
+		}
+		maxRTPStreamIndex = max(maxRTPStreamIndex, layer.RTPStreamIndex)
+	}
+
+	if a.RTPStreamIndex < 0 || a.RTPStreamIndex > maxRTPStreamIndex {
+		return errVideoLayersAllocationInvalid
+	}
+
+	return nil
+}
+
+// Marshal encodes the allocation as the payload of the header extension.
+func (a VideoLayersAllocation) Marshal() ([]byte, error) {
+	if err := a.validate(); err != nil {
+		return nil, err
+	}
+
+	// Nothing is sent on any RTP stream
+	if len(a.ActiveSpatialLayers) == 0 {
+		return []byte{0}, nil
+	}
+
+	var bitmasks [videoLayersAllocationMaxRTPStreams]uint8
+	maxRTPStreamIndex := 0
+	for _, layer := range a.ActiveSpatialLayers {
+		bitmasks[layer.RTPStreamIndex] |= 1 << layer.SpatialID
+		maxRTPStreamIndex = max(maxRTPStreamIndex, layer.RTPStreamIndex)
+	}
+
+	// The spatial layers are signaled once if they are the same for all RTP streams
+	header := uint8(a.RTPStreamIndex<<6 | maxRTPStreamIndex<<4) //nolint:gosec // validated
+	out := []byte{header}
+	if !slices.ContainsFunc(bitmasks[1:maxRTPStreamIndex+1], func(bitmask uint8) bool {
+		return bitmask != bitmasks[0]
+	}) {
+		out[0] |= bitmasks[0]
+	} else {
+		for i := 0; i <= maxRTPStreamIndex; i += 2 {
+			out = append(out, bitmasks[i]<<4|bitmasks[i+1])
+		}
+	}
+
+	// Two bits per spatial layer for the number of its temporal layers minus one
+	temporalLayers := make([]byte, (len(a.ActiveSpatialLayers)+3)/4)
+	for i, layer := range a.ActiveSpatialLayers {
+		temporalLayers[i/4] |= uint8(len(layer.TargetBitratesKbps)-1) << (6 - 2*(i%4)) //nolint:gosec // validated
+	}
+	out = append(out, temporalLayers...)
+
+	for _, layer := range a.ActiveSpatialLayers {
+		for _, bitrate := range layer.TargetBitratesKbps {
+			out = binary.AppendUvarint(out, uint64(bitrate))
+		}
+	}
+
+	if a.ResolutionAndFrameRateValid {
+		for _, layer := range a.ActiveSpatialLayers {
+			out = binary.BigEndian.AppendUint16(out, uint16(layer.Width-1))  //nolint:gosec // validated
+			out = binary.BigEndian.AppendUint16(out, uint16(layer.Height-1)) //nolint:gosec // validated
+			out = append(out, uint8(layer.FrameRate))                        //nolint:gosec // validated
+		}
+	}
+
+	return out, nil
+}
+
+// Unmarshal decodes the payload of the header extension.
+//
+//nolint:cyclop
+func (a *VideoLayersAllocation) Unmarshal(rawData []byte) error {
+	*a = VideoLayersAllocation{}
+	if len(rawData) == 0 {
+		return errVideoLayersAllocationTooShort
+	}
+
+	// Nothing is sent on any RTP stream
+	if len(rawData) == 1 && rawData[0] == 0 {
+		a.ResolutionAndFrameRateValid = true
+
+		return nil
+	}
+
+	a.RTPStreamIndex = int(rawData[0] >> 6)
+	numRTPStreams := 1 + int(rawData[0]>>4&0b11)
+	var bitmasks [videoLayersAllocationMaxRTPStreams]uint8
+	offset := 1
+	if bitmask := rawData[0] & 0b1111; bitmask != 0 {
+		for i := range numRTPStreams {
+			bitmasks[i] = bitmask
+		}
+	} else {
+		for i := 0; i < numRTPStreams; i += 2 {
+			if offset >= len(rawData) {
+				return errVideoLayersAllocationTooShort
+			}
+			bitmasks[i], bitmasks[i+1] = rawData[offset]>>4, rawData[offset]&0b1111
+			offset++
+		}
+	}
+
+	for rtpStreamIndex := range numRTPStreams {
+		for spatialID := range videoLayersAllocationMaxSpatialIDs {
+			if bitmasks[rtpStreamIndex]&(1<<spatialID) == 0 {
+				continue
+			}
+
+			i := len(a.ActiveSpatialLayers)
+			if offset+i/4 >= len(rawData) {
+				return errVideoLayersAllocationTooShort
+			}
+			numTemporalLayers := 1 + int(rawData[offset+i/4]>>(6-2*(i%4))&0b11)
+			a.ActiveSpatialLayers = append(a.ActiveSpatialLayers, VideoLayersAllocationSpatialLayer{
+				RTPStreamIndex:     rtpStreamIndex,
+				SpatialID:          spatialID,
+				TargetBitratesKbps: make([]uint32, numTemporalLayers),
+			})
+		}
+	}
+	offset += (len(a.ActiveSpatialLayers) + 3) / 4
+
+	for _, layer := range a.ActiveSpatialLayers {
+		for i := range layer.TargetBitratesKbps {
+			bitrate, n := binary.Uvarint(rawData[min(offset, len(rawData)):])
+			if n <= 0 {
+				return errVideoLayersAllocationTooShort
+			} else if bitrate > videoLayersAllocationMaxBitrateKbps {
+				return errVideoLayersAllocationInvalid
+			}
+			layer.TargetBitratesKbps[i] = uint32(bitrate)
+			offset += n
+		}
+	}
+
+	switch len(rawData) - offset {
+	case 0:
+	case videoLayersAllocationResolutionLength * len(a.ActiveSpatialLayers):
+		a.ResolutionAndFrameRateValid = true
+		for i := range a.ActiveSpatialLayers {
+			layer := &a.ActiveSpatialLayers[i]
+			layer.Width = 1 + int(binary.BigEndian.Uint16(rawData[offset:]))
+			layer.Height = 1 + int(binary.BigEndian.Uint16(rawData[offset+2:]))
+			layer.FrameRate = int(rawData[offset+4])
+			offset += videoLayersAllocationResolutionLength
+		}
+	default:
+		return errVideoLayersAllocationInvalid
+	}
+
+	return a.validate()
+}
+
+// clone returns a copy of a that doesn't share its layers.
+func (a VideoLayersAllocation) clone() VideoLayersAllocation {
+	a.ActiveSpatialLayers = slices.Clone(a.ActiveSpatialLayers)
+	for i := range a.ActiveSpatialLayers {
+		layer := &a.ActiveSpatialLayers[i]
+		layer.TargetBitratesKbps = slices.Clone(layer.TargetBitratesKbps)
+	}
+
+	return a
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"sync/atomic"
+
+	"github.com/pion/rtp"
+)
+
+// videoLayersAllocationWriter adds the allocation of an RTPSender to the first packet of every frame.
+type videoLayersAllocationWriter struct {
+	id             uint8
+	rtpStreamIndex int
+	allocation     *atomic.Pointer[VideoLayersAllocation]
+
+	// lastTimestamp is flagged with bit 32, the packets of a frame share their timestamp.
+	lastTimestamp atomic.Uint64
+}
+
+// apply returns header with the allocation added if it starts a new frame.
+func (w *videoLayersAllocationWriter) apply(header *rtp.Header) *rtp.Header {
+	allocation := w.allocation.Load()
+	if allocation == nil {
+		return header
+	}
+
+	timestamp := 1<<32 | uint64(header.Timestamp)
+	if w.lastTimestamp.Swap(timestamp) == timestamp {
+		return header
+	}
+
+	perStream := *allocation
+	perStream.RTPStreamIndex = w.rtpStreamIndex
+	payload, err := perStream.Marshal()
+	if err != nil {
+		// The allocation has no layers on the RTP stream
+		return header
+	}
+
+	return withHeaderExtension(header, w.id, payload)
+}
+
+// withHeaderExtension returns a copy of header with the header extension id set to payload,
+// header itself is returned if the extension can't be added.
+func withHeaderExtension(header *rtp.Header, id uint8, payload []byte) *rtp.Header {
+	// The header might be shared with other bindings of the track, so don't modify it.
+	extended := *header
+	extended.Extensions = append([]rtp.Extension(nil), header.Extensions...)
+	if !extended.Extension {
+		extended.Extension = true
+		extended.ExtensionProfile = rtp.ExtensionProfileOneByte
+	}
+	if extended.ExtensionProfile == rtp.ExtensionProfileOneByte &&
+		(len(payload) > 16 || id > maxOneByteHeaderExtensionID) {
+		extended.ExtensionProfile = rtp.ExtensionProfileTwoByte
+	}
+	if err := extended.SetExtension(id, payload); err != nil {
+		return header
+	}
+
+	return &extended
+}
+
+// SetVideoLayersAllocation sets the layers announced with the video-layers-allocation
+// RTP header extension, see ConfigureVideoLayersAllocationHeaderExtension. The allocation
+// is added to the first packet of every frame once the extension is negotiated, the
+// RTPStreamIndex is set to the index of the encoding the packet is sent on.
+func (r *RTPSender) SetVideoLayersAllocation(allocation VideoLayersAllocation) error {
+	allocation.RTPStreamIndex = 0
+	if _, err := allocation.Marshal(); err != nil {
+		return err
+	}
+
+	allocation = allocation.clone()
+	r.videoLayersAllocation.Store(&allocation)
+
+	return nil
+}
+
+// configureVideoLayersAllocation installs the writer of the allocation on e if the
+// header extension is negotiated, rtpStreamIndex is the index of e.
+func (r *RTPSender) configureVideoLayersAllocation(e *trackEncoding, rtpStreamIndex int) {
+	for _, extension := range e.headerExtensions {
+		if extension.URI != VideoLayersAllocationURI {
+			continue
+		}
+
+		e.writeStream.layersAllocation.Store(&videoLayersAllocationWriter{
+			id:             uint8(extension.ID), //nolint:gosec // header extension IDs are at most 255
+			rtpStreamIndex: rtpStreamIndex,
+			allocation:     &r.videoLayersAllocation,
+		})
+
+		return
+	}
+
+	e.writeStream.layersAllocation.Store(nil)
+}
+
+// videoLayersAllocation returns the allocation carried by packet, if it has the header
+// extension negotiated for the track.
+func (t *TrackRemote) videoLayersAllocation(packet *rtp.Packet) (VideoLayersAllocation, bool) {
+	if !packet.Extension {
+		return VideoLayersAllocation{}, false
+	}
+
+	t.mu.RLock()
+	id := 0
+	for _, extension := range t.params.HeaderExtensions {
+		if extension.URI == VideoLayersAllocationURI {
+			id = extension.ID
+		}
+	}
+	t.mu.RUnlock()
+
+	payload := packet.GetExtension(uint8(id)) //nolint:gosec // header extension IDs are at most 255
+	if id == 0 || payload == nil {
+		return VideoLayersAllocation{}, false
+	}
+
+	var allocation VideoLayersAllocation
+	if err := allocation.Unmarshal(payload); err != nil {
+		return VideoLayersAllocation{}, false
+	}
+
+	return allocation, true
+}
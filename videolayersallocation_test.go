@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+package webrtc
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoLayersAllocation_RoundTrip(t *testing.T) {
+	for _, test := range []struct {
+		name       string
+		allocation VideoLayersAllocation
+	}{
+		{
+			name:       "Empty",
+			allocation: VideoLayersAllocation{ResolutionAndFrameRateValid: true},
+		},
+		{
+			name: "SingleLayer",
+			allocation: VideoLayersAllocation{ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+				{TargetBitratesKbps: []uint32{50, 100}},
+			}},
+		},
+		{
+			name: "SimulcastSameSpatialLayers",
+			allocation: VideoLayersAllocation{
+				RTPStreamIndex:              1,
+				ResolutionAndFrameRateValid: true,
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+					{TargetBitratesKbps: []uint32{100, 150, 200}, Width: 320, Height: 180, FrameRate: 15},
+					{RTPStreamIndex: 1, TargetBitratesKbps: []uint32{300, 500, 700}, Width: 640, Height: 360, FrameRate: 30},
+					{RTPStreamIndex: 2, TargetBitratesKbps: []uint32{1500, 2500, 3000}, Width: 1280, Height: 720, FrameRate: 30},
+				},
+			},
+		},
+		{
+			name: "SimulcastDifferentSpatialLayers",
+			allocation: VideoLayersAllocation{
+				RTPStreamIndex: 2,
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+					{SpatialID: 0, TargetBitratesKbps: []uint32{100}},
+					{SpatialID: 1, TargetBitratesKbps: []uint32{200, 300}},
+					{RTPStreamIndex: 2, SpatialID: 1, TargetBitratesKbps: []uint32{1000, 2000, 3000, 1_000_000}},
+					{RTPStreamIndex: 3, SpatialID: 3, TargetBitratesKbps: []uint32{0}},
+				},
+			},
+		},
+		{
+			name: "SVC",
+			allocation: VideoLayersAllocation{
+				ResolutionAndFrameRateValid: true,
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+					{SpatialID: 0, TargetBitratesKbps: []uint32{150}, Width: 320, Height: 180, FrameRate: 30},
+					{SpatialID: 1, TargetBitratesKbps: []uint32{450}, Width: 640, Height: 360, FrameRate: 30},
+					{SpatialID: 2, TargetBitratesKbps: []uint32{1200}, Width: 1280, Height: 720, FrameRate: 30},
+					{SpatialID: 3, TargetBitratesKbps: []uint32{4000}, Width: 1 << 16, Height: 1 << 16, FrameRate: 255},
+				},
+			},
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			payload, err := test.allocation.Marshal()
+			require.NoError(t, err)
+
+			var parsed VideoLayersAllocation
+			require.NoError(t, parsed.Unmarshal(payload))
+			assert.Equal(t, test.allocation, parsed)
+		})
+	}
+}
+
+func TestVideoLayersAllocation_Marshal(t *testing.T) {
+	payload, err := VideoLayersAllocation{ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+		{TargetBitratesKbps: []uint32{50, 100}},
+	}}.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x01, 0x40, 0x32, 0x64}, payload)
+
+	payload, err = VideoLayersAllocation{}.Marshal()
+	require.NoError(t, err)
+	assert.Equal(t, []byte{0x00}, payload)
+}
+
+func TestVideoLayersAllocation_Invalid(t *testing.T) {
+	layer := func(rtpStreamIndex, spatialID int) VideoLayersAllocationSpatialLayer {
+		return VideoLayersAllocationSpatialLayer{
+			RTPStreamIndex:     rtpStreamIndex,
+			SpatialID:          spatialID,
+			TargetBitratesKbps: []uint32{100},
+		}
+	}
+
+	for _, test := range []struct {
+		name       string
+		allocation VideoLayersAllocation
+		err        error
+	}{
+		{
+			name: "RTPStreamIndexWithoutLayers",
+			allocation: VideoLayersAllocation{
+				RTPStreamIndex:      1,
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{layer(0, 0)},
+			},
+			err: errVideoLayersAllocationInvalid,
+		},
+		{
+			name:       "TooManyRTPStreams",
+			allocation: VideoLayersAllocation{ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{layer(4, 0)}},
+			err:        errVideoLayersAllocationInvalid,
+		},
+		{
+			name:       "TooManySpatialLayers",
+			allocation: VideoLayersAllocation{ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{layer(0, 4)}},
+			err:        errVideoLayersAllocationInvalid,
+		},
+		{
+			name: "NoTemporalLayers",
+			allocation: VideoLayersAllocation{ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+				{},
+			}},
+			err: errVideoLayersAllocationInvalid,
+		},
+		{
+			name: "BitrateTooHigh",
+			allocation: VideoLayersAllocation{ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{
+				{TargetBitratesKbps: []uint32{1_000_001}},
+			}},
+			err: errVideoLayersAllocationInvalid,
+		},
+		{
+			name: "MissingResolution",
+			allocation: VideoLayersAllocation{
+				ResolutionAndFrameRateValid: true,
+				ActiveSpatialLayers:         []VideoLayersAllocationSpatialLayer{layer(0, 0)},
+			},
+			err: errVideoLayersAllocationInvalid,
+		},
+		{
+			name: "Unordered",
+			allocation: VideoLayersAllocation{
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{layer(1, 0), layer(0, 0)},
+			},
+			err: errVideoLayersAllocationUnordered,
+		},
+		{
+			name: "Duplicated",
+			allocation: VideoLayersAllocation{
+				ActiveSpatialLayers: []VideoLayersAllocationSpatialLayer{layer(0, 1), layer(0, 1)},
+			},
+			err: errVideoLayersAllocationUnordered,
+		},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := test.allocation.Marshal()
+			assert.ErrorIs(t, err, test.err)
+		})
+	}
+
+	var allocation VideoLayersAllocation
+	for _, payload := range [][]byte{
+		{},
+		{0x01},
+		{0x01, 0x40, 0x32},
+		{0x10},
+		{0x01, 0x00, 0x80},
+	} {
+		assert.ErrorIs(t, allocation.Unmarshal(payload), errVideoLayersAllocationTooShort, "%x", payload)
+	}
+	// Resolutions of some of the layers only
+	assert.ErrorIs(t, allocation.Unmarshal([]byte{0x01, 0x00, 0x64, 0x00}), errVideoLayersAllocationInvalid)
+}
@@ -0,0 +1,153 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"fmt"
+
+	"github.com/pion/interceptor"
+	"github.com/pion/rtp"
+)
+
+// VideoOrientationURI is the URI of the coordination of video orientation (CVO) RTP header
+// extension, which tells the receiver how to rotate the frames for rendering them upright.
+//
+// https://www.3gpp.org/ftp/Specs/archive/26_series/26.114/
+const VideoOrientationURI = "urn:3gpp:video-orientation"
+
+const (
+	videoOrientationFlipBit     = 0x04
+	videoOrientationRotationMax = 270
+	videoOrientationRotationDeg = 90
+
+	// videoOrientationReceived flags that an orientation was received, it is kept together
+	// with the extension byte.
+	videoOrientationReceived = 0x100
+)
+
+// VideoOrientation is the orientation carried by the video orientation RTP header extension,
+// see VideoOrientationURI. It is the Metadata of a media.Sample written to a
+// TrackLocalStaticSample to send it, and the AttributeVideoOrientation of received packets.
+type VideoOrientation struct {
+	// Rotation is the clockwise rotation in degrees to apply to the frames for rendering them,
+	// one of 0, 90, 180 or 270.
+	Rotation int
+	// Flip is set if the frames have to be mirrored horizontally, before they are rotated.
+	Flip bool
+}
+
+// marshal returns the extension byte of o.
+func (o VideoOrientation) marshal() (byte, error) {
+	if o.Rotation < 0 || o.Rotation > videoOrientationRotationMax || o.Rotation%videoOrientationRotationDeg != 0 {
+		return 0, fmt.Errorf("%w: rotation %d", ErrInvalidVideoOrientation, o.Rotation)
+	}
+
+	b := byte(o.Rotation / videoOrientationRotationDeg)
+	if o.Flip {
+		b |= videoOrientationFlipBit
+	}
+
+	return b, nil
+}
+
+// unmarshalVideoOrientation returns the orientation of the extension byte b, the camera
+// bit is ignored.
+func unmarshalVideoOrientation(b byte) VideoOrientation {
+	return VideoOrientation{
+		Rotation: int(b&0x03) * videoOrientationRotationDeg,
+		Flip:     b&videoOrientationFlipBit != 0,
+	}
+}
+
+// videoOrientationWriter adds the orientation of the samples to the last packet of every frame.
+type videoOrientationWriter struct {
+	id uint8
+}
+
+// apply returns header with the orientation of attributes added if it ends a frame.
+func (w *videoOrientationWriter) apply(header *rtp.Header, attributes interceptor.Attributes) *rtp.Header {
+	orientation, ok := attributes.Get(AttributeVideoOrientation).(VideoOrientation)
+	if !ok || !header.Marker {
+		return header
+	}
+
+	b, err := orientation.marshal()
+	if err != nil {
+		return header
+	}
+
+	return withHeaderExtension(header, w.id, []byte{b})
+}
+
+// configureVideoOrientation installs the writer of the orientation on e if the header
+// extension is negotiated.
+func (r *RTPSender) configureVideoOrientation(e *trackEncoding) {
+	for _, extension := range e.headerExtensions {
+		if extension.URI != VideoOrientationURI {
+			continue
+		}
+
+		e.writeStream.videoOrientation.Store(&videoOrientationWriter{
+			id: uint8(extension.ID), //nolint:gosec // header extension IDs are at most 255
+		})
+
+		return
+	}
+
+	e.writeStream.videoOrientation.Store(nil)
+}
+
+// VideoOrientation returns the orientation of the last frame received with the video
+// orientation RTP header extension, see ConfigureVideoOrientationHeaderExtension. The
+// rotation is the clockwise rotation in degrees to apply for rendering the frames, and
+// flip is set if they have to be mirrored horizontally first. The frames are upright if
+// no orientation was received.
+func (t *TrackRemote) VideoOrientation() (rotation int, flip bool) {
+	b := t.videoOrientation.Load()
+	if b&videoOrientationReceived == 0 {
+		return 0, false
+	}
+	orientation := unmarshalVideoOrientation(byte(b))
+
+	return orientation.Rotation, orientation.Flip
+}
+
+// readVideoOrientation keeps the orientation carried by pkt, if it has the header extension
+// negotiated for the track. Packets carrying it are flagged with AttributeVideoOrientation.
+func (t *TrackRemote) readVideoOrientation(pkt []byte, attributes interceptor.Attributes) interceptor.Attributes {
+	if len(pkt) == 0 || pkt[0]&rtpExtensionBitmask == 0 {
+		return attributes
+	}
+
+	t.mu.RLock()
+	id := 0
+	for _, extension := range t.params.HeaderExtensions {
+		if extension.URI == VideoOrientationURI {
+			id = extension.ID
+		}
+	}
+	t.mu.RUnlock()
+	if id == 0 {
+		return attributes
+	}
+
+	header := rtp.Header{}
+	if _, err := header.Unmarshal(pkt); err != nil {
+		return attributes
+	}
+	payload := header.GetExtension(uint8(id)) //nolint:gosec // header extension IDs are at most 255
+	if len(payload) == 0 {
+		return attributes
+	}
+
+	t.videoOrientation.Store(videoOrientationReceived | uint32(payload[0]))
+	if attributes == nil {
+		attributes = make(interceptor.Attributes)
+	}
+	attributes.Set(AttributeVideoOrientation, unmarshalVideoOrientation(payload[0]))
+
+	return attributes
+}
@@ -0,0 +1,225 @@
+// SPDX-FileCopyrightText: 2026 The Pion community <https://pion.ly>
+// SPDX-License-Identifier: MIT
+
+//go:build !js
+
+package webrtc
+
+import (
+	"testing"
+	"time"
+
+	"github.com/pion/rtp"
+	"github.com/pion/transport/v4/test"
+	"github.com/pion/webrtc/v4/pkg/media"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVideoOrientation_Marshal(t *testing.T) {
+	for _, orientation := range []struct {
+		VideoOrientation
+		b byte
+	}{
+		{VideoOrientation{Rotation: 0}, 0x00},
+		{VideoOrientation{Rotation: 90}, 0x01},
+		{VideoOrientation{Rotation: 180}, 0x02},
+		{VideoOrientation{Rotation: 270}, 0x03},
+		{VideoOrientation{Rotation: 90, Flip: true}, 0x05},
+	} {
+		b, err := orientation.marshal()
+		require.NoError(t, err)
+		assert.Equal(t, orientation.b, b)
+		assert.Equal(t, orientation.VideoOrientation, unmarshalVideoOrientation(b))
+	}
+
+	// The camera bit doesn't change the orientation
+	assert.Equal(t, VideoOrientation{Rotation: 180}, unmarshalVideoOrientation(0x0A))
+
+	for _, rotation := range []int{-90, 45, 360} {
+		_, err := VideoOrientation{Rotation: rotation}.marshal()
+		assert.ErrorIs(t, err, ErrInvalidVideoOrientation)
+	}
+}
+
+func newVideoOrientationPair(t *testing.T) (*PeerConnection, *PeerConnection) {
+	t.Helper()
+
+	newPeerConnection := func() *PeerConnection {
+		mediaEngine := &MediaEngine{}
+		require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+		require.NoError(t, ConfigureVideoOrientationHeaderExtension(mediaEngine))
+		pc, err := NewAPI(WithMediaEngine(mediaEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, err)
+
+		return pc
+	}
+
+	return newPeerConnection(), newPeerConnection()
+}
+
+func TestTrackRemote_VideoOrientation(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer := newVideoOrientationPair(t)
+
+	track, err := NewTrackLocalStaticRTP(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	type received struct {
+		attribute    VideoOrientation
+		rotation     int
+		flip         bool
+		hasAttribute bool
+	}
+	receivedPackets := make(chan received, 1)
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		for {
+			_, attributes, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			var r received
+			r.attribute, r.hasAttribute = attributes.Get(AttributeVideoOrientation).(VideoOrientation)
+			r.rotation, r.flip = trackRemote.VideoOrientation()
+			select {
+			case receivedPackets <- r:
+			default:
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	var id uint8
+	for _, extension := range sender.GetParameters().HeaderExtensions {
+		if extension.URI == VideoOrientationURI {
+			id = uint8(extension.ID) //nolint:gosec // header extension IDs are at most 255
+		}
+	}
+	require.NotZero(t, id)
+
+	// Hand-crafted packets, until the receiver got one of them
+	sequenceNumber := uint16(0)
+	writeUntilReceived := func(extension []byte, check func(received) bool) {
+		ticker := time.NewTicker(10 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case r := <-receivedPackets:
+				if check(r) {
+					return
+				}
+			case <-ticker.C:
+				sequenceNumber++
+				packet := &rtp.Packet{
+					Header:  rtp.Header{Version: 2, SequenceNumber: sequenceNumber, Marker: true},
+					Payload: []byte{0x10, 0x00},
+				}
+				if extension != nil {
+					require.NoError(t, packet.SetExtension(id, extension))
+				}
+				require.NoError(t, track.WriteRTP(packet))
+			}
+		}
+	}
+
+	// The frames are upright until an orientation is received
+	writeUntilReceived(nil, func(r received) bool {
+		assert.Equal(t, received{}, r)
+
+		return true
+	})
+
+	for _, b := range []byte{0x01, 0x02, 0x00, 0x03, 0x07} {
+		expected := unmarshalVideoOrientation(b)
+		writeUntilReceived([]byte{b}, func(r received) bool {
+			return r.hasAttribute && r.attribute == expected
+		})
+
+		rotation, flip := pcAnswer.GetReceivers()[0].Track().VideoOrientation()
+		assert.Equal(t, expected.Rotation, rotation)
+		assert.Equal(t, expected.Flip, flip)
+	}
+
+	// Packets without the extension keep the last orientation
+	writeUntilReceived(nil, func(r received) bool {
+		assert.False(t, r.hasAttribute)
+
+		return r.rotation == 270 && r.flip
+	})
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestTrackLocalStaticSample_VideoOrientation(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	pcOffer, pcAnswer := newVideoOrientationPair(t)
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	_, err = pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	assert.ErrorIs(t, track.WriteSample(media.Sample{
+		Data: []byte{0x00}, Duration: time.Second, Metadata: VideoOrientation{Rotation: 45},
+	}), ErrInvalidVideoOrientation)
+
+	done := make(chan struct{})
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		framesWithOrientation, packetsWithoutOrientation := 0, 0
+		for {
+			pkt, attributes, readErr := trackRemote.ReadRTP()
+			if readErr != nil {
+				return
+			}
+
+			orientation, ok := attributes.Get(AttributeVideoOrientation).(VideoOrientation)
+			switch {
+			case ok:
+				assert.True(t, pkt.Marker, "orientation must only be sent with the last packet of a frame")
+				assert.Equal(t, VideoOrientation{Rotation: 90, Flip: true}, orientation)
+				framesWithOrientation++
+			case !pkt.Marker:
+				packetsWithoutOrientation++
+			}
+
+			if framesWithOrientation >= 3 && packetsWithoutOrientation >= 3 {
+				close(done)
+
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// Frames larger than the MTU are split into multiple packets
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for waiting := true; waiting; {
+		select {
+		case <-done:
+			waiting = false
+		case <-ticker.C:
+			assert.NoError(t, track.WriteSample(media.Sample{
+				Data: make([]byte, 3000), Duration: 20 * time.Millisecond,
+				Metadata: VideoOrientation{Rotation: 90, Flip: true},
+			}))
+		}
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+}
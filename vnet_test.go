@@ -6,13 +6,25 @@
 package webrtc
 
 import (
+	"fmt"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/pion/ice/v4"
 	"github.com/pion/interceptor"
+	"github.com/pion/interceptor/pkg/cc"
 	"github.com/pion/logging"
+	"github.com/pion/rtcp"
+	"github.com/pion/stun/v3"
+	"github.com/pion/transport/v4"
+	"github.com/pion/transport/v4/test"
 	"github.com/pion/transport/v4/vnet"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func createVNetPair(t *testing.T, interceptorRegistry *interceptor.Registry) (
@@ -73,3 +85,929 @@ func createVNetPair(t *testing.T, interceptorRegistry *interceptor.Registry) (
 
 	return offerPeerConnection, answerPeerConnection, wan
 }
+
+func TestICEMaxBindingRequestsWithSTUNLoss(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const maxBindingRequests = 3
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	// Drop every STUN Binding Request, so no candidate pair can ever succeed.
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		msg := &stun.Message{Raw: c.UserData()}
+
+		return !(stun.IsMessage(c.UserData()) && msg.Decode() == nil && msg.Type == stun.BindingRequest)
+	})
+
+	newPeerConnection := func(ip string) *PeerConnection {
+		vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(vnetNet))
+
+		settingEngine := SettingEngine{}
+		settingEngine.SetNet(vnetNet)
+		settingEngine.SetICEMaxBindingRequests(maxBindingRequests)
+		settingEngine.SetICECheckInterval(20 * time.Millisecond)
+		settingEngine.SetICENominationMode(ICENominationModeAggressive)
+
+		pc, pcErr := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+
+	pcOffer := newPeerConnection("1.2.3.4")
+	pcAnswer := newPeerConnection("1.2.3.5")
+	require.NoError(t, wan.Start())
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	// Give the agents far more check intervals than needed to exhaust the limit.
+	time.Sleep(time.Second)
+
+	for _, pc := range []*PeerConnection{pcOffer, pcAnswer} {
+		var requestsSent uint64
+		for _, s := range pc.GetStats() {
+			pairStats, ok := s.(ICECandidatePairStats)
+			if !ok {
+				continue
+			}
+
+			assert.LessOrEqual(t, pairStats.RequestsSent, uint64(maxBindingRequests+1))
+			requestsSent += pairStats.RequestsSent
+		}
+		assert.NotZero(t, requestsSent)
+	}
+
+	closePairNow(t, pcOffer, pcAnswer)
+	require.NoError(t, wan.Stop())
+}
+
+func TestSTUNBindingRequestHandler(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		answerUfrag  = "answerufrag"
+		legitUfrag   = "legitufrag"
+		spoofedUfrag = "spoofedufrag"
+		icePassword  = "icepassword1234567890123"
+	)
+
+	runPair := func(
+		t *testing.T,
+		offerUfrag string,
+		handler STUNBindingRequestHandler,
+	) (*PeerConnection, *PeerConnection) {
+		t.Helper()
+
+		wan, err := vnet.NewRouter(&vnet.RouterConfig{
+			CIDR:          "1.2.3.0/24",
+			LoggerFactory: logging.NewDefaultLoggerFactory(),
+		})
+		require.NoError(t, err)
+
+		newPeerConnection := func(ip, ufrag string, handler STUNBindingRequestHandler) *PeerConnection {
+			vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+			require.NoError(t, netErr)
+			require.NoError(t, wan.AddNet(vnetNet))
+
+			settingEngine := SettingEngine{}
+			settingEngine.SetNet(vnetNet)
+			settingEngine.SetICETimeouts(time.Second, time.Second, time.Millisecond*200)
+			settingEngine.SetICECredentials(ufrag, icePassword)
+			settingEngine.SetSTUNBindingRequestHandler(handler)
+
+			pc, pcErr := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+			require.NoError(t, pcErr)
+
+			return pc
+		}
+
+		pcOffer := newPeerConnection("1.2.3.4", offerUfrag, nil)
+		pcAnswer := newPeerConnection("1.2.3.5", answerUfrag, handler)
+		require.NoError(t, wan.Start())
+		t.Cleanup(func() {
+			assert.NoError(t, wan.Stop())
+		})
+
+		return pcOffer, pcAnswer
+	}
+
+	// Only accept requests from the remote ufrag the answerer was told about out of band.
+	var observed atomic.Int32
+	handler := func(m *stun.Message, local, remote net.Addr) bool {
+		var username stun.Username
+		if err := username.GetFrom(m); err != nil {
+			return true
+		}
+
+		assert.Equal(t, "1.2.3.5", local.(*net.UDPAddr).IP.String())  //nolint:forcetypeassert
+		assert.Equal(t, "1.2.3.4", remote.(*net.UDPAddr).IP.String()) //nolint:forcetypeassert
+		observed.Add(1)
+
+		return username.String() != answerUfrag+":"+legitUfrag
+	}
+
+	t.Run("Observe", func(t *testing.T) {
+		observed.Store(0)
+		pcOffer, pcAnswer := runPair(t, legitUfrag, handler)
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		assert.NotZero(t, observed.Load())
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+
+	t.Run("Drop spoofed username", func(t *testing.T) {
+		observed.Store(0)
+		pcOffer, pcAnswer := runPair(t, spoofedUfrag, handler)
+
+		failed := make(chan struct{})
+		var closeFailed sync.Once
+		pcOffer.OnICEConnectionStateChange(func(state ICEConnectionState) {
+			if state == ICEConnectionStateFailed {
+				closeFailed.Do(func() { close(failed) })
+			}
+		})
+		pcAnswer.OnICEConnectionStateChange(func(state ICEConnectionState) {
+			assert.NotEqual(t, ICEConnectionStateConnected, state)
+		})
+
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		<-failed
+
+		assert.NotZero(t, observed.Load())
+		for _, s := range pcOffer.GetStats() {
+			if pairStats, ok := s.(ICECandidatePairStats); ok {
+				assert.Zero(t, pairStats.ResponsesReceived)
+			}
+		}
+		closePairNow(t, pcOffer, pcAnswer)
+	})
+}
+
+func TestSTUNBindingRequestHandler_RejectRemoteCandidate(t *testing.T) {
+	lim := test.TimeOut(time.Second * 20)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const (
+		rejectedIP = "1.2.3.4"
+		acceptedIP = "1.2.3.6"
+	)
+
+	// runPair connects the offerer to an answerer that doesn't answer the requests from rejectedIP
+	runPair := func(t *testing.T, configure func(*SettingEngine), chunkFilter vnet.ChunkFilter) {
+		t.Helper()
+
+		wan, err := vnet.NewRouter(&vnet.RouterConfig{
+			CIDR:          "1.2.3.0/24",
+			LoggerFactory: logging.NewDefaultLoggerFactory(),
+		})
+		require.NoError(t, err)
+		if chunkFilter != nil {
+			wan.AddChunkFilter(chunkFilter)
+		}
+
+		newPeerConnection := func(configure func(*SettingEngine), ips ...string) *PeerConnection {
+			vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: ips})
+			require.NoError(t, netErr)
+			require.NoError(t, wan.AddNet(vnetNet))
+
+			settingEngine := SettingEngine{}
+			settingEngine.SetNet(vnetNet)
+			settingEngine.SetICETimeouts(time.Second, time.Second, time.Millisecond*200)
+			if configure != nil {
+				configure(&settingEngine)
+			}
+
+			pc, pcErr := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+			require.NoError(t, pcErr)
+
+			return pc
+		}
+
+		// The offerer has a candidate the answerer never answers
+		pcOffer := newPeerConnection(nil, rejectedIP, acceptedIP)
+		pcAnswer := newPeerConnection(configure, "1.2.3.5")
+		require.NoError(t, wan.Start())
+
+		connected := untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer)
+		require.NoError(t, signalPair(pcOffer, pcAnswer))
+		connected.Wait()
+
+		selected, err := pcOffer.SCTP().Transport().ICETransport().GetSelectedCandidatePair()
+		require.NoError(t, err)
+		assert.Equal(t, acceptedIP, selected.Local.Address)
+
+		stats := pcOffer.GetStats()
+		rejectedPairs := 0
+		for _, s := range stats {
+			pairStats, ok := s.(ICECandidatePairStats)
+			if !ok {
+				continue
+			}
+			if local, ok := stats[pairStats.LocalCandidateID].(ICECandidateStats); ok && local.IP == rejectedIP {
+				rejectedPairs++
+				assert.NotEqual(t, StatsICECandidatePairStateSucceeded, pairStats.State)
+				assert.Zero(t, pairStats.ResponsesReceived)
+			}
+		}
+		assert.NotZero(t, rejectedPairs)
+
+		closePairNow(t, pcOffer, pcAnswer)
+		require.NoError(t, wan.Stop())
+	}
+
+	t.Run("Drop", func(t *testing.T) {
+		var usernames sync.Map
+		runPair(t, func(settingEngine *SettingEngine) {
+			settingEngine.SetSTUNBindingRequestHandler(func(m *stun.Message, _, remote net.Addr) bool {
+				var username stun.Username
+				if err := username.GetFrom(m); err == nil {
+					usernames.Store(username.String(), struct{}{})
+				}
+
+				return remote.(*net.UDPAddr).IP.String() == rejectedIP //nolint:forcetypeassert
+			})
+		}, nil)
+
+		identities := 0
+		usernames.Range(func(any, any) bool {
+			identities++
+
+			return true
+		})
+		assert.NotZero(t, identities)
+	})
+
+	t.Run("Reject", func(t *testing.T) {
+		// The requests from rejectedIP are answered with an authenticated 403
+		var forbidden atomic.Int32
+		runPair(t, func(settingEngine *SettingEngine) {
+			settingEngine.SetICEBindingRequestFilter(func(
+				_ *stun.Message, local, remote ice.Candidate, pair *ice.CandidatePair,
+			) bool {
+				assert.Equal(t, local, pair.Local)
+				assert.Equal(t, remote, pair.Remote)
+				assert.Equal(t, "1.2.3.5", local.Address())
+
+				return remote.Address() != rejectedIP
+			})
+		}, func(c vnet.Chunk) bool {
+			msg := &stun.Message{Raw: append([]byte{}, c.UserData()...)}
+			if c.DestinationAddr().(*net.UDPAddr).IP.String() != rejectedIP || msg.Decode() != nil { //nolint:forcetypeassert
+				return true
+			}
+
+			var errorCode stun.ErrorCodeAttribute
+			if msg.Type == stun.NewType(stun.MethodBinding, stun.ClassErrorResponse) && errorCode.GetFrom(msg) == nil {
+				assert.Equal(t, stun.CodeForbidden, errorCode.Code)
+				assert.True(t, msg.Contains(stun.AttrMessageIntegrity))
+				forbidden.Add(1)
+			}
+
+			return true
+		})
+		assert.NotZero(t, forbidden.Load())
+	})
+
+	t.Run("Panic", func(t *testing.T) {
+		runPair(t, func(settingEngine *SettingEngine) {
+			settingEngine.SetSTUNBindingRequestHandler(func(_ *stun.Message, _, remote net.Addr) bool {
+				if remote.(*net.UDPAddr).IP.String() == rejectedIP { //nolint:forcetypeassert
+					panic("rejected")
+				}
+
+				return false
+			})
+		}, nil)
+	})
+}
+
+// addressChangingNet hands out copies of the interfaces, so addresses can be
+// added and removed while the ICE agent is polling them.
+type addressChangingNet struct {
+	*vnet.Net
+	mu      sync.Mutex
+	removed []string
+}
+
+func (n *addressChangingNet) Interfaces() ([]*transport.Interface, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	ifaces, err := n.Net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	copied := make([]*transport.Interface, 0, len(ifaces))
+	for _, iface := range ifaces {
+		addrs, err := iface.Addrs()
+		if err != nil {
+			return nil, err
+		}
+
+		ifaceCopy := transport.NewInterface(iface.Interface)
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && slices.Contains(n.removed, ipNet.IP.String()) {
+				continue
+			}
+			ifaceCopy.AddAddress(addr)
+		}
+		copied = append(copied, ifaceCopy)
+	}
+
+	return copied, nil
+}
+
+func (n *addressChangingNet) addAddress(ifName string, addr *net.IPNet) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	return n.Net.AddAddress(ifName, addr)
+}
+
+// removeAddress hides addr from the interfaces, the sockets bound to it keep working.
+func (n *addressChangingNet) removeAddress(addr string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.removed = append(n.removed, addr)
+}
+
+func TestContinualGatheringRemovedAddress(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	vnetNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"1.2.3.4", "1.2.3.6"}})
+	require.NoError(t, err)
+	require.NoError(t, wan.AddNet(vnetNet))
+	changingNet := &addressChangingNet{Net: vnetNet}
+
+	require.NoError(t, wan.Start())
+	defer func() {
+		assert.NoError(t, wan.Stop())
+	}()
+
+	settingEngine := SettingEngine{}
+	settingEngine.SetNet(changingNet)
+	settingEngine.EnableContinualGathering(ICEGatheringPolicyGatherContinually)
+	settingEngine.SetICENetworkMonitorInterval(time.Millisecond * 50)
+
+	pc, err := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	gathered := make(chan struct{})
+	var gatheredOnce sync.Once
+	pc.OnICECandidate(func(candidate *ICECandidate) {
+		if candidate != nil && candidate.Address == "1.2.3.6" {
+			gatheredOnce.Do(func() { close(gathered) })
+		}
+	})
+	removed := make(chan *ICECandidate, 4)
+	pc.OnICECandidateRemoved(func(candidate *ICECandidate) {
+		removed <- candidate
+	})
+
+	_, err = pc.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	offer, err := pc.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pc.SetLocalDescription(offer))
+	<-gathered
+	assert.Contains(t, pc.LocalDescription().SDP, "1.2.3.6")
+
+	changingNet.removeAddress("1.2.3.6")
+
+	// Only the candidate of the removed address is pruned
+	candidate := <-removed
+	assert.Equal(t, "1.2.3.6", candidate.Address)
+	assert.Equal(t, ICECandidateTypeHost, candidate.Typ)
+	assert.NotContains(t, pc.LocalDescription().SDP, "1.2.3.6")
+	assert.Contains(t, pc.LocalDescription().SDP, "1.2.3.4")
+
+	candidates, err := pc.iceGatherer.GetLocalCandidates()
+	require.NoError(t, err)
+	for _, c := range candidates {
+		assert.NotEqual(t, "1.2.3.6", c.Address)
+	}
+
+	assert.NoError(t, pc.Close())
+}
+
+func TestContinualGatheringNewAddress(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	offerVNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"1.2.3.4"}})
+	require.NoError(t, err)
+	require.NoError(t, wan.AddNet(offerVNet))
+	offerNet := &addressChangingNet{Net: offerVNet}
+
+	answerVNet, err := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{"1.2.3.5"}})
+	require.NoError(t, err)
+	require.NoError(t, wan.AddNet(answerVNet))
+
+	require.NoError(t, wan.Start())
+	defer func() {
+		assert.NoError(t, wan.Stop())
+	}()
+
+	offerSettingEngine := SettingEngine{}
+	offerSettingEngine.SetNet(offerNet)
+	offerSettingEngine.EnableContinualGathering(ICEGatheringPolicyGatherContinually)
+	offerSettingEngine.SetICENetworkMonitorInterval(time.Millisecond * 50)
+	// Renomination keeps checking every pair, including ones added after connecting
+	require.NoError(t, offerSettingEngine.SetICERenomination(WithRenominationInterval(time.Millisecond*100)))
+
+	answerSettingEngine := SettingEngine{}
+	answerSettingEngine.SetNet(answerVNet)
+
+	pcOffer, err := NewAPI(WithSettingEngine(offerSettingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+	pcAnswer, err := NewAPI(WithSettingEngine(answerSettingEngine)).NewPeerConnection(Configuration{})
+	require.NoError(t, err)
+
+	done := make(chan struct{})
+	candidates := make(chan *ICECandidate, 16)
+	pcOffer.OnICECandidate(func(candidate *ICECandidate) {
+		// Gathering never completes when gathering continually
+		assert.NotNil(t, candidate)
+		select {
+		case candidates <- candidate:
+		case <-done:
+		}
+	})
+
+	_, err = pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+
+	offer, err := pcOffer.CreateOffer(nil)
+	require.NoError(t, err)
+	require.NoError(t, pcOffer.SetLocalDescription(offer))
+	require.NoError(t, pcAnswer.SetRemoteDescription(offer))
+
+	answer, err := pcAnswer.CreateAnswer(nil)
+	require.NoError(t, err)
+	answerGatheringComplete := GatheringCompletePromise(pcAnswer)
+	require.NoError(t, pcAnswer.SetLocalDescription(answer))
+	<-answerGatheringComplete
+	require.NoError(t, pcOffer.SetRemoteDescription(*pcAnswer.LocalDescription()))
+
+	newCandidate := make(chan *ICECandidate, 1)
+	var trickled sync.WaitGroup
+	trickled.Add(1)
+	go func() {
+		defer trickled.Done()
+		for {
+			select {
+			case candidate := <-candidates:
+				assert.NoError(t, pcAnswer.AddICECandidate(candidate.ToJSON()))
+				if candidate.Address == "1.2.3.6" {
+					newCandidate <- candidate
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	untilConnectionState(PeerConnectionStateConnected, pcOffer, pcAnswer).Wait()
+
+	require.NoError(t, offerNet.addAddress("eth0", &net.IPNet{
+		IP:   net.ParseIP("1.2.3.6"),
+		Mask: net.CIDRMask(24, 32),
+	}))
+
+	candidate := <-newCandidate
+	assert.Equal(t, ICECandidateTypeHost, candidate.Typ)
+
+	// The candidate of the new address is checked against the remote candidates
+	for {
+		succeeded := false
+		for _, s := range pcOffer.GetStats() {
+			pairStats, ok := s.(ICECandidatePairStats)
+			if ok && pairStats.LocalCandidateID == candidate.statsID &&
+				pairStats.State == StatsICECandidatePairStateSucceeded {
+				succeeded = true
+			}
+		}
+		if succeeded {
+			break
+		}
+		time.Sleep(time.Millisecond * 20)
+	}
+
+	assert.Equal(t, ICEGathererStateGathering, pcOffer.iceGatherer.State())
+
+	close(done)
+	trickled.Wait()
+	closePairNow(t, pcOffer, pcAnswer)
+}
+
+func TestConnectionQuality(t *testing.T) {
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const oneWayDelay = 50 * time.Millisecond
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		MinDelay:      oneWayDelay,
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	// Drop every tenth RTP packet sent by the offerer.
+	var rtpPackets atomic.Uint32
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		data := c.UserData()
+		isRTP := len(data) > 2 && data[0]>>6 == 2 && (data[1] < 192 || data[1] > 223)
+		if !isRTP || c.SourceAddr().(*net.UDPAddr).IP.String() != "1.2.3.4" { //nolint:forcetypeassert
+			return true
+		}
+
+		return rtpPackets.Add(1)%10 != 0
+	})
+
+	newPeerConnection := func(ip string, options ...func(*API)) *PeerConnection {
+		vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(vnetNet))
+
+		settingEngine := SettingEngine{}
+		settingEngine.SetNet(vnetNet)
+		settingEngine.SetICETimeouts(time.Second*5, time.Second*5, time.Millisecond*200)
+
+		pc, pcErr := NewAPI(append(options, WithSettingEngine(settingEngine))...).NewPeerConnection(Configuration{})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+
+	mediaEngine := &MediaEngine{}
+	require.NoError(t, mediaEngine.RegisterDefaultCodecs())
+	registry := &interceptor.Registry{}
+	congestionController, err := cc.NewInterceptor(nil)
+	require.NoError(t, err)
+	estimators := make(chan cc.BandwidthEstimator, 1)
+	congestionController.OnNewPeerConnection(func(_ string, estimator cc.BandwidthEstimator) {
+		estimators <- estimator
+	})
+	registry.Add(congestionController)
+	require.NoError(t, ConfigureTWCCHeaderExtensionSender(mediaEngine, registry))
+	require.NoError(t, RegisterDefaultInterceptors(mediaEngine, registry))
+
+	pcOffer := newPeerConnection("1.2.3.4", WithMediaEngine(mediaEngine), WithInterceptorRegistry(registry))
+	pcOffer.SetBandwidthEstimator(<-estimators)
+	pcAnswer := newPeerConnection("1.2.3.5")
+	require.NoError(t, wan.Start())
+
+	assert.Equal(t, ConnectionQuality{}, pcAnswer.ConnectionQuality())
+
+	track, err := NewTrackLocalStaticSample(RTPCodecCapability{MimeType: MimeTypeVP8}, "video", "pion")
+	require.NoError(t, err)
+	sender, err := pcOffer.AddTrack(track)
+	require.NoError(t, err)
+
+	var readers sync.WaitGroup
+	readers.Add(2)
+	go func() {
+		defer readers.Done()
+		for {
+			if _, _, readErr := sender.ReadRTCP(); readErr != nil {
+				return
+			}
+		}
+	}()
+	pcAnswer.OnTrack(func(trackRemote *TrackRemote, _ *RTPReceiver) {
+		defer readers.Done()
+		for {
+			if _, _, readErr := trackRemote.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	done := make(chan struct{})
+	sent := make(chan struct{})
+	go func() {
+		defer close(sent)
+		sendVideoUntilDone(t, done, []*TrackLocalStaticSample{track})
+	}()
+
+	// Wait for a few receiver reports and samples of the inbound loss.
+	time.Sleep(time.Second * 5)
+	offerQuality, answerQuality := pcOffer.ConnectionQuality(), pcAnswer.ConnectionQuality()
+	close(done)
+	<-sent
+
+	for _, quality := range []ConnectionQuality{offerQuality, answerQuality} {
+		assert.InDelta(t, 2*oneWayDelay, quality.RoundTripTime, float64(oneWayDelay))
+		assert.WithinDuration(t, time.Now(), quality.RoundTripTimeTimestamp, time.Second*2)
+	}
+
+	assert.InDelta(t, 0.1, offerQuality.OutboundLoss, 0.06)
+	assert.False(t, offerQuality.OutboundLossTimestamp.IsZero())
+	assert.Zero(t, offerQuality.InboundLoss)
+	assert.True(t, offerQuality.InboundLossTimestamp.IsZero())
+	assert.Positive(t, offerQuality.EstimatedBitrate)
+
+	assert.InDelta(t, 0.1, answerQuality.InboundLoss, 0.06)
+	assert.False(t, answerQuality.InboundLossTimestamp.IsZero())
+	assert.True(t, answerQuality.OutboundLossTimestamp.IsZero())
+	assert.Zero(t, answerQuality.EstimatedBitrate)
+
+	closePairNow(t, pcOffer, pcAnswer)
+	readers.Wait()
+	require.NoError(t, wan.Stop())
+}
+
+func TestSCTPTransport_SmoothedRTT(t *testing.T) { //nolint:cyclop
+	lim := test.TimeOut(time.Second * 30)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const oneWayDelay = 50 * time.Millisecond
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		MinDelay:      oneWayDelay,
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	newPeerConnection := func(ip string) *PeerConnection {
+		vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(vnetNet))
+
+		settingEngine := SettingEngine{}
+		settingEngine.SetNet(vnetNet)
+		settingEngine.SetICETimeouts(time.Second*5, time.Second*5, time.Millisecond*200)
+		settingEngine.SetSCTPRTTUpdateThreshold(10 * time.Millisecond)
+
+		pc, pcErr := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+
+	pcOffer := newPeerConnection("1.2.3.4")
+	pcAnswer := newPeerConnection("1.2.3.5")
+	require.NoError(t, wan.Start())
+
+	_, ok := pcOffer.SCTP().SmoothedRTT()
+	assert.False(t, ok)
+
+	var updatesLock sync.Mutex
+	var updates []time.Duration
+	pcOffer.SCTP().OnRTTUpdate(func(srtt time.Duration) {
+		updatesLock.Lock()
+		defer updatesLock.Unlock()
+
+		updates = append(updates, srtt)
+	})
+
+	// Bursts of messages are acknowledged without the delay of a single one
+	done := make(chan struct{})
+	var sending sync.WaitGroup
+	sending.Add(1)
+	dataChannel, err := pcOffer.CreateDataChannel("data", nil)
+	require.NoError(t, err)
+	dataChannel.OnOpen(func() {
+		defer sending.Done()
+
+		for {
+			for i := 0; i < 4; i++ {
+				if dataChannel.SendText("ping") != nil {
+					return
+				}
+			}
+
+			select {
+			case <-done:
+				return
+			case <-time.After(20 * time.Millisecond):
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	assert.Eventually(t, func() bool {
+		srtt, measured := pcOffer.SCTP().SmoothedRTT()
+
+		return measured && srtt > 2*oneWayDelay-10*time.Millisecond && srtt < 2*oneWayDelay+30*time.Millisecond
+	}, 20*time.Second, 50*time.Millisecond)
+
+	stats, ok := pcOffer.GetStats()["sctpTransport"].(SCTPTransportStats)
+	require.True(t, ok)
+	assert.InDelta(t, (2 * oneWayDelay).Seconds(), stats.SmoothedRoundTripTime, 0.05)
+
+	close(done)
+	sending.Wait()
+	closePairNow(t, pcOffer, pcAnswer)
+	require.NoError(t, wan.Stop())
+
+	// The updates followed the smoothed RTT by at least the threshold
+	updatesLock.Lock()
+	defer updatesLock.Unlock()
+	require.NotEmpty(t, updates)
+	for i := 1; i < len(updates); i++ {
+		change := updates[i] - updates[i-1]
+		if change < 0 {
+			change = -change
+		}
+		assert.GreaterOrEqual(t, change, 10*time.Millisecond)
+	}
+	assert.Less(t, updates[len(updates)-1], updates[0])
+}
+
+// rtcpReportsPerSecond sends tracks video tracks over vnet and counts the datagrams carrying
+// Sender or Receiver Reports in both directions. It also asserts that every track was reported.
+func rtcpReportsPerSecond(t *testing.T, tracks int, batching bool) float64 {
+	t.Helper()
+
+	wan, err := vnet.NewRouter(&vnet.RouterConfig{
+		CIDR:          "1.2.3.0/24",
+		LoggerFactory: logging.NewDefaultLoggerFactory(),
+	})
+	require.NoError(t, err)
+
+	// The header of SRTCP is not encrypted, so the type of the first packet is visible.
+	var reportDatagrams atomic.Uint32
+	wan.AddChunkFilter(func(c vnet.Chunk) bool {
+		data := c.UserData()
+		if len(data) > 2 && data[0]>>6 == 2 && (data[1] == 200 || data[1] == 201) {
+			reportDatagrams.Add(1)
+		}
+
+		return true
+	})
+
+	newPeerConnection := func(ip string) *PeerConnection {
+		vnetNet, netErr := vnet.NewNet(&vnet.NetConfig{StaticIPs: []string{ip}})
+		require.NoError(t, netErr)
+		require.NoError(t, wan.AddNet(vnetNet))
+
+		settingEngine := SettingEngine{}
+		settingEngine.SetNet(vnetNet)
+		settingEngine.SetICETimeouts(time.Second*5, time.Second*5, time.Millisecond*200)
+		settingEngine.EnableRTCPReportBatching(batching)
+
+		pc, pcErr := NewAPI(WithSettingEngine(settingEngine)).NewPeerConnection(Configuration{})
+		require.NoError(t, pcErr)
+
+		return pc
+	}
+
+	pcOffer := newPeerConnection("1.2.3.4")
+	pcAnswer := newPeerConnection("1.2.3.5")
+	require.NoError(t, wan.Start())
+
+	var reportedLock sync.Mutex
+	reported := map[uint32]bool{}
+	var readers sync.WaitGroup
+	localTracks := make([]*TrackLocalStaticSample, 0, tracks)
+	senders := make([]*RTPSender, 0, tracks)
+	for i := 0; i < tracks; i++ {
+		track, trackErr := NewTrackLocalStaticSample(
+			RTPCodecCapability{MimeType: MimeTypeVP8}, fmt.Sprintf("video%d", i), "pion",
+		)
+		require.NoError(t, trackErr)
+		localTracks = append(localTracks, track)
+
+		sender, senderErr := pcOffer.AddTrack(track)
+		require.NoError(t, senderErr)
+		senders = append(senders, sender)
+
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+
+			for {
+				pkts, _, readErr := sender.ReadRTCP()
+				if readErr != nil {
+					return
+				}
+
+				reportedLock.Lock()
+				for _, pkt := range pkts {
+					if receiverReport, ok := pkt.(*rtcp.ReceiverReport); ok {
+						for _, block := range receiverReport.Reports {
+							reported[block.SSRC] = true
+						}
+					}
+				}
+				reportedLock.Unlock()
+			}
+		}()
+	}
+
+	pcAnswer.OnTrack(func(track *TrackRemote, _ *RTPReceiver) {
+		readers.Add(1)
+		defer readers.Done()
+
+		for {
+			if _, _, readErr := track.ReadRTP(); readErr != nil {
+				return
+			}
+		}
+	})
+
+	require.NoError(t, signalPair(pcOffer, pcAnswer))
+
+	done := make(chan struct{})
+	var sending sync.WaitGroup
+	sending.Add(1)
+	go func() {
+		defer sending.Done()
+
+		sendVideoUntilDone(t, done, localTracks)
+	}()
+
+	// Give every stream the time to be bound and reported once before counting.
+	time.Sleep(2 * time.Second)
+	const measurement = 3 * time.Second
+	reportDatagrams.Store(0)
+	time.Sleep(measurement)
+	perSecond := float64(reportDatagrams.Load()) / measurement.Seconds()
+
+	close(done)
+	sending.Wait()
+	closePairNow(t, pcOffer, pcAnswer)
+	readers.Wait()
+	require.NoError(t, wan.Stop())
+
+	reportedLock.Lock()
+	defer reportedLock.Unlock()
+	for _, sender := range senders {
+		assert.True(t, reported[uint32(sender.GetParameters().Encodings[0].SSRC)])
+	}
+
+	return perSecond
+}
+
+func TestRTCPReportBatching(t *testing.T) {
+	lim := test.TimeOut(time.Second * 40)
+	defer lim.Stop()
+
+	report := test.CheckRoutines(t)
+	defer report()
+
+	const tracks = 20
+
+	// Without batching every stream sends its own report every second, in both directions.
+	before := rtcpReportsPerSecond(t, tracks, false)
+	assert.GreaterOrEqual(t, before, float64(tracks))
+
+	// With batching each side sends a compound packet or two about once a second.
+	after := rtcpReportsPerSecond(t, tracks, true)
+	assert.Less(t, after, before/5)
+	t.Logf("RTCP report datagrams per second: %.1f without batching, %.1f with batching", before, after)
+}